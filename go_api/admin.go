@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"go_api/config"
+	"go_api/logging"
+	"go_api/migrations"
+	"go_api/models"
+	"go_api/repository"
+	"go_api/service"
+	"go_api/storage"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// --- Ferramenta de Administração ---
+// Uso: ./server admin <comando> [flags]
+// Fala direto com o banco da configuração, pelas mesmas camadas service e
+// repository da API: não precisa da API no ar nem de um token de admin.
+// As senhas vêm de -password ou, sem ele, da entrada padrão (fora do
+// histórico do shell).
+const adminUsage = `Uso: ./server admin <comando> [flags]
+
+Comandos:
+  create-admin    -name -email -user [-tenant slug] [-password]  cria um administrador
+  reset-password  -user <username ou e-mail> [-password]         troca a senha e encerra as sessões
+  migrate                                                        aplica as migrações pendentes
+  purge-deleted   [-older-than 720h]                             apaga os dados dos usuários removidos há mais tempo
+`
+
+func runAdmin(args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, adminUsage)
+		os.Exit(2)
+	}
+	command, args := args[0], args[1:]
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Erro fatal: configuração inválida:\n%v", err)
+	}
+	logging.Setup(cfg.LogLevel, cfg.Secrets()...)
+	db, err := repository.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Erro fatal: %v", err)
+	}
+	// Como no main: tenant nas consultas e webhooks dos eventos gravados
+	repository.ScopeTenants(db)
+	service.WatchWebhooks(db)
+	if cfg.OutboxBroker != "" {
+		service.WatchOutbox(db)
+	}
+
+	users := service.NewUserService(repository.NewUserStore(db)).
+		WithSessions(repository.NewSessionStore(db), service.SessionPolicy{TTL: cfg.RefreshTTL})
+	ctx := context.Background()
+
+	switch command {
+	case "create-admin":
+		err = adminCreate(ctx, db, users, args)
+	case "reset-password":
+		err = adminResetPassword(ctx, users, args)
+	case "migrate":
+		err = migrations.Run(db, cfg.DBDriver)
+	case "purge-deleted":
+		avatars := service.NewAvatarService(users, storage.New(cfg), cfg.AvatarSize)
+		err = adminPurgeDeleted(ctx, service.NewPrivacyService(db, users, avatars, cfg.AccountDeletionTTL), args)
+	default:
+		fmt.Fprintf(os.Stderr, "Comando desconhecido: %s\n\n%s", command, adminUsage)
+		os.Exit(2)
+	}
+	// Erro do comando no terminal, não no log JSON
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erro: %s: %v\n", command, invalidFields(err))
+		os.Exit(1)
+	}
+}
+
+func adminCreate(ctx context.Context, db *gorm.DB, users *service.UserService, args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	var input models.CreateUserInput
+	fs.StringVar(&input.Name, "name", "", "Nome")
+	fs.StringVar(&input.Email, "email", "", "E-mail")
+	fs.StringVar(&input.User, "user", "", "Username")
+	fs.StringVar(&input.Password, "password", "", "Senha (vazio = lê da entrada padrão)")
+	tenant := fs.String("tenant", "", "Slug do tenant (vazio = tenant padrão)")
+	fs.Parse(args)
+
+	if *tenant != "" {
+		var t models.Tenant
+		if err := db.WithContext(ctx).Where("slug = ?", *tenant).First(&t).Error; err != nil {
+			return fmt.Errorf("tenant %q: %w", *tenant, err)
+		}
+		ctx = repository.WithTenant(ctx, t.ID)
+	}
+	var err error
+	if input.Password, err = readPassword(input.Password); err != nil {
+		return err
+	}
+	// As mesmas regras do POST /users
+	if err := binding.Validator.ValidateStruct(input); err != nil {
+		return err
+	}
+	user, err := users.CreateAdmin(ctx, input)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Administrador %s criado (ID %d, tenant %d)\n", user.User, user.ID, user.TenantID)
+	return nil
+}
+
+func adminResetPassword(ctx context.Context, users *service.UserService, args []string) error {
+	fs := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	login := fs.String("user", "", "Username ou e-mail")
+	password := fs.String("password", "", "Senha nova (vazio = lê da entrada padrão)")
+	fs.Parse(args)
+	if *login == "" {
+		return errors.New("-user é obrigatório")
+	}
+
+	user, err := users.GetByLogin(ctx, *login)
+	if err != nil {
+		return err
+	}
+	input := models.ChangePasswordInput{}
+	if input.NewPassword, err = readPassword(*password); err != nil {
+		return err
+	}
+	if err := binding.Validator.ValidateStruct(input); err != nil {
+		return err
+	}
+	// Sem ator: como um admin, dispensa a senha atual
+	if err := users.ChangePassword(ctx, user.ID, 0, input); err != nil {
+		return err
+	}
+	fmt.Printf("Senha de %s trocada; sessões encerradas\n", user.User)
+	return nil
+}
+
+func adminPurgeDeleted(ctx context.Context, privacy *service.PrivacyService, args []string) error {
+	fs := flag.NewFlagSet("purge-deleted", flag.ExitOnError)
+	olderThan := fs.Duration("older-than", 30*24*time.Hour, "Removidos há pelo menos esse tempo (0 = todos)")
+	fs.Parse(args)
+	if *olderThan < 0 {
+		return errors.New("-older-than não pode ser negativo")
+	}
+
+	purged, err := privacy.PurgeDeleted(ctx, time.Now().Add(-*olderThan))
+	fmt.Printf("%d usuários removidos apagados e anonimizados\n", purged)
+	return err
+}
+
+// "email (email), password (min=8)" em vez do texto cru do validator
+func invalidFields(err error) error {
+	var fields validator.ValidationErrors
+	if !errors.As(err, &fields) {
+		return err
+	}
+	names := make([]string, 0, len(fields))
+	for _, fe := range fields {
+		rule := fe.Tag()
+		if fe.Param() != "" {
+			rule += "=" + fe.Param()
+		}
+		names = append(names, fmt.Sprintf("%s (%s)", fe.Field(), rule))
+	}
+	return fmt.Errorf("campos inválidos: %s", strings.Join(names, ", "))
+}
+
+// value do flag ou uma linha da entrada padrão
+func readPassword(value string) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+	fmt.Fprint(os.Stderr, "Senha: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("lendo a senha: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
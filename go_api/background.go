@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go_api/config"
+	"go_api/handlers"
+	"go_api/jobs"
+	"go_api/mailer"
+	"go_api/service"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// --- Tarefas em Segundo Plano ---
+// Os tipos de job da API e quem executa cada um. As periódicas substituem
+// os tickers que cada pacote mantinha; com JOB_QUEUE=redis, rodam numa
+// réplica só por intervalo.
+const (
+	jobSendEmail        = "email.send"
+	jobDeliverWebhooks  = "webhooks.deliver"
+	jobDeliverPush      = "push.deliver"
+	jobPurgeTokens      = "tokens.purge"
+	jobPurgeIdempotency = "idempotency.purge"
+	jobReadingRetention = "readings.retention"
+	jobPublishOutbox    = "outbox.publish"
+	jobPurgeOutbox      = "outbox.purge"
+)
+
+func newJobPool(cfg config.Config, rdb *redis.Client) *jobs.Pool {
+	var queueRedis *redis.Client
+	if cfg.JobQueue == "redis" {
+		queueRedis = rdb
+	}
+	return jobs.NewPool(jobs.New(queueRedis, cfg.JobQueueSize), jobs.Options{
+		Workers:     cfg.JobWorkers,
+		MaxAttempts: cfg.JobMaxAttempts,
+		RetryDelay:  cfg.JobRetryDelay,
+	})
+}
+
+// Mailer dos services: só enfileira, e o worker envia pelo mailer de verdade
+// (um SMTP lento ou fora do ar não segura a requisição)
+type queuedMailer struct {
+	pool *jobs.Pool
+}
+
+func (m queuedMailer) Send(ctx context.Context, msg mailer.Message) error {
+	return m.pool.Enqueue(ctx, jobSendEmail, msg)
+}
+
+func startJobs(pool *jobs.Pool, mail mailer.Mailer, users *service.UserService, h *handlers.Handler) {
+	pool.Register(jobSendEmail, func(ctx context.Context, payload json.RawMessage) error {
+		var msg mailer.Message
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return err
+		}
+		return mail.Send(ctx, msg)
+	})
+	pool.Register(jobDeliverWebhooks, func(ctx context.Context, _ json.RawMessage) error {
+		h.Webhooks.DeliverDue(ctx)
+		return nil
+	})
+	pool.Register(jobDeliverPush, func(ctx context.Context, _ json.RawMessage) error {
+		h.Notifications.DeliverDue(ctx)
+		return nil
+	})
+	pool.Register(jobPurgeTokens, func(ctx context.Context, _ json.RawMessage) error {
+		return errors.Join(users.CleanupTokens(ctx), h.Privacy.CleanupTokens(ctx))
+	})
+	pool.Register(jobPurgeIdempotency, func(ctx context.Context, _ json.RawMessage) error {
+		return h.CleanupIdempotency(ctx)
+	})
+
+	pool.Register(jobReadingRetention, func(ctx context.Context, _ json.RawMessage) error {
+		return h.Retention.Run(ctx)
+	})
+
+	if h.Outbox != nil {
+		pool.Register(jobPublishOutbox, func(ctx context.Context, _ json.RawMessage) error {
+			h.Outbox.PublishDue(ctx)
+			return nil
+		})
+		pool.Register(jobPurgeOutbox, func(ctx context.Context, _ json.RawMessage) error {
+			return h.Outbox.Cleanup(ctx)
+		})
+		pool.Every(time.Second, jobPublishOutbox)
+		pool.Every(time.Hour, jobPurgeOutbox)
+	}
+
+	pool.Every(2*time.Second, jobDeliverWebhooks)
+	pool.Every(2*time.Second, jobDeliverPush)
+	pool.Every(time.Hour, jobPurgeTokens)
+	pool.Every(time.Hour, jobPurgeIdempotency)
+	pool.Every(time.Hour, jobReadingRetention)
+	pool.Start()
+}
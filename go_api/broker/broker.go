@@ -0,0 +1,65 @@
+package broker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go_api/config"
+)
+
+// --- Broker dos Eventos de Domínio ---
+// Para onde o relay do outbox manda os eventos (user.created,
+// device.online, reading.ingested...). Quem publica depende só da interface
+// Publisher: NATS (protocolo de texto direto no TCP) ou Kafka (pelo REST
+// Proxy), os dois sem SDK, como os provedores do push. OUTBOX_BROKER=log só
+// registra as mensagens (desenvolvimento).
+
+type Message struct {
+	Topic string // assunto no NATS, tópico no Kafka
+	Key   string // chave de partição no Kafka: eventos da mesma entidade em ordem
+	Value []byte // JSON
+}
+
+type Publisher interface {
+	// Um erro por mensagem, na mesma ordem (nil = aceita pelo broker)
+	Publish(ctx context.Context, msgs []Message) []error
+	Name() string
+}
+
+// nil quando o outbox está desligado (OUTBOX_BROKER vazio)
+func New(cfg config.Config) Publisher {
+	switch cfg.OutboxBroker {
+	case "nats":
+		return NewNATS(cfg.OutboxBrokerURL)
+	case "kafka":
+		return NewKafka(cfg.OutboxBrokerURL)
+	case "log":
+		return Log{}
+	}
+	return nil
+}
+
+// O mesmo erro para as n mensagens
+func errorAll(n int, err error) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Prazo de cada publicação (o do contexto, se for menor)
+const publishTimeout = 10 * time.Second
+
+// Só registra as mensagens no log
+type Log struct{}
+
+func (Log) Name() string { return "log" }
+
+func (Log) Publish(ctx context.Context, msgs []Message) []error {
+	for _, msg := range msgs {
+		slog.InfoContext(ctx, "evento (não publicado, OUTBOX_BROKER=log)", "topic", msg.Topic, "key", msg.Key, "value", string(msg.Value))
+	}
+	return make([]error, len(msgs))
+}
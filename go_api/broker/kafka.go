@@ -0,0 +1,105 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// --- Kafka (REST Proxy) ---
+// Publica pela API v2 do REST Proxy (Confluent ou o Pandaproxy do
+// Redpanda): um POST /topics/<tópico> por tópico do lote, com os registros
+// em JSON. A chave vai junto, então os eventos da mesma entidade caem na
+// mesma partição e chegam em ordem. O proxy devolve o resultado de cada
+// registro. Usuário e senha na URL (http://u:s@proxy:8082) viram Basic Auth.
+
+type Kafka struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+func NewKafka(baseURL string) *Kafka {
+	return &Kafka{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: publishTimeout},
+	}
+}
+
+func (k *Kafka) Name() string { return "kafka" }
+
+type kafkaRecord struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+type kafkaOffsets struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+func (k *Kafka) Publish(ctx context.Context, msgs []Message) []error {
+	errs := make([]error, len(msgs))
+	// Índices das mensagens de cada tópico, na ordem do lote
+	byTopic := make(map[string][]int)
+	var topics []string
+	for i, msg := range msgs {
+		if _, ok := byTopic[msg.Topic]; !ok {
+			topics = append(topics, msg.Topic)
+		}
+		byTopic[msg.Topic] = append(byTopic[msg.Topic], i)
+	}
+	for _, topic := range topics {
+		idx := byTopic[topic]
+		records := make([]kafkaRecord, len(idx))
+		for j, i := range idx {
+			records[j] = kafkaRecord{Key: msgs[i].Key, Value: msgs[i].Value}
+		}
+		for j, err := range k.produce(ctx, topic, records) {
+			errs[idx[j]] = err
+		}
+	}
+	return errs
+}
+
+func (k *Kafka) produce(ctx context.Context, topic string, records []kafkaRecord) []error {
+	fail := func(err error) []error { return errorAll(len(records), err) }
+	body, _ := json.Marshal(map[string]interface{}{"records": records})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.BaseURL+"/topics/"+url.PathEscape(topic), bytes.NewReader(body))
+	if err != nil {
+		return fail(err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	resp, err := k.HTTP.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		var problem struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(raw, &problem)
+		return fail(fmt.Errorf("Kafka REST Proxy: HTTP %d: %s", resp.StatusCode, problem.Message))
+	}
+
+	var result kafkaOffsets
+	if err := json.Unmarshal(raw, &result); err != nil || len(result.Offsets) != len(records) {
+		return fail(fmt.Errorf("Kafka REST Proxy: unexpected response %q", raw))
+	}
+	errs := make([]error, len(records))
+	for i, offset := range result.Offsets {
+		if offset.ErrorCode != nil {
+			errs[i] = fmt.Errorf("Kafka: error %d: %s", *offset.ErrorCode, offset.Error)
+		}
+	}
+	return errs
+}
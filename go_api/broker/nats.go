@@ -0,0 +1,164 @@
+package broker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- NATS ---
+// Protocolo de texto do NATS direto no TCP: CONNECT, um PUB por mensagem e
+// um PING no fim. Como o servidor processa os comandos na ordem, o PONG
+// confirma que todos os PUBs antes dele foram aceitos; um -ERR ou a conexão
+// caindo no meio falha o lote inteiro, que volta para o outbox (o
+// consumidor deduplica pelo id). Para os eventos sobreviverem a consumidor
+// fora do ar, um stream do JetStream deve capturar os assuntos.
+//
+// URL: nats://host:4222, com usuário e senha (nats://u:s@host) ou token
+// (nats://token@host). Sem suporte a TLS.
+
+type NATS struct {
+	URL string
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func NewNATS(rawURL string) *NATS {
+	return &NATS{URL: rawURL}
+}
+
+func (n *NATS) Name() string { return "nats" }
+
+func (n *NATS) Publish(ctx context.Context, msgs []Message) []error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := n.publish(ctx, msgs); err != nil {
+		// Conexão em estado desconhecido: a próxima rodada reconecta
+		n.close()
+		return errorAll(len(msgs), err)
+	}
+	return make([]error, len(msgs))
+}
+
+func (n *NATS) publish(ctx context.Context, msgs []Message) error {
+	if n.conn == nil {
+		if err := n.connect(ctx); err != nil {
+			return err
+		}
+	}
+	n.conn.SetDeadline(deadline(ctx))
+	w := bufio.NewWriter(n.conn)
+	for _, msg := range msgs {
+		fmt.Fprintf(w, "PUB %s %d\r\n", msg.Topic, len(msg.Value))
+		w.Write(msg.Value)
+		w.WriteString("\r\n")
+	}
+	w.WriteString("PING\r\n")
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return n.awaitPong()
+}
+
+func (n *NATS) connect(ctx context.Context) error {
+	u, err := url.Parse(n.URL)
+	if err != nil || u.Scheme != "nats" || u.Hostname() == "" {
+		return fmt.Errorf("NATS URL must look like nats://host:4222, got %q", n.URL)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	conn, err := (&net.Dialer{Timeout: publishTimeout}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(deadline(ctx))
+	n.conn, n.r = conn, bufio.NewReader(conn)
+
+	// O servidor começa com INFO {...}
+	line, err := n.readLine()
+	if err != nil {
+		return err
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	if !strings.HasPrefix(line, "INFO ") || json.Unmarshal([]byte(line[5:]), &info) != nil {
+		return fmt.Errorf("NATS: unexpected greeting %q", line)
+	}
+	if info.TLSRequired {
+		return errors.New("NATS: server requires TLS, which is not supported")
+	}
+
+	options := map[string]interface{}{
+		"verbose": false, "pedantic": false, "name": "go_api", "lang": "go", "version": "1.0", "protocol": 1,
+	}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			options["user"], options["pass"] = u.User.Username(), password
+		} else {
+			options["auth_token"] = u.User.Username()
+		}
+	}
+	connect, _ := json.Marshal(options)
+	// O PING confirma o CONNECT (ex: credencial recusada vem como -ERR)
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		return err
+	}
+	return n.awaitPong()
+}
+
+// Lê até o PONG, respondendo os PINGs do servidor no caminho
+func (n *NATS) awaitPong() error {
+	for {
+		line, err := n.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := n.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+		// +OK e INFO (topologia do cluster) não mudam nada aqui
+	}
+}
+
+func (n *NATS) readLine() (string, error) {
+	line, err := n.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (n *NATS) close() {
+	if n.conn != nil {
+		n.conn.Close()
+		n.conn, n.r = nil, nil
+	}
+}
+
+// publishTimeout a partir de agora, ou o prazo do contexto se vier antes
+func deadline(ctx context.Context) time.Time {
+	limit := time.Now().Add(publishTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(limit) {
+		return d
+	}
+	return limit
+}
@@ -0,0 +1,62 @@
+package coap
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/ugorji/go/codec"
+)
+
+// --- CBOR (RFC 8949) ---
+// Pelo mesmo codec das respostas application/cbor (handlers/negotiate.go).
+// O payload das leituras vira o mesmo JSON do POST /devices/:id/readings:
+// mapas com chaves de texto, listas, números, texto, booleanos e null. O
+// instante pode vir como data (tag 0), epoch (tag 1) ou número solto; bytes
+// saem em base64, como o encoding/json faz com []byte.
+
+const maxCBORDepth = 16
+
+// Chaves em ordem: a mesma resposta sai sempre com os mesmos bytes
+var cborHandle = func() *codec.CborHandle {
+	h := &codec.CborHandle{}
+	h.Canonical = true
+	h.MapType = reflect.TypeOf(map[string]any(nil))
+	h.SignedInteger = true
+	h.MaxDepth = maxCBORDepth
+	return h
+}()
+
+func decodeCBOR(data []byte) (any, error) {
+	var v any
+	d := codec.NewDecoderBytes(data, cborHandle)
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	if d.NumBytesRead() != len(data) {
+		return nil, errors.New("trailing bytes after CBOR item")
+	}
+	return v, nil
+}
+
+func encodeCBOR(v any) []byte {
+	var out []byte
+	// Só mapas e números montados aqui: não há o que falhar
+	codec.NewEncoderBytes(&out, cborHandle).MustEncode(v)
+	return out
+}
+
+// Epoch em segundos (inteiro ou com fração), sem a tag 1
+func epochTime(v any) (time.Time, bool) {
+	switch n := v.(type) {
+	case uint64:
+		return time.Unix(int64(n), 0).UTC(), n <= math.MaxInt64
+	case int64:
+		return time.Unix(n, 0).UTC(), true
+	case float64:
+		sec, frac := math.Modf(n)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), !math.IsNaN(n) && !math.IsInf(n, 0)
+	}
+	return time.Time{}, false
+}
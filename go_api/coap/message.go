@@ -0,0 +1,244 @@
+package coap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// --- Mensagens CoAP (RFC 7252) ---
+// Só o necessário para receber requisições e responder: cabeçalho de 4
+// bytes, token, opções (com os deltas e comprimentos estendidos) e payload.
+// Sem transferência em blocos (RFC 7959): a requisição inteira cabe num
+// datagrama.
+
+type Type uint8
+
+const (
+	Confirmable     Type = 0
+	NonConfirmable  Type = 1
+	Acknowledgement Type = 2
+	Reset           Type = 3
+)
+
+// Código "classe.detalhe" num byte (3 bits + 5 bits)
+type Code uint8
+
+func code(class, detail uint8) Code { return Code(class<<5 | detail) }
+
+var (
+	CodeEmpty = code(0, 0)
+	GET       = code(0, 1)
+	POST      = code(0, 2)
+	PUT       = code(0, 3)
+	DELETE    = code(0, 4)
+
+	Created               = code(2, 1)
+	Changed               = code(2, 4)
+	Content               = code(2, 5)
+	BadRequest            = code(4, 0)
+	Unauthorized          = code(4, 1)
+	BadOption             = code(4, 2)
+	Forbidden             = code(4, 3)
+	NotFound              = code(4, 4)
+	MethodNotAllowed      = code(4, 5)
+	NotAcceptable         = code(4, 6)
+	RequestEntityTooLarge = code(4, 13)
+	UnsupportedFormat     = code(4, 15)
+	InternalServerError   = code(5, 0)
+	ServiceUnavailable    = code(5, 3)
+)
+
+func (c Code) String() string {
+	return fmt.Sprintf("%d.%02d", c>>5, c&0x1f)
+}
+
+// Números das opções usadas aqui
+const (
+	OptionURIHost       = 3
+	OptionURIPort       = 7
+	OptionURIPath       = 11
+	OptionContentFormat = 12
+	OptionURIQuery      = 15
+	OptionAccept        = 17
+)
+
+// Content-Formats (registro da IANA)
+const (
+	FormatJSON = 50
+	FormatCBOR = 60
+)
+
+type Option struct {
+	Number uint16
+	Value  []byte
+}
+
+type Message struct {
+	Type      Type
+	Code      Code
+	MessageID uint16
+	Token     []byte
+	Options   []Option
+	Payload   []byte
+}
+
+var errFormat = errors.New("malformed CoAP message")
+
+// Opção numérica (uint de 0 a 4 bytes, sem zeros à esquerda)
+func UintOption(number uint16, v uint32) Option {
+	buf := binary.BigEndian.AppendUint32(nil, v)
+	for len(buf) > 0 && buf[0] == 0 {
+		buf = buf[1:]
+	}
+	return Option{Number: number, Value: buf}
+}
+
+// Valores de todas as ocorrências de uma opção, na ordem da mensagem
+func (m Message) values(number uint16) [][]byte {
+	var out [][]byte
+	for _, o := range m.Options {
+		if o.Number == number {
+			out = append(out, o.Value)
+		}
+	}
+	return out
+}
+
+// Uri-Path montado ("devices/7/readings")
+func (m Message) Path() string {
+	var parts []string
+	for _, v := range m.values(OptionURIPath) {
+		parts = append(parts, string(v))
+	}
+	return strings.Join(parts, "/")
+}
+
+// Valor de um parâmetro do Uri-Query ("token=abc" -> "abc")
+func (m Message) Query(name string) string {
+	for _, v := range m.values(OptionURIQuery) {
+		if key, value, _ := strings.Cut(string(v), "="); key == name {
+			return value
+		}
+	}
+	return ""
+}
+
+// Opção numérica; ok = false se ausente
+func (m Message) Uint(number uint16) (uint32, bool) {
+	values := m.values(number)
+	if len(values) == 0 || len(values[0]) > 4 {
+		return 0, false
+	}
+	var v uint32
+	for _, b := range values[0] {
+		v = v<<8 | uint32(b)
+	}
+	return v, true
+}
+
+// Delta e comprimento de uma opção: 0-12 no próprio nibble, 13 e 14 com 1
+// ou 2 bytes a mais
+func readExtended(nibble byte, data []byte) (uint32, []byte, error) {
+	switch nibble {
+	case 13:
+		if len(data) < 1 {
+			return 0, nil, errFormat
+		}
+		return uint32(data[0]) + 13, data[1:], nil
+	case 14:
+		if len(data) < 2 {
+			return 0, nil, errFormat
+		}
+		return uint32(binary.BigEndian.Uint16(data)) + 269, data[2:], nil
+	case 15:
+		return 0, nil, errFormat
+	}
+	return uint32(nibble), data, nil
+}
+
+func ParseMessage(data []byte) (Message, error) {
+	var m Message
+	if len(data) < 4 || data[0]>>6 != 1 {
+		return m, errFormat
+	}
+	m.Type = Type(data[0] >> 4 & 0x3)
+	tkl := int(data[0] & 0xf)
+	m.Code = Code(data[1])
+	m.MessageID = binary.BigEndian.Uint16(data[2:])
+	data = data[4:]
+	if tkl > 8 || len(data) < tkl {
+		return m, errFormat
+	}
+	m.Token = append([]byte(nil), data[:tkl]...)
+	data = data[tkl:]
+
+	var number uint32
+	for len(data) > 0 {
+		if data[0] == 0xff {
+			// Marcador sem payload depois é erro de formato
+			if len(data) == 1 {
+				return m, errFormat
+			}
+			m.Payload = append([]byte(nil), data[1:]...)
+			break
+		}
+		delta, length := data[0]>>4, data[0]&0xf
+		data = data[1:]
+		d, rest, err := readExtended(delta, data)
+		if err != nil {
+			return m, err
+		}
+		l, rest, err := readExtended(length, rest)
+		if err != nil {
+			return m, err
+		}
+		if uint32(len(rest)) < l || number+d > 0xffff {
+			return m, errFormat
+		}
+		number += d
+		m.Options = append(m.Options, Option{Number: uint16(number), Value: append([]byte(nil), rest[:l]...)})
+		data = rest[l:]
+	}
+	return m, nil
+}
+
+func appendExtended(header byte, v uint32, shift uint) (byte, []byte) {
+	switch {
+	case v < 13:
+		return header | byte(v)<<shift, nil
+	case v < 269:
+		return header | 13<<shift, []byte{byte(v - 13)}
+	}
+	return header | 14<<shift, binary.BigEndian.AppendUint16(nil, uint16(v-269))
+}
+
+func (m Message) MarshalBinary() ([]byte, error) {
+	if len(m.Token) > 8 {
+		return nil, errors.New("CoAP token longer than 8 bytes")
+	}
+	out := []byte{1<<6 | byte(m.Type)<<4 | byte(len(m.Token)), byte(m.Code), 0, 0}
+	binary.BigEndian.PutUint16(out[2:], m.MessageID)
+	out = append(out, m.Token...)
+
+	// As opções vão em ordem crescente, cada uma como delta da anterior
+	options := append([]Option(nil), m.Options...)
+	sort.SliceStable(options, func(i, j int) bool { return options[i].Number < options[j].Number })
+	var last uint16
+	for _, o := range options {
+		header, delta := appendExtended(0, uint32(o.Number-last), 4)
+		header, length := appendExtended(header, uint32(len(o.Value)), 0)
+		out = append(out, header)
+		out = append(out, delta...)
+		out = append(out, length...)
+		out = append(out, o.Value...)
+		last = o.Number
+	}
+	if len(m.Payload) > 0 {
+		out = append(out, 0xff)
+		out = append(out, m.Payload...)
+	}
+	return out, nil
+}
@@ -0,0 +1,320 @@
+package coap
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go_api/service"
+)
+
+// --- Servidor CoAP (Sensores com Bateria) ---
+// Para os sensores que não podem pagar o TCP e o HTTP: as mesmas operações
+// de ingestão do HTTP sobre UDP (COAP_ADDR), com payload em CBOR, pelo mesmo
+// service.Telemetry e service.DeviceService:
+//
+//	POST coap://api:5683/devices/{id}/readings?token=...   -> 2.01 {"created": n}
+//	POST coap://api:5683/devices/{id}/heartbeat?token=...  -> 2.04
+//
+// O sensor se autentica com o token do dispositivo (o mostrado na criação),
+// já que não tem usuário nem chave de API. Requisições confirmáveis (CON)
+// recebem o ACK com a resposta; as não confirmáveis (NON), uma resposta NON.
+// Uma retransmissão (mesmo endpoint e Message ID) recebe a mesma resposta
+// sem gravar de novo. Sem DTLS: o token trafega em claro, então a porta
+// deve ficar na rede dos sensores.
+
+const (
+	handleTimeout = 10 * time.Second
+	// EXCHANGE_LIFETIME da RFC 7252: por quanto tempo uma retransmissão
+	// ainda pode chegar
+	exchangeLifetime = 247 * time.Second
+	maxExchanges     = 10000
+	maxDatagram      = 64 * 1024
+)
+
+type Server struct {
+	devices   *service.DeviceService
+	telemetry *service.Telemetry
+	conn      net.PacketConn
+
+	mu        sync.Mutex
+	exchanges map[string]*exchange
+	nextID    uint16
+}
+
+// Resposta de uma requisição já recebida; nil enquanto ainda processa
+type exchange struct {
+	response []byte
+	expires  time.Time
+}
+
+func New(devices *service.DeviceService, telemetry *service.Telemetry) *Server {
+	var seed [2]byte
+	rand.Read(seed[:])
+	return &Server{
+		devices:   devices,
+		telemetry: telemetry,
+		exchanges: map[string]*exchange{},
+		nextID:    binary.BigEndian.Uint16(seed[:]),
+	}
+}
+
+// Escuta em addr (UDP) em segundo plano; o Close fica com quem chamou
+func (s *Server) Serve(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	go s.readLoop()
+	slog.Info("CoAP escutando", "addr", conn.LocalAddr().String())
+	return nil
+}
+
+// Endereço de fato (com a porta escolhida quando addr termina em :0)
+func (s *Server) Addr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+func (s *Server) Close() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+}
+
+func (s *Server) readLoop() {
+	buf := make([]byte, maxDatagram)
+	for {
+		n, from, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			slog.Warn("CoAP: erro na leitura", "error", err)
+			continue
+		}
+		datagram := append([]byte(nil), buf[:n]...)
+		go s.serve(datagram, from)
+	}
+}
+
+func (s *Server) serve(datagram []byte, from net.Addr) {
+	req, err := ParseMessage(datagram)
+	if err != nil {
+		// Sem Message ID confiável não há o que responder (RFC 7252 4.2)
+		if len(datagram) >= 4 && Type(datagram[0]>>4&0x3) == Confirmable {
+			s.write(Message{Type: Reset, MessageID: binary.BigEndian.Uint16(datagram[2:])}, from)
+		}
+		return
+	}
+	switch {
+	case req.Type == Acknowledgement || req.Type == Reset:
+		return // não enviamos nada confirmável
+	case req.Code == CodeEmpty:
+		// "CoAP ping": CON vazio recebe um RST
+		if req.Type == Confirmable {
+			s.write(Message{Type: Reset, MessageID: req.MessageID}, from)
+		}
+		return
+	case req.Code>>5 != 0:
+		return // resposta onde se esperava requisição
+	}
+
+	key := from.String() + "/" + strconv.Itoa(int(req.MessageID))
+	if cached, duplicate := s.remember(key); duplicate {
+		if cached != nil {
+			s.writeRaw(cached, from)
+		}
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), handleTimeout)
+	defer cancel()
+	res := s.Handle(ctx, req)
+	res.Token = req.Token
+	if req.Type == Confirmable {
+		res.Type, res.MessageID = Acknowledgement, req.MessageID
+	} else {
+		res.Type, res.MessageID = NonConfirmable, s.messageID()
+	}
+	out, err := res.MarshalBinary()
+	if err != nil {
+		slog.ErrorContext(ctx, "CoAP: resposta inválida", "error", err)
+		return
+	}
+	s.store(key, out)
+	s.writeRaw(out, from)
+	slog.DebugContext(ctx, "CoAP", "from", from.String(), "path", req.Path(), "code", res.Code.String())
+}
+
+// Marca a requisição como recebida; duplicate = já estava (com a resposta,
+// ou nil se a primeira ainda está sendo processada)
+func (s *Server) remember(key string) (cached []byte, duplicate bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if ex, ok := s.exchanges[key]; ok && now.Before(ex.expires) {
+		return ex.response, true
+	}
+	if len(s.exchanges) >= maxExchanges {
+		for k, ex := range s.exchanges {
+			if now.After(ex.expires) {
+				delete(s.exchanges, k)
+			}
+		}
+	}
+	// Ainda cheio: sem espaço para deduplicar, processa assim mesmo
+	if len(s.exchanges) < maxExchanges {
+		s.exchanges[key] = &exchange{expires: now.Add(exchangeLifetime)}
+	}
+	return nil, false
+}
+
+func (s *Server) store(key string, response []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ex, ok := s.exchanges[key]; ok {
+		ex.response = response
+	}
+}
+
+func (s *Server) messageID() uint16 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	return s.nextID
+}
+
+func (s *Server) write(m Message, to net.Addr) {
+	if out, err := m.MarshalBinary(); err == nil {
+		s.writeRaw(out, to)
+	}
+}
+
+func (s *Server) writeRaw(out []byte, to net.Addr) {
+	if _, err := s.conn.WriteTo(out, to); err != nil {
+		slog.Warn("CoAP: erro no envio", "to", to.String(), "error", err)
+	}
+}
+
+// --- Recursos ---
+
+// Erro com o texto de diagnóstico no payload (RFC 7252 5.5.2)
+func failure(c Code, diagnostic string) Message {
+	return Message{Code: c, Payload: []byte(diagnostic)}
+}
+
+// Opções críticas (número ímpar) que o servidor entende; uma desconhecida
+// obriga a recusar a requisição com 4.02
+var knownCritical = map[uint16]bool{OptionURIHost: true, OptionURIPort: true, OptionURIPath: true, OptionURIQuery: true, OptionAccept: true}
+
+// Responde a uma requisição (exportado para os testes); tipo, Message ID e
+// token da resposta ficam com quem chamou
+func (s *Server) Handle(ctx context.Context, req Message) Message {
+	for _, o := range req.Options {
+		if o.Number%2 == 1 && !knownCritical[o.Number] {
+			return failure(BadOption, "Unsupported critical option "+strconv.Itoa(int(o.Number)))
+		}
+	}
+	parts := strings.Split(req.Path(), "/")
+	if len(parts) != 3 || parts[0] != "devices" || (parts[2] != "readings" && parts[2] != "heartbeat") {
+		return failure(NotFound, "Resource not found")
+	}
+	if req.Code != POST {
+		return failure(MethodNotAllowed, "Only POST is supported")
+	}
+	if accept, ok := req.Uint(OptionAccept); ok && accept != FormatCBOR {
+		return failure(NotAcceptable, "Responses are CBOR")
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil || id == 0 {
+		return failure(BadRequest, "Invalid device ID")
+	}
+	device, err := s.devices.Authenticate(ctx, uint(id), req.Query("token"))
+	if errors.Is(err, service.ErrInvalidDeviceToken) {
+		return failure(Unauthorized, "Invalid device or token")
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "CoAP: falha ao carregar o dispositivo", "device_id", id, "error", err)
+		return failure(InternalServerError, "Internal error")
+	}
+
+	if parts[2] == "heartbeat" {
+		if _, err := s.devices.Heartbeat(ctx, device.ID, 0); err != nil {
+			slog.ErrorContext(ctx, "CoAP: heartbeat falhou", "device_id", device.ID, "error", err)
+			return failure(InternalServerError, "Internal error")
+		}
+		return Message{Code: Changed}
+	}
+
+	body, res, ok := readingsJSON(req)
+	if !ok {
+		return res
+	}
+	created, err := s.telemetry.Ingest(ctx, device, body)
+	var invalid *service.ReadingsError
+	switch {
+	case errors.As(err, &invalid):
+		diagnostic := invalid.Message
+		if invalid.Index >= 0 {
+			diagnostic += " (index " + strconv.Itoa(invalid.Index) + ")"
+		}
+		return failure(BadRequest, diagnostic)
+	case errors.Is(err, service.ErrReadingsBufferFull):
+		return failure(ServiceUnavailable, "Readings buffer is full; try again later")
+	case err != nil:
+		slog.ErrorContext(ctx, "CoAP: falha ao gravar leituras", "device_id", device.ID, "error", err)
+		return failure(InternalServerError, "Could not store readings")
+	}
+	// Como no HTTP: com o buffer, as leituras ainda vão ser gravadas
+	key := "created"
+	if s.telemetry.Buffered() {
+		key = "accepted"
+	}
+	return Message{Code: Created, Options: []Option{UintOption(OptionContentFormat, FormatCBOR)}, Payload: encodeCBOR(map[string]any{key: created})}
+}
+
+// Payload das leituras -> JSON do HTTP. CBOR é o padrão (sem
+// Content-Format); JSON também é aceito.
+func readingsJSON(req Message) ([]byte, Message, bool) {
+	format, ok := req.Uint(OptionContentFormat)
+	if !ok {
+		format = FormatCBOR
+	}
+	switch format {
+	case FormatJSON:
+		return req.Payload, Message{}, true
+	case FormatCBOR:
+	default:
+		return nil, failure(UnsupportedFormat, "Send the readings as CBOR (60) or JSON (50)"), false
+	}
+	v, err := decodeCBOR(req.Payload)
+	if err != nil {
+		return nil, failure(BadRequest, "Invalid CBOR: "+err.Error()), false
+	}
+	// Sensores sem relógio de calendário mandam o instante como epoch, sem
+	// a tag 1
+	readings, isList := v.([]any)
+	if !isList {
+		readings = []any{v}
+	}
+	for _, r := range readings {
+		if reading, ok := r.(map[string]any); ok {
+			if t, ok := epochTime(reading["timestamp"]); ok {
+				reading["timestamp"] = t.Format(time.RFC3339Nano)
+			}
+		}
+	}
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, failure(BadRequest, "Invalid CBOR: "+err.Error()), false
+	}
+	return body, Message{}, true
+}
@@ -0,0 +1,773 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Configuração Centralizada ---
+// Toda a configuração vem de variáveis de ambiente (definidas no
+// docker-compose). Opcionalmente, CONFIG_FILE aponta para um arquivo no
+// formato CHAVE=valor (mesmos nomes das variáveis); o ambiente tem
+// prioridade sobre o arquivo. As senhas e chaves também podem vir de um
+// arquivo montado (Docker/Kubernetes secrets): DB_PASSWORD_FILE=/run/secrets/db
+// no lugar de DB_PASSWORD, e assim por diante (ver secretKeys). Tudo é
+// validado na inicialização: qualquer problema derruba o processo com uma
+// mensagem clara, em vez de um panic no meio da conexão com o banco.
+type Config struct {
+	Port     string
+	GinMode  string
+	LogLevel string
+
+	// Banco de dados
+	DBDriver            string // "postgres" ou "sqlite"
+	DBPath              string // arquivo do SQLite (":memory:" = só em memória)
+	DBHost              string
+	DBPort              int
+	DBUser              string
+	DBPassword          string
+	DBName              string
+	DBMaxIdleConns      int
+	DBMaxOpenConns      int
+	DBConnMaxLifetime   time.Duration
+	DBConnectRetries    int
+	DBConnectRetryDelay time.Duration
+	DBStatementTimeout  time.Duration // só Postgres; 0 desliga
+	DBSlowQuery         time.Duration // consultas mais lentas saem no log em WARN; 0 desliga
+	DBReplicaHosts      []string      // réplicas de leitura ("host" ou "host:porta"); só Postgres
+	DBHealthInterval    time.Duration // ping periódico que detecta a queda e reconecta; 0 desliga
+	DBQueryRetries      int           // novas tentativas de uma consulta após erro transitório
+	DBBreakerFailures   int           // falhas seguidas que abrem o circuit breaker (global e por rota); 0 desliga
+	DBBreakerCooldown   time.Duration // tempo aberto antes da chamada de teste
+	MigrateOnStart      bool          // false quando as migrações rodam à parte ("server migrate")
+
+	// Autenticação
+	JWTSecret  string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	// Validade do token de POST /admin/impersonate/:id (sem refresh)
+	ImpersonationTTL time.Duration
+
+	// Bloqueio de conta após senhas erradas seguidas
+	LoginMaxAttempts     int // 0 desliga
+	LoginLockoutWindow   time.Duration
+	LoginLockoutDuration time.Duration
+
+	// Redefinição de senha por e-mail (sem SMTP_ADDR, os e-mails vão para o log)
+	PasswordResetTTL time.Duration
+	PasswordResetURL string
+
+	// Código enviado por e-mail para confirmar a exclusão da conta (DELETE /me)
+	AccountDeletionTTL time.Duration
+
+	// Verificação de e-mail no cadastro
+	EmailVerificationTTL     time.Duration
+	EmailVerificationURL     string
+	RequireEmailVerification bool
+	SMTPAddr                 string
+	SMTPFrom                 string
+	SMTPUsername             string
+	SMTPPassword             string
+
+	// Servidor
+	ShutdownTimeout      time.Duration
+	HTTP3Addr            string
+	TLSCertFile          string
+	TLSKeyFile           string
+	TLSAddr              string   // HTTPS no próprio servidor (ex: ":443"); vazio = só HTTP
+	AutocertDomains      []string // certificado automático (Let's Encrypt) em vez dos arquivos
+	AutocertCacheDir     string
+	AutocertEmail        string
+	HTTPSRedirect        bool // a porta HTTP só redireciona para HTTPS
+	MaxDecompressedBytes int64
+	MaxBodyBytes         int64         // 0 desliga
+	CompressMinBytes     int           // respostas menores saem sem compressão; 0 desliga
+	RequestTimeout       time.Duration // 0 desliga
+	TrustedProxies       []string      // IPs ou CIDRs do nginx: só deles vale o X-Forwarded-For; vazio = nenhum
+
+	// Caminhos sem /api/v1 (firmware antigo): desligáveis e com data de fim
+	LegacyRoutes       bool
+	LegacyRoutesSunset time.Time // zero = sem data (sem Sunset)
+
+	// Limite de requisições por cliente (token bucket)
+	RateLimit       int // requisições por janela; 0 desliga
+	RateLimitWindow time.Duration
+	RedisURL        string // opcional: baldes compartilhados entre réplicas e cache
+
+	// Cache de usuários no Redis (só com REDIS_URL); 0 desliga
+	CacheTTL time.Duration
+
+	// Por quanto tempo a resposta de um Idempotency-Key é guardada; 0 desliga
+	IdempotencyTTL time.Duration
+
+	// Dispositivo sem chamar a API por mais que isso fica offline (/ws)
+	PresenceTimeout time.Duration
+
+	// Tarefas em segundo plano (e-mails, webhooks, limpezas)
+	JobQueue       string // "memory" ou "redis" (exige REDIS_URL)
+	JobQueueSize   int    // só na fila em memória
+	JobWorkers     int
+	JobMaxAttempts int
+	JobRetryDelay  time.Duration // primeira espera; dobra a cada falha
+
+	// Entrega dos webhooks: tentativas, prazo de cada POST e espera antes da
+	// primeira nova tentativa (dobra a cada falha)
+	WebhookMaxAttempts int
+	WebhookTimeout     time.Duration
+	WebhookRetryDelay  time.Duration
+
+	// Notificações push: FCM com o JSON da conta de serviço do Firebase,
+	// APNs com a chave .p8 (autenticação por token). Plataforma sem
+	// credencial manda as notificações para o log
+	FCMCredentialsFile string
+	APNsKeyFile        string
+	APNsKeyID          string
+	APNsTeamID         string
+	APNsTopic          string // bundle ID do app
+	APNsSandbox        bool
+	PushMaxAttempts    int
+	PushRetryDelay     time.Duration // primeira espera; dobra a cada falha
+
+	// Retenção das leituras: brutas por ReadingsRawRetention, depois como
+	// média por hora até ReadingsHourlyRetention e por dia até
+	// ReadingsDailyRetention (0 = para sempre; bruta 0 desliga o job)
+	ReadingsRawRetention    time.Duration
+	ReadingsHourlyRetention time.Duration
+	ReadingsDailyRetention  time.Duration
+
+	// Buffer da ingestão: leituras gravadas em bloco a cada
+	// ReadingsBufferInterval ou ReadingsBufferSize linhas (0 desliga)
+	ReadingsBufferInterval time.Duration
+	ReadingsBufferSize     int
+
+	// Ponte MQTT da telemetria (opcional)
+	MQTTBrokerURL string
+	MQTTClientID  string // vazio = "go_api-<hostname>" (único por réplica)
+	MQTTUsername  string
+	MQTTPassword  string
+	MQTTTopic     string
+	MQTTQoS       int
+
+	// Outbox dos eventos de domínio para os outros serviços: "nats"
+	// (nats://host:4222) ou "kafka" (REST Proxy, http://host:8082); "log"
+	// só registra e vazio desliga (nenhum evento é gravado)
+	OutboxBroker      string
+	OutboxBrokerURL   string
+	OutboxTopicPrefix string // assunto/tópico = prefixo + tipo (ex: "go_api.user.created")
+
+	// API gRPC (ex: ":9090"); vazio desliga
+	GRPCAddr string
+
+	// Servidor CoAP (UDP) da ingestão dos sensores (ex: ":5683"); vazio desliga
+	CoAPAddr string
+
+	// IDs de usuários e dispositivos nas respostas e nos caminhos: "int"
+	// (numérico, com o UUID ao lado) ou "uuid" (ver models/public_id.go)
+	PublicIDs string
+
+	// pprof e /debug/vars: porta separada, sem autenticação (ex:
+	// "localhost:6060"; vazio desliga) e/ou a porta principal, só para admin
+	DebugAddr      string
+	DebugEndpoints bool
+
+	// Regiões
+	Region          string
+	RegionEndpoints map[string]string
+
+	// Avatares: redimensionados para AvatarSize x AvatarSize e guardados no
+	// disco (AvatarDir, servidos em /avatars) ou num bucket S3-compatível
+	AvatarStorage  string // "local" ou "s3"
+	AvatarDir      string
+	AvatarBaseURL  string // prefixo do avatar_url
+	AvatarSize     int
+	AvatarMaxBytes int64
+	S3Endpoint     string // ex: "https://s3.amazonaws.com" ou "http://minio:9000"
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+
+	// Login social (OAuth2): cada provedor só vale com client id e secret.
+	// O callback fica em <OAuthCallbackBase>/auth/<provedor>/callback, o
+	// endereço público da API (atrás do gateway, com o /go)
+	GoogleClientID     string
+	GoogleClientSecret string
+	GitHubClientID     string
+	GitHubClientSecret string
+	OAuthCallbackBase  string
+	OAuthSuccessURL    string // painel que recebe os tokens no fragmento (#access_token=...); vazio = JSON
+
+	// Domínio base dos tenants por subdomínio (ex: "api.exemplo.com" faz
+	// "acme.api.exemplo.com" valer como X-Tenant: acme); vazio, só o cabeçalho
+	TenantDomain string
+
+	// Feature flags: grupo de réplicas desta instância (ex: "canary"), para
+	// ligar uma flag num grupo antes de todos, e de quanto em quanto tempo
+	// a réplica relê as flags do banco (0 = a cada consulta)
+	ReplicaGroup        string
+	FeatureFlagsRefresh time.Duration
+
+	// CORS para o painel web servido de outra origem; sem origens, desligado
+	CORSAllowedOrigins   []string // "*" = qualquer origem
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+
+	// Tracing OpenTelemetry (OTLP/gRPC); sem endpoint, desligado
+	OTelEndpoint     string
+	OTelServiceName  string
+	TraceSampleRatio float64 // fração dos traces novos exportada (0 a 1)
+
+	// Desenvolvimento
+	ChaosMode bool
+
+	// Valores das variáveis de secretKeys, escondidos dos logs
+	secrets []string
+}
+
+// Variáveis que aceitam <NOME>_FILE. A URL do Redis entra porque pode
+// levar a senha (redis://:senha@host).
+var secretKeys = []string{
+	"DB_PASSWORD", "JWT_SECRET", "SMTP_PASSWORD", "MQTT_PASSWORD", "REDIS_URL",
+	"S3_ACCESS_KEY_ID", "S3_SECRET_ACCESS_KEY", "GOOGLE_CLIENT_SECRET", "GITHUB_CLIENT_SECRET",
+}
+
+// Valores secretos carregados (para o logging.Redact)
+func (c Config) Secrets() []string {
+	return c.secrets
+}
+
+// Lê as variáveis e acumula os erros de validação para mostrar todos de uma vez
+type configLoader struct {
+	file    map[string]string
+	errs    []error
+	secrets []string
+	// Conteúdo dos <NOME>_FILE, por nome
+	fromFiles map[string]string
+}
+
+func (l *configLoader) lookup(key string) (string, bool) {
+	if v, ok := l.fromFiles[key]; ok {
+		return v, true
+	}
+	if v, ok := os.LookupEnv(key); ok {
+		return v, true
+	}
+	v, ok := l.file[key]
+	return v, ok
+}
+
+// Lê as variáveis de secretKeys de <NOME>_FILE, quando definida (no
+// ambiente ou no CONFIG_FILE). As duas formas juntas são um erro. Só a
+// última quebra de linha do arquivo é removida: o resto, inclusive
+// espaços, faz parte do segredo.
+func (l *configLoader) loadSecretFiles() {
+	for _, key := range secretKeys {
+		path := l.str(key+"_FILE", "")
+		if path == "" {
+			if v := l.str(key, ""); v != "" {
+				l.addSecret(v)
+			}
+			continue
+		}
+		if l.str(key, "") != "" {
+			l.errs = append(l.errs, fmt.Errorf("set %s or %s_FILE, not both", key, key))
+			continue
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("%s_FILE: %w", key, err))
+			continue
+		}
+		v := strings.TrimSuffix(strings.TrimSuffix(string(raw), "\n"), "\r")
+		if v == "" {
+			l.errs = append(l.errs, fmt.Errorf("%s_FILE: %s is empty", key, path))
+			continue
+		}
+		if l.fromFiles == nil {
+			l.fromFiles = make(map[string]string)
+		}
+		l.fromFiles[key] = v
+		l.addSecret(v)
+	}
+}
+
+// Numa URL (REDIS_URL), a senha sozinha também é escondida: uma mensagem
+// de erro pode citá-la sem o resto do endereço
+func (l *configLoader) addSecret(v string) {
+	l.secrets = append(l.secrets, v)
+	if u, err := url.Parse(v); err == nil && u.User != nil {
+		if password, ok := u.User.Password(); ok && password != "" {
+			l.secrets = append(l.secrets, password)
+		}
+	}
+}
+
+func (l *configLoader) str(key, def string) string {
+	if v, ok := l.lookup(key); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+func (l *configLoader) required(key string) string {
+	v := l.str(key, "")
+	if v == "" {
+		l.errs = append(l.errs, fmt.Errorf("%s is required", key))
+	}
+	return v
+}
+
+func (l *configLoader) integer(key string, def int) int {
+	v := l.str(key, "")
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		l.errs = append(l.errs, fmt.Errorf("%s must be a non-negative integer, got %q", key, v))
+		return def
+	}
+	return n
+}
+
+func (l *configLoader) float(key string, def float64) float64 {
+	v := l.str(key, "")
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s must be a number, got %q", key, v))
+		return def
+	}
+	return f
+}
+
+func (l *configLoader) duration(key string, def time.Duration) time.Duration {
+	v := l.str(key, "")
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		l.errs = append(l.errs, fmt.Errorf("%s must be a duration like 30s or 15m, got %q", key, v))
+		return def
+	}
+	return d
+}
+
+// Lista separada por vírgulas (ex: "GET, POST"); vazia = def
+func (l *configLoader) list(key, def string) []string {
+	var items []string
+	for _, item := range strings.Split(l.str(key, def), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (l *configLoader) boolean(key string, def bool) bool {
+	v := l.str(key, "")
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s must be true or false, got %q", key, v))
+		return def
+	}
+	return b
+}
+
+// Arquivo CHAVE=valor; linhas vazias e começando com # são ignoradas
+func readConfigFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=value", path, n)
+		}
+		values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return values, scanner.Err()
+}
+
+// Lê e valida a configuração (chamado uma vez no main)
+func Load() (Config, error) {
+	l := &configLoader{}
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		values, err := readConfigFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("reading CONFIG_FILE: %w", err)
+		}
+		l.file = values
+	}
+	l.loadSecretFiles()
+
+	// Com SQLite (desenvolvimento local e testes) as variáveis do Postgres
+	// deixam de ser obrigatórias
+	driver := strings.ToLower(l.str("DB_DRIVER", "postgres"))
+	dbSetting := l.required
+	if driver == "sqlite" {
+		dbSetting = func(key string) string { return l.str(key, "") }
+	}
+
+	c := Config{
+		Port:     l.str("PORT", "8080"),
+		GinMode:  l.str("GIN_MODE", "release"),
+		LogLevel: strings.ToLower(l.str("LOG_LEVEL", "info")),
+
+		DBDriver:            driver,
+		DBPath:              l.str("DB_PATH", "api.db"),
+		DBHost:              dbSetting("DB_HOST"),
+		DBPort:              l.integer("DB_PORT", 5432),
+		DBUser:              dbSetting("DB_USER"),
+		DBPassword:          dbSetting("DB_PASSWORD"),
+		DBName:              dbSetting("DB_NAME"),
+		DBMaxIdleConns:      l.integer("DB_MAX_IDLE_CONNS", 20),
+		DBMaxOpenConns:      l.integer("DB_MAX_OPEN_CONNS", 80),
+		DBConnMaxLifetime:   l.duration("DB_CONN_MAX_LIFETIME", time.Hour),
+		DBConnectRetries:    l.integer("DB_CONNECT_RETRIES", 5),
+		DBConnectRetryDelay: l.duration("DB_CONNECT_RETRY_DELAY", 2*time.Second),
+		DBStatementTimeout:  l.duration("DB_STATEMENT_TIMEOUT", 0),
+		DBSlowQuery:         l.duration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+		DBReplicaHosts:      l.list("DB_REPLICA_HOSTS", ""),
+		DBHealthInterval:    l.duration("DB_HEALTH_INTERVAL", 10*time.Second),
+		DBQueryRetries:      l.integer("DB_QUERY_RETRIES", 2),
+		DBBreakerFailures:   l.integer("DB_BREAKER_FAILURES", 5),
+		DBBreakerCooldown:   l.duration("DB_BREAKER_COOLDOWN", 10*time.Second),
+		MigrateOnStart:      l.boolean("MIGRATE_ON_START", true),
+
+		JWTSecret:  l.required("JWT_SECRET"),
+		AccessTTL:  l.duration("JWT_ACCESS_TTL", 15*time.Minute),
+		RefreshTTL: l.duration("JWT_REFRESH_TTL", 7*24*time.Hour),
+
+		ImpersonationTTL: l.duration("IMPERSONATION_TTL", 10*time.Minute),
+
+		LoginMaxAttempts:     l.integer("LOGIN_MAX_ATTEMPTS", 5),
+		LoginLockoutWindow:   l.duration("LOGIN_LOCKOUT_WINDOW", 15*time.Minute),
+		LoginLockoutDuration: l.duration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+
+		PasswordResetTTL: l.duration("PASSWORD_RESET_TTL", 30*time.Minute),
+		PasswordResetURL: l.str("PASSWORD_RESET_URL", ""),
+
+		AccountDeletionTTL: l.duration("ACCOUNT_DELETION_TTL", time.Hour),
+
+		EmailVerificationTTL:     l.duration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+		EmailVerificationURL:     l.str("EMAIL_VERIFICATION_URL", ""),
+		RequireEmailVerification: l.boolean("REQUIRE_EMAIL_VERIFICATION", false),
+
+		IdempotencyTTL: l.duration("IDEMPOTENCY_TTL", 24*time.Hour),
+
+		SMTPAddr:     l.str("SMTP_ADDR", ""),
+		SMTPFrom:     l.str("SMTP_FROM", ""),
+		SMTPUsername: l.str("SMTP_USERNAME", ""),
+		SMTPPassword: l.str("SMTP_PASSWORD", ""),
+
+		ShutdownTimeout:      l.duration("SHUTDOWN_TIMEOUT", 20*time.Second),
+		HTTP3Addr:            l.str("HTTP3_ADDR", ""),
+		TLSCertFile:          l.str("TLS_CERT_FILE", ""),
+		TLSKeyFile:           l.str("TLS_KEY_FILE", ""),
+		TLSAddr:              l.str("TLS_ADDR", ""),
+		AutocertDomains:      l.list("AUTOCERT_DOMAINS", ""),
+		AutocertCacheDir:     l.str("AUTOCERT_CACHE_DIR", "certs"),
+		AutocertEmail:        l.str("AUTOCERT_EMAIL", ""),
+		HTTPSRedirect:        l.boolean("HTTPS_REDIRECT", false),
+		MaxDecompressedBytes: int64(l.integer("MAX_DECOMPRESSED_BODY_BYTES", 10<<20)),
+		MaxBodyBytes:         int64(l.integer("MAX_BODY_BYTES", 2<<20)),
+		CompressMinBytes:     l.integer("COMPRESS_MIN_BYTES", 1024),
+		RequestTimeout:       l.duration("REQUEST_TIMEOUT", 30*time.Second),
+		TrustedProxies:       l.list("TRUSTED_PROXIES", ""),
+		LegacyRoutes:         l.boolean("LEGACY_ROUTES", true),
+
+		RateLimit:       l.integer("RATE_LIMIT", 100),
+		RateLimitWindow: l.duration("RATE_LIMIT_WINDOW", time.Minute),
+		RedisURL:        l.str("REDIS_URL", ""),
+		CacheTTL:        l.duration("CACHE_TTL", time.Minute),
+
+		PresenceTimeout: l.duration("PRESENCE_TIMEOUT", 2*time.Minute),
+
+		JobQueue:       l.str("JOB_QUEUE", "memory"),
+		JobQueueSize:   l.integer("JOB_QUEUE_SIZE", 1000),
+		JobWorkers:     l.integer("JOB_WORKERS", 4),
+		JobMaxAttempts: l.integer("JOB_MAX_ATTEMPTS", 5),
+		JobRetryDelay:  l.duration("JOB_RETRY_DELAY", 10*time.Second),
+
+		WebhookMaxAttempts: l.integer("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookTimeout:     l.duration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookRetryDelay:  l.duration("WEBHOOK_RETRY_DELAY", 30*time.Second),
+
+		FCMCredentialsFile: l.str("FCM_CREDENTIALS_FILE", ""),
+		APNsKeyFile:        l.str("APNS_KEY_FILE", ""),
+		APNsKeyID:          l.str("APNS_KEY_ID", ""),
+		APNsTeamID:         l.str("APNS_TEAM_ID", ""),
+		APNsTopic:          l.str("APNS_TOPIC", ""),
+		APNsSandbox:        l.boolean("APNS_SANDBOX", false),
+		PushMaxAttempts:    l.integer("PUSH_MAX_ATTEMPTS", 5),
+		PushRetryDelay:     l.duration("PUSH_RETRY_DELAY", 30*time.Second),
+
+		ReadingsRawRetention:    l.duration("READINGS_RAW_RETENTION", 7*24*time.Hour),
+		ReadingsHourlyRetention: l.duration("READINGS_HOURLY_RETENTION", 90*24*time.Hour),
+		ReadingsDailyRetention:  l.duration("READINGS_DAILY_RETENTION", 0),
+		ReadingsBufferInterval:  l.duration("READINGS_BUFFER_INTERVAL", 0),
+		ReadingsBufferSize:      l.integer("READINGS_BUFFER_SIZE", 1000),
+
+		MQTTBrokerURL: l.str("MQTT_BROKER_URL", ""),
+		MQTTClientID:  l.str("MQTT_CLIENT_ID", ""),
+		MQTTUsername:  l.str("MQTT_USERNAME", ""),
+		MQTTPassword:  l.str("MQTT_PASSWORD", ""),
+		MQTTTopic:     l.str("MQTT_TOPIC", "$share/go_api/devices/+/readings"),
+		MQTTQoS:       l.integer("MQTT_QOS", 1),
+		GRPCAddr:      l.str("GRPC_ADDR", ""),
+		CoAPAddr:      l.str("COAP_ADDR", ""),
+
+		OutboxBroker:      l.str("OUTBOX_BROKER", ""),
+		OutboxBrokerURL:   l.str("OUTBOX_BROKER_URL", ""),
+		OutboxTopicPrefix: l.str("OUTBOX_TOPIC_PREFIX", "go_api."),
+
+		PublicIDs: strings.ToLower(l.str("PUBLIC_IDS", "int")),
+
+		DebugAddr:      l.str("DEBUG_ADDR", ""),
+		DebugEndpoints: l.boolean("DEBUG_ENDPOINTS", false),
+
+		Region:          l.str("REGION", ""),
+		RegionEndpoints: parseRegionEndpoints(l.str("REGION_ENDPOINTS", "")),
+
+		AvatarStorage:  strings.ToLower(l.str("AVATAR_STORAGE", "local")),
+		AvatarDir:      l.str("AVATAR_DIR", "avatars"),
+		AvatarSize:     l.integer("AVATAR_SIZE", 256),
+		AvatarMaxBytes: int64(l.integer("AVATAR_MAX_BYTES", 5<<20)),
+		S3Endpoint:     strings.TrimSuffix(l.str("S3_ENDPOINT", ""), "/"),
+		S3Region:       l.str("S3_REGION", "us-east-1"),
+		S3Bucket:       l.str("S3_BUCKET", ""),
+		S3AccessKey:    l.str("S3_ACCESS_KEY_ID", ""),
+		S3SecretKey:    l.str("S3_SECRET_ACCESS_KEY", ""),
+
+		GoogleClientID:     l.str("GOOGLE_CLIENT_ID", ""),
+		GoogleClientSecret: l.str("GOOGLE_CLIENT_SECRET", ""),
+		GitHubClientID:     l.str("GITHUB_CLIENT_ID", ""),
+		GitHubClientSecret: l.str("GITHUB_CLIENT_SECRET", ""),
+		OAuthCallbackBase:  strings.TrimSuffix(l.str("OAUTH_CALLBACK_BASE_URL", ""), "/"),
+		OAuthSuccessURL:    l.str("OAUTH_SUCCESS_URL", ""),
+
+		TenantDomain: strings.ToLower(strings.TrimPrefix(l.str("TENANT_DOMAIN", ""), ".")),
+
+		ReplicaGroup:        l.str("REPLICA_GROUP", ""),
+		FeatureFlagsRefresh: l.duration("FEATURE_FLAGS_REFRESH", 30*time.Second),
+
+		CORSAllowedOrigins:   l.list("CORS_ALLOWED_ORIGINS", ""),
+		CORSAllowedMethods:   l.list("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE"),
+		CORSAllowedHeaders:   l.list("CORS_ALLOWED_HEADERS", "Authorization,Content-Type,Content-Encoding,Accept-Language,Idempotency-Key,If-Match,If-None-Match,X-Request-ID,X-Tenant"),
+		CORSAllowCredentials: l.boolean("CORS_ALLOW_CREDENTIALS", false),
+		CORSMaxAge:           l.duration("CORS_MAX_AGE", 10*time.Minute),
+
+		OTelEndpoint:     l.str("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		OTelServiceName:  l.str("OTEL_SERVICE_NAME", "go_api"),
+		TraceSampleRatio: l.float("TRACE_SAMPLE_RATIO", 1),
+
+		ChaosMode: l.boolean("CHAOS_MODE", false),
+
+		secrets: l.secrets,
+	}
+
+	// Regras que envolvem mais de um campo
+	switch c.GinMode {
+	case "release", "debug", "test":
+	default:
+		l.errs = append(l.errs, fmt.Errorf("GIN_MODE must be release, debug or test, got %q", c.GinMode))
+	}
+	// As rotas /chaos derrubam a API de propósito: nunca em produção
+	if c.ChaosMode && c.GinMode == "release" {
+		l.errs = append(l.errs, errors.New("CHAOS_MODE cannot be enabled with GIN_MODE=release"))
+	}
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		l.errs = append(l.errs, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error, got %q", c.LogLevel))
+	}
+	switch c.DBDriver {
+	case "postgres", "sqlite":
+	default:
+		l.errs = append(l.errs, fmt.Errorf("DB_DRIVER must be postgres or sqlite, got %q", c.DBDriver))
+	}
+	if c.DBMaxOpenConns > 0 && c.DBMaxIdleConns > c.DBMaxOpenConns {
+		l.errs = append(l.errs, errors.New("DB_MAX_IDLE_CONNS cannot be greater than DB_MAX_OPEN_CONNS"))
+	}
+	if c.DBConnectRetries < 1 {
+		l.errs = append(l.errs, errors.New("DB_CONNECT_RETRIES must be at least 1"))
+	}
+	if c.DBSlowQuery < 0 {
+		l.errs = append(l.errs, errors.New("DB_SLOW_QUERY_THRESHOLD cannot be negative"))
+	}
+	if c.DBQueryRetries < 0 {
+		l.errs = append(l.errs, errors.New("DB_QUERY_RETRIES cannot be negative"))
+	}
+	if c.DBBreakerFailures < 0 || c.DBBreakerFailures > 0 && c.DBBreakerCooldown <= 0 {
+		l.errs = append(l.errs, errors.New("DB_BREAKER_FAILURES cannot be negative and DB_BREAKER_COOLDOWN must be positive"))
+	}
+	if len(c.DBReplicaHosts) > 0 && c.DBDriver != "postgres" {
+		l.errs = append(l.errs, errors.New("DB_REPLICA_HOSTS requires DB_DRIVER=postgres"))
+	}
+	if c.RateLimit > 0 && c.RateLimitWindow <= 0 {
+		l.errs = append(l.errs, errors.New("RATE_LIMIT_WINDOW must be greater than zero"))
+	}
+	if c.LoginMaxAttempts > 0 && (c.LoginLockoutWindow <= 0 || c.LoginLockoutDuration <= 0) {
+		l.errs = append(l.errs, errors.New("LOGIN_LOCKOUT_WINDOW and LOGIN_LOCKOUT_DURATION must be greater than zero"))
+	}
+	if c.PasswordResetTTL <= 0 || c.EmailVerificationTTL <= 0 || c.AccountDeletionTTL <= 0 {
+		l.errs = append(l.errs, errors.New("PASSWORD_RESET_TTL, EMAIL_VERIFICATION_TTL and ACCOUNT_DELETION_TTL must be greater than zero"))
+	}
+	if c.ImpersonationTTL <= 0 || c.ImpersonationTTL > time.Hour {
+		l.errs = append(l.errs, errors.New("IMPERSONATION_TTL must be between 1s and 1h"))
+	}
+	if c.SMTPAddr != "" && c.SMTPFrom == "" {
+		l.errs = append(l.errs, errors.New("SMTP_ADDR requires SMTP_FROM"))
+	}
+	if c.PresenceTimeout <= 0 {
+		l.errs = append(l.errs, errors.New("PRESENCE_TIMEOUT must be greater than zero"))
+	}
+	switch {
+	case c.JobQueue != "memory" && c.JobQueue != "redis":
+		l.errs = append(l.errs, fmt.Errorf("JOB_QUEUE must be memory or redis, got %q", c.JobQueue))
+	case c.JobQueue == "redis" && c.RedisURL == "":
+		l.errs = append(l.errs, errors.New("JOB_QUEUE=redis requires REDIS_URL"))
+	}
+	if c.JobQueueSize < 1 || c.JobWorkers < 1 || c.JobMaxAttempts < 1 || c.JobRetryDelay <= 0 {
+		l.errs = append(l.errs, errors.New("JOB_QUEUE_SIZE, JOB_WORKERS, JOB_MAX_ATTEMPTS and JOB_RETRY_DELAY must be greater than zero"))
+	}
+	if c.WebhookMaxAttempts < 1 || c.WebhookTimeout <= 0 || c.WebhookRetryDelay <= 0 {
+		l.errs = append(l.errs, errors.New("WEBHOOK_MAX_ATTEMPTS must be at least 1 and WEBHOOK_TIMEOUT and WEBHOOK_RETRY_DELAY greater than zero"))
+	}
+	if c.PushMaxAttempts < 1 || c.PushRetryDelay <= 0 {
+		l.errs = append(l.errs, errors.New("PUSH_MAX_ATTEMPTS must be at least 1 and PUSH_RETRY_DELAY greater than zero"))
+	}
+	// Cada janela maior que a anterior (a hora só vira dia depois de agregada)
+	raw, hourly, daily := c.ReadingsRawRetention, c.ReadingsHourlyRetention, c.ReadingsDailyRetention
+	switch {
+	case raw < 0 || hourly < 0 || daily < 0:
+		l.errs = append(l.errs, errors.New("READINGS_RAW_RETENTION, READINGS_HOURLY_RETENTION and READINGS_DAILY_RETENTION must not be negative"))
+	case raw > 0 && hourly > 0 && hourly <= raw:
+		l.errs = append(l.errs, errors.New("READINGS_HOURLY_RETENTION must be longer than READINGS_RAW_RETENTION"))
+	case raw > 0 && daily > 0 && daily <= max(raw, hourly):
+		l.errs = append(l.errs, errors.New("READINGS_DAILY_RETENTION must be longer than READINGS_RAW_RETENTION and READINGS_HOURLY_RETENTION"))
+	}
+	if c.ReadingsBufferInterval < 0 || c.ReadingsBufferInterval > 0 && c.ReadingsBufferSize <= 0 {
+		l.errs = append(l.errs, errors.New("READINGS_BUFFER_INTERVAL cannot be negative and READINGS_BUFFER_SIZE must be positive"))
+	}
+	if c.APNsKeyFile != "" && (c.APNsKeyID == "" || c.APNsTeamID == "" || c.APNsTopic == "") {
+		l.errs = append(l.errs, errors.New("APNS_KEY_FILE requires APNS_KEY_ID, APNS_TEAM_ID and APNS_TOPIC"))
+	}
+	// Sem AVATAR_BASE_URL: a rota /avatars da própria API ou o bucket
+	c.AvatarBaseURL = strings.TrimSuffix(l.str("AVATAR_BASE_URL", ""), "/")
+	switch c.AvatarStorage {
+	case "local":
+		if c.AvatarBaseURL == "" {
+			c.AvatarBaseURL = "/avatars"
+		}
+	case "s3":
+		if c.S3Endpoint == "" || c.S3Bucket == "" || c.S3AccessKey == "" || c.S3SecretKey == "" {
+			l.errs = append(l.errs, errors.New("AVATAR_STORAGE=s3 requires S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY"))
+		}
+		if c.AvatarBaseURL == "" {
+			c.AvatarBaseURL = c.S3Endpoint + "/" + c.S3Bucket
+		}
+	default:
+		l.errs = append(l.errs, fmt.Errorf("AVATAR_STORAGE must be local or s3, got %q", c.AvatarStorage))
+	}
+	if c.AvatarSize < 16 || c.AvatarSize > 2048 || c.AvatarMaxBytes <= 0 {
+		l.errs = append(l.errs, errors.New("AVATAR_SIZE must be between 16 and 2048 and AVATAR_MAX_BYTES greater than zero"))
+	}
+	for _, p := range []struct{ name, id, secret string }{
+		{"GOOGLE", c.GoogleClientID, c.GoogleClientSecret},
+		{"GITHUB", c.GitHubClientID, c.GitHubClientSecret},
+	} {
+		if (p.id == "") != (p.secret == "") {
+			l.errs = append(l.errs, fmt.Errorf("%s_CLIENT_ID and %s_CLIENT_SECRET must be set together", p.name, p.name))
+		}
+	}
+	if (c.GoogleClientID != "" || c.GitHubClientID != "") && c.OAuthCallbackBase == "" {
+		l.errs = append(l.errs, errors.New("OAuth login requires OAUTH_CALLBACK_BASE_URL"))
+	}
+	if c.MQTTQoS > 2 {
+		l.errs = append(l.errs, fmt.Errorf("MQTT_QOS must be 0, 1 or 2, got %d", c.MQTTQoS))
+	}
+	switch c.OutboxBroker {
+	case "", "log":
+	case "nats", "kafka":
+		if c.OutboxBrokerURL == "" {
+			l.errs = append(l.errs, fmt.Errorf("OUTBOX_BROKER=%s requires OUTBOX_BROKER_URL", c.OutboxBroker))
+		}
+	default:
+		l.errs = append(l.errs, fmt.Errorf("OUTBOX_BROKER must be nats, kafka or log, got %q", c.OutboxBroker))
+	}
+	if c.PublicIDs != "int" && c.PublicIDs != "uuid" {
+		l.errs = append(l.errs, fmt.Errorf("PUBLIC_IDS must be int or uuid, got %q", c.PublicIDs))
+	}
+	if c.TraceSampleRatio < 0 || c.TraceSampleRatio > 1 {
+		l.errs = append(l.errs, fmt.Errorf("TRACE_SAMPLE_RATIO must be between 0 and 1, got %g", c.TraceSampleRatio))
+	}
+	// O navegador recusa "Access-Control-Allow-Origin: *" com credenciais
+	if c.CORSAllowCredentials && slices.Contains(c.CORSAllowedOrigins, "*") {
+		l.errs = append(l.errs, errors.New("CORS_ALLOW_CREDENTIALS cannot be combined with CORS_ALLOWED_ORIGINS=*"))
+	}
+	if v := l.str("LEGACY_ROUTES_SUNSET", ""); v != "" {
+		sunset, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("LEGACY_ROUTES_SUNSET must be a date like 2027-06-30, got %q", v))
+		}
+		c.LegacyRoutesSunset = sunset
+	}
+	for _, proxy := range c.TrustedProxies {
+		if _, err := netip.ParsePrefix(proxy); err != nil {
+			if _, err := netip.ParseAddr(proxy); err != nil {
+				l.errs = append(l.errs, fmt.Errorf("TRUSTED_PROXIES must list IPs or CIDRs, got %q", proxy))
+			}
+		}
+	}
+	if c.HTTP3Addr != "" && (c.TLSCertFile == "" || c.TLSKeyFile == "") {
+		l.errs = append(l.errs, errors.New("HTTP3_ADDR requires TLS_CERT_FILE and TLS_KEY_FILE"))
+	}
+	hasCertFiles := c.TLSCertFile != "" && c.TLSKeyFile != ""
+	switch {
+	case c.TLSAddr != "" && !hasCertFiles && len(c.AutocertDomains) == 0:
+		l.errs = append(l.errs, errors.New("TLS_ADDR requires TLS_CERT_FILE and TLS_KEY_FILE, or AUTOCERT_DOMAINS"))
+	case c.TLSAddr == "" && (len(c.AutocertDomains) > 0 || c.HTTPSRedirect):
+		l.errs = append(l.errs, errors.New("AUTOCERT_DOMAINS and HTTPS_REDIRECT require TLS_ADDR"))
+	}
+
+	return c, errors.Join(l.errs...)
+}
+
+// REGION_ENDPOINTS: "campus-a=https://a.exemplo,campus-b=https://b.exemplo"
+func parseRegionEndpoints(value string) map[string]string {
+	endpoints := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		name, url, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && name != "" {
+			endpoints[name] = url
+		}
+	}
+	return endpoints
+}
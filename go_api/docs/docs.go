@@ -0,0 +1,30 @@
+package docs
+
+import _ "embed"
+
+// --- Documentação da API (OpenAPI) ---
+// A especificação é escrita à mão em openapi.json e embutida no binário.
+// Rota nova no router precisa entrar lá também; o teste de integração
+// falha se alguma rota ficar sem documentação.
+
+//go:embed openapi.json
+var Spec []byte
+
+// Swagger UI carregado do CDN. O caminho da especificação é relativo para
+// funcionar tanto direto na API (/docs) quanto pelo gateway (/go/docs).
+const UI = `<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+  <meta charset="utf-8">
+  <title>API Go - Documentação</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    SwaggerUIBundle({ url: "openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,492 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// --- Execução ---
+
+// Corpo do POST /graphql (ou a query string do GET)
+type Request struct {
+	Query         string                 `json:"query" form:"query"`
+	OperationName string                 `json:"operationName" form:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	// Só consultas (GET): mutações são recusadas
+	ReadOnly bool `json:"-" form:"-"`
+}
+
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type Error struct {
+	Message    string                 `json:"message"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+type Response struct {
+	// Ausente quando a consulta nem chegou a executar (erro de sintaxe, de
+	// validação ou nas variáveis); null quando um campo obrigatório da raiz
+	// falhou
+	Data   json.RawMessage `json:"data,omitempty"`
+	Errors []*Error        `json:"errors,omitempty"`
+}
+
+// Códigos em extensions.code dos erros da própria consulta (os dos
+// resolvers vêm do FormatError)
+const (
+	CodeParseFailed      = "graphql_parse_failed"
+	CodeValidationFailed = "graphql_validation_failed"
+	CodeBadInput         = "bad_request"
+)
+
+// Executa a operação pedida (ou a única do documento)
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		se := err.(*SyntaxError)
+		return requestErrors(&Error{
+			Message:    se.Error(),
+			Locations:  []Location{location(req.Query, se.Pos)},
+			Extensions: map[string]interface{}{"code": CodeParseFailed},
+		})
+	}
+	op, root, gqlErr := s.operation(doc, req)
+	if gqlErr != nil {
+		return requestErrors(gqlErr)
+	}
+	if errs := s.validate(req.Query, doc, op); len(errs) > 0 {
+		return requestErrors(errs...)
+	}
+
+	e := &executor{ctx: ctx, schema: s, src: req.Query, doc: doc, variables: map[string]interface{}{}}
+	if errs := e.coerceVariables(op, req.Variables); len(errs) > 0 {
+		return requestErrors(errs...)
+	}
+	// Os campos da raiz rodam em sequência (o que a especificação exige das
+	// mutações)
+	data, ok := e.executeObject(root, nil, [][]selection{op.selections}, nil)
+	raw := []byte("null")
+	if ok {
+		if raw, err = json.Marshal(data); err != nil {
+			return requestErrors(&Error{Message: err.Error()})
+		}
+	}
+	return &Response{Data: raw, Errors: e.errors}
+}
+
+func requestErrors(errs ...*Error) *Response {
+	for _, err := range errs {
+		if err.Extensions == nil {
+			err.Extensions = map[string]interface{}{"code": CodeValidationFailed}
+		}
+	}
+	return &Response{Errors: errs}
+}
+
+func (s *Schema) operation(doc *document, req Request) (*operation, *Object, *Error) {
+	var op *operation
+	switch {
+	case req.OperationName != "":
+		for _, candidate := range doc.operations {
+			if candidate.name == req.OperationName {
+				op = candidate
+			}
+		}
+		if op == nil {
+			return nil, nil, &Error{Message: fmt.Sprintf("Unknown operation named %q.", req.OperationName)}
+		}
+	case len(doc.operations) == 1:
+		op = doc.operations[0]
+	case len(doc.operations) == 0:
+		return nil, nil, &Error{Message: "Document has no operations."}
+	default:
+		return nil, nil, &Error{Message: "Must provide operation name if query contains multiple operations."}
+	}
+	loc := []Location{location(req.Query, op.pos)}
+	switch op.kind {
+	case "query":
+		return op, s.Query, nil
+	case "mutation":
+		if s.Mutation == nil {
+			return nil, nil, &Error{Message: "Schema is not configured for mutations.", Locations: loc}
+		}
+		if req.ReadOnly {
+			return nil, nil, &Error{Message: "Mutations must be sent with POST.", Locations: loc,
+				Extensions: map[string]interface{}{"code": CodeBadInput}}
+		}
+		return op, s.Mutation, nil
+	}
+	return nil, nil, &Error{Message: "Subscriptions are not supported.", Locations: loc}
+}
+
+type executor struct {
+	ctx       context.Context
+	schema    *Schema
+	src       string
+	doc       *document
+	variables map[string]interface{} // só as informadas (ou com padrão)
+	errors    []*Error
+}
+
+func (e *executor) coerceVariables(op *operation, raw map[string]interface{}) []*Error {
+	var errs []*Error
+	for _, def := range op.variables {
+		t := e.schema.inputType(def.typ)
+		v, provided := raw[def.name]
+		fail := func(format string, args ...interface{}) {
+			errs = append(errs, &Error{
+				Message:    fmt.Sprintf(format, args...),
+				Locations:  []Location{location(e.src, def.pos)},
+				Extensions: map[string]interface{}{"code": CodeBadInput},
+			})
+		}
+		switch {
+		case !provided && def.hasDefault:
+			v = def.def
+		case !provided && def.typ.nonNull:
+			fail("Variable \"$%s\" of required type %q was not provided.", def.name, def.typ)
+			continue
+		case !provided:
+			continue
+		}
+		if _, err := coerceInput(t, v); err != nil {
+			fail("Variable \"$%s\" got invalid value: %v", def.name, err)
+			continue
+		}
+		// Guardada como veio: a conversão é refeita para o tipo do argumento
+		e.variables[def.name] = v
+	}
+	return errs
+}
+
+// Tipo do schema para o tipo escrito na consulta (já validado)
+func (s *Schema) inputType(ref *typeRef) Type {
+	var t Type
+	if ref.elem != nil {
+		t = ListOf(s.inputType(ref.elem))
+	} else {
+		t = s.types[ref.name]
+	}
+	if ref.nonNull {
+		t = NonNullOf(t)
+	}
+	return t
+}
+
+// --- Campos ---
+
+// Campos com a mesma chave (alias ou nome) na resposta, em ordem
+type fieldGroup struct {
+	key    string
+	fields []*field
+}
+
+func (e *executor) collect(obj *Object, sets [][]selection) []fieldGroup {
+	var groups []fieldGroup
+	index := map[string]int{}
+	visited := map[string]bool{}
+	var walk func(sels []selection)
+	walk = func(sels []selection) {
+		for _, sel := range sels {
+			switch sel := sel.(type) {
+			case *field:
+				if !e.included(sel.directives) {
+					continue
+				}
+				key := sel.key()
+				if i, ok := index[key]; ok {
+					groups[i].fields = append(groups[i].fields, sel)
+					continue
+				}
+				index[key] = len(groups)
+				groups = append(groups, fieldGroup{key: key, fields: []*field{sel}})
+			case *fragmentSpread:
+				frag := e.doc.fragments[sel.name]
+				if visited[sel.name] || !e.included(sel.directives) || frag.typeCondition != obj.Name {
+					continue
+				}
+				visited[sel.name] = true
+				walk(frag.selections)
+			case *inlineFragment:
+				if !e.included(sel.directives) || (sel.typeCondition != "" && sel.typeCondition != obj.Name) {
+					continue
+				}
+				walk(sel.selections)
+			}
+		}
+	}
+	for _, sels := range sets {
+		walk(sels)
+	}
+	return groups
+}
+
+// @skip(if: ...) e @include(if: ...)
+func (e *executor) included(dirs []*directive) bool {
+	for _, d := range dirs {
+		args, err := e.arguments(ifArgument, d.arguments)
+		if err != nil {
+			continue // erro de validação: a diretiva não vale
+		}
+		if cond := args["if"].(bool); (d.name == "skip" && cond) || (d.name == "include" && !cond) {
+			return false
+		}
+	}
+	return true
+}
+
+// Objeto ordenado da resposta
+type object []objectField
+
+type objectField struct {
+	key   string
+	value interface{}
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.key)
+		b.Write(key)
+		b.WriteByte(':')
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// false = um campo obrigatório falhou e o objeto inteiro vira null
+func (e *executor) executeObject(obj *Object, source interface{}, sets [][]selection, path []interface{}) (object, bool) {
+	groups := e.collect(obj, sets)
+	out := make(object, 0, len(groups))
+	for _, g := range groups {
+		value, ok := e.executeField(obj, source, g, extend(path, g.key))
+		if !ok {
+			return nil, false
+		}
+		out = append(out, objectField{key: g.key, value: value})
+	}
+	return out, true
+}
+
+func extend(path []interface{}, key interface{}) []interface{} {
+	return append(append(make([]interface{}, 0, len(path)+1), path...), key)
+}
+
+func (e *executor) executeField(obj *Object, source interface{}, g fieldGroup, path []interface{}) (interface{}, bool) {
+	f := g.fields[0]
+	if f.name == "__typename" {
+		return obj.Name, true
+	}
+	def := obj.field(f.name)
+	_, required := def.Type.(*NonNull)
+	args, err := e.arguments(def.Args, f.arguments)
+	if err != nil {
+		e.fail(f, path, err.Error(), map[string]interface{}{"code": CodeBadInput})
+		return nil, !required
+	}
+	var value interface{}
+	if def.Resolve != nil {
+		value, err = def.Resolve(ResolveParams{Context: e.ctx, Source: source, Args: args})
+	} else {
+		value, err = defaultResolve(source, def.Name)
+	}
+	if err != nil {
+		message, ext := err.Error(), map[string]interface{}(nil)
+		if e.schema.FormatError != nil {
+			message, ext = e.schema.FormatError(e.ctx, err)
+		}
+		e.fail(f, path, message, ext)
+		return nil, !required
+	}
+	return e.complete(def.Type, g.fields, value, path)
+}
+
+func (e *executor) fail(f *field, path []interface{}, message string, ext map[string]interface{}) {
+	e.errors = append(e.errors, &Error{
+		Message:    message,
+		Locations:  []Location{location(e.src, f.pos)},
+		Path:       path,
+		Extensions: ext,
+	})
+}
+
+// Converte o valor do resolver para o tipo do campo. false = null num
+// campo obrigatório: sobe para o pai.
+func (e *executor) complete(t Type, fields []*field, v interface{}, path []interface{}) (interface{}, bool) {
+	if nn, ok := t.(*NonNull); ok {
+		before := len(e.errors)
+		out, ok := e.complete(nn.Of, fields, v, path)
+		if ok && out == nil && len(e.errors) == before {
+			e.fail(fields[0], path, fmt.Sprintf("Cannot return null for non-nullable field %q.", fields[0].name), nil)
+		}
+		return out, ok && out != nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, true
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil, true
+	}
+	switch t := t.(type) {
+	case *List:
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.fail(fields[0], path, fmt.Sprintf("Expected a list for field %q.", fields[0].name), nil)
+			return nil, true
+		}
+		out := make([]interface{}, rv.Len())
+		for i := range out {
+			item, ok := e.complete(t.Of, fields, rv.Index(i).Interface(), extend(path, i))
+			if !ok {
+				return nil, true
+			}
+			out[i] = item
+		}
+		return out, true
+	case *Scalar:
+		if (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Map) && rv.IsNil() {
+			return nil, true
+		}
+		out, err := t.Serialize(rv.Interface())
+		if err != nil {
+			e.fail(fields[0], path, err.Error(), nil)
+			return nil, true
+		}
+		return out, true
+	case *Object:
+		sets := make([][]selection, len(fields))
+		for i, f := range fields {
+			sets[i] = f.selections
+		}
+		out, ok := e.executeObject(t, rv.Interface(), sets, path)
+		if !ok {
+			return nil, true
+		}
+		return out, true
+	}
+	return nil, true
+}
+
+// --- Argumentos ---
+
+func (e *executor) arguments(defs []*Argument, args []*argument) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, arg := range args {
+		if v, ok := e.value(arg.value); ok {
+			values[arg.name] = v
+		}
+	}
+	out := make(map[string]interface{}, len(defs))
+	for _, def := range defs {
+		v, err := coerceArgument(def, values)
+		if errors.Is(err, errMissing) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Argument %q has invalid value: %v", def.Name, err)
+		}
+		out[def.Name] = v
+	}
+	return out, nil
+}
+
+// Valor da consulta com as variáveis substituídas; false = variável sem
+// valor (o argumento fica ausente)
+func (e *executor) value(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case variable:
+		value, ok := e.variables[string(v)]
+		return value, ok
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i], _ = e.value(item)
+		}
+		return out, true
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			if value, ok := e.value(item); ok {
+				out[name] = value
+			}
+		}
+		return out, true
+	}
+	return v, true
+}
+
+// --- Resolver padrão ---
+
+// Campo do struct (ou do struct embutido) com a tag json de mesmo nome
+func defaultResolve(source interface{}, name string) (interface{}, error) {
+	if m, ok := source.(map[string]interface{}); ok {
+		return m[name], nil
+	}
+	rv := reflect.Indirect(reflect.ValueOf(source))
+	if rv.Kind() == reflect.Struct {
+		if index, ok := jsonField(rv.Type(), name); ok {
+			field, err := rv.FieldByIndexErr(index)
+			if err != nil {
+				return nil, nil
+			}
+			return field.Interface(), nil
+		}
+	}
+	return nil, fmt.Errorf("no resolver for field %q on %T", name, source)
+}
+
+type jsonFieldKey struct {
+	t    reflect.Type
+	name string
+}
+
+var jsonFields sync.Map // jsonFieldKey -> []int (nil = não existe)
+
+func jsonField(t reflect.Type, name string) ([]int, bool) {
+	key := jsonFieldKey{t, name}
+	if index, ok := jsonFields.Load(key); ok {
+		return index.([]int), index.([]int) != nil
+	}
+	var found []int
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() || sf.Anonymous {
+			continue
+		}
+		tag, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if tag == "" {
+			tag = sf.Name
+		}
+		if tag == name {
+			found = sf.Index
+			break
+		}
+	}
+	jsonFields.Store(key, found)
+	return found, found != nil
+}
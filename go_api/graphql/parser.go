@@ -0,0 +1,626 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// --- Parser ---
+// Documento da consulta -> AST. Só a parte executável da linguagem
+// (operações e fragmentos); definições de tipo no documento são recusadas.
+
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string // query, mutation ou subscription
+	name       string
+	variables  []*variableDef
+	directives []*directive
+	selections []selection
+	pos        int
+}
+
+type variableDef struct {
+	name       string
+	typ        *typeRef
+	def        interface{}
+	hasDefault bool
+	pos        int
+}
+
+// Tipo escrito na consulta ($id: ID!, $ids: [ID!])
+type typeRef struct {
+	name    string
+	elem    *typeRef // lista
+	nonNull bool
+}
+
+func (t *typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+type selection interface{}
+
+type field struct {
+	alias      string
+	name       string
+	arguments  []*argument
+	directives []*directive
+	selections []selection
+	pos        int
+}
+
+func (f *field) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type argument struct {
+	name  string
+	value interface{}
+}
+
+type directive struct {
+	name      string
+	arguments []*argument
+	pos       int
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	pos        int
+}
+
+type inlineFragment struct {
+	typeCondition string
+	directives    []*directive
+	selections    []selection
+	pos           int
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	directives    []*directive
+	selections    []selection
+	pos           int
+}
+
+// Valores da consulta: int64, float64, string, bool, nil (null),
+// []interface{}, map[string]interface{} e os dois abaixo
+type variable string
+type enumValue string
+
+// --- Léxico ---
+
+const (
+	tokEOF = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind  int
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "<EOF>"
+	case tokString:
+		return strconv.Quote(t.value)
+	}
+	return fmt.Sprintf("%q", t.value)
+}
+
+// Erro de sintaxe, com a posição no documento
+type SyntaxError struct {
+	Message string
+	Pos     int
+}
+
+func (e *SyntaxError) Error() string { return "Syntax Error: " + e.Message }
+
+type parser struct {
+	src string
+	pos int   // próximo byte a ler
+	tok token // token atual
+}
+
+func parse(src string) (doc *document, err error) {
+	p := &parser{src: src}
+	// Os erros de sintaxe interrompem com panic para não checar a cada passo
+	defer func() {
+		if r := recover(); r != nil {
+			se, ok := r.(*SyntaxError)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, se
+		}
+	}()
+	p.advance()
+	doc = &document{fragments: map[string]*fragment{}}
+	if p.tok.kind == tokEOF {
+		p.fail(p.tok.pos, "document has no operations")
+	}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.peek(tokPunct, "{"):
+			doc.operations = append(doc.operations, &operation{kind: "query", pos: p.tok.pos, selections: p.selectionSet()})
+		case p.peek(tokName, "query"), p.peek(tokName, "mutation"), p.peek(tokName, "subscription"):
+			doc.operations = append(doc.operations, p.operation())
+		case p.peek(tokName, "fragment"):
+			f := p.fragment()
+			if _, dup := doc.fragments[f.name]; dup {
+				p.fail(f.pos, "There can be only one fragment named %q.", f.name)
+			}
+			doc.fragments[f.name] = f
+		default:
+			p.unexpected()
+		}
+	}
+	return doc, nil
+}
+
+func (p *parser) fail(pos int, format string, args ...interface{}) {
+	panic(&SyntaxError{Message: fmt.Sprintf(format, args...), Pos: pos})
+}
+
+func (p *parser) unexpected() {
+	p.fail(p.tok.pos, "Unexpected %s", p.tok)
+}
+
+func (p *parser) peek(kind int, value string) bool {
+	return p.tok.kind == kind && p.tok.value == value
+}
+
+// Consome o token esperado
+func (p *parser) expect(kind int, value string) token {
+	t := p.tok
+	if t.kind != kind || (value != "" && t.value != value) {
+		if value == "" {
+			p.fail(t.pos, "Expected Name, found %s", t)
+		}
+		p.fail(t.pos, "Expected %q, found %s", value, t)
+	}
+	p.advance()
+	return t
+}
+
+func (p *parser) name() string {
+	return p.expect(tokName, "").value
+}
+
+func (p *parser) operation() *operation {
+	op := &operation{pos: p.tok.pos, kind: p.name()}
+	if p.tok.kind == tokName {
+		op.name = p.name()
+	}
+	if p.peek(tokPunct, "(") {
+		p.advance()
+		for !p.peek(tokPunct, ")") {
+			v := &variableDef{pos: p.tok.pos}
+			p.expect(tokPunct, "$")
+			v.name = p.name()
+			p.expect(tokPunct, ":")
+			v.typ = p.typeRef()
+			if p.peek(tokPunct, "=") {
+				p.advance()
+				v.def, v.hasDefault = p.value(true), true
+			}
+			p.directives() // diretivas em variáveis não mudam nada aqui
+			op.variables = append(op.variables, v)
+		}
+		p.advance()
+	}
+	op.directives = p.directives()
+	op.selections = p.selectionSet()
+	return op
+}
+
+func (p *parser) fragment() *fragment {
+	f := &fragment{pos: p.tok.pos}
+	p.advance()
+	if p.peek(tokName, "on") {
+		p.unexpected()
+	}
+	f.name = p.name()
+	p.expect(tokName, "on")
+	f.typeCondition = p.name()
+	f.directives = p.directives()
+	f.selections = p.selectionSet()
+	return f
+}
+
+func (p *parser) typeRef() *typeRef {
+	var t *typeRef
+	if p.peek(tokPunct, "[") {
+		p.advance()
+		t = &typeRef{elem: p.typeRef()}
+		p.expect(tokPunct, "]")
+	} else {
+		t = &typeRef{name: p.name()}
+	}
+	if p.peek(tokPunct, "!") {
+		p.advance()
+		t.nonNull = true
+	}
+	return t
+}
+
+func (p *parser) selectionSet() []selection {
+	p.expect(tokPunct, "{")
+	var sel []selection
+	for !p.peek(tokPunct, "}") {
+		sel = append(sel, p.selection())
+	}
+	p.advance()
+	return sel
+}
+
+func (p *parser) selection() selection {
+	pos := p.tok.pos
+	if p.peek(tokPunct, "...") {
+		p.advance()
+		if p.tok.kind == tokName && p.tok.value != "on" {
+			return &fragmentSpread{name: p.name(), directives: p.directives(), pos: pos}
+		}
+		frag := &inlineFragment{pos: pos}
+		if p.peek(tokName, "on") {
+			p.advance()
+			frag.typeCondition = p.name()
+		}
+		frag.directives = p.directives()
+		frag.selections = p.selectionSet()
+		return frag
+	}
+	f := &field{pos: pos, name: p.name()}
+	if p.peek(tokPunct, ":") {
+		p.advance()
+		f.alias, f.name = f.name, p.name()
+	}
+	f.arguments = p.arguments()
+	f.directives = p.directives()
+	if p.peek(tokPunct, "{") {
+		f.selections = p.selectionSet()
+	}
+	return f
+}
+
+func (p *parser) arguments() []*argument {
+	if !p.peek(tokPunct, "(") {
+		return nil
+	}
+	p.advance()
+	var args []*argument
+	for !p.peek(tokPunct, ")") {
+		arg := &argument{name: p.name()}
+		p.expect(tokPunct, ":")
+		arg.value = p.value(false)
+		args = append(args, arg)
+	}
+	p.advance()
+	return args
+}
+
+func (p *parser) directives() []*directive {
+	var dirs []*directive
+	for p.peek(tokPunct, "@") {
+		pos := p.tok.pos
+		p.advance()
+		dirs = append(dirs, &directive{pos: pos, name: p.name(), arguments: p.arguments()})
+	}
+	return dirs
+}
+
+// constant = sem variáveis (valor padrão de uma variável)
+func (p *parser) value(constant bool) interface{} {
+	t := p.tok
+	switch t.kind {
+	case tokPunct:
+		switch t.value {
+		case "$":
+			if constant {
+				p.unexpected()
+			}
+			p.advance()
+			return variable(p.name())
+		case "[":
+			p.advance()
+			list := []interface{}{}
+			for !p.peek(tokPunct, "]") {
+				list = append(list, p.value(constant))
+			}
+			p.advance()
+			return list
+		case "{":
+			p.advance()
+			obj := map[string]interface{}{}
+			for !p.peek(tokPunct, "}") {
+				name := p.name()
+				p.expect(tokPunct, ":")
+				obj[name] = p.value(constant)
+			}
+			p.advance()
+			return obj
+		}
+	case tokInt:
+		p.advance()
+		n, err := strconv.ParseInt(t.value, 10, 64)
+		if err != nil {
+			p.fail(t.pos, "Int %s is out of range", t.value)
+		}
+		return n
+	case tokFloat:
+		p.advance()
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			p.fail(t.pos, "Float %s is out of range", t.value)
+		}
+		return f
+	case tokString:
+		p.advance()
+		return t.value
+	case tokName:
+		p.advance()
+		switch t.value {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return enumValue(t.value)
+	}
+	p.unexpected()
+	return nil
+}
+
+// Lê o próximo token para p.tok
+func (p *parser) advance() {
+	src := p.src
+	// Espaços, vírgulas, BOM e comentários (# até o fim da linha) não contam
+	for p.pos < len(src) {
+		c := src[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(src) && src[p.pos] != '\n' && src[p.pos] != '\r' {
+				p.pos++
+			}
+		case strings.HasPrefix(src[p.pos:], "\uFEFF"):
+			p.pos += len("\uFEFF")
+		default:
+			goto scan
+		}
+	}
+	p.tok = token{kind: tokEOF, pos: p.pos}
+	return
+
+scan:
+	start := p.pos
+	c := src[start]
+	switch {
+	case strings.HasPrefix(src[start:], "..."):
+		p.pos += 3
+		p.tok = token{kind: tokPunct, value: "...", pos: start}
+	case strings.IndexByte("!$&()[]{}:=@|", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokPunct, value: string(c), pos: start}
+	case c == '_' || isLetter(c):
+		for p.pos < len(src) && (src[p.pos] == '_' || isLetter(src[p.pos]) || isDigit(src[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: tokName, value: src[start:p.pos], pos: start}
+	case c == '-' || isDigit(c):
+		p.number()
+	case strings.HasPrefix(src[start:], `"""`):
+		p.blockString()
+	case c == '"':
+		p.string()
+	default:
+		r, _ := utf8.DecodeRuneInString(src[start:])
+		p.fail(start, "Unexpected character %q", r)
+	}
+}
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+func (p *parser) number() {
+	src, start := p.src, p.pos
+	digits := func() int {
+		n := 0
+		for p.pos < len(src) && isDigit(src[p.pos]) {
+			p.pos++
+			n++
+		}
+		return n
+	}
+	if src[p.pos] == '-' {
+		p.pos++
+	}
+	if p.pos < len(src) && src[p.pos] == '0' {
+		p.pos++
+		if p.pos < len(src) && isDigit(src[p.pos]) {
+			p.fail(p.pos, "Invalid number, unexpected digit after 0")
+		}
+	} else if digits() == 0 {
+		p.fail(p.pos, "Invalid number, expected digit")
+	}
+	kind := tokInt
+	if p.pos < len(src) && src[p.pos] == '.' {
+		p.pos++
+		kind = tokFloat
+		if digits() == 0 {
+			p.fail(p.pos, "Invalid number, expected digit")
+		}
+	}
+	if p.pos < len(src) && (src[p.pos] == 'e' || src[p.pos] == 'E') {
+		p.pos++
+		kind = tokFloat
+		if p.pos < len(src) && (src[p.pos] == '+' || src[p.pos] == '-') {
+			p.pos++
+		}
+		if digits() == 0 {
+			p.fail(p.pos, "Invalid number, expected digit")
+		}
+	}
+	if p.pos < len(src) && (src[p.pos] == '_' || src[p.pos] == '.' || isLetter(src[p.pos])) {
+		p.fail(p.pos, "Invalid number, expected digit")
+	}
+	p.tok = token{kind: kind, value: src[start:p.pos], pos: start}
+}
+
+func (p *parser) string() {
+	src, start := p.src, p.pos
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.pos >= len(src) || src[p.pos] == '\n' || src[p.pos] == '\r' {
+			p.fail(start, "Unterminated string")
+		}
+		c := src[p.pos]
+		if c == '"' {
+			p.pos++
+			break
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			p.pos++
+			continue
+		}
+		if p.pos+1 >= len(src) {
+			p.fail(start, "Unterminated string")
+		}
+		esc := src[p.pos+1]
+		p.pos += 2
+		switch esc {
+		case '"', '\\', '/':
+			b.WriteByte(esc)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			r := p.unicodeEscape()
+			// Par substituto (😀)
+			if r >= 0xD800 && r <= 0xDBFF && strings.HasPrefix(src[p.pos:], `\u`) {
+				p.pos += 2
+				low := p.unicodeEscape()
+				r = (r-0xD800)<<10 + (low - 0xDC00) + 0x10000
+			}
+			b.WriteRune(r)
+		default:
+			p.fail(p.pos-2, "Invalid character escape sequence \\%c", esc)
+		}
+	}
+	p.tok = token{kind: tokString, value: b.String(), pos: start}
+}
+
+func (p *parser) unicodeEscape() rune {
+	if p.pos+4 > len(p.src) {
+		p.fail(p.pos, "Invalid Unicode escape sequence")
+	}
+	n, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+	if err != nil {
+		p.fail(p.pos, "Invalid Unicode escape sequence")
+	}
+	p.pos += 4
+	return rune(n)
+}
+
+// """texto""": sem escapes (só \"""), com a indentação comum removida
+func (p *parser) blockString() {
+	start := p.pos
+	p.pos += 3
+	var b strings.Builder
+	for {
+		if p.pos >= len(p.src) {
+			p.fail(start, "Unterminated string")
+		}
+		rest := p.src[p.pos:]
+		if strings.HasPrefix(rest, `"""`) {
+			p.pos += 3
+			break
+		}
+		if strings.HasPrefix(rest, `\"""`) {
+			b.WriteString(`"""`)
+			p.pos += 4
+			continue
+		}
+		b.WriteByte(p.src[p.pos])
+		p.pos++
+	}
+	p.tok = token{kind: tokString, value: dedent(b.String()), pos: start}
+}
+
+func dedent(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(raw, "\r\n", "\n"), "\r", "\n"), "\n")
+	common := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if indent := len(line) - len(trimmed); common < 0 || indent < common {
+			common = indent
+		}
+	}
+	if common > 0 {
+		for i := 1; i < len(lines); i++ {
+			lines[i] = lines[i][min(common, len(lines[i])):]
+		}
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[0], " \t") == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[len(lines)-1], " \t") == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Linha e coluna (a partir de 1) de um byte do documento
+func location(src string, pos int) Location {
+	line, col := 1, 1
+	for i, r := range src[:min(pos, len(src))] {
+		switch {
+		case r == '\n' && i > 0 && src[i-1] == '\r':
+		case r == '\n' || r == '\r':
+			line, col = line+1, 1
+		default:
+			col++
+		}
+	}
+	return Location{Line: line, Column: col}
+}
@@ -0,0 +1,464 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// --- GraphQL ---
+// Executor mínimo da especificação, sem SDK (como o filtro RSQL e os
+// clientes do push e do broker): consultas e mutações com variáveis,
+// aliases, fragmentos, @include/@skip e __typename. O schema é montado em
+// Go (sem gerador de código): cada campo declara o tipo, os argumentos e a
+// função que o resolve. Sem introspecção (__schema/__type) e sem
+// subscriptions; o SDL sai de Schema.SDL.
+
+type Type interface {
+	String() string
+}
+
+// ResolveFunc devolve o valor de um campo a partir do objeto pai (Source)
+type ResolveFunc func(p ResolveParams) (interface{}, error)
+
+type ResolveParams struct {
+	Context context.Context
+	Source  interface{}
+	// Argumentos já convertidos para os tipos declarados (ausente = chave
+	// faltando, a menos que o argumento tenha Default)
+	Args map[string]interface{}
+}
+
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*Field
+}
+
+type Field struct {
+	Name        string
+	Description string
+	Type        Type
+	Args        []*Argument
+	// nil = campo do struct com a mesma tag json (ou chave do map)
+	Resolve ResolveFunc
+}
+
+// Argumento de um campo ou campo de um input
+type Argument struct {
+	Name        string
+	Description string
+	Type        Type
+	Default     interface{} // nil = sem valor padrão
+}
+
+type InputObject struct {
+	Name        string
+	Description string
+	Fields      []*Argument
+}
+
+type List struct{ Of Type }
+
+type NonNull struct{ Of Type }
+
+func ListOf(t Type) *List       { return &List{Of: t} }
+func NonNullOf(t Type) *NonNull { return &NonNull{Of: t} }
+
+func (o *Object) String() string      { return o.Name }
+func (o *InputObject) String() string { return o.Name }
+func (l *List) String() string        { return "[" + l.Of.String() + "]" }
+func (n *NonNull) String() string     { return n.Of.String() + "!" }
+
+func (o *Object) field(name string) *Field {
+	for _, f := range o.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// --- Escalares ---
+
+type Scalar struct {
+	Name        string
+	Description string
+	// Valor do resolver -> valor do JSON da resposta
+	Serialize func(v interface{}) (interface{}, error)
+	// Valor da consulta (literal) ou das variáveis (JSON) -> valor da entrada.
+	// Literais inteiros chegam como int64; os números do JSON, como float64.
+	Parse func(v interface{}) (interface{}, error)
+}
+
+func (s *Scalar) String() string { return s.Name }
+
+var (
+	Int = &Scalar{
+		Name: "Int",
+		Serialize: func(v interface{}) (interface{}, error) {
+			rv := reflect.ValueOf(v)
+			switch {
+			case rv.CanInt():
+				return rv.Int(), nil
+			case rv.CanUint():
+				return rv.Uint(), nil
+			}
+			return nil, fmt.Errorf("Int cannot represent %v", v)
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			switch n := v.(type) {
+			case int64:
+				if n >= math.MinInt32 && n <= math.MaxInt32 {
+					return int(n), nil
+				}
+			case float64:
+				if n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32 {
+					return int(n), nil
+				}
+			case int:
+				return n, nil
+			}
+			return nil, fmt.Errorf("Int cannot represent %s", describe(v))
+		},
+	}
+	Float = &Scalar{
+		Name: "Float",
+		Serialize: func(v interface{}) (interface{}, error) {
+			rv := reflect.ValueOf(v)
+			switch {
+			case rv.CanFloat():
+				return rv.Float(), nil
+			case rv.CanInt():
+				return float64(rv.Int()), nil
+			}
+			return nil, fmt.Errorf("Float cannot represent %v", v)
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			switch n := v.(type) {
+			case float64:
+				return n, nil
+			case int64:
+				return float64(n), nil
+			case int:
+				return float64(n), nil
+			}
+			return nil, fmt.Errorf("Float cannot represent %s", describe(v))
+		},
+	}
+	String = &Scalar{
+		Name: "String",
+		Serialize: func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			if s, ok := v.(fmt.Stringer); ok {
+				return s.String(), nil
+			}
+			return nil, fmt.Errorf("String cannot represent %v", v)
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("String cannot represent %s", describe(v))
+		},
+	}
+	Boolean = &Scalar{
+		Name: "Boolean",
+		Serialize: func(v interface{}) (interface{}, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent %v", v)
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent %s", describe(v))
+		},
+	}
+	// Sai sempre como string; entra como string ou inteiro
+	ID = &Scalar{
+		Name: "ID",
+		Serialize: func(v interface{}) (interface{}, error) {
+			rv := reflect.ValueOf(v)
+			switch {
+			case rv.Kind() == reflect.String:
+				return rv.String(), nil
+			case rv.CanInt(), rv.CanUint():
+				return fmt.Sprint(v), nil
+			}
+			return nil, fmt.Errorf("ID cannot represent %v", v)
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			switch n := v.(type) {
+			case string:
+				return n, nil
+			case int64:
+				return fmt.Sprint(n), nil
+			case float64:
+				if n == math.Trunc(n) {
+					return fmt.Sprint(int64(n)), nil
+				}
+			}
+			return nil, fmt.Errorf("ID cannot represent %s", describe(v))
+		},
+	}
+)
+
+var builtinScalars = []*Scalar{Int, Float, String, Boolean, ID}
+
+// Como o valor aparece nas mensagens de erro
+func describe(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case enumValue:
+		return string(v)
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	}
+	raw, _ := json.Marshal(v)
+	return string(raw)
+}
+
+// --- Schema ---
+
+type Schema struct {
+	Query    *Object
+	Mutation *Object // nil = sem mutações
+	// Mensagem e extensões de um erro devolvido por um resolver; nil =
+	// err.Error() sem extensões
+	FormatError func(ctx context.Context, err error) (string, map[string]interface{})
+	// Limites de cada consulta (0 = sem limite): níveis de campos aninhados
+	// e campos selecionados, contando os dos fragmentos
+	MaxDepth  int
+	MaxFields int
+
+	types map[string]Type // tipos com nome, para as variáveis e o SDL
+}
+
+func NewSchema(query, mutation *Object) (*Schema, error) {
+	s := &Schema{Query: query, Mutation: mutation, types: map[string]Type{}}
+	for _, scalar := range builtinScalars {
+		s.types[scalar.Name] = scalar
+	}
+	roots := []Type{query}
+	if mutation != nil {
+		roots = append(roots, mutation)
+	}
+	for _, t := range roots {
+		if err := s.collect(t); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *Schema) collect(t Type) error {
+	t = named(t)
+	name := t.String()
+	if seen, ok := s.types[name]; ok {
+		if seen != t {
+			return fmt.Errorf("graphql: two types named %s", name)
+		}
+		return nil
+	}
+	s.types[name] = t
+	switch t := t.(type) {
+	case *Object:
+		for _, f := range t.Fields {
+			if err := s.collect(f.Type); err != nil {
+				return err
+			}
+			for _, arg := range f.Args {
+				if err := s.collect(arg.Type); err != nil {
+					return err
+				}
+			}
+		}
+	case *InputObject:
+		for _, f := range t.Fields {
+			if err := s.collect(f.Type); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Tipo sem os List/NonNull em volta
+func named(t Type) Type {
+	for {
+		switch w := t.(type) {
+		case *List:
+			t = w.Of
+		case *NonNull:
+			t = w.Of
+		default:
+			return t
+		}
+	}
+}
+
+var errMissing = errors.New("missing")
+
+// Converte um valor de entrada para o tipo declarado
+func coerceInput(t Type, v interface{}) (interface{}, error) {
+	if nn, ok := t.(*NonNull); ok {
+		if v == nil {
+			return nil, fmt.Errorf("expected a non-null %s", nn.Of)
+		}
+		return coerceInput(nn.Of, v)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	switch t := t.(type) {
+	case *List:
+		items, ok := v.([]interface{})
+		if !ok {
+			// Um valor sozinho vale como lista de um item
+			items = []interface{}{v}
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			c, err := coerceInput(t.Of, item)
+			if err != nil {
+				return nil, fmt.Errorf("at index %d: %w", i, err)
+			}
+			out[i] = c
+		}
+		return out, nil
+	case *Scalar:
+		return t.Parse(v)
+	case *InputObject:
+		fields, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s must be an object, got %s", t.Name, describe(v))
+		}
+		out := make(map[string]interface{}, len(t.Fields))
+		for _, f := range t.Fields {
+			c, err := coerceArgument(f, fields)
+			if errors.Is(err, errMissing) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.Name, err)
+			}
+			out[f.Name] = c
+		}
+		for name := range fields {
+			if !slices.ContainsFunc(t.Fields, func(f *Argument) bool { return f.Name == name }) {
+				return nil, fmt.Errorf("field %s is not defined by %s", name, t.Name)
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%s is not an input type", t)
+}
+
+// Valor de um argumento (ou campo de input) em values; errMissing = ausente
+// e sem padrão
+func coerceArgument(arg *Argument, values map[string]interface{}) (interface{}, error) {
+	v, ok := values[arg.Name]
+	if !ok {
+		if arg.Default != nil {
+			return arg.Default, nil
+		}
+		if _, required := arg.Type.(*NonNull); required {
+			return nil, fmt.Errorf("expected a non-null %s", arg.Type.(*NonNull).Of)
+		}
+		return nil, errMissing
+	}
+	return coerceInput(arg.Type, v)
+}
+
+// --- SDL ---
+
+// Schema no formato da linguagem de definição (GET /graphql/schema)
+func (s *Schema) SDL() string {
+	var names []string
+	for name, t := range s.types {
+		if scalar, ok := t.(*Scalar); ok && slices.Contains(builtinScalars, scalar) {
+			continue
+		}
+		if t == s.Query || (s.Mutation != nil && t == s.Mutation) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ordered := []Type{s.Query}
+	if s.Mutation != nil {
+		ordered = append(ordered, s.Mutation)
+	}
+	for _, name := range names {
+		ordered = append(ordered, s.types[name])
+	}
+
+	var b strings.Builder
+	for i, t := range ordered {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		switch t := t.(type) {
+		case *Object:
+			writeDescription(&b, "", t.Description)
+			fmt.Fprintf(&b, "type %s {\n", t.Name)
+			for _, f := range t.Fields {
+				writeDescription(&b, "  ", f.Description)
+				fmt.Fprintf(&b, "  %s%s: %s\n", f.Name, sdlArgs(f.Args), f.Type)
+			}
+			b.WriteString("}\n")
+		case *InputObject:
+			writeDescription(&b, "", t.Description)
+			fmt.Fprintf(&b, "input %s {\n", t.Name)
+			for _, f := range t.Fields {
+				writeDescription(&b, "  ", f.Description)
+				fmt.Fprintf(&b, "  %s\n", sdlArg(f))
+			}
+			b.WriteString("}\n")
+		case *Scalar:
+			writeDescription(&b, "", t.Description)
+			fmt.Fprintf(&b, "scalar %s\n", t.Name)
+		}
+	}
+	return b.String()
+}
+
+func writeDescription(b *strings.Builder, indent, text string) {
+	if text != "" {
+		fmt.Fprintf(b, "%s%q\n", indent, text)
+	}
+}
+
+func sdlArgs(args []*Argument) string {
+	if len(args) == 0 {
+		return ""
+	}
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = sdlArg(arg)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+func sdlArg(arg *Argument) string {
+	s := arg.Name + ": " + arg.Type.String()
+	if arg.Default != nil {
+		raw, _ := json.Marshal(arg.Default)
+		s += " = " + string(raw)
+	}
+	return s
+}
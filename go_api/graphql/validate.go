@@ -0,0 +1,204 @@
+package graphql
+
+import (
+	"fmt"
+	"slices"
+)
+
+// --- Validação ---
+// Antes de executar: campos e argumentos existem, os obrigatórios vieram,
+// as variáveis estão declaradas, os fragmentos existem e não se repetem em
+// ciclo. Também aplica os limites de profundidade e de campos, que evitam
+// uma consulta aninhada que faria milhares de buscas no banco.
+
+type validator struct {
+	schema *Schema
+	src    string
+	doc    *document
+	vars   map[string]*variableDef
+	errs   []*Error
+	// Fragmentos sendo percorridos (ciclos)
+	spreading []string
+	fields    int
+	tooDeep   bool
+	tooWide   bool
+}
+
+func (s *Schema) validate(src string, doc *document, op *operation) []*Error {
+	v := &validator{schema: s, src: src, doc: doc, vars: map[string]*variableDef{}}
+	for _, def := range op.variables {
+		if _, dup := v.vars[def.name]; dup {
+			v.fail(def.pos, "There can be only one variable named \"$%s\".", def.name)
+		}
+		v.vars[def.name] = def
+		if !v.inputType(def.typ) {
+			v.fail(def.pos, "Variable \"$%s\" cannot be non-input type %q.", def.name, def.typ)
+		}
+	}
+	root := s.Query
+	if op.kind == "mutation" {
+		root = s.Mutation
+	}
+	v.directives(op.directives)
+	v.selections(root, op.selections, 1)
+	return v.errs
+}
+
+func (v *validator) fail(pos int, format string, args ...interface{}) {
+	v.errs = append(v.errs, &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{location(v.src, pos)}})
+}
+
+func (v *validator) inputType(ref *typeRef) bool {
+	if ref.elem != nil {
+		return v.inputType(ref.elem)
+	}
+	switch v.schema.types[ref.name].(type) {
+	case *Scalar, *InputObject:
+		return true
+	}
+	return false
+}
+
+func (v *validator) selections(t *Object, sels []selection, depth int) {
+	if max := v.schema.MaxDepth; max > 0 && depth > max {
+		if !v.tooDeep {
+			v.tooDeep = true
+			v.errs = append(v.errs, &Error{Message: fmt.Sprintf("Query is nested deeper than %d levels.", max)})
+		}
+		return
+	}
+	// Mesma chave na resposta tem que ser o mesmo campo
+	keys := map[string]string{}
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *field:
+			if max := v.schema.MaxFields; max > 0 {
+				if v.fields++; v.fields > max {
+					if !v.tooWide {
+						v.tooWide = true
+						v.errs = append(v.errs, &Error{Message: fmt.Sprintf("Query selects more than %d fields.", max)})
+					}
+					return
+				}
+			}
+			if name, ok := keys[sel.key()]; ok && name != sel.name {
+				v.fail(sel.pos, "Fields %q conflict because %s and %s are different fields.", sel.key(), name, sel.name)
+			}
+			keys[sel.key()] = sel.name
+			v.directives(sel.directives)
+			v.field(t, sel, depth)
+		case *fragmentSpread:
+			v.directives(sel.directives)
+			frag, ok := v.doc.fragments[sel.name]
+			if !ok {
+				v.fail(sel.pos, "Unknown fragment %q.", sel.name)
+				continue
+			}
+			if slices.Contains(v.spreading, sel.name) {
+				v.fail(sel.pos, "Cannot spread fragment %q within itself.", sel.name)
+				continue
+			}
+			if !v.condition(t, frag.typeCondition, frag.pos) {
+				continue
+			}
+			v.spreading = append(v.spreading, sel.name)
+			v.directives(frag.directives)
+			v.selections(t, frag.selections, depth)
+			v.spreading = v.spreading[:len(v.spreading)-1]
+		case *inlineFragment:
+			v.directives(sel.directives)
+			if sel.typeCondition == "" || v.condition(t, sel.typeCondition, sel.pos) {
+				v.selections(t, sel.selections, depth)
+			}
+		}
+	}
+}
+
+// Sem interfaces nem uniões, um fragmento só se aplica ao próprio tipo
+func (v *validator) condition(t *Object, name string, pos int) bool {
+	if _, ok := v.schema.types[name].(*Object); !ok {
+		v.fail(pos, "Unknown type %q.", name)
+		return false
+	}
+	if name != t.Name {
+		v.fail(pos, "Fragment cannot be spread here as objects of type %q can never be of type %q.", t.Name, name)
+		return false
+	}
+	return true
+}
+
+func (v *validator) field(t *Object, f *field, depth int) {
+	if f.name == "__typename" {
+		if len(f.arguments) > 0 || f.selections != nil {
+			v.fail(f.pos, "Field \"__typename\" takes no arguments or subfields.")
+		}
+		return
+	}
+	def := t.field(f.name)
+	if def == nil {
+		v.fail(f.pos, "Cannot query field %q on type %q.", f.name, t.Name)
+		return
+	}
+	v.arguments(fmt.Sprintf("field \"%s.%s\"", t.Name, f.name), def.Args, f.arguments, f.pos)
+
+	switch nt := named(def.Type).(type) {
+	case *Object:
+		if f.selections == nil {
+			v.fail(f.pos, "Field %q of type %q must have a selection of subfields.", f.name, def.Type)
+			return
+		}
+		v.selections(nt, f.selections, depth+1)
+	default:
+		if f.selections != nil {
+			v.fail(f.pos, "Field %q must not have a selection since type %q has no subfields.", f.name, def.Type)
+		}
+	}
+}
+
+func (v *validator) arguments(owner string, defs []*Argument, args []*argument, pos int) {
+	given := map[string]bool{}
+	for _, arg := range args {
+		if given[arg.name] {
+			v.fail(pos, "There can be only one argument named %q.", arg.name)
+		}
+		given[arg.name] = true
+		if !slices.ContainsFunc(defs, func(d *Argument) bool { return d.Name == arg.name }) {
+			v.fail(pos, "Unknown argument %q on %s.", arg.name, owner)
+		}
+		v.variablesIn(arg.value, pos)
+	}
+	for _, def := range defs {
+		if _, required := def.Type.(*NonNull); required && def.Default == nil && !given[def.Name] {
+			v.fail(pos, "Argument %q of type %q is required on %s, but it was not provided.", def.Name, def.Type, owner)
+		}
+	}
+}
+
+func (v *validator) variablesIn(value interface{}, pos int) {
+	switch value := value.(type) {
+	case variable:
+		if _, ok := v.vars[string(value)]; !ok {
+			v.fail(pos, "Variable \"$%s\" is not defined.", value)
+		}
+	case []interface{}:
+		for _, item := range value {
+			v.variablesIn(item, pos)
+		}
+	case map[string]interface{}:
+		for _, item := range value {
+			v.variablesIn(item, pos)
+		}
+	}
+}
+
+var ifArgument = []*Argument{{Name: "if", Type: NonNullOf(Boolean)}}
+
+func (v *validator) directives(dirs []*directive) {
+	for _, d := range dirs {
+		if d.name != "skip" && d.name != "include" {
+			v.fail(d.pos, "Unknown directive \"@%s\".", d.name)
+			continue
+		}
+		v.arguments("directive \"@"+d.name+"\"", ifArgument, d.arguments, d.pos)
+	}
+}
@@ -0,0 +1,81 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+
+	"go_api/handlers"
+	"go_api/repository"
+	"go_api/service"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Erros do service -> status gRPC (a mesma tradução do handlers.toAPIError)
+func toStatus(ctx context.Context, err error) error {
+	var conflict *service.ConflictError
+	var locked *service.AccountLockedError
+	var readings *service.ReadingsError
+	switch {
+	case errors.As(err, &conflict):
+		return withField(codes.AlreadyExists, conflict.Error(), conflict.Field, "is already in use")
+	case errors.As(err, &locked):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.As(err, &readings):
+		return status.Error(codes.InvalidArgument, readings.Error())
+	case errors.Is(err, repository.ErrStaleRevision):
+		return status.Error(codes.Aborted, "user was modified by another request")
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrDeviceNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidCredentials):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, service.ErrEmailNotVerified):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, service.ErrUserConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "call cancelled")
+	}
+	slog.ErrorContext(ctx, "erro na chamada gRPC", "error", err)
+	return status.Error(codes.Internal, "internal error")
+}
+
+func withField(code codes.Code, msg, field, description string) error {
+	st, err := status.New(code, msg).WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: field, Description: description}},
+	})
+	if err != nil {
+		return status.Error(code, msg)
+	}
+	return st.Err()
+}
+
+// Valida com as mesmas regras dos bindings do Gin; os campos inválidos vão
+// nos detalhes do status (errdetails.BadRequest)
+func validate(input interface{}) error {
+	fields := handlers.FieldErrors(input)
+	if fields == nil {
+		return nil
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	details := &errdetails.BadRequest{}
+	for _, name := range names {
+		details.FieldViolations = append(details.FieldViolations,
+			&errdetails.BadRequest_FieldViolation{Field: name, Description: fields[name]})
+	}
+	st, err := status.New(codes.InvalidArgument, "validation failed").WithDetails(details)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "validation failed")
+	}
+	return st.Err()
+}
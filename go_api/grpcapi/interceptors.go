@@ -0,0 +1,127 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go_api/handlers"
+	"go_api/models"
+	"go_api/repository"
+	"go_api/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Métodos que não exigem token (equivalentes ao POST /login e POST /users)
+var publicMethods = map[string]bool{
+	"/ubiquitous.v1.AuthService/Login":      true,
+	"/ubiquitous.v1.UserService/CreateUser": true,
+	"/grpc.health.v1.Health/Check":          true,
+	"/grpc.health.v1.Health/Watch":          true,
+}
+
+type callerKey struct{}
+
+// Quem fez a chamada, lido do access token
+type caller struct {
+	ID   uint
+	Role string
+}
+
+func callerFrom(ctx context.Context) caller {
+	c, _ := ctx.Value(callerKey{}).(caller)
+	return c
+}
+
+// Mesmas regras do RBAC da API REST
+func (c caller) isAdmin() bool {
+	return c.Role == models.RoleAdmin
+}
+
+func (c caller) canAccessUser(id uint) bool {
+	return c.isAdmin() || c.ID == id
+}
+
+// "authorization: Bearer <access_token>" ou "x-api-key: <chave>" na metadata
+func authenticate(h *handlers.Handler) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		if publicMethods[info.FullMethod] {
+			return next(ctx, req)
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		if keys := md.Get("x-api-key"); len(keys) > 0 {
+			key, user, err := h.APIKeys.Authenticate(ctx, keys[0])
+			if errors.Is(err, service.ErrInvalidAPIKey) {
+				return nil, status.Error(codes.Unauthenticated, "invalid, expired or revoked api key")
+			}
+			if err != nil {
+				return nil, toStatus(ctx, err)
+			}
+			ctx = repository.WithTenant(ctx, user.TenantID)
+			ctx = repository.WithActor(ctx, repository.Actor{UserID: user.ID, APIKeyID: key.ID})
+			return next(context.WithValue(ctx, callerKey{}, caller{ID: user.ID, Role: user.Role}), req)
+		}
+		var raw string
+		if values := md.Get("authorization"); len(values) > 0 {
+			raw, _ = strings.CutPrefix(values[0], "Bearer ")
+		}
+		if raw == "" {
+			return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+		}
+		token, err := h.ParseAccessToken(raw)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+		}
+		// Sem X-Tenant no gRPC: cada um só enxerga o próprio tenant
+		ctx = repository.WithTenant(ctx, token.TenantID)
+		ctx = repository.WithActor(ctx, repository.Actor{UserID: token.UserID, ImpersonatorID: token.ImpersonatorID})
+		return next(context.WithValue(ctx, callerKey{}, caller{ID: token.UserID, Role: token.Role}), req)
+	}
+}
+
+// IP do cliente (o nginx manda o X-Real-IP na metadata) para o login e a
+// auditoria, com as mesmas regras de TRUSTED_PROXIES da API REST
+func recordClientIP(h *handlers.Handler) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		var remote string
+		if p, ok := peer.FromContext(ctx); ok {
+			remote = p.Addr.String()
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		ip := h.ClientIP(remote, func(name string) string {
+			return strings.Join(md.Get(name), ",")
+		})
+		return next(repository.WithClientIP(ctx, ip), req)
+	}
+}
+
+func logCalls(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := next(ctx, req)
+	code := status.Code(err)
+	level := slog.LevelInfo
+	if code == codes.Internal || code == codes.Unknown {
+		level = slog.LevelError
+	}
+	slog.Log(ctx, level, "chamada gRPC", "method", info.FullMethod, "code", code.String(),
+		"duration_ms", float64(time.Since(start).Microseconds())/1000, "client_ip", repository.ClientIPFromContext(ctx))
+	return resp, err
+}
+
+// Panic vira codes.Internal, como o Recovery do Gin faz com o 500
+func recoverPanics(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ctx, "panic na chamada gRPC", "method", info.FullMethod, "panic", r)
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return next(ctx, req)
+}
@@ -0,0 +1,64 @@
+package grpcapi
+
+import (
+	"log"
+	"net"
+
+	"go_api/grpcapi/pb"
+	"go_api/handlers"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// --- API gRPC ---
+// As mesmas operações de usuários e dispositivos da API REST (proto em
+// proto/api.proto), numa porta separada (GRPC_ADDR). Os servidores abaixo só
+// traduzem protobuf <-> service, como os handlers do Gin fazem com JSON, e
+// reaproveitam o Handler para tokens, configuração e services.
+type server struct {
+	h *handlers.Handler
+}
+
+type authServer struct {
+	pb.UnimplementedAuthServiceServer
+	server
+}
+
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	server
+}
+
+type deviceServer struct {
+	pb.UnimplementedDeviceServiceServer
+	server
+}
+
+// Servidor com os três serviços, o health check padrão do gRPC e os
+// interceptors de recuperação de panic, log e autenticação
+func New(h *handlers.Handler) *grpc.Server {
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(recoverPanics, recordClientIP(h), logCalls, authenticate(h)))
+	base := server{h: h}
+	pb.RegisterAuthServiceServer(srv, &authServer{server: base})
+	pb.RegisterUserServiceServer(srv, &userServer{server: base})
+	pb.RegisterDeviceServiceServer(srv, &deviceServer{server: base})
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	return srv
+}
+
+// Escuta em addr em segundo plano; o GracefulStop fica com quem chamou
+func Serve(srv *grpc.Server, addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		log.Printf("gRPC escutando em %s", addr)
+		if err := srv.Serve(lis); err != nil {
+			log.Printf("Erro no servidor gRPC: %v", err)
+		}
+	}()
+	return nil
+}
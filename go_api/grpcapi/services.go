@@ -0,0 +1,251 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+
+	"go_api/grpcapi/pb"
+	"go_api/handlers"
+	"go_api/models"
+	"go_api/repository"
+	"go_api/service"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// --- Conversões ---
+
+func toUser(u models.User) *pb.User {
+	return &pb.User{
+		Id:            uint64(u.ID),
+		Name:          u.Name,
+		Email:         u.Email,
+		User:          u.User,
+		Role:          u.Role,
+		Region:        u.Region,
+		Revision:      uint64(u.Revision),
+		EmailVerified: u.EmailVerified,
+	}
+}
+
+func toDevice(d models.Device) *pb.Device {
+	device := &pb.Device{Id: uint64(d.ID), UserId: uint64(d.UserID), Name: d.Name, Type: d.Type}
+	if d.LastSeen != nil {
+		device.LastSeen = timestamppb.New(*d.LastSeen)
+	}
+	return device
+}
+
+var (
+	errForbiddenUser   = status.Error(codes.PermissionDenied, "you can only access your own user")
+	errForbiddenDevice = status.Error(codes.PermissionDenied, "you can only access your own devices")
+	errAdminOnly       = status.Error(codes.PermissionDenied, "admin role required")
+)
+
+// --- AuthService ---
+
+func (s *authServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.TokenPair, error) {
+	input := handlers.LoginInput{User: req.GetUser(), Password: req.GetPassword()}
+	if err := validate(input); err != nil {
+		return nil, err
+	}
+	ip := repository.ClientIPFromContext(ctx)
+	user, err := s.h.Users.Authenticate(ctx, input.User, input.Password, ip)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	client := service.SessionClient{IP: ip}
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("user-agent")) > 0 {
+		client.UserAgent = md.Get("user-agent")[0]
+	}
+	tokens, err := s.h.IssueTokenPair(ctx, user, client)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &pb.TokenPair{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    tokens.TokenType,
+		ExpiresIn:    tokens.ExpiresIn,
+	}, nil
+}
+
+// --- UserService ---
+
+// Cadastro público, sempre na região desta instância
+func (s *userServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
+	input := models.CreateUserInput{
+		Name:     req.GetName(),
+		Email:    req.GetEmail(),
+		User:     req.GetUser(),
+		Password: req.GetPassword(),
+		Region:   s.h.Config.Region,
+	}
+	if err := validate(input); err != nil {
+		return nil, err
+	}
+	user, err := s.h.Users.Register(ctx, input)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toUser(user), nil
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	if !callerFrom(ctx).canAccessUser(uint(req.GetId())) {
+		return nil, errForbiddenUser
+	}
+	user, err := s.h.Users.Get(ctx, uint(req.GetId()))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toUser(user), nil
+}
+
+func (s *userServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	if !callerFrom(ctx).isAdmin() {
+		return nil, errAdminOnly
+	}
+	page, size := int(req.GetPage()), int(req.GetPageSize())
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = 20
+	}
+	if size > 100 {
+		size = 100
+	}
+	users, total, err := s.h.Users.List(ctx, repository.UserQuery{
+		Order:  []string{"id"},
+		Limit:  size,
+		Offset: (page - 1) * size,
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	resp := &pb.ListUsersResponse{Total: total}
+	for _, u := range users {
+		resp.Users = append(resp.Users, toUser(u))
+	}
+	return resp, nil
+}
+
+func (s *userServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User, error) {
+	if !callerFrom(ctx).canAccessUser(uint(req.GetId())) {
+		return nil, errForbiddenUser
+	}
+	input := models.UpdateUserInput{Name: req.GetName(), Email: req.GetEmail(), User: req.GetUser()}
+	if err := validate(input); err != nil {
+		return nil, err
+	}
+	user, err := s.h.Users.Update(ctx, uint(req.GetId()), input, 0)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toUser(user), nil
+}
+
+func (s *userServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	if !callerFrom(ctx).isAdmin() {
+		return nil, errAdminOnly
+	}
+	if err := s.h.Users.Delete(ctx, uint(req.GetId())); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &pb.DeleteUserResponse{}, nil
+}
+
+// --- DeviceService ---
+
+// Mesmo papel do middleware DeviceAccess: busca e confere o dono
+func (s *deviceServer) device(ctx context.Context, id uint64) (models.Device, error) {
+	device, err := s.h.Devices.Get(ctx, uint(id))
+	if err != nil {
+		return device, toStatus(ctx, err)
+	}
+	if !callerFrom(ctx).canAccessUser(device.UserID) {
+		return device, errForbiddenDevice
+	}
+	return device, nil
+}
+
+func (s *deviceServer) CreateDevice(ctx context.Context, req *pb.CreateDeviceRequest) (*pb.CreateDeviceResponse, error) {
+	if !callerFrom(ctx).canAccessUser(uint(req.GetUserId())) {
+		return nil, errForbiddenUser
+	}
+	user, err := s.h.Users.Get(ctx, uint(req.GetUserId()))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	input := models.DeviceInput{Name: req.GetName(), Type: req.GetType()}
+	if err := validate(input); err != nil {
+		return nil, err
+	}
+	device, token, err := s.h.Devices.Create(ctx, user.ID, input)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &pb.CreateDeviceResponse{Device: toDevice(device), Token: token}, nil
+}
+
+func (s *deviceServer) ListDevices(ctx context.Context, req *pb.ListDevicesRequest) (*pb.ListDevicesResponse, error) {
+	if !callerFrom(ctx).canAccessUser(uint(req.GetUserId())) {
+		return nil, errForbiddenUser
+	}
+	devices, err := s.h.Devices.ListByUser(ctx, uint(req.GetUserId()))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	resp := &pb.ListDevicesResponse{}
+	for _, d := range devices {
+		resp.Devices = append(resp.Devices, toDevice(d))
+	}
+	return resp, nil
+}
+
+func (s *deviceServer) GetDevice(ctx context.Context, req *pb.GetDeviceRequest) (*pb.Device, error) {
+	device, err := s.device(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return toDevice(device), nil
+}
+
+func (s *deviceServer) DeleteDevice(ctx context.Context, req *pb.DeleteDeviceRequest) (*pb.DeleteDeviceResponse, error) {
+	device, err := s.device(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.h.Devices.Delete(ctx, device); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &pb.DeleteDeviceResponse{}, nil
+}
+
+// Mesmas regras do POST /devices/:id/readings (limite por chamada, métrica
+// obrigatória, payload JSON)
+func (s *deviceServer) CreateReadings(ctx context.Context, req *pb.CreateReadingsRequest) (*pb.CreateReadingsResponse, error) {
+	device, err := s.device(ctx, req.GetDeviceId())
+	if err != nil {
+		return nil, err
+	}
+	inputs := make([]models.ReadingInput, len(req.GetReadings()))
+	for i, r := range req.GetReadings() {
+		inputs[i] = models.ReadingInput{Metric: r.GetMetric(), Value: r.GetValue()}
+		if r.GetTimestamp() != nil {
+			inputs[i].Timestamp = r.GetTimestamp().AsTime()
+		}
+		if len(r.GetPayload()) > 0 {
+			inputs[i].Payload = json.RawMessage(r.GetPayload())
+		}
+	}
+	created, err := s.h.Telemetry.IngestInputs(ctx, device, inputs)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &pb.CreateReadingsResponse{Created: int32(created)}, nil
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Reconhecimento de Atividade (Contexto do Usuário) ---
+// O rótulo de cada janela pode vir pronto do cliente ou ser inferido a
+// partir das leituras do acelerômetro.
+// Atividades aceitas (mesmos nomes usados pelas APIs de Android/iOS)
+var validActivities = map[string]bool{
+	"still":      true,
+	"sitting":    true,
+	"standing":   true,
+	"walking":    true,
+	"running":    true,
+	"cycling":    true,
+	"in_vehicle": true,
+	"unknown":    true,
+}
+
+// Campos que podem ser usados em ?filter= (RSQL)
+var activityFilterFields = map[string]filterField{
+	"activity":    {"activity", kindString},
+	"source":      {"source", kindString},
+	"confidence":  {"confidence", kindFloat},
+	"started_at":  {"started_at", kindTime},
+	"ended_at":    {"ended_at", kindTime},
+	"duration_ms": {"duration_ms", kindInt},
+}
+
+// Uma leitura de sensor (eixos x, y, z)
+type SensorSample struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// Formato recebido do cliente: ou manda o rótulo (activity), ou manda a
+// janela bruta de acelerômetro/giroscópio para o servidor classificar.
+type ActivityInput struct {
+	Activity      string         `json:"activity"`
+	Confidence    float64        `json:"confidence"`
+	StartedAt     time.Time      `json:"started_at" binding:"required"`
+	EndedAt       time.Time      `json:"ended_at" binding:"required"`
+	Accelerometer []SensorSample `json:"accelerometer"`
+	Gyroscope     []SensorSample `json:"gyroscope"`
+}
+
+// Resumo diário: tempo total gasto em cada atividade
+type ActivitySummary struct {
+	Activity   string `json:"activity"`
+	Windows    int64  `json:"windows"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Calcula média e desvio padrão da magnitude do vetor aceleração (m/s²)
+func accelStats(samples []SensorSample) (mean, std float64) {
+	if len(samples) == 0 {
+		return 0, 0
+	}
+	for _, s := range samples {
+		mean += math.Sqrt(s.X*s.X + s.Y*s.Y + s.Z*s.Z)
+	}
+	mean /= float64(len(samples))
+	for _, s := range samples {
+		d := math.Sqrt(s.X*s.X+s.Y*s.Y+s.Z*s.Z) - mean
+		std += d * d
+	}
+	std = math.Sqrt(std / float64(len(samples)))
+	return mean, std
+}
+
+// Classificador simples por limiar: a variação da aceleração cresce com a
+// intensidade do movimento. Suficiente para o protótipo; um modelo treinado
+// pode substituir esta função sem mudar as rotas.
+func classifyActivity(std float64) (string, float64) {
+	switch {
+	case std < 0.3:
+		return "still", 0.6
+	case std < 2.5:
+		return "walking", 0.5
+	default:
+		return "running", 0.5
+	}
+}
+
+// --- Handlers ---
+
+func (h *Handler) CreateActivities(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	var user models.User
+	if err := h.db(c).First(&user, id).Error; err != nil {
+		abortError(c, newAPIError(http.StatusNotFound, "User not found"))
+		return
+	}
+
+	// Aceita várias janelas de uma vez (o celular envia em lote)
+	var inputs []ActivityInput
+	if err := c.ShouldBindJSON(&inputs); err != nil {
+		bindError(c, err)
+		return
+	}
+	if len(inputs) == 0 {
+		abortError(c, newAPIError(http.StatusBadRequest, "At least one activity window is required"))
+		return
+	}
+
+	samples := make([]models.ActivitySample, 0, len(inputs))
+	for i, in := range inputs {
+		if !in.EndedAt.After(in.StartedAt) {
+			abortError(c, newAPIError(http.StatusBadRequest, "ended_at must be after started_at").WithDetails(gin.H{"index": i}))
+			return
+		}
+
+		s := models.ActivitySample{
+			UserID:      user.ID,
+			Activity:    in.Activity,
+			Source:      "client",
+			Confidence:  in.Confidence,
+			StartedAt:   in.StartedAt.UTC(),
+			EndedAt:     in.EndedAt.UTC(),
+			DurationMs:  in.EndedAt.Sub(in.StartedAt).Milliseconds(),
+			SampleCount: len(in.Accelerometer),
+		}
+		s.MeanAccel, s.StdAccel = accelStats(in.Accelerometer)
+
+		if s.Activity == "" {
+			if len(in.Accelerometer) == 0 {
+				abortError(c, newAPIError(http.StatusBadRequest, "activity or accelerometer samples are required").WithDetails(gin.H{"index": i}))
+				return
+			}
+			s.Activity, s.Confidence = classifyActivity(s.StdAccel)
+			s.Source = "sensor"
+		} else if !validActivities[s.Activity] {
+			abortError(c, newAPIErrorf(http.StatusBadRequest, "Invalid activity: %s", s.Activity).WithDetails(gin.H{"index": i}))
+			return
+		}
+		samples = append(samples, s)
+	}
+
+	if err := h.db(c).CreateInBatches(&samples, 100).Error; err != nil {
+		abortError(c, newAPIError(http.StatusInternalServerError, "Could not store activities"))
+		return
+	}
+	c.JSON(http.StatusCreated, samples)
+}
+
+// Intervalo [início, fim) do dia pedido em ?date=AAAA-MM-DD (padrão: hoje, UTC)
+func parseDay(c *gin.Context) (time.Time, time.Time, bool) {
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	if d := c.Query("date"); d != "" {
+		parsed, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			abortError(c, newAPIError(http.StatusBadRequest, "date must be in YYYY-MM-DD format"))
+			return time.Time{}, time.Time{}, false
+		}
+		day = parsed
+	}
+	return day, day.Add(24 * time.Hour), true
+}
+
+func (h *Handler) GetActivities(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	from, to, ok := parseDay(c)
+	if !ok {
+		return
+	}
+	query := h.db(c).Where("user_id = ? AND started_at >= ? AND started_at < ?", id, from, to)
+	if f := c.Query("filter"); f != "" {
+		cond, args, err := parseFilter(f, activityFilterFields)
+		if err != nil {
+			abortError(c, newAPIError(http.StatusBadRequest, err.Error()))
+			return
+		}
+		query = query.Where(cond, args...)
+	}
+
+	var samples []models.ActivitySample
+	if err := query.Order("started_at").Find(&samples).Error; err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, samples)
+}
+
+func (h *Handler) GetActivitySummary(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	from, to, ok := parseDay(c)
+	if !ok {
+		return
+	}
+	// Agregação feita no banco, para não trazer todas as janelas para a API
+	var summary []ActivitySummary
+	err := h.db(c).Model(&models.ActivitySample{}).
+		Select("activity, COUNT(*) AS windows, SUM(duration_ms) AS duration_ms").
+		Where("user_id = ? AND started_at >= ? AND started_at < ?", id, from, to).
+		Group("activity").
+		Order("duration_ms DESC").
+		Scan(&summary).Error
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":       from.Format("2006-01-02"),
+		"activities": summary,
+	})
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"go_api/models"
+	"go_api/repository"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Chaves de API ---
+// Sensores e jobs em lote se autenticam com "X-API-Key: <chave>" em vez do
+// fluxo usuário/senha + JWT. A requisição vale como se fosse do dono da
+// chave, com o papel dele. As regras ficam no service.APIKeyService.
+const apiKeyHeader = "X-API-Key"
+
+// Resposta da criação: única vez em que a chave aparece em texto puro
+type APIKeyWithSecret struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+type apiKeyAuth struct {
+	key  models.APIKey
+	user models.User
+	err  error
+}
+
+// Confere o X-API-Key uma vez por requisição: o rate limit (que roda
+// antes) e o AuthRequired usam o mesmo resultado
+func (h *Handler) apiKeyFromRequest(c *gin.Context) (apiKeyAuth, bool) {
+	raw := c.GetHeader(apiKeyHeader)
+	if raw == "" {
+		return apiKeyAuth{}, false
+	}
+	if cached, ok := c.Get("apiKeyAuth"); ok {
+		return cached.(apiKeyAuth), true
+	}
+	var auth apiKeyAuth
+	auth.key, auth.user, auth.err = h.APIKeys.Authenticate(c.Request.Context(), raw)
+	c.Set("apiKeyAuth", auth)
+	return auth, true
+}
+
+// A requisição veio com chave de API (e não com JWT)?
+func viaAPIKey(c *gin.Context) bool {
+	_, ok := c.Get("apiKeyID")
+	return ok
+}
+
+// --- Handlers ---
+
+// POST /users/:id/api-keys
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	// Uma chave vazada não pode gerar outras
+	if viaAPIKey(c) {
+		abortError(c, newAPIError(http.StatusForbidden, "API keys cannot create other API keys"))
+		return
+	}
+	if impersonating(c) {
+		abortError(c, newAPIError(http.StatusForbidden, "Impersonation tokens cannot create API keys"))
+		return
+	}
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	user, err := h.Users.Get(c.Request.Context(), id)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	var input models.APIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	key, plain, err := h.APIKeys.Create(c.Request.Context(), user.ID, input)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, APIKeyWithSecret{APIKey: key, Key: plain})
+}
+
+// GET /users/:id/api-keys
+func (h *Handler) GetAPIKeys(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	keys, err := h.APIKeys.ListByUser(c.Request.Context(), id)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// DELETE /users/:id/api-keys/:key
+// A chave revogada continua na listagem (com revoked_at), para auditoria
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	keyID, ok := idParam(c, "key")
+	if !ok {
+		return
+	}
+	key, err := h.APIKeys.Revoke(c.Request.Context(), id, keyID)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, key)
+}
+
+// Usado pelo AuthRequired quando a requisição traz X-API-Key
+func (h *Handler) authenticateAPIKey(c *gin.Context, auth apiKeyAuth) bool {
+	switch {
+	case errors.Is(auth.err, service.ErrInvalidAPIKey):
+		abortError(c, newAPIError(http.StatusUnauthorized, "Invalid, expired or revoked API key"))
+		return false
+	case auth.err != nil:
+		abortError(c, auth.err)
+		return false
+	}
+	if !enterTenant(c, auth.user.TenantID, auth.user.Role) {
+		return false
+	}
+	c.Set("userID", auth.user.ID)
+	c.Set("role", auth.user.Role)
+	c.Set("apiKeyID", auth.key.ID)
+	setActor(c, repository.Actor{UserID: auth.user.ID, APIKeyID: auth.key.ID})
+	return true
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_api/models"
+	"go_api/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Auditoria ---
+// GET /audit?entity=&entity_id=&actor_id=&impersonator_id=&action=&from=&to=&page=&per_page=
+// (admin). Mais recentes primeiro; o total vai no X-Total-Count, como no
+// GET /users. from/to em RFC3339.
+var (
+	auditEntities = map[string]bool{"user": true, "device": true, "api_key": true}
+	auditActions  = map[string]bool{
+		models.AuditCreate: true, models.AuditUpdate: true, models.AuditDelete: true, models.AuditRestore: true,
+		models.AuditImpersonate: true,
+	}
+)
+
+func queryID(c *gin.Context, name string) (uint, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseUint(v, 10, 64)
+	if err != nil || id == 0 {
+		abortError(c, newAPIErrorf(http.StatusBadRequest, "%s must be a positive integer", name))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func queryTime(c *gin.Context, name string) (time.Time, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return time.Time{}, true
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		abortError(c, newAPIErrorf(http.StatusBadRequest, "%s must be an RFC3339 timestamp", name))
+		return t, false
+	}
+	return t, true
+}
+
+func (h *Handler) GetAudit(c *gin.Context) {
+	q := repository.AuditQuery{Entity: c.Query("entity"), Action: c.Query("action")}
+	if q.Entity != "" && !auditEntities[q.Entity] {
+		abortError(c, newAPIError(http.StatusBadRequest, "entity must be user, device or api_key"))
+		return
+	}
+	if q.Action != "" && !auditActions[q.Action] {
+		abortError(c, newAPIError(http.StatusBadRequest, "action must be create, update, delete, restore or impersonate"))
+		return
+	}
+	var ok bool
+	if q.EntityID, ok = queryID(c, "entity_id"); !ok {
+		return
+	}
+	if q.ActorID, ok = queryID(c, "actor_id"); !ok {
+		return
+	}
+	if q.ImpersonatorID, ok = queryID(c, "impersonator_id"); !ok {
+		return
+	}
+	if q.From, ok = queryTime(c, "from"); !ok {
+		return
+	}
+	if q.To, ok = queryTime(c, "to"); !ok {
+		return
+	}
+	page, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	q.Limit, q.Offset = page.PerPage, page.Offset()
+
+	entries, total, err := h.Audit.List(c.Request.Context(), q)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	setPaginationHeaders(c, page, total)
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_api/models"
+	"go_api/repository"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// --- Autenticação JWT ---
+// O login devolve dois tokens assinados (HS256):
+//   - access: curto, enviado em "Authorization: Bearer <token>" nas rotas;
+//   - refresh: longo, usado apenas em POST /refresh para gerar um novo par.
+//     Leva a sessão (sid) e um jti que troca a cada refresh: o token antigo
+//     para de valer (ver service/session.go).
+//
+// Segredo e validade vêm da configuração (JWT_SECRET, JWT_ACCESS_TTL,
+// JWT_REFRESH_TTL).
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+)
+
+type TokenClaims struct {
+	Type string `json:"typ"`
+	Role string `json:"role,omitempty"`
+	// Tenant do usuário; tokens antigos, sem ele, são do tenant padrão
+	Tenant uint `json:"tid,omitempty"`
+	// Sessão aberta no login (GET /me/sessions), no access e no refresh
+	Session uint `json:"sid,omitempty"`
+	// Token de personificação: o admin que age como o usuário (RFC 8693)
+	Actor *TokenActor `json:"act,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type TokenActor struct {
+	Subject string `json:"sub"`
+}
+
+type LoginInput struct {
+	User     string `json:"user" binding:"required"` // username ou e-mail
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // segundos até o access expirar
+}
+
+func (h *Handler) signToken(user models.User, session models.Session, jti, tokenType string, ttl time.Duration) (string, error) {
+	return h.signClaims(newClaims(user, session, jti, tokenType, ttl))
+}
+
+func newClaims(user models.User, session models.Session, jti, tokenType string, ttl time.Duration) TokenClaims {
+	now := time.Now()
+	return TokenClaims{
+		Type:    tokenType,
+		Role:    user.Role,
+		Tenant:  user.TenantID,
+		Session: session.ID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+}
+
+func (h *Handler) signClaims(claims TokenClaims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(h.Config.JWTSecret))
+}
+
+// O papel (role) vai no token para as checagens de permissão não
+// consultarem o banco; uma mudança de papel vale a partir do próximo token.
+// Pelo mesmo motivo, revogar a sessão só barra o refresh: o access token já
+// emitido vale até expirar.
+func (h *Handler) IssueTokenPair(ctx context.Context, user models.User, client service.SessionClient) (TokenPair, error) {
+	session, jti, err := h.Users.StartSession(ctx, user.ID, client)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return h.signTokenPair(user, session, jti)
+}
+
+func (h *Handler) signTokenPair(user models.User, session models.Session, jti string) (TokenPair, error) {
+	access, err := h.signToken(user, session, "", tokenTypeAccess, h.Config.AccessTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	refresh, err := h.signToken(user, session, jti, tokenTypeRefresh, h.Config.RefreshTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(h.Config.AccessTTL.Seconds()),
+	}, nil
+}
+
+// Valida assinatura, expiração e tipo do token
+func (h *Handler) parseToken(raw string, expectedType string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(h.Config.JWTSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != expectedType {
+		return nil, errors.New("wrong token type")
+	}
+	return claims, nil
+}
+
+// Quem o access token identifica
+type AccessToken struct {
+	UserID    uint
+	Role      string
+	TenantID  uint
+	SessionID uint
+	// != 0: token de personificação emitido por esse admin
+	ImpersonatorID uint
+}
+
+// Valida um access token e devolve o usuário, o papel e o tenant (usado
+// também no gRPC)
+func (h *Handler) ParseAccessToken(raw string) (AccessToken, error) {
+	claims, err := h.parseToken(raw, tokenTypeAccess)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	tenant := claims.Tenant
+	if tenant == 0 {
+		tenant = models.DefaultTenantID
+	}
+	token := AccessToken{UserID: uint(userID), Role: claims.Role, TenantID: tenant, SessionID: claims.Session}
+	if claims.Actor != nil {
+		admin, err := strconv.ParseUint(claims.Actor.Subject, 10, 64)
+		if err != nil || admin == 0 {
+			return AccessToken{}, errors.New("invalid actor claim")
+		}
+		token.ImpersonatorID = uint(admin)
+	}
+	return token, nil
+}
+
+// Middleware: exige um access token válido (ou uma chave de API em
+// X-API-Key) e guarda o ID do usuário no contexto
+func (h *Handler) AuthRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if auth, ok := h.apiKeyFromRequest(c); ok {
+			if h.authenticateAPIKey(c, auth) {
+				c.Next()
+			}
+			return
+		}
+		raw, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || raw == "" {
+			abortError(c, newAPIError(http.StatusUnauthorized, "Missing bearer token"))
+			return
+		}
+		token, err := h.ParseAccessToken(raw)
+		if err != nil {
+			abortError(c, newAPIError(http.StatusUnauthorized, "Invalid or expired token"))
+			return
+		}
+		if !enterTenant(c, token.TenantID, token.Role) {
+			return
+		}
+		c.Set("userID", token.UserID)
+		c.Set("role", token.Role)
+		c.Set("sessionID", token.SessionID)
+		if token.ImpersonatorID != 0 {
+			c.Set("impersonatorID", token.ImpersonatorID)
+		}
+		setActor(c, repository.Actor{UserID: token.UserID, ImpersonatorID: token.ImpersonatorID})
+		c.Next()
+	}
+}
+
+// Quem está autenticado vai no contexto até a auditoria, no repository
+func setActor(c *gin.Context, actor repository.Actor) {
+	c.Request = c.Request.WithContext(repository.WithActor(c.Request.Context(), actor))
+}
+
+// --- Handlers ---
+
+func (h *Handler) Login(c *gin.Context) {
+	var input LoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	// Mesma resposta para usuário inexistente e senha errada
+	user, err := h.Users.Authenticate(c.Request.Context(), input.User, input.Password, c.ClientIP())
+	var locked *service.AccountLockedError
+	switch {
+	case errors.As(err, &locked):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(locked.Until).Seconds()))))
+		abortError(c, err)
+		return
+	case errors.Is(err, service.ErrInvalidCredentials):
+		abortError(c, newAPIError(http.StatusUnauthorized, "Invalid credentials"))
+		return
+	case err != nil:
+		abortError(c, err)
+		return
+	}
+
+	tokens, err := h.IssueTokenPair(c.Request.Context(), user, sessionClient(c))
+	if err != nil {
+		abortError(c, newAPIError(http.StatusInternalServerError, "Could not issue tokens"))
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+func sessionClient(c *gin.Context) service.SessionClient {
+	return service.SessionClient{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+}
+
+// Cada refresh troca o par inteiro: o refresh token usado aqui não serve
+// de novo, e reaproveitá-lo encerra a sessão
+func (h *Handler) Refresh(c *gin.Context) {
+	var input RefreshInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	claims, err := h.parseToken(input.RefreshToken, tokenTypeRefresh)
+	if err != nil {
+		abortError(c, newAPIError(http.StatusUnauthorized, "Invalid or expired refresh token"))
+		return
+	}
+
+	// O usuário pode ter sido removido depois que o token foi emitido
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil {
+		abortError(c, newAPIError(http.StatusUnauthorized, "Invalid or expired refresh token"))
+		return
+	}
+	user, err := h.Users.Get(c.Request.Context(), uint(userID))
+	if err != nil {
+		abortError(c, newAPIError(http.StatusUnauthorized, "Invalid or expired refresh token"))
+		return
+	}
+	session, jti, err := h.Users.RefreshSession(c.Request.Context(), user.ID, claims.Session, claims.ID, sessionClient(c))
+	if errors.Is(err, service.ErrInvalidSession) {
+		abortError(c, newAPIError(http.StatusUnauthorized, "Invalid or expired refresh token"))
+		return
+	}
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+
+	tokens, err := h.signTokenPair(user, session, jti)
+	if err != nil {
+		abortError(c, newAPIError(http.StatusInternalServerError, "Could not issue tokens"))
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Avatar ---
+// POST /users/:id/avatar (multipart com o campo "file"): JPEG, PNG ou GIF
+// de até AVATAR_MAX_BYTES. O service redimensiona e grava no armazenamento
+// configurado (AVATAR_STORAGE); a resposta é o usuário com o avatar_url novo.
+
+// Folga para os cabeçalhos do multipart além do arquivo em si
+const multipartOverhead = 64 << 10
+
+// POST /users/:id/avatar
+func (h *Handler) UploadAvatar(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	tooLarge := newAPIErrorf(http.StatusRequestEntityTooLarge, "Avatar exceeds %d bytes", h.Config.AvatarMaxBytes)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxBytes *http.MaxBytesError
+		if errors.As(err, &maxBytes) {
+			abortError(c, tooLarge)
+			return
+		}
+		abortError(c, newAPIError(http.StatusBadRequest, `Send the image as multipart/form-data in the "file" field`))
+		return
+	}
+	if header.Size > h.Config.AvatarMaxBytes {
+		abortError(c, tooLarge)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+
+	user, err := h.Avatars.Upload(c.Request.Context(), id, data)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// GET /avatars/:file: os avatares gravados no disco (AVATAR_STORAGE=local).
+// Cada envio gera um arquivo novo, então o navegador pode guardar para sempre.
+func (h *Handler) GetAvatar(c *gin.Context) {
+	path, ok := h.Avatars.File(c.Param("file"))
+	if ok {
+		_, err := os.Stat(path)
+		ok = err == nil
+	}
+	if !ok {
+		abortError(c, newAPIError(http.StatusNotFound, "Avatar not found"))
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.File(path)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Requisições em Lote (Multiplexação) ---
+// O cliente móvel em rede de alta latência manda várias operações em uma
+// única ida ao servidor. Cada sub-requisição passa pelo mesmo router (com
+// os mesmos middlewares), herdando os cabeçalhos de autenticação da
+// requisição externa (e o tenant, pelo X-Tenant ou pelo Host). As
+// sub-requisições rodam em ordem.
+const maxBatchRequests = 20
+
+type BatchRequest struct {
+	Method string          `json:"method" binding:"required"`
+	Path   string          `json:"path" binding:"required"`
+	Body   json.RawMessage `json:"body"`
+}
+
+type BatchResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// Cabeçalhos da requisição externa repassados para cada sub-requisição. Com
+// os do proxy e o mesmo RemoteAddr, o c.ClientIP() (rate limit, sessões,
+// auditoria) da sub-requisição é o mesmo da externa.
+var batchForwardHeaders = append([]string{"Authorization", "X-API-Key", "Accept-Language", "X-Request-ID", "X-Tenant"}, clientIPHeaders...)
+
+func Batch(r *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var requests []BatchRequest
+		if err := c.ShouldBindJSON(&requests); err != nil {
+			bindError(c, err)
+			return
+		}
+		if len(requests) == 0 || len(requests) > maxBatchRequests {
+			abortError(c, newAPIError(http.StatusBadRequest, "A batch must contain between 1 and 20 requests"))
+			return
+		}
+
+		responses := make([]BatchResponse, 0, len(requests))
+		for _, br := range requests {
+			// Evita lote dentro de lote (recursão)
+			if !strings.HasPrefix(br.Path, "/") || strings.HasPrefix(unversionedPath(br.Path), "/batch") {
+				responses = append(responses, batchError(c, http.StatusBadRequest, "Invalid path"))
+				continue
+			}
+
+			sub, err := http.NewRequestWithContext(c.Request.Context(), strings.ToUpper(br.Method), br.Path, bytes.NewReader(br.Body))
+			if err != nil {
+				responses = append(responses, batchError(c, http.StatusBadRequest, "Invalid request"))
+				continue
+			}
+			sub.RemoteAddr = c.Request.RemoteAddr
+			sub.Host = c.Request.Host // o subdomínio do TENANT_DOMAIN
+			if len(br.Body) > 0 {
+				sub.Header.Set("Content-Type", "application/json")
+			}
+			for _, h := range batchForwardHeaders {
+				if v := c.GetHeader(h); v != "" {
+					sub.Header.Set(h, v)
+				}
+			}
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, sub)
+
+			resp := BatchResponse{Status: rec.Code}
+			if body := bytes.TrimSpace(rec.Body.Bytes()); len(body) > 0 {
+				if json.Valid(body) {
+					resp.Body = body
+				} else {
+					resp.Body, _ = json.Marshal(string(body))
+				}
+			}
+			responses = append(responses, resp)
+		}
+		c.JSON(http.StatusOK, responses)
+	}
+}
+
+func batchError(c *gin.Context, status int, message string) BatchResponse {
+	body, _ := json.Marshal(gin.H{"error": newAPIError(status, message).localized(language(c))})
+	return BatchResponse{Status: status, Body: body}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"go_api/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Circuit Breaker por Rota ---
+// Cada rota (método + padrão, sem o prefixo da versão) tem o próprio
+// circuito, que conta as requisições que falharam por sobrecarga do banco
+// ou por prazo estourado (ver repository/breaker.go). Aberto, a rota
+// responde 503 com Retry-After sem chegar ao handler; as outras seguem
+// normais. O circuito global, no pool, responde do mesmo jeito.
+
+func (h *Handler) RouteBreaker() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.Breakers == nil || c.FullPath() == "" {
+			c.Next()
+			return
+		}
+		breaker := h.Breakers.Get(c.Request.Method + " " + unversionedPath(c.FullPath()))
+		done, err := breaker.Allow()
+		if err != nil {
+			abortError(c, err)
+			return
+		}
+		c.Next()
+		// O erro que a requisição registrou (prazo estourado, banco caído...)
+		var last error
+		if len(c.Errors) > 0 {
+			last = c.Errors.Last().Err
+		}
+		done(last)
+	}
+}
+
+// Retry-After do 503 de circuito aberto (em segundos, arredondado para cima)
+func setCircuitRetryAfter(c *gin.Context, err error) {
+	var open *repository.CircuitOpenError
+	if errors.As(err, &open) {
+		c.Header("Retry-After", strconv.Itoa(max(1, int(math.Ceil(open.RetryAfter.Seconds())))))
+	}
+}
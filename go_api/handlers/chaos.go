@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// --- Modo Caos (somente desenvolvimento) ---
+// Com CHAOS_MODE=true a API passa a aceitar a injeção de latência e de
+// erros, tanto nos handlers quanto nas chamadas ao banco. Serve para validar
+// timeouts e proteções sob falha sem precisar derrubar o Postgres de verdade.
+// Nunca ative em produção: as rotas /chaos não têm autenticação.
+type ChaosConfig struct {
+	Latency     time.Duration `json:"latency"`       // atraso fixo por requisição
+	ErrorRate   float64       `json:"error_rate"`    // 0..1: chance de responder 503
+	DBLatency   time.Duration `json:"db_latency"`    // atraso por query
+	DBErrorRate float64       `json:"db_error_rate"` // 0..1: chance da query falhar
+}
+
+var (
+	chaosMu     sync.RWMutex
+	chaosConfig ChaosConfig
+	errChaos    = errors.New("chaos: injected database failure")
+)
+
+func currentChaos() ChaosConfig {
+	chaosMu.RLock()
+	defer chaosMu.RUnlock()
+	return chaosConfig
+}
+
+// Middleware que aplica a latência e os erros configurados
+func chaosMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, "/chaos") {
+			c.Next()
+			return
+		}
+		chaos := currentChaos()
+		if chaos.Latency > 0 {
+			time.Sleep(chaos.Latency)
+		}
+		if chaos.ErrorRate > 0 && rand.Float64() < chaos.ErrorRate {
+			abortError(c, newAPIError(http.StatusServiceUnavailable, "Chaos: injected failure"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// Callbacks do GORM rodando antes de cada operação no banco
+func registerChaosCallbacks(db *gorm.DB) {
+	inject := func(tx *gorm.DB) {
+		chaos := currentChaos()
+		if chaos.DBLatency > 0 {
+			time.Sleep(chaos.DBLatency)
+		}
+		if chaos.DBErrorRate > 0 && rand.Float64() < chaos.DBErrorRate {
+			tx.AddError(errChaos)
+		}
+	}
+	db.Callback().Query().Before("gorm:query").Register("chaos:query", inject)
+	db.Callback().Create().Before("gorm:create").Register("chaos:create", inject)
+	db.Callback().Update().Before("gorm:update").Register("chaos:update", inject)
+	db.Callback().Delete().Before("gorm:delete").Register("chaos:delete", inject)
+	db.Callback().Raw().Before("gorm:raw").Register("chaos:raw", inject)
+}
+
+// Ativa o modo caos no banco e devolve o middleware (chamado pelo router,
+// só com CHAOS_MODE=true)
+func (h *Handler) Chaos() gin.HandlerFunc {
+	log.Printf("ATENÇÃO: modo caos ativado (CHAOS_MODE=true)")
+	registerChaosCallbacks(h.DB)
+	return chaosMiddleware()
+}
+
+// Formato aceito no PUT: durações como texto ("200ms", "1s")
+type chaosInput struct {
+	Latency     string  `json:"latency"`
+	ErrorRate   float64 `json:"error_rate" binding:"min=0,max=1"`
+	DBLatency   string  `json:"db_latency"`
+	DBErrorRate float64 `json:"db_error_rate" binding:"min=0,max=1"`
+}
+
+func chaosResponse(chaos ChaosConfig) gin.H {
+	return gin.H{
+		"latency":       chaos.Latency.String(),
+		"error_rate":    chaos.ErrorRate,
+		"db_latency":    chaos.DBLatency.String(),
+		"db_error_rate": chaos.DBErrorRate,
+	}
+}
+
+func GetChaos(c *gin.Context) {
+	c.JSON(http.StatusOK, chaosResponse(currentChaos()))
+}
+
+func UpdateChaos(c *gin.Context) {
+	var input chaosInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	chaos := ChaosConfig{ErrorRate: input.ErrorRate, DBErrorRate: input.DBErrorRate}
+	var err error
+	if input.Latency != "" {
+		if chaos.Latency, err = time.ParseDuration(input.Latency); err != nil {
+			abortError(c, newAPIError(http.StatusBadRequest, "latency must be a duration like 200ms"))
+			return
+		}
+	}
+	if input.DBLatency != "" {
+		if chaos.DBLatency, err = time.ParseDuration(input.DBLatency); err != nil {
+			abortError(c, newAPIError(http.StatusBadRequest, "db_latency must be a duration like 200ms"))
+			return
+		}
+	}
+
+	chaosMu.Lock()
+	chaosConfig = chaos
+	chaosMu.Unlock()
+	c.JSON(http.StatusOK, chaosResponse(chaos))
+}
+
+func ResetChaos(c *gin.Context) {
+	chaosMu.Lock()
+	chaosConfig = ChaosConfig{}
+	chaosMu.Unlock()
+	c.JSON(http.StatusOK, chaosResponse(ChaosConfig{}))
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"log/slog"
+	"net"
+	"net/netip"
+	"strings"
+
+	"go_api/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- IP Real do Cliente ---
+// Atrás do nginx toda conexão vem do IP do proxy; o do cliente chega no
+// X-Forwarded-For (ou no X-Real-IP). Os cabeçalhos só valem quando quem
+// conectou está em TRUSTED_PROXIES: de qualquer outro, seriam forjados para
+// fugir do rate limit ou sujar a auditoria. No X-Forwarded-For vale o
+// último endereço que não é de um proxy confiável (os anteriores, o
+// próprio cliente pode ter escrito).
+
+var clientIPHeaders = []string{"X-Forwarded-For", "X-Real-IP"}
+
+// Aplica TRUSTED_PROXIES ao engine: c.ClientIP() (rate limit, logs,
+// sessões, auditoria) passa a seguir a regra acima. Sem proxies, vale o
+// endereço da conexão (o padrão do gin seria confiar em qualquer um).
+func (h *Handler) TrustProxies(r *gin.Engine) {
+	r.ForwardedByClientIP = true
+	r.RemoteIPHeaders = clientIPHeaders
+	if err := r.SetTrustedProxies(h.Config.TrustedProxies); err != nil {
+		// O config.Load já recusa entradas inválidas
+		slog.Error("TRUSTED_PROXIES inválido", "error", err)
+	}
+}
+
+// Guarda o IP do cliente no contexto para a auditoria
+func (h *Handler) RecordClientIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(repository.WithClientIP(c.Request.Context(), c.ClientIP()))
+		c.Next()
+	}
+}
+
+// O mesmo cálculo do c.ClientIP() para o que não passa pelo gin (gRPC, com
+// os cabeçalhos na metadata). header devolve "" para o cabeçalho ausente.
+func (h *Handler) ClientIP(remoteAddr string, header func(name string) string) string {
+	remote := remoteAddr
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		remote = host
+	}
+	addr, err := netip.ParseAddr(remote)
+	if err != nil || !h.trustedProxy(addr) {
+		return remote
+	}
+	for _, name := range clientIPHeaders {
+		if ip, ok := h.forwardedIP(header(name)); ok {
+			return ip
+		}
+	}
+	return remote
+}
+
+// Percorre "cliente, proxy1, proxy2" da direita para a esquerda
+func (h *Handler) forwardedIP(value string) (string, bool) {
+	if value == "" {
+		return "", false
+	}
+	items := strings.Split(value, ",")
+	for i := len(items) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(items[i]))
+		if err != nil {
+			return "", false
+		}
+		if i == 0 || !h.trustedProxy(addr) {
+			return addr.String(), true
+		}
+	}
+	return "", false
+}
+
+func (h *Handler) trustedProxy(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, proxy := range h.Config.TrustedProxies {
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			single, err := netip.ParseAddr(proxy)
+			if err != nil {
+				continue
+			}
+			prefix = netip.PrefixFrom(single, single.BitLen())
+		}
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Hora do Servidor ---
+// Dispositivos com relógio impreciso (RTC que deriva) usam esta rota para
+// se ressincronizar. Se o cliente enviar ?client_time= (epoch em ms), a
+// resposta também traz a diferença medida entre os dois relógios.
+func GetServerTime(c *gin.Context) {
+	now := time.Now().UTC()
+	resp := gin.H{
+		"time":    now.Format(time.RFC3339Nano),
+		"unix_ms": now.UnixMilli(),
+	}
+
+	if v := c.Query("client_time"); v != "" {
+		clientMs, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			abortError(c, newAPIError(http.StatusBadRequest, "client_time must be a unix timestamp in milliseconds"))
+			return
+		}
+		// Positivo: relógio do cliente está adiantado
+		resp["skew_ms"] = clientMs - now.UnixMilli()
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, resp)
+}
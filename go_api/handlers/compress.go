@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Compressão das Respostas ---
+// Par do DecompressBody: as listagens grandes (usuários, leituras,
+// sincronização) saem comprimidas para os clientes móveis e IoT que mandam
+// Accept-Encoding: gzip ou deflate. Respostas menores que
+// COMPRESS_MIN_BYTES (padrão: 1 KB) saem como estão: o cabeçalho do gzip
+// e a CPU gasta não compensam.
+
+var gzipWriters = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+
+// Escolhe o encoding pelo Accept-Encoding (q=0 recusa); gzip tem preferência
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "gzip" && name != "deflate" && name != "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if name == "*" {
+			name = "gzip"
+		}
+		if q > bestQ || (q == bestQ && name == "gzip") {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// Guarda o começo do corpo até saber se passa do limite; daí em diante
+// escreve direto no encoder
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+	buf      []byte
+	enc      io.WriteCloser
+	plain    bool // decidido: sai sem compressão
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	switch {
+	case w.enc != nil:
+		return w.enc.Write(p)
+	case w.plain:
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Enquanto o começo está no buffer, a resposta já conta como escrita
+func (w *compressWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+// Streaming (/changes, exportação): o que já chegou sai comprimido
+func (w *compressWriter) Flush() {
+	if w.enc == nil && !w.plain {
+		if err := w.start(); err != nil {
+			return
+		}
+	}
+	if f, ok := w.enc.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) start() error {
+	header := w.Header()
+	status := w.Status()
+	// Já codificado pelo handler, ou uma resposta sem corpo
+	if header.Get("Content-Encoding") != "" || status == http.StatusNoContent || status == http.StatusNotModified {
+		w.plain = true
+		return w.flushBuffer(w.ResponseWriter)
+	}
+
+	header.Set("Content-Encoding", w.encoding)
+	header.Del("Content-Length")
+	if w.encoding == "gzip" {
+		gz := gzipWriters.Get().(*gzip.Writer)
+		gz.Reset(w.ResponseWriter)
+		w.enc = gz
+	} else {
+		fl, _ := flate.NewWriter(w.ResponseWriter, flate.DefaultCompression)
+		w.enc = fl
+	}
+	return w.flushBuffer(w.enc)
+}
+
+func (w *compressWriter) flushBuffer(dst io.Writer) error {
+	// Nada escrito (ex: erro a cargo do ErrorHandler): não envia cabeçalhos
+	if len(w.buf) == 0 {
+		return nil
+	}
+	buf := w.buf
+	w.buf = nil
+	_, err := dst.Write(buf)
+	return err
+}
+
+// Fim do handler: fecha o encoder ou manda o corpo pequeno sem compressão
+func (w *compressWriter) finish() {
+	if w.enc == nil {
+		w.plain = true
+		w.flushBuffer(w.ResponseWriter)
+		return
+	}
+	w.enc.Close()
+	if gz, ok := w.enc.(*gzip.Writer); ok {
+		gz.Reset(io.Discard)
+		gzipWriters.Put(gz)
+	}
+}
+
+func (h *Handler) CompressResponse() gin.HandlerFunc {
+	minSize := h.Config.CompressMinBytes
+
+	return func(c *gin.Context) {
+		// Proxies guardam uma versão por Accept-Encoding
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if minSize <= 0 || encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, minSize: minSize}
+		c.Writer = w
+		defer func() {
+			w.finish()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
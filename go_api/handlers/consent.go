@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// --- Registro de Consentimento ---
+// O histórico de concessões e as finalidades ficam em models/consent.go;
+// aqui ficam o bloqueio das rotas e o CRUD.
+var validPurposes = map[string]bool{
+	models.PurposeLocationTracking: true,
+	models.PurposeActivityTracking: true,
+	models.PurposeAnalytics:        true,
+	models.PurposeNotifications:    true,
+}
+
+type ConsentInput struct {
+	Purpose string `json:"purpose" binding:"required"`
+	Version string `json:"version" binding:"required"`
+}
+
+func (h *Handler) hasConsent(c *gin.Context, userID uint, purpose string) (bool, error) {
+	var count int64
+	err := h.db(c).Model(&models.Consent{}).
+		Where("user_id = ? AND purpose = ? AND revoked_at IS NULL", userID, purpose).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Middleware de bloqueio: a rota só processa dados do usuário (:id) se ele
+// tiver consentido com a finalidade. Sem registro = sem consentimento (opt-in).
+func (h *Handler) RequireConsent(purpose string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		h.checkConsent(c, id, purpose)
+	}
+}
+
+// O mesmo bloqueio nas rotas /devices/:id (depois do DeviceAccess): vale o
+// consentimento do dono do dispositivo, não o de quem chama
+func (h *Handler) RequireDeviceConsent(purpose string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.checkConsent(c, currentDevice(c).UserID, purpose)
+	}
+}
+
+// Segue a cadeia só com o consentimento ativo; na dúvida (erro do banco), bloqueia
+func (h *Handler) checkConsent(c *gin.Context, userID uint, purpose string) {
+	ok, err := h.hasConsent(c, userID, purpose)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	if !ok {
+		abortError(c, newAPIError(http.StatusForbidden, "Consent required").
+			WithCode("consent_required").
+			WithDetails(gin.H{"purpose": purpose}))
+		return
+	}
+	c.Next()
+}
+
+// --- Handlers ---
+
+// GET /users/:id/consents?history=true
+func (h *Handler) GetConsents(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	query := h.db(c).Where("user_id = ?", id)
+	if c.Query("history") != "true" {
+		query = query.Where("revoked_at IS NULL")
+	}
+	var consents []models.Consent
+	if err := query.Order("granted_at DESC").Find(&consents).Error; err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, consents)
+}
+
+// POST /users/:id/consents: só o titular concede. O admin (inclusive por
+// personificação) pode consultar e retirar, mas não consentir por ele.
+func (h *Handler) GrantConsent(c *gin.Context) {
+	if impersonating(c) {
+		abortError(c, newAPIError(http.StatusForbidden, "Impersonation tokens cannot grant consent"))
+		return
+	}
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	if id != currentUserID(c) {
+		abortError(c, newAPIError(http.StatusForbidden, "Only the user can grant their own consent"))
+		return
+	}
+	var user models.User
+	if err := h.db(c).First(&user, id).Error; err != nil {
+		abortError(c, newAPIError(http.StatusNotFound, "User not found"))
+		return
+	}
+	var input ConsentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	if !validPurposes[input.Purpose] {
+		abortError(c, newAPIErrorf(http.StatusBadRequest, "Invalid purpose: %s", input.Purpose))
+		return
+	}
+
+	// Conceder de novo (ex: nova versão do termo) encerra o registro anterior,
+	// na mesma transação: uma falha na gravação não deixa o usuário sem nenhum
+	now := time.Now().UTC()
+	consent := models.Consent{UserID: user.ID, Purpose: input.Purpose, Version: input.Version, GrantedAt: now}
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Consent{}).
+			Where("user_id = ? AND purpose = ? AND revoked_at IS NULL", user.ID, input.Purpose).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&consent).Error
+	})
+	if err != nil {
+		abortError(c, newAPIError(http.StatusInternalServerError, "Could not store consent"))
+		return
+	}
+	c.JSON(http.StatusCreated, consent)
+}
+
+// DELETE /users/:id/consents/:purpose
+func (h *Handler) WithdrawConsent(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	result := h.db(c).Model(&models.Consent{}).
+		Where("user_id = ? AND purpose = ? AND revoked_at IS NULL", id, c.Param("purpose")).
+		Update("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		abortError(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		abortError(c, newAPIError(http.StatusNotFound, "Consent not found"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, "Consent withdrawn")})
+}
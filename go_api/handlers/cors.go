@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- CORS ---
+// O painel web roda em outra origem; sem estes cabeçalhos o navegador barra
+// o preflight (OPTIONS) e nenhuma chamada chega à API. Só as origens de
+// CORS_ALLOWED_ORIGINS são liberadas; sem nenhuma, o middleware não faz nada.
+
+// Cabeçalhos das respostas que o JavaScript do painel precisa ler
+var corsExposedHeaders = strings.Join([]string{
+	"ETag", "Retry-After", "X-Request-ID", "X-Total-Count", "X-Page", "X-Per-Page",
+	"X-RateLimit-Limit", "X-RateLimit-Remaining", "Idempotent-Replayed",
+	"X-Served-Region", "X-Data-Region", "X-Region-Endpoint",
+}, ", ")
+
+func (h *Handler) CORS() gin.HandlerFunc {
+	cfg := h.Config
+	anyOrigin := slices.Contains(cfg.CORSAllowedOrigins, "*")
+	methods := strings.Join(cfg.CORSAllowedMethods, ", ")
+	headers := strings.Join(cfg.CORSAllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.CORSMaxAge.Seconds()))
+
+	allowed := func(origin string) bool {
+		return anyOrigin || slices.ContainsFunc(cfg.CORSAllowedOrigins, func(o string) bool {
+			return strings.EqualFold(strings.TrimSuffix(o, "/"), origin)
+		})
+	}
+
+	return func(c *gin.Context) {
+		if len(cfg.CORSAllowedOrigins) == 0 {
+			c.Next()
+			return
+		}
+		// A resposta muda conforme a origem: caches não podem misturar
+		c.Writer.Header().Add("Vary", "Origin")
+
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if origin == "" {
+			c.Next()
+			return
+		}
+		if !allowed(origin) {
+			if preflight {
+				abortError(c, newAPIError(http.StatusForbidden, "Origin not allowed"))
+				return
+			}
+			// Sem os cabeçalhos, o navegador não entrega a resposta ao script
+			c.Next()
+			return
+		}
+
+		if anyOrigin && !cfg.CORSAllowCredentials {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.CORSAllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+			c.Header("Access-Control-Allow-Methods", methods)
+			c.Header("Access-Control-Allow-Headers", headers)
+			c.Header("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Header("Access-Control-Expose-Headers", corsExposedHeaders)
+		c.Next()
+	}
+}
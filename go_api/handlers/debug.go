@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Diagnóstico em Execução ---
+// pprof e um resumo do runtime, para perfilar a API durante o teste de
+// carga sem recompilar. Ficam numa porta separada (DEBUG_ADDR, sem
+// autenticação: não publique essa porta) e/ou na porta principal só para
+// admin (DEBUG_ENDPOINTS=true).
+//
+//	go tool pprof http://localhost:6060/debug/pprof/profile?seconds=30
+//	go tool pprof http://localhost:6060/debug/pprof/heap
+
+// GET /debug/pprof/ e /debug/pprof/:profile (heap, goroutine, allocs,
+// threadcreate, profile, trace, cmdline, symbol)
+func DebugPprof(c *gin.Context) {
+	switch c.Param("profile") {
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		// O Index serve os perfis nomeados pelo caminho (/debug/pprof/heap)
+		pprof.Index(c.Writer, c.Request)
+	}
+}
+
+// GET /debug/vars: goroutines, memória, GC e pool de conexões do banco
+func (h *Handler) DebugVars(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	var lastPause time.Duration
+	var lastGC *time.Time
+	if mem.NumGC > 0 {
+		lastPause = time.Duration(mem.PauseNs[(mem.NumGC+255)%256])
+		t := time.Unix(0, int64(mem.LastGC))
+		lastGC = &t
+	}
+
+	resp := replicaInfo()
+	resp["go_version"] = runtime.Version()
+	resp["cpus"] = runtime.NumCPU()
+	resp["gomaxprocs"] = runtime.GOMAXPROCS(0)
+	resp["goroutines"] = runtime.NumGoroutine()
+	resp["memory"] = gin.H{
+		"heap_alloc_bytes":  mem.HeapAlloc,
+		"heap_inuse_bytes":  mem.HeapInuse,
+		"heap_objects":      mem.HeapObjects,
+		"stack_inuse_bytes": mem.StackInuse,
+		"sys_bytes":         mem.Sys,
+		"total_alloc_bytes": mem.TotalAlloc,
+	}
+	resp["gc"] = gin.H{
+		"count":          mem.NumGC,
+		"next_gc_bytes":  mem.NextGC,
+		"last_gc":        lastGC,
+		"last_pause_ms":  float64(lastPause) / float64(time.Millisecond),
+		"pause_total_ms": float64(mem.PauseTotalNs) / float64(time.Millisecond),
+		"cpu_fraction":   mem.GCCPUFraction,
+	}
+
+	if sqlDB, err := h.DB.DB(); err == nil {
+		stats := sqlDB.Stats()
+		resp["database"] = gin.H{
+			"max_open_connections": stats.MaxOpenConnections,
+			"open_connections":     stats.OpenConnections,
+			"in_use":               stats.InUse,
+			"idle":                 stats.Idle,
+			"wait_count":           stats.WaitCount,
+			"wait_duration_ms":     stats.WaitDuration.Milliseconds(),
+			"max_idle_closed":      stats.MaxIdleClosed,
+			"max_idle_time_closed": stats.MaxIdleTimeClosed,
+			"max_lifetime_closed":  stats.MaxLifetimeClosed,
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Corpo de Requisição Comprimido ---
+// Dispositivos que enviam horas de dados em lote podem comprimir o upload
+// (Content-Encoding: gzip ou deflate). O limite é aplicado ao conteúdo JÁ
+// descomprimido, senão um arquivo pequeno poderia virar gigabytes em memória
+// (zip bomb). O limite vem de MAX_DECOMPRESSED_BODY_BYTES (padrão: 10 MB).
+func (h *Handler) DecompressBody() gin.HandlerFunc {
+	limit := h.Config.MaxDecompressedBytes
+
+	return func(c *gin.Context) {
+		var reader io.ReadCloser
+		var err error
+
+		switch strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding"))) {
+		case "", "identity":
+			c.Next()
+			return
+		case "gzip", "x-gzip":
+			reader, err = gzip.NewReader(c.Request.Body)
+		case "deflate":
+			// No HTTP, "deflate" é o formato zlib (RFC 9110)
+			reader, err = zlib.NewReader(c.Request.Body)
+		default:
+			abortError(c, newAPIError(http.StatusUnsupportedMediaType, "Unsupported Content-Encoding"))
+			return
+		}
+		if err != nil {
+			abortError(c, newAPIError(http.StatusBadRequest, "Invalid compressed body"))
+			return
+		}
+		defer reader.Close()
+
+		// Daqui em diante o handler enxerga um corpo comum, sem compressão
+		c.Request.Body = http.MaxBytesReader(c.Writer, reader, limit)
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.ContentLength = -1
+		c.Next()
+	}
+}
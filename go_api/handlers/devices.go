@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go_api/models"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Dispositivos ---
+// As regras (e o token do dispositivo) ficam no service.DeviceService,
+// compartilhado com o gRPC.
+
+// Resposta da criação: única vez em que o token aparece em texto puro
+type DeviceWithToken struct {
+	models.Device
+	Token string `json:"token"`
+}
+
+// Sem isso valeria o MarshalJSON herdado do Device, que deixa o token de fora
+func (d DeviceWithToken) MarshalJSON() ([]byte, error) {
+	return models.ExtendJSON(d.Device, gin.H{"token": d.Token})
+}
+
+// Middleware das rotas /devices/:id: carrega o dispositivo e confere se
+// quem chama é o dono (ou admin). O dispositivo fica em c.Get("device").
+// :id malformado dá 400 (invalid_id) antes de consultar o banco.
+func (h *Handler) DeviceAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		device, err := h.Devices.Get(c.Request.Context(), id)
+		if err != nil {
+			abortError(c, err)
+			return
+		}
+		if !canAccessUser(c, device.UserID) {
+			abortError(c, newAPIError(http.StatusForbidden, "You can only access your own devices"))
+			return
+		}
+		c.Set("device", device)
+		c.Next()
+	}
+}
+
+func currentDevice(c *gin.Context) models.Device {
+	return c.MustGet("device").(models.Device)
+}
+
+// --- Handlers ---
+
+// POST /users/:id/devices
+func (h *Handler) CreateDevice(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	user, err := h.Users.Get(c.Request.Context(), id)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	var input models.DeviceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	device, token, err := h.Devices.Create(c.Request.Context(), user.ID, input)
+	if err != nil {
+		abortError(c, newAPIError(http.StatusInternalServerError, "Could not create device"))
+		return
+	}
+	respond(c, http.StatusCreated, DeviceWithToken{Device: device, Token: token})
+}
+
+// GET /users/:id/devices
+func (h *Handler) GetUserDevices(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	fields, columns, ok := parseFields(c, deviceSparseFields)
+	if !ok {
+		return
+	}
+	devices, err := h.Devices.ListByUser(c.Request.Context(), id, columns...)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	respondFields(c, http.StatusOK, devices, fields)
+}
+
+// GET /devices?status=online|offline&page=&per_page=&fields=&created_after=...
+// Admin lista os dispositivos do tenant; os demais, os próprios. online =
+// last_seen há menos de PRESENCE_TIMEOUT, em qualquer réplica.
+func (h *Handler) GetDevices(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && status != service.DeviceOnline && status != service.DeviceOffline {
+		abortError(c, newAPIError(http.StatusBadRequest, "status must be online or offline"))
+		return
+	}
+	page, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	fields, columns, ok := parseFields(c, deviceSparseFields)
+	if !ok {
+		return
+	}
+	times, ok := parseTimeFilter(c)
+	if !ok {
+		return
+	}
+	q := service.DeviceQuery{Status: status, Offset: page.Offset(), Limit: page.PerPage, Select: columns, Times: times}
+	if !isAdmin(c) {
+		q.UserID = currentUserID(c)
+	}
+	devices, total, err := h.Devices.List(c.Request.Context(), q)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	setPaginationHeaders(c, page, total)
+	respondFields(c, http.StatusOK, devices, fields)
+}
+
+// GET /devices/:id
+func (h *Handler) GetDevice(c *gin.Context) {
+	fields, _, ok := parseFields(c, deviceSparseFields)
+	if !ok {
+		return
+	}
+	respondFields(c, http.StatusOK, currentDevice(c), fields)
+}
+
+// PUT /devices/:id
+func (h *Handler) UpdateDevice(c *gin.Context) {
+	device := currentDevice(c)
+	var input models.DeviceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	if err := h.Devices.Update(c.Request.Context(), &device, input); err != nil {
+		abortError(c, err)
+		return
+	}
+	respond(c, http.StatusOK, device)
+}
+
+// DELETE /devices/:id
+func (h *Handler) DeleteDevice(c *gin.Context) {
+	if err := h.Devices.Delete(c.Request.Context(), currentDevice(c)); err != nil {
+		abortError(c, err)
+		return
+	}
+	respond(c, http.StatusOK, gin.H{"message": localize(c, "Device deleted")})
+}
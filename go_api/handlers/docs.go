@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go_api/docs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Documentação ---
+
+// GET /openapi.json: especificação crua (para geradores de cliente)
+func GetOpenAPISpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", docs.Spec)
+}
+
+// GET /docs: Swagger UI interativo
+func GetDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docs.UI))
+}
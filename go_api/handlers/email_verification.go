@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Verificação de E-mail ---
+
+// GET /verify-email?token=... (o link do e-mail de cadastro)
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		abortError(c, newAPIError(http.StatusBadRequest, "token is required"))
+		return
+	}
+	if _, err := h.Users.VerifyEmail(c.Request.Context(), token); err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, "Email verified")})
+}
+
+// POST /verify-email/resend: sempre 202, exista ou não o e-mail
+func (h *Handler) ResendVerification(c *gin.Context) {
+	var input models.EmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	if err := h.Users.ResendVerification(c.Request.Context(), input.Email); err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": localize(c, "If the email is registered and not verified, a new link has been sent")})
+}
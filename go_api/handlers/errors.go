@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go_api/logging"
+	"go_api/repository"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// --- Respostas de Erro Padronizadas ---
+// Todo erro sai no mesmo formato, para os clientes tratarem pelo "code" em
+// vez de comparar mensagens:
+//
+//	{"error": {"code": "not_found", "message": "User not found", "request_id": "..."}}
+//
+// Os handlers só registram o erro (abortError); quem escreve a resposta é o
+// ErrorHandler, que também traduz os erros do service e do GORM e passa a
+// mensagem para o idioma do Accept-Language.
+type APIError struct {
+	Status    int    `json:"-"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   gin.H  `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+
+	text localText // Message antes da tradução
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Código padrão de cada status; dá para trocar com WithCode
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            "bad_request",
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusRequestTimeout:        "request_timeout",
+	http.StatusConflict:              "conflict",
+	http.StatusRequestEntityTooLarge: "payload_too_large",
+	http.StatusLocked:                "locked",
+	http.StatusMisdirectedRequest:    "misdirected_request",
+	http.StatusTooManyRequests:       "rate_limited",
+	http.StatusInternalServerError:   "internal_error",
+	http.StatusServiceUnavailable:    "unavailable",
+	statusClientClosedRequest:        "client_closed_request",
+}
+
+// Não existe no net/http; é o código que o nginx usa para o mesmo caso
+const statusClientClosedRequest = 499
+
+func newAPIError(status int, message string) *APIError {
+	code, ok := statusCodes[status]
+	if !ok {
+		code = "error"
+	}
+	return &APIError{Status: status, Code: code, Message: message, text: tr(message)}
+}
+
+// Mensagem com argumentos; o formato é a chave da tradução
+func newAPIErrorf(status int, format string, args ...any) *APIError {
+	apiErr := newAPIError(status, fmt.Sprintf(format, args...))
+	apiErr.text = tr(format, args...)
+	return apiErr
+}
+
+func (e *APIError) WithCode(code string) *APIError {
+	e.Code = code
+	return e
+}
+
+func (e *APIError) WithDetails(details gin.H) *APIError {
+	e.Details = details
+	return e
+}
+
+// Interrompe a requisição com o erro; a resposta sai no ErrorHandler
+func abortError(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
+
+// Erros do service e do banco -> resposta HTTP (único lugar dessa tradução)
+func toAPIError(err error) *APIError {
+	var apiErr *APIError
+	var conflict *service.ConflictError
+	var dup *repository.DuplicateError
+	var locked *service.AccountLockedError
+	var stale *service.RevisionConflictError
+	var tooLarge *http.MaxBytesError
+	var circuitOpen *repository.CircuitOpenError
+	switch {
+	case errors.As(err, &apiErr):
+		copied := *apiErr
+		return &copied
+	case errors.As(err, &conflict):
+		return newAPIError(http.StatusConflict, "User or Email already exists").
+			WithDetails(gin.H{conflict.Field: tr("is already in use")})
+	case errors.As(err, &dup):
+		return newAPIError(http.StatusConflict, "Already exists").
+			WithDetails(gin.H{dup.Field: tr("is already in use")})
+	case errors.As(err, &locked):
+		return newAPIError(http.StatusLocked, "Account temporarily locked after too many failed logins").
+			WithCode("account_locked").
+			WithDetails(gin.H{"locked_until": locked.Until.UTC().Format(time.RFC3339)})
+	case errors.As(err, &stale):
+		return newAPIError(http.StatusConflict, "User was modified by another request; apply your change to the current version").
+			WithCode("revision_conflict").
+			WithDetails(gin.H{"current": stale.Current})
+	case errors.Is(err, repository.ErrStaleRevision):
+		return newAPIError(http.StatusConflict, "User was modified by another request; try again").
+			WithCode("revision_conflict")
+	case errors.As(err, &tooLarge):
+		return newAPIErrorf(http.StatusRequestEntityTooLarge, "Request body exceeds %d bytes", tooLarge.Limit)
+	case errors.As(err, &circuitOpen):
+		return newAPIError(http.StatusServiceUnavailable, "Database is overloaded; try again later").
+			WithCode("circuit_open")
+	case errors.Is(err, context.DeadlineExceeded):
+		return newAPIError(http.StatusRequestTimeout, "Request took too long to process")
+	case errors.Is(err, context.Canceled):
+		// O cliente desistiu; ninguém lê a resposta, mas o log e as métricas
+		// não devem contar como falha do servidor
+		return newAPIError(statusClientClosedRequest, "Client closed the request")
+	case errors.Is(err, service.ErrSessionNotFound):
+		return newAPIError(http.StatusNotFound, "Session not found")
+	case errors.Is(err, service.ErrAPIKeyNotFound):
+		return newAPIError(http.StatusNotFound, "API key not found")
+	case errors.Is(err, service.ErrAPIKeyExpiry):
+		return newAPIError(http.StatusBadRequest, "expires_at must be in the future")
+	case errors.Is(err, service.ErrNotificationNotFound):
+		return newAPIError(http.StatusNotFound, "Notification not found")
+	case errors.Is(err, service.ErrPushTokenNotFound):
+		return newAPIError(http.StatusNotFound, "Push token not found")
+	case errors.Is(err, service.ErrWebhookNotFound):
+		return newAPIError(http.StatusNotFound, "Webhook not found")
+	case errors.Is(err, service.ErrTenantNotFound):
+		return newAPIError(http.StatusNotFound, "Tenant not found")
+	case errors.Is(err, service.ErrGroupNotFound):
+		return newAPIError(http.StatusNotFound, "Group not found")
+	case errors.Is(err, service.ErrMembershipNotFound):
+		return newAPIError(http.StatusNotFound, "User is not a member of this group")
+	case errors.Is(err, service.ErrAlreadyMember):
+		return newAPIError(http.StatusConflict, "User is already a member of this group")
+	case errors.Is(err, service.ErrLastGroupOwner):
+		return newAPIError(http.StatusConflict, "The group must keep at least one owner")
+	case errors.Is(err, service.ErrFlagNotFound):
+		return newAPIError(http.StatusNotFound, "Feature flag not found")
+	case errors.Is(err, service.ErrNoLocation):
+		return newAPIError(http.StatusNotFound, "Device has no location yet")
+	case errors.Is(err, service.ErrDeviceNotOwned):
+		return newAPIError(http.StatusForbidden, "You can only access your own devices")
+	case errors.Is(err, service.ErrDeviceNotFound):
+		return newAPIError(http.StatusNotFound, "Device not found")
+	case errors.Is(err, service.ErrUserNotFound):
+		return newAPIError(http.StatusNotFound, "User not found")
+	case errors.Is(err, service.ErrUserConflict):
+		return newAPIError(http.StatusConflict, "User or Email already exists")
+	case errors.Is(err, service.ErrUserNotDeleted):
+		return newAPIError(http.StatusConflict, "User is not deleted")
+	case errors.Is(err, service.ErrUserAnonymized):
+		return newAPIError(http.StatusConflict, "User was erased and cannot be restored").WithCode("user_erased")
+	case errors.Is(err, service.ErrWrongPassword):
+		return newAPIError(http.StatusForbidden, "Current password is incorrect")
+	case errors.Is(err, service.ErrInvalidResetToken):
+		return newAPIError(http.StatusBadRequest, "Invalid or expired reset token").WithCode("invalid_reset_token")
+	case errors.Is(err, service.ErrInvalidVerificationToken):
+		return newAPIError(http.StatusBadRequest, "Invalid or expired verification token").WithCode("invalid_verification_token")
+	case errors.Is(err, service.ErrInvalidDeletionToken):
+		return newAPIError(http.StatusBadRequest, "Invalid or expired deletion token").WithCode("invalid_deletion_token")
+	case errors.Is(err, service.ErrEmailNotVerified):
+		return newAPIError(http.StatusForbidden, "Email not verified").WithCode("email_not_verified")
+	case errors.Is(err, service.ErrIdentityUnverifiedEmail):
+		return newAPIError(http.StatusForbidden, "The provider account has no verified email").WithCode("unverified_email")
+	case errors.Is(err, service.ErrIdentityEmailTaken):
+		return newAPIError(http.StatusConflict, "An account with this email already exists; sign in with your password and verify the email first").
+			WithCode("account_exists")
+	case errors.Is(err, service.ErrInvalidImage):
+		return newAPIError(http.StatusBadRequest, "File must be a JPEG, PNG or GIF image").WithCode("invalid_image")
+	case errors.Is(err, service.ErrImageTooLarge):
+		return newAPIError(http.StatusBadRequest, "Image dimensions are too large").WithCode("invalid_image")
+	case errors.Is(err, service.ErrInvalidRole):
+		return newAPIError(http.StatusBadRequest, "Invalid role")
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, repository.ErrNotFound):
+		return newAPIError(http.StatusNotFound, "Not found")
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return newAPIError(http.StatusConflict, "Already exists")
+	}
+	return newAPIError(http.StatusInternalServerError, "Internal error")
+}
+
+// Cópia do erro no idioma pedido
+func (e *APIError) localized(lang string) *APIError {
+	copied := *e
+	copied.Message = e.text.in(lang)
+	copied.Details = localizeDetails(lang, e.Details)
+	return &copied
+}
+
+func writeAPIError(c *gin.Context, apiErr *APIError) {
+	lang := language(c)
+	apiErr = apiErr.localized(lang)
+	apiErr.RequestID = logging.RequestIDFromContext(c.Request.Context())
+	c.Header("Content-Language", lang)
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	c.Abort()
+	respond(c, apiErr.Status, gin.H{"error": apiErr})
+}
+
+// Middleware: escreve o último erro registrado pelos handlers. Se a resposta
+// já começou (ex: streaming do /changes), não há mais o que fazer.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		apiErr := toAPIError(err)
+		if apiErr.Status >= http.StatusInternalServerError && apiErr.Code != "circuit_open" {
+			slog.ErrorContext(c.Request.Context(), "erro na requisição", "error", err)
+		}
+		setCircuitRetryAfter(c, err)
+		writeAPIError(c, apiErr)
+	}
+}
+
+// Panic vira 500 no mesmo formato (usado no gin.CustomRecovery)
+func RecoveryHandler(c *gin.Context, recovered any) {
+	slog.ErrorContext(c.Request.Context(), "panic na requisição", "panic", recovered)
+	writeAPIError(c, newAPIError(http.StatusInternalServerError, "Internal error"))
+}
+
+// --- Rota e Método Desconhecidos ---
+// No lugar do "404 page not found" em texto puro do gin, que as bibliotecas
+// HTTP dos dispositivos não tratam. Passam pelos mesmos middlewares das
+// rotas (log, métricas, rate limit).
+
+func NoRoute(c *gin.Context) {
+	abortError(c, newAPIError(http.StatusNotFound, "Route not found").WithCode("route_not_found"))
+}
+
+// O gin já preencheu o Allow com os métodos da rota; OPTIONS vale em todas
+// (o preflight do CORS é respondido antes, no middleware)
+func NoMethod(c *gin.Context) {
+	allow := c.Writer.Header().Get("Allow") + ", " + http.MethodOptions
+	c.Header("Allow", allow)
+	if c.Request.Method == http.MethodOptions {
+		c.AbortWithStatus(http.StatusNoContent)
+		return
+	}
+	abortError(c, newAPIError(http.StatusMethodNotAllowed, "Method not allowed").WithDetails(gin.H{"allow": allow}))
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go_api/models"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- ETag e Lock Otimista dos Usuários ---
+// O ETag de um usuário é a revisão dele (ex: "3"), que muda a cada escrita.
+// GET /users/:id com If-None-Match igual responde 304; PUT e PATCH com
+// If-Match só gravam se o usuário ainda estiver naquela revisão, senão 409
+// com o estado atual. Sem If-Match, duas escritas simultâneas também não
+// se sobrescrevem: a segunda recebe o mesmo 409.
+
+func userETag(u models.User) string {
+	return `"` + strconv.FormatUint(uint64(u.Revision), 10) + `"`
+}
+
+// If-Match -> revisão esperada; 0 quando não há condição (ausente ou "*")
+func ifMatchRevision(c *gin.Context) (uint, bool) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, true
+	}
+	revision, err := strconv.ParseUint(strings.Trim(value, `"`), 10, 32)
+	if err != nil || revision == 0 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		abortError(c, newAPIError(http.StatusBadRequest, `If-Match must be a single ETag from this API, e.g. "3"`))
+		return 0, false
+	}
+	return uint(revision), true
+}
+
+// Resposta com o usuário e o ETag dele
+func writeUser(c *gin.Context, status int, user models.User) {
+	c.Header("ETag", userETag(user))
+	respond(c, status, user)
+}
+
+// Como abortError, mas um conflito de revisão leva o ETag atual
+func abortUserError(c *gin.Context, err error) {
+	var stale *service.RevisionConflictError
+	if errors.As(err, &stale) {
+		c.Header("ETag", userETag(stale.Current))
+	}
+	abortError(c, err)
+}
+
+// If-None-Match com o ETag atual (ou "*")
+func notModified(c *gin.Context, user models.User) bool {
+	etag := userETag(user)
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// --- Histórico do Usuário (Event Sourcing) ---
+
+const changesBatchSize = 500
+
+func (h *Handler) GetUserEvents(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	var events []models.UserEvent
+	if err := h.db(c).Where("user_id = ?", id).Order("id").Find(&events).Error; err != nil {
+		abortError(c, err)
+		return
+	}
+	if len(events) == 0 {
+		abortError(c, newAPIError(http.StatusNotFound, "User not found"))
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+// Consulta temporal: como o usuário estava no instante ?at= (RFC3339)
+func (h *Handler) GetUserAt(c *gin.Context) {
+	at := time.Now()
+	if v := c.Query("at"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			abortError(c, newAPIError(http.StatusBadRequest, "at must be an RFC3339 timestamp"))
+			return
+		}
+		at = parsed
+	}
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var events []models.UserEvent
+	if err := h.db(c).Where("user_id = ? AND created_at <= ?", id, at).Order("id").Find(&events).Error; err != nil {
+		abortError(c, err)
+		return
+	}
+
+	user, exists := models.ReplayUser(events)
+	if !exists {
+		abortError(c, newAPIError(http.StatusNotFound, "User not found at the given time"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"at": at.UTC(), "user": user, "version": len(events)})
+}
+
+// GET /changes?since=<cursor>&limit=
+// Envia as alterações em NDJSON (uma por linha), lendo o banco em lotes,
+// para que um gateway que ficou offline consiga se atualizar sem que a API
+// carregue todo o histórico em memória.
+func (h *Handler) GetChanges(c *gin.Context) {
+	since, err := strconv.ParseUint(c.DefaultQuery("since", "0"), 10, 64)
+	if err != nil {
+		abortError(c, newAPIError(http.StatusBadRequest, "since must be a positive integer cursor"))
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10000"))
+	if err != nil || limit <= 0 {
+		abortError(c, newAPIError(http.StatusBadRequest, "limit must be a positive integer"))
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	enc := json.NewEncoder(c.Writer)
+
+	var batch []models.UserEvent
+	err = h.db(c).Where("id > ?", since).Limit(limit).
+		FindInBatches(&batch, changesBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, e := range batch {
+				if err := enc.Encode(models.ToChangeEntry(e)); err != nil {
+					return err // cliente desconectou
+				}
+			}
+			c.Writer.Flush()
+			return nil
+		}).Error
+	// O 200 já saiu: o cliente percebe pela falta do fim e retoma do
+	// último cursor recebido
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "envio das alterações interrompido", "error", err)
+	}
+}
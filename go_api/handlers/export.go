@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_api/models"
+	"go_api/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Exportação de Usuários ---
+// GET /users/export?format=csv|json (admin): a lista inteira, lida do banco
+// linha a linha e escrita direto na resposta, sem montar tudo em memória.
+// Aceita o mesmo ?filter=, ?sort=, ?include_deleted= e os filtros de
+// created_at/updated_at da listagem.
+
+// Escreve na rede a cada tantas linhas
+const exportFlushEvery = 500
+
+var exportColumns = []string{"id", "name", "email", "user", "role", "region", "email_verified", "revision", "deleted_at"}
+
+// Planilhas executam células que começam com esses caracteres como fórmula
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func exportRow(u models.User) []string {
+	var deletedAt string
+	if u.DeletedAt.Valid {
+		deletedAt = u.DeletedAt.Time.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		strconv.FormatUint(uint64(u.ID), 10),
+		csvSafe(u.Name),
+		csvSafe(u.Email),
+		csvSafe(u.User),
+		u.Role,
+		csvSafe(u.Region),
+		strconv.FormatBool(u.EmailVerified),
+		strconv.FormatUint(uint64(u.Revision), 10),
+		deletedAt,
+	}
+}
+
+// GET /users/export
+func (h *Handler) ExportUsers(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		abortError(c, newAPIError(http.StatusBadRequest, "format must be csv or json"))
+		return
+	}
+	var q repository.UserQuery
+	if f := c.Query("filter"); f != "" {
+		cond, args, err := parseFilter(f, userFilterFields)
+		if err != nil {
+			abortError(c, newAPIError(http.StatusBadRequest, err.Error()))
+			return
+		}
+		q.Where, q.Args = cond, args
+	}
+	var ok bool
+	if q.Order, ok = sortClauses(c, userFilterFields, "id"); !ok {
+		return
+	}
+	if q.IncludeDeleted, ok = includeDeleted(c); !ok {
+		return
+	}
+	if q.Times, ok = parseTimeFilter(c); !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("users-%s.%s", time.Now().UTC().Format("20060102"), format)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+	}
+	c.Status(http.StatusOK)
+
+	// Depois do primeiro byte o status já foi enviado: um erro no meio só
+	// interrompe o arquivo (o cliente recebe um CSV/JSON truncado)
+	var err error
+	if format == "csv" {
+		err = h.exportCSV(c, q)
+	} else {
+		err = h.exportJSON(c, q)
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "exportação de usuários interrompida", "error", err)
+	}
+}
+
+func (h *Handler) exportCSV(c *gin.Context, q repository.UserQuery) error {
+	w := csv.NewWriter(c.Writer)
+	if err := w.Write(exportColumns); err != nil {
+		return err
+	}
+	n := 0
+	err := h.Users.Each(c.Request.Context(), q, func(u models.User) error {
+		if err := w.Write(exportRow(u)); err != nil {
+			return err
+		}
+		if n++; n%exportFlushEvery == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+		return w.Error()
+	})
+	w.Flush()
+	if err != nil {
+		return err
+	}
+	return w.Error()
+}
+
+// Um array JSON com o mesmo formato do GET /users
+func (h *Handler) exportJSON(c *gin.Context, q repository.UserQuery) error {
+	if _, err := c.Writer.WriteString("["); err != nil {
+		return err
+	}
+	n := 0
+	err := h.Users.Each(c.Request.Context(), q, func(u models.User) error {
+		raw, err := json.Marshal(u)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			c.Writer.WriteString(",")
+		}
+		if _, err := c.Writer.Write(raw); err != nil {
+			return err
+		}
+		if n++; n%exportFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.Writer.WriteString("]\n")
+	return err
+}
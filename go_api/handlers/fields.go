@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Campos Esparsos (?fields=) ---
+// ?fields=id,name,email nas leituras de usuários e dispositivos: a resposta
+// só leva os campos pedidos e, nas listagens, o SELECT só traz as colunas
+// deles (mais o id, que a paginação usa). GET /users/:id e GET /devices/:id
+// já têm o registro inteiro (cache de usuários, checagem de acesso), então
+// ali só a resposta é recortada.
+
+// Campo do JSON -> colunas que ele precisa. "id" leva o uuid (que vira o id
+// no modo PUBLIC_IDS=uuid); "online" é calculado do last_seen.
+var userSparseFields = map[string][]string{
+	"id":             {"id", "uuid"},
+	"uuid":           {"uuid"},
+	"name":           {"name"},
+	"email":          {"email"},
+	"user":           {"user"},
+	"role":           {"role"},
+	"region":         {"region"},
+	"tenant_id":      {"tenant_id"},
+	"revision":       {"revision"},
+	"email_verified": {"email_verified"},
+	"avatar_url":     {"avatar_url"},
+	"created_at":     {"created_at"},
+	"updated_at":     {"updated_at"},
+	"deleted_at":     {"deleted_at"},
+}
+
+var deviceSparseFields = map[string][]string{
+	"id":         {"id", "uuid"},
+	"uuid":       {"uuid"},
+	"user_id":    {"user_id", "user_uuid"},
+	"user_uuid":  {"user_uuid"},
+	"tenant_id":  {"tenant_id"},
+	"name":       {"name"},
+	"type":       {"type"},
+	"last_seen":  {"last_seen"},
+	"online":     {"last_seen"},
+	"created_at": {"created_at"},
+	"updated_at": {"updated_at"},
+}
+
+// Lê ?fields=. Sem o parâmetro, fields e columns vêm vazios (tudo). ok =
+// false: a resposta 400 já foi enviada.
+func parseFields(c *gin.Context, allowed map[string][]string) (fields, columns []string, ok bool) {
+	raw, present := c.GetQuery("fields")
+	if !present {
+		return nil, nil, true
+	}
+	seen := map[string]bool{"id": true}
+	columns = []string{"id"}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		cols, known := allowed[name]
+		if !known {
+			abortError(c, newAPIError(http.StatusBadRequest, "Invalid fields").
+				WithCode("invalid_fields").
+				WithDetails(gin.H{"fields": tr("unknown field %q", name)}))
+			return nil, nil, false
+		}
+		if !slices.Contains(fields, name) {
+			fields = append(fields, name)
+		}
+		for _, col := range cols {
+			if !seen[col] {
+				seen[col] = true
+				columns = append(columns, col)
+			}
+		}
+	}
+	return fields, columns, true
+}
+
+// Objeto com só alguns campos, na ordem pedida
+type sparseObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func (o sparseObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for _, key := range o.keys {
+		value, ok := o.values[key]
+		if !ok {
+			continue // omitempty (ex: region vazia)
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Recorta obj (um objeto ou uma lista deles) pelo JSON dele, para o
+// MarshalJSON dos modelos (senha, modo UUID) continuar valendo
+func sparse(obj any, fields []string) (any, error) {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		var rows []map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &rows); err != nil {
+			return nil, err
+		}
+		out := make([]sparseObject, len(rows))
+		for i, row := range rows {
+			out[i] = sparseObject{keys: fields, values: row}
+		}
+		return out, nil
+	}
+	var row map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &row); err != nil {
+		return nil, err
+	}
+	return sparseObject{keys: fields, values: row}, nil
+}
+
+// Como respond, mas só com os campos de ?fields= (nenhum = todos)
+func respondFields(c *gin.Context, status int, obj any, fields []string) {
+	if len(fields) == 0 {
+		respond(c, status, obj)
+		return
+	}
+	out, err := sparse(obj, fields)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	respond(c, status, out)
+}
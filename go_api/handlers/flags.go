@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"go_api/models"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Feature Flags ---
+// Cadastro em /admin/flags (admin da plataforma: as flags valem para a
+// implantação inteira). A avaliação fica no service.FeatureFlags, com as
+// regras em cache.
+
+// A flag vale para quem fez a requisição? Sem tenant resolvido (rota
+// pública sem X-Tenant), conta o tenant padrão.
+func (h *Handler) FeatureEnabled(c *gin.Context, key string) bool {
+	tenant := c.GetUint("tenantID")
+	if tenant == 0 {
+		tenant = models.DefaultTenantID
+	}
+	return h.Flags.Enabled(c.Request.Context(), key, service.FlagSubject{TenantID: tenant, UserID: currentUserID(c)})
+}
+
+// --- Handlers ---
+
+// GET /admin/flags
+func (h *Handler) GetFeatureFlags(c *gin.Context) {
+	flags, err := h.Flags.List(c.Request.Context())
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, flags)
+}
+
+// POST /admin/flags
+func (h *Handler) CreateFeatureFlag(c *gin.Context) {
+	var input models.CreateFeatureFlagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	flag, err := h.Flags.Create(c.Request.Context(), currentUserID(c), input)
+	if errors.Is(err, service.ErrFlagKeyTaken) {
+		abortError(c, newAPIError(http.StatusConflict, "Feature flag already exists").
+			WithDetails(gin.H{"key": tr("is already in use")}))
+		return
+	}
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, flag)
+}
+
+// GET /admin/flags/:key
+func (h *Handler) GetFeatureFlag(c *gin.Context) {
+	flag, err := h.Flags.Get(c.Request.Context(), c.Param("key"))
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, flag)
+}
+
+// PUT /admin/flags/:key
+func (h *Handler) UpdateFeatureFlag(c *gin.Context) {
+	var input models.FeatureFlagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	flag, err := h.Flags.Update(c.Request.Context(), currentUserID(c), c.Param("key"), input)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, flag)
+}
+
+// DELETE /admin/flags/:key
+func (h *Handler) DeleteFeatureFlag(c *gin.Context) {
+	if err := h.Flags.Delete(c.Request.Context(), c.Param("key")); err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, "Feature flag deleted")})
+}
@@ -0,0 +1,532 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_api/graphql"
+	"go_api/models"
+	"go_api/repository"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- GraphQL ---
+// POST /graphql expõe usuários, dispositivos e leituras num grafo só: o
+// painel busca o usuário com os dispositivos e as últimas leituras numa
+// ida ao servidor, em vez de três chamadas REST. Os resolvers usam os
+// mesmos services das rotas REST e as mesmas regras de acesso (o próprio
+// usuário ou admin; dispositivos só do dono). O executor é o do pacote
+// graphql, sem gerador de código; o schema sai em GET /graphql/schema.
+const (
+	graphqlMaxDepth  = 8
+	graphqlMaxFields = 200
+	// Padrão das listas aninhadas; o teto das leituras é o do REST
+	graphqlDefaultFirst    = 20
+	graphqlDefaultReadings = 10
+)
+
+// O executor repassa o *gin.Context como contexto dos resolvers
+func gqlContext(p graphql.ResolveParams) *gin.Context {
+	return p.Context.(*gin.Context)
+}
+
+// Argumento ID -> uint; ausente = o usuário autenticado. IDs inválidos são
+// tratados como registro inexistente, como no REST.
+func gqlID(p graphql.ResolveParams, name string, notFound error) (uint, error) {
+	v, ok := p.Args[name].(string)
+	if !ok {
+		return currentUserID(gqlContext(p)), nil
+	}
+	id, err := strconv.ParseUint(v, 10, 64)
+	if err != nil || id == 0 {
+		return 0, notFound
+	}
+	return uint(id), nil
+}
+
+// Entrada de uma mutação -> modelo do REST, com as mesmas validações
+func gqlInput(p graphql.ResolveParams, name string, out interface{}) error {
+	raw, _ := json.Marshal(p.Args[name])
+	if err := json.Unmarshal(raw, out); err != nil {
+		return newAPIError(http.StatusBadRequest, err.Error())
+	}
+	if fields := fieldErrors(out); fields != nil {
+		return newAPIError(http.StatusBadRequest, "Validation failed").WithCode("validation_failed").WithDetails(fields)
+	}
+	return nil
+}
+
+// Erro de um resolver -> extensions com o mesmo code (e details) do REST
+func gqlFormatError(ctx context.Context, err error) (string, map[string]interface{}) {
+	apiErr := toAPIError(err)
+	if apiErr.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(ctx, "erro no resolver GraphQL", "error", err)
+	}
+	c, _ := ctx.(*gin.Context)
+	apiErr = apiErr.localized(language(c))
+	ext := map[string]interface{}{"code": apiErr.Code, "status": apiErr.Status}
+	if apiErr.Details != nil {
+		ext["details"] = apiErr.Details
+	}
+	return apiErr.Message, ext
+}
+
+// --- Escalares ---
+
+var (
+	gqlTime = &graphql.Scalar{
+		Name:        "Time",
+		Description: "RFC3339 timestamp",
+		Serialize: func(v interface{}) (interface{}, error) {
+			t, ok := v.(time.Time)
+			if !ok {
+				return nil, fmt.Errorf("Time cannot represent %v", v)
+			}
+			return t.UTC().Format(time.RFC3339Nano), nil
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			s, ok := v.(string)
+			if !ok {
+				return nil, errors.New("Time must be an RFC3339 string")
+			}
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return nil, errors.New("Time must be an RFC3339 timestamp")
+			}
+			return t, nil
+		},
+	}
+	gqlJSON = &graphql.Scalar{
+		Name:        "JSON",
+		Description: "Any JSON value",
+		Serialize: func(v interface{}) (interface{}, error) {
+			return v, nil
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			raw, err := json.Marshal(v)
+			return json.RawMessage(raw), err
+		},
+	}
+)
+
+// --- Schema ---
+
+func (h *Handler) graphqlSchema() *graphql.Schema {
+	str, nonNull, list := graphql.String, graphql.NonNullOf, graphql.ListOf
+	id := nonNull(graphql.ID)
+
+	user := &graphql.Object{Name: "User"}
+	device := &graphql.Object{Name: "Device"}
+	reading := &graphql.Object{Name: "Reading", Fields: []*graphql.Field{
+		{Name: "id", Type: id},
+		{Name: "device_id", Type: id},
+		{Name: "timestamp", Type: nonNull(gqlTime)},
+		{Name: "metric", Type: nonNull(str)},
+		{Name: "value", Type: nonNull(graphql.Float)},
+		{Name: "payload", Type: gqlJSON},
+	}}
+	user.Fields = []*graphql.Field{
+		{Name: "id", Type: id},
+		{Name: "name", Type: nonNull(str)},
+		{Name: "email", Type: nonNull(str)},
+		{Name: "user", Type: nonNull(str)},
+		{Name: "role", Type: nonNull(str)},
+		{Name: "region", Type: str},
+		{Name: "tenant_id", Type: id},
+		{Name: "revision", Type: nonNull(graphql.Int)},
+		{Name: "email_verified", Type: nonNull(graphql.Boolean)},
+		{Name: "avatar_url", Type: str},
+		{Name: "created_at", Type: nonNull(gqlTime)},
+		{Name: "updated_at", Type: nonNull(gqlTime)},
+		{Name: "devices", Type: nonNull(list(nonNull(device))), Resolve: h.gqlUserDevices},
+	}
+	device.Fields = []*graphql.Field{
+		{Name: "id", Type: id},
+		{Name: "user_id", Type: id},
+		{Name: "name", Type: nonNull(str)},
+		{Name: "type", Type: nonNull(str)},
+		{Name: "last_seen", Type: gqlTime},
+		{Name: "online", Type: nonNull(graphql.Boolean)},
+		{Name: "created_at", Type: nonNull(gqlTime)},
+		{Name: "updated_at", Type: nonNull(gqlTime)},
+		{Name: "owner", Type: user, Resolve: h.gqlDeviceOwner},
+		{
+			Name:        "readings",
+			Description: "Most recent readings first",
+			Type:        nonNull(list(nonNull(reading))),
+			Args: []*graphql.Argument{
+				{Name: "metric", Type: str},
+				{Name: "from", Type: gqlTime},
+				{Name: "to", Type: gqlTime},
+				{Name: "limit", Type: graphql.Int, Default: graphqlDefaultReadings},
+			},
+			Resolve: h.gqlDeviceReadings,
+		},
+		{
+			Name:    "latest_reading",
+			Type:    reading,
+			Args:    []*graphql.Argument{{Name: "metric", Type: str}},
+			Resolve: h.gqlLatestReading,
+		},
+	}
+	createdDevice := &graphql.Object{
+		Name:        "CreatedDevice",
+		Description: "The device token is only shown once, on creation",
+		Fields: []*graphql.Field{
+			{Name: "device", Type: nonNull(device), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(DeviceWithToken).Device, nil
+			}},
+			{Name: "token", Type: nonNull(str)},
+		},
+	}
+
+	query := &graphql.Object{Name: "Query", Fields: []*graphql.Field{
+		{Name: "me", Type: nonNull(user), Resolve: h.gqlMe},
+		{Name: "user", Type: user, Args: []*graphql.Argument{{Name: "id", Type: id}}, Resolve: h.gqlUser},
+		{
+			Name:        "users",
+			Description: "Admin only; keyset pagination by id",
+			Type:        nonNull(list(nonNull(user))),
+			Args: []*graphql.Argument{
+				{Name: "first", Type: graphql.Int, Default: graphqlDefaultFirst},
+				{Name: "after", Type: graphql.ID},
+			},
+			Resolve: h.gqlUsers,
+		},
+		{Name: "device", Type: device, Args: []*graphql.Argument{{Name: "id", Type: id}}, Resolve: h.gqlDevice},
+		{
+			Name:        "devices",
+			Description: "All devices of the tenant for admins, the caller's own otherwise",
+			Type:        nonNull(list(nonNull(device))),
+			Args: []*graphql.Argument{
+				{Name: "status", Type: str},
+				{Name: "first", Type: graphql.Int, Default: graphqlDefaultFirst},
+				{Name: "offset", Type: graphql.Int, Default: 0},
+			},
+			Resolve: h.gqlDevices,
+		},
+	}}
+
+	userInput := &graphql.InputObject{Name: "UserInput", Fields: []*graphql.Argument{
+		{Name: "name", Type: str},
+		{Name: "email", Type: str},
+		{Name: "user", Type: str},
+	}}
+	deviceInput := &graphql.InputObject{Name: "DeviceInput", Fields: []*graphql.Argument{
+		{Name: "name", Type: nonNull(str)},
+		{Name: "type", Type: nonNull(str)},
+	}}
+	readingInput := &graphql.InputObject{Name: "ReadingInput", Fields: []*graphql.Argument{
+		{Name: "metric", Type: nonNull(str)},
+		{Name: "value", Type: nonNull(graphql.Float)},
+		{Name: "timestamp", Type: gqlTime, Description: "Server time when omitted"},
+		{Name: "payload", Type: gqlJSON},
+	}}
+	mutation := &graphql.Object{Name: "Mutation", Fields: []*graphql.Field{
+		{
+			Name:        "update_user",
+			Description: "Same rules as PUT /users/:id; id defaults to the caller",
+			Type:        nonNull(user),
+			Args:        []*graphql.Argument{{Name: "id", Type: graphql.ID}, {Name: "input", Type: nonNull(userInput)}},
+			Resolve:     h.gqlUpdateUser,
+		},
+		{
+			Name:        "create_device",
+			Description: "user_id defaults to the caller",
+			Type:        nonNull(createdDevice),
+			Args:        []*graphql.Argument{{Name: "user_id", Type: graphql.ID}, {Name: "input", Type: nonNull(deviceInput)}},
+			Resolve:     h.gqlCreateDevice,
+		},
+		{
+			Name:    "update_device",
+			Type:    nonNull(device),
+			Args:    []*graphql.Argument{{Name: "id", Type: id}, {Name: "input", Type: nonNull(deviceInput)}},
+			Resolve: h.gqlUpdateDevice,
+		},
+		{
+			Name:    "delete_device",
+			Type:    nonNull(graphql.Boolean),
+			Args:    []*graphql.Argument{{Name: "id", Type: id}},
+			Resolve: h.gqlDeleteDevice,
+		},
+		{
+			Name:        "create_readings",
+			Description: "Stores the readings like POST /devices/:id/readings; returns how many were stored",
+			Type:        nonNull(graphql.Int),
+			Args:        []*graphql.Argument{{Name: "device_id", Type: id}, {Name: "readings", Type: nonNull(list(nonNull(readingInput)))}},
+			Resolve:     h.gqlCreateReadings,
+		},
+	}}
+
+	schema, err := graphql.NewSchema(query, mutation)
+	if err != nil {
+		panic(err)
+	}
+	schema.FormatError = gqlFormatError
+	schema.MaxDepth, schema.MaxFields = graphqlMaxDepth, graphqlMaxFields
+	return schema
+}
+
+// --- Resolvers: Usuários ---
+
+var errUserNotAccessible = newAPIError(http.StatusForbidden, "You can only access your own user")
+
+func (h *Handler) gqlMe(p graphql.ResolveParams) (interface{}, error) {
+	c := gqlContext(p)
+	return h.Users.Get(c.Request.Context(), currentUserID(c))
+}
+
+func (h *Handler) gqlUser(p graphql.ResolveParams) (interface{}, error) {
+	c := gqlContext(p)
+	id, err := gqlID(p, "id", service.ErrUserNotFound)
+	if err != nil {
+		return nil, err
+	}
+	if !canAccessUser(c, id) {
+		return nil, errUserNotAccessible
+	}
+	return h.Users.Get(repository.PreferReplica(c.Request.Context()), id)
+}
+
+func (h *Handler) gqlUsers(p graphql.ResolveParams) (interface{}, error) {
+	c := gqlContext(p)
+	if !isAdmin(c) {
+		return nil, newAPIError(http.StatusForbidden, "Admin role required")
+	}
+	first := p.Args["first"].(int)
+	if first < 1 {
+		return nil, newAPIError(http.StatusBadRequest, "first must be a positive integer")
+	}
+	q := repository.UserQuery{Where: "id > ?", Args: []interface{}{uint(0)}, Order: []string{"id"}, Limit: min(first, maxPerPage)}
+	if _, ok := p.Args["after"]; ok {
+		after, err := gqlID(p, "after", newAPIError(http.StatusBadRequest, "after must be a numeric ID"))
+		if err != nil {
+			return nil, err
+		}
+		q.Args[0] = after
+	}
+	users, _, err := h.Users.List(repository.PreferReplica(c.Request.Context()), q)
+	return users, err
+}
+
+func (h *Handler) gqlUserDevices(p graphql.ResolveParams) (interface{}, error) {
+	c := gqlContext(p)
+	return h.Devices.ListByUser(c.Request.Context(), p.Source.(models.User).ID)
+}
+
+func (h *Handler) gqlUpdateUser(p graphql.ResolveParams) (interface{}, error) {
+	c := gqlContext(p)
+	id, err := gqlID(p, "id", service.ErrUserNotFound)
+	if err != nil {
+		return nil, err
+	}
+	if !canAccessUser(c, id) {
+		return nil, errUserNotAccessible
+	}
+	var input models.UpdateUserInput
+	if err := gqlInput(p, "input", &input); err != nil {
+		return nil, err
+	}
+	return h.Users.Update(c.Request.Context(), id, input, 0)
+}
+
+// --- Resolvers: Dispositivos ---
+
+// Mesma regra do DeviceAccess
+func (h *Handler) gqlDeviceArg(p graphql.ResolveParams, name string) (models.Device, error) {
+	c := gqlContext(p)
+	id, err := gqlID(p, name, service.ErrDeviceNotFound)
+	if err != nil {
+		return models.Device{}, err
+	}
+	device, err := h.Devices.Get(c.Request.Context(), id)
+	if err != nil {
+		return device, err
+	}
+	if !canAccessUser(c, device.UserID) {
+		return device, service.ErrDeviceNotOwned
+	}
+	return device, nil
+}
+
+func (h *Handler) gqlDevice(p graphql.ResolveParams) (interface{}, error) {
+	return h.gqlDeviceArg(p, "id")
+}
+
+func (h *Handler) gqlDevices(p graphql.ResolveParams) (interface{}, error) {
+	c := gqlContext(p)
+	status, _ := p.Args["status"].(string)
+	if status != "" && status != service.DeviceOnline && status != service.DeviceOffline {
+		return nil, newAPIError(http.StatusBadRequest, "status must be online or offline")
+	}
+	first, offset := p.Args["first"].(int), p.Args["offset"].(int)
+	if first < 1 || offset < 0 {
+		return nil, newAPIError(http.StatusBadRequest, "first must be positive and offset non-negative")
+	}
+	q := service.DeviceQuery{Status: status, Offset: offset, Limit: min(first, maxPerPage)}
+	if !isAdmin(c) {
+		q.UserID = currentUserID(c)
+	}
+	devices, _, err := h.Devices.List(c.Request.Context(), q)
+	return devices, err
+}
+
+// O dono já passou pela regra de acesso do dispositivo
+func (h *Handler) gqlDeviceOwner(p graphql.ResolveParams) (interface{}, error) {
+	c := gqlContext(p)
+	return h.Users.Get(c.Request.Context(), p.Source.(models.Device).UserID)
+}
+
+func (h *Handler) gqlCreateDevice(p graphql.ResolveParams) (interface{}, error) {
+	c := gqlContext(p)
+	id, err := gqlID(p, "user_id", service.ErrUserNotFound)
+	if err != nil {
+		return nil, err
+	}
+	if !canAccessUser(c, id) {
+		return nil, errUserNotAccessible
+	}
+	var input models.DeviceInput
+	if err := gqlInput(p, "input", &input); err != nil {
+		return nil, err
+	}
+	user, err := h.Users.Get(c.Request.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	device, token, err := h.Devices.Create(c.Request.Context(), user.ID, input)
+	if err != nil {
+		return nil, err
+	}
+	return DeviceWithToken{Device: device, Token: token}, nil
+}
+
+func (h *Handler) gqlUpdateDevice(p graphql.ResolveParams) (interface{}, error) {
+	device, err := h.gqlDeviceArg(p, "id")
+	if err != nil {
+		return nil, err
+	}
+	var input models.DeviceInput
+	if err := gqlInput(p, "input", &input); err != nil {
+		return nil, err
+	}
+	err = h.Devices.Update(gqlContext(p).Request.Context(), &device, input)
+	return device, err
+}
+
+func (h *Handler) gqlDeleteDevice(p graphql.ResolveParams) (interface{}, error) {
+	device, err := h.gqlDeviceArg(p, "id")
+	if err != nil {
+		return nil, err
+	}
+	if err := h.Devices.Delete(gqlContext(p).Request.Context(), device); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// --- Resolvers: Leituras ---
+
+// Mais recentes primeiro, como o GET /devices/:id/readings sem after_id
+func (h *Handler) gqlReadings(p graphql.ResolveParams, limit int) ([]models.Reading, error) {
+	c := gqlContext(p)
+	query := repository.Replica(h.db(c)).Where("device_id = ?", p.Source.(models.Device).ID)
+	if from, ok := p.Args["from"].(time.Time); ok {
+		query = query.Where(`"timestamp" >= ?`, from)
+	}
+	if to, ok := p.Args["to"].(time.Time); ok {
+		query = query.Where(`"timestamp" < ?`, to)
+	}
+	if metric, _ := p.Args["metric"].(string); metric != "" {
+		query = query.Where("metric = ?", metric)
+	}
+	var readings []models.Reading
+	err := query.Order(`"timestamp" DESC`).Limit(limit).Find(&readings).Error
+	return readings, err
+}
+
+func (h *Handler) gqlDeviceReadings(p graphql.ResolveParams) (interface{}, error) {
+	limit := p.Args["limit"].(int)
+	if limit < 1 {
+		return nil, newAPIError(http.StatusBadRequest, "limit must be a positive integer")
+	}
+	return h.gqlReadings(p, min(limit, defaultReadingsLimit))
+}
+
+func (h *Handler) gqlLatestReading(p graphql.ResolveParams) (interface{}, error) {
+	readings, err := h.gqlReadings(p, 1)
+	if err != nil || len(readings) == 0 {
+		return nil, err
+	}
+	return readings[0], nil
+}
+
+func (h *Handler) gqlCreateReadings(p graphql.ResolveParams) (interface{}, error) {
+	device, err := h.gqlDeviceArg(p, "device_id")
+	if err != nil {
+		return nil, err
+	}
+	var inputs []models.ReadingInput
+	raw, _ := json.Marshal(p.Args["readings"])
+	if err := json.Unmarshal(raw, &inputs); err != nil {
+		return nil, newAPIError(http.StatusBadRequest, err.Error())
+	}
+	created, err := h.Telemetry.IngestInputs(gqlContext(p).Request.Context(), device, inputs)
+	var invalid *service.ReadingsError
+	if errors.As(err, &invalid) {
+		apiErr := newAPIError(http.StatusBadRequest, invalid.Message)
+		if invalid.Index >= 0 {
+			apiErr.WithDetails(gin.H{"index": invalid.Index})
+		}
+		return nil, apiErr
+	}
+	return created, err
+}
+
+// --- Handlers ---
+
+// POST /graphql {"query": ..., "operationName": ..., "variables": {...}}
+// GET /graphql?query=&operationName=&variables= só aceita consultas. Erros
+// dos resolvers saem em "errors" com status 200 (os demais campos ainda
+// vêm em "data"); consulta inválida (sintaxe, campos, variáveis) é 400.
+func (h *Handler) GraphQL(c *gin.Context) {
+	var req graphql.Request
+	if c.Request.Method == http.MethodPost {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
+		}
+	} else {
+		req.Query, req.OperationName, req.ReadOnly = c.Query("query"), c.Query("operationName"), true
+		if v := c.Query("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				abortError(c, newAPIError(http.StatusBadRequest, "variables must be a JSON object"))
+				return
+			}
+		}
+	}
+	if req.Query == "" {
+		abortError(c, newAPIError(http.StatusBadRequest, "query is required"))
+		return
+	}
+
+	res := h.GraphQLSchema.Execute(c, req)
+	status := http.StatusOK
+	if res.Data == nil {
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, res)
+}
+
+// GET /graphql/schema: o schema em SDL, para o codegen e as IDEs do painel
+func (h *Handler) GetGraphQLSchema(c *gin.Context) {
+	c.String(http.StatusOK, h.GraphQLSchema.SDL())
+}
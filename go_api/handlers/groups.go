@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"go_api/models"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Grupos ---
+// Rotas /groups/:id passam pelo GroupAccess: só membros (e admins do
+// tenant) enxergam o grupo, os membros e a frota compartilhada; alterar a
+// lista de membros exige ser owner. Um membro sempre pode sair sozinho.
+
+// Middleware: carrega o grupo e o papel de quem pede ("" para o admin que
+// não é membro)
+func (h *Handler) GroupAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		group, err := h.Groups.Get(c.Request.Context(), id)
+		if err != nil {
+			abortError(c, err)
+			return
+		}
+		role, err := h.Groups.Role(c.Request.Context(), group.ID, currentUserID(c))
+		if errors.Is(err, service.ErrMembershipNotFound) {
+			if !isAdmin(c) {
+				abortError(c, newAPIError(http.StatusForbidden, "You are not a member of this group"))
+				return
+			}
+		} else if err != nil {
+			abortError(c, err)
+			return
+		}
+		c.Set("group", group)
+		c.Set("groupRole", role)
+		c.Next()
+	}
+}
+
+func currentGroup(c *gin.Context) models.Group {
+	return c.MustGet("group").(models.Group)
+}
+
+// Owner do grupo ou admin do tenant
+func canManageGroup(c *gin.Context) bool {
+	return isAdmin(c) || c.GetString("groupRole") == models.GroupOwner
+}
+
+// --- Handlers ---
+
+// POST /groups
+func (h *Handler) CreateGroup(c *gin.Context) {
+	var input models.GroupInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	group, err := h.Groups.Create(c.Request.Context(), currentUserID(c), input)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, group)
+}
+
+// GET /groups/:id
+func (h *Handler) GetGroup(c *gin.Context) {
+	c.JSON(http.StatusOK, currentGroup(c))
+}
+
+// DELETE /groups/:id
+func (h *Handler) DeleteGroup(c *gin.Context) {
+	if !canManageGroup(c) {
+		abortError(c, newAPIError(http.StatusForbidden, "Only group owners can delete the group"))
+		return
+	}
+	if err := h.Groups.Delete(c.Request.Context(), currentGroup(c).ID); err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, "Group deleted")})
+}
+
+// GET /groups/:id/members
+func (h *Handler) GetGroupMembers(c *gin.Context) {
+	members, err := h.Groups.Members(c.Request.Context(), currentGroup(c).ID)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// POST /groups/:id/members
+func (h *Handler) AddGroupMember(c *gin.Context) {
+	if !canManageGroup(c) {
+		abortError(c, newAPIError(http.StatusForbidden, "Only group owners can add members"))
+		return
+	}
+	var input models.MembershipInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	// O validator não olha o required de um struct (o Ref)
+	if input.UserID.IsZero() {
+		abortError(c, newAPIError(http.StatusBadRequest, "Validation failed").
+			WithCode("validation_failed").
+			WithDetails(gin.H{"user_id": tr("is required")}))
+		return
+	}
+	member, err := h.Groups.AddMember(c.Request.Context(), currentGroup(c).ID, input)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, member)
+}
+
+// DELETE /groups/:id/members/:user_id
+func (h *Handler) RemoveGroupMember(c *gin.Context) {
+	userID, ok := idParam(c, "user_id")
+	if !ok {
+		return
+	}
+	if userID != currentUserID(c) && !canManageGroup(c) {
+		abortError(c, newAPIError(http.StatusForbidden, "Only group owners can remove other members"))
+		return
+	}
+	if err := h.Groups.RemoveMember(c.Request.Context(), currentGroup(c).ID, userID); err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, "Member removed")})
+}
+
+// GET /groups/:id/devices?status=online|offline&page=&per_page=
+// Dispositivos de todos os membros, com os mesmos filtros do GET /devices
+func (h *Handler) GetGroupDevices(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && status != service.DeviceOnline && status != service.DeviceOffline {
+		abortError(c, newAPIError(http.StatusBadRequest, "status must be online or offline"))
+		return
+	}
+	page, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	fields, columns, ok := parseFields(c, deviceSparseFields)
+	if !ok {
+		return
+	}
+	times, ok := parseTimeFilter(c)
+	if !ok {
+		return
+	}
+	q := service.DeviceQuery{GroupID: currentGroup(c).ID, Status: status, Offset: page.Offset(), Limit: page.PerPage, Select: columns, Times: times}
+	devices, total, err := h.Devices.List(c.Request.Context(), q)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	setPaginationHeaders(c, page, total)
+	respondFields(c, http.StatusOK, devices, fields)
+}
+
+// GET /users/:id/groups
+func (h *Handler) GetUserGroups(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	groups, err := h.Groups.ForUser(c.Request.Context(), id)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, groups)
+}
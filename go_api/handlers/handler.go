@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"sync/atomic"
+
+	"go_api/broker"
+	"go_api/config"
+	"go_api/graphql"
+	"go_api/jobs"
+	"go_api/models"
+	"go_api/oauth"
+	"go_api/push"
+	"go_api/ratelimit"
+	"go_api/repository"
+	"go_api/service"
+	"go_api/storage"
+
+	"gorm.io/gorm"
+)
+
+// --- Handlers HTTP ---
+// Dependências compartilhadas pelas rotas. As rotas de usuário só falam com
+// o service; as demais ainda consultam o banco direto pelo DB.
+type Handler struct {
+	DB     *gorm.DB
+	Config config.Config
+	Users  *service.UserService
+	Hub    *service.EventHub
+	// Presença dos dispositivos (WebSocket /ws)
+	Presence *service.PresenceHub
+	// Gravação das leituras (a mesma usada pela ponte MQTT)
+	Telemetry *service.Telemetry
+	// Agregação e limpeza das leituras antigas (job "readings.retention")
+	Retention *service.ReadingRetention
+	Devices   *service.DeviceService
+	APIKeys   *service.APIKeyService
+	// Grupos de usuários que compartilham a frota de dispositivos
+	Groups *service.GroupService
+	// Assinaturas e entregas dos webhooks (o Run fica a cargo do main)
+	Webhooks *service.WebhookService
+	// Publicação dos eventos do outbox (job "outbox.publish"); nil = sem OUTBOX_BROKER
+	Outbox *service.OutboxRelay
+	// Tokens push dos dispositivos e envio das notificações
+	Notifications *service.NotificationService
+	// Upload e armazenamento das fotos de perfil
+	Avatars *service.AvatarService
+	// Exportação dos dados e exclusão da conta (GET /me/export, DELETE /me)
+	Privacy *service.PrivacyService
+	// Login social, pelo nome em /auth/:provider
+	OAuth map[string]oauth.Provider
+	// Organizações (X-Tenant, GET/POST /tenants)
+	Tenants *service.TenantService
+	// Contagens do tenant para o relatório (GET /admin/stats)
+	Stats *service.StatsService
+	// Feature flags (cadastro em /admin/flags, avaliação pelo FeatureEnabled)
+	Flags *service.FeatureFlags
+	// Trilha de auditoria (GET /audit)
+	Audit repository.AuditStore
+	// Fila dos jobs em segundo plano (GET /admin/jobs); nil = sem fila
+	Jobs *jobs.Pool
+	// POST /graphql (montado no New, sobre os services acima)
+	GraphQLSchema *graphql.Schema
+	// Respostas guardadas do Idempotency-Key
+	Idempotency repository.IdempotencyStore
+	// nil = sem limite de requisições
+	Limiter ratelimit.Limiter
+	// Circuit breaker de cada rota; nil com DB_BREAKER_FAILURES=0
+	Breakers *repository.BreakerSet
+
+	shuttingDown atomic.Bool
+}
+
+func New(db *gorm.DB, cfg config.Config, users *service.UserService, hub *service.EventHub, presence *service.PresenceHub, limiter ratelimit.Limiter) *Handler {
+	// Formato dos IDs no JSON de User e Device (vale para o processo todo)
+	models.PublicUUIDs = cfg.PublicIDs == "uuid"
+	avatars := service.NewAvatarService(users, storage.New(cfg), cfg.AvatarSize)
+	h := &Handler{
+		DB:        db,
+		Config:    cfg,
+		Users:     users,
+		Hub:       hub,
+		Presence:  presence,
+		Telemetry: service.NewTelemetry(db, presence),
+		Retention: service.NewReadingRetention(db, service.RetentionPolicy{
+			Raw:    cfg.ReadingsRawRetention,
+			Hourly: cfg.ReadingsHourlyRetention,
+			Daily:  cfg.ReadingsDailyRetention,
+		}),
+		Devices: service.NewDeviceService(db, presence),
+		APIKeys: service.NewAPIKeyService(db),
+		Groups:  service.NewGroupService(db),
+		Webhooks: service.NewWebhookService(db, service.WebhookPolicy{
+			MaxAttempts: cfg.WebhookMaxAttempts,
+			Timeout:     cfg.WebhookTimeout,
+			RetryDelay:  cfg.WebhookRetryDelay,
+		}),
+		Notifications: service.NewNotificationService(db, push.FromConfig(cfg), service.NotificationPolicy{
+			MaxAttempts: cfg.PushMaxAttempts,
+			RetryDelay:  cfg.PushRetryDelay,
+		}),
+		Avatars:     avatars,
+		Privacy:     service.NewPrivacyService(db, users, avatars, cfg.AccountDeletionTTL),
+		OAuth:       oauth.FromConfig(cfg),
+		Tenants:     service.NewTenantService(db),
+		Stats:       service.NewStatsService(db, presence),
+		Flags:       service.NewFeatureFlags(db, cfg.ReplicaGroup, cfg.FeatureFlagsRefresh),
+		Idempotency: repository.NewIdempotencyStore(db),
+		Audit:       repository.NewAuditStore(db),
+		Limiter:     limiter,
+	}
+	if publisher := broker.New(cfg); publisher != nil {
+		h.Outbox = service.NewOutboxRelay(db, publisher, cfg.OutboxTopicPrefix)
+	}
+	if cfg.DBBreakerFailures > 0 {
+		h.Breakers = repository.NewBreakerSet(cfg.DBBreakerFailures, cfg.DBBreakerCooldown)
+	}
+	h.Telemetry.StartBuffer(service.ReadingBufferPolicy{
+		Interval: cfg.ReadingsBufferInterval,
+		Size:     cfg.ReadingsBufferSize,
+	})
+	h.GraphQLSchema = h.graphqlSchema()
+	return h
+}
+
+// Marca a réplica como em encerramento: /readyz passa a responder 503 e os
+// WebSockets e os streams SSE são fechados (senão o Shutdown esperaria por eles)
+func (h *Handler) BeginShutdown() {
+	h.shuttingDown.Store(true)
+	h.Presence.Close()
+	h.Telemetry.Close()
+}
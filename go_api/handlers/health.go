@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Health Checks ---
+// /healthz (liveness): o processo está de pé e respondendo.
+// /readyz (readiness): a réplica pode receber tráfego, ou seja, o banco
+// responde e ela não está em processo de encerramento. O nginx e o
+// healthcheck do docker-compose usam o /readyz para tirar do balanceamento
+// réplicas que perderam a conexão com o Postgres.
+var startedAt = time.Now()
+
+const readinessTimeout = 2 * time.Second
+
+func replicaInfo() gin.H {
+	hostname, _ := os.Hostname() // no Docker, é o ID do container
+	return gin.H{
+		"replica": hostname,
+		"uptime":  time.Since(startedAt).Round(time.Second).String(),
+	}
+}
+
+func (h *Handler) Healthz(c *gin.Context) {
+	resp := replicaInfo()
+	resp["status"] = "ok"
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) Readyz(c *gin.Context) {
+	resp := replicaInfo()
+
+	if h.shuttingDown.Load() {
+		resp["status"] = "shutting_down"
+		c.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+
+	sqlDB, err := h.DB.DB()
+	if err == nil {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+		defer cancel()
+		err = sqlDB.PingContext(ctx)
+	}
+	if err != nil {
+		// A rota é pública: o erro (com host e porta do banco) fica só no log
+		slog.ErrorContext(c.Request.Context(), "readiness: banco inacessível", "error", err)
+		resp["status"] = "unavailable"
+		resp["database"] = gin.H{"status": "down"}
+		c.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+
+	stats := sqlDB.Stats()
+	resp["status"] = "ready"
+	resp["database"] = gin.H{
+		"status":           "up",
+		"open_connections": stats.OpenConnections,
+		"in_use":           stats.InUse,
+		"idle":             stats.Idle,
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"go_api/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Idioma das Respostas ---
+// Erros, detalhes de validação e mensagens de confirmação saem no idioma do
+// Accept-Language (en ou pt-BR, ver o pacote i18n). Os handlers continuam
+// escrevendo em inglês; a tradução acontece no writeAPIError, e as demais
+// mensagens usam localize.
+
+// Idioma pedido pela requisição (sem contexto, inglês)
+func language(c *gin.Context) string {
+	if c == nil || c.Request == nil {
+		return i18n.English
+	}
+	return i18n.Negotiate(c.GetHeader("Accept-Language"))
+}
+
+// Mensagem já traduzida, para respostas que não são erros
+func localize(c *gin.Context, format string, args ...any) string {
+	return i18n.T(language(c), format, args...)
+}
+
+// Texto de um detalhe (ex: o erro de um campo) traduzido só na resposta;
+// fora dela (gRPC, logs) sai em inglês
+type localText struct {
+	format string
+	args   []any
+}
+
+func tr(format string, args ...any) localText {
+	return localText{format: format, args: args}
+}
+
+func (t localText) in(lang string) string {
+	return i18n.T(lang, t.format, t.args...)
+}
+
+func (t localText) String() string {
+	return t.in(i18n.English)
+}
+
+func (t localText) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// Cópia dos detalhes com os textos traduzidos; os outros valores (IDs,
+// datas, o usuário atual) ficam como estão
+func localizeDetails(lang string, details gin.H) gin.H {
+	if details == nil {
+		return nil
+	}
+	out := make(gin.H, len(details))
+	for key, value := range details {
+		if text, ok := value.(localText); ok {
+			value = text.in(lang)
+		}
+		out[key] = value
+	}
+	return out
+}
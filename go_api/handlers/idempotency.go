@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Idempotency-Key ---
+// O app móvel repete o POST quando a conexão cai sem resposta. Com o
+// cabeçalho Idempotency-Key, a primeira resposta fica guardada por
+// IDEMPOTENCY_TTL e as repetições (mesma chave, mesmo corpo) recebem a mesma
+// resposta, com Idempotent-Replayed: true, sem executar de novo. Erros 5xx
+// não são guardados: a chave é liberada para uma nova tentativa.
+
+const maxIdempotencyKey = 255
+
+// Guarda o que o handler escreveu, além de mandar ao cliente
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (h *Handler) Idempotent(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" || h.Config.IdempotencyTTL <= 0 {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			abortError(c, newAPIError(http.StatusBadRequest, "Idempotency-Key must have at most 255 characters"))
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			bindError(c, err)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+
+		ctx := c.Request.Context()
+		record := models.IdempotencyKey{
+			Scope:       scope,
+			Key:         key,
+			Fingerprint: hex.EncodeToString(sum[:]),
+			ExpiresAt:   time.Now().Add(h.Config.IdempotencyTTL),
+		}
+		existing, err := h.Idempotency.Reserve(ctx, &record)
+		if err != nil {
+			abortError(c, err)
+			return
+		}
+		if existing != nil {
+			replayIdempotent(c, *existing, record.Fingerprint)
+			return
+		}
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		// Os erros só viram resposta no ErrorHandler, depois daqui: os 4xx
+		// são escritos agora para entrarem na gravação
+		if len(c.Errors) > 0 && !w.Written() {
+			if apiErr := toAPIError(c.Errors.Last().Err); apiErr.Status < http.StatusInternalServerError {
+				writeAPIError(c, apiErr)
+			}
+		}
+		// Sem o contexto da requisição: o cliente pode já ter desistido, e a
+		// chave não pode ficar presa "em andamento"
+		if status := w.Status(); status >= http.StatusInternalServerError || !w.Written() {
+			err = h.Idempotency.Release(context.WithoutCancel(ctx), record.ID)
+		} else {
+			err = h.Idempotency.Complete(context.WithoutCancel(ctx), record.ID, status, w.Header().Get("Content-Type"), w.body.Bytes())
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "falha ao gravar a chave de idempotência", "scope", scope, "error", err)
+		}
+	}
+}
+
+func replayIdempotent(c *gin.Context, record models.IdempotencyKey, fingerprint string) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		abortError(c, newAPIError(http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request body").
+			WithCode("idempotency_key_reused"))
+	case record.Status == 0:
+		c.Header("Retry-After", "1")
+		abortError(c, newAPIError(http.StatusConflict, "A request with this Idempotency-Key is still in progress").
+			WithCode("idempotency_in_progress"))
+	default:
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(record.Status, record.ContentType, record.Body)
+		c.Abort()
+	}
+}
+
+// Apaga as respostas vencidas (job periódico "idempotency.purge")
+func (h *Handler) CleanupIdempotency(ctx context.Context) error {
+	deleted, err := h.Idempotency.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		slog.InfoContext(ctx, "chaves de idempotência vencidas removidas", "count", deleted)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_api/models"
+	"go_api/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Personificação (Suporte) ---
+// POST /admin/impersonate/:id: o admin recebe um access token curto
+// (IMPERSONATION_TTL, sem refresh nem sessão) agindo como o usuário, para
+// reproduzir no painel um problema que só acontece com ele. O token leva o
+// admin na claim "act" (RFC 8693), a emissão vai para a auditoria
+// (action=impersonate, com o motivo) e cada alteração feita com ele sai com
+// impersonator_id. Com esse token não dá para criar chaves de API, trocar a
+// senha, exportar nem apagar a conta; admins não podem ser personificados.
+
+type ImpersonateInput struct {
+	Reason string `json:"reason" binding:"required,max=500"` // ex: número do chamado
+}
+
+type ImpersonationToken struct {
+	AccessToken string      `json:"access_token"`
+	TokenType   string      `json:"token_type"`
+	ExpiresIn   int64       `json:"expires_in"`
+	User        models.User `json:"user"`
+}
+
+// A requisição veio com um token de personificação?
+func impersonating(c *gin.Context) bool {
+	_, ok := c.Get("impersonatorID")
+	return ok
+}
+
+func (h *Handler) Impersonate(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	var input ImpersonateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	admin := currentUserID(c)
+	if id == admin {
+		abortError(c, newAPIError(http.StatusBadRequest, "Cannot impersonate yourself"))
+		return
+	}
+	user, err := h.Users.Get(c.Request.Context(), id)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	if user.Role == models.RoleAdmin {
+		abortError(c, newAPIError(http.StatusForbidden, "Admins cannot be impersonated"))
+		return
+	}
+
+	ttl := h.Config.ImpersonationTTL
+	claims := newClaims(user, models.Session{}, "", tokenTypeAccess, ttl)
+	claims.Actor = &TokenActor{Subject: strconv.FormatUint(uint64(admin), 10)}
+	token, err := h.signClaims(claims)
+	if err != nil {
+		abortError(c, newAPIError(http.StatusInternalServerError, "Could not issue tokens"))
+		return
+	}
+	record := gin.H{"reason": input.Reason, "expires_at": claims.ExpiresAt.Time.UTC().Format(time.RFC3339)}
+	if err := repository.RecordAudit(h.db(c), models.AuditImpersonate, "user", user.ID, nil, record); err != nil {
+		abortError(c, err)
+		return
+	}
+	slog.Info("token de personificação emitido", "admin_id", admin, "user_id", user.ID, "ttl", ttl)
+	respond(c, http.StatusCreated, ImpersonationToken{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(ttl.Seconds()),
+		User:        user,
+	})
+}
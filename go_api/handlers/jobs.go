@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Jobs em Segundo Plano ---
+
+// GET /admin/jobs (admin): tamanho da fila, jobs em andamento e falhas.
+// A contagem por tipo e as falhas recentes são da réplica que respondeu.
+func (h *Handler) GetJobStats(c *gin.Context) {
+	if h.Jobs == nil {
+		abortError(c, newAPIError(http.StatusServiceUnavailable, "Background jobs are not running"))
+		return
+	}
+	stats, err := h.Jobs.Stats(c.Request.Context())
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// --- Tempo Limite e Tamanho do Corpo ---
+// Uma consulta lenta ou um upload enorme não podem prender um worker por
+// tempo indeterminado. Toda requisição ganha um contexto com prazo
+// (REQUEST_TIMEOUT), que chega ao banco pelo WithContext, e um corpo
+// limitado a MAX_BODY_BYTES. Estourou o prazo: 408; o corpo: 413.
+
+// Rotas que ficam abertas de propósito (WebSocket, long-polling, download
+// em streaming) ou que precisam de mais tempo que o padrão, em qualquer versão
+var routeTimeouts = map[string]time.Duration{
+	"/ws":                          0,
+	"/events/poll":                 0,
+	"/users/export":                0,
+	"/me/export":                   0,
+	"/changes":                     0,
+	"/devices/:id/readings/stream": 0,
+	"/users/import":                10 * time.Minute,
+	// O perfil de CPU e o trace duram o ?seconds= pedido
+	"/debug/pprof/:profile": 0,
+}
+
+// Atalho para as consultas feitas direto nos handlers: o prazo e o request
+// ID da requisição vão junto para o banco
+func (h *Handler) db(c *gin.Context) *gorm.DB {
+	return h.DB.WithContext(c.Request.Context())
+}
+
+func (h *Handler) RequestTimeout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := h.Config.RequestTimeout
+		if t, ok := routeTimeouts[unversionedPath(c.FullPath())]; ok {
+			timeout = t
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		// O handler desistiu sem responder (ou nem percebeu o prazo)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() && len(c.Errors) == 0 {
+			abortError(c, context.DeadlineExceeded)
+		}
+	}
+}
+
+// As rotas de envio em lote aceitam o mesmo volume com ou sem compressão;
+// a importação de CSV tem o próprio limite
+func (h *Handler) LimitBody() gin.HandlerFunc {
+	bulk := h.Config.MaxDecompressedBytes
+	routeLimits := map[string]int64{
+		"/users/import":         maxImportBytes,
+		"/users/batch":          bulk,
+		"/sync/push":            bulk,
+		"/users/:id/activities": bulk,
+		"/devices/:id/readings": bulk,
+		"/users/:id/avatar":     h.Config.AvatarMaxBytes + multipartOverhead,
+	}
+
+	return func(c *gin.Context) {
+		limit := h.Config.MaxBodyBytes
+		if l, ok := routeLimits[unversionedPath(c.FullPath())]; ok && l > limit {
+			limit = l
+		}
+		if limit <= 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+		// Content-Length declarado já acima do limite: nem lê
+		if c.Request.ContentLength > limit {
+			abortError(c, &http.MaxBytesError{Limit: limit})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Localização dos Dispositivos ---
+// A gravação e a busca por raio ficam no service.Telemetry, junto das
+// leituras
+const defaultNearbyLimit = 100
+
+// --- Handlers ---
+
+// POST /devices/:id/locations
+// Aceita um ponto ({...}) ou vários ([{...}, ...]), como as leituras
+func (h *Handler) CreateLocations(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+	created, err := h.Telemetry.IngestLocations(c.Request.Context(), currentDevice(c), body)
+	var invalid *service.LocationsError
+	switch {
+	case errors.As(err, &invalid):
+		apiErr := newAPIError(http.StatusBadRequest, invalid.Message)
+		if invalid.Index >= 0 {
+			apiErr.WithDetails(gin.H{"index": invalid.Index})
+		}
+		abortError(c, apiErr)
+		return
+	case err != nil:
+		abortError(c, newAPIError(http.StatusInternalServerError, "Could not store locations"))
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"created": created})
+}
+
+// GET /devices/:id/location: a posição mais recente
+func (h *Handler) GetDeviceLocation(c *gin.Context) {
+	location, err := h.Telemetry.LatestLocation(c.Request.Context(), currentDevice(c).ID)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, location)
+}
+
+// GET /devices/nearby?lat=&lon=&radius=&limit=
+// radius em metros. Admin busca entre todos os dispositivos do tenant; os
+// demais, entre os próprios.
+func (h *Handler) GetNearbyDevices(c *gin.Context) {
+	var coords [3]float64
+	for i, param := range []string{"lat", "lon", "radius"} {
+		v, err := strconv.ParseFloat(c.Query(param), 64)
+		if err != nil {
+			abortError(c, newAPIErrorf(http.StatusBadRequest, "%s must be a number", param))
+			return
+		}
+		coords[i] = v
+	}
+	lat, lon, radius := coords[0], coords[1], coords[2]
+	switch {
+	case lat < -90 || lat > 90:
+		abortError(c, newAPIError(http.StatusBadRequest, "lat must be between -90 and 90"))
+		return
+	case lon < -180 || lon > 180:
+		abortError(c, newAPIError(http.StatusBadRequest, "lon must be between -180 and 180"))
+		return
+	case radius <= 0 || radius > service.MaxNearbyRadius:
+		abortError(c, newAPIErrorf(http.StatusBadRequest, "radius must be between 0 and %d meters", service.MaxNearbyRadius))
+		return
+	}
+
+	limit := defaultNearbyLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			abortError(c, newAPIError(http.StatusBadRequest, "limit must be a positive integer"))
+			return
+		}
+		limit = min(n, service.MaxLocationsPerMessage)
+	}
+
+	var owner uint
+	if !isAdmin(c) {
+		owner = currentUserID(c)
+	}
+	devices, err := h.Telemetry.Nearby(c.Request.Context(), lat, lon, radius, owner, limit)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, devices)
+}
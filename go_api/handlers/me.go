@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Conta Própria (/me) ---
+// Atalhos para os clientes que só conhecem o token: /me e /users/me valem
+// como /users/<id do token> e caem nos mesmos handlers (inclusive ETag e
+// If-Match). A troca de senha por aqui sempre pede a senha atual, e o DELETE
+// pede confirmação por e-mail (ver privacy.go).
+
+// Preenche o :id com o usuário autenticado
+func Me() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(currentUserID(c)), 10)})
+		c.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+)
+
+// --- Métricas Prometheus ---
+// Exportadas em /metrics por réplica. O rótulo "route" usa o padrão da rota
+// (ex: /users/:id) e não a URL real, para não explodir a cardinalidade.
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total de requisições HTTP por rota, método e status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latência das requisições HTTP por rota e método.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"method", "route"})
+
+	httpInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Requisições HTTP sendo processadas no momento.",
+	})
+)
+
+// Registra as métricas HTTP e as estatísticas do pool do GORM
+// (go_sql_open_connections, go_sql_wait_count_total, ...). Chamado uma
+// única vez no main: registrar de novo no mesmo processo dá panic.
+func RegisterMetrics(db *gorm.DB) {
+	prometheus.MustRegister(httpRequests, httpDuration, httpInFlight)
+	if sqlDB, err := db.DB(); err == nil {
+		prometheus.MustRegister(collectors.NewDBStatsCollector(sqlDB, "go_api"))
+	}
+}
+
+// GET /metrics
+func MetricsEndpoint() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.URL.Path == "/metrics" {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		httpInFlight.Inc()
+		c.Next()
+		httpInFlight.Dec()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
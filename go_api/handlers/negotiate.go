@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/ugorji/go/codec"
+)
+
+// --- Formato da Resposta (JSON, MessagePack ou CBOR) ---
+// Os microcontroladores gastam RAM montando o JSON; com Accept:
+// application/msgpack (ou application/cbor) as rotas de usuários,
+// dispositivos e leituras respondem em binário. Sem Accept, com */* ou com
+// outro formato, a resposta continua em JSON. O binário sai do próprio JSON
+// da resposta (nomes dos campos, campos omitidos, a senha que nunca sai e
+// datas em RFC3339 são os mesmos); números sem casa decimal viram inteiros.
+const (
+	MIMEMsgPack = "application/msgpack"
+	MIMECBOR    = "application/cbor"
+)
+
+var responseFormats = []string{binding.MIMEJSON, MIMEMsgPack, "application/x-msgpack", MIMECBOR}
+
+// Chaves em ordem: a mesma resposta sai sempre com os mesmos bytes
+var (
+	msgpackHandle = func() *codec.MsgpackHandle {
+		h := &codec.MsgpackHandle{WriteExt: true} // str e bin separados (formato atual)
+		h.Canonical = true
+		return h
+	}()
+	cborHandle = func() *codec.CborHandle {
+		h := &codec.CborHandle{}
+		h.Canonical = true
+		return h
+	}()
+)
+
+// Responde obj no formato pedido em Accept
+func respond(c *gin.Context, status int, obj any) {
+	c.Writer.Header().Add("Vary", "Accept")
+	var handle codec.Handle
+	contentType := MIMEMsgPack
+	switch c.NegotiateFormat(responseFormats...) {
+	case MIMEMsgPack, "application/x-msgpack":
+		handle = msgpackHandle
+	case MIMECBOR:
+		handle, contentType = cborHandle, MIMECBOR
+	default:
+		c.JSON(status, obj)
+		return
+	}
+
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var plain any
+	if err := decoder.Decode(&plain); err != nil {
+		abortError(c, err)
+		return
+	}
+	var out []byte
+	if err := codec.NewEncoderBytes(&out, handle).Encode(plainNumbers(plain)); err != nil {
+		abortError(c, err)
+		return
+	}
+	c.Data(status, contentType, out)
+}
+
+// json.Number -> int64 (ou uint64) quando é inteiro, senão float64
+func plainNumbers(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for k, item := range value {
+			value[k] = plainNumbers(item)
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = plainNumbers(item)
+		}
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			return n
+		}
+		if n, err := strconv.ParseUint(value.String(), 10, 64); err == nil {
+			return n
+		}
+		f, _ := value.Float64()
+		return f
+	}
+	return v
+}
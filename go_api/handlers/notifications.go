@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Notificações Push ---
+// O dispositivo registra o próprio token; o admin dispara e acompanha as
+// entregas. O envio fica no service.NotificationService (job "push.deliver").
+
+// PUT /devices/:id/push-token
+func (h *Handler) PutPushToken(c *gin.Context) {
+	var input models.PushTokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	token, err := h.Notifications.RegisterToken(c.Request.Context(), currentDevice(c).ID, input)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, token)
+}
+
+// DELETE /devices/:id/push-token (ex: logout no app)
+func (h *Handler) DeletePushToken(c *gin.Context) {
+	if err := h.Notifications.DeleteToken(c.Request.Context(), currentDevice(c).ID); err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, "Push token removed")})
+}
+
+// POST /notifications (admin): 202, o envio é assíncrono
+func (h *Handler) CreateNotification(c *gin.Context) {
+	var input models.NotificationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	if len(input.UserIDs) == 0 && len(input.DeviceIDs) == 0 {
+		abortError(c, newAPIError(http.StatusBadRequest, "user_ids or device_ids is required"))
+		return
+	}
+	admin := currentUserID(c)
+	n, err := h.Notifications.Notify(c.Request.Context(), models.NotificationSourceAdmin, &admin, input)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, n)
+}
+
+// GET /notifications/:id: com a contagem das entregas por status
+func (h *Handler) GetNotification(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	n, err := h.Notifications.Get(c.Request.Context(), id)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, n)
+}
+
+// GET /notifications/:id/deliveries?page=&per_page=
+func (h *Handler) GetNotificationDeliveries(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	if _, err := h.Notifications.Get(c.Request.Context(), id); err != nil {
+		abortError(c, err)
+		return
+	}
+	page, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	deliveries, total, err := h.Notifications.Deliveries(c.Request.Context(), id, page.PerPage, page.Offset())
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	setPaginationHeaders(c, page, total)
+	c.JSON(http.StatusOK, deliveries)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_api/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// --- Login Social (OAuth2) ---
+// GET /auth/:provider manda o navegador ao provedor; o provedor devolve em
+// GET /auth/:provider/callback, que entra (ou cria a conta, ver
+// service/identity.go) e responde com o mesmo par de tokens do /login. O
+// state é assinado com o JWT_SECRET e leva o tenant pedido; o nonce dele
+// também vai num cookie, para um callback forjado num outro navegador não
+// logar a vítima na conta do atacante.
+
+const (
+	tokenTypeOAuthState = "oauth_state"
+	oauthStateCookie    = "oauth_state"
+	oauthStateTTL       = 10 * time.Minute
+)
+
+func (h *Handler) oauthRedirectURL(provider string) string {
+	return h.Config.OAuthCallbackBase + "/auth/" + provider + "/callback"
+}
+
+// GET /auth/:provider
+func (h *Handler) OAuthStart(c *gin.Context) {
+	name := c.Param("provider")
+	provider, ok := h.OAuth[name]
+	if !ok {
+		abortError(c, newAPIError(http.StatusNotFound, "Unknown login provider"))
+		return
+	}
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		abortError(c, err)
+		return
+	}
+	nonce := base64.RawURLEncoding.EncodeToString(raw)
+	now := time.Now()
+	state, err := jwt.NewWithClaims(jwt.SigningMethodHS256, TokenClaims{
+		Type:   tokenTypeOAuthState,
+		Tenant: c.GetUint("tenantID"),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        nonce,
+			Subject:   name,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(oauthStateTTL)),
+		},
+	}).SignedString([]byte(h.Config.JWTSecret))
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, nonce, int(oauthStateTTL.Seconds()), "/", "",
+		strings.HasPrefix(h.Config.OAuthCallbackBase, "https://"), true)
+	c.Redirect(http.StatusFound, provider.AuthCodeURL(state, h.oauthRedirectURL(name)))
+}
+
+// GET /auth/:provider/callback
+func (h *Handler) OAuthCallback(c *gin.Context) {
+	name := c.Param("provider")
+	provider, ok := h.OAuth[name]
+	if !ok {
+		abortError(c, newAPIError(http.StatusNotFound, "Unknown login provider"))
+		return
+	}
+	// Usuário recusou no provedor (ou o provedor recusou o pedido)
+	if reason := c.Query("error"); reason != "" {
+		abortError(c, newAPIError(http.StatusUnauthorized, "Login was not authorized by the provider").
+			WithCode("oauth_denied").WithDetails(gin.H{"error": reason}))
+		return
+	}
+	state, err := h.parseToken(c.Query("state"), tokenTypeOAuthState)
+	nonce, _ := c.Cookie(oauthStateCookie)
+	if err != nil || state.Subject != name || nonce == "" || nonce != state.ID {
+		abortError(c, newAPIError(http.StatusBadRequest, "Invalid or expired login state; start again").WithCode("invalid_oauth_state"))
+		return
+	}
+	c.SetCookie(oauthStateCookie, "", -1, "/", "", strings.HasPrefix(h.Config.OAuthCallbackBase, "https://"), true)
+	code := c.Query("code")
+	if code == "" {
+		abortError(c, newAPIError(http.StatusBadRequest, "Missing authorization code").WithCode("invalid_oauth_state"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	profile, err := provider.Exchange(ctx, code, h.oauthRedirectURL(name))
+	if err != nil {
+		slog.WarnContext(ctx, "login social falhou no provedor", "provider", name, "error", err)
+		abortError(c, newAPIError(http.StatusBadGateway, "Could not complete the login with the provider"))
+		return
+	}
+	// O tenant do início do fluxo: o callback volta sem o X-Tenant
+	if state.Tenant != 0 {
+		ctx = repository.WithTenant(ctx, state.Tenant)
+		c.Request = c.Request.WithContext(ctx)
+	}
+	user, err := h.Users.LoginWithIdentity(ctx, name, profile, h.Config.Region)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	tokens, err := h.IssueTokenPair(ctx, user, sessionClient(c))
+	if err != nil {
+		abortError(c, newAPIError(http.StatusInternalServerError, "Could not issue tokens"))
+		return
+	}
+
+	// O painel lê os tokens do fragmento, que não vai para o servidor dele
+	if h.Config.OAuthSuccessURL != "" {
+		fragment := url.Values{
+			"access_token":  {tokens.AccessToken},
+			"refresh_token": {tokens.RefreshToken},
+			"token_type":    {tokens.TokenType},
+			"expires_in":    {strconv.FormatInt(tokens.ExpiresIn, 10)},
+		}
+		c.Redirect(http.StatusFound, h.Config.OAuthSuccessURL+"#"+fragment.Encode())
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_api/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Paginação e Ordenação ---
+// ?page=1&per_page=20&sort=-id,name
+// O total de registros (antes da paginação) vai no cabeçalho X-Total-Count,
+// assim o corpo continua sendo a lista simples que os clientes já esperam.
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+type Pagination struct {
+	Page    int
+	PerPage int
+}
+
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+func parsePagination(c *gin.Context) (Pagination, bool) {
+	p := Pagination{Page: 1, PerPage: defaultPerPage}
+	if v := c.Query("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			abortError(c, newAPIError(http.StatusBadRequest, "page must be a positive integer"))
+			return p, false
+		}
+		p.Page = page
+	}
+	if v := c.Query("per_page"); v != "" {
+		perPage, err := strconv.Atoi(v)
+		if err != nil || perPage < 1 {
+			abortError(c, newAPIError(http.StatusBadRequest, "per_page must be a positive integer"))
+			return p, false
+		}
+		if perPage > maxPerPage {
+			perPage = maxPerPage
+		}
+		p.PerPage = perPage
+	}
+	return p, true
+}
+
+// Traduz ?sort= (lista separada por vírgula; "-" na frente = decrescente)
+// em cláusulas ORDER BY. Só aceita campos da lista permitida, os mesmos
+// usados pelo ?filter=.
+func sortClauses(c *gin.Context, fields map[string]filterField, fallback string) ([]string, bool) {
+	sort := c.Query("sort")
+	if sort == "" {
+		return []string{fallback}, true
+	}
+	var clauses []string
+	for _, key := range strings.Split(sort, ",") {
+		direction := "ASC"
+		if strings.HasPrefix(key, "-") {
+			direction = "DESC"
+			key = key[1:]
+		}
+		field, ok := fields[key]
+		if !ok {
+			abortError(c, newAPIErrorf(http.StatusBadRequest, "Invalid sort field: %s", key))
+			return nil, false
+		}
+		clauses = append(clauses, `"`+field.Column+`" `+direction)
+	}
+	return clauses, true
+}
+
+func setPaginationHeaders(c *gin.Context, p Pagination, total int64) {
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.Header("X-Page", strconv.Itoa(p.Page))
+	c.Header("X-Per-Page", strconv.Itoa(p.PerPage))
+}
+
+// --- Paginação por Cursor (Keyset) ---
+// ?after_id=<next_cursor>: em vez de pular OFFSET linhas (que o banco lê e
+// descarta), continua depois do último ID visto, em ordem de ID. O corpo
+// vira {"data": [...], "next_cursor": <ID>}, com next_cursor null na última
+// página; after_id=0 começa do início.
+type keysetPage struct {
+	Data       interface{} `json:"data"`
+	NextCursor *uint       `json:"next_cursor"`
+}
+
+// keyset = false: sem after_id (paginação por página). ok = false: a
+// resposta de erro já foi enviada.
+func parseAfterID(c *gin.Context) (afterID uint, keyset, ok bool) {
+	v, keyset := c.GetQuery("after_id")
+	if !keyset {
+		return 0, false, true
+	}
+	id, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		abortError(c, newAPIError(http.StatusBadRequest, "after_id must be a non-negative integer"))
+		return 0, true, false
+	}
+	return uint(id), true, true
+}
+
+// A consulta busca limit+1 linhas: a que sobra só diz que há próxima
+// página. Corta a sobra e monta o corpo com o cursor da próxima.
+func keysetBody[T any](rows []T, limit int, id func(T) uint) keysetPage {
+	if len(rows) <= limit {
+		return keysetPage{Data: rows}
+	}
+	rows = rows[:limit]
+	next := id(rows[limit-1])
+	return keysetPage{Data: rows, NextCursor: &next}
+}
+
+// ?created_after=&created_before=&updated_after=&updated_before= (RFC3339)
+// das coleções de usuários e dispositivos. ok = false: a resposta de erro
+// já foi enviada.
+func parseTimeFilter(c *gin.Context) (f repository.TimeFilter, ok bool) {
+	params := []struct {
+		name string
+		dest *time.Time
+	}{
+		{"created_after", &f.CreatedAfter},
+		{"created_before", &f.CreatedBefore},
+		{"updated_after", &f.UpdatedAfter},
+		{"updated_before", &f.UpdatedBefore},
+	}
+	for _, p := range params {
+		if *p.dest, ok = queryTime(c, p.name); !ok {
+			return f, false
+		}
+	}
+	return f, true
+}
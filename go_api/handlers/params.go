@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go_api/models"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Parâmetros de Caminho ---
+// Os IDs do caminho são convertidos antes de chegar ao banco: /users/abc,
+// /users/0 ou /users/1;drop respondem 400 (invalid_id) sem nenhuma
+// consulta. Um ID bem formado que não existe continua sendo 404.
+
+// Inteiro positivo que cabe no bigint do Postgres (até 2^63-1)
+func idParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 63)
+	if err != nil || id == 0 {
+		abortError(c, invalidIDError(name))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func invalidIDError(name string) *APIError {
+	return newAPIErrorf(http.StatusBadRequest, "Invalid %s", name).
+		WithCode("invalid_id").
+		WithDetails(gin.H{name: tr("must be a positive integer")})
+}
+
+// --- UUIDs no Caminho ---
+// /users/:id, /devices/:id, /groups/:id/members/:user_id e
+// /admin/impersonate/:id aceitam o UUID
+// público (ver models/public_id.go). Antes dos middlewares das rotas
+// (SelfOrAdmin, DeviceAccess...), o UUID é trocado pelo ID numérico, como o
+// Me() faz com o :id, e o resto da API não percebe a diferença. Com
+// PUBLIC_IDS=uuid o ID numérico dá 400.
+
+type publicIDParam struct {
+	name     string
+	model    interface{}
+	notFound error
+}
+
+// Parâmetros do caminho registrado (c.FullPath()) que são usuário ou dispositivo
+func publicIDParams(path string) []publicIDParam {
+	var params []publicIDParam
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments); i++ {
+		switch {
+		case segments[i] == ":id" && (segments[i-1] == "users" || segments[i-1] == "impersonate"), segments[i] == ":user_id":
+			params = append(params, publicIDParam{segments[i][1:], &models.User{}, service.ErrUserNotFound})
+		case segments[i] == ":id" && segments[i-1] == "devices":
+			params = append(params, publicIDParam{"id", &models.Device{}, service.ErrDeviceNotFound})
+		}
+	}
+	return params
+}
+
+func (h *Handler) PublicIDs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, p := range publicIDParams(c.FullPath()) {
+			value := c.Param(p.name)
+			if !models.ValidUUID(value) {
+				if models.PublicUUIDs {
+					abortError(c, newAPIErrorf(http.StatusBadRequest, "Invalid %s", p.name).
+						WithCode("invalid_id").
+						WithDetails(gin.H{p.name: tr("must be a UUID")}))
+					return
+				}
+				continue // numérico (ou inválido: o idParam responde)
+			}
+			// Unscoped: o restore procura um usuário removido
+			var ids []uint
+			err := h.db(c).Unscoped().Model(p.model).Where("uuid = ?", strings.ToLower(value)).Pluck("id", &ids).Error
+			if err != nil {
+				abortError(c, err)
+				return
+			}
+			if len(ids) == 0 {
+				abortError(c, p.notFound)
+				return
+			}
+			for i := range c.Params {
+				if c.Params[i].Key == p.name {
+					c.Params[i].Value = strconv.FormatUint(uint64(ids[0]), 10)
+				}
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Redefinição de Senha ---
+
+// POST /password/forgot: sempre 202, exista ou não o e-mail
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var input models.EmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	if err := h.Users.RequestPasswordReset(c.Request.Context(), input.Email); err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": localize(c, "If the email is registered, a reset code has been sent")})
+}
+
+// POST /password/reset
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var input models.ResetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	if err := h.Users.ResetPassword(c.Request.Context(), input); err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, "Password changed")})
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Long-Polling ---
+
+const (
+	defaultPollWait = 30 * time.Second
+	maxPollWait     = 60 * time.Second
+	pollRecheck     = time.Second // Eventos de outras réplicas não passam pelo hub local
+)
+
+func (h *Handler) findChanges(ctx context.Context, cursor uint64) ([]models.ChangeEntry, error) {
+	var events []models.UserEvent
+	if err := h.DB.WithContext(ctx).Where("id > ?", cursor).Order("id").Limit(changesBatchSize).Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.ChangeEntry, 0, len(events))
+	for _, e := range events {
+		entries = append(entries, models.ToChangeEntry(e))
+	}
+	return entries, nil
+}
+
+// GET /events/poll?cursor=&wait=30s
+// Segura a requisição até chegar algum evento depois do cursor ou o tempo
+// de espera acabar. Alternativa para clientes atrás de proxies que derrubam
+// WebSockets.
+func (h *Handler) PollEvents(c *gin.Context) {
+	cursor, err := strconv.ParseUint(c.DefaultQuery("cursor", "0"), 10, 64)
+	if err != nil {
+		abortError(c, newAPIError(http.StatusBadRequest, "cursor must be a positive integer"))
+		return
+	}
+	wait := defaultPollWait
+	if v := c.Query("wait"); v != "" {
+		wait, err = time.ParseDuration(v)
+		if err != nil || wait < 0 {
+			abortError(c, newAPIError(http.StatusBadRequest, "wait must be a duration like 30s"))
+			return
+		}
+		if wait > maxPollWait {
+			wait = maxPollWait
+		}
+	}
+
+	// Inscreve antes da primeira consulta para não perder eventos no meio
+	events, unsubscribe := h.Hub.Subscribe()
+	defer unsubscribe()
+
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+	recheck := time.NewTicker(pollRecheck)
+	defer recheck.Stop()
+
+	for {
+		changes, err := h.findChanges(c.Request.Context(), cursor)
+		if err != nil {
+			abortError(c, err)
+			return
+		}
+		if len(changes) > 0 {
+			c.JSON(http.StatusOK, gin.H{"cursor": changes[len(changes)-1].Cursor, "events": changes})
+			return
+		}
+
+		select {
+		case <-events:
+		case <-recheck.C:
+		case <-timeout.C:
+			c.JSON(http.StatusOK, gin.H{"cursor": cursor, "events": []models.ChangeEntry{}})
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go_api/models"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Dados Pessoais (/me/export e DELETE /me) ---
+// GET /me/export?format=json|zip: tudo o que está ligado ao usuário (perfil,
+// dispositivos, leituras, auditoria...). No JSON, uma chave por seção; no
+// ZIP, um <seção>.json por seção. Como no /users/export, as linhas vão do
+// banco direto para a resposta.
+//
+// DELETE /me em dois passos: sem corpo, manda o código de confirmação por
+// e-mail (202); com {"token": "..."}, apaga a conta e os dados (200).
+
+// GET /me/export
+func (h *Handler) ExportMe(c *gin.Context) {
+	if viaAPIKey(c) {
+		abortError(c, newAPIError(http.StatusForbidden, "API keys cannot export the account"))
+		return
+	}
+	if impersonating(c) {
+		abortError(c, newAPIError(http.StatusForbidden, "Impersonation tokens cannot export the account"))
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		abortError(c, newAPIError(http.StatusBadRequest, "format must be json or zip"))
+		return
+	}
+	sections, err := h.Privacy.ExportSections(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	filename := fmt.Sprintf("account-%d-%s.%s", currentUserID(c), now.Format("20060102"), format)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	if format == "zip" {
+		c.Header("Content-Type", "application/zip")
+	} else {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+	}
+	c.Status(http.StatusOK)
+
+	// Depois do primeiro byte o status já foi enviado: um erro no meio só
+	// interrompe o arquivo
+	if format == "zip" {
+		err = writeExportZip(c.Writer, sections, now)
+	} else {
+		err = writeExportJSON(c.Writer, sections, now)
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "exportação da conta interrompida", "error", err)
+	}
+}
+
+func writeExportJSON(w io.Writer, sections []service.ExportSection, now time.Time) error {
+	if _, err := fmt.Fprintf(w, `{"exported_at":%q`, now.Format(time.RFC3339)); err != nil {
+		return err
+	}
+	for _, section := range sections {
+		if _, err := fmt.Fprintf(w, ",%q:", section.Name); err != nil {
+			return err
+		}
+		if err := writeSection(w, section); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "}\n")
+	return err
+}
+
+func writeExportZip(w io.Writer, sections []service.ExportSection, now time.Time) error {
+	archive := zip.NewWriter(w)
+	for _, section := range sections {
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: section.Name + ".json", Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		if err := writeSection(file, section); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, "\n"); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// Um objeto (Single) ou um array com os registros da seção
+func writeSection(w io.Writer, section service.ExportSection) error {
+	if !section.Single {
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+	}
+	n := 0
+	err := section.Each(func(row interface{}) error {
+		raw, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			io.WriteString(w, ",")
+		}
+		n++
+		_, err = w.Write(raw)
+		return err
+	})
+	if err != nil || section.Single {
+		return err
+	}
+	_, err = io.WriteString(w, "]")
+	return err
+}
+
+// DELETE /me: a chave de API de um sensor não apaga a conta do dono
+func (h *Handler) DeleteMe(c *gin.Context) {
+	if viaAPIKey(c) {
+		abortError(c, newAPIError(http.StatusForbidden, "API keys cannot delete the account"))
+		return
+	}
+	if impersonating(c) {
+		abortError(c, newAPIError(http.StatusForbidden, "Impersonation tokens cannot delete the account"))
+		return
+	}
+	var input models.DeletionConfirmInput
+	err := c.ShouldBindJSON(&input)
+	if errors.Is(err, io.EOF) {
+		expires, err := h.Privacy.RequestDeletion(c.Request.Context(), currentUserID(c))
+		if err != nil {
+			abortError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":    localize(c, "Confirmation code sent by email"),
+			"expires_at": expires.UTC(),
+		})
+		return
+	}
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+	if err := h.Privacy.ConfirmDeletion(c.Request.Context(), currentUserID(c), input.Token); err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, "Account deleted")})
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Limite de Requisições ---
+// Quem manda um access token válido é contado pelo usuário (o mesmo limite
+// em qualquer IP); os demais, pelo IP. Token inválido conta pelo IP, senão
+// bastaria inventar tokens para escapar do limite no /login. Uma chave de
+// API válida conta pelo dono, como o token.
+func (h *Handler) rateLimitKey(c *gin.Context) string {
+	if auth, ok := h.apiKeyFromRequest(c); ok && auth.err == nil {
+		return "user:" + strconv.FormatUint(uint64(auth.user.ID), 10)
+	}
+	if raw, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); found && raw != "" {
+		if claims, err := h.parseToken(raw, tokenTypeAccess); err == nil {
+			return "user:" + claims.Subject
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// Responde 429 com Retry-After quando o cliente passa do limite. Se o Redis
+// cair, as requisições seguem sem limite (melhor que derrubar a API toda).
+func (h *Handler) RateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.Limiter == nil {
+			c.Next()
+			return
+		}
+		res, err := h.Limiter.Allow(c.Request.Context(), h.rateLimitKey(c))
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "rate limit indisponível", "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(h.Config.RateLimit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		if !res.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+			abortError(c, newAPIError(http.StatusTooManyRequests, "Too many requests"))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Controle de Acesso por Papel (RBAC) ---
+// "admin" gerencia todos os usuários; "user" só enxerga e altera o próprio
+// registro. Os middlewares rodam depois do AuthRequired, que coloca
+// "userID" e "role" no contexto.
+
+func currentUserID(c *gin.Context) uint {
+	return c.GetUint("userID")
+}
+
+func isAdmin(c *gin.Context) bool {
+	return c.GetString("role") == models.RoleAdmin
+}
+
+// O usuário autenticado pode agir sobre o registro :id?
+func canAccessUser(c *gin.Context, id uint) bool {
+	return isAdmin(c) || currentUserID(c) == id
+}
+
+func AdminOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAdmin(c) {
+			abortError(c, newAPIError(http.StatusForbidden, "Admin role required"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// Rotas /users/:id/...: o próprio usuário ou um admin
+func SelfOrAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		if !canAccessUser(c, id) {
+			abortError(c, newAPIError(http.StatusForbidden, "You can only access your own user"))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_api/models"
+	"go_api/repository"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Telemetria (Leituras de Sensores) ---
+// A gravação fica no service.Telemetry, compartilhada com a ponte MQTT
+const (
+	defaultReadingsLimit = 1000
+	minStatsBucket       = time.Minute
+	maxStatsBucket       = 365 * 24 * time.Hour
+
+	streamBatchSize = 500
+	streamRecheck   = time.Second      // Leituras gravadas por outras réplicas
+	streamKeepAlive = 15 * time.Second // Proxies derrubam conexões paradas
+)
+
+// --- Handlers ---
+
+// POST /devices/:id/readings
+// Aceita uma leitura ({...}) ou várias ([{...}, ...]) na mesma requisição;
+// o lote é gravado com INSERTs em bloco em vez de um por leitura.
+func (h *Handler) CreateReadings(c *gin.Context) {
+	device := currentDevice(c)
+
+	body, err := c.GetRawData()
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+	created, err := h.Telemetry.Ingest(c.Request.Context(), device, body)
+	var invalid *service.ReadingsError
+	switch {
+	case errors.As(err, &invalid):
+		apiErr := newAPIError(http.StatusBadRequest, invalid.Message)
+		if invalid.Index >= 0 {
+			apiErr.WithDetails(gin.H{"index": invalid.Index})
+		}
+		abortError(c, apiErr)
+		return
+	case errors.Is(err, service.ErrReadingsBufferFull):
+		c.Header("Retry-After", "1")
+		abortError(c, newAPIError(http.StatusServiceUnavailable, "Readings buffer is full; try again later").WithCode("buffer_full"))
+		return
+	case err != nil:
+		abortError(c, newAPIError(http.StatusInternalServerError, "Could not store readings"))
+		return
+	}
+	// Com o buffer, as leituras ainda vão ser gravadas
+	if h.Telemetry.Buffered() {
+		respond(c, http.StatusAccepted, gin.H{"accepted": created})
+		return
+	}
+	respond(c, http.StatusCreated, gin.H{"created": created})
+}
+
+// Filtros comuns das leituras e das séries: from/to em RFC3339, metric e
+// limit. false = a resposta de erro já foi enviada.
+func readingFilters(c *gin.Context) (service.SeriesQuery, bool) {
+	q := service.SeriesQuery{Metric: c.Query("metric"), Limit: defaultReadingsLimit}
+	for _, param := range []string{"from", "to"} {
+		v := c.Query(param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			abortError(c, newAPIErrorf(http.StatusBadRequest, "%s must be an RFC3339 timestamp", param))
+			return q, false
+		}
+		if param == "from" {
+			q.From = t
+		} else {
+			q.To = t
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			abortError(c, newAPIError(http.StatusBadRequest, "limit must be a positive integer"))
+			return q, false
+		}
+		q.Limit = min(n, service.MaxReadingsPerMessage)
+	}
+	return q, true
+}
+
+// GET /devices/:id/readings?from=&to=&metric=&limit=&resolution=&after_id=
+// Sem from, devolve as leituras mais recentes. resolution=hourly|daily
+// troca as leituras por intervalos agregados; after_id pagina por cursor.
+func (h *Handler) GetReadings(c *gin.Context) {
+	device := currentDevice(c)
+	filters, ok := readingFilters(c)
+	if !ok {
+		return
+	}
+	afterID, keyset, ok := parseAfterID(c)
+	if !ok {
+		return
+	}
+
+	// Série agregada: média, mínimo e máximo por hora ou por dia, incluindo
+	// o período em que as leituras brutas já foram apagadas
+	switch resolution := c.DefaultQuery("resolution", models.ResolutionRaw); resolution {
+	case models.ResolutionRaw:
+	case models.ResolutionHourly, models.ResolutionDaily:
+		if keyset {
+			abortError(c, newAPIError(http.StatusBadRequest, "after_id only applies to raw readings"))
+			return
+		}
+		filters.Bucket = time.Hour
+		if resolution == models.ResolutionDaily {
+			filters.Bucket = 24 * time.Hour
+		}
+		h.writeSeries(c, device, filters)
+		return
+	default:
+		abortError(c, newAPIError(http.StatusBadRequest, "resolution must be raw, hourly or daily"))
+		return
+	}
+
+	// Série histórica: pode vir de uma réplica de leitura
+	query := repository.Replica(h.db(c)).Where("device_id = ?", device.ID)
+	if !filters.From.IsZero() {
+		query = query.Where(`"timestamp" >= ?`, filters.From)
+	}
+	if !filters.To.IsZero() {
+		query = query.Where(`"timestamp" < ?`, filters.To)
+	}
+	if filters.Metric != "" {
+		query = query.Where("metric = ?", filters.Metric)
+	}
+	var readings []models.Reading
+	if keyset {
+		// Em ordem de gravação (ID), como o stream: a próxima página começa
+		// no índice (device_id, id), sem OFFSET
+		if err := query.Where("id > ?", afterID).Order("id").Limit(filters.Limit + 1).Find(&readings).Error; err != nil {
+			abortError(c, err)
+			return
+		}
+		respond(c, http.StatusOK, keysetBody(readings, filters.Limit, func(r models.Reading) uint { return r.ID }))
+		return
+	}
+	if err := query.Order(`"timestamp" DESC`).Limit(filters.Limit).Find(&readings).Error; err != nil {
+		abortError(c, err)
+		return
+	}
+	respond(c, http.StatusOK, readings)
+}
+
+// GET /devices/:id/readings/stats?metric=&from=&to=&bucket=1h&limit=
+// Mínimo, máximo, média e contagem por intervalo, calculados no banco: o
+// gráfico baixa um ponto por intervalo em vez de milhares de leituras.
+func (h *Handler) GetReadingStats(c *gin.Context) {
+	device := currentDevice(c)
+	filters, ok := readingFilters(c)
+	if !ok {
+		return
+	}
+	bucket, err := time.ParseDuration(c.DefaultQuery("bucket", "1h"))
+	if err != nil || bucket < minStatsBucket || bucket > maxStatsBucket || bucket%time.Second != 0 {
+		abortError(c, newAPIError(http.StatusBadRequest, "bucket must be a duration between 1m and 8760h, like 15m or 1h"))
+		return
+	}
+	filters.Bucket = bucket
+	h.writeSeries(c, device, filters)
+}
+
+func (h *Handler) writeSeries(c *gin.Context, device models.Device, q service.SeriesQuery) {
+	series, err := h.Telemetry.Series(c.Request.Context(), device.ID, q)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	respond(c, http.StatusOK, series)
+}
+
+// GET /devices/:id/readings/stream?metric=
+// Server-Sent Events: mantém a conexão aberta e manda cada leitura nova como
+// um evento "reading", com o id da leitura no "id:". O EventSource do
+// navegador reconecta sozinho mandando Last-Event-ID e recebe o que perdeu;
+// sem ele, o stream começa nas leituras gravadas depois da conexão.
+func (h *Handler) StreamReadings(c *gin.Context) {
+	device := currentDevice(c)
+	metric := c.Query("metric")
+
+	var cursor uint64
+	if v := c.GetHeader("Last-Event-ID"); v != "" {
+		var err error
+		if cursor, err = strconv.ParseUint(v, 10, 64); err != nil {
+			abortError(c, newAPIError(http.StatusBadRequest, "Last-Event-ID must be a reading ID"))
+			return
+		}
+	}
+
+	// Inscreve antes de ler o cursor para não perder leituras no meio
+	notify, unsubscribe := h.Telemetry.Subscribe(device.ID)
+	defer unsubscribe()
+	if c.GetHeader("Last-Event-ID") == "" {
+		err := h.db(c).Model(&models.Reading{}).Where("device_id = ?", device.ID).
+			Select("COALESCE(MAX(id), 0)").Scan(&cursor).Error
+		if err != nil {
+			abortError(c, err)
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // nginx não segura os eventos no buffer
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	recheck := time.NewTicker(streamRecheck)
+	defer recheck.Stop()
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		query := h.db(c).Where("device_id = ? AND id > ?", device.ID, cursor)
+		if metric != "" {
+			query = query.Where("metric = ?", metric)
+		}
+		var readings []models.Reading
+		if err := query.Order("id").Limit(streamBatchSize).Find(&readings).Error; err != nil {
+			// O 200 já saiu: só resta registrar e fechar; o EventSource
+			// reconecta com o Last-Event-ID e não perde nada
+			slog.ErrorContext(c.Request.Context(), "stream de leituras interrompido", "device_id", device.ID, "error", err)
+			return
+		}
+		for _, r := range readings {
+			data, _ := json.Marshal(r)
+			if _, err := fmt.Fprintf(c.Writer, "id: %d\nevent: reading\ndata: %s\n\n", r.ID, data); err != nil {
+				return // cliente desconectou
+			}
+			cursor = uint64(r.ID)
+		}
+		if len(readings) > 0 {
+			c.Writer.Flush()
+			if len(readings) == streamBatchSize {
+				continue // ainda tem leituras no banco
+			}
+		}
+
+		select {
+		case _, ok := <-notify:
+			if !ok {
+				return // réplica encerrando: o cliente reconecta em outra
+			}
+		case <-recheck.C:
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
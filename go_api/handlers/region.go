@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Regiões e Residência de Dados ---
+// Cada implantação (campus) roda com seu próprio banco e declara sua região
+// em REGION. REGION_ENDPOINTS lista as outras regiões e seus endereços.
+// Um usuário pertence a uma região e os dados dele só são gravados no banco
+// dessa região; requisições que chegam no lugar errado recebem 421 com o
+// endereço correto, em vez de gravar os dados fora da região.
+func (h *Handler) knownRegion(region string) bool {
+	_, ok := h.Config.RegionEndpoints[region]
+	return region == h.Config.Region || ok
+}
+
+// Informa em toda resposta qual região atendeu a requisição
+func (h *Handler) RegionHeaders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.Config.Region != "" {
+			c.Header("X-Served-Region", h.Config.Region)
+		}
+		c.Next()
+	}
+}
+
+// Responde 421 (Misdirected Request) com a dica de para onde ir.
+// Retorna true quando a requisição foi desviada.
+func (h *Handler) misdirectedRegion(c *gin.Context, region string) bool {
+	if h.Config.Region == "" || region == "" || region == h.Config.Region {
+		return false
+	}
+	c.Header("X-Data-Region", region)
+	details := gin.H{"region": region}
+	if url := h.Config.RegionEndpoints[region]; url != "" {
+		c.Header("X-Region-Endpoint", url)
+		details["endpoint"] = url
+	}
+	abortError(c, newAPIError(http.StatusMisdirectedRequest, "User data belongs to another region").WithDetails(details))
+	return true
+}
+
+// Define e valida a região de um usuário novo (padrão: região local)
+func (h *Handler) assignRegion(c *gin.Context, region string) (string, bool) {
+	if region == "" {
+		return h.Config.Region, true
+	}
+	if h.Config.Region != "" && !h.knownRegion(region) {
+		abortError(c, newAPIErrorf(http.StatusBadRequest, "Unknown region: %s", region))
+		return "", false
+	}
+	return region, !h.misdirectedRegion(c, region)
+}
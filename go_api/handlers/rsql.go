@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Filtros RSQL/FIQL nas listagens ---
+// Permite filtros como ?filter=name==jo*;id=gt=10 sem criar um parâmetro
+// novo para cada campo. Sintaxe suportada:
+//   ;  = E (AND)      ,  = OU (OR)      ( )  = agrupamento
+//   ==  !=  <  <=  >  >=  =lt=  =le=  =gt=  =ge=  =in=(a,b)  =out=(a,b)
+//   * no valor de == / != vira LIKE (ex: name==jo*)
+// Somente campos da lista permitida viram colunas; os valores sempre vão
+// como parâmetros (?), então não há como injetar SQL.
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindInt
+	kindFloat
+	kindTime
+)
+
+type filterField struct {
+	Column string
+	Kind   fieldKind
+}
+
+const (
+	maxFilterLength      = 1000
+	maxFilterComparisons = 20
+)
+
+var rsqlOperators = map[string]string{
+	"==": "=", "!=": "<>",
+	"<": "<", "=lt=": "<", "<=": "<=", "=le=": "<=",
+	">": ">", "=gt=": ">", ">=": ">=", "=ge=": ">=",
+	"=in=": "IN", "=out=": "NOT IN",
+}
+
+type rsqlParser struct {
+	input       string
+	pos         int
+	fields      map[string]filterField
+	args        []interface{}
+	comparisons int
+}
+
+// Converte o filtro em uma condição SQL com placeholders para o db.Where
+func parseFilter(input string, fields map[string]filterField) (string, []interface{}, error) {
+	if len(input) > maxFilterLength {
+		return "", nil, errors.New("filter is too long")
+	}
+	p := &rsqlParser{input: input, fields: fields}
+	sql, err := p.parseOr()
+	if err != nil {
+		return "", nil, err
+	}
+	if p.pos < len(p.input) {
+		return "", nil, p.errorf("unexpected %q", p.input[p.pos])
+	}
+	return sql, p.args, nil
+}
+
+func (p *rsqlParser) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("invalid filter at position %d: %s", p.pos, fmt.Sprintf(format, a...))
+}
+
+func (p *rsqlParser) peek() byte {
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+// or := and (',' and)*
+func (p *rsqlParser) parseOr() (string, error) {
+	parts := []string{}
+	for {
+		part, err := p.parseAnd()
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", nil
+}
+
+// and := term (';' term)*
+func (p *rsqlParser) parseAnd() (string, error) {
+	parts := []string{}
+	for {
+		part, err := p.parseTerm()
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+		if p.peek() != ';' {
+			break
+		}
+		p.pos++
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return "(" + strings.Join(parts, " AND ") + ")", nil
+}
+
+// term := '(' or ')' | comparison
+func (p *rsqlParser) parseTerm() (string, error) {
+	if p.peek() == '(' {
+		p.pos++
+		sql, err := p.parseOr()
+		if err != nil {
+			return "", err
+		}
+		if p.peek() != ')' {
+			return "", p.errorf("missing ')'")
+		}
+		p.pos++
+		return sql, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *rsqlParser) parseComparison() (string, error) {
+	p.comparisons++
+	if p.comparisons > maxFilterComparisons {
+		return "", errors.New("filter has too many comparisons")
+	}
+
+	// Seletor (nome do campo)
+	start := p.pos
+	for p.pos < len(p.input) && isSelectorChar(p.input[p.pos]) {
+		p.pos++
+	}
+	name := p.input[start:p.pos]
+	if name == "" {
+		return "", p.errorf("expected field name")
+	}
+	field, ok := p.fields[name]
+	if !ok {
+		return "", fmt.Errorf("invalid filter: unknown field %q", name)
+	}
+
+	op, err := p.parseOperator()
+	if err != nil {
+		return "", err
+	}
+	column := `"` + field.Column + `"`
+
+	// Lista de valores para =in= / =out=
+	if op == "=in=" || op == "=out=" {
+		values, err := p.parseValueList()
+		if err != nil {
+			return "", err
+		}
+		list := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			converted, err := convertFilterValue(field, name, v)
+			if err != nil {
+				return "", err
+			}
+			list = append(list, converted)
+		}
+		p.args = append(p.args, list)
+		return column + " " + rsqlOperators[op] + " ?", nil
+	}
+
+	raw, err := p.parseValue()
+	if err != nil {
+		return "", err
+	}
+
+	// Curinga: name==jo* vira LIKE 'jo%'
+	if field.Kind == kindString && (op == "==" || op == "!=") && strings.Contains(raw, "*") {
+		pattern := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(raw)
+		pattern = strings.ReplaceAll(pattern, "*", "%")
+		p.args = append(p.args, pattern)
+		if op == "!=" {
+			return column + ` NOT LIKE ? ESCAPE '\'`, nil
+		}
+		return column + ` LIKE ? ESCAPE '\'`, nil
+	}
+
+	converted, err := convertFilterValue(field, name, raw)
+	if err != nil {
+		return "", err
+	}
+	p.args = append(p.args, converted)
+	return column + " " + rsqlOperators[op] + " ?", nil
+}
+
+func (p *rsqlParser) parseOperator() (string, error) {
+	rest := p.input[p.pos:]
+	// Operadores FIQL (=xx=)
+	if strings.HasPrefix(rest, "=") && !strings.HasPrefix(rest, "==") {
+		end := strings.IndexByte(rest[1:], '=')
+		if end >= 0 {
+			op := rest[:end+2]
+			if _, ok := rsqlOperators[op]; ok {
+				p.pos += len(op)
+				return op, nil
+			}
+		}
+		return "", p.errorf("unknown operator")
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(rest, op) {
+			p.pos += len(op)
+			return op, nil
+		}
+	}
+	return "", p.errorf("expected operator")
+}
+
+func (p *rsqlParser) parseValueList() ([]string, error) {
+	if p.peek() != '(' {
+		return nil, p.errorf("expected '(' after list operator")
+	}
+	p.pos++
+	var values []string
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		if p.peek() == ',' {
+			p.pos++
+			continue
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("missing ')'")
+		}
+		p.pos++
+		return values, nil
+	}
+}
+
+// Valor simples ou entre aspas ('...' ou "...")
+func (p *rsqlParser) parseValue() (string, error) {
+	if q := p.peek(); q == '\'' || q == '"' {
+		end := strings.IndexByte(p.input[p.pos+1:], q)
+		if end < 0 {
+			return "", p.errorf("unterminated quoted value")
+		}
+		v := p.input[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return v, nil
+	}
+	start := p.pos
+	for p.pos < len(p.input) && !strings.ContainsRune(`;,()'"`, rune(p.input[p.pos])) {
+		p.pos++
+	}
+	if start == p.pos {
+		return "", p.errorf("expected value")
+	}
+	return p.input[start:p.pos], nil
+}
+
+func isSelectorChar(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+// Converte o texto do filtro para o tipo da coluna
+func convertFilterValue(field filterField, name, raw string) (interface{}, error) {
+	switch field.Kind {
+	case kindInt:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %s must be an integer", name)
+		}
+		return v, nil
+	case kindFloat:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %s must be a number", name)
+		}
+		return v, nil
+	case kindTime:
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		}
+		if t, err := time.Parse("2006-01-02", raw); err == nil {
+			return t, nil
+		}
+		return nil, fmt.Errorf("invalid filter: %s must be a date (YYYY-MM-DD or RFC3339)", name)
+	}
+	return raw, nil
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Busca de Usuários ---
+// GET /users/search?q=ana&limit=10&offset=0 (admin): typeahead do app.
+// Procura o termo em qualquer parte do nome, e-mail ou username; os mais
+// parecidos vêm primeiro (ver repository.Search). Cada resultado traz os
+// campos que casaram com o trecho marcado em <mark>, já escapado para HTML.
+const (
+	minSearchTerm      = 2
+	maxSearchTerm      = 100
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+)
+
+type SearchResult struct {
+	User       models.User       `json:"user"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// Marca toda ocorrência de term em value (sem diferenciar maiúsculas).
+// "" quando não há ocorrência.
+func highlight(value, term string) string {
+	lower, needle := strings.ToLower(value), strings.ToLower(term)
+	// Letras cujo minúsculo muda de tamanho desalinham os índices
+	if len(lower) != len(value) || !strings.Contains(lower, needle) {
+		return ""
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(lower, needle)
+		if i < 0 {
+			break
+		}
+		b.WriteString(html.EscapeString(value[:i]))
+		b.WriteString("<mark>" + html.EscapeString(value[i:i+len(needle)]) + "</mark>")
+		value, lower = value[i+len(needle):], lower[i+len(needle):]
+	}
+	b.WriteString(html.EscapeString(value))
+	return b.String()
+}
+
+func searchInt(c *gin.Context, name string, fallback, min int) (int, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return fallback, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min {
+		abortError(c, newAPIErrorf(http.StatusBadRequest, "%s must be an integer >= %d", name, min))
+		return 0, false
+	}
+	return n, true
+}
+
+// GET /users/search
+func (h *Handler) SearchUsers(c *gin.Context) {
+	term := strings.TrimSpace(c.Query("q"))
+	if n := utf8.RuneCountInString(term); n < minSearchTerm || n > maxSearchTerm {
+		abortError(c, newAPIError(http.StatusBadRequest, "q must have between 2 and 100 characters"))
+		return
+	}
+	limit, ok := searchInt(c, "limit", defaultSearchLimit, 1)
+	if !ok {
+		return
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	offset, ok := searchInt(c, "offset", 0, 0)
+	if !ok {
+		return
+	}
+
+	// Um a mais para saber se há próxima página
+	users, err := h.Users.Search(c.Request.Context(), term, limit+1, offset)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	hasMore := len(users) > limit
+	if hasMore {
+		users = users[:limit]
+	}
+
+	results := make([]SearchResult, len(users))
+	for i, u := range users {
+		results[i] = SearchResult{User: u, Highlights: map[string]string{}}
+		for field, value := range map[string]string{"name": u.Name, "email": u.Email, "user": u.User} {
+			if marked := highlight(value, term); marked != "" {
+				results[i].Highlights[field] = marked
+			}
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"query": term, "results": results, "has_more": hasMore})
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Sessões (/me/sessions) ---
+// Os aparelhos com login aberto na conta. Revogar uma sessão barra o
+// refresh token dela; a do próprio token funciona como logout.
+
+// GET /me/sessions
+func (h *Handler) GetSessions(c *gin.Context) {
+	sessions, err := h.Users.ListSessions(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	current := c.GetUint("sessionID")
+	for i := range sessions {
+		sessions[i].Current = current != 0 && sessions[i].ID == current
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// DELETE /me/sessions/:id
+func (h *Handler) RevokeSession(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.Users.RevokeSession(c.Request.Context(), currentUserID(c), id); err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, "Session revoked")})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// --- Estatísticas (Relatório do Projeto) ---
+
+const defaultStatsDays = 30
+
+// Requisições atendidas por esta réplica desde a subida (o mesmo
+// http_requests_total do /metrics). Somar todas as réplicas é com o Prometheus.
+type requestStats struct {
+	Since    time.Time        `json:"since"`
+	Total    int64            `json:"total"`
+	ByStatus map[string]int64 `json:"by_status"` // "2xx", "4xx", "5xx"...
+	ByRoute  map[string]int64 `json:"by_route"`  // "GET /users/:id"
+}
+
+func requestCounts() requestStats {
+	stats := requestStats{Since: startedAt.UTC(), ByStatus: map[string]int64{}, ByRoute: map[string]int64{}}
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		httpRequests.Collect(metrics)
+		close(metrics)
+	}()
+	for m := range metrics {
+		var sample dto.Metric
+		if m.Write(&sample) != nil {
+			continue
+		}
+		labels := map[string]string{}
+		for _, l := range sample.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		n := int64(sample.GetCounter().GetValue())
+		stats.Total += n
+		if status := labels["status"]; status != "" {
+			stats.ByStatus[status[:1]+"xx"] += n
+		}
+		stats.ByRoute[labels["method"]+" "+labels["route"]] += n
+	}
+	return stats
+}
+
+// GET /admin/stats?days=30 (admin): usuários (total, por papel, novos por
+// dia), dispositivos (total, online, por tipo) e requisições atendidas.
+// Usuários e dispositivos são do tenant; requisições, da réplica.
+func (h *Handler) GetAdminStats(c *gin.Context) {
+	days := defaultStatsDays
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > service.MaxStatsDays {
+			abortError(c, newAPIErrorf(http.StatusBadRequest, "days must be between 1 and %d", service.MaxStatsDays))
+			return
+		}
+		days = n
+	}
+	stats, err := h.Stats.Get(c.Request.Context(), days)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"users": stats.Users, "devices": stats.Devices, "requests": requestCounts()})
+}
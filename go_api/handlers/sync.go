@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_api/models"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// --- Sincronização Offline-First ---
+// O cliente móvel guarda uma cópia local dos usuários. Para sincronizar:
+//   - pull: pede tudo que mudou desde o último checkpoint (ID do UserEvent);
+//   - push: envia as alterações locais junto com a revisão que tinha em mãos.
+// Se a revisão do servidor mudou nesse meio tempo, há conflito: vence a
+// alteração mais recente (last-writer-wins) e o conflito fica registrado
+// para o cliente mostrar ao usuário.
+
+// Uma alteração feita offline pelo cliente
+type SyncChange struct {
+	ID           uint                   `json:"id" binding:"required"`
+	Op           string                 `json:"op" binding:"required,oneof=update delete"`
+	BaseRevision uint                   `json:"base_revision"`
+	ModifiedAt   time.Time              `json:"modified_at" binding:"required"`
+	Data         models.UpdateUserInput `json:"data"`
+}
+
+type SyncPushInput struct {
+	ClientID string       `json:"client_id" binding:"required"`
+	Changes  []SyncChange `json:"changes" binding:"required,dive"`
+}
+
+type SyncResult struct {
+	ID     uint         `json:"id"`
+	Status string       `json:"status"` // applied, conflict_client_won, conflict_server_won, not_found, forbidden, rejected
+	User   *models.User `json:"user,omitempty"`
+	Error  string       `json:"error,omitempty"`
+
+	emailChanged bool // o link de verificação sai depois do commit
+}
+
+const syncPullLimit = 500
+
+// Desfaz a transação de uma alteração rejeitada sem virar erro da requisição
+var errSyncRejected = errors.New("sync change rejected")
+
+// Momento da última alteração do usuário no servidor (último evento)
+func lastModifiedAt(tx *gorm.DB, userID uint) (time.Time, error) {
+	var last models.UserEvent
+	err := tx.Where("user_id = ?", userID).Order("id DESC").Limit(1).Find(&last).Error
+	return last.CreatedAt, err
+}
+
+// Aplica uma única alteração do cliente, resolvendo conflitos. Conflitos e
+// rejeições voltam no SyncResult; o erro é só para falhas do banco.
+func (h *Handler) applySyncChange(ctx context.Context, tx *gorm.DB, clientID string, ch SyncChange) (SyncResult, error) {
+	var current models.User
+	if err := tx.First(&current, ch.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return SyncResult{ID: ch.ID, Status: "not_found"}, nil
+		}
+		return SyncResult{}, err
+	}
+
+	status := "applied"
+	if ch.BaseRevision != current.Revision {
+		clientData, _ := json.Marshal(ch.Data)
+		serverData, _ := json.Marshal(current)
+		conflict := models.SyncConflict{
+			ClientID:       clientID,
+			UserID:         current.ID,
+			BaseRevision:   ch.BaseRevision,
+			ServerRevision: current.Revision,
+			ClientData:     clientData,
+			ServerData:     serverData,
+			Winner:         "server",
+		}
+		last, err := lastModifiedAt(tx, current.ID)
+		if err != nil {
+			return SyncResult{}, err
+		}
+		if ch.ModifiedAt.After(last) {
+			conflict.Winner = "client"
+		}
+		if err := tx.Create(&conflict).Error; err != nil {
+			return SyncResult{}, err
+		}
+
+		if conflict.Winner == "server" {
+			return SyncResult{ID: ch.ID, Status: "conflict_server_won", User: &current}, nil
+		}
+		status = "conflict_client_won"
+	}
+
+	users := h.Users.WithTx(tx)
+	previous := current.Email
+	var err error
+	if ch.Op == "delete" {
+		err = users.Remove(ctx, &current)
+		current = models.User{ID: ch.ID}
+	} else {
+		err = users.Apply(ctx, &current, ch.Data)
+	}
+	// Outra escrita passou entre a leitura e o update: o servidor fica com a
+	// versão dele, como num conflito de revisão
+	var stale *service.RevisionConflictError
+	if errors.As(err, &stale) {
+		return SyncResult{ID: ch.ID, Status: "conflict_server_won", User: &stale.Current}, nil
+	}
+	if errors.Is(err, service.ErrUserConflict) {
+		return SyncResult{ID: ch.ID, Status: "rejected", Error: "User or Email already exists"}, nil
+	}
+	if err != nil {
+		return SyncResult{}, err
+	}
+	return SyncResult{ID: ch.ID, Status: status, User: &current, emailChanged: ch.Op != "delete" && current.Email != previous}, nil
+}
+
+// --- Handlers ---
+
+// GET /sync/pull?checkpoint=<id do último evento visto>
+func (h *Handler) SyncPull(c *gin.Context) {
+	checkpoint, err := strconv.ParseUint(c.DefaultQuery("checkpoint", "0"), 10, 64)
+	if err != nil {
+		abortError(c, newAPIError(http.StatusBadRequest, "checkpoint must be a positive integer"))
+		return
+	}
+
+	// Usuário comum sincroniza só o próprio registro; admin recebe todos
+	query := h.db(c).Where("id > ?", checkpoint)
+	if !isAdmin(c) {
+		query = query.Where("user_id = ?", currentUserID(c))
+	}
+	var events []models.UserEvent
+	if err := query.Order("id").Limit(syncPullLimit).Find(&events).Error; err != nil {
+		abortError(c, err)
+		return
+	}
+
+	next := checkpoint
+	ids := make([]uint, 0, len(events))
+	seen := make(map[uint]bool)
+	for _, e := range events {
+		next = uint64(e.ID)
+		if !seen[e.UserID] {
+			seen[e.UserID] = true
+			ids = append(ids, e.UserID)
+		}
+	}
+
+	// Estado atual de quem mudou; quem não está mais na tabela foi removido
+	var users []models.User
+	if len(ids) > 0 {
+		if err := h.db(c).Where("id IN ?", ids).Find(&users).Error; err != nil {
+			abortError(c, err)
+			return
+		}
+	}
+	deleted := make([]uint, 0)
+	for _, u := range users {
+		delete(seen, u.ID)
+	}
+	for _, id := range ids {
+		if seen[id] {
+			deleted = append(deleted, id)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"checkpoint": next,
+		"has_more":   len(events) == syncPullLimit,
+		"updated":    users,
+		"deleted":    deleted,
+	})
+}
+
+// --- Delta Sync (GET /sync) ---
+// Para o app que guarda usuários e dispositivos num SQLite local: o que foi
+// criado, alterado ou removido a partir de ?since=, pelo updated_at (a
+// remoção também conta, e os removidos ficam como tombstones). since é um
+// instante RFC3339 ou o cursor da resposta anterior; sem since, vem tudo.
+// Cada página traz até syncPullLimit de cada tipo; com has_more, o cliente
+// chama de novo com o cursor.
+
+// Último (updated_at, id) entregue em uma das listas
+type syncPosition struct {
+	At time.Time `json:"t"`
+	ID uint      `json:"id"`
+}
+
+type syncCursor struct {
+	Users   syncPosition `json:"u"`
+	Devices syncPosition `json:"d"`
+}
+
+func (c syncCursor) encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func parseSyncSince(v string) (syncCursor, error) {
+	var cursor syncCursor
+	if v == "" {
+		return cursor, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return syncCursor{Users: syncPosition{At: t}, Devices: syncPosition{At: t}}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return cursor, err
+	}
+	return cursor, json.Unmarshal(raw, &cursor)
+}
+
+type syncChanges[T any] struct {
+	Updated []T          `json:"updated"`
+	Deleted []models.Ref `json:"deleted"` // IDs (no modo PUBLIC_IDS=uuid, UUIDs)
+}
+
+func publicRef(id uint, uuid string) models.Ref {
+	if models.PublicUUIDs {
+		return models.Ref{UUID: uuid}
+	}
+	return models.Ref{ID: id}
+}
+
+// GET /sync?since=<RFC3339 ou cursor>
+func (h *Handler) SyncDelta(c *gin.Context) {
+	cursor, err := parseSyncSince(c.Query("since"))
+	if err != nil {
+		abortError(c, newAPIError(http.StatusBadRequest, "since must be an RFC3339 timestamp or a cursor"))
+		return
+	}
+	// Usuário comum sincroniza o próprio registro e os próprios
+	// dispositivos; admin, os do tenant
+	var owner uint
+	if !isAdmin(c) {
+		owner = currentUserID(c)
+	}
+
+	pos := cursor.Users
+	query := h.db(c).Unscoped().Where("updated_at > ? OR (updated_at = ? AND id > ?)", pos.At, pos.At, pos.ID)
+	if owner != 0 {
+		query = query.Where("id = ?", owner)
+	}
+	var users []models.User
+	if err := query.Order("updated_at, id").Limit(syncPullLimit + 1).Find(&users).Error; err != nil {
+		abortError(c, err)
+		return
+	}
+	pos = cursor.Devices
+	devices, err := h.Devices.Changed(c.Request.Context(), owner, pos.At, pos.ID, syncPullLimit+1)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	// A linha a mais só diz que há próxima página
+	hasMore := len(users) > syncPullLimit || len(devices) > syncPullLimit
+	users, devices = users[:min(len(users), syncPullLimit)], devices[:min(len(devices), syncPullLimit)]
+
+	userChanges := syncChanges[models.User]{Updated: []models.User{}, Deleted: []models.Ref{}}
+	for _, u := range users {
+		cursor.Users = syncPosition{At: u.UpdatedAt, ID: u.ID}
+		if u.DeletedAt.Valid {
+			userChanges.Deleted = append(userChanges.Deleted, publicRef(u.ID, u.UUID))
+		} else {
+			userChanges.Updated = append(userChanges.Updated, u)
+		}
+	}
+	deviceChanges := syncChanges[models.Device]{Updated: []models.Device{}, Deleted: []models.Ref{}}
+	for _, d := range devices {
+		cursor.Devices = syncPosition{At: d.UpdatedAt, ID: d.ID}
+		if d.DeletedAt.Valid {
+			deviceChanges.Deleted = append(deviceChanges.Deleted, publicRef(d.ID, d.UUID))
+		} else {
+			deviceChanges.Updated = append(deviceChanges.Updated, d)
+		}
+	}
+	respond(c, http.StatusOK, gin.H{
+		"users":    userChanges,
+		"devices":  deviceChanges,
+		"cursor":   cursor.encode(),
+		"has_more": hasMore,
+	})
+}
+
+// POST /sync/push
+func (h *Handler) SyncPush(c *gin.Context) {
+	var input SyncPushInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	// Cada alteração tem sua própria transação: um conflito ou rejeição em
+	// um registro não desfaz as demais alterações do lote. Falha do banco
+	// encerra o lote com 500; o cliente reenvia o que ficou sem resultado.
+	results := make([]SyncResult, 0, len(input.Changes))
+	for _, ch := range input.Changes {
+		// Mesmas regras do REST: remover usuários é exclusivo do admin
+		if !canAccessUser(c, ch.ID) || (ch.Op == "delete" && !isAdmin(c)) {
+			results = append(results, SyncResult{ID: ch.ID, Status: "forbidden"})
+			continue
+		}
+		var result SyncResult
+		err := h.db(c).Transaction(func(tx *gorm.DB) error {
+			var err error
+			if result, err = h.applySyncChange(c.Request.Context(), tx, input.ClientID, ch); err != nil {
+				return err
+			}
+			if result.Status == "rejected" {
+				return errSyncRejected
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errSyncRejected) {
+			abortError(c, err)
+			return
+		}
+		if result.emailChanged {
+			h.Users.NotifyEmailChanged(c.Request.Context(), *result.User)
+		}
+		results = append(results, result)
+	}
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// GET /sync/conflicts?client_id=
+func (h *Handler) GetSyncConflicts(c *gin.Context) {
+	clientID := c.Query("client_id")
+	if clientID == "" {
+		abortError(c, newAPIError(http.StatusBadRequest, "client_id is required"))
+		return
+	}
+	query := h.db(c).Where("client_id = ?", clientID)
+	if !isAdmin(c) {
+		query = query.Where("user_id = ?", currentUserID(c))
+	}
+	var conflicts []models.SyncConflict
+	if err := query.Order("id DESC").Limit(100).Find(&conflicts).Error; err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, conflicts)
+}
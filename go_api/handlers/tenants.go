@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"go_api/models"
+	"go_api/repository"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Tenants (Multi-tenancy) ---
+// Cada organização enxerga só os próprios usuários, dispositivos, eventos,
+// auditoria e webhooks. O tenant vem do cabeçalho X-Tenant (slug) ou do
+// subdomínio de TENANT_DOMAIN; nas rotas autenticadas, do token, e o
+// cabeçalho só pode apontar para outro tenant se quem chama é o admin da
+// plataforma (admin do tenant padrão). O filtro em si é o
+// repository.ScopeTenants, pelo contexto da requisição.
+
+// Slug pedido na requisição; X-Tenant vence o subdomínio
+func (h *Handler) requestedTenant(c *gin.Context) string {
+	if slug := c.GetHeader("X-Tenant"); slug != "" {
+		return strings.ToLower(slug)
+	}
+	domain := h.Config.TenantDomain
+	if domain == "" {
+		return ""
+	}
+	host := strings.ToLower(c.Request.Host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	slug, ok := strings.CutSuffix(host, "."+domain)
+	if !ok || strings.Contains(slug, ".") {
+		return ""
+	}
+	return slug
+}
+
+// Middleware: tenant pedido -> contexto. Sem pedido, as rotas públicas não
+// filtram nada (o cadastro cai no tenant padrão) e as autenticadas usam o
+// tenant do token.
+func (h *Handler) ResolveTenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		slug := h.requestedTenant(c)
+		if slug == "" {
+			c.Next()
+			return
+		}
+		tenant, err := h.Tenants.BySlug(c.Request.Context(), slug)
+		if err != nil {
+			abortError(c, err)
+			return
+		}
+		c.Set("tenantID", tenant.ID)
+		c.Request = c.Request.WithContext(repository.WithTenant(c.Request.Context(), tenant.ID))
+		c.Next()
+	}
+}
+
+// Autenticação: o usuário só age no próprio tenant; o admin da plataforma
+// pode entrar em outro pelo X-Tenant. false = requisição já abortada.
+func enterTenant(c *gin.Context, home uint, role string) bool {
+	c.Set("homeTenant", home)
+	requested, ok := c.Get("tenantID")
+	switch {
+	case !ok:
+		c.Set("tenantID", home)
+		c.Request = c.Request.WithContext(repository.WithTenant(c.Request.Context(), home))
+	case requested.(uint) != home && (home != models.DefaultTenantID || role != models.RoleAdmin):
+		abortError(c, newAPIError(http.StatusForbidden, "Credentials belong to another tenant"))
+		return false
+	}
+	return true
+}
+
+// Admin do tenant padrão
+func isPlatformAdmin(c *gin.Context) bool {
+	return isAdmin(c) && c.GetUint("homeTenant") == models.DefaultTenantID
+}
+
+// Admin da plataforma: o único que cria e lista tenants
+func PlatformAdminOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isPlatformAdmin(c) {
+			abortError(c, newAPIError(http.StatusForbidden, "Platform admin role required"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// Rotas /users/:id/...: depois do SelfOrAdmin, garante que o admin não
+// alcança um usuário de outro tenant pelas tabelas que não têm tenant_id
+// (atividades, consentimentos, chaves de API)
+func (h *Handler) UserInTenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		if id == currentUserID(c) {
+			c.Next()
+			return
+		}
+		var count int64
+		if err := h.db(c).Unscoped().Model(&models.User{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			abortError(c, err)
+			return
+		}
+		if count == 0 {
+			abortError(c, service.ErrUserNotFound)
+			return
+		}
+		c.Next()
+	}
+}
+
+// --- Handlers ---
+
+// POST /tenants
+func (h *Handler) CreateTenant(c *gin.Context) {
+	var input models.TenantInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	tenant, err := h.Tenants.Create(c.Request.Context(), input)
+	if errors.Is(err, service.ErrTenantSlugTaken) {
+		abortError(c, newAPIError(http.StatusConflict, "Tenant already exists").
+			WithDetails(gin.H{"slug": tr("is already in use")}))
+		return
+	}
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, tenant)
+}
+
+// GET /tenants
+func (h *Handler) GetTenants(c *gin.Context) {
+	tenants, err := h.Tenants.List(c.Request.Context())
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tenants)
+}
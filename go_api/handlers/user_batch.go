@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"go_api/models"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Cadastro de Usuários em Lote ---
+// POST /users/batch (admin): cada item é validado sozinho e recebe seu
+// próprio status. No modo "atomic" (padrão) o lote inteiro roda numa
+// transação e nada é gravado se algum item falhar; no "partial" os itens
+// válidos são gravados mesmo com falhas nos demais.
+
+type CreateUsersBatchInput struct {
+	Mode  string                   `json:"mode" binding:"omitempty,oneof=atomic partial"`
+	Users []models.CreateUserInput `json:"users" binding:"required"`
+}
+
+type UserBatchResult struct {
+	Index   int          `json:"index"`
+	Status  string       `json:"status"` // created, conflict, invalid, skipped, failed
+	User    *models.User `json:"user,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Details gin.H        `json:"details,omitempty"`
+}
+
+// Desfaz a transação do modo atômico
+var errBatchRejected = errors.New("batch rejected")
+
+// Região de um item do lote: mesmas regras do assignRegion, mas como erro
+// do item (no idioma da requisição) em vez de resposta da requisição
+func (h *Handler) batchRegion(c *gin.Context, region string) (string, string) {
+	if region == "" {
+		return h.Config.Region, ""
+	}
+	if h.Config.Region != "" && !h.knownRegion(region) {
+		return "", localize(c, "Unknown region: %s", region)
+	}
+	if h.Config.Region != "" && region != h.Config.Region {
+		return "", localize(c, "User data belongs to another region")
+	}
+	return region, ""
+}
+
+// Regras do POST /users para um item; devolve o item com a região
+// definida, ou o resultado "invalid"
+func (h *Handler) checkBatchUser(c *gin.Context, in models.CreateUserInput) (models.CreateUserInput, *UserBatchResult) {
+	if fields := fieldErrors(in); fields != nil {
+		return in, &UserBatchResult{Status: "invalid", Error: localize(c, "Validation failed"), Details: localizeDetails(language(c), fields)}
+	}
+	region, msg := h.batchRegion(c, in.Region)
+	if msg != "" {
+		return in, &UserBatchResult{Status: "invalid", Error: msg}
+	}
+	in.Region = region
+	return in, nil
+}
+
+// Status de um item que passou pelo service
+func batchOutcome(c *gin.Context, r service.BatchResult) UserBatchResult {
+	var conflict *service.ConflictError
+	switch {
+	case r.Skipped:
+		return UserBatchResult{Status: "skipped"}
+	case errors.As(r.Err, &conflict):
+		return UserBatchResult{Status: "conflict", Error: localize(c, "%s already exists", conflict.Field), Details: gin.H{conflict.Field: localize(c, "is already in use")}}
+	case r.Err != nil:
+		slog.ErrorContext(c.Request.Context(), "cadastro em lote: item falhou", "error", r.Err)
+		return UserBatchResult{Status: "failed", Error: localize(c, "Could not create user")}
+	}
+	user := r.User
+	return UserBatchResult{Status: "created", User: &user}
+}
+
+// POST /users/batch
+func (h *Handler) CreateUsersBatch(c *gin.Context) {
+	var input CreateUsersBatchInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	if len(input.Users) == 0 || len(input.Users) > service.MaxBatchUsers {
+		abortError(c, newAPIErrorf(http.StatusBadRequest, "A batch must contain between 1 and %d users", service.MaxBatchUsers))
+		return
+	}
+	atomic := input.Mode != "partial"
+
+	// Validação item a item; só os válidos seguem para o service
+	results := make([]UserBatchResult, len(input.Users))
+	valid := make([]models.CreateUserInput, 0, len(input.Users))
+	positions := make([]int, 0, len(input.Users))
+	for i, in := range input.Users {
+		in, invalid := h.checkBatchUser(c, in)
+		if invalid != nil {
+			results[i] = *invalid
+			results[i].Index = i
+			continue
+		}
+		results[i].Index = i
+		valid = append(valid, in)
+		positions = append(positions, i)
+	}
+	if atomic && len(valid) < len(input.Users) {
+		for _, i := range positions {
+			results[i].Status = "skipped"
+		}
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"mode": "atomic", "created": 0, "results": results})
+		return
+	}
+
+	var outcome []service.BatchResult
+	register := func(users *service.UserService) error {
+		var err error
+		outcome, err = users.RegisterBatch(c.Request.Context(), valid, atomic)
+		if err != nil {
+			return err
+		}
+		for _, r := range outcome {
+			if atomic && (r.Err != nil || r.Skipped) {
+				return errBatchRejected
+			}
+		}
+		return nil
+	}
+	var err error
+	if atomic {
+		err = h.Users.InTx(c.Request.Context(), register)
+	} else {
+		err = register(h.Users)
+	}
+	if err != nil && !errors.Is(err, errBatchRejected) {
+		abortError(c, err)
+		return
+	}
+
+	var created []models.User
+	for k, r := range outcome {
+		res := &results[positions[k]]
+		if err != nil && r.Err == nil {
+			// Gravado, mas desfeito com o resto do lote
+			res.Status = "skipped"
+			continue
+		}
+		*res = batchOutcome(c, r)
+		res.Index = positions[k]
+		if res.User != nil {
+			created = append(created, *res.User)
+		}
+	}
+	h.Users.NotifyRegistered(c.Request.Context(), created...)
+
+	mode, status := "partial", http.StatusOK
+	if atomic {
+		mode, status = "atomic", http.StatusCreated
+		if err != nil {
+			status = http.StatusUnprocessableEntity
+		}
+	}
+	c.JSON(status, gin.H{"mode": mode, "created": len(created), "results": results})
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go_api/models"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Importação de Usuários (CSV) ---
+// POST /users/import (admin, multipart com o campo "file"): o CSV da
+// secretaria, com cabeçalho e as colunas name, email, user, password e
+// (opcional) region, em qualquer ordem. Cada linha é validada como no
+// POST /users; as válidas são gravadas em lotes de MaxBatchUsers (modo
+// partial), então uma linha ruim não impede as outras. Reimportar o mesmo
+// arquivo é seguro: quem já existe volta como conflito.
+
+const maxImportBytes = 20 << 20
+
+var importColumns = []string{"name", "email", "user", "password", "region"}
+
+type ImportRowError struct {
+	Line    int    `json:"line"`   // linha do arquivo, contando o cabeçalho
+	Status  string `json:"status"` // conflict, invalid ou failed
+	Error   string `json:"error"`
+	Details gin.H  `json:"details,omitempty"`
+}
+
+type ImportReport struct {
+	Rows    int              `json:"rows"`
+	Created int              `json:"created"`
+	Skipped int              `json:"skipped"`
+	Errors  []ImportRowError `json:"errors"`
+}
+
+// Linha já lida, esperando o próximo lote
+type importRow struct {
+	line  int
+	input models.CreateUserInput
+}
+
+// Posição de cada coluna conhecida no cabeçalho
+func importHeader(header []string) (map[string]int, error) {
+	index := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff") // BOM do Excel
+		}
+		index[name] = i
+	}
+	var missing []string
+	for _, name := range importColumns[:4] {
+		if _, ok := index[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("CSV header is missing columns: %s", strings.Join(missing, ", "))
+	}
+	return index, nil
+}
+
+func importInput(record []string, index map[string]int) models.CreateUserInput {
+	field := func(name string) string {
+		if i, ok := index[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	return models.CreateUserInput{
+		Name:     field("name"),
+		Email:    field("email"),
+		User:     field("user"),
+		Password: record[index["password"]], // espaços fazem parte da senha
+		Region:   field("region"),
+	}
+}
+
+// POST /users/import
+func (h *Handler) ImportUsers(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			abortError(c, newAPIErrorf(http.StatusRequestEntityTooLarge, "CSV file exceeds %d bytes", maxImportBytes))
+			return
+		}
+		abortError(c, newAPIError(http.StatusBadRequest, `Send the CSV as multipart/form-data in the "file" field`))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	defer file.Close()
+
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	first, err := r.Read()
+	if err != nil {
+		abortError(c, newAPIError(http.StatusBadRequest, "CSV file is empty or malformed"))
+		return
+	}
+	index, err := importHeader(first)
+	if err != nil {
+		abortError(c, newAPIError(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	report := ImportReport{Errors: []ImportRowError{}}
+	pending := make([]importRow, 0, service.MaxBatchUsers)
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		inputs := make([]models.CreateUserInput, len(pending))
+		for i, row := range pending {
+			inputs[i] = row.input
+		}
+		outcome, err := h.Users.RegisterBatch(c.Request.Context(), inputs, false)
+		if err != nil {
+			return err
+		}
+		var created []models.User
+		for i, r := range outcome {
+			res := batchOutcome(c, r)
+			if res.User != nil {
+				created = append(created, *res.User)
+				continue
+			}
+			report.Errors = append(report.Errors, ImportRowError{Line: pending[i].line, Status: res.Status, Error: res.Error, Details: res.Details})
+		}
+		report.Created += len(created)
+		h.Users.NotifyRegistered(c.Request.Context(), created...)
+		pending = pending[:0]
+		return nil
+	}
+
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Aspas quebradas etc.: daqui em diante não dá para confiar nas linhas
+			details := gin.H{"report": report}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				details["line"] = parseErr.StartLine
+			}
+			abortError(c, newAPIErrorf(http.StatusBadRequest, "Malformed CSV: %s", err).WithDetails(details))
+			return
+		}
+		line, _ := r.FieldPos(0)
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue // linha em branco
+		}
+		report.Rows++
+		if len(record) < len(first) {
+			report.Errors = append(report.Errors, ImportRowError{Line: line, Status: "invalid",
+				Error: localize(c, "Expected %d columns, got %d", len(first), len(record))})
+			continue
+		}
+		input, invalid := h.checkBatchUser(c, importInput(record, index))
+		if invalid != nil {
+			report.Errors = append(report.Errors, ImportRowError{Line: line, Status: invalid.Status, Error: invalid.Error, Details: invalid.Details})
+			continue
+		}
+		if pending = append(pending, importRow{line: line, input: input}); len(pending) == service.MaxBatchUsers {
+			if err := flush(); err != nil {
+				abortError(c, err)
+				return
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		abortError(c, err)
+		return
+	}
+	report.Skipped = report.Rows - report.Created
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go_api/models"
+	"go_api/repository"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// --- Usuários (CRUD) ---
+
+// Campos que podem ser usados em ?filter= (RSQL) e ?sort=
+var userFilterFields = map[string]filterField{
+	"id":         {"id", kindInt},
+	"name":       {"name", kindString},
+	"email":      {"email", kindString},
+	"user":       {"user", kindString},
+	"revision":   {"revision", kindInt},
+	"created_at": {"created_at", kindTime},
+	"updated_at": {"updated_at", kindTime},
+}
+
+// Campo do JSON do PATCH -> coluna. Papel, região e senha têm rotas próprias.
+var patchableUserFields = map[string]string{"name": "name", "email": "email", "user": "user"}
+
+// ?include_deleted=true: só admin enxerga os usuários removidos
+func includeDeleted(c *gin.Context) (bool, bool) {
+	if c.Query("include_deleted") != "true" {
+		return false, true
+	}
+	if !isAdmin(c) {
+		abortError(c, newAPIError(http.StatusForbidden, "Admin role required"))
+		return false, false
+	}
+	return true, true
+}
+
+func (h *Handler) CreateUser(c *gin.Context) {
+	var input models.CreateUserInput
+	// Valida o JSON recebido
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	region, ok := h.assignRegion(c, input.Region)
+	if !ok {
+		return
+	}
+	input.Region = region
+
+	user, err := h.Users.Register(c.Request.Context(), input)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	respond(c, http.StatusCreated, user)
+}
+
+func (h *Handler) GetUsers(c *gin.Context) {
+	var q repository.UserQuery
+	if f := c.Query("filter"); f != "" {
+		cond, args, err := parseFilter(f, userFilterFields)
+		if err != nil {
+			abortError(c, newAPIError(http.StatusBadRequest, err.Error()))
+			return
+		}
+		q.Where, q.Args = cond, args
+	}
+
+	page, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	afterID, keyset, ok := parseAfterID(c)
+	if !ok {
+		return
+	}
+	if keyset {
+		// O cursor é o ID: a ordem tem que ser a dele
+		if c.Query("page") != "" || c.Query("sort") != "" {
+			abortError(c, newAPIError(http.StatusBadRequest, "after_id cannot be combined with page or sort"))
+			return
+		}
+		if q.Where != "" {
+			q.Where = "(" + q.Where + ") AND "
+		}
+		q.Where += "id > ?"
+		q.Args = append(q.Args, afterID)
+		q.Order, q.Limit = []string{"id"}, page.PerPage+1
+	} else {
+		q.Order, ok = sortClauses(c, userFilterFields, "id")
+		if !ok {
+			return
+		}
+		q.Limit, q.Offset = page.PerPage, page.Offset()
+	}
+	if q.IncludeDeleted, ok = includeDeleted(c); !ok {
+		return
+	}
+	if q.Times, ok = parseTimeFilter(c); !ok {
+		return
+	}
+	fields, columns, ok := parseFields(c, userSparseFields)
+	if !ok {
+		return
+	}
+	q.Select = columns
+
+	// Listagem aceita um atraso pequeno: pode vir de uma réplica de leitura
+	users, total, err := h.Users.List(repository.PreferReplica(c.Request.Context()), q)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	if keyset {
+		c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+		body := keysetBody(users, page.PerPage, func(u models.User) uint { return u.ID })
+		if len(fields) > 0 {
+			if body.Data, err = sparse(body.Data, fields); err != nil {
+				abortError(c, err)
+				return
+			}
+		}
+		respond(c, http.StatusOK, body)
+		return
+	}
+	setPaginationHeaders(c, page, total)
+	respondFields(c, http.StatusOK, users, fields)
+}
+
+func (h *Handler) GetUser(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	withDeleted, ok := includeDeleted(c)
+	if !ok {
+		return
+	}
+	fields, _, ok := parseFields(c, userSparseFields)
+	if !ok {
+		return
+	}
+
+	var user models.User
+	var err error
+	ctx := repository.PreferReplica(c.Request.Context())
+	if withDeleted {
+		user, err = h.Users.GetAny(ctx, id)
+	} else {
+		user, err = h.Users.Get(ctx, id)
+	}
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	if notModified(c, user) {
+		c.Header("ETag", userETag(user))
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Header("ETag", userETag(user))
+	respondFields(c, http.StatusOK, user, fields)
+}
+
+func (h *Handler) UpdateUser(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	var input models.UpdateUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	ifRevision, ok := ifMatchRevision(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.Users.Update(c.Request.Context(), id, input, ifRevision)
+	if err != nil {
+		abortUserError(c, err)
+		return
+	}
+	writeUser(c, http.StatusOK, user)
+}
+
+// --- PATCH /users/:id (JSON Merge Patch, RFC 7396) ---
+// Só os campos presentes no corpo são alterados. Diferente do PUT, um campo
+// enviado com valor vazio não é ignorado: é aplicado (e validado) como veio.
+// null remove o campo, o que nenhum dos campos editáveis hoje permite.
+func (h *Handler) PatchUser(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	body, err := c.GetRawData()
+	if err != nil {
+		bindError(c, err)
+		return
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		abortError(c, newAPIError(http.StatusBadRequest, "Body must be a JSON object"))
+		return
+	}
+	for name, raw := range fields {
+		if _, ok := patchableUserFields[name]; !ok {
+			abortError(c, newAPIErrorf(http.StatusBadRequest, "Field cannot be patched: %s", name))
+			return
+		}
+		if string(raw) == "null" {
+			abortError(c, newAPIErrorf(http.StatusBadRequest, "Field cannot be removed: %s", name))
+			return
+		}
+	}
+
+	var input models.PatchUserInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		abortError(c, newAPIErrorf(http.StatusBadRequest, "Invalid JSON: %s", err))
+		return
+	}
+	if err := binding.Validator.ValidateStruct(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	ifRevision, ok := ifMatchRevision(c)
+	if !ok {
+		return
+	}
+
+	// Patch vazio não grava nada, mas o If-Match continua valendo
+	var user models.User
+	if len(fields) == 0 {
+		user, err = h.Users.Get(c.Request.Context(), id)
+		if err == nil && ifRevision != 0 && user.Revision != ifRevision {
+			err = &service.RevisionConflictError{Current: user}
+		}
+	} else {
+		user, err = h.Users.Patch(c.Request.Context(), id, input, ifRevision)
+	}
+	if err != nil {
+		abortUserError(c, err)
+		return
+	}
+	writeUser(c, http.StatusOK, user)
+}
+
+// --- Troca de Senha ---
+// PUT /users/:id/password. Quem troca a própria senha precisa confirmar a
+// atual; um admin pode redefinir a senha de outro usuário sem ela.
+func (h *Handler) ChangePassword(c *gin.Context) {
+	if impersonating(c) {
+		abortError(c, newAPIError(http.StatusForbidden, "Impersonation tokens cannot change the password"))
+		return
+	}
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	var input models.ChangePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	if err := h.Users.ChangePassword(c.Request.Context(), id, currentUserID(c), input); err != nil {
+		abortError(c, err)
+		return
+	}
+	respond(c, http.StatusOK, gin.H{"message": localize(c, "Password changed")})
+}
+
+// PUT /users/:id/role (somente admin)
+func (h *Handler) UpdateUserRole(c *gin.Context) {
+	var input models.RoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, err := h.Users.ChangeRole(c.Request.Context(), id, input.Role)
+	if errors.Is(err, service.ErrInvalidRole) {
+		abortError(c, newAPIErrorf(http.StatusBadRequest, "Invalid role: %s", input.Role))
+		return
+	}
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	respond(c, http.StatusOK, user)
+}
+
+func (h *Handler) DeleteUser(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.Users.Delete(c.Request.Context(), id); err != nil {
+		abortError(c, err)
+		return
+	}
+	respond(c, http.StatusOK, gin.H{"message": localize(c, "User deleted")})
+}
+
+// POST /users/:id/restore (somente admin): desfaz a remoção
+func (h *Handler) RestoreUser(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	user, err := h.Users.Restore(c.Request.Context(), id)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	respond(c, http.StatusOK, user)
+}
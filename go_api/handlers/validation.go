@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// --- Erros de Validação por Campo ---
+// Em vez do texto cru do validator ("Key: 'CreateUserInput.Email' Error:..."),
+// a resposta diz qual campo do JSON falhou e por quê:
+//
+//	{"error": {"code": "validation_failed", "message": "Validation failed",
+//	           "details": {"email": "must be a valid email address"}}}
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// Slug de tenant: vira subdomínio, então só minúsculas, dígitos e hífen
+// (sem hífen nas pontas)
+var slugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	// Nomes dos campos como aparecem no JSON
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return usernamePattern.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+		return slugPattern.MatchString(fl.Field().String())
+	})
+}
+
+// Traduzida só na resposta (Accept-Language)
+func fieldMessage(fe validator.FieldError) localText {
+	switch fe.Tag() {
+	case "required":
+		return tr("is required")
+	case "email":
+		return tr("must be a valid email address")
+	case "min":
+		return tr("must be at least %s characters", fe.Param())
+	case "max":
+		return tr("must be at most %s characters", fe.Param())
+	case "username":
+		return tr("may only contain letters, digits, '.', '_' and '-'")
+	case "slug":
+		return tr("may only contain lowercase letters, digits and '-'")
+	case "oneof":
+		return tr("must be one of: %s", strings.ReplaceAll(fe.Param(), " ", ", "))
+	}
+	return tr("is invalid")
+}
+
+// "SyncPushInput.changes[0].id" -> "changes[0].id"
+func fieldPath(prefix string, fe validator.FieldError) string {
+	_, path, _ := strings.Cut(fe.Namespace(), ".")
+	return prefix + path
+}
+
+func collectFieldErrors(fields gin.H, prefix string, err error) bool {
+	var ve validator.ValidationErrors
+	if errors.As(err, &ve) {
+		for _, fe := range ve {
+			fields[fieldPath(prefix, fe)] = fieldMessage(fe)
+		}
+		return true
+	}
+	// Corpo que é uma lista: um erro por item
+	var se binding.SliceValidationError
+	if errors.As(err, &se) {
+		for i, item := range se {
+			if item != nil && !collectFieldErrors(fields, fmt.Sprintf("[%d].", i), item) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Erro 400 para o ShouldBindJSON/ValidateStruct. JSON malformado
+// não tem campo, então mantém a mensagem do decoder.
+func bindError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		abortError(c, err)
+		return
+	}
+	fields := gin.H{}
+	if !collectFieldErrors(fields, "", err) {
+		abortError(c, newAPIError(http.StatusBadRequest, err.Error()))
+		return
+	}
+	abortError(c, newAPIError(http.StatusBadRequest, "Validation failed").
+		WithCode("validation_failed").
+		WithDetails(fields))
+}
+
+// Mesmas regras para entradas que não passam pelo bind (lote, GraphQL): o
+// erro de cada campo, ainda sem tradução, ou nil se a entrada é válida. Um
+// erro que não é de campo vem na chave "".
+func fieldErrors(input interface{}) gin.H {
+	err := binding.Validator.ValidateStruct(input)
+	if err == nil {
+		return nil
+	}
+	fields := gin.H{}
+	if !collectFieldErrors(fields, "", err) {
+		return gin.H{"": err.Error()}
+	}
+	return fields
+}
+
+// fieldErrors para o gRPC, em inglês
+func FieldErrors(input interface{}) map[string]string {
+	fields := fieldErrors(input)
+	if fields == nil {
+		return nil
+	}
+	out := make(map[string]string, len(fields))
+	for name, msg := range fields {
+		out[name] = fmt.Sprint(msg)
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Versões da API ---
+// As rotas ficam em /api/v<N>. Os caminhos sem prefixo (os de antes do
+// versionamento, gravados no firmware dos dispositivos) continuam como
+// apelidos, respondendo com Deprecation, Sunset (LEGACY_ROUTES_SUNSET) e o
+// Link do caminho novo. Neles o cliente pode escolher a versão mandando
+// API-Version; sem o cabeçalho, vale a mais antiga, que nunca muda. Toda
+// resposta diz qual versão atendeu em API-Version. Uma versão nova entra
+// em APIVersions, e o handler que mudou de formato pergunta APIVersion(c).
+
+// Versões servidas, da mais antiga para a mais nova
+var APIVersions = []int{1}
+
+const apiVersionKey = "api_version"
+
+// Dia em que os caminhos sem prefixo passaram a ser só apelidos da v1
+var legacyDeprecatedAt = time.Date(2026, time.October, 14, 0, 0, 0, 0, time.UTC)
+
+func APIPrefix(version int) string {
+	return "/api/v" + strconv.Itoa(version)
+}
+
+// Versão que está atendendo a requisição
+func APIVersion(c *gin.Context) int {
+	if v, ok := c.Get(apiVersionKey); ok {
+		return v.(int)
+	}
+	return APIVersions[0]
+}
+
+// Caminho da rota sem o /api/v<N> (as tabelas por rota valem para todas as versões)
+func unversionedPath(path string) string {
+	for _, v := range APIVersions {
+		if rest, ok := strings.CutPrefix(path, APIPrefix(v)); ok && (rest == "" || rest[0] == '/') {
+			return rest
+		}
+	}
+	return path
+}
+
+// "1" ou "v1", se for uma das APIVersions
+func parseAPIVersion(value string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(value)), "v"))
+	return n, err == nil && slices.Contains(APIVersions, n)
+}
+
+func setAPIVersion(c *gin.Context, version int) {
+	c.Set(apiVersionKey, version)
+	c.Header("API-Version", strconv.Itoa(version))
+}
+
+// Rotas de /api/v<N>: a versão vem do caminho
+func (h *Handler) VersionedRoutes(version int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setAPIVersion(c, version)
+		c.Next()
+	}
+}
+
+// Caminhos sem prefixo: versão pelo API-Version (padrão: a mais antiga) e
+// os avisos de descontinuação
+func (h *Handler) LegacyRoutes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version := APIVersions[0]
+		if v := c.GetHeader("API-Version"); v != "" {
+			var ok bool
+			if version, ok = parseAPIVersion(v); !ok {
+				supported := make([]string, len(APIVersions))
+				for i, s := range APIVersions {
+					supported[i] = strconv.Itoa(s)
+				}
+				abortError(c, newAPIError(http.StatusBadRequest, "Unsupported API-Version").
+					WithCode("unsupported_api_version").WithDetails(gin.H{"supported": strings.Join(supported, ", ")}))
+				return
+			}
+		}
+		setAPIVersion(c, version)
+
+		c.Header("Deprecation", "@"+strconv.FormatInt(legacyDeprecatedAt.Unix(), 10))
+		if !h.Config.LegacyRoutesSunset.IsZero() {
+			c.Header("Sunset", h.Config.LegacyRoutesSunset.UTC().Format(http.TimeFormat))
+		}
+		successor := APIPrefix(version) + c.Request.URL.Path
+		if c.Request.URL.RawQuery != "" {
+			successor += "?" + c.Request.URL.RawQuery
+		}
+		c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Webhooks ---
+// Cadastro das assinaturas (admin). A entrega em si fica no
+// service.WebhookService, disparada pelos UserEvents.
+
+// Resposta da criação: única vez em que o secret aparece
+type WebhookWithSecret struct {
+	models.Webhook
+	Secret string `json:"secret"`
+}
+
+// POST /webhooks
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var input models.WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		bindError(c, err)
+		return
+	}
+	hook, secret, err := h.Webhooks.Create(c.Request.Context(), currentUserID(c), input)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, WebhookWithSecret{Webhook: hook, Secret: secret})
+}
+
+// GET /webhooks
+func (h *Handler) GetWebhooks(c *gin.Context) {
+	hooks, err := h.Webhooks.List(c.Request.Context())
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+// DELETE /webhooks/:id
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.Webhooks.Delete(c.Request.Context(), id); err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, "Webhook deleted")})
+}
+
+// GET /webhooks/:id/deliveries?page=&per_page=
+// Mais recentes primeiro, com o status e o erro da última tentativa
+func (h *Handler) GetWebhookDeliveries(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	if _, err := h.Webhooks.Get(c.Request.Context(), id); err != nil {
+		abortError(c, err)
+		return
+	}
+	page, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	deliveries, total, err := h.Webhooks.Deliveries(c.Request.Context(), id, page.PerPage, page.Offset())
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	setPaginationHeaders(c, page, total)
+	c.JSON(http.StatusOK, deliveries)
+}
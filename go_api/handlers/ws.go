@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// --- WebSocket de Presença ---
+// GET /ws abre um WebSocket que recebe, em JSON, os eventos de presença dos
+// dispositivos (device.online, device.seen, device.offline). Logo ao
+// conectar chega um device.online para cada dispositivo já conectado.
+// Admin recebe os eventos do próprio tenant (o admin da plataforma, de
+// todos); os demais, só dos próprios dispositivos.
+//
+// Navegadores não mandam cabeçalhos no WebSocket: o access token pode vir
+// em ?access_token=.
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsPingInterval = 30 * time.Second
+	wsPongTimeout  = 2 * wsPingInterval
+)
+
+// A autenticação é por token, não por cookie, então qualquer origem serve
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// Middleware: usa ?access_token= quando o Authorization não vem
+func QueryToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		c.Next()
+	}
+}
+
+// POST /devices/:id/heartbeat: presença sem mandar leituras. Fica fora do
+// DeviceAccess (que carrega o dispositivo): sensores chamam com frequência,
+// então a checagem de dono vai no próprio UPDATE.
+func (h *Handler) DeviceHeartbeat(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	var owner uint
+	if !isAdmin(c) {
+		owner = currentUserID(c)
+	}
+	device, err := h.Devices.Heartbeat(c.Request.Context(), uint(id), owner)
+	if err != nil {
+		abortError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, device)
+}
+
+// GET /ws
+func (h *Handler) PresenceSocket(c *gin.Context) {
+	events, cancel := h.Presence.Subscribe()
+	defer cancel()
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // o Upgrade já respondeu com o erro
+	}
+	defer conn.Close()
+
+	userID, admin, tenant := currentUserID(c), isAdmin(c), c.GetUint("tenantID")
+	platform := isPlatformAdmin(c)
+	visible := func(e service.PresenceEvent) bool {
+		if admin {
+			return platform || e.TenantID == tenant
+		}
+		return e.UserID == userID
+	}
+
+	// Leitura: só para responder aos pings e perceber quando o cliente fecha
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+		})
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(e service.PresenceEvent) bool {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		return conn.WriteJSON(e) == nil
+	}
+	for _, e := range h.Presence.Snapshot() {
+		if visible(e) && !send(e) {
+			return
+		}
+	}
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				// Réplica encerrando: avisa o cliente para reconectar em outra
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+					time.Now().Add(wsWriteTimeout))
+				return
+			}
+			if visible(e) && !send(e) {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
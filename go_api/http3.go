@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"go_api/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// --- Listener HTTP/3 (QUIC) opcional ---
+// Ativado quando HTTP3_ADDR estiver definido (ex: ":8443"). QUIC exige TLS,
+// então TLS_CERT_FILE e TLS_KEY_FILE também são obrigatórios. O servidor TCP
+// continua rodando normalmente; o HTTP/3 é anunciado aos clientes pelo
+// cabeçalho Alt-Svc nas respostas HTTP/1.1 e HTTP/2.
+func newHTTP3Server(cfg config.Config) *http3.Server {
+	if cfg.HTTP3Addr == "" {
+		return nil
+	}
+	return &http3.Server{Addr: cfg.HTTP3Addr}
+}
+
+// Precisa ser registrado antes das rotas
+func altSvc(server *http3.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		server.SetQUICHeaders(c.Writer.Header())
+		c.Next()
+	}
+}
+
+func startHTTP3(server *http3.Server, handler http.Handler, certFile, keyFile string) {
+	if server == nil {
+		return
+	}
+	server.Handler = handler
+	go func() {
+		log.Printf("HTTP/3 escutando em %s (UDP)", server.Addr)
+		if err := server.ListenAndServeTLS(certFile, keyFile); err != nil && err != http.ErrServerClosed {
+			log.Printf("Erro no listener HTTP/3: %v", err)
+		}
+	}()
+}
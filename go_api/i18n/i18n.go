@@ -0,0 +1,68 @@
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// --- Mensagens Traduzidas (Accept-Language) ---
+// As mensagens para o usuário são escritas em inglês no código e traduzidas
+// só na hora de montar a resposta, no idioma pedido no Accept-Language:
+// en (padrão) ou pt-BR. A chave do catálogo é o próprio texto em inglês
+// (com os verbos do fmt, ex: "Invalid role: %s"), então uma mensagem sem
+// tradução continua saindo em inglês. O "code" dos erros não muda com o
+// idioma; é por ele que os clientes decidem o que fazer.
+
+const (
+	English    = "en"
+	Portuguese = "pt-BR"
+)
+
+// Traduções por idioma; o inglês é o texto original
+var catalogs = map[string]map[string]string{
+	Portuguese: ptBR,
+}
+
+// Idioma da resposta para um Accept-Language (ex: "pt-BR,pt;q=0.9,en;q=0.8"):
+// o de maior q que a API conhece, desempatando pela ordem; sem nenhum
+// conhecido, inglês. Qualquer variante do português cai no pt-BR.
+func Negotiate(header string) string {
+	best, bestQ := English, 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		var lang string
+		switch primary {
+		case "pt":
+			lang = Portuguese
+		case "en", "*":
+			lang = English
+		default:
+			continue
+		}
+		if q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// Texto no idioma pedido, com os argumentos aplicados ao formato
+func T(lang, format string, args ...any) string {
+	if translated, ok := catalogs[lang][format]; ok {
+		format = translated
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
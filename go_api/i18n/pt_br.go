@@ -0,0 +1,229 @@
+package i18n
+
+// Catálogo do pt-BR: texto em inglês (como está no código) -> tradução. Os
+// verbos do fmt (%s, %d, %q) ficam na mesma ordem do original.
+var ptBR = map[string]string{
+	// Erros gerais
+	"Internal error":                                 "Erro interno",
+	"Not found":                                      "Não encontrado",
+	"Already exists":                                 "Já existe",
+	"Route not found":                                "Rota não encontrada",
+	"Method not allowed":                             "Método não permitido",
+	"Request took too long to process":               "A requisição demorou demais para ser processada",
+	"Client closed the request":                      "O cliente encerrou a requisição",
+	"Request body exceeds %d bytes":                  "O corpo da requisição passa de %d bytes",
+	"Database is overloaded; try again later":        "O banco de dados está sobrecarregado; tente de novo mais tarde",
+	"Too many requests":                              "Requisições demais",
+	"Origin not allowed":                             "Origem não permitida",
+	"Unsupported API-Version":                        "API-Version não suportada",
+	"Invalid compressed body":                        "Corpo comprimido inválido",
+	"Unsupported Content-Encoding":                   "Content-Encoding não suportado",
+	"Body must be a JSON object":                     "O corpo deve ser um objeto JSON",
+	"Invalid JSON: %s":                               "JSON inválido: %s",
+	"Invalid %s":                                     "%s inválido",
+	"Invalid fields":                                 "Campos inválidos",
+	"unknown field %q":                               "campo desconhecido %q",
+	"Background jobs are not running":                "Os jobs em segundo plano não estão rodando",
+	"Chaos: injected failure":                        "Chaos: falha injetada",
+	"A batch must contain between 1 and 20 requests": "Um lote deve ter entre 1 e 20 requisições",
+	"If-Match must be a single ETag from this API, e.g. \"3\"": "If-Match deve ser um único ETag desta API, ex: \"3\"",
+
+	// Validação
+	"Validation failed":                                  "Falha na validação",
+	"is required":                                        "é obrigatório",
+	"is invalid":                                         "é inválido",
+	"is already in use":                                  "já está em uso",
+	"must be a valid email address":                      "deve ser um e-mail válido",
+	"must be at least %s characters":                     "deve ter pelo menos %s caracteres",
+	"must be at most %s characters":                      "deve ter no máximo %s caracteres",
+	"must be one of: %s":                                 "deve ser um destes: %s",
+	"must be a UUID":                                     "deve ser um UUID",
+	"must be a positive integer":                         "deve ser um inteiro positivo",
+	"may only contain letters, digits, '.', '_' and '-'": "só pode ter letras, dígitos, '.', '_' e '-'",
+	"may only contain lowercase letters, digits and '-'": "só pode ter letras minúsculas, dígitos e '-'",
+
+	// Parâmetros de consulta
+	"%s must be a positive integer":                  "%s deve ser um inteiro positivo",
+	"%s must be an RFC3339 timestamp":                "%s deve ser um instante RFC3339",
+	"%s must be a number":                            "%s deve ser um número",
+	"%s must be an integer >= %d":                    "%s deve ser um inteiro >= %d",
+	"Invalid sort field: %s":                         "Campo de ordenação inválido: %s",
+	"page must be a positive integer":                "page deve ser um inteiro positivo",
+	"per_page must be a positive integer":            "per_page deve ser um inteiro positivo",
+	"after_id must be a non-negative integer":        "after_id deve ser um inteiro não negativo",
+	"after_id cannot be combined with page or sort":  "after_id não pode ser combinado com page ou sort",
+	"cursor must be a positive integer":              "cursor deve ser um inteiro positivo",
+	"limit must be a positive integer":               "limit deve ser um inteiro positivo",
+	"checkpoint must be a positive integer":          "checkpoint deve ser um inteiro positivo",
+	"since must be a positive integer cursor":        "since deve ser um cursor inteiro positivo",
+	"since must be an RFC3339 timestamp or a cursor": "since deve ser um instante RFC3339 ou um cursor",
+	"at must be an RFC3339 timestamp":                "at deve ser um instante RFC3339",
+	"wait must be a duration like 30s":               "wait deve ser uma duração como 30s",
+	"days must be between 1 and %d":                  "days deve estar entre 1 e %d",
+	"q must have between 2 and 100 characters":       "q deve ter entre 2 e 100 caracteres",
+	"format must be csv or json":                     "format deve ser csv ou json",
+	"format must be json or zip":                     "format deve ser json ou zip",
+	"date must be in YYYY-MM-DD format":              "date deve estar no formato AAAA-MM-DD",
+	"filter is too long":                             "filter é longo demais",
+	"filter has too many comparisons":                "filter tem comparações demais",
+
+	// Autenticação e sessões
+	"Missing bearer token":                                    "Falta o bearer token",
+	"Invalid or expired token":                                "Token inválido ou expirado",
+	"Invalid or expired refresh token":                        "Refresh token inválido ou expirado",
+	"Invalid credentials":                                     "Credenciais inválidas",
+	"Could not issue tokens":                                  "Não foi possível emitir os tokens",
+	"Admin role required":                                     "É preciso o papel admin",
+	"Platform admin role required":                            "É preciso ser admin da plataforma",
+	"Credentials belong to another tenant":                    "As credenciais são de outro tenant",
+	"Account temporarily locked after too many failed logins": "Conta bloqueada temporariamente após muitas tentativas de login",
+	"Session not found":                                       "Sessão não encontrada",
+	"Session revoked":                                         "Sessão encerrada",
+	"Current password is incorrect":                           "A senha atual está incorreta",
+	"Password changed":                                        "Senha alterada",
+	"Invalid or expired reset token":                          "Código de redefinição inválido ou expirado",
+	"If the email is registered, a reset code has been sent":  "Se o e-mail estiver cadastrado, um código de redefinição foi enviado",
+	"Email not verified":                                      "E-mail não verificado",
+	"Email verified":                                          "E-mail verificado",
+	"Invalid or expired verification token":                   "Link de verificação inválido ou expirado",
+	"If the email is registered and not verified, a new link has been sent": "Se o e-mail estiver cadastrado e não verificado, um novo link foi enviado",
+	"token is required": "token é obrigatório",
+
+	// Login social
+	"Unknown login provider":                         "Provedor de login desconhecido",
+	"Missing authorization code":                     "Falta o código de autorização",
+	"Invalid or expired login state; start again":    "Estado do login inválido ou expirado; comece de novo",
+	"Login was not authorized by the provider":       "O provedor não autorizou o login",
+	"Could not complete the login with the provider": "Não foi possível concluir o login com o provedor",
+	"The provider account has no verified email":     "A conta no provedor não tem e-mail verificado",
+	"An account with this email already exists; sign in with your password and verify the email first": "Já existe uma conta com este e-mail; entre com a senha e verifique o e-mail antes",
+
+	// Chaves de API e personificação
+	"API key not found":                               "Chave de API não encontrada",
+	"Invalid, expired or revoked API key":             "Chave de API inválida, expirada ou revogada",
+	"API keys cannot create other API keys":           "Chaves de API não podem criar outras chaves de API",
+	"API keys cannot export the account":              "Chaves de API não podem exportar a conta",
+	"API keys cannot delete the account":              "Chaves de API não podem apagar a conta",
+	"expires_at must be in the future":                "expires_at deve estar no futuro",
+	"Cannot impersonate yourself":                     "Não é possível personificar a si mesmo",
+	"Admins cannot be impersonated":                   "Admins não podem ser personificados",
+	"Impersonation tokens cannot create API keys":     "Tokens de personificação não podem criar chaves de API",
+	"Impersonation tokens cannot change the password": "Tokens de personificação não podem trocar a senha",
+	"Impersonation tokens cannot export the account":  "Tokens de personificação não podem exportar a conta",
+	"Impersonation tokens cannot delete the account":  "Tokens de personificação não podem apagar a conta",
+	"Impersonation tokens cannot grant consent":       "Tokens de personificação não podem conceder consentimento",
+	"Only the user can grant their own consent":       "Só o próprio usuário pode conceder o consentimento",
+
+	// Usuários
+	"User not found":                                  "Usuário não encontrado",
+	"User not found at the given time":                "Usuário não encontrado no instante pedido",
+	"User or Email already exists":                    "Usuário ou e-mail já existe",
+	"User is not deleted":                             "O usuário não está removido",
+	"User was erased and cannot be restored":          "O usuário foi apagado e não pode ser restaurado",
+	"User deleted":                                    "Usuário removido",
+	"You can only access your own user":               "Você só pode acessar o próprio usuário",
+	"Invalid role":                                    "Papel inválido",
+	"Invalid role: %s":                                "Papel inválido: %s",
+	"Field cannot be patched: %s":                     "O campo não pode ser alterado: %s",
+	"Field cannot be removed: %s":                     "O campo não pode ser removido: %s",
+	"User was modified by another request; try again": "O usuário foi alterado por outra requisição; tente de novo",
+	"User was modified by another request; apply your change to the current version": "O usuário foi alterado por outra requisição; aplique sua alteração sobre a versão atual",
+	"User data belongs to another region":                                            "Os dados do usuário pertencem a outra região",
+	"Unknown region: %s":                                                             "Região desconhecida: %s",
+	"Avatar not found":                                                               "Avatar não encontrado",
+	"Avatar exceeds %d bytes":                                                        "O avatar passa de %d bytes",
+	"File must be a JPEG, PNG or GIF image":                                          "O arquivo deve ser uma imagem JPEG, PNG ou GIF",
+	"Image dimensions are too large":                                                 "As dimensões da imagem são grandes demais",
+	"Send the image as multipart/form-data in the \"file\" field":                    "Envie a imagem como multipart/form-data no campo \"file\"",
+
+	// Cadastro em lote e importação
+	"A batch must contain between 1 and %d users":               "Um lote deve ter entre 1 e %d usuários",
+	"Could not create user":                                     "Não foi possível criar o usuário",
+	"%s already exists":                                         "%s já existe",
+	"CSV file exceeds %d bytes":                                 "O arquivo CSV passa de %d bytes",
+	"CSV file is empty or malformed":                            "O arquivo CSV está vazio ou malformado",
+	"Malformed CSV: %s":                                         "CSV malformado: %s",
+	"Expected %d columns, got %d":                               "Esperadas %d colunas, vieram %d",
+	"Send the CSV as multipart/form-data in the \"file\" field": "Envie o CSV como multipart/form-data no campo \"file\"",
+
+	// Privacidade e consentimento
+	"Consent required":                  "Consentimento necessário",
+	"Consent not found":                 "Consentimento não encontrado",
+	"Consent withdrawn":                 "Consentimento retirado",
+	"Could not store consent":           "Não foi possível gravar o consentimento",
+	"Invalid purpose: %s":               "Finalidade inválida: %s",
+	"Confirmation code sent by email":   "Código de confirmação enviado por e-mail",
+	"Account deleted":                   "Conta apagada",
+	"Invalid or expired deletion token": "Código de exclusão inválido ou expirado",
+
+	// Dispositivos, telemetria e localização
+	"Device not found":                                               "Dispositivo não encontrado",
+	"Device deleted":                                                 "Dispositivo removido",
+	"Could not create device":                                        "Não foi possível criar o dispositivo",
+	"You can only access your own devices":                           "Você só pode acessar os próprios dispositivos",
+	"status must be online or offline":                               "status deve ser online ou offline",
+	"Could not store readings":                                       "Não foi possível gravar as leituras",
+	"Readings buffer is full; try again later":                       "O buffer de leituras está cheio; tente de novo mais tarde",
+	"metric is required":                                             "metric é obrigatório",
+	"resolution must be raw, hourly or daily":                        "resolution deve ser raw, hourly ou daily",
+	"after_id only applies to raw readings":                          "after_id só vale para as leituras brutas",
+	"bucket must be a duration between 1m and 8760h, like 15m or 1h": "bucket deve ser uma duração entre 1m e 8760h, como 15m ou 1h",
+	"Last-Event-ID must be a reading ID":                             "Last-Event-ID deve ser o ID de uma leitura",
+	"Device has no location yet":                                     "O dispositivo ainda não tem localização",
+	"Could not store locations":                                      "Não foi possível gravar as localizações",
+	"lat and lon are required":                                       "lat e lon são obrigatórios",
+	"lat must be between -90 and 90":                                 "lat deve estar entre -90 e 90",
+	"lon must be between -180 and 180":                               "lon deve estar entre -180 e 180",
+	"accuracy must not be negative":                                  "accuracy não pode ser negativo",
+	"radius must be between 0 and %d meters":                         "radius deve estar entre 0 e %d metros",
+	"At least one activity window is required":                       "É preciso pelo menos uma janela de atividade",
+	"Could not store activities":                                     "Não foi possível gravar as atividades",
+	"Invalid activity: %s":                                           "Atividade inválida: %s",
+	"activity or accelerometer samples are required":                 "activity ou as amostras do acelerômetro são obrigatórios",
+	"ended_at must be after started_at":                              "ended_at deve ser depois de started_at",
+	"client_time must be a unix timestamp in milliseconds":           "client_time deve ser um timestamp unix em milissegundos",
+	"client_id is required":                                          "client_id é obrigatório",
+
+	// Notificações e webhooks
+	"Notification not found":             "Notificação não encontrada",
+	"Push token not found":               "Push token não encontrado",
+	"Push token removed":                 "Push token removido",
+	"user_ids or device_ids is required": "user_ids ou device_ids é obrigatório",
+	"Webhook not found":                  "Webhook não encontrado",
+	"Webhook deleted":                    "Webhook removido",
+
+	// Grupos
+	"Group not found":                            "Grupo não encontrado",
+	"Group deleted":                              "Grupo removido",
+	"Member removed":                             "Membro removido",
+	"You are not a member of this group":         "Você não é membro deste grupo",
+	"User is not a member of this group":         "O usuário não é membro deste grupo",
+	"User is already a member of this group":     "O usuário já é membro deste grupo",
+	"The group must keep at least one owner":     "O grupo precisa manter pelo menos um dono",
+	"Only group owners can add members":          "Só os donos do grupo podem adicionar membros",
+	"Only group owners can remove other members": "Só os donos do grupo podem remover outros membros",
+	"Only group owners can delete the group":     "Só os donos do grupo podem apagar o grupo",
+
+	// Tenants, feature flags, auditoria e administração
+	"Tenant not found":                                              "Tenant não encontrado",
+	"Tenant already exists":                                         "Tenant já existe",
+	"Feature flag not found":                                        "Feature flag não encontrada",
+	"Feature flag already exists":                                   "Feature flag já existe",
+	"Feature flag deleted":                                          "Feature flag removida",
+	"entity must be user, device or api_key":                        "entity deve ser user, device ou api_key",
+	"action must be create, update, delete, restore or impersonate": "action deve ser create, update, delete, restore ou impersonate",
+	"db_latency must be a duration like 200ms":                      "db_latency deve ser uma duração como 200ms",
+	"latency must be a duration like 200ms":                         "latency deve ser uma duração como 200ms",
+
+	// Idempotência
+	"Idempotency-Key must have at most 255 characters":               "Idempotency-Key deve ter no máximo 255 caracteres",
+	"A request with this Idempotency-Key is still in progress":       "Uma requisição com esta Idempotency-Key ainda está em andamento",
+	"Idempotency-Key was already used with a different request body": "Idempotency-Key já foi usada com outro corpo de requisição",
+
+	// GraphQL
+	"query is required":                              "query é obrigatório",
+	"variables must be a JSON object":                "variables deve ser um objeto JSON",
+	"first must be a positive integer":               "first deve ser um inteiro positivo",
+	"first must be positive and offset non-negative": "first deve ser positivo e offset não negativo",
+	"after must be a numeric ID":                     "after deve ser um ID numérico",
+}
@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// --- Tarefas em Segundo Plano ---
+// Trabalho que não precisa segurar a requisição (envio de e-mail, entrega
+// dos webhooks, limpeza de tokens vencidos) vira um Job numa fila,
+// consumida por um pool de workers (Pool). A fila fica em memória, ou no
+// Redis (JOB_QUEUE=redis) para as réplicas dividirem o trabalho e os jobs
+// pendentes sobreviverem ao reinício de uma delas.
+
+var (
+	ErrQueueFull = errors.New("job queue is full")
+	ErrClosed    = errors.New("job pool is shutting down")
+)
+
+type Job struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempt    int             `json:"attempt"` // tentativas já feitas
+	EnqueuedAt time.Time       `json:"enqueued_at"`
+}
+
+type Queue interface {
+	Push(ctx context.Context, job Job) error
+	// Espera até wait por um job; ok = false se a fila continuou vazia
+	Pop(ctx context.Context, wait time.Duration) (job Job, ok bool, err error)
+	Len(ctx context.Context) (int64, error)
+	// Reserva key por ttl (em todas as réplicas, no Redis); false se outro
+	// já reservou. Usado pelas tarefas periódicas (Pool.Every).
+	Once(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Fila que sobrevive ao processo: no encerramento, o que está nela
+	// fica para as outras réplicas em vez de ser esvaziado
+	Persistent() bool
+	Name() string
+}
+
+// rdb nil = em memória, com até size jobs esperando
+func New(rdb *redis.Client, size int) Queue {
+	if rdb != nil {
+		return NewRedis(rdb)
+	}
+	return NewMemory(size)
+}
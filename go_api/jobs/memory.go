@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Fila num canal com buffer: cheia, o Push falha na hora em vez de
+// bloquear quem enfileira (uma requisição HTTP, por exemplo)
+type Memory struct {
+	ch chan Job
+
+	mu   sync.Mutex
+	once map[string]time.Time
+}
+
+func NewMemory(size int) *Memory {
+	return &Memory{ch: make(chan Job, size), once: make(map[string]time.Time)}
+}
+
+func (m *Memory) Push(_ context.Context, job Job) error {
+	select {
+	case m.ch <- job:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (m *Memory) Pop(ctx context.Context, wait time.Duration) (Job, bool, error) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case job := <-m.ch:
+		return job, true, nil
+	case <-timer.C:
+		return Job{}, false, nil
+	case <-ctx.Done():
+		return Job{}, false, ctx.Err()
+	}
+}
+
+func (m *Memory) Len(context.Context) (int64, error) {
+	return int64(len(m.ch)), nil
+}
+
+func (m *Memory) Once(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if until, ok := m.once[key]; ok && now.Before(until) {
+		return false, nil
+	}
+	m.once[key] = now.Add(ttl)
+	return true, nil
+}
+
+func (m *Memory) Persistent() bool { return false }
+
+func (m *Memory) Name() string { return "memory" }
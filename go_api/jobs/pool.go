@@ -0,0 +1,317 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Espera de cada Pop: é também o tempo que um worker leva para perceber o
+// encerramento
+const popWait = time.Second
+
+// Falhas definitivas guardadas para o GET /admin/jobs
+const maxRecentFailures = 50
+
+// Executa um job; erro (ou panic) conta como falha e o job é repetido
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+type Options struct {
+	Workers     int
+	MaxAttempts int
+	RetryDelay  time.Duration // primeira espera; dobra a cada falha
+}
+
+type TypeStats struct {
+	Processed int64 `json:"processed"`
+	Retried   int64 `json:"retried"`
+	Failed    int64 `json:"failed"` // esgotou as tentativas
+}
+
+type Failure struct {
+	JobID    string    `json:"job_id"`
+	Type     string    `json:"type"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// Depth é a fila inteira; o resto é só desta réplica
+type Stats struct {
+	Queue          string               `json:"queue"`
+	Depth          int64                `json:"depth"`
+	InFlight       int64                `json:"in_flight"`
+	Retrying       int                  `json:"retrying"` // esperando a próxima tentativa
+	Workers        int                  `json:"workers"`
+	Types          map[string]TypeStats `json:"types"`
+	RecentFailures []Failure            `json:"recent_failures"`
+}
+
+type Pool struct {
+	queue    Queue
+	opts     Options
+	handlers map[string]Handler
+
+	// Contexto dos jobs: só é cancelado se o Drain estourar o prazo
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stop     chan struct{}
+	draining atomic.Bool
+	workers  sync.WaitGroup
+	inFlight atomic.Int64
+
+	mu       sync.Mutex
+	stats    map[string]*TypeStats
+	failures []Failure
+	retries  map[*time.Timer]Job
+}
+
+func NewPool(queue Queue, opts Options) *Pool {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Pool{
+		queue:    queue,
+		opts:     opts,
+		handlers: make(map[string]Handler),
+		ctx:      ctx,
+		cancel:   cancel,
+		stop:     make(chan struct{}),
+		stats:    make(map[string]*TypeStats),
+		retries:  make(map[*time.Timer]Job),
+	}
+}
+
+// Antes do Start
+func (p *Pool) Register(jobType string, h Handler) {
+	p.handlers[jobType] = h
+	p.stats[jobType] = &TypeStats{}
+}
+
+func (p *Pool) Start() {
+	for i := 0; i < p.opts.Workers; i++ {
+		p.workers.Add(1)
+		go p.work()
+	}
+}
+
+func (p *Pool) Enqueue(ctx context.Context, jobType string, payload interface{}) error {
+	if p.draining.Load() {
+		return ErrClosed
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	return p.queue.Push(ctx, Job{ID: hex.EncodeToString(id), Type: jobType, Payload: raw, EnqueuedAt: time.Now()})
+}
+
+// Enfileira jobType (sem payload) a cada interval. Com a fila no Redis,
+// só uma réplica enfileira por intervalo.
+func (p *Pool) Every(interval time.Duration, jobType string) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+			}
+			// Um pouco menos que o intervalo, para o tick seguinte da
+			// mesma réplica não encontrar a reserva ainda valendo
+			ok, err := p.queue.Once(p.ctx, "every:"+jobType, interval*9/10)
+			if err == nil && ok {
+				err = p.Enqueue(p.ctx, jobType, nil)
+			}
+			if err != nil && err != ErrClosed {
+				slog.Warn("tarefa periódica não enfileirada", "type", jobType, "error", err)
+			}
+		}
+	}()
+}
+
+func (p *Pool) work() {
+	defer p.workers.Done()
+	for {
+		wait := popWait
+		if p.draining.Load() {
+			// Fila persistente fica para as outras réplicas; a em memória
+			// é esvaziada antes de sair (senão os jobs se perdem)
+			if p.queue.Persistent() {
+				return
+			}
+			wait = 10 * time.Millisecond
+		}
+		// Sem cancelamento: um BRPOP interrompido depois de retirar o job o perderia
+		job, ok, err := p.queue.Pop(context.Background(), wait)
+		switch {
+		case err != nil:
+			slog.Warn("leitura da fila de jobs falhou", "error", err)
+			time.Sleep(popWait)
+		case ok:
+			p.run(job)
+		case p.draining.Load():
+			return
+		}
+	}
+}
+
+func (p *Pool) run(job Job) {
+	p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	job.Attempt++
+
+	handler, known := p.handlers[job.Type]
+	var err error
+	if known {
+		err = p.call(handler, job)
+	} else {
+		err = fmt.Errorf("no handler for job type %q", job.Type)
+	}
+
+	retry := err != nil && known && job.Attempt < p.opts.MaxAttempts
+	p.mu.Lock()
+	stats, ok := p.stats[job.Type]
+	if !ok {
+		stats = &TypeStats{}
+		p.stats[job.Type] = stats
+	}
+	switch {
+	case err == nil:
+		stats.Processed++
+	case retry:
+		stats.Retried++
+	default:
+		stats.Failed++
+		p.recordFailure(job, err)
+	}
+	p.mu.Unlock()
+
+	switch {
+	case retry:
+		delay := p.retryDelay(job.Attempt)
+		slog.Warn("job falhou, nova tentativa agendada", "job_id", job.ID, "type", job.Type, "attempt", job.Attempt, "retry_in", delay, "error", err)
+		p.retryLater(job, delay)
+	case err != nil:
+		slog.Error("job desistido", "job_id", job.ID, "type", job.Type, "attempts", job.Attempt, "error", err)
+	}
+}
+
+func (p *Pool) call(handler Handler, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(p.ctx, job.Payload)
+}
+
+// RetryDelay, 2x, 4x...
+func (p *Pool) retryDelay(attempt int) time.Duration {
+	return p.opts.RetryDelay << (attempt - 1)
+}
+
+func (p *Pool) retryLater(job Job, delay time.Duration) {
+	p.mu.Lock()
+	if p.draining.Load() {
+		p.mu.Unlock()
+		p.requeue(job)
+		return
+	}
+	// Com o lock, o callback só roda depois de o timer estar no mapa
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		p.mu.Lock()
+		delete(p.retries, timer)
+		p.mu.Unlock()
+		p.requeue(job)
+	})
+	p.retries[timer] = job
+	p.mu.Unlock()
+}
+
+func (p *Pool) requeue(job Job) {
+	if err := p.queue.Push(context.Background(), job); err != nil {
+		slog.Error("job perdido ao voltar para a fila", "job_id", job.ID, "type", job.Type, "error", err)
+		p.mu.Lock()
+		p.recordFailure(job, err)
+		p.mu.Unlock()
+	}
+}
+
+// Chamado com p.mu travado
+func (p *Pool) recordFailure(job Job, err error) {
+	p.failures = append(p.failures, Failure{
+		JobID: job.ID, Type: job.Type, Attempts: job.Attempt, Error: err.Error(), FailedAt: time.Now(),
+	})
+	if len(p.failures) > maxRecentFailures {
+		p.failures = p.failures[len(p.failures)-maxRecentFailures:]
+	}
+}
+
+// Encerramento: para de aceitar jobs, devolve à fila os que esperavam nova
+// tentativa e espera os workers (até ctx). Estourado o prazo, os jobs em
+// andamento são cancelados.
+func (p *Pool) Drain(ctx context.Context) error {
+	if p.draining.Swap(true) {
+		return nil
+	}
+	close(p.stop)
+
+	p.mu.Lock()
+	var pending []Job
+	for timer, job := range p.retries {
+		if timer.Stop() {
+			pending = append(pending, job)
+		}
+		delete(p.retries, timer)
+	}
+	p.mu.Unlock()
+	for _, job := range pending {
+		p.requeue(job)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		left, _ := p.queue.Len(context.Background())
+		slog.Warn("encerramento dos jobs estourou o prazo", "in_flight", p.inFlight.Load(), "queued", left)
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) Stats(ctx context.Context) (Stats, error) {
+	depth, err := p.queue.Len(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := Stats{
+		Queue:          p.queue.Name(),
+		Depth:          depth,
+		InFlight:       p.inFlight.Load(),
+		Retrying:       len(p.retries),
+		Workers:        p.opts.Workers,
+		Types:          make(map[string]TypeStats, len(p.stats)),
+		RecentFailures: append([]Failure{}, p.failures...),
+	}
+	for name, stats := range p.stats {
+		s.Types[name] = *stats
+	}
+	return s, nil
+}
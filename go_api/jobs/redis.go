@@ -0,0 +1,63 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Lista no Redis (LPUSH + BRPOP: a mais antiga sai primeiro), compartilhada
+// pelas réplicas. Um job já retirado por uma réplica que cai no meio da
+// execução se perde; as tarefas daqui toleram isso (o e-mail pode ser
+// pedido de novo, as limpezas e os webhooks rodam de novo na próxima vez).
+const (
+	redisQueueKey   = "jobs:queue"
+	redisOncePrefix = "jobs:once:"
+)
+
+type Redis struct {
+	client *redis.Client
+}
+
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+func (r *Redis) Push(ctx context.Context, job Job) error {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return r.client.LPush(ctx, redisQueueKey, raw).Err()
+}
+
+func (r *Redis) Pop(ctx context.Context, wait time.Duration) (Job, bool, error) {
+	// O go-redis estende o prazo de leitura dos comandos bloqueantes
+	res, err := r.client.BRPop(ctx, wait, redisQueueKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return Job{}, false, nil
+	}
+	if err != nil {
+		return Job{}, false, err
+	}
+	var job Job
+	if err := json.Unmarshal([]byte(res[1]), &job); err != nil {
+		return Job{}, false, err
+	}
+	return job, true, nil
+}
+
+func (r *Redis) Len(ctx context.Context) (int64, error) {
+	return r.client.LLen(ctx, redisQueueKey).Result()
+}
+
+func (r *Redis) Once(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, redisOncePrefix+key, 1, ttl).Result()
+}
+
+func (r *Redis) Persistent() bool { return true }
+
+func (r *Redis) Name() string { return "redis" }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Gerador de Carga Interno ---
+// Uso: ./server loadgen -url http://localhost:4000/go/users -c 50 -n 5000
+// Alternativa rápida ao JMeter para conferir o comportamento da API com o
+// modo caos ligado. Mostra a distribuição de status e os percentis de latência.
+func runLoadGen(args []string) {
+	fs := flag.NewFlagSet("loadgen", flag.ExitOnError)
+	url := fs.String("url", "http://localhost:8080/users", "URL alvo")
+	method := fs.String("method", "GET", "Método HTTP")
+	body := fs.String("body", "", "Corpo JSON da requisição")
+	concurrency := fs.Int("c", 10, "Número de workers simultâneos")
+	total := fs.Int("n", 1000, "Total de requisições")
+	timeout := fs.Duration("timeout", 10*time.Second, "Timeout por requisição")
+	fs.Parse(args)
+
+	client := &http.Client{Timeout: *timeout}
+	jobs := make(chan struct{})
+
+	var mu sync.Mutex
+	statuses := make(map[string]int)
+	latencies := make([]time.Duration, 0, *total)
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				req, _ := http.NewRequest(*method, *url, strings.NewReader(*body))
+				if *body != "" {
+					req.Header.Set("Content-Type", "application/json")
+				}
+
+				t := time.Now()
+				resp, err := client.Do(req)
+				elapsed := time.Since(t)
+
+				status := "error"
+				if err == nil {
+					io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+					status = fmt.Sprint(resp.StatusCode)
+				}
+
+				mu.Lock()
+				statuses[status]++
+				latencies = append(latencies, elapsed)
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < *total; i++ {
+		jobs <- struct{}{}
+	}
+	close(jobs)
+	wg.Wait()
+	duration := time.Since(start)
+
+	// --- Relatório ---
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	percentile := func(p float64) time.Duration {
+		if len(latencies) == 0 {
+			return 0
+		}
+		return latencies[int(float64(len(latencies)-1)*p)]
+	}
+
+	fmt.Printf("Requisições: %d em %s (%.1f req/s)\n", *total, duration.Round(time.Millisecond), float64(*total)/duration.Seconds())
+	fmt.Printf("Latência: p50=%s p95=%s p99=%s max=%s\n", percentile(0.50), percentile(0.95), percentile(0.99), percentile(1))
+	fmt.Println("Status:")
+	keys := make([]string, 0, len(statuses))
+	for k := range statuses {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("  %s: %d\n", k, statuses[k])
+	}
+
+	if statuses["error"] > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,253 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// --- Logs Estruturados (slog, JSON) ---
+// Cada requisição recebe um X-Request-ID (gerado ou repassado pelo cliente),
+// devolvido na resposta e incluído em todos os logs dela, inclusive nos logs
+// de SQL do GORM, para ligar uma query lenta à requisição que a disparou.
+// LOG_LEVEL: debug, info (padrão), warn ou error. Em debug o GORM loga todo SQL;
+// nos outros níveis, só as falhas e as mais lentas que DB_SLOW_QUERY_THRESHOLD.
+
+type ctxKey string
+
+const requestIDKey ctxKey = "request_id"
+
+// IDs vindos do cliente só são aceitos se forem curtos e "bem-comportados"
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Caminhos chamados o tempo todo por healthcheck/Prometheus não entram no log
+var quietPaths = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// Configura o logger padrão (chamado no main, com LOG_LEVEL já validado).
+// secrets (senhas e chaves da configuração) nunca saem no log.
+func Setup(logLevel string, secrets ...string) {
+	level := slog.LevelInfo
+	switch logLevel {
+	case "debug":
+		level = slog.LevelDebug
+	case "warn":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	}
+	// slog.SetDefault também redireciona o pacote log (log.Printf) para cá
+	handler := Redact(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}), secrets...)
+	slog.SetDefault(slog.New(traceHandler{handler}))
+}
+
+// --- Segredos fora do log ---
+// Uma senha pode chegar ao log por uma mensagem de erro (ex: DSN ou URL na
+// falha de conexão) ou por um log.Printf descuidado. O redactHandler troca
+// cada ocorrência por [REDACTED] na mensagem e em todos os atributos.
+
+const redacted = "[REDACTED]"
+
+// Segredos com menos de 4 caracteres ficam de fora: esconderiam pedaços de
+// qualquer mensagem
+const minSecretLen = 4
+
+// Envolve h com a troca dos segredos; sem segredos, devolve o próprio h
+func Redact(h slog.Handler, secrets ...string) slog.Handler {
+	secrets = slices.DeleteFunc(slices.Clone(secrets), func(s string) bool { return len(s) < minSecretLen })
+	if len(secrets) == 0 {
+		return h
+	}
+	// O mais longo primeiro: a senha que aparece dentro da URL sai junto com ela
+	slices.SortFunc(secrets, func(a, b string) int { return len(b) - len(a) })
+	pairs := make([]string, 0, 2*len(secrets))
+	for _, s := range secrets {
+		pairs = append(pairs, s, redacted)
+	}
+	return redactHandler{h, strings.NewReplacer(pairs...)}
+}
+
+type redactHandler struct {
+	slog.Handler
+	replacer *strings.Replacer
+}
+
+func (h redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, h.replacer.Replace(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(h.attr(a))
+		return true
+	})
+	return h.Handler.Handle(ctx, out)
+}
+
+func (h redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		clean[i] = h.attr(a)
+	}
+	return redactHandler{h.Handler.WithAttrs(clean), h.replacer}
+}
+
+func (h redactHandler) WithGroup(name string) slog.Handler {
+	return redactHandler{h.Handler.WithGroup(name), h.replacer}
+}
+
+// Strings são trocadas direto; erros e outros valores, pelo texto, só
+// quando contêm um segredo (senão continuam saindo no formato original)
+func (h redactHandler) attr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(h.replacer.Replace(a.Value.String()))
+	case slog.KindGroup:
+		group := a.Value.Group()
+		clean := make([]slog.Attr, len(group))
+		for i, item := range group {
+			clean[i] = h.attr(item)
+		}
+		a.Value = slog.GroupValue(clean...)
+	case slog.KindAny:
+		text := fmt.Sprint(a.Value.Any())
+		if replaced := h.replacer.Replace(text); replaced != text {
+			a.Value = slog.StringValue(replaced)
+		}
+	}
+	return a
+}
+
+// Acrescenta trace_id e span_id aos logs feitos dentro de um span
+// (OpenTelemetry), para ir do log ao trace no coletor
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{h.Handler.WithGroup(name)}
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Header("X-Request-ID", id)
+		c.Set("requestID", id)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey, id))
+
+		start := time.Now()
+		c.Next()
+
+		if quietPaths[c.Request.URL.Path] {
+			return
+		}
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		} else if status >= 400 {
+			level = slog.LevelWarn
+		}
+		slog.LogAttrs(c.Request.Context(), level, "request",
+			slog.String("request_id", id),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		)
+	}
+}
+
+// --- Logger do GORM sobre o slog ---
+// O request ID só aparece quando a query usa db.WithContext(c.Request.Context()).
+// Consultas acima de SlowThreshold saem em WARN (0 desliga), para achar o
+// índice que falta antes que ele derrube uma demo. O SQL sai sem os valores
+// (só os placeholders): senão iriam para o log hashes de senha, jti das
+// sessões e hashes dos tokens de redefinição, verificação e API.
+type GormLogger struct {
+	SlowThreshold time.Duration
+}
+
+func (l GormLogger) LogMode(logger.LogLevel) logger.Interface { return l }
+
+// gorm.ParamsFilter: o GORM monta o SQL do log (fc no Trace) com os valores
+// devolvidos aqui
+func (GormLogger) ParamsFilter(_ context.Context, sql string, _ ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+func (GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	slog.InfoContext(ctx, msg, "request_id", RequestIDFromContext(ctx), "args", args)
+}
+
+func (GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	slog.WarnContext(ctx, msg, "request_id", RequestIDFromContext(ctx), "args", args)
+}
+
+func (GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	slog.ErrorContext(ctx, msg, "request_id", RequestIDFromContext(ctx), "args", args)
+}
+
+func (l GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	// "Não encontrado" faz parte do fluxo normal (404), não é erro de banco
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	elapsed := time.Since(begin)
+	slow := l.SlowThreshold > 0 && elapsed > l.SlowThreshold
+	if !failed && !slow && !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("request_id", RequestIDFromContext(ctx)),
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("elapsed_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if failed {
+		slog.LogAttrs(ctx, slog.LevelError, "query failed", append(attrs, slog.String("error", err.Error()))...)
+		return
+	}
+	if slow {
+		slog.LogAttrs(ctx, slog.LevelWarn, "slow query", append(attrs, slog.Float64("threshold_ms", float64(l.SlowThreshold.Microseconds())/1000))...)
+		return
+	}
+	slog.LogAttrs(ctx, slog.LevelDebug, "query", attrs...)
+}
@@ -0,0 +1,64 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// --- Envio de E-mails ---
+// Quem envia e-mail (ex: redefinição de senha) depende só da interface
+// Mailer. Sem SMTP_ADDR, as mensagens vão para o log (desenvolvimento): o
+// corpo aparece inteiro, com o token, então não use assim em produção.
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string // texto puro
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// addr vazio = LogMailer
+func New(addr, from, username, password string) Mailer {
+	if addr == "" {
+		return LogMailer{}
+	}
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTP{Addr: addr, From: from, Auth: auth}
+}
+
+// Só registra a mensagem no log
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, msg Message) error {
+	slog.InfoContext(ctx, "e-mail (não enviado, sem SMTP_ADDR)", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// Envio por SMTP (STARTTLS quando o servidor oferece)
+type SMTP struct {
+	Addr string // host:porta
+	From string
+	Auth smtp.Auth
+}
+
+func (m *SMTP) Send(_ context.Context, msg Message) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return smtp.SendMail(m.Addr, m.Auth, m.From, []string{msg.To}, []byte(b.String()))
+}
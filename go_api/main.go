@@ -1,146 +1,216 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"log"
-	"net/http"
 	"os"
 	"time"
 
+	"go_api/coap"
+	"go_api/config"
+	"go_api/grpcapi"
+	"go_api/handlers"
+	"go_api/logging"
+	"go_api/mailer"
+	"go_api/migrations"
+	"go_api/mqttbridge"
+	"go_api/ratelimit"
+	"go_api/repository"
+	"go_api/router"
+	"go_api/service"
+	"go_api/tracing"
+
 	"github.com/gin-gonic/gin"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
 )
 
-// --- 1. Definição da Entidade (Modelo) ---
-// As "tags" (ex: `json:"name"`) definem como os dados aparecem no JSON e no Banco.
-type User struct {
-	ID       uint   `gorm:"primaryKey" json:"id"`
-	Name     string `gorm:"not null" json:"name"`
-	Email    string `gorm:"uniqueIndex;not null" json:"email"`
-	User     string `gorm:"uniqueIndex;not null" json:"user"`
-	Password string `gorm:"not null" json:"password"`
-}
-
-var db *gorm.DB
-
-// --- 2. Conexão Otimizada com o Banco ---
-func connectDatabase() {
-	// Lê as variáveis de ambiente que definiremos no docker-compose
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=5432 sslmode=disable TimeZone=UTC",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-	)
-
-	var err error
-	// Loop de retry: Tenta conectar 5 vezes caso o banco demore a subir
-	for i := 0; i < 5; i++ {
-		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
-		if err == nil {
-			break
-		}
-		log.Printf("Tentando conectar ao banco (%d/5)...", i+1)
-		time.Sleep(2 * time.Second)
+// --- Função Principal ---
+// Só monta as peças: configuração -> banco -> repository -> service ->
+// handlers -> router. As regras ficam nos pacotes de cada camada.
+func main() {
+	// Subcomando do gerador de carga (não precisa do banco)
+	if len(os.Args) > 1 && os.Args[1] == "loadgen" {
+		runLoadGen(os.Args[2:])
+		return
 	}
+	// Subcomando "seed": dados falsos para testes de carga e demonstração
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		runSeed(os.Args[2:])
+		return
+	}
+	// Subcomando "admin": tarefas de operação direto no banco
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		runAdmin(os.Args[2:])
+		return
+	}
+	// Subcomando "migrate": aplica as migrações e sai, sem servir tráfego
+	migrateOnly := len(os.Args) > 1 && os.Args[1] == "migrate"
 
+	cfg, err := config.Load()
 	if err != nil {
-		panic("Erro fatal: Não foi possível conectar ao PostgreSQL!")
+		log.Fatalf("Erro fatal: configuração inválida:\n%v", err)
 	}
+	logging.Setup(cfg.LogLevel, cfg.Secrets()...)
 
-	// Cria a tabela 'users' automaticamente
-	db.AutoMigrate(&User{})
-
-	// --- PERFORMANCE TUNING ---
-// --- PERFORMANCE TUNING ---
-    sqlDB, _ := db.DB()
-
-    // MELHORIA 4: Aumentar conexões em espera e máximas
-    sqlDB.SetMaxIdleConns(20)   // Era 10
-    sqlDB.SetMaxOpenConns(80)   // Era 100 (Reduzi um pouco por segurança pois temos 4 replicas: 4*80=320)
-    sqlDB.SetConnMaxLifetime(time.Hour)
-}
-
-// --- 3. Handlers (Funções das Rotas) ---
+	// Tracing opcional (OTEL_EXPORTER_OTLP_ENDPOINT), antes do banco e do router
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("Erro fatal: OTEL_EXPORTER_OTLP_ENDPOINT: %v", err)
+	}
 
-func createUser(c *gin.Context) {
-	var input User
-	// Valida o JSON recebido
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	db, err := repository.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Erro fatal: %v", err)
 	}
-	// Tenta salvar no banco
-	if result := db.Create(&input); result.Error != nil {
-		// Retorna erro se email/user já existirem
-		c.JSON(http.StatusConflict, gin.H{"error": "User or Email already exists"})
-		return
+	if migrateOnly || cfg.MigrateOnStart {
+		if err := migrations.Run(db, cfg.DBDriver); err != nil {
+			log.Fatalf("Erro fatal: migrações: %v", err)
+		}
 	}
-	c.JSON(http.StatusCreated, input)
-}
-
-func getUsers(c *gin.Context) {
-	var users []User
-	db.Find(&users)
-	c.JSON(http.StatusOK, users)
-}
-
-func getUser(c *gin.Context) {
-	var user User
-	// Busca pelo ID passado na URL
-	if err := db.First(&user, c.Param("id")).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	if migrateOnly {
 		return
 	}
-	c.JSON(http.StatusOK, user)
-}
+	// Reconexão com espera exponencial se o Postgres cair depois da subida
+	stopMonitor := repository.MonitorConnection(db, cfg)
+
+	// Toda consulta filtrada pelo tenant da requisição (X-Tenant ou token)
+	repository.ScopeTenants(db)
+
+	// Todo UserEvent gravado é anunciado no hub (long-polling)
+	hub := service.NewEventHub()
+	hub.Watch(db)
+	// ...e gera as entregas dos webhooks inscritos, na mesma transação
+	service.WatchWebhooks(db)
+	// ...e, com um broker, os eventos do outbox (também na mesma transação)
+	if cfg.OutboxBroker != "" {
+		service.WatchOutbox(db)
+	}
 
-func updateUser(c *gin.Context) {
-	var user User
-	if err := db.First(&user, c.Param("id")).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		return
+	// Redis opcional: rate limit compartilhado e cache de usuários
+	rdb, err := repository.ConnectRedis(cfg)
+	if err != nil {
+		log.Fatalf("Erro fatal: REDIS_URL: %v", err)
 	}
+	// Fila dos jobs (e-mails, webhooks, limpezas), em memória ou no Redis
+	pool := newJobPool(cfg, rdb)
+	mail := mailer.New(cfg.SMTPAddr, cfg.SMTPFrom, cfg.SMTPUsername, cfg.SMTPPassword)
 
-	var input User
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	store := repository.NewUserStore(db)
+	if rdb != nil && cfg.CacheTTL > 0 {
+		store = repository.NewCachedUserStore(store, rdb, cfg.CacheTTL)
+	}
+	users := service.NewUserService(store).
+		WithLockout(repository.NewLoginAttemptStore(db), service.LockoutPolicy{
+			MaxAttempts: cfg.LoginMaxAttempts,
+			Window:      cfg.LoginLockoutWindow,
+			Duration:    cfg.LoginLockoutDuration,
+		}).
+		WithMailer(queuedMailer{pool}).
+		WithPasswordReset(repository.NewPasswordResetStore(db),
+			service.PasswordResetPolicy{TTL: cfg.PasswordResetTTL, URL: cfg.PasswordResetURL}).
+		WithEmailVerification(repository.NewEmailVerificationStore(db), service.EmailVerificationPolicy{
+			TTL:      cfg.EmailVerificationTTL,
+			URL:      cfg.EmailVerificationURL,
+			Required: cfg.RequireEmailVerification,
+		}).
+		WithSessions(repository.NewSessionStore(db), service.SessionPolicy{TTL: cfg.RefreshTTL}).
+		WithIdentities(repository.NewIdentityStore(db))
+	presence := service.NewPresenceHub(cfg.PresenceTimeout)
+	go presence.Run(cfg.PresenceTimeout / 4)
+	h := handlers.New(db, cfg, users, hub, presence, ratelimit.New(cfg.RateLimit, cfg.RateLimitWindow, rdb))
+	handlers.RegisterMetrics(db)
+	h.Jobs = pool
+	startJobs(pool, mail, users, h)
+
+	// Ponte MQTT opcional: leituras publicadas pelos sensores no broker
+	closers := []func(){stopMonitor}
+	if cfg.MQTTBrokerURL != "" {
+		clientID := cfg.MQTTClientID
+		if clientID == "" {
+			hostname, _ := os.Hostname()
+			clientID = "go_api-" + hostname
+		}
+		bridge, err := mqttbridge.New(mqttbridge.Options{
+			BrokerURL: cfg.MQTTBrokerURL,
+			ClientID:  clientID,
+			Username:  cfg.MQTTUsername,
+			Password:  cfg.MQTTPassword,
+			Topic:     cfg.MQTTTopic,
+			QoS:       byte(cfg.MQTTQoS),
+		}, db, h.Telemetry)
+		if err != nil {
+			log.Fatalf("Erro fatal: MQTT_TOPIC: %v", err)
+		}
+		bridge.Start()
+		closers = append(closers, bridge.Close)
 	}
 
-	db.Model(&user).Updates(input)
-	c.JSON(http.StatusOK, user)
-}
+	// API gRPC opcional, na mesma camada service da API REST
+	if cfg.GRPCAddr != "" {
+		srv := grpcapi.New(h)
+		if err := grpcapi.Serve(srv, cfg.GRPCAddr); err != nil {
+			log.Fatalf("Erro fatal: GRPC_ADDR: %v", err)
+		}
+		closers = append(closers, srv.GracefulStop)
+	}
 
-func deleteUser(c *gin.Context) {
-	var user User
-	if err := db.First(&user, c.Param("id")).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		return
+	// CoAP opcional: leituras e heartbeat dos sensores com bateria, por UDP
+	if cfg.CoAPAddr != "" {
+		srv := coap.New(h.Devices, h.Telemetry)
+		if err := srv.Serve(cfg.CoAPAddr); err != nil {
+			log.Fatalf("Erro fatal: COAP_ADDR: %v", err)
+		}
+		closers = append(closers, srv.Close)
 	}
-	db.Delete(&user)
-	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
-}
 
-// --- 4. Função Principal ---
-func main() {
-	connectDatabase()
+	// Leituras que ainda estão no buffer da ingestão (depois da ponte MQTT,
+	// do gRPC e do CoAP, que ainda podem mandar leituras)
+	closers = append(closers, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		h.Telemetry.StopBuffer(ctx)
+	})
+
+	// Por último: os closers acima ainda podem enfileirar jobs
+	closers = append(closers, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := pool.Drain(ctx); err != nil {
+			log.Printf("Jobs interrompidos no encerramento: %v", err)
+		}
+	})
+	// Envia os spans que ainda estão no buffer (inclusive os dos jobs acima)
+	closers = append(closers, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Printf("Erro ao enviar os últimos traces: %v", err)
+		}
+	})
 
-	// Define modo de produção (remove logs de debug, melhora performance)
-	gin.SetMode(gin.ReleaseMode)
+	// Padrão: modo de produção (remove logs de debug, melhora performance)
+	gin.SetMode(cfg.GinMode)
 
-	r := gin.New()        // Cria router sem middlewares padrão
-	r.Use(gin.Recovery()) // Adiciona apenas recuperação de pânico (mais leve)
+	// HTTP/3 opcional (anuncia via Alt-Svc)
+	h3 := newHTTP3Server(cfg)
+	var extra []gin.HandlerFunc
+	if h3 != nil {
+		extra = append(extra, altSvc(h3))
+	}
+	r := router.New(h, extra...)
+	startHTTP3(h3, r, cfg.TLSCertFile, cfg.TLSKeyFile)
 
-	// Rotas
-	r.POST("/users", createUser)
-	r.GET("/users", getUsers)
-	r.GET("/users/:id", getUser)
-	r.PUT("/users/:id", updateUser)
-	r.DELETE("/users/:id", deleteUser)
+	// pprof e /debug/vars numa porta à parte, fora do balanceamento. Fecha
+	// depois de tudo: dá para perfilar até o encerramento.
+	if cfg.DebugAddr != "" {
+		closers = append(closers, startDebugServer(cfg.DebugAddr, router.NewDebug(h)))
+	}
 
-	// Roda na porta 8080
-	r.Run(":8080")
-}
\ No newline at end of file
+	// HTTPS opcional no próprio servidor (TLS_ADDR)
+	tlsSrv, plain, err := newTLSServer(cfg, r)
+	if err != nil {
+		log.Fatalf("Erro fatal: %v", err)
+	}
+
+	// Roda na porta 8080, com encerramento gracioso
+	runServer(plain, ":"+cfg.Port, tlsSrv, h3, h, cfg.ShutdownTimeout, closers...)
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"go_api/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Esquema que antes era criado pelo AutoMigrate na subida. Em bancos que já
+// existiam o AutoMigrate não muda nada (as tabelas já estão lá), então a
+// primeira execução só registra a migração.
+//
+// Próximas mudanças não devem mexer aqui nem depender de models.All(): cada
+// migração nova declara o que muda (AddColumn, RenameColumn, UPDATE de
+// backfill...) para continuar valendo quando os models mudarem de novo.
+var baseline = &gormigrate.Migration{
+	ID: "202610140001_baseline",
+	Migrate: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(models.All()...)
+	},
+}
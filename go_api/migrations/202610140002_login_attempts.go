@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Tabela das tentativas de login (bloqueio de conta). A struct é uma cópia
+// de models.LoginAttempt como estava nesta migração.
+var loginAttempts = &gormigrate.Migration{
+	ID: "202610140002_login_attempts",
+	Migrate: func(tx *gorm.DB) error {
+		type LoginAttempt struct {
+			ID        uint   `gorm:"primaryKey"`
+			UserID    *uint  `gorm:"index:idx_login_attempts_user_time"`
+			Login     string `gorm:"not null"`
+			IP        string
+			Success   bool      `gorm:"not null"`
+			CreatedAt time.Time `gorm:"index:idx_login_attempts_user_time"`
+		}
+		return tx.Migrator().CreateTable(&LoginAttempt{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("login_attempts")
+	},
+}
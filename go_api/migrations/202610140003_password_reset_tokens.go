@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Tokens de redefinição de senha (cópia de models.PasswordResetToken)
+var passwordResetTokens = &gormigrate.Migration{
+	ID: "202610140003_password_reset_tokens",
+	Migrate: func(tx *gorm.DB) error {
+		type PasswordResetToken struct {
+			ID        uint      `gorm:"primaryKey"`
+			UserID    uint      `gorm:"index;not null"`
+			TokenHash string    `gorm:"uniqueIndex;not null"`
+			ExpiresAt time.Time `gorm:"index;not null"`
+			UsedAt    *time.Time
+			CreatedAt time.Time
+		}
+		return tx.Migrator().CreateTable(&PasswordResetToken{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("password_reset_tokens")
+	},
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Coluna users.email_verified e tabela dos tokens de verificação. Quem já
+// tinha conta é marcado como verificado: o cadastro dessas contas não
+// mandava link, e REQUIRE_EMAIL_VERIFICATION não pode trancá-las para fora.
+var emailVerification = &gormigrate.Migration{
+	ID: "202610140004_email_verification",
+	Migrate: func(tx *gorm.DB) error {
+		type User struct {
+			EmailVerified bool `gorm:"not null;default:false"`
+		}
+		type EmailVerificationToken struct {
+			ID        uint      `gorm:"primaryKey"`
+			UserID    uint      `gorm:"index;not null"`
+			Email     string    `gorm:"not null"`
+			TokenHash string    `gorm:"uniqueIndex;not null"`
+			ExpiresAt time.Time `gorm:"index;not null"`
+			CreatedAt time.Time
+		}
+		// Num banco novo o baseline (AutoMigrate com o model atual) já criou a coluna
+		if !tx.Migrator().HasColumn(&User{}, "EmailVerified") {
+			if err := tx.Migrator().AddColumn(&User{}, "EmailVerified"); err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec("UPDATE users SET email_verified = ?", true).Error; err != nil {
+			return err
+		}
+		return tx.Migrator().CreateTable(&EmailVerificationToken{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		type User struct {
+			EmailVerified bool
+		}
+		if err := tx.Migrator().DropTable("email_verification_tokens"); err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&User{}, "EmailVerified")
+	},
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Índice trigram para o GET /users/search (ILIKE '%termo%' sem varrer a
+// tabela). A expressão tem que ser a mesma da consulta em
+// repository.searchExpr. Só no Postgres: no SQLite a busca varre a tabela.
+var userSearch = &gormigrate.Migration{
+	ID: "202610140005_user_search",
+	Migrate: func(tx *gorm.DB) error {
+		if tx.Dialector.Name() != "postgres" {
+			return nil
+		}
+		if err := tx.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+			return err
+		}
+		return tx.Exec(`CREATE INDEX IF NOT EXISTS idx_users_search_trgm ON users
+			USING gin ((name || ' ' || email || ' ' || "user") gin_trgm_ops)`).Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		if tx.Dialector.Name() != "postgres" {
+			return nil
+		}
+		return tx.Exec("DROP INDEX IF EXISTS idx_users_search_trgm").Error
+	},
+}
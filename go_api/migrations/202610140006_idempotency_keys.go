@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Respostas guardadas do Idempotency-Key (cópia de models.IdempotencyKey)
+var idempotencyKeys = &gormigrate.Migration{
+	ID: "202610140006_idempotency_keys",
+	Migrate: func(tx *gorm.DB) error {
+		type IdempotencyKey struct {
+			ID          uint   `gorm:"primaryKey"`
+			Scope       string `gorm:"uniqueIndex:idx_idempotency_keys_scope_key;not null"`
+			Key         string `gorm:"uniqueIndex:idx_idempotency_keys_scope_key;not null"`
+			Fingerprint string `gorm:"not null"`
+			Status      int    `gorm:"not null;default:0"`
+			ContentType string
+			Body        []byte
+			ExpiresAt   time.Time `gorm:"index;not null"`
+			CreatedAt   time.Time
+		}
+		return tx.Migrator().CreateTable(&IdempotencyKey{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("idempotency_keys")
+	},
+}
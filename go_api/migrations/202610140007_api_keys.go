@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Chaves de API das integrações máquina a máquina (cópia de models.APIKey)
+var apiKeys = &gormigrate.Migration{
+	ID: "202610140007_api_keys",
+	Migrate: func(tx *gorm.DB) error {
+		type APIKey struct {
+			ID         uint   `gorm:"primaryKey"`
+			UserID     uint   `gorm:"index;not null"`
+			Name       string `gorm:"not null"`
+			Prefix     string `gorm:"not null"`
+			KeyHash    string `gorm:"uniqueIndex;not null"`
+			ExpiresAt  *time.Time
+			LastUsedAt *time.Time
+			RevokedAt  *time.Time
+			CreatedAt  time.Time
+		}
+		return tx.Migrator().CreateTable(&APIKey{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("api_keys")
+	},
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Trilha de auditoria das alterações (cópia de models.AuditLog)
+var auditLogs = &gormigrate.Migration{
+	ID: "202610140008_audit_logs",
+	Migrate: func(tx *gorm.DB) error {
+		type AuditLog struct {
+			ID        uint  `gorm:"primaryKey"`
+			ActorID   *uint `gorm:"index"`
+			APIKeyID  *uint
+			Action    string `gorm:"not null"`
+			Entity    string `gorm:"index:idx_audit_logs_entity;not null"`
+			EntityID  uint   `gorm:"index:idx_audit_logs_entity;not null"`
+			Fields    []byte
+			Before    []byte
+			After     []byte
+			RequestID string
+			CreatedAt time.Time `gorm:"index"`
+		}
+		return tx.Migrator().CreateTable(&AuditLog{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("audit_logs")
+	},
+}
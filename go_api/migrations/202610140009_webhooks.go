@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Assinaturas de webhooks e a fila de entregas (cópias de models.Webhook e
+// models.WebhookDelivery)
+var webhooks = &gormigrate.Migration{
+	ID: "202610140009_webhooks",
+	Migrate: func(tx *gorm.DB) error {
+		type Webhook struct {
+			ID        uint   `gorm:"primaryKey"`
+			URL       string `gorm:"not null"`
+			Secret    string `gorm:"not null"`
+			Events    []byte `gorm:"not null"`
+			CreatedBy uint
+			CreatedAt time.Time
+		}
+		type WebhookDelivery struct {
+			ID            uint      `gorm:"primaryKey"`
+			WebhookID     uint      `gorm:"index;not null"`
+			Event         string    `gorm:"not null"`
+			Payload       []byte    `gorm:"not null"`
+			Status        string    `gorm:"index:idx_webhook_deliveries_due;not null"`
+			Attempts      int       `gorm:"not null"`
+			NextAttemptAt time.Time `gorm:"index:idx_webhook_deliveries_due"`
+			LastStatus    int
+			LastError     string
+			DeliveredAt   *time.Time
+			CreatedAt     time.Time
+		}
+		return tx.Migrator().CreateTable(&Webhook{}, &WebhookDelivery{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("webhook_deliveries", "webhooks")
+	},
+}
@@ -0,0 +1,73 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Tabela dos tenants (cópia de models.Tenant) e a coluna tenant_id nas
+// tabelas isoladas por tenant. Tudo o que já existia fica no tenant padrão
+// (id 1, slug "default"), que é o default da coluna.
+var tenants = &gormigrate.Migration{
+	ID: "202610140010_tenants",
+	Migrate: func(tx *gorm.DB) error {
+		type Tenant struct {
+			ID        uint   `gorm:"primaryKey"`
+			Slug      string `gorm:"uniqueIndex;not null"`
+			Name      string `gorm:"not null"`
+			CreatedAt time.Time
+		}
+		type User struct {
+			TenantID uint `gorm:"index;not null;default:1"`
+		}
+		type Device struct {
+			TenantID uint `gorm:"index;not null;default:1"`
+		}
+		type UserEvent struct {
+			TenantID uint `gorm:"index;not null;default:1"`
+		}
+		type AuditLog struct {
+			TenantID uint `gorm:"index;not null;default:1"`
+		}
+		type Webhook struct {
+			TenantID uint `gorm:"index;not null;default:1"`
+		}
+		if err := tx.Migrator().CreateTable(&Tenant{}); err != nil {
+			return err
+		}
+		// Primeira linha da tabela: recebe o id 1
+		if err := tx.Create(&Tenant{Slug: "default", Name: "Default"}).Error; err != nil {
+			return err
+		}
+		// Num banco novo o baseline (AutoMigrate com o model atual) já criou
+		// a coluna e o índice em users, devices e user_events
+		for _, model := range []interface{}{&User{}, &Device{}, &UserEvent{}, &AuditLog{}, &Webhook{}} {
+			if !tx.Migrator().HasColumn(model, "TenantID") {
+				if err := tx.Migrator().AddColumn(model, "TenantID"); err != nil {
+					return err
+				}
+			}
+			if !tx.Migrator().HasIndex(model, "TenantID") {
+				if err := tx.Migrator().CreateIndex(model, "TenantID"); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		type User struct{ TenantID uint }
+		type Device struct{ TenantID uint }
+		type UserEvent struct{ TenantID uint }
+		type AuditLog struct{ TenantID uint }
+		type Webhook struct{ TenantID uint }
+		for _, model := range []interface{}{&User{}, &Device{}, &UserEvent{}, &AuditLog{}, &Webhook{}} {
+			if err := tx.Migrator().DropColumn(model, "TenantID"); err != nil {
+				return err
+			}
+		}
+		return tx.Migrator().DropTable("tenants")
+	},
+}
@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Coluna users.avatar_url (POST /users/:id/avatar)
+var avatars = &gormigrate.Migration{
+	ID: "202610140011_avatars",
+	Migrate: func(tx *gorm.DB) error {
+		type User struct {
+			AvatarURL string
+		}
+		// Num banco novo o baseline (AutoMigrate com o model atual) já criou a coluna
+		if tx.Migrator().HasColumn(&User{}, "AvatarURL") {
+			return nil
+		}
+		return tx.Migrator().AddColumn(&User{}, "AvatarURL")
+	},
+	Rollback: func(tx *gorm.DB) error {
+		type User struct {
+			AvatarURL string
+		}
+		return tx.Migrator().DropColumn(&User{}, "AvatarURL")
+	},
+}
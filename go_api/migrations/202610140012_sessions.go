@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Sessões dos refresh tokens (cópia de models.Session)
+var sessions = &gormigrate.Migration{
+	ID: "202610140012_sessions",
+	Migrate: func(tx *gorm.DB) error {
+		type Session struct {
+			ID         uint   `gorm:"primaryKey"`
+			UserID     uint   `gorm:"index;not null"`
+			TokenHash  string `gorm:"uniqueIndex;not null"`
+			UserAgent  string
+			IP         string
+			ExpiresAt  time.Time `gorm:"index;not null"`
+			LastUsedAt time.Time
+			RevokedAt  *time.Time
+			CreatedAt  time.Time
+		}
+		return tx.Migrator().CreateTable(&Session{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("sessions")
+	},
+}
@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Identidades do login social (cópia de models.Identity)
+var identities = &gormigrate.Migration{
+	ID: "202610140013_identities",
+	Migrate: func(tx *gorm.DB) error {
+		type Identity struct {
+			ID        uint   `gorm:"primaryKey"`
+			UserID    uint   `gorm:"index;not null"`
+			Provider  string `gorm:"uniqueIndex:idx_identities_provider_subject;not null"`
+			Subject   string `gorm:"uniqueIndex:idx_identities_provider_subject;not null"`
+			Email     string
+			CreatedAt time.Time
+		}
+		return tx.Migrator().CreateTable(&Identity{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("identities")
+	},
+}
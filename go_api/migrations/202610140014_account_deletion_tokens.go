@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Códigos de confirmação da exclusão de conta (cópia de models.AccountDeletionToken)
+var accountDeletionTokens = &gormigrate.Migration{
+	ID: "202610140014_account_deletion_tokens",
+	Migrate: func(tx *gorm.DB) error {
+		type AccountDeletionToken struct {
+			ID        uint      `gorm:"primaryKey"`
+			UserID    uint      `gorm:"index;not null"`
+			TokenHash string    `gorm:"uniqueIndex;not null"`
+			ExpiresAt time.Time `gorm:"index;not null"`
+			UsedAt    *time.Time
+			CreatedAt time.Time
+		}
+		return tx.Migrator().CreateTable(&AccountDeletionToken{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("account_deletion_tokens")
+	},
+}
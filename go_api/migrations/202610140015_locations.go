@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Posições dos dispositivos (cópia de models.Location)
+var locations = &gormigrate.Migration{
+	ID: "202610140015_locations",
+	Migrate: func(tx *gorm.DB) error {
+		type Device struct {
+			ID uint `gorm:"primaryKey"`
+		}
+		type Location struct {
+			ID        uint      `gorm:"primaryKey"`
+			DeviceID  uint      `gorm:"index:idx_location_device_time;not null"`
+			Timestamp time.Time `gorm:"index:idx_location_device_time;not null"`
+			Lat       float64   `gorm:"not null"`
+			Lon       float64   `gorm:"not null"`
+			Accuracy  *float64
+			Device    Device `gorm:"constraint:OnDelete:CASCADE"`
+		}
+		return tx.Migrator().CreateTable(&Location{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("locations")
+	},
+}
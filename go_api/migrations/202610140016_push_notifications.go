@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Tokens push e notificações (cópia de models.PushToken, Notification e
+// NotificationDelivery)
+var pushNotifications = &gormigrate.Migration{
+	ID: "202610140016_push_notifications",
+	Migrate: func(tx *gorm.DB) error {
+		type Device struct {
+			ID uint `gorm:"primaryKey"`
+		}
+		type PushToken struct {
+			ID        uint   `gorm:"primaryKey"`
+			DeviceID  uint   `gorm:"uniqueIndex;not null"`
+			Platform  string `gorm:"not null"`
+			Token     string `gorm:"uniqueIndex;not null"`
+			CreatedAt time.Time
+			UpdatedAt time.Time
+			Device    Device `gorm:"constraint:OnDelete:CASCADE"`
+		}
+		type Notification struct {
+			ID        uint   `gorm:"primaryKey"`
+			TenantID  uint   `gorm:"index;not null;default:1"`
+			Title     string `gorm:"not null"`
+			Body      string
+			Data      json.RawMessage
+			Source    string `gorm:"not null"`
+			CreatedBy *uint
+			CreatedAt time.Time
+		}
+		type NotificationDelivery struct {
+			ID             uint      `gorm:"primaryKey"`
+			NotificationID uint      `gorm:"index;not null"`
+			DeviceID       uint      `gorm:"index;not null"`
+			Status         string    `gorm:"index:idx_notification_deliveries_due;not null"`
+			Attempts       int       `gorm:"not null"`
+			NextAttemptAt  time.Time `gorm:"index:idx_notification_deliveries_due"`
+			LastError      string
+			DeliveredAt    *time.Time
+			CreatedAt      time.Time
+		}
+		return tx.Migrator().CreateTable(&PushToken{}, &Notification{}, &NotificationDelivery{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("notification_deliveries", "notifications", "push_tokens")
+	},
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Agregados por hora/dia das leituras antigas (cópia de models.ReadingRollup)
+var readingRollups = &gormigrate.Migration{
+	ID: "202610140017_reading_rollups",
+	Migrate: func(tx *gorm.DB) error {
+		type Device struct {
+			ID uint `gorm:"primaryKey"`
+		}
+		type ReadingRollup struct {
+			ID         uint      `gorm:"primaryKey"`
+			DeviceID   uint      `gorm:"uniqueIndex:idx_rollup_key;not null"`
+			Resolution string    `gorm:"uniqueIndex:idx_rollup_key;not null"`
+			Metric     string    `gorm:"uniqueIndex:idx_rollup_key;not null"`
+			Bucket     time.Time `gorm:"uniqueIndex:idx_rollup_key;not null"`
+			Count      int64     `gorm:"not null"`
+			Sum        float64   `gorm:"not null"`
+			Min        float64   `gorm:"not null"`
+			Max        float64   `gorm:"not null"`
+			Device     Device    `gorm:"constraint:OnDelete:CASCADE"`
+		}
+		return tx.Migrator().CreateTable(&ReadingRollup{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("reading_rollups")
+	},
+}
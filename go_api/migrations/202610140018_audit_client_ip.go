@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// IP do cliente em cada registro da auditoria (o do X-Forwarded-For atrás
+// do nginx). Registros antigos ficam sem.
+var auditClientIP = &gormigrate.Migration{
+	ID: "202610140018_audit_client_ip",
+	Migrate: func(tx *gorm.DB) error {
+		type AuditLog struct {
+			ClientIP string
+		}
+		return tx.Migrator().AddColumn(&AuditLog{}, "ClientIP")
+	},
+	Rollback: func(tx *gorm.DB) error {
+		type AuditLog struct {
+			ClientIP string
+		}
+		return tx.Migrator().DropColumn(&AuditLog{}, "ClientIP")
+	},
+}
@@ -0,0 +1,18 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Índice da paginação por cursor das leituras (device_id = ? AND id > ?
+// ORDER BY id): cada página começa direto no ID, sem varrer as anteriores
+var readingKeyset = &gormigrate.Migration{
+	ID: "202610140019_reading_keyset",
+	Migrate: func(tx *gorm.DB) error {
+		return tx.Exec("CREATE INDEX IF NOT EXISTS idx_reading_device_id ON readings (device_id, id)").Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Exec("DROP INDEX IF EXISTS idx_reading_device_id").Error
+	},
+}
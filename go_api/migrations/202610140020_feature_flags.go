@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Feature flags da implantação (cópia de models.FeatureFlag)
+var featureFlags = &gormigrate.Migration{
+	ID: "202610140020_feature_flags",
+	Migrate: func(tx *gorm.DB) error {
+		type FeatureFlag struct {
+			ID          uint   `gorm:"primaryKey"`
+			Key         string `gorm:"uniqueIndex;not null"`
+			Description string
+			Enabled     bool   `gorm:"not null;default:false"`
+			Percentage  int    `gorm:"not null;default:0"`
+			TenantIDs   []byte `gorm:"not null"`
+			Groups      []byte `gorm:"not null"`
+			UpdatedBy   uint
+			CreatedAt   time.Time
+			UpdatedAt   time.Time
+		}
+		return tx.Migrator().CreateTable(&FeatureFlag{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("feature_flags")
+	},
+}
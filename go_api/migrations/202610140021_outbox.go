@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Outbox dos eventos de domínio (cópia de models.OutboxEvent)
+var outbox = &gormigrate.Migration{
+	ID: "202610140021_outbox",
+	Migrate: func(tx *gorm.DB) error {
+		type OutboxEvent struct {
+			ID            uint       `gorm:"primaryKey"`
+			TenantID      uint       `gorm:"not null;default:1"`
+			Type          string     `gorm:"not null"`
+			Entity        string     `gorm:"not null"`
+			Payload       []byte     `gorm:"not null"`
+			OccurredAt    time.Time  `gorm:"not null"`
+			PublishedAt   *time.Time `gorm:"index:idx_outbox_pending"`
+			Attempts      int        `gorm:"not null"`
+			NextAttemptAt time.Time  `gorm:"index:idx_outbox_pending"`
+			LastError     string
+		}
+		return tx.Migrator().CreateTable(&OutboxEvent{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("outbox_events")
+	},
+}
@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Grupos e participações (cópia de models.Group e Membership)
+var groups = &gormigrate.Migration{
+	ID: "202610140022_groups",
+	Migrate: func(tx *gorm.DB) error {
+		type User struct {
+			ID uint `gorm:"primaryKey"`
+		}
+		type Group struct {
+			ID          uint   `gorm:"primaryKey"`
+			TenantID    uint   `gorm:"index;not null;default:1"`
+			Name        string `gorm:"not null"`
+			Description string
+			CreatedBy   uint
+			CreatedAt   time.Time
+		}
+		type Membership struct {
+			ID        uint   `gorm:"primaryKey"`
+			GroupID   uint   `gorm:"uniqueIndex:idx_memberships_group_user;not null"`
+			UserID    uint   `gorm:"uniqueIndex:idx_memberships_group_user;index;not null"`
+			Role      string `gorm:"not null"`
+			CreatedAt time.Time
+			User      User  `gorm:"constraint:OnDelete:CASCADE"`
+			Group     Group `gorm:"constraint:OnDelete:CASCADE"`
+		}
+		return tx.Migrator().CreateTable(&Group{}, &Membership{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("memberships", "groups")
+	},
+}
@@ -0,0 +1,78 @@
+package migrations
+
+import (
+	"go_api/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// UUID público de usuários e dispositivos (ver models/public_id.go), com o
+// UUID do dono copiado em devices.user_uuid. Os registros que já existiam
+// ganham um UUID aqui, um por linha (o SQLite não gera UUID); a chave
+// primária e as FKs continuam no bigint. Os índices únicos só são criados
+// depois do preenchimento.
+var publicIDs = &gormigrate.Migration{
+	ID: "202610140023_public_ids",
+	Migrate: func(tx *gorm.DB) error {
+		type User struct {
+			UUID string `gorm:"uniqueIndex;size:36"`
+		}
+		type Device struct {
+			UUID     string `gorm:"uniqueIndex;size:36"`
+			UserUUID string `gorm:"index;size:36"`
+		}
+		columns := []struct {
+			model interface{}
+			field string
+		}{{&User{}, "UUID"}, {&Device{}, "UUID"}, {&Device{}, "UserUUID"}}
+		// Num banco novo o baseline já criou as colunas e os índices
+		for _, c := range columns {
+			if !tx.Migrator().HasColumn(c.model, c.field) {
+				if err := tx.Migrator().AddColumn(c.model, c.field); err != nil {
+					return err
+				}
+			}
+		}
+		for _, table := range []string{"users", "devices"} {
+			var ids []uint
+			if err := tx.Table(table).Where("uuid IS NULL OR uuid = ''").Pluck("id", &ids).Error; err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if err := tx.Table(table).Where("id = ?", id).Update("uuid", models.NewUUID()).Error; err != nil {
+					return err
+				}
+			}
+		}
+		err := tx.Exec(`UPDATE devices SET user_uuid = (SELECT uuid FROM users WHERE users.id = devices.user_id)
+			WHERE user_uuid IS NULL OR user_uuid = ''`).Error
+		if err != nil {
+			return err
+		}
+		for _, c := range columns {
+			if !tx.Migrator().HasIndex(c.model, c.field) {
+				if err := tx.Migrator().CreateIndex(c.model, c.field); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		type User struct{ UUID string }
+		type Device struct {
+			UUID     string
+			UserUUID string
+		}
+		if err := tx.Migrator().DropColumn(&User{}, "UUID"); err != nil {
+			return err
+		}
+		for _, field := range []string{"UUID", "UserUUID"} {
+			if err := tx.Migrator().DropColumn(&Device{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,71 @@
+package migrations
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// created_at/updated_at em usuários e dispositivos. Para os registros que já
+// existiam, a auditoria diz quando foram criados (primeiro registro) e
+// alterados (último); sem auditoria, fica a hora da migração.
+var timestamps = &gormigrate.Migration{
+	ID: "202610140024_timestamps",
+	Migrate: func(tx *gorm.DB) error {
+		type User struct {
+			CreatedAt time.Time `gorm:"index"`
+			UpdatedAt time.Time `gorm:"index"`
+		}
+		type Device struct {
+			CreatedAt time.Time `gorm:"index"`
+			UpdatedAt time.Time `gorm:"index"`
+		}
+		tables := []struct {
+			model  interface{}
+			table  string
+			entity string
+		}{{&User{}, "users", "user"}, {&Device{}, "devices", "device"}}
+		for _, t := range tables {
+			// Num banco novo o baseline já criou as colunas e os índices
+			for _, field := range []string{"CreatedAt", "UpdatedAt"} {
+				if !tx.Migrator().HasColumn(t.model, field) {
+					if err := tx.Migrator().AddColumn(t.model, field); err != nil {
+						return err
+					}
+				}
+			}
+			audit := `(SELECT %s(created_at) FROM audit_logs WHERE entity = '%s' AND entity_id = %s.id)`
+			first, last := fmt.Sprintf(audit, "MIN", t.entity, t.table), fmt.Sprintf(audit, "MAX", t.entity, t.table)
+			err := tx.Exec("UPDATE "+t.table+" SET created_at = COALESCE("+first+", ?) WHERE created_at IS NULL", time.Now().UTC()).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Exec("UPDATE " + t.table + " SET updated_at = COALESCE(" + last + ", created_at) WHERE updated_at IS NULL").Error
+			if err != nil {
+				return err
+			}
+			for _, field := range []string{"CreatedAt", "UpdatedAt"} {
+				if !tx.Migrator().HasIndex(t.model, field) {
+					if err := tx.Migrator().CreateIndex(t.model, field); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		type User struct{ CreatedAt, UpdatedAt time.Time }
+		type Device struct{ CreatedAt, UpdatedAt time.Time }
+		for _, model := range []interface{}{&User{}, &Device{}} {
+			for _, field := range []string{"CreatedAt", "UpdatedAt"} {
+				if err := tx.Migrator().DropColumn(model, field); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Soft delete dos dispositivos: o registro removido fica como tombstone para
+// o GET /sync (os dados dele continuam sendo apagados na remoção)
+var deviceSoftDelete = &gormigrate.Migration{
+	ID: "202610140025_device_soft_delete",
+	Migrate: func(tx *gorm.DB) error {
+		type Device struct {
+			DeletedAt gorm.DeletedAt `gorm:"index"`
+		}
+		// Num banco novo o baseline já criou a coluna e o índice
+		if !tx.Migrator().HasColumn(&Device{}, "DeletedAt") {
+			if err := tx.Migrator().AddColumn(&Device{}, "DeletedAt"); err != nil {
+				return err
+			}
+		}
+		if !tx.Migrator().HasIndex(&Device{}, "DeletedAt") {
+			return tx.Migrator().CreateIndex(&Device{}, "DeletedAt")
+		}
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		type Device struct{ DeletedAt gorm.DeletedAt }
+		// Os tombstones não voltam a ser dispositivos
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").Delete(&Device{}).Error; err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&Device{}, "DeletedAt")
+	},
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Admin que agia como o ator (token de POST /admin/impersonate/:id) em cada
+// registro da auditoria
+var auditImpersonator = &gormigrate.Migration{
+	ID: "202610140026_audit_impersonator",
+	Migrate: func(tx *gorm.DB) error {
+		type AuditLog struct {
+			ImpersonatorID *uint `gorm:"index"`
+		}
+		// Num banco novo o baseline já criou a coluna e o índice
+		if !tx.Migrator().HasColumn(&AuditLog{}, "ImpersonatorID") {
+			if err := tx.Migrator().AddColumn(&AuditLog{}, "ImpersonatorID"); err != nil {
+				return err
+			}
+		}
+		if !tx.Migrator().HasIndex(&AuditLog{}, "ImpersonatorID") {
+			return tx.Migrator().CreateIndex(&AuditLog{}, "ImpersonatorID")
+		}
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		type AuditLog struct {
+			ImpersonatorID *uint
+		}
+		return tx.Migrator().DropColumn(&AuditLog{}, "ImpersonatorID")
+	},
+}
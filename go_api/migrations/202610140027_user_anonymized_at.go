@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Quando a conta foi apagada de vez (exclusão confirmada ou PurgeDeleted):
+// o registro anônimo não pode mais ser restaurado. Os já anonimizados antes
+// da coluna são reconhecidos pelo e-mail em deleted.invalid.
+var userAnonymizedAt = &gormigrate.Migration{
+	ID: "202610140027_user_anonymized_at",
+	Migrate: func(tx *gorm.DB) error {
+		type User struct {
+			AnonymizedAt *time.Time `gorm:"index"`
+		}
+		// Num banco novo o baseline já criou a coluna e o índice
+		if !tx.Migrator().HasColumn(&User{}, "AnonymizedAt") {
+			if err := tx.Migrator().AddColumn(&User{}, "AnonymizedAt"); err != nil {
+				return err
+			}
+		}
+		if !tx.Migrator().HasIndex(&User{}, "AnonymizedAt") {
+			if err := tx.Migrator().CreateIndex(&User{}, "AnonymizedAt"); err != nil {
+				return err
+			}
+		}
+		return tx.Exec("UPDATE users SET anonymized_at = COALESCE(deleted_at, updated_at) WHERE anonymized_at IS NULL AND email LIKE ?", "%@deleted.invalid").Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		type User struct {
+			AnonymizedAt *time.Time
+		}
+		return tx.Migrator().DropColumn(&User{}, "AnonymizedAt")
+	},
+}
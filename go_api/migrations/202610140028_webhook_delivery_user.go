@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Dono de cada entrega de webhook, para a exclusão da conta achar os
+// payloads com os dados dele. As entregas que já existiam ganham o user_id
+// lido do próprio payload.
+var webhookDeliveryUser = &gormigrate.Migration{
+	ID: "202610140028_webhook_delivery_user",
+	Migrate: func(tx *gorm.DB) error {
+		type WebhookDelivery struct {
+			ID      uint
+			UserID  uint `gorm:"index;not null;default:0"`
+			Payload []byte
+		}
+		if err := tx.Migrator().AddColumn(&WebhookDelivery{}, "UserID"); err != nil {
+			return err
+		}
+		if err := tx.Migrator().CreateIndex(&WebhookDelivery{}, "UserID"); err != nil {
+			return err
+		}
+		var batch []WebhookDelivery
+		return tx.Select("id", "payload").FindInBatches(&batch, 500, func(*gorm.DB, int) error {
+			for _, d := range batch {
+				var payload struct {
+					UserID uint `json:"user_id"`
+				}
+				if json.Unmarshal(d.Payload, &payload) != nil || payload.UserID == 0 {
+					continue
+				}
+				if err := tx.Model(&WebhookDelivery{}).Where("id = ?", d.ID).Update("user_id", payload.UserID).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		type WebhookDelivery struct {
+			UserID uint
+		}
+		return tx.Migrator().DropColumn(&WebhookDelivery{}, "UserID")
+	},
+}
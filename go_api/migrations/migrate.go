@@ -0,0 +1,77 @@
+package migrations
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// --- Migrações Versionadas ---
+// Cada mudança de esquema (ou de dados) é uma migração com ID fixo, aplicada
+// uma única vez e registrada na tabela "migrations". Uma migração já
+// publicada nunca é editada: quem precisa mudar algo escreve uma nova.
+//
+// IDs no formato AAAAMMDDNNNN_descricao, para a ordem ficar óbvia.
+var all = []*gormigrate.Migration{
+	baseline,
+	loginAttempts,
+	passwordResetTokens,
+	emailVerification,
+	userSearch,
+	idempotencyKeys,
+	apiKeys,
+	auditLogs,
+	webhooks,
+	tenants,
+	avatars,
+	sessions,
+	identities,
+	accountDeletionTokens,
+	locations,
+	pushNotifications,
+	readingRollups,
+	auditClientIP,
+	readingKeyset,
+	featureFlags,
+	outbox,
+	groups,
+	publicIDs,
+	timestamps,
+	deviceSoftDelete,
+	auditImpersonator,
+	userAnonymizedAt,
+	webhookDeliveryUser,
+}
+
+// Chave do advisory lock do Postgres (qualquer int64 fixo serve)
+const lockKey = 20261014
+
+// Aplica as migrações pendentes. No Postgres, um advisory lock garante que
+// só uma réplica migra por vez: as outras esperam e depois não encontram
+// nada pendente. Tudo roda numa transação (DDL é transacional no Postgres e
+// no SQLite), então uma migração que falha não deixa o esquema pela metade.
+func Run(db *gorm.DB, driver string) error {
+	// Connection: lock, migrações e unlock precisam usar a mesma conexão
+	return db.Connection(func(conn *gorm.DB) error {
+		if driver == "postgres" {
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", lockKey).Error; err != nil {
+				return fmt.Errorf("advisory lock: %w", err)
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(?)", lockKey)
+		}
+
+		m := gormigrate.New(conn, &gormigrate.Options{
+			TableName:      "migrations",
+			IDColumnName:   "id",
+			IDColumnSize:   255,
+			UseTransaction: true,
+		}, all)
+		if err := m.Migrate(); err != nil {
+			return err
+		}
+		log.Printf("Migrações em dia (última: %s)", all[len(all)-1].ID)
+		return nil
+	})
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// --- Exclusão de Conta ---
+// DELETE /me em dois passos: o pedido manda um código por e-mail e só o
+// código confirma. Como na redefinição de senha, só o hash fica no banco e
+// cada código vale uma vez até ExpiresAt.
+type AccountDeletionToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"index;not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Segundo passo do DELETE /me
+type DeletionConfirmInput struct {
+	Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// --- Reconhecimento de Atividade (Contexto do Usuário) ---
+// Cada registro representa uma "janela" de tempo em que o usuário estava
+// em uma atividade (andando, sentado, em veículo...). O rótulo pode vir
+// pronto do cliente ou ser inferido a partir das leituras do acelerômetro.
+type ActivitySample struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"index:idx_activity_user_time;not null" json:"user_id"`
+	Activity    string    `gorm:"not null" json:"activity"`
+	Source      string    `gorm:"not null" json:"source"` // "client" ou "sensor"
+	Confidence  float64   `json:"confidence"`
+	StartedAt   time.Time `gorm:"index:idx_activity_user_time;not null" json:"started_at"`
+	EndedAt     time.Time `gorm:"not null" json:"ended_at"`
+	DurationMs  int64     `gorm:"not null" json:"duration_ms"`
+	SampleCount int       `json:"sample_count"`
+	MeanAccel   float64   `json:"mean_accel"`
+	StdAccel    float64   `json:"std_accel"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// --- Chaves de API ---
+// Credencial de longa duração para sensores e jobs em lote (X-API-Key),
+// que não devem guardar usuário e senha nem renovar JWT. A chave age em nome
+// do dono, com o papel dele. Como o token dos dispositivos, aparece uma
+// única vez na criação e só o hash SHA-256 fica no banco; Prefix (o começo
+// da chave) serve para o dono reconhecer qual é qual na listagem.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	Name       string     `gorm:"not null" json:"name"`
+	Prefix     string     `gorm:"not null" json:"prefix"`
+	KeyHash    string     `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Entrada da criação; sem expires_at, a chave vale até ser revogada
+type APIKeyInput struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// --- Auditoria ---
+// Quem mudou o quê e quando, para usuários, dispositivos e chaves de API.
+// Before/After são o JSON público da entidade (sem senha nem tokens); na
+// criação só há After, na remoção só Before. Gravado na mesma transação da
+// alteração: não existe mudança sem registro.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	// Admin emitiu um token agindo como o usuário (POST /admin/impersonate/:id)
+	AuditImpersonate = "impersonate"
+)
+
+type AuditLog struct {
+	ID       uint `gorm:"primaryKey" json:"id"`
+	TenantID uint `gorm:"index;not null;default:1" json:"-"`
+	// Sem ator: operação pública (cadastro) ou interna
+	ActorID  *uint           `gorm:"index" json:"actor_id"`
+	APIKeyID *uint           `json:"api_key_id,omitempty"` // autenticado por chave de API
+	Action   string          `gorm:"not null" json:"action"`
+	Entity   string          `gorm:"index:idx_audit_logs_entity;not null" json:"entity"` // user, device, api_key
+	EntityID uint            `gorm:"index:idx_audit_logs_entity;not null" json:"entity_id"`
+	Fields   json.RawMessage `json:"fields,omitempty"` // colunas alteradas (update)
+	Before   json.RawMessage `json:"before,omitempty"`
+	After    json.RawMessage `json:"after,omitempty"`
+	// Admin que agia como o ator, com um token de personificação
+	ImpersonatorID *uint `gorm:"index" json:"impersonator_id,omitempty"`
+	// Liga o registro aos logs da requisição
+	RequestID string    `json:"request_id,omitempty"`
+	ClientIP  string    `json:"client_ip,omitempty"` // o real, atrás do nginx (TRUSTED_PROXIES)
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// --- Registro de Consentimento ---
+// Cada concessão é uma linha nova (nunca sobrescrita), então o histórico de
+// quando o usuário concedeu ou retirou cada finalidade fica preservado.
+// Um consentimento está ativo enquanto RevokedAt for nulo.
+// Finalidades reconhecidas
+const (
+	PurposeLocationTracking = "location_tracking"
+	PurposeActivityTracking = "activity_tracking"
+	PurposeAnalytics        = "analytics"
+	PurposeNotifications    = "notifications"
+)
+
+type Consent struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index:idx_consent_user_purpose;not null" json:"user_id"`
+	Purpose   string     `gorm:"index:idx_consent_user_purpose;not null" json:"purpose"`
+	Version   string     `gorm:"not null" json:"version"` // versão do texto aceito
+	GrantedAt time.Time  `gorm:"not null" json:"granted_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
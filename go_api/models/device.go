@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// --- Dispositivos ---
+// Celulares, wearables e sensores registrados na conta de um usuário.
+// O token do dispositivo é gerado pelo servidor, mostrado uma única vez na
+// criação e guardado apenas como hash SHA-256.
+type Device struct {
+	ID       uint       `gorm:"primaryKey" json:"id"`
+	UUID     string     `gorm:"uniqueIndex;size:36" json:"uuid"` // ID público (ver public_id.go)
+	UserID   uint       `gorm:"index;not null" json:"user_id"`
+	UserUUID string     `gorm:"index;size:36" json:"user_uuid"`            // UUID do dono
+	TenantID uint       `gorm:"index;not null;default:1" json:"tenant_id"` // o mesmo do dono
+	Name     string     `gorm:"not null" json:"name"`
+	Type     string     `gorm:"not null" json:"type"`
+	Token    string     `gorm:"uniqueIndex;not null" json:"-"` // hash do token
+	LastSeen *time.Time `json:"last_seen"`
+	// last_seen há menos de PRESENCE_TIMEOUT; calculado na leitura, não
+	// gravado
+	Online bool `gorm:"-" json:"online"`
+	// Como no usuário; o heartbeat (last_seen) não conta como alteração
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt time.Time `gorm:"index" json:"updated_at"`
+	// Soft delete: os dados do dispositivo (leituras, posições...) são
+	// apagados, mas o registro fica para o GET /sync avisar os clientes
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	// Remover o usuário remove os dispositivos dele (FK com ON DELETE CASCADE)
+	Owner User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// Entrada da criação e da edição
+type DeviceInput struct {
+	Name string `json:"name" binding:"required"`
+	Type string `json:"type" binding:"required,oneof=phone tablet wearable sensor gateway other"`
+}
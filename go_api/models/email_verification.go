@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// --- Tokens de Verificação de E-mail ---
+// Como na redefinição de senha, só o hash fica no banco. Email guarda o
+// endereço confirmado: se o usuário trocar de e-mail antes de clicar, o
+// link antigo não confirma o novo.
+type EmailVerificationToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	Email     string    `gorm:"not null" json:"email"`
+	TokenHash string    `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// --- Event Sourcing do Usuário ---
+// Toda alteração de usuário vira um evento imutável (append-only).
+// A tabela 'users' continua existindo, mas é apenas a projeção do estado
+// atual, atualizada na mesma transação em que o evento é gravado.
+const (
+	EventUserRegistered  = "UserRegistered"
+	EventNameChanged     = "NameChanged"
+	EventEmailChanged    = "EmailChanged"
+	EventUsernameChanged = "UsernameChanged"
+	EventPasswordChanged = "PasswordChanged"
+	EventRoleChanged     = "RoleChanged"
+	EventUserDeleted     = "UserDeleted"
+	EventUserRestored    = "UserRestored"
+	EventEmailVerified   = "EmailVerified"
+	EventAvatarChanged   = "AvatarChanged"
+)
+
+// O ID é sequencial e global: serve de cursor para quem consome os eventos
+type UserEvent struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	UserID    uint            `gorm:"index;not null" json:"user_id"`
+	TenantID  uint            `gorm:"index;not null;default:1" json:"-"` // o mesmo do usuário
+	Type      string          `gorm:"not null" json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `gorm:"index" json:"created_at"`
+}
+
+// Dados carregados pelos eventos. A senha nunca entra no stream.
+type UserEventData struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+	User  string `json:"user,omitempty"`
+	Role  string `json:"role,omitempty"`
+	// Vazio no AvatarChanged: avatar removido
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
+
+func NewUserEvent(userID uint, eventType string, data UserEventData) UserEvent {
+	raw, _ := json.Marshal(data)
+	return UserEvent{UserID: userID, Type: eventType, Data: raw}
+}
+
+// Gera os eventos correspondentes a um update. Segue a mesma regra do
+// db.Updates com struct: campos vazios significam "não alterar".
+func UserChangeEvents(current User, input UpdateUserInput) []UserEvent {
+	var events []UserEvent
+	if input.Name != "" && input.Name != current.Name {
+		events = append(events, NewUserEvent(current.ID, EventNameChanged, UserEventData{Name: input.Name}))
+	}
+	if input.Email != "" && input.Email != current.Email {
+		events = append(events, NewUserEvent(current.ID, EventEmailChanged, UserEventData{Email: input.Email}))
+	}
+	if input.User != "" && input.User != current.User {
+		events = append(events, NewUserEvent(current.ID, EventUsernameChanged, UserEventData{User: input.User}))
+	}
+	return events
+}
+
+// Aplica um evento sobre o estado. Retorna false quando o usuário deixou de existir.
+func ApplyUserEvent(u *User, e UserEvent) bool {
+	var data UserEventData
+	json.Unmarshal(e.Data, &data)
+
+	switch e.Type {
+	case EventUserRegistered:
+		*u = User{ID: e.UserID, Name: data.Name, Email: data.Email, User: data.User, Role: data.Role}
+	case EventNameChanged:
+		u.Name = data.Name
+	case EventEmailChanged:
+		u.Email = data.Email
+		u.EmailVerified = false
+	case EventEmailVerified:
+		u.EmailVerified = true
+	case EventUsernameChanged:
+		u.User = data.User
+	case EventRoleChanged:
+		u.Role = data.Role
+	case EventAvatarChanged:
+		u.AvatarURL = data.AvatarURL
+	case EventUserDeleted:
+		return false
+	case EventUserRestored:
+		// Volta com o estado que tinha antes da remoção
+	}
+	return true
+}
+
+// Reconstrói o usuário a partir do stream (projeção em memória)
+func ReplayUser(events []UserEvent) (User, bool) {
+	var u User
+	exists := false
+	for _, e := range events {
+		exists = ApplyUserEvent(&u, e)
+	}
+	return u, exists
+}
+
+// --- Feed de Alterações ---
+// Formato de cada linha do feed. "cursor" é o valor a ser enviado em
+// ?since= na próxima chamada para continuar de onde parou.
+type ChangeEntry struct {
+	Cursor    uint            `json:"cursor"`
+	Entity    string          `json:"entity"`
+	EntityID  uint            `json:"entity_id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func ToChangeEntry(e UserEvent) ChangeEntry {
+	return ChangeEntry{
+		Cursor:    e.ID,
+		Entity:    "user",
+		EntityID:  e.UserID,
+		Type:      e.Type,
+		Data:      e.Data,
+		CreatedAt: e.CreatedAt,
+	}
+}
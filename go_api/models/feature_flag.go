@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// --- Feature Flags ---
+// Liga um comportamento novo aos poucos, sem novo deploy. Uma flag vale
+// para a implantação inteira (não tem tenant_id) e só o admin da
+// plataforma a altera. Desligada (Enabled false), vale false para todos;
+// ligada, vale:
+//   - só nas réplicas de Groups (REPLICA_GROUP), se a lista não for vazia;
+//   - para todo tenant de TenantIDs;
+//   - para Percentage% dos usuários, sempre os mesmos (hash da flag com o ID).
+type FeatureFlag struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	Key         string          `gorm:"uniqueIndex;not null" json:"key"`
+	Description string          `json:"description"`
+	Enabled     bool            `gorm:"not null;default:false" json:"enabled"`
+	Percentage  int             `gorm:"not null;default:0" json:"percentage"`
+	TenantIDs   json.RawMessage `gorm:"not null" json:"tenant_ids"` // lista JSON, ex: [1, 3]
+	Groups      json.RawMessage `gorm:"not null" json:"groups"`     // lista JSON, ex: ["canary"]
+	UpdatedBy   uint            `json:"updated_by"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// PUT /admin/flags/:key (substitui as regras)
+type FeatureFlagInput struct {
+	Description string   `json:"description" binding:"max=500"`
+	Enabled     bool     `json:"enabled"`
+	Percentage  int      `json:"percentage" binding:"min=0,max=100"`
+	TenantIDs   []uint   `json:"tenant_ids" binding:"max=1000"`
+	Groups      []string `json:"groups" binding:"max=20,dive,min=1,max=50"`
+}
+
+// POST /admin/flags; a chave é a que os handlers consultam
+type CreateFeatureFlagInput struct {
+	Key string `json:"key" binding:"required,min=2,max=100,slug"`
+	FeatureFlagInput
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// --- Grupos ---
+// Espaços compartilhados: os membros de um grupo enxergam a frota de
+// dispositivos uns dos outros (GET /groups/:id/devices). Quem cria o grupo
+// entra como owner; owners (e admins do tenant) adicionam e removem
+// membros, e o grupo nunca fica sem owner.
+const (
+	GroupOwner  = "owner"
+	GroupMember = "member"
+)
+
+type Group struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	TenantID    uint      `gorm:"index;not null;default:1" json:"tenant_id"`
+	Name        string    `gorm:"not null" json:"name"`
+	Description string    `json:"description"`
+	CreatedBy   uint      `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Sem tenant_id: vale o do grupo. User e Group vêm preenchidos conforme a
+// consulta (membros de um grupo ou grupos de um usuário).
+type Membership struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	GroupID   uint      `gorm:"uniqueIndex:idx_memberships_group_user;not null" json:"group_id"`
+	UserID    uint      `gorm:"uniqueIndex:idx_memberships_group_user;index;not null" json:"user_id"`
+	Role      string    `gorm:"not null" json:"role"`
+	CreatedAt time.Time `json:"joined_at"`
+
+	// Remover o grupo ou o usuário remove a participação
+	User  *User  `gorm:"constraint:OnDelete:CASCADE" json:"user,omitempty"`
+	Group *Group `gorm:"constraint:OnDelete:CASCADE" json:"group,omitempty"`
+}
+
+type GroupInput struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// POST /groups/:id/members; sem role, entra como member
+type MembershipInput struct {
+	UserID Ref    `json:"user_id"` // obrigatório (conferido no handler)
+	Role   string `json:"role" binding:"omitempty,oneof=owner member"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// --- Chaves de Idempotência ---
+// Resposta guardada de uma requisição com Idempotency-Key, para devolver de
+// novo quando o cliente repetir a mesma requisição. Status 0 = a primeira
+// requisição ainda está em andamento.
+type IdempotencyKey struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Scope       string    `gorm:"uniqueIndex:idx_idempotency_keys_scope_key;not null" json:"scope"` // ex: "POST /users"
+	Key         string    `gorm:"uniqueIndex:idx_idempotency_keys_scope_key;not null" json:"key"`
+	Fingerprint string    `gorm:"not null" json:"-"` // SHA-256 do corpo
+	Status      int       `gorm:"not null;default:0" json:"status"`
+	ContentType string    `json:"-"`
+	Body        []byte    `json:"-"`
+	ExpiresAt   time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
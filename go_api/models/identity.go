@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// --- Identidades Externas ---
+// Conta do usuário num provedor de login social (Google, GitHub). Subject é
+// o ID do usuário no provedor, que não muda quando o e-mail muda; um mesmo
+// usuário pode ter uma identidade por provedor.
+type Identity struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	Provider  string    `gorm:"uniqueIndex:idx_identities_provider_subject;not null" json:"provider"`
+	Subject   string    `gorm:"uniqueIndex:idx_identities_provider_subject;not null" json:"-"`
+	Email     string    `json:"email"` // e-mail informado pelo provedor no vínculo
+	CreatedAt time.Time `json:"created_at"`
+}
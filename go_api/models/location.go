@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// --- Localização dos Dispositivos ---
+// Cada ponto é uma posição (WGS84, em graus) informada pelo dispositivo,
+// com a precisão em metros quando o GPS/rede informa. A posição "atual" é
+// o ponto de timestamp mais recente.
+type Location struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	DeviceID  uint      `gorm:"index:idx_location_device_time;not null" json:"device_id"`
+	Timestamp time.Time `gorm:"index:idx_location_device_time;not null" json:"timestamp"`
+	Lat       float64   `gorm:"not null" json:"lat"`
+	Lon       float64   `gorm:"not null" json:"lon"`
+	Accuracy  *float64  `json:"accuracy,omitempty"` // raio de incerteza, em metros
+
+	Device Device `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// Formato de cada ponto enviado pelo dispositivo; lat/lon são ponteiros
+// porque 0 é uma coordenada válida
+type LocationInput struct {
+	Timestamp time.Time `json:"timestamp"` // vazio = horário do servidor
+	Lat       *float64  `json:"lat"`
+	Lon       *float64  `json:"lon"`
+	Accuracy  *float64  `json:"accuracy"`
+}
+
+// Resultado da busca por raio: o dispositivo, a última posição e a
+// distância até o ponto pedido
+type NearbyDevice struct {
+	Device
+	Location  Location `json:"location"`
+	DistanceM float64  `json:"distance_m"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// --- Tentativas de Login ---
+// Toda verificação de senha vira uma linha (sucesso ou falha), usada no
+// bloqueio temporário da conta e na auditoria. UserID fica nulo quando o
+// login não corresponde a nenhum usuário.
+type LoginAttempt struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    *uint     `gorm:"index:idx_login_attempts_user_time" json:"user_id"`
+	Login     string    `gorm:"not null" json:"login"` // como foi digitado (username ou e-mail)
+	IP        string    `json:"ip"`
+	Success   bool      `gorm:"not null" json:"success"`
+	CreatedAt time.Time `gorm:"index:idx_login_attempts_user_time" json:"created_at"`
+}
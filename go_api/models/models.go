@@ -0,0 +1,11 @@
+package models
+
+// --- Modelos (tabelas do banco) ---
+// Structs persistidas pelo GORM e os formatos de entrada compartilhados
+// entre as camadas (handlers, service e repository).
+
+// Tabelas do esquema inicial (migração baseline), na ordem do AutoMigrate.
+// Tabelas criadas depois entram por migrações próprias, não aqui.
+func All() []interface{} {
+	return []interface{}{&User{}, &ActivitySample{}, &UserEvent{}, &SyncConflict{}, &Consent{}, &Device{}, &Reading{}}
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// --- Notificações Push ---
+// O dispositivo registra o token do FCM ou do APNs (um por dispositivo);
+// cada Notification vira uma NotificationDelivery por dispositivo com
+// token, enviada depois pelo job "push.deliver". O token é lido na hora do
+// envio: um token renovado pelo app vale para as entregas já pendentes.
+
+type PushToken struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	DeviceID uint   `gorm:"uniqueIndex;not null" json:"device_id"`
+	Platform string `gorm:"not null" json:"platform"` // "fcm" ou "apns"
+	// Quem tem o token e a credencial do app manda push para o aparelho
+	Token     string    `gorm:"uniqueIndex;not null" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Device Device `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+type PushTokenInput struct {
+	Platform string `json:"platform" binding:"required,oneof=fcm apns"`
+	Token    string `json:"token" binding:"required,max=4096"`
+}
+
+// Quem disparou a notificação
+const (
+	NotificationSourceAdmin = "admin"
+)
+
+type Notification struct {
+	ID       uint            `gorm:"primaryKey" json:"id"`
+	TenantID uint            `gorm:"index;not null;default:1" json:"-"`
+	Title    string          `gorm:"not null" json:"title"`
+	Body     string          `json:"body"`
+	Data     json.RawMessage `json:"data,omitempty"` // objeto JSON de strings, repassado ao app
+	Source   string          `gorm:"not null" json:"source"`
+	// Admin que disparou (vazio nos eventos do servidor)
+	CreatedBy *uint     `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Stats *NotificationStats `gorm:"-" json:"stats,omitempty"`
+}
+
+// Entregas de uma notificação, por status
+type NotificationStats struct {
+	Total     int64 `json:"total"`
+	Pending   int64 `json:"pending"`
+	Delivered int64 `json:"delivered"`
+	Failed    int64 `json:"failed"`
+}
+
+// Destinatários: os dispositivos dos usuários e/ou dispositivos avulsos
+type NotificationInput struct {
+	Title     string            `json:"title" binding:"required,max=200"`
+	Body      string            `json:"body" binding:"max=2000"`
+	Data      map[string]string `json:"data" binding:"max=50"`
+	UserIDs   []Ref             `json:"user_ids" binding:"max=1000"`
+	DeviceIDs []Ref             `json:"device_ids" binding:"max=1000"`
+}
+
+// Usa os mesmos status das entregas de webhook (DeliveryPending...)
+type NotificationDelivery struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	NotificationID uint   `gorm:"index;not null" json:"notification_id"`
+	DeviceID       uint   `gorm:"index;not null" json:"device_id"`
+	Status         string `gorm:"index:idx_notification_deliveries_due;not null" json:"status"`
+	Attempts       int    `gorm:"not null" json:"attempts"`
+	// Próxima tentativa (também serve de "lease" enquanto o envio está em curso)
+	NextAttemptAt time.Time  `gorm:"index:idx_notification_deliveries_due" json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// --- Outbox dos Eventos de Domínio ---
+// Eventos para os outros serviços do sistema, gravados na mesma transação
+// da alteração que os originou e publicados depois no broker
+// (OUTBOX_BROKER) pelo relay. A entrega é "pelo menos uma vez": o
+// consumidor deduplica pelo ID.
+const (
+	OutboxUserCreated     = WebhookUserCreated
+	OutboxUserUpdated     = WebhookUserUpdated
+	OutboxUserDeleted     = WebhookUserDeleted
+	OutboxDeviceOnline    = "device.online"
+	OutboxReadingIngested = "reading.ingested"
+)
+
+type OutboxEvent struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	TenantID uint   `gorm:"not null;default:1" json:"tenant_id"`
+	Type     string `gorm:"not null" json:"type"`
+	// Entidade de origem ("user:42", "device:7"): chave de partição no
+	// Kafka, para os eventos dela saírem em ordem
+	Entity     string          `gorm:"not null" json:"entity"`
+	Payload    json.RawMessage `gorm:"not null" json:"data"`
+	OccurredAt time.Time       `gorm:"not null" json:"occurred_at"`
+	// Pendente enquanto PublishedAt for nulo; NextAttemptAt também serve de
+	// "lease" enquanto a publicação está em curso
+	PublishedAt   *time.Time `gorm:"index:idx_outbox_pending" json:"-"`
+	Attempts      int        `gorm:"not null" json:"-"`
+	NextAttemptAt time.Time  `gorm:"index:idx_outbox_pending" json:"-"`
+	LastError     string     `json:"-"`
+}
+
+// Payload do device.online: o dispositivo voltou a chamar a API depois de
+// ficar offline (ou chamou pela primeira vez)
+type DeviceOnlineEvent struct {
+	DeviceID uint      `json:"device_id"`
+	UserID   uint      `json:"user_id"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Payload do reading.ingested: um por lote gravado, não por leitura
+type ReadingsIngestedEvent struct {
+	DeviceID uint      `json:"device_id"`
+	UserID   uint      `json:"user_id"`
+	Count    int       `json:"count"`
+	Metrics  []string  `json:"metrics"`
+	From     time.Time `json:"from"` // menor e maior timestamp do lote
+	To       time.Time `json:"to"`
+}
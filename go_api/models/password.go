@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// --- Senhas (bcrypt) ---
+// A senha chega em texto puro no JSON, mas só é gravada como hash bcrypt e
+// nunca volta nas respostas.
+
+func HashPassword(plain string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(plain), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func IsPasswordHash(value string) bool {
+	_, err := bcrypt.Cost([]byte(value))
+	return err == nil
+}
+
+// Hook do GORM: vale para db.Create e db.Save
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	if u.Password == "" || IsPasswordHash(u.Password) {
+		return nil
+	}
+	hash, err := HashPassword(u.Password)
+	if err != nil {
+		return err
+	}
+	u.Password = hash
+	return nil
+}
+
+// Verifica a senha informada contra o hash armazenado
+func (u *User) ComparePassword(plain string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(plain)) == nil
+}
+
+// Remove a senha de qualquer serialização do usuário
+func (u User) MarshalJSON() ([]byte, error) {
+	type userJSON User // mesmo formato, sem o método MarshalJSON (evita recursão)
+	out := userJSON(u)
+	out.Password = ""
+	out.CreatedAt, out.UpdatedAt = u.CreatedAt.UTC(), u.UpdatedAt.UTC()
+	if !PublicUUIDs {
+		return json.Marshal(out)
+	}
+	// Modo UUID (ver public_id.go): o "id" de fora esconde o numérico
+	return json.Marshal(struct {
+		ID string `json:"id"`
+		userJSON
+		UUID string `json:"uuid,omitempty"`
+	}{ID: u.UUID, userJSON: out})
+}
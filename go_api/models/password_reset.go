@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// --- Tokens de Redefinição de Senha ---
+// Só o hash (SHA-256) do token fica no banco: quem lê a tabela não
+// consegue usar os tokens. Cada token vale uma vez (UsedAt) até ExpiresAt.
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"index;not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// --- IDs Públicos (UUID) ---
+// Usuários e dispositivos ganham um UUID (v7: ordenado pelo tempo, o que
+// mantém o índice compacto) gerado na criação. A chave primária continua
+// sendo o bigint: as FKs, o GraphQL, o gRPC e os demais recursos não mudam.
+// O que muda é o que o cliente vê e manda no caminho:
+//   - PUBLIC_IDS=int (padrão): "id" segue numérico e o UUID vai em "uuid";
+//     o caminho aceita os dois (período de migração dos clientes)
+//   - PUBLIC_IDS=uuid: "id" (e o "user_id" do dispositivo) passam a ser o
+//     UUID, e um ID numérico no caminho dá 400: ninguém mais conta os
+//     usuários nem percorre /users/1, /users/2...
+
+// Ligado pelo handlers.New conforme PUBLIC_IDS
+var PublicUUIDs bool
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func ValidUUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
+
+// UUIDv7 (RFC 9562): 48 bits de milissegundos e o resto aleatório
+func NewUUID() string {
+	var b [16]byte
+	rand.Read(b[6:])
+	ms := uint64(time.Now().UnixMilli())
+	binary.BigEndian.PutUint16(b[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:6], uint32(ms))
+	b[6] = 0x70 | b[6]&0x0f // versão 7
+	b[8] = 0x80 | b[8]&0x3f // variante RFC
+	h := hex.EncodeToString(b[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// Hooks do GORM: o UUID é gerado na criação (o importador e o seed também
+// passam por aqui)
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.UUID == "" {
+		u.UUID = NewUUID()
+	}
+	return nil
+}
+
+// O dono de um dispositivo não muda, então o UUID dele é copiado na criação
+// (serve para o "user_id" no modo UUID sem um JOIN em cada listagem)
+func (d *Device) BeforeCreate(tx *gorm.DB) error {
+	if d.UUID == "" {
+		d.UUID = NewUUID()
+	}
+	if d.UserUUID == "" && d.UserID != 0 {
+		var uuids []string
+		err := tx.Session(&gorm.Session{NewDB: true}).Unscoped().Model(&User{}).
+			Where("id = ?", d.UserID).Pluck("uuid", &uuids).Error
+		if err != nil {
+			return err
+		}
+		if len(uuids) > 0 {
+			d.UserUUID = uuids[0]
+		}
+	}
+	return nil
+}
+
+func (d Device) MarshalJSON() ([]byte, error) {
+	type deviceJSON Device // sem o método MarshalJSON (evita recursão)
+	out := deviceJSON(d)
+	out.CreatedAt, out.UpdatedAt = d.CreatedAt.UTC(), d.UpdatedAt.UTC()
+	if !PublicUUIDs {
+		return json.Marshal(out)
+	}
+	// Os campos de fora escondem os de mesmo nome do struct embutido
+	return json.Marshal(struct {
+		ID     string `json:"id"`
+		UserID string `json:"user_id"`
+		deviceJSON
+		UUID     string `json:"uuid,omitempty"`
+		UserUUID string `json:"user_uuid,omitempty"`
+	}{ID: d.UUID, UserID: d.UserUUID, deviceJSON: out})
+}
+
+// JSON de v com os campos de extra no fim. Para os structs que embutem um
+// Device: o MarshalJSON dele seria herdado e os campos de fora sumiriam.
+func ExtendJSON(v, extra interface{}) ([]byte, error) {
+	base, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	more, err := json.Marshal(extra)
+	if err != nil {
+		return nil, err
+	}
+	if len(more) <= 2 {
+		return base, nil
+	}
+	return append(append(base[:len(base)-1:len(base)-1], ','), more[1:]...), nil
+}
+
+func (n NearbyDevice) MarshalJSON() ([]byte, error) {
+	return ExtendJSON(n.Device, struct {
+		Location  Location `json:"location"`
+		DistanceM float64  `json:"distance_m"`
+	}{n.Location, n.DistanceM})
+}
+
+// No modo UUID o user_id é o UUID do usuário carregado junto (sem ele, sai
+// do JSON)
+func (m Membership) MarshalJSON() ([]byte, error) {
+	type membershipJSON Membership
+	if !PublicUUIDs {
+		return json.Marshal(membershipJSON(m))
+	}
+	out := struct {
+		UserID string `json:"user_id,omitempty"`
+		membershipJSON
+	}{membershipJSON: membershipJSON(m)}
+	if m.User != nil {
+		out.UserID = m.User.UUID
+	}
+	return json.Marshal(out)
+}
+
+// --- Referências no Corpo ---
+
+// Usuário ou dispositivo citado num corpo JSON (user_id, user_ids,
+// device_ids): o número ou o UUID. No modo UUID, só o UUID.
+type Ref struct {
+	ID   uint
+	UUID string
+}
+
+var ErrRefNotUUID = errors.New("IDs must be UUIDs")
+
+func (r *Ref) UnmarshalJSON(raw []byte) error {
+	if len(raw) > 0 && raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return err
+		}
+		if !ValidUUID(s) {
+			return ErrRefNotUUID
+		}
+		*r = Ref{UUID: strings.ToLower(s)}
+		return nil
+	}
+	if PublicUUIDs {
+		return ErrRefNotUUID
+	}
+	*r = Ref{}
+	return json.Unmarshal(raw, &r.ID)
+}
+
+func (r Ref) MarshalJSON() ([]byte, error) {
+	if r.UUID != "" {
+		return json.Marshal(r.UUID)
+	}
+	return json.Marshal(r.ID)
+}
+
+func (r Ref) IsZero() bool {
+	return r.ID == 0 && r.UUID == ""
+}
+
+// Separa os números dos UUIDs, para um "id IN ? OR uuid IN ?"
+func SplitRefs(refs []Ref) (ids []uint, uuids []string) {
+	for _, r := range refs {
+		if r.UUID != "" {
+			uuids = append(uuids, r.UUID)
+		} else {
+			ids = append(ids, r.ID)
+		}
+	}
+	return ids, uuids
+}
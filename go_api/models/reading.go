@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// --- Telemetria (Leituras de Sensores) ---
+// Cada leitura é um ponto de uma série temporal: métrica + valor numérico,
+// com um payload JSON opcional para dados extras do sensor.
+type Reading struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	DeviceID  uint            `gorm:"index:idx_reading_device_time;not null" json:"device_id"`
+	Timestamp time.Time       `gorm:"index:idx_reading_device_time;not null" json:"timestamp"`
+	Metric    string          `gorm:"not null" json:"metric"`
+	Value     float64         `json:"value"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+
+	Device Device `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// Formato de cada leitura enviada pelo dispositivo (HTTP ou MQTT)
+type ReadingInput struct {
+	Timestamp time.Time       `json:"timestamp"` // vazio = horário do servidor
+	Metric    string          `json:"metric" binding:"required"`
+	Value     float64         `json:"value"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// --- Retenção (Séries Agregadas) ---
+// Leituras mais antigas que a janela bruta viram um agregado por hora, e os
+// horários mais antigos que a janela horária, um por dia (UTC). Guarda soma
+// e contagem, não a média: assim dá para juntar agregados (uma hora ainda
+// bruta com as já agregadas, ou leituras atrasadas na mesma hora).
+const (
+	ResolutionRaw    = "raw"
+	ResolutionHourly = "hourly"
+	ResolutionDaily  = "daily"
+)
+
+type ReadingRollup struct {
+	ID         uint      `gorm:"primaryKey"`
+	DeviceID   uint      `gorm:"uniqueIndex:idx_rollup_key;not null"`
+	Resolution string    `gorm:"uniqueIndex:idx_rollup_key;not null"`
+	Metric     string    `gorm:"uniqueIndex:idx_rollup_key;not null"`
+	Bucket     time.Time `gorm:"uniqueIndex:idx_rollup_key;not null"` // início da hora/dia
+	Count      int64     `gorm:"not null"`
+	Sum        float64   `gorm:"not null"`
+	Min        float64   `gorm:"not null"`
+	Max        float64   `gorm:"not null"`
+
+	Device Device `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Um ponto de GET /devices/:id/readings?resolution=hourly|daily
+type ReadingAggregate struct {
+	Timestamp time.Time `json:"timestamp"` // início do intervalo
+	Metric    string    `json:"metric"`
+	Count     int64     `json:"count"`
+	Avg       float64   `json:"avg"`
+	Min       float64   `json:"min"`
+	Max       float64   `json:"max"`
+}
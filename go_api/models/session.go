@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// --- Sessões ---
+// Cada login abre uma sessão por aparelho. O refresh token leva o ID dela
+// (sid) e um identificador aleatório (jti) que troca a cada POST /refresh;
+// só o hash do jti atual fica no banco. Um refresh token já trocado que
+// aparece de novo indica vazamento, e a sessão é encerrada.
+type Session struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	TokenHash  string     `gorm:"uniqueIndex;not null" json:"-"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	ExpiresAt  time.Time  `gorm:"index;not null" json:"expires_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	// A sessão do token usado em GET /me/sessions
+	Current bool `gorm:"-" json:"current"`
+}
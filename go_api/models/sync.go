@@ -0,0 +1,19 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Registro de conflito resolvido pelo servidor na sincronização offline
+type SyncConflict struct {
+	ID             uint            `gorm:"primaryKey" json:"id"`
+	ClientID       string          `gorm:"index;not null" json:"client_id"`
+	UserID         uint            `gorm:"index;not null" json:"user_id"`
+	BaseRevision   uint            `json:"base_revision"`
+	ServerRevision uint            `json:"server_revision"`
+	ClientData     json.RawMessage `json:"client_data"`
+	ServerData     json.RawMessage `json:"server_data"`
+	Winner         string          `gorm:"not null" json:"winner"` // "client" ou "server"
+	CreatedAt      time.Time       `json:"created_at"`
+}
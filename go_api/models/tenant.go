@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// --- Tenants (Organizações) ---
+// Turmas e grupos que dividem a mesma implantação sem enxergar os usuários
+// uns dos outros. Usuários, dispositivos, eventos, auditoria e webhooks
+// levam o tenant_id, e o repository filtra as consultas pelo tenant da
+// requisição. Quem já existia antes dos tenants ficou no padrão.
+const DefaultTenantID = 1
+
+type Tenant struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Slug      string    `gorm:"uniqueIndex;not null" json:"slug"` // valor do X-Tenant ou subdomínio
+	Name      string    `gorm:"not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// POST /tenants; o slug vira subdomínio, então segue as regras de DNS
+type TenantInput struct {
+	Slug string `json:"slug" binding:"required,min=2,max=50,slug"`
+	Name string `json:"name" binding:"required,max=100"`
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// --- Usuário (Modelo) ---
+// As "tags" (ex: `json:"name"`) definem como os dados aparecem no JSON e no Banco.
+type User struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	UUID     string `gorm:"uniqueIndex;size:36" json:"uuid"` // ID público (ver public_id.go)
+	Name     string `gorm:"not null" json:"name"`
+	Email    string `gorm:"uniqueIndex;not null" json:"email"`
+	User     string `gorm:"uniqueIndex;not null" json:"user"`
+	Password string `gorm:"not null" json:"password,omitempty"` // Hash bcrypt, nunca sai nas respostas
+	Role     string `gorm:"not null;default:user" json:"role"`  // "user" ou "admin"
+	Region   string `gorm:"index" json:"region,omitempty"`      // Região onde os dados do usuário residem
+	TenantID uint   `gorm:"index;not null;default:1" json:"tenant_id"`
+	Revision uint   `gorm:"not null;default:1" json:"revision"` // Incrementa a cada alteração (usado no sync)
+
+	// Confirmado pelo link enviado no cadastro; volta a false quando o e-mail muda
+	EmailVerified bool `gorm:"not null;default:false" json:"email_verified"`
+
+	// Foto enviada em POST /users/:id/avatar, já redimensionada
+	AvatarURL string `json:"avatar_url,omitempty"`
+
+	// Preenchidos pelo GORM; no JSON saem em UTC. updated_at muda a cada
+	// alteração (para o cliente buscar só o que mudou: ?updated_after=)
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt time.Time `gorm:"index" json:"updated_at"`
+
+	// Soft delete: o DELETE só preenche a data, e o GORM passa a esconder o
+	// registro das consultas. POST /users/:id/restore traz de volta.
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	// Conta apagada de vez (DELETE /me confirmado ou expurgo dos removidos):
+	// os dados foram limpos e o registro anônimo não volta no restore
+	AnonymizedAt *time.Time `gorm:"index" json:"-"`
+}
+
+// "admin" gerencia todos os usuários; "user" só enxerga e altera o próprio registro
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+var ValidRoles = map[string]bool{RoleUser: true, RoleAdmin: true}
+
+// Formatos aceitos na entrada: o cliente nunca escreve direto no modelo,
+// então não consegue mandar "id", "role", "revision" ou trocar a senha por
+// engano num PUT. A senha só muda por PUT /users/:id/password.
+type CreateUserInput struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Email    string `json:"email" binding:"required,email,max=255"`
+	User     string `json:"user" binding:"required,min=3,max=50,username"`
+	Password string `json:"password" binding:"required,min=8,max=72"` // bcrypt ignora o que passar de 72 bytes
+	Region   string `json:"region" binding:"omitempty,max=50"`
+}
+
+// Campos vazios significam "não alterar" (mesma regra do db.Updates com struct)
+type UpdateUserInput struct {
+	Name  string `json:"name" binding:"omitempty,max=100"`
+	Email string `json:"email" binding:"omitempty,email,max=255"`
+	User  string `json:"user" binding:"omitempty,min=3,max=50,username"`
+}
+
+// PATCH (JSON Merge Patch): nil = campo ausente no corpo
+type PatchUserInput struct {
+	Name  *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Email *string `json:"email" binding:"omitempty,email,max=255"`
+	User  *string `json:"user" binding:"omitempty,min=3,max=50,username"`
+}
+
+// Quem troca a própria senha precisa confirmar a atual
+// POST /password/forgot e POST /verify-email/resend
+type EmailInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// POST /password/reset
+type ResetPasswordInput struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=72"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=72"`
+}
+
+type RoleInput struct {
+	Role string `json:"role" binding:"required"`
+}
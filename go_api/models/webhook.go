@@ -0,0 +1,66 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// --- Webhooks ---
+// Assinaturas de outros serviços do projeto nos eventos do ciclo de vida
+// do usuário. Cada evento gravado vira uma WebhookDelivery (na mesma
+// transação do UserEvent), entregue depois por um POST assinado com HMAC
+// do Secret.
+const (
+	WebhookUserCreated = "user.created"
+	WebhookUserUpdated = "user.updated"
+	WebhookUserDeleted = "user.deleted"
+)
+
+type Webhook struct {
+	ID     uint            `gorm:"primaryKey" json:"id"`
+	URL    string          `gorm:"not null" json:"url"`
+	Secret string          `gorm:"not null" json:"-"`
+	Events json.RawMessage `gorm:"not null" json:"events"` // lista JSON, ex: ["user.created"]
+	// Só recebe os eventos do próprio tenant
+	TenantID uint `gorm:"index;not null;default:1" json:"-"`
+	// Quem cadastrou (admin)
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Sem secret, um aleatório é gerado e devolvido só na criação
+type WebhookInput struct {
+	URL    string   `json:"url" binding:"required,http_url,max=2048"`
+	Secret string   `json:"secret" binding:"omitempty,min=16,max=200"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=user.created user.updated user.deleted"`
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed" // esgotou as tentativas
+)
+
+type WebhookDelivery struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	WebhookID uint            `gorm:"index;not null" json:"webhook_id"`
+	UserID    uint            `gorm:"index;not null;default:0" json:"user_id"` // o do payload
+	Event     string          `gorm:"not null" json:"event"`
+	Payload   json.RawMessage `gorm:"not null" json:"payload"`
+	Status    string          `gorm:"index:idx_webhook_deliveries_due;not null" json:"status"`
+	Attempts  int             `gorm:"not null" json:"attempts"`
+	// Próxima tentativa (também serve de "lease" enquanto o POST está em curso)
+	NextAttemptAt time.Time  `gorm:"index:idx_webhook_deliveries_due" json:"next_attempt_at"`
+	LastStatus    int        `json:"last_status,omitempty"` // status HTTP da última tentativa
+	LastError     string     `json:"last_error,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Corpo do POST: os UserEvents que originaram a entrega, na ordem
+type WebhookPayload struct {
+	Event      string      `json:"event"`
+	UserID     uint        `json:"user_id"`
+	Changes    []UserEvent `json:"changes"`
+	OccurredAt time.Time   `json:"occurred_at"`
+}
@@ -0,0 +1,147 @@
+package mqttbridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_api/models"
+	"go_api/service"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"gorm.io/gorm"
+)
+
+// --- Ponte MQTT (Telemetria) ---
+// Sensores que não falam HTTP publicam as leituras num broker MQTT, no
+// tópico devices/{id}/readings, com o mesmo JSON do POST
+// /devices/:id/readings. A ponte assina o tópico e grava pelo mesmo
+// service.Telemetry do HTTP.
+//
+// O padrão é uma assinatura compartilhada ($share/go_api/...): com várias
+// réplicas, o broker entrega cada mensagem a uma só, em vez de todas
+// gravarem a mesma leitura. Quem pode publicar em cada tópico é definido
+// pelas ACLs do broker; a ponte só confere se o dispositivo existe.
+
+type Options struct {
+	BrokerURL string // ex: tcp://mosquitto:1883; vazio desliga a ponte
+	ClientID  string
+	Username  string
+	Password  string
+	Topic     string // filtro de assinatura; o "+" marca o ID do dispositivo
+	QoS       byte
+}
+
+const handleTimeout = 10 * time.Second
+
+type Bridge struct {
+	client    mqtt.Client
+	db        *gorm.DB
+	telemetry *service.Telemetry
+	opts      Options
+	idIndex   int // posição do ID do dispositivo no tópico
+}
+
+// Posição do "+" no filtro, sem o prefixo $share/<grupo>/
+func deviceIDIndex(filter string) (int, error) {
+	parts := strings.Split(filter, "/")
+	if len(parts) > 2 && parts[0] == "$share" {
+		parts = parts[2:]
+	}
+	index := -1
+	for i, p := range parts {
+		if p == "+" {
+			if index >= 0 {
+				return 0, errors.New("topic must have a single '+' (the device ID)")
+			}
+			index = i
+		}
+	}
+	if index < 0 {
+		return 0, errors.New("topic must have a '+' for the device ID")
+	}
+	return index, nil
+}
+
+func New(opts Options, db *gorm.DB, telemetry *service.Telemetry) (*Bridge, error) {
+	index, err := deviceIDIndex(opts.Topic)
+	if err != nil {
+		return nil, err
+	}
+	return &Bridge{db: db, telemetry: telemetry, opts: opts, idIndex: index}, nil
+}
+
+// Conecta em segundo plano: com o broker fora do ar a API sobe mesmo assim
+// e a biblioteca fica tentando (e reconecta sozinha se a conexão cair). A
+// cada conexão a assinatura é refeita, porque a sessão é limpa.
+func (b *Bridge) Start() {
+	opts := mqtt.NewClientOptions().
+		AddBroker(b.opts.BrokerURL).
+		SetClientID(b.opts.ClientID).
+		SetUsername(b.opts.Username).
+		SetPassword(b.opts.Password).
+		SetCleanSession(true).
+		SetOrderMatters(false).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(5 * time.Second).
+		SetMaxReconnectInterval(time.Minute).
+		SetOnConnectHandler(func(c mqtt.Client) {
+			slog.Info("MQTT conectado, assinando", "broker", b.opts.BrokerURL, "topic", b.opts.Topic, "qos", b.opts.QoS)
+			token := c.Subscribe(b.opts.Topic, b.opts.QoS, func(_ mqtt.Client, msg mqtt.Message) {
+				b.onMessage(msg)
+			})
+			go func() {
+				if token.Wait() && token.Error() != nil {
+					slog.Error("assinatura MQTT falhou", "topic", b.opts.Topic, "error", token.Error())
+				}
+			}()
+		}).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			slog.Warn("conexão MQTT perdida, reconectando", "error", err)
+		})
+
+	b.client = mqtt.NewClient(opts)
+	b.client.Connect()
+}
+
+func (b *Bridge) onMessage(msg mqtt.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), handleTimeout)
+	defer cancel()
+	created, err := b.Handle(ctx, msg.Topic(), msg.Payload())
+	if err != nil {
+		slog.WarnContext(ctx, "mensagem MQTT descartada", "topic", msg.Topic(), "error", err)
+		return
+	}
+	slog.DebugContext(ctx, "leituras MQTT gravadas", "topic", msg.Topic(), "created", created)
+}
+
+// Grava as leituras de uma mensagem (exportado para os testes). Sem
+// resposta no MQTT, os erros só vão para o log.
+func (b *Bridge) Handle(ctx context.Context, topic string, payload []byte) (int, error) {
+	parts := strings.Split(topic, "/")
+	if b.idIndex >= len(parts) {
+		return 0, fmt.Errorf("unexpected topic %q", topic)
+	}
+	id, err := strconv.ParseUint(parts[b.idIndex], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid device ID in topic %q", topic)
+	}
+	var device models.Device
+	// Dono removido: o dispositivo não grava mais
+	if err := service.ActiveOwner(b.db.WithContext(ctx)).First(&device, id).Error; err != nil {
+		return 0, fmt.Errorf("device %d: %w", id, err)
+	}
+	return b.telemetry.Ingest(ctx, device, payload)
+}
+
+// Encerra a conexão esperando até 250ms pelas mensagens em andamento
+func (b *Bridge) Close() {
+	if b.client != nil {
+		b.client.Disconnect(250)
+	}
+}
@@ -0,0 +1,237 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go_api/config"
+)
+
+// --- Login Social (OAuth2) ---
+// Fluxo authorization code: o navegador vai ao provedor, volta no callback
+// com um code, a API troca o code por um access token do provedor e lê o
+// perfil. Quem usa depende só da interface Provider; Google e GitHub são
+// clientes HTTP simples, sem SDK, como o S3 do storage.
+
+// O provedor respondeu com erro ou num formato inesperado
+var ErrProvider = errors.New("oauth provider error")
+
+// Quem o provedor diz que é o usuário
+type Profile struct {
+	Subject       string // ID estável no provedor (o e-mail pode mudar)
+	Email         string
+	EmailVerified bool
+	Name          string
+	Login         string // username no provedor, quando existe (GitHub)
+}
+
+type Provider interface {
+	// Para onde mandar o navegador
+	AuthCodeURL(state, redirectURL string) string
+	// Troca o code do callback pelo perfil
+	Exchange(ctx context.Context, code, redirectURL string) (Profile, error)
+}
+
+// Provedores configurados, pelo nome usado em /auth/:provider
+func FromConfig(cfg config.Config) map[string]Provider {
+	providers := map[string]Provider{}
+	if cfg.GoogleClientID != "" {
+		providers["google"] = NewGoogle(cfg.GoogleClientID, cfg.GoogleClientSecret)
+	}
+	if cfg.GitHubClientID != "" {
+		providers["github"] = NewGitHub(cfg.GitHubClientID, cfg.GitHubClientSecret)
+	}
+	return providers
+}
+
+// --- Cliente OAuth2 ---
+// A parte comum aos provedores: URL de autorização e troca do code
+
+type Client struct {
+	ClientID     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	Scopes       []string
+	HTTP         *http.Client
+}
+
+func newClient(id, secret, authURL, tokenURL string, scopes ...string) Client {
+	return Client{
+		ClientID:     id,
+		ClientSecret: secret,
+		AuthURL:      authURL,
+		TokenURL:     tokenURL,
+		Scopes:       scopes,
+		HTTP:         &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *Client) AuthCodeURL(state, redirectURL string) string {
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {c.ClientID},
+		"redirect_uri":  {redirectURL},
+		"scope":         {strings.Join(c.Scopes, " ")},
+		"state":         {state},
+	}
+	return c.AuthURL + "?" + q.Encode()
+}
+
+// POST no token endpoint (RFC 6749, seção 4.1.3)
+func (c *Client) token(ctx context.Context, code, redirectURL string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"client_id":     {c.ClientID},
+		"client_secret": {c.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// O GitHub só responde JSON se pedir
+	req.Header.Set("Accept", "application/json")
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := c.do(req, &body); err != nil {
+		return "", err
+	}
+	// O GitHub responde 200 mesmo com o code inválido
+	if body.Error != "" || body.AccessToken == "" {
+		return "", fmt.Errorf("%w: token: %s %s", ErrProvider, body.Error, body.ErrorDescription)
+	}
+	return body.AccessToken, nil
+}
+
+// GET autenticado com o access token do provedor
+func (c *Client) getJSON(ctx context.Context, endpoint, accessToken string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	return c.do(req, out)
+}
+
+func (c *Client) do(req *http.Request, out interface{}) error {
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("%w: %s %s: status %d", ErrProvider, req.Method, req.URL.Path, resp.StatusCode)
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("%w: %s %s: %v", ErrProvider, req.Method, req.URL.Path, err)
+	}
+	return nil
+}
+
+// --- Google ---
+
+type Google struct {
+	Client
+	UserInfoURL string
+}
+
+func NewGoogle(id, secret string) *Google {
+	return &Google{
+		Client: newClient(id, secret,
+			"https://accounts.google.com/o/oauth2/v2/auth", "https://oauth2.googleapis.com/token",
+			"openid", "email", "profile"),
+		UserInfoURL: "https://openidconnect.googleapis.com/v1/userinfo",
+	}
+}
+
+func (g *Google) Exchange(ctx context.Context, code, redirectURL string) (Profile, error) {
+	token, err := g.token(ctx, code, redirectURL)
+	if err != nil {
+		return Profile{}, err
+	}
+	var info struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := g.getJSON(ctx, g.UserInfoURL, token, &info); err != nil {
+		return Profile{}, err
+	}
+	if info.Sub == "" {
+		return Profile{}, fmt.Errorf("%w: userinfo without sub", ErrProvider)
+	}
+	return Profile{Subject: info.Sub, Email: info.Email, EmailVerified: info.EmailVerified, Name: info.Name}, nil
+}
+
+// --- GitHub ---
+// O e-mail do perfil pode estar oculto: o verificado vem de /user/emails
+
+type GitHub struct {
+	Client
+	APIURL string
+}
+
+func NewGitHub(id, secret string) *GitHub {
+	return &GitHub{
+		Client: newClient(id, secret,
+			"https://github.com/login/oauth/authorize", "https://github.com/login/oauth/access_token",
+			"read:user", "user:email"),
+		APIURL: "https://api.github.com",
+	}
+}
+
+func (g *GitHub) Exchange(ctx context.Context, code, redirectURL string) (Profile, error) {
+	token, err := g.token(ctx, code, redirectURL)
+	if err != nil {
+		return Profile{}, err
+	}
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := g.getJSON(ctx, g.APIURL+"/user", token, &user); err != nil {
+		return Profile{}, err
+	}
+	if user.ID == 0 {
+		return Profile{}, fmt.Errorf("%w: user without id", ErrProvider)
+	}
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := g.getJSON(ctx, g.APIURL+"/user/emails", token, &emails); err != nil {
+		return Profile{}, err
+	}
+	profile := Profile{Subject: fmt.Sprint(user.ID), Name: user.Name, Login: user.Login}
+	for _, e := range emails {
+		if e.Primary {
+			profile.Email, profile.EmailVerified = e.Email, e.Verified
+		}
+	}
+	if profile.Name == "" {
+		profile.Name = user.Login
+	}
+	return profile, nil
+}
@@ -0,0 +1,374 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go_api/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// --- Notificações Push ---
+// Quem envia depende só da interface Provider, uma por plataforma do token
+// registrado pelo dispositivo: FCM (Android, web) e APNs (iOS). Os dois são
+// clientes HTTP simples, sem SDK, como o S3 do storage e o OAuth. Sem
+// credenciais, a plataforma cai no Log (desenvolvimento), como o mailer sem
+// SMTP_ADDR.
+
+const (
+	PlatformFCM  = "fcm"
+	PlatformAPNs = "apns"
+
+	// Envios simultâneos de cada provedor (um por requisição HTTP; no APNs
+	// todos na mesma conexão HTTP/2)
+	concurrency = 8
+)
+
+// O provedor diz que o token não vale mais (app desinstalado, token trocado):
+// não adianta tentar de novo
+var ErrUnregistered = errors.New("push token is no longer registered")
+
+type Message struct {
+	Token string
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+type Provider interface {
+	// Um erro por mensagem, na mesma ordem (nil = aceita pelo provedor)
+	Send(ctx context.Context, msgs []Message) []error
+}
+
+// Provedor de cada plataforma
+func FromConfig(cfg config.Config) map[string]Provider {
+	providers := map[string]Provider{
+		PlatformFCM:  Log{Platform: PlatformFCM},
+		PlatformAPNs: Log{Platform: PlatformAPNs},
+	}
+	if cfg.FCMCredentialsFile != "" {
+		providers[PlatformFCM] = NewFCM(cfg.FCMCredentialsFile)
+	}
+	if cfg.APNsKeyFile != "" {
+		providers[PlatformAPNs] = NewAPNs(cfg.APNsKeyFile, cfg.APNsKeyID, cfg.APNsTeamID, cfg.APNsTopic, cfg.APNsSandbox)
+	}
+	return providers
+}
+
+// Manda cada mensagem com send, até concurrency ao mesmo tempo
+func sendAll(msgs []Message, send func(Message) error) []error {
+	errs := make([]error, len(msgs))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, msg := range msgs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			errs[i] = send(msg)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+func errorAll(msgs []Message, err error) []error {
+	errs := make([]error, len(msgs))
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Só registra as mensagens no log
+type Log struct {
+	Platform string
+}
+
+func (l Log) Send(ctx context.Context, msgs []Message) []error {
+	for _, msg := range msgs {
+		slog.InfoContext(ctx, "push (não enviado, sem credenciais)", "platform", l.Platform, "title", msg.Title, "body", msg.Body)
+	}
+	return make([]error, len(msgs))
+}
+
+// Credencial lida do disco no primeiro envio: um arquivo ilegível aparece
+// no last_error das entregas, sem impedir a API de subir
+type lazyKey[T any] struct {
+	once sync.Once
+	key  T
+	err  error
+}
+
+func (k *lazyKey[T]) get(load func() (T, error)) (T, error) {
+	k.once.Do(func() { k.key, k.err = load() })
+	return k.key, k.err
+}
+
+// Token de acesso em cache até perto de expirar
+type cachedToken struct {
+	mu      sync.Mutex
+	value   string
+	expires time.Time
+}
+
+func (c *cachedToken) get(fetch func() (string, time.Duration, error)) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.value != "" && time.Now().Before(c.expires) {
+		return c.value, nil
+	}
+	value, ttl, err := fetch()
+	if err != nil {
+		return "", err
+	}
+	// Renova um minuto antes
+	c.value, c.expires = value, time.Now().Add(ttl-time.Minute)
+	return value, nil
+}
+
+// --- FCM (HTTP v1) ---
+// Autentica com a conta de serviço do projeto Firebase: um JWT assinado com
+// a chave dela vira um access token OAuth2, válido por uma hora.
+
+type serviceAccount struct {
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+	key          *rsa.PrivateKey
+}
+
+type FCM struct {
+	CredentialsFile string
+	// Endereços da API (trocados nos testes)
+	BaseURL  string
+	TokenURL string // vazio = token_uri da conta de serviço
+	HTTP     *http.Client
+
+	account lazyKey[*serviceAccount]
+	token   cachedToken
+}
+
+func NewFCM(credentialsFile string) *FCM {
+	return &FCM{
+		CredentialsFile: credentialsFile,
+		BaseURL:         "https://fcm.googleapis.com",
+		HTTP:            &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (f *FCM) loadAccount() (*serviceAccount, error) {
+	raw, err := os.ReadFile(f.CredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("FCM credentials: %w", err)
+	}
+	var account serviceAccount
+	if err := json.Unmarshal(raw, &account); err != nil {
+		return nil, fmt.Errorf("FCM credentials: %w", err)
+	}
+	if account.key, err = jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey)); err != nil {
+		return nil, fmt.Errorf("FCM credentials: %w", err)
+	}
+	if account.ProjectID == "" || account.ClientEmail == "" {
+		return nil, errors.New("FCM credentials: project_id and client_email are required")
+	}
+	return &account, nil
+}
+
+func (f *FCM) accessToken(ctx context.Context, account *serviceAccount) (string, error) {
+	return f.token.get(func() (string, time.Duration, error) {
+		tokenURL := f.TokenURL
+		if tokenURL == "" {
+			tokenURL = account.TokenURI
+		}
+		now := time.Now()
+		assertion := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":   account.ClientEmail,
+			"scope": "https://www.googleapis.com/auth/firebase.messaging",
+			"aud":   tokenURL,
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Hour).Unix(),
+		})
+		assertion.Header["kid"] = account.PrivateKeyID
+		signed, err := assertion.SignedString(account.key)
+		if err != nil {
+			return "", 0, err
+		}
+		form := url.Values{"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"}, "assertion": {signed}}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", 0, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		var body struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int    `json:"expires_in"`
+		}
+		status, raw, err := do(f.HTTP, req)
+		if err != nil {
+			return "", 0, err
+		}
+		if status != http.StatusOK || json.Unmarshal(raw, &body) != nil || body.AccessToken == "" {
+			return "", 0, fmt.Errorf("FCM token: HTTP %d", status)
+		}
+		return body.AccessToken, time.Duration(body.ExpiresIn) * time.Second, nil
+	})
+}
+
+func (f *FCM) Send(ctx context.Context, msgs []Message) []error {
+	account, err := f.account.get(f.loadAccount)
+	if err != nil {
+		return errorAll(msgs, err)
+	}
+	token, err := f.accessToken(ctx, account)
+	if err != nil {
+		return errorAll(msgs, err)
+	}
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/messages:send", f.BaseURL, url.PathEscape(account.ProjectID))
+	return sendAll(msgs, func(msg Message) error {
+		payload, _ := json.Marshal(map[string]interface{}{"message": map[string]interface{}{
+			"token":        msg.Token,
+			"notification": map[string]string{"title": msg.Title, "body": msg.Body},
+			"data":         msg.Data,
+		}})
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		status, raw, err := do(f.HTTP, req)
+		switch {
+		case err != nil:
+			return err
+		case status == http.StatusOK:
+			return nil
+		// Token de outro app ou desinstalado
+		case status == http.StatusNotFound || bytes.Contains(raw, []byte("UNREGISTERED")):
+			return ErrUnregistered
+		}
+		var body struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(raw, &body)
+		return fmt.Errorf("FCM: HTTP %d: %s", status, body.Error.Message)
+	})
+}
+
+// --- APNs ---
+// Autenticação por token: um JWT ES256 assinado com a chave .p8 da conta
+// de desenvolvedor, renovado a cada 40 minutos (a Apple recusa tokens com
+// mais de uma hora e também os gerados com frequência demais).
+
+type APNs struct {
+	KeyFile string
+	KeyID   string
+	TeamID  string
+	Topic   string // bundle ID do app
+	BaseURL string
+	HTTP    *http.Client
+
+	key   lazyKey[*ecdsa.PrivateKey]
+	token cachedToken
+}
+
+func NewAPNs(keyFile, keyID, teamID, topic string, sandbox bool) *APNs {
+	base := "https://api.push.apple.com"
+	if sandbox {
+		base = "https://api.sandbox.push.apple.com"
+	}
+	// O http.Client padrão negocia HTTP/2 no TLS, que o APNs exige
+	return &APNs{KeyFile: keyFile, KeyID: keyID, TeamID: teamID, Topic: topic, BaseURL: base, HTTP: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (a *APNs) loadKey() (*ecdsa.PrivateKey, error) {
+	raw, err := os.ReadFile(a.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("APNs key: %w", err)
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(raw)
+	if err != nil {
+		return nil, fmt.Errorf("APNs key: %w", err)
+	}
+	return key, nil
+}
+
+func (a *APNs) providerToken(key *ecdsa.PrivateKey) (string, error) {
+	return a.token.get(func() (string, time.Duration, error) {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"iss": a.TeamID, "iat": time.Now().Unix()})
+		token.Header["kid"] = a.KeyID
+		signed, err := token.SignedString(key)
+		return signed, 41 * time.Minute, err
+	})
+}
+
+func (a *APNs) Send(ctx context.Context, msgs []Message) []error {
+	key, err := a.key.get(a.loadKey)
+	if err != nil {
+		return errorAll(msgs, err)
+	}
+	token, err := a.providerToken(key)
+	if err != nil {
+		return errorAll(msgs, err)
+	}
+	return sendAll(msgs, func(msg Message) error {
+		// Os dados vão ao lado do "aps", como chaves próprias do app
+		payload := map[string]interface{}{}
+		for k, v := range msg.Data {
+			payload[k] = v
+		}
+		payload["aps"] = map[string]interface{}{"alert": map[string]string{"title": msg.Title, "body": msg.Body}, "sound": "default"}
+		raw, _ := json.Marshal(payload)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.BaseURL+"/3/device/"+url.PathEscape(msg.Token), bytes.NewReader(raw))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "bearer "+token)
+		req.Header.Set("apns-topic", a.Topic)
+		req.Header.Set("apns-push-type", "alert")
+		req.Header.Set("apns-priority", "10")
+		status, body, err := do(a.HTTP, req)
+		if err != nil {
+			return err
+		}
+		if status == http.StatusOK {
+			return nil
+		}
+		var reason struct {
+			Reason string `json:"reason"`
+		}
+		json.Unmarshal(body, &reason)
+		if status == http.StatusGone || reason.Reason == "BadDeviceToken" || reason.Reason == "Unregistered" {
+			return ErrUnregistered
+		}
+		return fmt.Errorf("APNs: HTTP %d: %s", status, reason.Reason)
+	})
+}
+
+func do(client *http.Client, req *http.Request) (int, []byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	return resp.StatusCode, body, err
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Baldes em memória, protegidos por um mutex
+type Memory struct {
+	limit     int
+	window    time.Duration
+	rate      float64
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemory(limit int, window time.Duration) *Memory {
+	return &Memory{
+		limit:     limit,
+		window:    window,
+		rate:      refillRate(limit, window),
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (m *Memory) Allow(_ context.Context, key string) (Result, error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(m.limit), last: now}
+		m.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(m.limit), b.tokens+now.Sub(b.last).Seconds()*m.rate)
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return result(allowed, b.tokens, m.rate), nil
+}
+
+// Balde parado por uma janela inteira já está cheio: é igual a um balde
+// novo, então pode sair do mapa (senão cada IP visto ocuparia memória
+// para sempre)
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < m.window {
+		return
+	}
+	for key, b := range m.buckets {
+		if now.Sub(b.last) >= m.window {
+			delete(m.buckets, key)
+		}
+	}
+	m.lastSweep = now
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// --- Limite de Requisições (Token Bucket) ---
+// Cada cliente tem um balde com Limit fichas que se reabastece aos poucos
+// (Limit fichas por Window). Cada requisição gasta uma ficha; sem fichas, a
+// resposta é 429. Rajadas curtas passam, o ritmo médio fica limitado.
+//
+// Em memória cada réplica conta sozinha (4 réplicas = 4x o limite); com
+// REDIS_URL os baldes ficam no Redis e o limite vale para o cluster todo.
+
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // quanto falta para a próxima ficha (se negado)
+}
+
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+// limit == 0 desliga o limite (retorna nil); rdb nil = em memória
+func New(limit int, window time.Duration, rdb *redis.Client) Limiter {
+	if limit <= 0 {
+		return nil
+	}
+	if rdb != nil {
+		return NewRedis(rdb, limit, window)
+	}
+	return NewMemory(limit, window)
+}
+
+// Fichas por segundo
+func refillRate(limit int, window time.Duration) float64 {
+	return float64(limit) / window.Seconds()
+}
+
+// Monta o resultado a partir das fichas que sobraram no balde
+func result(allowed bool, tokens, rate float64) Result {
+	r := Result{Allowed: allowed, Remaining: int(math.Floor(tokens))}
+	if !allowed {
+		r.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return r
+}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Mesmo token bucket, executado atomicamente no Redis (script Lua) para as
+// réplicas dividirem os baldes. O relógio usado é o do Redis (TIME), então
+// os relógios das réplicas não precisam concordar.
+var tokenBucket = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(b[1]) or limit
+local ts = tonumber(b[2]) or now
+tokens = math.min(limit, tokens + (now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('EXPIRE', KEYS[1], math.ceil(limit / rate))
+return {allowed, tostring(tokens)}
+`)
+
+type Redis struct {
+	client *redis.Client
+	limit  int
+	rate   float64
+}
+
+func NewRedis(client *redis.Client, limit int, window time.Duration) *Redis {
+	return &Redis{client: client, limit: limit, rate: refillRate(limit, window)}
+}
+
+func (r *Redis) Allow(ctx context.Context, key string) (Result, error) {
+	res, err := tokenBucket.Run(ctx, r.client, []string{"ratelimit:" + key},
+		r.limit, strconv.FormatFloat(r.rate, 'f', -1, 64)).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	allowed, _ := res[0].(int64)
+	raw, _ := res[1].(string)
+	tokens, _ := strconv.ParseFloat(raw, 64)
+	return result(allowed == 1, math.Max(tokens, 0), r.rate), nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"go_api/logging"
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Auditoria ---
+// Quem faz a alteração chega pelo contexto (WithActor, preenchido na
+// autenticação do REST e do gRPC) e o registro é gravado pela mesma
+// transação da escrita, com RecordAudit.
+
+type actorKey struct{}
+
+// Usuário autenticado e, se for o caso, a chave de API usada ou o admin
+// que está agindo como ele
+type Actor struct {
+	UserID         uint
+	APIKeyID       uint
+	ImpersonatorID uint
+}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func actorFrom(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
+
+type clientIPKey struct{}
+
+// IP de quem fez a requisição, inclusive nas públicas (cadastro)
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+func auditJSON(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+// Grava o registro com o tx da alteração; before/after são serializados
+// pelo JSON público (sem senha nem tokens). fields: colunas alteradas.
+func RecordAudit(tx *gorm.DB, action, entity string, id uint, before, after interface{}, fields ...string) error {
+	ctx := tx.Statement.Context
+	entry := models.AuditLog{
+		Action:    action,
+		Entity:    entity,
+		EntityID:  id,
+		Before:    auditJSON(before),
+		After:     auditJSON(after),
+		RequestID: logging.RequestIDFromContext(ctx),
+		ClientIP:  ClientIPFromContext(ctx),
+	}
+	if actor, ok := actorFrom(ctx); ok {
+		entry.ActorID = &actor.UserID
+		if actor.APIKeyID != 0 {
+			entry.APIKeyID = &actor.APIKeyID
+		}
+		if actor.ImpersonatorID != 0 {
+			entry.ImpersonatorID = &actor.ImpersonatorID
+		}
+	}
+	if len(fields) > 0 {
+		sort.Strings(fields)
+		entry.Fields = auditJSON(fields)
+	}
+	return tx.Create(&entry).Error
+}
+
+// Colunas de um map de Updates, para o campo Fields
+func changedColumns(changes map[string]interface{}) []string {
+	columns := make([]string, 0, len(changes))
+	for column := range changes {
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// Filtros do GET /audit; zero = sem filtro
+type AuditQuery struct {
+	Entity   string
+	EntityID uint
+	ActorID  uint
+	Action   string
+	From, To time.Time
+	Limit    int
+	Offset   int
+	// Só o que foi feito com o token de personificação desse admin
+	ImpersonatorID uint
+}
+
+type AuditStore interface {
+	// Mais recentes primeiro, com o total para a paginação
+	List(ctx context.Context, q AuditQuery) ([]models.AuditLog, int64, error)
+}
+
+type gormAuditStore struct {
+	db *gorm.DB
+}
+
+func NewAuditStore(db *gorm.DB) AuditStore {
+	return &gormAuditStore{db: db}
+}
+
+func (s *gormAuditStore) List(ctx context.Context, q AuditQuery) ([]models.AuditLog, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.AuditLog{})
+	if q.Entity != "" {
+		query = query.Where("entity = ?", q.Entity)
+	}
+	if q.EntityID != 0 {
+		query = query.Where("entity_id = ?", q.EntityID)
+	}
+	if q.ActorID != 0 {
+		query = query.Where("actor_id = ?", q.ActorID)
+	}
+	if q.ImpersonatorID != 0 {
+		query = query.Where("impersonator_id = ?", q.ImpersonatorID)
+	}
+	if q.Action != "" {
+		query = query.Where("action = ?", q.Action)
+	}
+	if !q.From.IsZero() {
+		query = query.Where("created_at >= ?", q.From)
+	}
+	if !q.To.IsZero() {
+		query = query.Where("created_at < ?", q.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var entries []models.AuditLog
+	err := query.Order("id DESC").Limit(q.Limit).Offset(q.Offset).Find(&entries).Error
+	return entries, total, err
+}
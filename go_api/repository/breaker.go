@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// --- Circuit Breaker do Banco ---
+// Com o Postgres sobrecarregado, cada réplica enfileira até DB_MAX_OPEN_CONNS
+// consultas e todo cliente espera até o prazo estourar, o que só piora a
+// carga. Depois de DB_BREAKER_FAILURES falhas seguidas de sobrecarga (prazo
+// estourado, statement_timeout, conexões esgotadas, conexão caída), o
+// circuito abre: por DB_BREAKER_COOLDOWN as chamadas falham na hora com
+// CircuitOpenError (503 com Retry-After na API), sem tocar no banco. Passado
+// esse tempo, uma chamada de teste passa (meio aberto): se der certo o
+// circuito fecha, senão abre de novo.
+//
+// Há um circuito global, no pool do primário (breakerPool), e um por rota
+// (handlers.RouteBreaker), para uma rota pesada que estoura o prazo não
+// derrubar as outras.
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// Chamada recusada com o circuito aberto
+type CircuitOpenError struct {
+	Name       string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return "circuit breaker " + e.Name + " is open"
+}
+
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool // a chamada de teste do meio aberto ainda não voltou
+}
+
+func NewBreaker(name string, threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{name: name, threshold: threshold, cooldown: cooldown}
+}
+
+// Pede para fazer uma chamada. Com o circuito aberto, devolve o
+// CircuitOpenError; senão, done precisa ser chamado com o resultado.
+func (b *Breaker) Allow() (done func(err error), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerOpen {
+		wait := b.cooldown - time.Since(b.openedAt)
+		if wait > 0 {
+			return nil, &CircuitOpenError{Name: b.name, RetryAfter: wait}
+		}
+		b.setState(breakerHalfOpen)
+	}
+	if b.state == breakerHalfOpen {
+		if b.probing {
+			return nil, &CircuitOpenError{Name: b.name, RetryAfter: time.Second}
+		}
+		b.probing = true
+		return b.done(true), nil
+	}
+	return b.done(false), nil
+}
+
+func (b *Breaker) done(probe bool) func(err error) {
+	return func(err error) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if probe {
+			b.probing = false
+		}
+		var open *CircuitOpenError
+		switch {
+		case errors.As(err, &open):
+			// Recusada por outro circuito (o global): não diz nada sobre este
+		case Overloaded(err):
+			b.failures++
+			if probe || b.state == breakerClosed && b.failures >= b.threshold {
+				b.openedAt = time.Now()
+				b.setState(breakerOpen)
+			}
+		default:
+			b.failures = 0
+			if probe {
+				b.setState(breakerClosed)
+			}
+		}
+	}
+}
+
+func (b *Breaker) setState(state breakerState) {
+	if b.state == state {
+		return
+	}
+	switch state {
+	case breakerOpen:
+		slog.Warn("circuit breaker do banco aberto", "circuit", b.name, "failures", b.failures, "cooldown", b.cooldown)
+	case breakerClosed:
+		slog.Info("circuit breaker do banco fechado", "circuit", b.name)
+	}
+	b.state = state
+}
+
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state.String()
+}
+
+// Sinal de banco sobrecarregado ou fora do ar (o que abre o circuito). Erros
+// da própria consulta (constraint, sintaxe, registro não encontrado) não
+// contam: o banco respondeu. O cliente que desistiu também não.
+func Overloaded(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// 57014: statement_timeout; classe 53: recursos esgotados
+		// (too_many_connections...); classe 08 e 57P0x: conexão e desligamento
+		return pgErr.Code == "57014" || strings.HasPrefix(pgErr.Code, "53") ||
+			strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "57P0")
+	}
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// Um circuito por nome (as rotas), criado no primeiro uso
+type BreakerSet struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+func NewBreakerSet(threshold int, cooldown time.Duration) *BreakerSet {
+	return &BreakerSet{threshold: threshold, cooldown: cooldown, breakers: map[string]*Breaker{}}
+}
+
+func (s *BreakerSet) Get(name string) *Breaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.breakers[name]
+	if !ok {
+		b = NewBreaker(name, s.threshold, s.cooldown)
+		s.breakers[name] = b
+	}
+	return b
+}
+
+// --- Circuito Global ---
+
+// ConnPool que passa toda chamada pelo circuito, por fora do retryPool: as
+// repetições de uma consulta contam como uma chamada só. Dentro de uma
+// transação os comandos vão pelo *sql.Tx; o circuito vale para o BeginTx.
+type breakerPool struct {
+	gorm.ConnPool
+	breaker *Breaker
+}
+
+func (p *breakerPool) do(ctx context.Context, fn func() error) error {
+	done, err := p.breaker.Allow()
+	if err != nil {
+		return err
+	}
+	// Prazo que já tinha estourado antes da consulta não é culpa do banco
+	if ctx.Err() != nil {
+		done(nil)
+		return fn()
+	}
+	err = fn()
+	done(err)
+	return err
+}
+
+func (p *breakerPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var res sql.Result
+	err := p.do(ctx, func() (err error) {
+		res, err = p.ConnPool.ExecContext(ctx, query, args...)
+		return err
+	})
+	return res, err
+}
+
+func (p *breakerPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := p.do(ctx, func() (err error) {
+		rows, err = p.ConnPool.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// O *sql.Row não carrega um erro nosso: o QueryRow passa direto (o
+// PrepareContext também, pelo ConnPool embutido)
+
+func (p *breakerPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	var tx *sql.Tx
+	err := p.do(ctx, func() (err error) {
+		tx, err = p.ConnPool.(gorm.TxBeginner).BeginTx(ctx, opts)
+		return err
+	})
+	return tx, err
+}
+
+// Para o db.DB() continuar devolvendo o pool
+func (p *breakerPool) GetDBConn() (*sql.DB, error) {
+	if getter, ok := p.ConnPool.(gorm.GetDBConnector); ok {
+		return getter.GetDBConn()
+	}
+	sqlDB, _ := p.ConnPool.(*sql.DB)
+	return sqlDB, nil
+}
+
+func useBreaker(db *gorm.DB, threshold int, cooldown time.Duration) {
+	if threshold <= 0 {
+		return
+	}
+	pool := &breakerPool{ConnPool: db.ConnPool, breaker: NewBreaker("database", threshold, cooldown)}
+	db.ConnPool, db.Statement.ConnPool = pool, pool
+}
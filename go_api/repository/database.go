@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"go_api/config"
+	"go_api/logging"
+	"go_api/tracing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// --- Conexão Otimizada com o Banco ---
+// DB_DRIVER=postgres (padrão, docker-compose) ou sqlite, para rodar a API e
+// os testes sem Postgres. O driver SQLite é em Go puro (sem cgo), então
+// continua compilando com CGO_ENABLED=0 como no Dockerfile.
+func dialector(cfg config.Config) gorm.Dialector {
+	if cfg.DBDriver == "sqlite" {
+		// FKs vêm desligadas no SQLite; sem elas o ON DELETE CASCADE não roda
+		return sqlite.Open(cfg.DBPath + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	}
+	dsn := fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%d sslmode=disable TimeZone=UTC",
+		cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort,
+	)
+	// Teto no servidor para qualquer consulta, inclusive as que não vêm de
+	// uma requisição (limpezas, ponte MQTT) e não têm prazo no contexto
+	if cfg.DBStatementTimeout > 0 {
+		dsn += fmt.Sprintf(" statement_timeout=%d", cfg.DBStatementTimeout.Milliseconds())
+	}
+	return postgres.Open(dsn)
+}
+
+func Connect(cfg config.Config) (*gorm.DB, error) {
+	var db *gorm.DB
+	var err error
+	// Loop de retry: o banco pode demorar a subir (DB_CONNECT_RETRIES)
+	for i := 0; i < cfg.DBConnectRetries; i++ {
+		db, err = gorm.Open(dialector(cfg), &gorm.Config{Logger: logging.GormLogger{SlowThreshold: cfg.DBSlowQuery}})
+		if err == nil {
+			break
+		}
+		log.Printf("Tentando conectar ao banco (%d/%d)...", i+1, cfg.DBConnectRetries)
+		time.Sleep(cfg.DBConnectRetryDelay)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("não foi possível conectar ao banco (%s): %w", cfg.DBDriver, err)
+	}
+
+	// Span por query (OpenTelemetry); no-op sem OTEL_EXPORTER_OTLP_ENDPOINT
+	if err := db.Use(tracing.GormPlugin()); err != nil {
+		return nil, err
+	}
+	// Repete as consultas que falharem por erro transitório (DB_QUERY_RETRIES);
+	// antes das réplicas, para o primário do dbresolver ser o pool com repetição
+	if err := useQueryRetries(db, cfg.DBQueryRetries); err != nil {
+		return nil, err
+	}
+	// Falha rápido com o banco sobrecarregado (DB_BREAKER_FAILURES), por fora
+	// das repetições
+	useBreaker(db, cfg.DBBreakerFailures, cfg.DBBreakerCooldown)
+	// Réplicas de leitura opcionais (DB_REPLICA_HOSTS)
+	if err := useReplicas(db, cfg); err != nil {
+		return nil, fmt.Errorf("réplicas de leitura: %w", err)
+	}
+
+	// --- PERFORMANCE TUNING ---
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	// MELHORIA 4: Aumentar conexões em espera e máximas
+	sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns) // Padrão 20 (era 10)
+	sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns) // Padrão 80 (4 réplicas: 4*80=320)
+	sqlDB.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+
+	// SQLite aceita um escritor por vez, e cada conexão com ":memory:" abre
+	// um banco vazio diferente: uma conexão só, que nunca é reciclada
+	if cfg.DBDriver == "sqlite" {
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+	}
+	return db, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Tokens de Verificação de E-mail ---
+type EmailVerificationStore interface {
+	// Grava o token novo e apaga os anteriores do usuário
+	Create(ctx context.Context, token *models.EmailVerificationToken) error
+	// Apaga o token e o devolve; ErrNotFound se não existe ou expirou
+	Consume(ctx context.Context, tokenHash string, now time.Time) (models.EmailVerificationToken, error)
+	// Remove os tokens vencidos antes de "before"
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+	// Mesmo repositório dentro de uma transação já aberta
+	WithTx(tx *gorm.DB) EmailVerificationStore
+}
+
+type gormEmailVerificationStore struct {
+	db *gorm.DB
+}
+
+func NewEmailVerificationStore(db *gorm.DB) EmailVerificationStore {
+	return &gormEmailVerificationStore{db: db}
+}
+
+func (s *gormEmailVerificationStore) WithTx(tx *gorm.DB) EmailVerificationStore {
+	return &gormEmailVerificationStore{db: tx}
+}
+
+func (s *gormEmailVerificationStore) Create(ctx context.Context, token *models.EmailVerificationToken) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", token.UserID).Delete(&models.EmailVerificationToken{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// Só a requisição que consegue apagar a linha usa o token
+func (s *gormEmailVerificationStore) Consume(ctx context.Context, tokenHash string, now time.Time) (models.EmailVerificationToken, error) {
+	var token models.EmailVerificationToken
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ? AND expires_at > ?", tokenHash, now).First(&token).Error; err != nil {
+			return err
+		}
+		res := tx.Delete(&token)
+		if res.Error == nil && res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return res.Error
+	})
+	return token, notFound(err)
+}
+
+func (s *gormEmailVerificationStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.EmailVerificationToken{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- Chaves de Idempotência ---
+type IdempotencyStore interface {
+	// Reserva a chave para a requisição atual. Se ela já existe (e não
+	// venceu), não reserva e devolve o registro existente.
+	Reserve(ctx context.Context, record *models.IdempotencyKey) (existing *models.IdempotencyKey, err error)
+	// Guarda a resposta da requisição que reservou a chave
+	Complete(ctx context.Context, id uint, status int, contentType string, body []byte) error
+	// Libera a chave (erro do servidor: o cliente pode tentar de novo)
+	Release(ctx context.Context, id uint) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type gormIdempotencyStore struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyStore(db *gorm.DB) IdempotencyStore {
+	return &gormIdempotencyStore{db: db}
+}
+
+// O INSERT ... ON CONFLICT DO NOTHING decide quem fica com a chave quando
+// duas tentativas chegam juntas: só uma insere a linha
+func (s *gormIdempotencyStore) Reserve(ctx context.Context, record *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	db := s.db.WithContext(ctx)
+	for attempt := 0; attempt < 2; attempt++ {
+		res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			return nil, nil
+		}
+		var existing models.IdempotencyKey
+		err := db.Where(`scope = ? AND "key" = ?`, record.Scope, record.Key).First(&existing).Error
+		if err != nil {
+			return nil, notFound(err)
+		}
+		if existing.ExpiresAt.After(time.Now()) {
+			return &existing, nil
+		}
+		// Venceu e a limpeza ainda não passou: libera e tenta de novo
+		if err := db.Where("id = ? AND expires_at <= ?", existing.ID, time.Now()).Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return nil, err
+		}
+		record.ID = 0
+	}
+	return nil, ErrNotFound
+}
+
+func (s *gormIdempotencyStore) Complete(ctx context.Context, id uint, status int, contentType string, body []byte) error {
+	return s.db.WithContext(ctx).Model(&models.IdempotencyKey{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": status, "content_type": contentType, "body": body}).Error
+}
+
+func (s *gormIdempotencyStore) Release(ctx context.Context, id uint) error {
+	return s.db.WithContext(ctx).Delete(&models.IdempotencyKey{}, id).Error
+}
+
+func (s *gormIdempotencyStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.IdempotencyKey{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Identidades Externas ---
+type IdentityStore interface {
+	// ErrNotFound se ninguém entrou ainda com essa conta do provedor
+	Find(ctx context.Context, provider, subject string) (models.Identity, error)
+	Create(ctx context.Context, identity *models.Identity) error
+	// Mesmo repositório dentro de uma transação já aberta
+	WithTx(tx *gorm.DB) IdentityStore
+}
+
+type gormIdentityStore struct {
+	db *gorm.DB
+}
+
+func NewIdentityStore(db *gorm.DB) IdentityStore {
+	return &gormIdentityStore{db: db}
+}
+
+func (s *gormIdentityStore) WithTx(tx *gorm.DB) IdentityStore {
+	return &gormIdentityStore{db: tx}
+}
+
+func (s *gormIdentityStore) Find(ctx context.Context, provider, subject string) (models.Identity, error) {
+	var identity models.Identity
+	err := s.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	return identity, notFound(err)
+}
+
+func (s *gormIdentityStore) Create(ctx context.Context, identity *models.Identity) error {
+	return s.db.WithContext(ctx).Create(identity).Error
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Tentativas de Login ---
+type LoginAttemptStore interface {
+	Record(ctx context.Context, attempt *models.LoginAttempt) error
+	// Falhas do usuário desde "since" e depois do último login bem-sucedido,
+	// da mais recente para a mais antiga, no máximo "limit"
+	RecentFailures(ctx context.Context, userID uint, since time.Time, limit int) ([]models.LoginAttempt, error)
+	// Mesmo repositório dentro de uma transação já aberta
+	WithTx(tx *gorm.DB) LoginAttemptStore
+}
+
+type gormLoginAttemptStore struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptStore(db *gorm.DB) LoginAttemptStore {
+	return &gormLoginAttemptStore{db: db}
+}
+
+func (s *gormLoginAttemptStore) WithTx(tx *gorm.DB) LoginAttemptStore {
+	return &gormLoginAttemptStore{db: tx}
+}
+
+func (s *gormLoginAttemptStore) Record(ctx context.Context, attempt *models.LoginAttempt) error {
+	return s.db.WithContext(ctx).Create(attempt).Error
+}
+
+func (s *gormLoginAttemptStore) RecentFailures(ctx context.Context, userID uint, since time.Time, limit int) ([]models.LoginAttempt, error) {
+	db := s.db.WithContext(ctx)
+
+	// Um login certo zera a contagem
+	var last models.LoginAttempt
+	err := db.Where("user_id = ? AND success AND created_at > ?", userID, since).
+		Order("created_at DESC").Limit(1).Find(&last).Error
+	if err != nil {
+		return nil, err
+	}
+	if last.ID != 0 {
+		since = last.CreatedAt
+	}
+
+	var failures []models.LoginAttempt
+	err = db.Where("user_id = ? AND NOT success AND created_at > ?", userID, since).
+		Order("created_at DESC").Limit(limit).Find(&failures).Error
+	return failures, err
+}
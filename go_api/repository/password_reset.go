@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Tokens de Redefinição de Senha ---
+type PasswordResetStore interface {
+	// Grava o token novo e invalida os anteriores do usuário ainda não usados
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	// Marca o token como usado e devolve o dono; ErrNotFound se não existe,
+	// expirou ou já foi usado
+	Consume(ctx context.Context, tokenHash string, now time.Time) (uint, error)
+	// Remove os tokens vencidos antes de "before"
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+	// Mesmo repositório dentro de uma transação já aberta
+	WithTx(tx *gorm.DB) PasswordResetStore
+}
+
+type gormPasswordResetStore struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetStore(db *gorm.DB) PasswordResetStore {
+	return &gormPasswordResetStore{db: db}
+}
+
+func (s *gormPasswordResetStore) WithTx(tx *gorm.DB) PasswordResetStore {
+	return &gormPasswordResetStore{db: tx}
+}
+
+func (s *gormPasswordResetStore) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Delete(&models.PasswordResetToken{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// O UPDATE condicional garante uso único mesmo com duas requisições
+// simultâneas: só uma delas altera a linha
+func (s *gormPasswordResetStore) Consume(ctx context.Context, tokenHash string, now time.Time) (uint, error) {
+	var token models.PasswordResetToken
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.PasswordResetToken{}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+			Update("used_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return tx.Where("token_hash = ?", tokenHash).First(&token).Error
+	})
+	return token.UserID, notFound(err)
+}
+
+func (s *gormPasswordResetStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.PasswordResetToken{})
+	return res.RowsAffected, res.Error
+}
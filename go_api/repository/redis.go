@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"time"
+
+	"go_api/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// REDIS_URL no formato redis://[:senha@]host:porta/db. Sem REDIS_URL,
+// retorna nil e quem usa o Redis (rate limit, cache) cai no modo local.
+// Não testa a conexão: o Redis fora do ar não impede a API de subir.
+func ConnectRedis(cfg config.Config) (*redis.Client, error) {
+	if cfg.RedisURL == "" {
+		return nil, nil
+	}
+	opts, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, err
+	}
+	// Com o Redis fora do ar, cada requisição falha rápido e segue sem ele,
+	// em vez de esperar os timeouts padrão (5s) e as retentativas
+	opts.DialTimeout = 500 * time.Millisecond
+	opts.ReadTimeout = 250 * time.Millisecond
+	opts.WriteTimeout = 250 * time.Millisecond
+	opts.MaxRetries = -1
+	opts.DialerRetries = 1
+	return redis.NewClient(opts), nil
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"go_api/config"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// --- Réplicas de Leitura ---
+// Com DB_REPLICA_HOSTS, as leituras que aceitam um pequeno atraso (GET
+// /users, GET /users/:id, leituras dos sensores) vão para as réplicas; o
+// resto, inclusive toda escrita e toda leitura dentro de transação, fica no
+// primário. A escolha é explícita (Replica ou PreferReplica): uma leitura
+// seguida de escrita, como a do lock otimista, não pode ver dado atrasado.
+// Réplica que não responde ao ping sai do sorteio até voltar; sem nenhuma
+// de pé, as leituras vão para o primário.
+
+// Nome do resolver do dbresolver: só as consultas marcadas com ele mudam de conexão
+const replicaResolver = "replicas"
+
+const (
+	replicaCheckInterval = 5 * time.Second
+	replicaPingTimeout   = time.Second
+)
+
+type replicaKey struct{}
+
+// Marca o contexto: as leituras do UserStore feitas com ele podem ir para
+// uma réplica
+func PreferReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaKey{}, true)
+}
+
+func prefersReplica(ctx context.Context) bool {
+	ok, _ := ctx.Value(replicaKey{}).(bool)
+	return ok
+}
+
+// Tira a marca (ex: o cache não pode guardar o que veio atrasado da réplica)
+func primaryOnly(ctx context.Context) context.Context {
+	if !prefersReplica(ctx) {
+		return ctx
+	}
+	return context.WithValue(ctx, replicaKey{}, false)
+}
+
+// Consulta feita numa réplica; sem réplicas configuradas, no primário
+func Replica(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Use(replicaResolver))
+}
+
+// Sessão com o contexto e, se ele pedir, na réplica
+func reader(db *gorm.DB, ctx context.Context) *gorm.DB {
+	db = db.WithContext(ctx)
+	if prefersReplica(ctx) {
+		db = Replica(db)
+	}
+	return db
+}
+
+// Mesmo usuário, senha e banco do primário; a porta padrão é DB_PORT
+func replicaDialector(cfg config.Config, host string) gorm.Dialector {
+	port := cfg.DBPort
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		host = h
+		port, _ = strconv.Atoi(p)
+	}
+	cfg.DBHost, cfg.DBPort = host, port
+	return dialector(cfg)
+}
+
+func useReplicas(db *gorm.DB, cfg config.Config) error {
+	if len(cfg.DBReplicaHosts) == 0 {
+		return nil
+	}
+	// O primário entra por último, como reserva: a política só o escolhe
+	// quando todas as réplicas estão fora (e o dbresolver pula a política
+	// quando há uma conexão só)
+	replicas := make([]gorm.Dialector, 0, len(cfg.DBReplicaHosts)+1)
+	for _, host := range cfg.DBReplicaHosts {
+		replicas = append(replicas, replicaDialector(cfg, host))
+	}
+	replicas = append(replicas, dialector(cfg))
+
+	// Réplica fora do ar na subida não impede a API de subir
+	db.Config.DisableAutomaticPing = true
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   &replicaPolicy{hosts: cfg.DBReplicaHosts, down: map[gorm.ConnPool]bool{}},
+	}, replicaResolver).
+		SetMaxIdleConns(cfg.DBMaxIdleConns).
+		SetMaxOpenConns(cfg.DBMaxOpenConns).
+		SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	return db.Use(resolver)
+}
+
+// Sorteia entre as réplicas que responderam ao último ping. O ping roda em
+// segundo plano, no máximo a cada replicaCheckInterval, disparado pelas
+// próprias consultas.
+type replicaPolicy struct {
+	hosts []string
+
+	mu       sync.Mutex
+	down     map[gorm.ConnPool]bool
+	checking bool
+	checked  time.Time
+}
+
+func (p *replicaPolicy) Resolve(pools []gorm.ConnPool) gorm.ConnPool {
+	replicas, primary := pools[:len(pools)-1], pools[len(pools)-1]
+
+	p.mu.Lock()
+	if !p.checking && time.Since(p.checked) >= replicaCheckInterval {
+		p.checking = true
+		go p.check(replicas)
+	}
+	up := make([]gorm.ConnPool, 0, len(replicas))
+	for _, pool := range replicas {
+		if !p.down[pool] {
+			up = append(up, pool)
+		}
+	}
+	p.mu.Unlock()
+
+	if len(up) == 0 {
+		return primary
+	}
+	return up[rand.IntN(len(up))]
+}
+
+func (p *replicaPolicy) check(replicas []gorm.ConnPool) {
+	down := make(map[gorm.ConnPool]bool, len(replicas))
+	errs := make(map[gorm.ConnPool]error, len(replicas))
+	for _, pool := range replicas {
+		pinger, ok := pool.(interface{ PingContext(context.Context) error })
+		if !ok {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), replicaPingTimeout)
+		if err := pinger.PingContext(ctx); err != nil {
+			down[pool], errs[pool] = true, err
+		}
+		cancel()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, pool := range replicas {
+		switch {
+		case down[pool] && !p.down[pool]:
+			slog.Warn("réplica de leitura fora do ar, leituras redirecionadas", "host", p.hosts[i], "error", errs[pool])
+		case !down[pool] && p.down[pool]:
+			slog.Info("réplica de leitura de volta", "host", p.hosts[i])
+		}
+	}
+	p.down, p.checking, p.checked = down, false, time.Now()
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"go_api/config"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// --- Falhas Transitórias do Banco ---
+// O DB_CONNECT_RETRIES só cobre a subida. Depois dela, um restart do
+// Postgres deixa o pool cheio de conexões mortas: o MonitorConnection
+// percebe pelo ping, descarta as conexões paradas e volta a testar com
+// espera exponencial, e o retryPool repete as consultas que falharam por
+// um motivo passageiro (serialização, deadlock, conexão caída).
+
+const (
+	// Espera antes da segunda tentativa de uma consulta; dobra a cada falha
+	queryRetryDelay = 50 * time.Millisecond
+	// Espera entre os pings com o banco fora do ar: 1s, 2s, 4s... até o teto
+	reconnectMinDelay = time.Second
+	reconnectMaxDelay = 30 * time.Second
+	pingTimeout       = 2 * time.Second
+)
+
+// Vale repetir a consulta? Escritas só quando é garantido que o comando não
+// foi aplicado; leituras também quando a conexão caiu no meio.
+func transient(err error, read bool) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		// Serialização, deadlock e conexão derrubada pelo servidor
+		// (restart): o Postgres desfez o comando
+		case "40001", "40P01", "57P01", "57P02", "57P03":
+			return true
+		}
+		// Classe 08: exceções de conexão
+		return read && strings.HasPrefix(pgErr.Code, "08")
+	}
+	if pgconn.SafeToRetry(err) || errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	if !read {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
+
+func isRead(query string) bool {
+	query = strings.TrimSpace(query)
+	return len(query) > 6 && strings.EqualFold(query[:6], "select") && !strings.Contains(strings.ToUpper(query), " FOR UPDATE")
+}
+
+// ConnPool do GORM que repete as consultas fora de transação (até retries
+// vezes). Dentro de uma transação os comandos vão pelo *sql.Tx: depois de
+// uma falha a transação inteira já está perdida, e repetir o comando sozinho
+// não adiantaria.
+type retryPool struct {
+	db      *sql.DB
+	retries int
+}
+
+func (p *retryPool) do(ctx context.Context, read bool, fn func() error) error {
+	delay := queryRetryDelay
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.retries || ctx.Err() != nil || !transient(err, read) {
+			return err
+		}
+		slog.WarnContext(ctx, "consulta falhou por um erro transitório, repetindo", "attempt", attempt+1, "error", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (p *retryPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.db.PrepareContext(ctx, query)
+}
+
+func (p *retryPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var res sql.Result
+	err := p.do(ctx, false, func() (err error) {
+		res, err = p.db.ExecContext(ctx, query, args...)
+		return err
+	})
+	return res, err
+}
+
+func (p *retryPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := p.do(ctx, isRead(query), func() (err error) {
+		rows, err = p.db.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// O erro do QueryRow só aparece no Scan, fora daqui: sem repetição
+func (p *retryPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return p.db.QueryRowContext(ctx, query, args...)
+}
+
+// Abrir a transação nunca aplicou nada: pode repetir em qualquer falha de conexão
+func (p *retryPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	var tx *sql.Tx
+	err := p.do(ctx, true, func() (err error) {
+		tx, err = p.db.BeginTx(ctx, opts)
+		return err
+	})
+	return tx, err
+}
+
+// Para o db.DB() continuar devolvendo o pool
+func (p *retryPool) GetDBConn() (*sql.DB, error) {
+	return p.db, nil
+}
+
+func useQueryRetries(db *gorm.DB, retries int) error {
+	if retries <= 0 {
+		return nil
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	pool := &retryPool{db: sqlDB, retries: retries}
+	db.ConnPool, db.Statement.ConnPool = pool, pool
+	return nil
+}
+
+// Pinga o banco a cada DB_HEALTH_INTERVAL. Na primeira falha, fecha as conexões
+// paradas no pool (mortas se o Postgres reiniciou) e passa a pingar com
+// espera exponencial até voltar; as requisições nesse meio tempo falham
+// rápido em vez de esperar uma conexão morta. A função devolvida para o
+// monitor. Só Postgres: o SQLite é local e, em memória, fechar a conexão
+// apagaria o banco.
+func MonitorConnection(db *gorm.DB, cfg config.Config) func() {
+	sqlDB, err := db.DB()
+	if err != nil || cfg.DBDriver != "postgres" || cfg.DBHealthInterval <= 0 {
+		return func() {}
+	}
+	interval := cfg.DBHealthInterval
+	stop := make(chan struct{})
+	go func() {
+		delay := interval
+		var downSince time.Time
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(delay):
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+			err := sqlDB.PingContext(ctx)
+			cancel()
+
+			switch {
+			case err != nil && downSince.IsZero():
+				downSince = time.Now()
+				delay = reconnectMinDelay
+				slog.Error("conexão com o banco perdida, reconectando", "error", err)
+				// Zerar o limite de ociosas fecha as que estão paradas
+				sqlDB.SetMaxIdleConns(0)
+			case err != nil:
+				delay = min(delay*2, reconnectMaxDelay)
+				slog.Warn("banco ainda fora do ar", "down_for", time.Since(downSince).Round(time.Second), "retry_in", delay, "error", err)
+			case !downSince.IsZero():
+				slog.Info("conexão com o banco restabelecida", "down_for", time.Since(downSince).Round(time.Second))
+				sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)
+				downSince, delay = time.Time{}, interval
+			}
+		}
+	}()
+	return func() { close(stop) }
+}
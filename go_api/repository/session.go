@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Sessões ---
+
+// O refresh token apresentado já tinha sido trocado por outro
+var ErrTokenReused = errors.New("refresh token reused")
+
+type SessionStore interface {
+	Create(ctx context.Context, session *models.Session) error
+	// Troca o hash do refresh token (e renova validade e IP) se a
+	// sessão está ativa e o hash confere. ErrNotFound se não existe, expirou
+	// ou foi revogada; ErrTokenReused se o hash é de um token antigo, e nesse
+	// caso a sessão é revogada.
+	Rotate(ctx context.Context, session *models.Session, oldHash string, now time.Time) error
+	// Sessões não vencidas nem revogadas, da mais recente para a mais antiga
+	ListActive(ctx context.Context, userID uint, now time.Time) ([]models.Session, error)
+	// ErrNotFound se a sessão não é do usuário ou já não está ativa
+	Revoke(ctx context.Context, userID, id uint, now time.Time) error
+	RevokeAll(ctx context.Context, userID uint, now time.Time) error
+	// Remove as sessões vencidas ou revogadas antes de "before"
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+	// Mesmo repositório dentro de uma transação já aberta
+	WithTx(tx *gorm.DB) SessionStore
+}
+
+type gormSessionStore struct {
+	db *gorm.DB
+}
+
+func NewSessionStore(db *gorm.DB) SessionStore {
+	return &gormSessionStore{db: db}
+}
+
+func (s *gormSessionStore) WithTx(tx *gorm.DB) SessionStore {
+	return &gormSessionStore{db: tx}
+}
+
+func (s *gormSessionStore) Create(ctx context.Context, session *models.Session) error {
+	return s.db.WithContext(ctx).Create(session).Error
+}
+
+// Como no Consume dos tokens de senha, o UPDATE condicional resolve a
+// corrida: de dois refresh simultâneos com o mesmo token, só um troca o hash
+func (s *gormSessionStore) Rotate(ctx context.Context, session *models.Session, oldHash string, now time.Time) error {
+	db := s.db.WithContext(ctx)
+	res := db.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND token_hash = ? AND revoked_at IS NULL AND expires_at > ?",
+			session.ID, session.UserID, oldHash, now).
+		Updates(map[string]interface{}{
+			"token_hash":   session.TokenHash,
+			"ip":           session.IP,
+			"expires_at":   session.ExpiresAt,
+			"last_used_at": now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 1 {
+		return db.First(session, session.ID).Error
+	}
+
+	// Sessão ativa com outro hash: alguém usou um token que já foi trocado
+	res = db.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", session.ID, session.UserID, now).
+		Update("revoked_at", now)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 1 {
+		return ErrTokenReused
+	}
+	return ErrNotFound
+}
+
+func (s *gormSessionStore) ListActive(ctx context.Context, userID uint, now time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := s.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_used_at DESC, id DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+func (s *gormSessionStore) Revoke(ctx context.Context, userID, id uint, now time.Time) error {
+	res := s.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", id, userID, now).
+		Update("revoked_at", now)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *gormSessionStore) RevokeAll(ctx context.Context, userID uint, now time.Time) error {
+	return s.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
+
+func (s *gormSessionStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Where("expires_at < ? OR revoked_at < ?", before, before).Delete(&models.Session{})
+	return res.RowsAffected, res.Error
+}
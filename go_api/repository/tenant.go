@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- Isolamento por Tenant ---
+// O tenant da requisição chega pelo contexto (WithTenant, preenchido no
+// middleware do REST e na autenticação do gRPC). Os callbacks do
+// ScopeTenants acrescentam "tenant_id = ?" a toda consulta, alteração e
+// remoção de um modelo com TenantID, e preenchem o TenantID na criação:
+// nenhum handler precisa lembrar do filtro. SQL cru (Raw/Exec) fica de fora.
+// Sem tenant no contexto (jobs, cadastro sem X-Tenant), nada é filtrado.
+
+type tenantKey struct{}
+
+func WithTenant(ctx context.Context, id uint) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// Tenant do contexto; false quando as consultas valem para todos
+func TenantFrom(ctx context.Context) (uint, bool) {
+	id, ok := ctx.Value(tenantKey{}).(uint)
+	return id, ok && id != 0
+}
+
+// Consultas que precisam enxergar todos os tenants (ex: unicidade de e-mail
+// e username, que continua global)
+func AllTenants(ctx context.Context) context.Context {
+	if _, ok := TenantFrom(ctx); !ok {
+		return ctx
+	}
+	return WithTenant(ctx, 0)
+}
+
+// Tenant do próprio registro quando a requisição não trouxe um (rotas
+// públicas, como a redefinição de senha): os eventos e a auditoria
+// gravados junto ficam no tenant certo
+func intoTenant(ctx context.Context, id uint) context.Context {
+	if _, set := ctx.Value(tenantKey{}).(uint); set || id == 0 {
+		return ctx
+	}
+	return WithTenant(ctx, id)
+}
+
+func tenantField(tx *gorm.DB) (uint, bool) {
+	if tx.Error != nil || tx.Statement.Schema == nil || tx.Statement.Schema.LookUpField("TenantID") == nil {
+		return 0, false
+	}
+	return TenantFrom(tx.Statement.Context)
+}
+
+// Uma vez por statement: a mesma consulta pode passar duas vezes pelos
+// callbacks (Count e depois Find)
+func scopeTenant(tx *gorm.DB) {
+	tenant, ok := tenantField(tx)
+	if !ok {
+		return
+	}
+	if _, scoped := tx.Statement.Settings.LoadOrStore("tenants:scoped", true); scoped {
+		return
+	}
+	tx.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "tenant_id"}, Value: tenant},
+	}})
+}
+
+// TenantID vazio recebe o do contexto, tanto em Create(&x) quanto em
+// Create(&[]X{...})
+func fillTenant(tx *gorm.DB) {
+	tenant, ok := tenantField(tx)
+	if !ok {
+		return
+	}
+	field := tx.Statement.Schema.LookUpField("TenantID")
+	ctx := tx.Statement.Context
+	set := func(rv reflect.Value) {
+		if _, zero := field.ValueOf(ctx, rv); zero {
+			tx.AddError(field.Set(ctx, rv, tenant))
+		}
+	}
+	value := tx.Statement.ReflectValue
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			set(reflect.Indirect(value.Index(i)))
+		}
+	case reflect.Struct:
+		set(value)
+	}
+}
+
+func ScopeTenants(db *gorm.DB) {
+	db.Callback().Query().Before("gorm:query").Register("tenants:query", scopeTenant)
+	db.Callback().Row().Before("gorm:row").Register("tenants:row", scopeTenant)
+	db.Callback().Update().Before("gorm:update").Register("tenants:update", scopeTenant)
+	db.Callback().Delete().Before("gorm:delete").Register("tenants:delete", scopeTenant)
+	db.Callback().Create().Before("gorm:create").Register("tenants:create", fillTenant)
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Filtros ?created_after=&created_before=&updated_after=&updated_before= das
+// coleções (zero = sem limite). Os limites não entram: updated_after com a
+// hora da última consulta traz só o que mudou depois dela.
+type TimeFilter struct {
+	CreatedAfter, CreatedBefore time.Time
+	UpdatedAfter, UpdatedBefore time.Time
+}
+
+func (f TimeFilter) Apply(query *gorm.DB) *gorm.DB {
+	conditions := []struct {
+		sql   string
+		value time.Time
+	}{
+		{"created_at > ?", f.CreatedAfter},
+		{"created_at < ?", f.CreatedBefore},
+		{"updated_at > ?", f.UpdatedAfter},
+		{"updated_at < ?", f.UpdatedBefore},
+	}
+	for _, c := range conditions {
+		if !c.value.IsZero() {
+			query = query.Where(c.sql, c.value)
+		}
+	}
+	return query
+}
@@ -0,0 +1,356 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go_api/models"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- Repositório de Usuários ---
+// Único ponto que lê e grava a tabela users. A tabela é a projeção do
+// stream de eventos, então toda escrita grava o evento correspondente na
+// mesma transação.
+
+var ErrNotFound = errors.New("record not found")
+
+// Outra escrita mudou a revisão entre a leitura e o Update
+var ErrStaleRevision = errors.New("stale revision")
+
+// Violação de índice único; Field é o campo do JSON ("email" ou "user")
+type DuplicateError struct {
+	Field string
+}
+
+func (e *DuplicateError) Error() string {
+	return "duplicate " + e.Field
+}
+
+// Descobre qual índice único foi violado. Postgres: código 23505 com o nome
+// do índice criado pelo GORM (idx_users_email); SQLite: só pela mensagem.
+func duplicate(err error) error {
+	if err == nil {
+		return nil
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return &DuplicateError{Field: strings.TrimPrefix(pgErr.ConstraintName, "idx_users_")}
+	}
+	if msg := err.Error(); strings.Contains(msg, "UNIQUE constraint failed: users.") {
+		field := msg[strings.Index(msg, "users.")+len("users."):]
+		if end := strings.IndexAny(field, " ,"); end >= 0 {
+			field = field[:end]
+		}
+		return &DuplicateError{Field: field}
+	}
+	return err
+}
+
+// Consulta da listagem já traduzida para SQL (filtro RSQL, ?sort=, página)
+type UserQuery struct {
+	Where  string
+	Args   []interface{}
+	Order  []string
+	Limit  int
+	Offset int
+	// Somente admin: inclui os usuários removidos (soft delete)
+	IncludeDeleted bool
+	// Colunas do SELECT (?fields=); vazio = todas
+	Select []string
+	Times  TimeFilter
+}
+
+// UserStore é o que o service precisa do banco: a implementação com o GORM,
+// com o cache de user_cache.go por cima.
+type UserStore interface {
+	// FindByID, FindAnyByID e List leem de uma réplica quando o contexto vem
+	// de PreferReplica
+	FindByID(ctx context.Context, id uint) (models.User, error)
+	// Como FindByID, mas também encontra usuários removidos
+	FindAnyByID(ctx context.Context, id uint) (models.User, error)
+	FindByLogin(ctx context.Context, login string) (models.User, error) // username ou e-mail
+	// Algum outro usuário (inclusive removido) já usa o valor na coluna?
+	Taken(ctx context.Context, column, value string, exceptID uint) (bool, error)
+	// Quais dos valores já estão em uso na coluna (cadastro em lote)
+	TakenValues(ctx context.Context, column string, values []string) (map[string]bool, error)
+	List(ctx context.Context, q UserQuery) ([]models.User, int64, error)
+	// Busca por trecho do nome, e-mail ou username, dos mais parecidos
+	// para os menos
+	Search(ctx context.Context, term string, limit, offset int) ([]models.User, error)
+	// Percorre a consulta linha a linha, sem carregar tudo (exportação);
+	// ignora Limit e Offset
+	Each(ctx context.Context, q UserQuery, fn func(models.User) error) error
+	Create(ctx context.Context, user *models.User) error
+	// Aplica as colunas alteradas, incrementa a revisão e grava os eventos.
+	// Só grava se a revisão no banco ainda for user.Revision (lock otimista);
+	// senão devolve ErrStaleRevision e nada muda.
+	Update(ctx context.Context, user *models.User, changes map[string]interface{}, events ...models.UserEvent) error
+	Delete(ctx context.Context, user *models.User) error
+	Restore(ctx context.Context, user *models.User) error
+	// Mesmo repositório dentro de uma transação já aberta (ver Transaction)
+	WithTx(tx *gorm.DB) UserStore
+	// Abre uma transação no banco do repositório: a unidade de trabalho dos
+	// services, que passam o tx para o WithTx de cada repositório usado.
+	// Erro (ou pânico) em fn desfaz tudo; dentro de outra transação, vira
+	// um savepoint.
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
+}
+
+type gormUserStore struct {
+	db *gorm.DB
+}
+
+func NewUserStore(db *gorm.DB) UserStore {
+	return &gormUserStore{db: db}
+}
+
+func (s *gormUserStore) WithTx(tx *gorm.DB) UserStore {
+	return &gormUserStore{db: tx}
+}
+
+func (s *gormUserStore) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return s.db.WithContext(ctx).Transaction(fn)
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *gormUserStore) FindByID(ctx context.Context, id uint) (models.User, error) {
+	var user models.User
+	err := reader(s.db, ctx).First(&user, id).Error
+	return user, notFound(err)
+}
+
+func (s *gormUserStore) FindAnyByID(ctx context.Context, id uint) (models.User, error) {
+	var user models.User
+	err := reader(s.db, ctx).Unscoped().First(&user, id).Error
+	return user, notFound(err)
+}
+
+func (s *gormUserStore) FindByLogin(ctx context.Context, login string) (models.User, error) {
+	var user models.User
+	err := s.db.WithContext(ctx).Where("\"user\" = ? OR email = ?", login, login).First(&user).Error
+	return user, notFound(err)
+}
+
+// column vem sempre de constantes do service, nunca da requisição. Os
+// índices únicos valem para todos os tenants, então a checagem também.
+func (s *gormUserStore) Taken(ctx context.Context, column, value string, exceptID uint) (bool, error) {
+	var count int64
+	err := s.db.WithContext(AllTenants(ctx)).Unscoped().Model(&models.User{}).
+		Where(fmt.Sprintf("%q = ? AND id <> ?", column), value, exceptID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (s *gormUserStore) TakenValues(ctx context.Context, column string, values []string) (map[string]bool, error) {
+	taken := make(map[string]bool)
+	if len(values) == 0 {
+		return taken, nil
+	}
+	var found []string
+	err := s.db.WithContext(AllTenants(ctx)).Unscoped().Model(&models.User{}).
+		Where(fmt.Sprintf("%q IN ?", column), values).
+		Pluck(column, &found).Error
+	for _, v := range found {
+		taken[v] = true
+	}
+	return taken, err
+}
+
+func (s *gormUserStore) List(ctx context.Context, q UserQuery) ([]models.User, int64, error) {
+	query := reader(s.db, ctx).Model(&models.User{})
+	if q.IncludeDeleted {
+		query = query.Unscoped()
+	}
+	if q.Where != "" {
+		query = query.Where(q.Where, q.Args...)
+	}
+	query = q.Times.Apply(query)
+	// Session: o Count não pode deixar o SELECT COUNT na query reaproveitada
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	for _, order := range q.Order {
+		query = query.Order(order)
+	}
+	if len(q.Select) > 0 {
+		query = query.Select(q.Select)
+	}
+
+	var users []models.User
+	err := query.Limit(q.Limit).Offset(q.Offset).Find(&users).Error
+	return users, total, err
+}
+
+// Mesma expressão do índice trigram (migração 202610140005)
+const searchExpr = `(name || ' ' || email || ' ' || "user")`
+
+// Escapa os curingas do LIKE digitados pelo usuário
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Ordem: username ou e-mail exato, username que começa com o termo, nome
+// com uma palavra que começa com o termo, e-mail que começa com o termo e,
+// por fim, o termo em qualquer posição
+func (s *gormUserStore) Search(ctx context.Context, term string, limit, offset int) ([]models.User, error) {
+	like := "LIKE" // no SQLite já ignora maiúsculas (ASCII)
+	if s.db.Dialector.Name() == "postgres" {
+		like = "ILIKE"
+	}
+	escaped := likeEscaper.Replace(term)
+	contains, prefix, word := "%"+escaped+"%", escaped+"%", "% "+escaped+"%"
+	rank := fmt.Sprintf(`CASE
+		WHEN LOWER("user") = LOWER(@term) OR LOWER(email) = LOWER(@term) THEN 0
+		WHEN "user" %[1]s @prefix ESCAPE '\' THEN 1
+		WHEN name %[1]s @prefix ESCAPE '\' OR name %[1]s @word ESCAPE '\' THEN 2
+		WHEN email %[1]s @prefix ESCAPE '\' THEN 3
+		ELSE 4 END`, like)
+
+	var users []models.User
+	err := s.db.WithContext(ctx).
+		Where(searchExpr+" "+like+" @contains ESCAPE '\\'", map[string]interface{}{"contains": contains}).
+		Order(clause.OrderBy{Expression: clause.NamedExpr{
+			SQL:  rank + ", name, id",
+			Vars: []interface{}{map[string]interface{}{"term": term, "prefix": prefix, "word": word}},
+		}}).
+		Limit(limit).Offset(offset).
+		Find(&users).Error
+	return users, err
+}
+
+func (s *gormUserStore) Each(ctx context.Context, q UserQuery, fn func(models.User) error) error {
+	query := s.db.WithContext(ctx).Model(&models.User{})
+	if q.IncludeDeleted {
+		query = query.Unscoped()
+	}
+	if q.Where != "" {
+		query = query.Where(q.Where, q.Args...)
+	}
+	query = q.Times.Apply(query)
+	for _, order := range q.Order {
+		query = query.Order(order)
+	}
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var user models.User
+		if err := query.ScanRows(rows, &user); err != nil {
+			return err
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func appendUserEvents(tx *gorm.DB, events ...models.UserEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return tx.Create(&events).Error
+}
+
+func (s *gormUserStore) Create(ctx context.Context, user *models.User) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return duplicate(err)
+		}
+		if err := RecordAudit(tx, models.AuditCreate, "user", user.ID, nil, user); err != nil {
+			return err
+		}
+		return appendUserEvents(tx, models.NewUserEvent(user.ID, models.EventUserRegistered, models.UserEventData{
+			Name: user.Name, Email: user.Email, User: user.User, Role: user.Role,
+		}))
+	})
+}
+
+// Estado gravado depois da alteração, para o After da auditoria
+func reloadUser(tx *gorm.DB, id uint) (models.User, error) {
+	var user models.User
+	err := tx.Unscoped().First(&user, id).Error
+	return user, err
+}
+
+// Updates com map grava também valores "zero", ao contrário do struct
+func (s *gormUserStore) Update(ctx context.Context, user *models.User, changes map[string]interface{}, events ...models.UserEvent) error {
+	updates := map[string]interface{}{"revision": user.Revision + 1}
+	for column, value := range changes {
+		updates[column] = value
+	}
+	before := *user
+	return s.db.WithContext(intoTenant(ctx, user.TenantID)).Transaction(func(tx *gorm.DB) error {
+		if err := appendUserEvents(tx, events...); err != nil {
+			return err
+		}
+		result := tx.Model(user).Where("revision = ?", user.Revision).Updates(updates)
+		if result.Error != nil {
+			return duplicate(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrStaleRevision
+		}
+		after, err := reloadUser(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		return RecordAudit(tx, models.AuditUpdate, "user", user.ID, before, after, changedColumns(changes)...)
+	})
+}
+
+func (s *gormUserStore) Delete(ctx context.Context, user *models.User) error {
+	return s.db.WithContext(intoTenant(ctx, user.TenantID)).Transaction(func(tx *gorm.DB) error {
+		if err := appendUserEvents(tx, models.NewUserEvent(user.ID, models.EventUserDeleted, models.UserEventData{})); err != nil {
+			return err
+		}
+		if err := RecordAudit(tx, models.AuditDelete, "user", user.ID, user, nil); err != nil {
+			return err
+		}
+		// A remoção também é uma alteração (GET /sync)
+		if err := tx.Model(user).UpdateColumn("updated_at", time.Now()).Error; err != nil {
+			return err
+		}
+		// Soft delete (DeletedAt): as linhas dependentes (dispositivos,
+		// consentimentos...) continuam no banco para o caso de restauração
+		return tx.Delete(user).Error
+	})
+}
+
+// Limpa o deleted_at; email e user continuam reservados enquanto o usuário
+// está removido (índice único), então a restauração não tem como conflitar
+func (s *gormUserStore) Restore(ctx context.Context, user *models.User) error {
+	return s.db.WithContext(intoTenant(ctx, user.TenantID)).Transaction(func(tx *gorm.DB) error {
+		if err := appendUserEvents(tx, models.NewUserEvent(user.ID, models.EventUserRestored, models.UserEventData{})); err != nil {
+			return err
+		}
+		before := *user
+		err := tx.Unscoped().Model(user).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"revision":   user.Revision + 1,
+		}).Error
+		if err != nil {
+			return err
+		}
+		user.DeletedAt = gorm.DeletedAt{}
+		after, err := reloadUser(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		return RecordAudit(tx, models.AuditRestore, "user", user.ID, before, after)
+	})
+}
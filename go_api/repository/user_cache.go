@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"go_api/models"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// --- Cache de Usuários (Redis, cache-aside) ---
+// Leituras de FindByID e List passam primeiro pelo Redis; no miss, vão ao
+// banco e guardam o resultado por CACHE_TTL. Toda escrita apaga a entrada
+// do usuário e invalida todas as listagens de uma vez (as chaves das
+// listagens levam um número de geração, incrementado a cada escrita).
+// Se o Redis falhar, a leitura segue direto no banco.
+
+const (
+	userCacheKey = "users:id:"
+	listGenKey   = "users:list:gen"
+	listCacheKey = "users:list:"
+)
+
+type userCache struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+// Repositório que invalida o cache a cada escrita, sem ler dele. É o que
+// vale dentro de uma transação (sync): o cache não pode devolver nem guardar
+// algo que ainda não foi confirmado. Como a invalidação acontece antes do
+// commit, uma leitura nesse meio tempo pode deixar uma entrada velha no
+// cache, no máximo por CACHE_TTL.
+type invalidatingStore struct {
+	UserStore
+	cache *userCache
+}
+
+// Escritas comuns e leituras pelo cache
+type cachedUserStore struct {
+	invalidatingStore
+}
+
+func NewCachedUserStore(store UserStore, rdb *redis.Client, ttl time.Duration) UserStore {
+	return &cachedUserStore{invalidatingStore{UserStore: store, cache: &userCache{rdb: rdb, ttl: ttl}}}
+}
+
+// O MarshalJSON do User tira a senha; o cache precisa do hash (troca de
+// senha compara com ele), então serializa sem o método
+type cachedUser models.User
+
+type cachedList struct {
+	Users []cachedUser `json:"users"`
+	Total int64        `json:"total"`
+}
+
+func cacheWarn(ctx context.Context, err error) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		slog.WarnContext(ctx, "cache indisponível, lendo do banco", "error", err)
+	}
+}
+
+func (c *userCache) get(ctx context.Context, key string, v interface{}) bool {
+	raw, err := c.rdb.Get(ctx, key).Bytes()
+	if err != nil {
+		cacheWarn(ctx, err)
+		return false
+	}
+	return json.Unmarshal(raw, v) == nil
+}
+
+func (c *userCache) set(ctx context.Context, key string, v interface{}) {
+	raw, err := json.Marshal(v)
+	if err == nil {
+		err = c.rdb.Set(ctx, key, raw, c.ttl).Err()
+	}
+	cacheWarn(ctx, err)
+}
+
+func userKey(id uint) string {
+	return userCacheKey + strconv.FormatUint(uint64(id), 10)
+}
+
+// Chamado depois de toda escrita bem-sucedida
+func (c *userCache) invalidate(ctx context.Context, id uint) {
+	_, err := c.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, userKey(id))
+		p.Incr(ctx, listGenKey)
+		return nil
+	})
+	cacheWarn(ctx, err)
+}
+
+func (s *cachedUserStore) FindByID(ctx context.Context, id uint) (models.User, error) {
+	var cached cachedUser
+	if s.cache.get(ctx, userKey(id), &cached) {
+		// A chave é só o ID: o usuário de outro tenant não aparece
+		if tenant, ok := TenantFrom(ctx); ok && cached.TenantID != tenant {
+			return models.User{}, ErrNotFound
+		}
+		return models.User(cached), nil
+	}
+	// O que vai para o cache sai do primário: uma réplica atrasada deixaria
+	// o valor antigo no cache por CACHE_TTL, mesmo depois da invalidação
+	user, err := s.UserStore.FindByID(primaryOnly(ctx), id)
+	if err == nil {
+		s.cache.set(ctx, userKey(id), cachedUser(user))
+	}
+	return user, err
+}
+
+func (s *cachedUserStore) List(ctx context.Context, q UserQuery) ([]models.User, int64, error) {
+	gen, err := s.cache.rdb.Get(ctx, listGenKey).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		cacheWarn(ctx, err)
+		return s.UserStore.List(ctx, q)
+	}
+	raw, _ := json.Marshal(q)
+	sum := sha1.Sum(raw)
+	tenant, _ := TenantFrom(ctx)
+	key := listCacheKey + gen + ":" + strconv.FormatUint(uint64(tenant), 10) + ":" + hex.EncodeToString(sum[:])
+
+	var cached cachedList
+	if s.cache.get(ctx, key, &cached) {
+		users := make([]models.User, len(cached.Users))
+		for i, u := range cached.Users {
+			users[i] = models.User(u)
+		}
+		return users, cached.Total, nil
+	}
+
+	users, total, err := s.UserStore.List(primaryOnly(ctx), q)
+	if err == nil {
+		entry := cachedList{Users: make([]cachedUser, len(users)), Total: total}
+		for i, u := range users {
+			entry.Users[i] = cachedUser(u)
+		}
+		s.cache.set(ctx, key, entry)
+	}
+	return users, total, err
+}
+
+func (s *invalidatingStore) Create(ctx context.Context, user *models.User) error {
+	err := s.UserStore.Create(ctx, user)
+	if err == nil {
+		s.cache.invalidate(ctx, user.ID)
+	}
+	return err
+}
+
+func (s *invalidatingStore) Update(ctx context.Context, user *models.User, changes map[string]interface{}, events ...models.UserEvent) error {
+	err := s.UserStore.Update(ctx, user, changes, events...)
+	if err == nil {
+		s.cache.invalidate(ctx, user.ID)
+	}
+	return err
+}
+
+func (s *invalidatingStore) Delete(ctx context.Context, user *models.User) error {
+	err := s.UserStore.Delete(ctx, user)
+	if err == nil {
+		s.cache.invalidate(ctx, user.ID)
+	}
+	return err
+}
+
+func (s *invalidatingStore) Restore(ctx context.Context, user *models.User) error {
+	err := s.UserStore.Restore(ctx, user)
+	if err == nil {
+		s.cache.invalidate(ctx, user.ID)
+	}
+	return err
+}
+
+func (s *invalidatingStore) WithTx(tx *gorm.DB) UserStore {
+	return &invalidatingStore{UserStore: s.UserStore.WithTx(tx), cache: s.cache}
+}
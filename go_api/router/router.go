@@ -0,0 +1,289 @@
+package router
+
+import (
+	"net/http"
+
+	"go_api/handlers"
+	"go_api/logging"
+	"go_api/models"
+	"go_api/tracing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- Rotas da API ---
+// Monta o router com todos os middlewares e rotas. Middlewares extras (ex:
+// o Alt-Svc do HTTP/3) entram antes das rotas, senão não valem para elas.
+func New(h *handlers.Handler, extra ...gin.HandlerFunc) *gin.Engine {
+	r := gin.New()                                         // Cria router sem middlewares padrão
+	h.TrustProxies(r)                                      // c.ClientIP() atrás do nginx (TRUSTED_PROXIES)
+	r.HandleMethodNotAllowed = true                        // 405 com Allow em vez de 404
+	r.NoRoute(handlers.NoRoute)                            // 404 no JSON de erro padrão
+	r.NoMethod(handlers.NoMethod)                          // 405 idem; OPTIONS responde 204 com o Allow
+	r.Use(gin.CustomRecovery(handlers.RecoveryHandler))    // Adiciona apenas recuperação de pânico (mais leve)
+	r.Use(tracing.Middleware(h.Config.OTelServiceName)...) // Span da requisição + X-Trace-ID
+	r.Use(logging.RequestLogger())                         // Log estruturado + X-Request-ID
+	r.Use(h.RecordClientIP())                              // IP do cliente na auditoria
+	root := routes{&r.RouterGroup}                         // todo GET também responde HEAD
+
+	// Métricas Prometheus em /metrics (antes das rotas, para medir todas)
+	r.Use(handlers.Metrics())
+	root.GET("/metrics", handlers.MetricsEndpoint())
+
+	// Respostas de erro no formato padrão (depois das métricas e do log,
+	// para os dois enxergarem o status final)
+	r.Use(handlers.ErrorHandler())
+
+	// CORS do painel web (CORS_ALLOWED_ORIGINS); responde o preflight antes
+	// da autenticação e do rate limit
+	r.Use(h.CORS())
+
+	// Prazo de cada requisição e tamanho máximo do corpo (408/413). O
+	// circuit breaker da rota vem antes, para contar os prazos estourados
+	r.Use(h.RouteBreaker(), h.RequestTimeout(), h.LimitBody())
+
+	r.Use(extra...)
+
+	// Identifica a região que atendeu (implantação multi-campus)
+	r.Use(h.RegionHeaders())
+
+	// Modo caos (apenas desenvolvimento, CHAOS_MODE=true)
+	if h.Config.ChaosMode {
+		r.Use(h.Chaos())
+		root.GET("/chaos", handlers.GetChaos)
+		r.PUT("/chaos", handlers.UpdateChaos)
+		r.DELETE("/chaos", handlers.ResetChaos)
+	}
+
+	// Documentação (Swagger UI e especificação OpenAPI)
+	root.GET("/docs", handlers.GetDocs)
+	root.GET("/openapi.json", handlers.GetOpenAPISpec)
+
+	// Avatares gravados no disco (AVATAR_STORAGE=local)
+	root.GET("/avatars/:file", h.GetAvatar)
+
+	// Health checks (liveness e readiness)
+	root.GET("/healthz", h.Healthz)
+	root.GET("/readyz", h.Readyz)
+
+	// Tenant pedido no X-Tenant ou no subdomínio (TENANT_DOMAIN)
+	r.Use(h.ResolveTenant())
+
+	// Limite de requisições por usuário/IP (health checks e docs ficam de fora)
+	r.Use(h.RateLimit())
+
+	// pprof e estatísticas do runtime na porta principal, só para admin
+	if h.Config.DebugEndpoints {
+		debugRoutes(root.Group("/debug", h.AuthRequired(), handlers.AdminOnly()), h)
+	}
+
+	// Rotas da API em /api/v<N>; os caminhos de antes do versionamento
+	// continuam como apelidos (com Deprecation/Sunset) para o firmware já gravado
+	for _, version := range handlers.APIVersions {
+		apiRoutes(root.Group(handlers.APIPrefix(version), h.VersionedRoutes(version)), r, h)
+	}
+	if h.Config.LegacyRoutes {
+		apiRoutes(root.Group("/", h.LegacyRoutes()), r, h)
+	}
+
+	return r
+}
+
+// Rotas de uma versão da API (ou os apelidos sem prefixo)
+func apiRoutes(v routes, r *gin.Engine, h *handlers.Handler) {
+	// Rotas públicas: cadastro e autenticação
+	v.POST("/users", h.Idempotent("POST /users"), h.CreateUser)
+	v.POST("/login", h.Login)
+	v.POST("/refresh", h.Refresh)
+	v.GET("/auth/:provider", h.OAuthStart)
+	v.GET("/auth/:provider/callback", h.OAuthCallback)
+	v.POST("/password/forgot", h.ForgotPassword)
+	v.POST("/password/reset", h.ResetPassword)
+	v.GET("/verify-email", h.VerifyEmail)
+	v.POST("/verify-email/resend", h.ResendVerification)
+
+	// Demais rotas exigem "Authorization: Bearer <access_token>". O
+	// PublicIDs troca o UUID de /users/:id e /devices/:id pelo ID numérico
+	api := v.Group("/", h.AuthRequired(), h.PublicIDs())
+
+	// Gestão de usuários: listagem e remoção só para admin;
+	// as rotas de um usuário específico valem para ele mesmo ou para admin.
+	// As listagens grandes saem comprimidas (CompressResponse) para quem aceita
+	api.GET("/users", handlers.AdminOnly(), h.CompressResponse(), h.GetUsers)
+	api.GET("/users/search", handlers.AdminOnly(), h.CompressResponse(), h.SearchUsers)
+	api.GET("/users/export", handlers.AdminOnly(), h.CompressResponse(), h.ExportUsers)
+	api.POST("/users/batch", handlers.AdminOnly(), h.DecompressBody(), h.CreateUsersBatch)
+	api.POST("/users/import", handlers.AdminOnly(), h.ImportUsers)
+	api.DELETE("/users/:id", handlers.AdminOnly(), h.DeleteUser)
+	api.POST("/users/:id/restore", handlers.AdminOnly(), h.RestoreUser)
+	api.PUT("/users/:id/role", handlers.AdminOnly(), h.UpdateUserRole)
+
+	// Conta de quem está autenticado, sem precisar saber o próprio ID
+	for _, path := range []string{"/me", "/users/me"} {
+		me := api.Group(path, handlers.Me())
+		me.GET("", h.GetUser)
+		me.PUT("", h.UpdateUser)
+		me.DELETE("", h.DeleteMe)
+		me.PUT("/password", h.ChangePassword)
+	}
+
+	// Aparelhos com login aberto (refresh tokens)
+	api.GET("/me/sessions", h.GetSessions)
+	api.DELETE("/me/sessions/:id", h.RevokeSession)
+	// Cópia dos dados pessoais
+	api.GET("/me/export", h.ExportMe)
+
+	self := api.Group("/users/:id", handlers.SelfOrAdmin(), h.UserInTenant())
+	self.GET("", h.GetUser)
+	self.PUT("", h.UpdateUser)
+	self.PATCH("", h.PatchUser)
+	self.PUT("/password", h.ChangePassword)
+	self.POST("/avatar", h.UploadAvatar)
+
+	// Histórico (event sourcing)
+	self.GET("/events", h.GetUserEvents)
+	self.GET("/history", h.GetUserAt)
+	api.GET("/changes", handlers.AdminOnly(), h.CompressResponse(), h.GetChanges)
+	api.GET("/events/poll", handlers.AdminOnly(), h.PollEvents)
+
+	// Auditoria: quem mudou usuários, dispositivos e chaves de API
+	api.GET("/audit", handlers.AdminOnly(), h.GetAudit)
+
+	// Fila dos jobs em segundo plano (e-mails, webhooks, limpezas)
+	api.GET("/admin/jobs", handlers.AdminOnly(), h.GetJobStats)
+	// Números do tenant para o relatório do projeto
+	api.GET("/admin/stats", handlers.AdminOnly(), h.GetAdminStats)
+	// Suporte: token curto agindo como um usuário (registrado na auditoria)
+	api.POST("/admin/impersonate/:id", handlers.AdminOnly(), h.Impersonate)
+
+	// Organizações: só o admin da plataforma (admin do tenant padrão)
+	api.GET("/tenants", handlers.PlatformAdminOnly(), h.GetTenants)
+	api.POST("/tenants", handlers.PlatformAdminOnly(), h.CreateTenant)
+	// Feature flags: valem para a implantação inteira
+	flags := api.Group("/admin/flags", handlers.PlatformAdminOnly())
+	flags.GET("", h.GetFeatureFlags)
+	flags.POST("", h.CreateFeatureFlag)
+	flags.GET("/:key", h.GetFeatureFlag)
+	flags.PUT("/:key", h.UpdateFeatureFlag)
+	flags.DELETE("/:key", h.DeleteFeatureFlag)
+
+	// Webhooks do ciclo de vida do usuário (user.created/updated/deleted)
+	webhooks := api.Group("/webhooks", handlers.AdminOnly())
+	webhooks.GET("", h.GetWebhooks)
+	webhooks.POST("", h.CreateWebhook)
+	webhooks.DELETE("/:id", h.DeleteWebhook)
+	webhooks.GET("/:id/deliveries", h.GetWebhookDeliveries)
+
+	// Notificações push (o admin dispara; o envio é do job "push.deliver")
+	notifications := api.Group("/notifications", handlers.AdminOnly())
+	notifications.POST("", h.CreateNotification)
+	notifications.GET("/:id", h.GetNotification)
+	notifications.GET("/:id/deliveries", h.GetNotificationDeliveries)
+
+	// Sincronização offline-first (clientes móveis)
+	api.GET("/sync", h.CompressResponse(), h.SyncDelta)
+	api.GET("/sync/pull", h.CompressResponse(), h.SyncPull)
+	api.POST("/sync/push", h.DecompressBody(), h.SyncPush)
+	api.GET("/sync/conflicts", h.GetSyncConflicts)
+
+	// Consentimento (LGPD)
+	self.GET("/consents", h.GetConsents)
+	self.POST("/consents", h.GrantConsent)
+	self.DELETE("/consents/:purpose", h.WithdrawConsent)
+
+	// Atividades (contexto do usuário)
+	self.POST("/activities", h.RequireConsent(models.PurposeActivityTracking), h.DecompressBody(), h.CreateActivities)
+	self.GET("/activities", h.CompressResponse(), h.GetActivities)
+	self.GET("/activities/summary", h.RequireConsent(models.PurposeAnalytics), h.GetActivitySummary)
+
+	// Chaves de API (sensores e jobs autenticam com X-API-Key)
+	self.GET("/api-keys", h.GetAPIKeys)
+	self.POST("/api-keys", h.CreateAPIKey)
+	self.DELETE("/api-keys/:key", h.RevokeAPIKey)
+
+	// Dispositivos
+	self.GET("/devices", h.GetUserDevices)
+	self.POST("/devices", h.CreateDevice)
+	api.GET("/devices", h.GetDevices)
+	api.GET("/devices/nearby", h.GetNearbyDevices)
+	device := api.Group("/devices/:id", h.DeviceAccess())
+	device.GET("", h.GetDevice)
+	device.PUT("", h.UpdateDevice)
+	device.DELETE("", h.DeleteDevice)
+
+	// Grupos: membros enxergam os dispositivos uns dos outros
+	api.POST("/groups", h.CreateGroup)
+	self.GET("/groups", h.GetUserGroups)
+	group := api.Group("/groups/:id", h.GroupAccess())
+	group.GET("", h.GetGroup)
+	group.DELETE("", h.DeleteGroup)
+	group.GET("/members", h.GetGroupMembers)
+	group.POST("/members", h.AddGroupMember)
+	group.DELETE("/members/:user_id", h.RemoveGroupMember)
+	group.GET("/devices", h.GetGroupDevices)
+
+	// Telemetria
+	device.POST("/readings", h.DecompressBody(), h.CreateReadings)
+	device.GET("/readings", h.CompressResponse(), h.GetReadings)
+	device.GET("/readings/stats", h.RequireDeviceConsent(models.PurposeAnalytics), h.CompressResponse(), h.GetReadingStats)
+	// Leituras novas em tempo real (SSE). O EventSource do navegador não
+	// manda cabeçalhos: o token pode vir em ?access_token=. Sem HEAD: o
+	// stream não termina
+	v.RouterGroup.GET("/devices/:id/readings/stream", handlers.QueryToken(), h.AuthRequired(), h.PublicIDs(), h.DeviceAccess(), h.StreamReadings)
+
+	// Localização (última posição e busca por raio)
+	device.POST("/locations", h.RequireDeviceConsent(models.PurposeLocationTracking), h.DecompressBody(), h.CreateLocations)
+	device.GET("/location", h.GetDeviceLocation)
+
+	// Token push do dispositivo (FCM ou APNs)
+	device.PUT("/push-token", h.PutPushToken)
+	device.DELETE("/push-token", h.DeletePushToken)
+
+	// Presença em tempo real (WebSocket); o token pode vir em ?access_token=
+	api.POST("/devices/:id/heartbeat", h.DeviceHeartbeat)
+	v.RouterGroup.GET("/ws", handlers.QueryToken(), h.AuthRequired(), h.PresenceSocket)
+
+	// GraphQL: usuário, dispositivos e leituras numa requisição só. O GET
+	// só aceita consultas; o schema (SDL) é público, como a documentação
+	api.POST("/graphql", h.GraphQL)
+	api.GET("/graphql", h.GraphQL)
+	v.GET("/graphql/schema", h.GetGraphQLSchema)
+
+	// Hora do servidor (ressincronização de relógio dos dispositivos)
+	v.GET("/time", handlers.GetServerTime)
+
+	// Várias requisições em uma só ida ao servidor
+	v.POST("/batch", handlers.Batch(r))
+}
+
+// Router da porta de diagnóstico (DEBUG_ADDR): só o pprof e o /debug/vars,
+// sem autenticação
+func NewDebug(h *handlers.Handler) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.CustomRecovery(handlers.RecoveryHandler))
+	debugRoutes(routes{&r.RouterGroup}.Group("/debug"), h)
+	return r
+}
+
+func debugRoutes(debug routes, h *handlers.Handler) {
+	debug.GET("/vars", h.DebugVars)
+	debug.GET("/pprof/", handlers.DebugPprof)
+	debug.GET("/pprof/:profile", handlers.DebugPprof)
+}
+
+// Grupo de rotas em que o GET também registra o HEAD, com os mesmos
+// handlers: o net/http descarta o corpo e ficam os cabeçalhos (ETag,
+// Content-Length, X-Total-Count), que é o que o cliente pediu
+type routes struct {
+	*gin.RouterGroup
+}
+
+var getAndHead = []string{http.MethodGet, http.MethodHead}
+
+func (g routes) GET(path string, chain ...gin.HandlerFunc) {
+	g.Match(getAndHead, path, chain...)
+}
+
+func (g routes) Group(path string, chain ...gin.HandlerFunc) routes {
+	return routes{g.RouterGroup.Group(path, chain...)}
+}
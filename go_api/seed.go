@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"go_api/config"
+	"go_api/logging"
+	"go_api/migrations"
+	"go_api/repository"
+	"go_api/service"
+)
+
+// --- Dados de Demonstração ---
+// Uso: ./server seed -demo -users 200 -readings 1000
+// Popula o banco da configuração (migrando antes, se preciso) com usuários,
+// dispositivos e leituras falsos, para os testes de carga e a apresentação
+// não dependerem de laços de curl. -demo cria também as contas fixas
+// "admin" e "demo": só em banco de teste.
+func runSeed(args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	demo := fs.Bool("demo", false, `Cria também as contas "admin" (admin) e "demo"`)
+	users := fs.Int("users", 50, "Usuários gerados")
+	devices := fs.Int("devices", 2, "Dispositivos por usuário")
+	readings := fs.Int("readings", 288, "Leituras por dispositivo")
+	span := fs.Duration("span", 24*time.Hour, "Período das leituras, até agora")
+	batch := fs.Int("batch", 1000, "Linhas por INSERT")
+	seed := fs.Int64("seed", 0, "Semente dos dados (0 = aleatória)")
+	fs.Parse(args)
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Erro fatal: configuração inválida:\n%v", err)
+	}
+	logging.Setup(cfg.LogLevel, cfg.Secrets()...)
+	db, err := repository.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Erro fatal: %v", err)
+	}
+	if err := migrations.Run(db, cfg.DBDriver); err != nil {
+		log.Fatalf("Erro fatal: migrações: %v", err)
+	}
+
+	opts := service.SeedOptions{
+		Users: *users, DevicesPerUser: *devices, ReadingsPerDevice: *readings,
+		Span: *span, BatchSize: *batch, Demo: *demo,
+	}
+	if *seed != 0 {
+		opts.Rand = rand.New(rand.NewSource(*seed))
+	}
+	start := time.Now()
+	result, err := service.NewSeeder(db).Run(context.Background(), opts)
+	if err != nil {
+		log.Fatalf("Erro fatal: seed: %v", err)
+	}
+
+	fmt.Printf("%d usuários, %d dispositivos e %d leituras em %v (senha de todos: %s)\n",
+		result.Users, result.Devices, result.Readings, time.Since(start).Round(time.Millisecond), service.SeedPassword)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go_api/handlers"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+// --- Ciclo de Vida do Servidor ---
+// Ao receber SIGTERM (docker stop / rolling deploy) ou SIGINT (Ctrl+C), o
+// servidor para de aceitar conexões novas, espera as requisições em andamento
+// terminarem (até SHUTDOWN_TIMEOUT) e só então fecha o pool do banco.
+// closers rodam antes do banco fechar (ex: a ponte MQTT, que também grava).
+// tlsSrv (TLS_ADDR) é opcional e encerra junto com o HTTP.
+func runServer(handler http.Handler, addr string, tlsSrv *http.Server, h3 *http3.Server, h *handlers.Handler, timeout time.Duration, closers ...func()) {
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: handler,
+		// Cliente que nunca termina de mandar os cabeçalhos não segura a conexão
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		log.Printf("Servidor escutando em %s", addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Erro fatal no servidor HTTP: %v", err)
+		}
+	}()
+	if tlsSrv != nil {
+		go func() {
+			log.Printf("HTTPS escutando em %s", tlsSrv.Addr)
+			// O certificado vem do TLSConfig (arquivo recarregável ou autocert)
+			if err := tlsSrv.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("Erro fatal no servidor HTTPS: %v", err)
+			}
+		}()
+	}
+
+	// Bloqueia até chegar o sinal de parada
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	h.BeginShutdown() // /readyz passa a responder 503
+	log.Printf("Sinal recebido, encerrando (aguardando até %s)...", timeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Requisições interrompidas no encerramento: %v", err)
+	}
+	if tlsSrv != nil {
+		if err := tlsSrv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Requisições HTTPS interrompidas no encerramento: %v", err)
+		}
+	}
+	if h3 != nil {
+		if err := h3.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Erro ao encerrar HTTP/3: %v", err)
+		}
+	}
+
+	for _, fn := range closers {
+		fn()
+	}
+
+	// Só fecha o pool depois que nenhum handler está mais usando o banco
+	if sqlDB, err := h.DB.DB(); err == nil {
+		sqlDB.Close()
+	}
+	log.Printf("Servidor encerrado")
+}
+
+// Porta de diagnóstico (DEBUG_ADDR). Sem prazo de escrita: o perfil de CPU
+// e o trace demoram o ?seconds= pedido. Devolve o closer.
+func startDebugServer(addr string, handler http.Handler) func() {
+	srv := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		log.Printf("Diagnóstico (pprof) escutando em %s", addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Erro fatal no servidor de diagnóstico: %v", err)
+		}
+	}()
+	return func() { srv.Close() }
+}
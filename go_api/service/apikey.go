@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"go_api/models"
+	"go_api/repository"
+
+	"gorm.io/gorm"
+)
+
+// --- Chaves de API ---
+// Regras das chaves usadas por sensores e jobs (X-API-Key). A chave é
+// gerada aqui, devolvida uma única vez e guardada só como hash SHA-256:
+// com 32 bytes aleatórios, não precisa de um hash lento como o bcrypt.
+
+// Prefixo fixo: deixa a chave fácil de reconhecer (e de achar em vazamentos)
+const apiKeyPrefix = "gak_"
+
+// Quanto do começo da chave aparece na listagem
+const apiKeyShownChars = len(apiKeyPrefix) + 8
+
+// LastUsedAt é atualizado no máximo uma vez por intervalo, para um sensor
+// que chama a API a cada segundo não gerar uma escrita por requisição
+const apiKeyTouchInterval = time.Minute
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidAPIKey  = errors.New("invalid, expired or revoked api key")
+	ErrAPIKeyExpiry   = errors.New("expires_at must be in the future")
+)
+
+type APIKeyService struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyService(db *gorm.DB) *APIKeyService {
+	return &APIKeyService{db: db}
+}
+
+func hashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// Devolve a chave e o valor em texto puro (a única vez em que aparece)
+func (s *APIKeyService) Create(ctx context.Context, userID uint, input models.APIKeyInput) (models.APIKey, string, error) {
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return models.APIKey{}, "", ErrAPIKeyExpiry
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return models.APIKey{}, "", err
+	}
+	plain := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+	key := models.APIKey{
+		UserID:    userID,
+		Name:      input.Name,
+		Prefix:    plain[:apiKeyShownChars],
+		KeyHash:   hashAPIKey(plain),
+		ExpiresAt: input.ExpiresAt,
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&key).Error; err != nil {
+			return err
+		}
+		return repository.RecordAudit(tx, models.AuditCreate, "api_key", key.ID, nil, key)
+	})
+	return key, plain, err
+}
+
+// Todas as chaves do usuário, inclusive as revogadas e vencidas
+func (s *APIKeyService) ListByUser(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&keys).Error
+	return keys, err
+}
+
+// Revogar de novo uma chave já revogada não muda a data original
+func (s *APIKeyService) Revoke(ctx context.Context, userID, id uint) (models.APIKey, error) {
+	var key models.APIKey
+	err := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return key, ErrAPIKeyNotFound
+	}
+	if err != nil || key.RevokedAt != nil {
+		return key, err
+	}
+	before := key
+	now := time.Now()
+	key.RevokedAt = &now
+	return key, s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&key).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return repository.RecordAudit(tx, models.AuditUpdate, "api_key", key.ID, before, key, "revoked_at")
+	})
+}
+
+// Confere a chave e devolve ela e o dono. Dono removido (soft delete) ou
+// chave vencida/revogada: ErrInvalidAPIKey.
+func (s *APIKeyService) Authenticate(ctx context.Context, plain string) (models.APIKey, models.User, error) {
+	var key models.APIKey
+	var user models.User
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return key, user, ErrInvalidAPIKey
+	}
+	db := s.db.WithContext(ctx)
+	now := time.Now()
+	err := db.Where("key_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", hashAPIKey(plain), now).
+		First(&key).Error
+	if err == nil {
+		err = db.First(&user, key.UserID).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return key, user, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return key, user, err
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		key.LastUsedAt = &now
+		db.Model(&key).UpdateColumn("last_used_at", now)
+	}
+	return key, user, nil
+}
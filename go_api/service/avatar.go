@@ -0,0 +1,155 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // registra o formato no image.Decode
+	"image/jpeg"
+	_ "image/png"
+	"log/slog"
+	"strings"
+
+	"go_api/models"
+	"go_api/storage"
+)
+
+// --- Avatares ---
+// A foto enviada vira um JPEG quadrado de no máximo size x size (recorte
+// central e redução pela média dos pixels), gravado com uma chave nova a
+// cada envio: o avatar_url muda junto, então caches e CDNs nunca servem a
+// foto antiga. O arquivo anterior é apagado depois que o usuário aponta
+// para o novo.
+
+var (
+	ErrInvalidImage  = errors.New("file is not a JPEG, PNG or GIF image")
+	ErrImageTooLarge = errors.New("image dimensions are too large")
+)
+
+// Largura x altura máxima da imagem enviada: decodificar aloca 4 bytes por
+// pixel, e um PNG pequeno pode declarar dimensões enormes
+const maxAvatarPixels = 25_000_000
+
+type AvatarService struct {
+	users   *UserService
+	storage storage.Storage
+	size    int
+}
+
+func NewAvatarService(users *UserService, store storage.Storage, size int) *AvatarService {
+	return &AvatarService{users: users, storage: store, size: size}
+}
+
+func (s *AvatarService) Upload(ctx context.Context, userID uint, data []byte) (models.User, error) {
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
+		return user, err
+	}
+	img, err := decodeImage(data)
+	if err != nil {
+		return user, err
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, squareThumbnail(img, s.size), &jpeg.Options{Quality: 85}); err != nil {
+		return user, err
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return user, err
+	}
+	key := fmt.Sprintf("%d-%s.jpg", user.ID, hex.EncodeToString(suffix))
+	if err := s.storage.Put(ctx, key, "image/jpeg", buf.Bytes()); err != nil {
+		return user, err
+	}
+	previous := user.AvatarURL
+	user, err = s.users.SetAvatar(ctx, user.ID, s.storage.URL(key))
+	if err != nil {
+		// Ninguém aponta para o arquivo novo
+		s.Remove(context.WithoutCancel(ctx), s.storage.URL(key))
+		return user, err
+	}
+	s.Remove(ctx, previous)
+	return user, nil
+}
+
+// Caminho do arquivo no disco, quando o armazenamento é local (GET /avatars/:file)
+func (s *AvatarService) File(name string) (string, bool) {
+	local, ok := s.storage.(*storage.Local)
+	if !ok {
+		return "", false
+	}
+	path, err := local.Path(name)
+	return path, err == nil
+}
+
+// Apaga o arquivo do avatar_url (foto trocada ou conta excluída). Só apaga
+// o que é deste armazenamento: um avatar_url antigo pode apontar para
+// outro lugar, se AVATAR_STORAGE mudou.
+func (s *AvatarService) Remove(ctx context.Context, url string) {
+	key, ok := strings.CutPrefix(url, s.storage.URL(""))
+	if !ok || key == "" {
+		return
+	}
+	if err := s.storage.Delete(ctx, key); err != nil {
+		slog.WarnContext(ctx, "avatar antigo não foi apagado", "key", key, "error", err)
+	}
+}
+
+func decodeImage(data []byte) (image.Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxAvatarPixels {
+		return nil, ErrImageTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil || (format != "jpeg" && format != "png" && format != "gif") {
+		return nil, ErrInvalidImage
+	}
+	return img, nil
+}
+
+// Recorte quadrado do centro, reduzido para no máximo size x size (imagem
+// menor não é ampliada). Cada pixel de saída é a média do bloco de origem;
+// a transparência vira fundo branco, que o JPEG não tem alfa.
+func squareThumbnail(img image.Image, size int) *image.RGBA {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	crop := image.Rect(0, 0, side, side)
+	src := image.NewRGBA(crop)
+	draw.Draw(src, crop, image.NewUniform(color.White), image.Point{}, draw.Src)
+	offset := image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2)
+	draw.Draw(src, crop, img, offset, draw.Over)
+
+	n := min(size, side)
+	if n == side {
+		return src
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, n, n))
+	for y := 0; y < n; y++ {
+		y0, y1 := y*side/n, (y+1)*side/n
+		for x := 0; x < n; x++ {
+			x0, x1 := x*side/n, (x+1)*side/n
+			var r, g, bl, count int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+3]
+					r, g, bl = r+int(p[0]), g+int(p[1]), bl+int(p[2])
+					count++
+				}
+			}
+			i := y*dst.Stride + x*4
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/count), uint8(g/count), uint8(bl/count), 0xff
+		}
+	}
+	return dst
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Apaga os tokens de e-mail e as sessões vencidas ou revogadas (job
+// periódico "tokens.purge"). O DELETE é idempotente, então não importa qual
+// réplica rode.
+func (s *UserService) CleanupTokens(ctx context.Context) error {
+	var errs []error
+	now := time.Now()
+	for name, store := range map[string]interface {
+		DeleteExpired(context.Context, time.Time) (int64, error)
+	}{"password_reset": s.resetTokens, "email_verification": s.verifyTokens, "sessions": s.sessions} {
+		if store == nil {
+			continue
+		}
+		deleted, err := store.DeleteExpired(ctx, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		if deleted > 0 {
+			slog.InfoContext(ctx, "tokens vencidos removidos", "kind", name, "count", deleted)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"go_api/models"
+	"go_api/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- Dispositivos ---
+// Regras compartilhadas pelas rotas REST e pelo gRPC. O token do
+// dispositivo é gerado aqui, devolvido uma única vez na criação e guardado
+// apenas como hash SHA-256.
+
+var (
+	ErrDeviceNotFound = errors.New("device not found")
+	ErrDeviceNotOwned = errors.New("device belongs to another user")
+	// Dispositivo inexistente ou token errado: não diz qual dos dois
+	ErrInvalidDeviceToken = errors.New("invalid device or token")
+)
+
+// Filtros do GET /devices
+const (
+	DeviceOnline  = "online"
+	DeviceOffline = "offline"
+)
+
+type DeviceQuery struct {
+	UserID  uint   // 0 = todos os dispositivos do tenant
+	GroupID uint   // != 0: só os dos membros do grupo
+	Status  string // "", DeviceOnline ou DeviceOffline
+	Offset  int
+	Limit   int
+	Select  []string // colunas do SELECT (?fields=); vazio = todas
+	Times   repository.TimeFilter
+}
+
+type DeviceService struct {
+	db       *gorm.DB
+	presence *PresenceHub
+}
+
+func NewDeviceService(db *gorm.DB, presence *PresenceHub) *DeviceService {
+	return &DeviceService{db: db, presence: presence}
+}
+
+func generateDeviceToken() (plain string, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	plain = hex.EncodeToString(buf)
+	return plain, hashDeviceToken(plain), nil
+}
+
+func hashDeviceToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// Só os dispositivos cujo dono não foi removido. Os de um usuário removido
+// (soft delete) ficam no banco para o restore, mas não se autenticam, não
+// gravam pelo MQTT e não recebem push.
+func ActiveOwner(db *gorm.DB) *gorm.DB {
+	owners := db.Session(&gorm.Session{NewDB: true}).Table("users").Select("id").Where("deleted_at IS NULL")
+	return db.Where("devices.user_id IN (?)", owners)
+}
+
+// O próprio sensor se identificando com o token da criação (CoAP), sem
+// usuário nem chave de API
+func (s *DeviceService) Authenticate(ctx context.Context, id uint, plain string) (models.Device, error) {
+	var device models.Device
+	err := ActiveOwner(s.db.WithContext(ctx)).Where("id = ? AND token = ?", id, hashDeviceToken(plain)).First(&device).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return device, ErrInvalidDeviceToken
+	}
+	return device, err
+}
+
+func (s *DeviceService) Get(ctx context.Context, id uint) (models.Device, error) {
+	var device models.Device
+	err := s.db.WithContext(ctx).First(&device, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return device, ErrDeviceNotFound
+	}
+	device.Online = s.presence.isOnline(device.LastSeen, time.Now())
+	return device, err
+}
+
+// columns: as do SELECT (?fields=); nenhuma = todas
+func (s *DeviceService) ListByUser(ctx context.Context, userID uint, columns ...string) ([]models.Device, error) {
+	query := s.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(columns) > 0 {
+		query = query.Select(columns)
+	}
+	var devices []models.Device
+	err := query.Order("id").Find(&devices).Error
+	s.presence.markOnline(devices, time.Now())
+	return devices, err
+}
+
+// Página de dispositivos e o total antes da paginação
+func (s *DeviceService) List(ctx context.Context, q DeviceQuery) ([]models.Device, int64, error) {
+	now := time.Now()
+	query := s.db.WithContext(ctx).Model(&models.Device{})
+	if q.UserID != 0 {
+		query = query.Where("user_id = ?", q.UserID)
+	}
+	if q.GroupID != 0 {
+		query = query.Where("user_id IN (?)", s.db.Model(&models.Membership{}).Select("user_id").Where("group_id = ?", q.GroupID))
+	}
+	query = q.Times.Apply(query)
+	cutoff := now.Add(-s.presence.timeout)
+	switch q.Status {
+	case DeviceOnline:
+		query = query.Where("last_seen >= ?", cutoff)
+	case DeviceOffline:
+		query = query.Where("last_seen IS NULL OR last_seen < ?", cutoff)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(q.Select) > 0 {
+		query = query.Select(q.Select)
+	}
+	var devices []models.Device
+	err := query.Order("id").Offset(q.Offset).Limit(q.Limit).Find(&devices).Error
+	s.presence.markOnline(devices, now)
+	return devices, total, err
+}
+
+// Dispositivos alterados depois de (after, afterID) na ordem (updated_at,
+// id), inclusive os removidos: uma página do GET /sync. owner != 0 limita
+// aos dispositivos do usuário.
+func (s *DeviceService) Changed(ctx context.Context, owner uint, after time.Time, afterID uint, limit int) ([]models.Device, error) {
+	query := s.db.WithContext(ctx).Unscoped().
+		Where("updated_at > ? OR (updated_at = ? AND id > ?)", after, after, afterID)
+	if owner != 0 {
+		query = query.Where("user_id = ?", owner)
+	}
+	var devices []models.Device
+	err := query.Order("updated_at, id").Limit(limit).Find(&devices).Error
+	s.presence.markOnline(devices, time.Now())
+	return devices, err
+}
+
+// Sinal de vida sem carregar o dispositivo antes: um UPDATE ... RETURNING
+// só (mais o device.online do outbox na volta de offline). owner != 0
+// limita aos dispositivos do usuário.
+func (s *DeviceService) Heartbeat(ctx context.Context, id, owner uint) (models.Device, error) {
+	now := time.Now().UTC()
+	db := s.db.WithContext(ctx)
+	var device models.Device
+	affected, err := s.presence.touch(db, &device, now, func(q *gorm.DB) *gorm.DB {
+		q = q.Clauses(clause.Returning{}).Where("id = ?", id)
+		if owner != 0 {
+			q = q.Where("user_id = ?", owner)
+		}
+		return q
+	})
+	if err != nil {
+		return device, err
+	}
+	if affected == 0 {
+		// Caminho raro: só aqui vale descobrir se existe
+		var n int64
+		if err := db.Model(&models.Device{}).Where("id = ?", id).Count(&n).Error; err != nil {
+			return device, err
+		}
+		if n > 0 {
+			return device, ErrDeviceNotOwned
+		}
+		return device, ErrDeviceNotFound
+	}
+	device.Online = true
+	s.presence.Seen(device, now)
+	return device, nil
+}
+
+// Devolve o dispositivo e o token em texto puro (a única vez em que aparece)
+func (s *DeviceService) Create(ctx context.Context, userID uint, input models.DeviceInput) (models.Device, string, error) {
+	plain, hash, err := generateDeviceToken()
+	if err != nil {
+		return models.Device{}, "", err
+	}
+	device := models.Device{UserID: userID, Name: input.Name, Type: input.Type, Token: hash}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&device).Error; err != nil {
+			return err
+		}
+		return repository.RecordAudit(tx, models.AuditCreate, "device", device.ID, nil, device)
+	})
+	return device, plain, err
+}
+
+func (s *DeviceService) Update(ctx context.Context, device *models.Device, input models.DeviceInput) error {
+	before := *device
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(device).Updates(models.Device{Name: input.Name, Type: input.Type}).Error; err != nil {
+			return err
+		}
+		return repository.RecordAudit(tx, models.AuditUpdate, "device", device.ID, before, device, "name", "type")
+	})
+}
+
+// Tabelas com os dados de um dispositivo
+var deviceData = []interface{}{
+	&models.Reading{}, &models.ReadingRollup{}, &models.Location{}, &models.PushToken{}, &models.NotificationDelivery{},
+}
+
+// Apaga os dados dos dispositivos (ids: lista ou subconsulta)
+func deleteDeviceData(tx *gorm.DB, ids interface{}) error {
+	for _, model := range deviceData {
+		if err := tx.Where("device_id IN (?)", ids).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Os dados vão embora e o registro vira um tombstone (deleted_at, com o
+// updated_at da remoção). O dispositivo removido sai da presença na hora.
+func (s *DeviceService) Delete(ctx context.Context, device models.Device) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteDeviceData(tx, []uint{device.ID}); err != nil {
+			return err
+		}
+		if err := tx.Model(&device).UpdateColumn("updated_at", time.Now()).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&device).Error; err != nil {
+			return err
+		}
+		return repository.RecordAudit(tx, models.AuditDelete, "device", device.ID, device, nil)
+	})
+	if err != nil {
+		return err
+	}
+	s.presence.Forget(device)
+	return nil
+}
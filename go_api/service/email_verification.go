@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go_api/mailer"
+	"go_api/models"
+	"go_api/repository"
+)
+
+// --- Verificação de E-mail ---
+// O cadastro manda um link com um token para o e-mail informado; GET
+// /verify-email?token=... marca a conta como verificada. Trocar o e-mail
+// volta o flag para false e manda um link novo. Com Required, o login de
+// uma conta não verificada é recusado (ErrEmailNotVerified).
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrEmailNotVerified         = errors.New("email not verified")
+)
+
+type EmailVerificationPolicy struct {
+	TTL      time.Duration
+	URL      string // opcional: o e-mail leva URL + token (ex: "https://api/verify-email?token=")
+	Required bool   // recusa o login enquanto o e-mail não for verificado
+}
+
+// Mesmo service, com a verificação de e-mail ligada (exige WithMailer)
+func (s *UserService) WithEmailVerification(tokens repository.EmailVerificationStore, policy EmailVerificationPolicy) *UserService {
+	copied := *s
+	copied.verifyTokens = tokens
+	copied.verify = policy
+	return &copied
+}
+
+// Mesmo service, enviando e-mails por mail
+func (s *UserService) WithMailer(mail mailer.Mailer) *UserService {
+	copied := *s
+	copied.mailer = mail
+	return &copied
+}
+
+func (s *UserService) sendVerification(ctx context.Context, user models.User) error {
+	if s.verifyTokens == nil {
+		return nil
+	}
+	token, hash, err := newEmailToken()
+	if err != nil {
+		return err
+	}
+	err = s.verifyTokens.Create(ctx, &models.EmailVerificationToken{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.verify.TTL),
+	})
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address: %s%s\n\nThe link expires in %s.\n",
+			user.Name, s.verify.URL, token, s.verify.TTL),
+	})
+}
+
+// Chamado depois do cadastro e da troca de e-mail. A conta já foi gravada,
+// então uma falha no envio só é registrada: o usuário pede outro link em
+// POST /verify-email/resend.
+func (s *UserService) notifyVerification(ctx context.Context, user models.User) {
+	if err := s.sendVerification(ctx, user); err != nil {
+		slog.WarnContext(ctx, "envio do link de verificação falhou", "user_id", user.ID, "error", err)
+	}
+}
+
+// Manda um link novo. Como no /password/forgot, e-mail desconhecido (ou já
+// verificado) não é erro, para não revelar quem tem conta.
+func (s *UserService) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.store.FindByLogin(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && (user.Email != email || user.EmailVerified)) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.sendVerification(ctx, user)
+}
+
+// O token só é gasto se a conta for marcada como verificada
+func (s *UserService) VerifyEmail(ctx context.Context, token string) (models.User, error) {
+	var user models.User
+	err := s.InTx(ctx, func(users *UserService) error {
+		stored, err := users.verifyTokens.Consume(ctx, hashEmailToken(token), time.Now())
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidVerificationToken
+		}
+		if err != nil {
+			return err
+		}
+
+		user, err = users.Get(ctx, stored.UserID)
+		if errors.Is(err, ErrUserNotFound) || (err == nil && user.Email != stored.Email) {
+			return ErrInvalidVerificationToken
+		}
+		if err != nil || user.EmailVerified {
+			return err
+		}
+		event := models.NewUserEvent(user.ID, models.EventEmailVerified, models.UserEventData{Email: user.Email})
+		return users.store.Update(ctx, &user, map[string]interface{}{"email_verified": true}, event)
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Feature Flags ---
+// Cadastro (admin da plataforma) e avaliação. Os handlers perguntam
+// Enabled a cada requisição, então a avaliação lê as regras de um cache em
+// memória, relido do banco a cada refresh: uma alteração vale na hora na
+// réplica que a recebeu e, nas outras, em até FEATURE_FLAGS_REFRESH. Flag
+// desconhecida vale false.
+
+var (
+	ErrFlagNotFound = errors.New("feature flag not found")
+	ErrFlagKeyTaken = errors.New("feature flag key already in use")
+)
+
+// Para quem a flag é avaliada: tenant e usuário da requisição (0 = anônimo)
+type FlagSubject struct {
+	TenantID uint
+	UserID   uint
+}
+
+// Regras de uma flag já decodificadas
+type flagRules struct {
+	enabled    bool
+	percentage int
+	tenants    []uint
+	groups     []string
+}
+
+type FeatureFlags struct {
+	db      *gorm.DB
+	group   string // REPLICA_GROUP desta réplica
+	refresh time.Duration
+
+	mu       sync.Mutex
+	rules    map[string]flagRules
+	loadedAt time.Time
+}
+
+func NewFeatureFlags(db *gorm.DB, group string, refresh time.Duration) *FeatureFlags {
+	return &FeatureFlags{db: db, group: group, refresh: refresh}
+}
+
+// --- Avaliação ---
+
+func (f *FeatureFlags) Enabled(ctx context.Context, key string, subject FlagSubject) bool {
+	rules, ok := f.snapshot(ctx)[key]
+	if !ok || !rules.enabled {
+		return false
+	}
+	if len(rules.groups) > 0 && !slices.Contains(rules.groups, f.group) {
+		return false
+	}
+	if slices.Contains(rules.tenants, subject.TenantID) {
+		return true
+	}
+	if rules.percentage >= 100 {
+		return true
+	}
+	if subject.UserID == 0 || rules.percentage <= 0 {
+		return false
+	}
+	// O mesmo usuário cai sempre no mesmo lado; a flag entra no hash para
+	// os 10% de uma não serem os mesmos 10% de todas
+	h := fnv.New32a()
+	h.Write([]byte(key + ":" + strconv.FormatUint(uint64(subject.UserID), 10)))
+	return int(h.Sum32()%100) < rules.percentage
+}
+
+// Regras do cache, relidas quando passam do refresh. Se o banco falhar,
+// continua com as anteriores até a próxima tentativa.
+func (f *FeatureFlags) snapshot(ctx context.Context) map[string]flagRules {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.rules != nil && time.Since(f.loadedAt) < f.refresh {
+		return f.rules
+	}
+	rules, err := f.load(ctx)
+	f.loadedAt = time.Now()
+	if err != nil {
+		slog.WarnContext(ctx, "não deu para reler as feature flags", "error", err)
+		return f.rules
+	}
+	f.rules = rules
+	return rules
+}
+
+func (f *FeatureFlags) load(ctx context.Context) (map[string]flagRules, error) {
+	var flags []models.FeatureFlag
+	if err := f.db.WithContext(ctx).Find(&flags).Error; err != nil {
+		return nil, err
+	}
+	rules := make(map[string]flagRules, len(flags))
+	for _, flag := range flags {
+		r := flagRules{enabled: flag.Enabled, percentage: flag.Percentage}
+		if err := json.Unmarshal(flag.TenantIDs, &r.tenants); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(flag.Groups, &r.groups); err != nil {
+			return nil, err
+		}
+		rules[flag.Key] = r
+	}
+	return rules, nil
+}
+
+// A próxima avaliação relê do banco
+func (f *FeatureFlags) invalidate() {
+	f.mu.Lock()
+	f.rules = nil
+	f.mu.Unlock()
+}
+
+// --- Cadastro ---
+
+func (f *FeatureFlags) List(ctx context.Context) ([]models.FeatureFlag, error) {
+	var flags []models.FeatureFlag
+	err := f.db.WithContext(ctx).Order(`"key"`).Find(&flags).Error
+	return flags, err
+}
+
+func (f *FeatureFlags) Get(ctx context.Context, key string) (models.FeatureFlag, error) {
+	var flag models.FeatureFlag
+	err := f.db.WithContext(ctx).Where(`"key" = ?`, key).First(&flag).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return flag, ErrFlagNotFound
+	}
+	return flag, err
+}
+
+func (f *FeatureFlags) Create(ctx context.Context, actorID uint, input models.CreateFeatureFlagInput) (models.FeatureFlag, error) {
+	flag := models.FeatureFlag{Key: input.Key}
+	applyFlagInput(&flag, actorID, input.FeatureFlagInput)
+	err := f.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.FeatureFlag{}).Where(`"key" = ?`, input.Key).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrFlagKeyTaken
+		}
+		return tx.Create(&flag).Error
+	})
+	if err == nil {
+		f.invalidate()
+	}
+	return flag, err
+}
+
+// Substitui todas as regras da flag
+func (f *FeatureFlags) Update(ctx context.Context, actorID uint, key string, input models.FeatureFlagInput) (models.FeatureFlag, error) {
+	flag, err := f.Get(ctx, key)
+	if err != nil {
+		return flag, err
+	}
+	applyFlagInput(&flag, actorID, input)
+	if err := f.db.WithContext(ctx).Save(&flag).Error; err != nil {
+		return flag, err
+	}
+	f.invalidate()
+	return flag, nil
+}
+
+func (f *FeatureFlags) Delete(ctx context.Context, key string) error {
+	res := f.db.WithContext(ctx).Where(`"key" = ?`, key).Delete(&models.FeatureFlag{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrFlagNotFound
+	}
+	f.invalidate()
+	return nil
+}
+
+// Listas vazias gravadas como [] (nunca null)
+func applyFlagInput(flag *models.FeatureFlag, actorID uint, input models.FeatureFlagInput) {
+	if input.TenantIDs == nil {
+		input.TenantIDs = []uint{}
+	}
+	if input.Groups == nil {
+		input.Groups = []string{}
+	}
+	flag.Description = input.Description
+	flag.Enabled = input.Enabled
+	flag.Percentage = input.Percentage
+	flag.TenantIDs, _ = json.Marshal(input.TenantIDs)
+	flag.Groups, _ = json.Marshal(input.Groups)
+	flag.UpdatedBy = actorID
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Grupos ---
+// Os grupos ficam no tenant de quem os cria (ScopeTenants); as
+// participações herdam o tenant do grupo, e só entra quem é do mesmo
+// tenant. As listas carregam os usuários (ou os grupos) num JOIN só, sem
+// uma consulta por membro.
+
+var (
+	ErrGroupNotFound      = errors.New("group not found")
+	ErrMembershipNotFound = errors.New("user is not a member of the group")
+	ErrAlreadyMember      = errors.New("user is already a member of the group")
+	ErrLastGroupOwner     = errors.New("group must keep at least one owner")
+)
+
+type GroupService struct {
+	db *gorm.DB
+}
+
+func NewGroupService(db *gorm.DB) *GroupService {
+	return &GroupService{db: db}
+}
+
+// Cria o grupo com quem o criou como owner
+func (s *GroupService) Create(ctx context.Context, createdBy uint, input models.GroupInput) (models.Group, error) {
+	group := models.Group{Name: input.Name, Description: input.Description, CreatedBy: createdBy}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&group).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.Membership{GroupID: group.ID, UserID: createdBy, Role: models.GroupOwner}).Error
+	})
+	return group, err
+}
+
+func (s *GroupService) Get(ctx context.Context, id uint) (models.Group, error) {
+	var group models.Group
+	err := s.db.WithContext(ctx).First(&group, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return group, ErrGroupNotFound
+	}
+	return group, err
+}
+
+// Remove o grupo e, pela FK, as participações
+func (s *GroupService) Delete(ctx context.Context, id uint) error {
+	res := s.db.WithContext(ctx).Delete(&models.Group{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrGroupNotFound
+	}
+	return nil
+}
+
+// Papel de userID no grupo; ErrMembershipNotFound se não for membro
+func (s *GroupService) Role(ctx context.Context, groupID, userID uint) (string, error) {
+	var m models.Membership
+	err := s.db.WithContext(ctx).Where("group_id = ? AND user_id = ?", groupID, userID).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrMembershipNotFound
+	}
+	return m.Role, err
+}
+
+// Membros com os dados do usuário, na ordem em que entraram. O INNER JOIN
+// deixa de fora os usuários removidos (soft delete).
+func (s *GroupService) Members(ctx context.Context, groupID uint) ([]models.Membership, error) {
+	var members []models.Membership
+	err := s.db.WithContext(ctx).InnerJoins("User").
+		Where("memberships.group_id = ?", groupID).Order("memberships.id").Find(&members).Error
+	return members, err
+}
+
+// Grupos de que o usuário participa, com o papel dele em cada um
+func (s *GroupService) ForUser(ctx context.Context, userID uint) ([]models.Membership, error) {
+	var groups []models.Membership
+	err := s.db.WithContext(ctx).InnerJoins("Group").
+		Where("memberships.user_id = ?", userID).Order("memberships.group_id").Find(&groups).Error
+	return groups, err
+}
+
+// Adiciona o usuário (que precisa existir no tenant do grupo)
+func (s *GroupService) AddMember(ctx context.Context, groupID uint, input models.MembershipInput) (models.Membership, error) {
+	role := input.Role
+	if role == "" {
+		role = models.GroupMember
+	}
+	member := models.Membership{GroupID: groupID, Role: role}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		query := tx.Where("id = ?", input.UserID.ID)
+		if input.UserID.UUID != "" {
+			query = tx.Where("uuid = ?", input.UserID.UUID)
+		}
+		if err := query.First(&user).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		} else if err != nil {
+			return err
+		}
+		member.UserID = user.ID
+		var count int64
+		if err := tx.Model(&models.Membership{}).Where("group_id = ? AND user_id = ?", groupID, user.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrAlreadyMember
+		}
+		if err := tx.Create(&member).Error; err != nil {
+			return err
+		}
+		member.User = &user
+		return nil
+	})
+	return member, err
+}
+
+// Remove a participação; o último owner não sai (o grupo ficaria sem
+// ninguém para administrá-lo, a não ser um admin)
+func (s *GroupService) RemoveMember(ctx context.Context, groupID, userID uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var m models.Membership
+		err := tx.Where("group_id = ? AND user_id = ?", groupID, userID).First(&m).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMembershipNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if m.Role == models.GroupOwner {
+			var owners int64
+			if err := tx.Model(&models.Membership{}).Where("group_id = ? AND role = ?", groupID, models.GroupOwner).Count(&owners).Error; err != nil {
+				return err
+			}
+			if owners <= 1 {
+				return ErrLastGroupOwner
+			}
+		}
+		return tx.Delete(&m).Error
+	})
+}
+
+// Tira o usuário de todos os grupos (exclusão da conta). Um grupo que ficaria
+// sem owner passa para o membro mais antigo; sem ninguém, é removido.
+func leaveGroups(tx *gorm.DB, userID uint) error {
+	var owned []uint
+	err := tx.Model(&models.Membership{}).Where("user_id = ? AND role = ?", userID, models.GroupOwner).Pluck("group_id", &owned).Error
+	if err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.Membership{}).Error; err != nil {
+		return err
+	}
+	for _, groupID := range owned {
+		var owners int64
+		if err := tx.Model(&models.Membership{}).Where("group_id = ? AND role = ?", groupID, models.GroupOwner).Count(&owners).Error; err != nil {
+			return err
+		}
+		if owners > 0 {
+			continue
+		}
+		var heir models.Membership
+		err := tx.Where("group_id = ?", groupID).Order("created_at, id").First(&heir).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := tx.Delete(&models.Group{}, groupID).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&heir).Update("role", models.GroupOwner).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
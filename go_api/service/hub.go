@@ -0,0 +1,82 @@
+package service
+
+import (
+	"reflect"
+	"sync"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Hub de Eventos (Pub/Sub em memória) ---
+// Ponto único de distribuição de eventos em tempo real dentro da réplica.
+// Os canais de entrega (long-polling, WebSocket, SSE) se inscrevem aqui em
+// vez de consultar o banco em loop.
+type EventHub struct {
+	mu          sync.Mutex
+	subscribers map[chan models.UserEvent]struct{}
+}
+
+func NewEventHub() *EventHub {
+	return &EventHub{subscribers: make(map[chan models.UserEvent]struct{})}
+}
+
+// Inscreve um novo ouvinte. A função retornada cancela a inscrição.
+func (h *EventHub) Subscribe() (<-chan models.UserEvent, func()) {
+	ch := make(chan models.UserEvent, 16)
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subscribers, ch)
+		h.mu.Unlock()
+	}
+}
+
+// Entrega o evento a todos os inscritos sem bloquear: quem estiver com o
+// buffer cheio perde o aviso, mas pode recuperar pelo cursor no banco.
+func (h *EventHub) Publish(e models.UserEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Callback do GORM: todo UserEvent gravado nesse banco é anunciado no hub
+func (h *EventHub) Watch(db *gorm.DB) {
+	db.Callback().Create().After("gorm:create").Register("hub:publish", func(tx *gorm.DB) {
+		for _, e := range createdUserEvents(tx) {
+			h.Publish(e)
+		}
+	})
+}
+
+// UserEvents gravados pelo statement, tanto tx.Create(&evento) quanto
+// tx.Create(&[]UserEvent{...}); vazio para outros modelos ou em caso de erro
+func createdUserEvents(tx *gorm.DB) []models.UserEvent {
+	if tx.Error != nil {
+		return nil
+	}
+	var events []models.UserEvent
+	value := reflect.Indirect(tx.Statement.ReflectValue)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if e, ok := reflect.Indirect(value.Index(i)).Interface().(models.UserEvent); ok {
+				events = append(events, e)
+			}
+		}
+	case reflect.Struct:
+		if e, ok := value.Interface().(models.UserEvent); ok {
+			events = append(events, e)
+		}
+	}
+	return events
+}
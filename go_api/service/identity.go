@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"go_api/models"
+	"go_api/oauth"
+	"go_api/repository"
+)
+
+// --- Login Social ---
+// Quem entra por um provedor (Google, GitHub) fica ligado a um usuário pela
+// identidade (provedor + ID no provedor). Na primeira vez:
+//   - e-mail já cadastrado e verificado: a identidade é ligada à conta;
+//   - e-mail livre: a conta é criada, já verificada e com uma senha
+//     aleatória (dá para definir uma em /password/forgot).
+//
+// E-mail cadastrado mas não verificado não é ligado: quem cadastrou pode
+// não ser o dono do e-mail e ficaria com acesso à conta de quem entrar pelo
+// provedor.
+
+var (
+	ErrIdentityUnverifiedEmail = errors.New("provider account has no verified email")
+	ErrIdentityEmailTaken      = errors.New("email belongs to an unverified account")
+)
+
+// O que o validador "username" aceita
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// Mesmo service, guardando as identidades em store
+func (s *UserService) WithIdentities(store repository.IdentityStore) *UserService {
+	copied := *s
+	copied.identities = store
+	return &copied
+}
+
+// Devolve o usuário da identidade, ligando ou criando a conta na primeira
+// vez. Conta removida: ErrInvalidCredentials, como no login com senha.
+func (s *UserService) LoginWithIdentity(ctx context.Context, provider string, profile oauth.Profile, region string) (models.User, error) {
+	identity, err := s.identities.Find(ctx, provider, profile.Subject)
+	switch {
+	case err == nil:
+		user, err := s.Get(ctx, identity.UserID)
+		if errors.Is(err, ErrUserNotFound) {
+			return models.User{}, ErrInvalidCredentials
+		}
+		if err == nil && s.verify.Required && !user.EmailVerified {
+			return models.User{}, ErrEmailNotVerified
+		}
+		return user, err
+	case !errors.Is(err, repository.ErrNotFound):
+		return models.User{}, err
+	}
+
+	if profile.Email == "" || !profile.EmailVerified {
+		return models.User{}, ErrIdentityUnverifiedEmail
+	}
+	// Conta nova e identidade juntas: sem conta órfã se a ligação falhar
+	var user models.User
+	err = s.InTx(ctx, func(users *UserService) error {
+		var err error
+		user, err = users.store.FindByLogin(ctx, profile.Email)
+		switch {
+		case err == nil && user.Email == profile.Email:
+			if !user.EmailVerified {
+				return ErrIdentityEmailTaken
+			}
+		case err == nil || errors.Is(err, repository.ErrNotFound):
+			if user, err = users.registerFromProfile(ctx, profile, region); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		identity := models.Identity{UserID: user.ID, Provider: provider, Subject: profile.Subject, Email: profile.Email}
+		return users.identities.Create(ctx, &identity)
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (s *UserService) registerFromProfile(ctx context.Context, profile oauth.Profile, region string) (models.User, error) {
+	username, err := s.freeUsername(ctx, profile)
+	if err != nil {
+		return models.User{}, err
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return models.User{}, err
+	}
+	name := profile.Name
+	if name == "" {
+		name = username
+	}
+	user := models.User{
+		Name:     name,
+		Email:    profile.Email,
+		User:     username,
+		Password: base64.RawURLEncoding.EncodeToString(raw),
+		Region:   region,
+		Role:     models.RoleUser,
+	}
+	if err := conflict(s.store.Create(ctx, &user)); err != nil {
+		return user, err
+	}
+	// O provedor já verificou o e-mail: sem link de confirmação
+	event := models.NewUserEvent(user.ID, models.EventEmailVerified, models.UserEventData{Email: user.Email})
+	return user, s.store.Update(ctx, &user, map[string]interface{}{"email_verified": true}, event)
+}
+
+// Username do provedor ou do começo do e-mail, com um sufixo se já existe
+func (s *UserService) freeUsername(ctx context.Context, profile oauth.Profile) (string, error) {
+	base := profile.Login
+	if base == "" {
+		base, _, _ = strings.Cut(profile.Email, "@")
+	}
+	base = usernameInvalidChars.ReplaceAllString(base, "")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	for len(base) < 3 {
+		base += "_"
+	}
+	candidate := base
+	for range 5 {
+		taken, err := s.store.Taken(ctx, "user", candidate, 0)
+		if err != nil || !taken {
+			return candidate, err
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s-%04d", base, n)
+	}
+	return "", &ConflictError{Field: "user"}
+}
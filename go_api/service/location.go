@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Localização dos Dispositivos ---
+// Mesmo caminho das leituras: valida, grava em bloco e marca o dispositivo
+// como visto. A busca por raio usa a fórmula de haversine direto no SQL
+// (funções que o Postgres e o SQLite têm), sobre a última posição de cada
+// dispositivo: sem PostGIS, e sem trazer as posições para a memória.
+
+const (
+	MaxLocationsPerMessage = 1000
+	// Raio máximo da busca, em metros
+	MaxNearbyRadius = 100_000
+
+	earthRadiusM = 6371000
+)
+
+var ErrNoLocation = errors.New("device has no location yet")
+
+// Corpo inválido; Index aponta o ponto com problema (-1 = o corpo todo)
+type LocationsError struct {
+	Index   int
+	Message string
+}
+
+func (e *LocationsError) Error() string {
+	if e.Index < 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("location %d: %s", e.Index, e.Message)
+}
+
+// Aceita um ponto ({...}) ou vários ([{...}, ...]) e devolve quantos gravou
+func (t *Telemetry) IngestLocations(ctx context.Context, device models.Device, body []byte) (int, error) {
+	var inputs []models.LocationInput
+	var err error
+	if len(body) > 0 && body[0] == '[' {
+		err = json.Unmarshal(body, &inputs)
+	} else {
+		var single models.LocationInput
+		err = json.Unmarshal(body, &single)
+		inputs = []models.LocationInput{single}
+	}
+	if err != nil {
+		return 0, &LocationsError{Index: -1, Message: "Invalid JSON: " + err.Error()}
+	}
+	if len(inputs) == 0 || len(inputs) > MaxLocationsPerMessage {
+		return 0, &LocationsError{Index: -1, Message: fmt.Sprintf("A request must contain between 1 and %d locations", MaxLocationsPerMessage)}
+	}
+
+	now := time.Now().UTC()
+	locations := make([]models.Location, 0, len(inputs))
+	for i, in := range inputs {
+		switch {
+		case in.Lat == nil || in.Lon == nil:
+			return 0, &LocationsError{Index: i, Message: "lat and lon are required"}
+		case math.Abs(*in.Lat) > 90 || math.IsNaN(*in.Lat):
+			return 0, &LocationsError{Index: i, Message: "lat must be between -90 and 90"}
+		case math.Abs(*in.Lon) > 180 || math.IsNaN(*in.Lon):
+			return 0, &LocationsError{Index: i, Message: "lon must be between -180 and 180"}
+		case in.Accuracy != nil && *in.Accuracy < 0:
+			return 0, &LocationsError{Index: i, Message: "accuracy must not be negative"}
+		}
+		ts := in.Timestamp.UTC()
+		if in.Timestamp.IsZero() {
+			ts = now
+		}
+		locations = append(locations, models.Location{
+			DeviceID:  device.ID,
+			Timestamp: ts,
+			Lat:       *in.Lat,
+			Lon:       *in.Lon,
+			Accuracy:  in.Accuracy,
+		})
+	}
+
+	if err := t.db.WithContext(ctx).CreateInBatches(&locations, readingsBatchSize).Error; err != nil {
+		return 0, err
+	}
+	t.Seen(ctx, &device)
+	return len(locations), nil
+}
+
+// Posição mais recente do dispositivo
+func (t *Telemetry) LatestLocation(ctx context.Context, deviceID uint) (models.Location, error) {
+	var location models.Location
+	err := t.db.WithContext(ctx).Where("device_id = ?", deviceID).
+		Order(`"timestamp" DESC, id DESC`).First(&location).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return location, ErrNoLocation
+	}
+	return location, err
+}
+
+// Dispositivos cuja última posição está a até radius metros do ponto, do
+// mais perto para o mais longe. userID != 0 limita aos dispositivos do
+// usuário; o tenant vem do contexto, como nas outras consultas de Device.
+func (t *Telemetry) Nearby(ctx context.Context, lat, lon, radius float64, userID uint, limit int) ([]models.NearbyDevice, error) {
+	db := t.db.WithContext(ctx)
+	devices := db.Model(&models.Device{}).Select("id")
+	if userID != 0 {
+		devices = devices.Where("user_id = ?", userID)
+	}
+	// Última posição de cada dispositivo; o CASE segura o arredondamento
+	// (ASIN de algo acima de 1 é erro no Postgres)
+	latest := db.Table("locations AS l").
+		Select(`l.*, POWER(SIN(RADIANS(l.lat - ?) / 2), 2) + COS(RADIANS(?)) * COS(RADIANS(l.lat)) * POWER(SIN(RADIANS(l.lon - ?) / 2), 2) AS hav`, lat, lat, lon).
+		Where(`l.device_id IN (?) AND l.id = (SELECT l2.id FROM locations l2 WHERE l2.device_id = l.device_id ORDER BY l2."timestamp" DESC, l2.id DESC LIMIT 1)`, devices)
+	distances := db.Table("(?) AS h", latest).
+		Select("h.*, 2 * ? * ASIN(SQRT(CASE WHEN h.hav > 1 THEN 1 ELSE h.hav END)) AS distance_m", earthRadiusM)
+
+	var rows []struct {
+		models.Location
+		DistanceM float64
+	}
+	err := db.Table("(?) AS d", distances).Where("d.distance_m <= ?", radius).
+		Order("d.distance_m, d.device_id").Limit(limit).Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return []models.NearbyDevice{}, err
+	}
+
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.DeviceID
+	}
+	var found []models.Device
+	if err := db.Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	t.presence.markOnline(found, time.Now())
+	byID := make(map[uint]models.Device, len(found))
+	for _, d := range found {
+		byID[d.ID] = d
+	}
+	result := make([]models.NearbyDevice, 0, len(rows))
+	for _, row := range rows {
+		if device, ok := byID[row.DeviceID]; ok {
+			result = append(result, models.NearbyDevice{Device: device, Location: row.Location, DistanceM: row.DistanceM})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go_api/models"
+	"go_api/repository"
+)
+
+// --- Bloqueio de Conta ---
+// Depois de MaxAttempts senhas erradas dentro de Window (sem um login certo
+// no meio), a conta fica bloqueada por Duration a partir da última falha.
+// Enquanto bloqueada a senha nem é verificada, então as tentativas nesse
+// período não estendem o bloqueio. Se Window for maior que Duration, uma
+// falha logo depois do desbloqueio volta a bloquear.
+type LockoutPolicy struct {
+	MaxAttempts int // 0 desliga o bloqueio (as tentativas continuam registradas)
+	Window      time.Duration
+	Duration    time.Duration
+}
+
+// Conta bloqueada; errors.Is(err, ErrAccountLocked) também vale
+type AccountLockedError struct {
+	Until time.Time
+}
+
+var ErrAccountLocked = errors.New("account temporarily locked")
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("account locked until %s", e.Until.Format(time.RFC3339))
+}
+
+func (e *AccountLockedError) Is(target error) bool {
+	return target == ErrAccountLocked
+}
+
+// Mesmo service, registrando as tentativas de login e aplicando o bloqueio
+func (s *UserService) WithLockout(attempts repository.LoginAttemptStore, policy LockoutPolicy) *UserService {
+	copied := *s
+	copied.attempts = attempts
+	copied.lockout = policy
+	return &copied
+}
+
+// *AccountLockedError se a conta está bloqueada agora
+func (s *UserService) checkLockout(ctx context.Context, userID uint) error {
+	if s.attempts == nil || s.lockout.MaxAttempts <= 0 {
+		return nil
+	}
+	now := time.Now()
+	failures, err := s.attempts.RecentFailures(ctx, userID, now.Add(-s.lockout.Window), s.lockout.MaxAttempts)
+	if err != nil || len(failures) < s.lockout.MaxAttempts {
+		return err
+	}
+	if until := failures[0].CreatedAt.Add(s.lockout.Duration); now.Before(until) {
+		return &AccountLockedError{Until: until}
+	}
+	return nil
+}
+
+func (s *UserService) recordAttempt(ctx context.Context, login, ip string, user *models.User, success bool) error {
+	if s.attempts == nil {
+		return nil
+	}
+	attempt := models.LoginAttempt{Login: login, IP: ip, Success: success}
+	if user != nil {
+		attempt.UserID = &user.ID
+	}
+	return s.attempts.Record(ctx, &attempt)
+}
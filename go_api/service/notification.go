@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go_api/models"
+	"go_api/push"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- Notificações Push ---
+// Como os webhooks: Notify grava a notificação e uma entrega por
+// dispositivo com token, na mesma transação, e o job "push.deliver" envia
+// as vencidas. Cada entrega é reservada com um UPDATE condicional (duas
+// réplicas não mandam a mesma) e as reservadas seguem em lote para o
+// provedor da plataforma. Token recusado pelo provedor é apagado.
+//
+// POST /notifications chama Notify com a origem "admin"; eventos do
+// servidor (ex: um dispositivo entrando numa área) chamam Notify direto,
+// com a própria origem.
+
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrPushTokenNotFound    = errors.New("push token not found")
+)
+
+const (
+	// Entregas processadas por rodada
+	pushBatchSize = 500
+	// Prazo do envio antes de outra réplica poder tentar de novo
+	pushLease = time.Minute
+)
+
+type NotificationPolicy struct {
+	MaxAttempts int
+	RetryDelay  time.Duration // primeira espera; dobra a cada falha
+}
+
+type NotificationService struct {
+	db        *gorm.DB
+	providers map[string]push.Provider
+	policy    NotificationPolicy
+}
+
+func NewNotificationService(db *gorm.DB, providers map[string]push.Provider, policy NotificationPolicy) *NotificationService {
+	return &NotificationService{db: db, providers: providers, policy: policy}
+}
+
+// --- Tokens ---
+
+// Um token por dispositivo: registrar de novo troca o anterior. O mesmo
+// token em outro dispositivo (aparelho recadastrado) sai de lá.
+func (s *NotificationService) RegisterToken(ctx context.Context, deviceID uint, input models.PushTokenInput) (models.PushToken, error) {
+	token := models.PushToken{DeviceID: deviceID, Platform: input.Platform, Token: input.Token}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token = ? AND device_id <> ?", input.Token, deviceID).Delete(&models.PushToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "device_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"platform", "token", "updated_at"}),
+		}).Create(&token).Error
+	})
+	if err != nil {
+		return token, err
+	}
+	// No conflito, o ID e o created_at que valem são os da linha existente
+	err = s.db.WithContext(ctx).Where("device_id = ?", deviceID).First(&token).Error
+	return token, err
+}
+
+func (s *NotificationService) DeleteToken(ctx context.Context, deviceID uint) error {
+	res := s.db.WithContext(ctx).Where("device_id = ?", deviceID).Delete(&models.PushToken{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrPushTokenNotFound
+	}
+	return nil
+}
+
+// --- Disparo ---
+
+// Grava a notificação e as entregas para os dispositivos com token dos
+// usuários e dos dispositivos indicados (do tenant do contexto), fora os de
+// donos removidos ou sem o consentimento "notifications"
+func (s *NotificationService) Notify(ctx context.Context, source string, createdBy *uint, input models.NotificationInput) (models.Notification, error) {
+	var data json.RawMessage
+	if len(input.Data) > 0 {
+		data, _ = json.Marshal(input.Data)
+	}
+	n := models.Notification{Title: input.Title, Body: input.Body, Data: data, Source: source, CreatedBy: createdBy}
+	var total int
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&n).Error; err != nil {
+			return err
+		}
+		userIDs, userUUIDs := models.SplitRefs(input.UserIDs)
+		deviceIDs, deviceUUIDs := models.SplitRefs(input.DeviceIDs)
+		// Sem o consentimento "notifications" ativo do dono, nada de push
+		consenting := tx.Session(&gorm.Session{NewDB: true}).Model(&models.Consent{}).Select("user_id").
+			Where("purpose = ? AND revoked_at IS NULL", models.PurposeNotifications)
+		devices := ActiveOwner(tx.Model(&models.Device{})).Select("id").
+			Where("user_id IN ? OR id IN ? OR user_uuid IN ? OR uuid IN ?",
+				nonEmpty(userIDs), nonEmpty(deviceIDs), nonEmptyUUIDs(userUUIDs), nonEmptyUUIDs(deviceUUIDs)).
+			Where("devices.user_id IN (?)", consenting)
+		var tokens []models.PushToken
+		if err := tx.Where("device_id IN (?)", devices).Order("device_id").Find(&tokens).Error; err != nil {
+			return err
+		}
+		total = len(tokens)
+		if total == 0 {
+			return nil
+		}
+		now := time.Now()
+		deliveries := make([]models.NotificationDelivery, len(tokens))
+		for i, t := range tokens {
+			deliveries[i] = models.NotificationDelivery{NotificationID: n.ID, DeviceID: t.DeviceID, Status: models.DeliveryPending, NextAttemptAt: now}
+		}
+		return tx.CreateInBatches(&deliveries, pushBatchSize).Error
+	})
+	n.Stats = &models.NotificationStats{Total: int64(total), Pending: int64(total)}
+	return n, err
+}
+
+// IN com lista vazia não é SQL válido em todo banco
+func nonEmpty(ids []uint) []uint {
+	if len(ids) == 0 {
+		return []uint{0}
+	}
+	return ids
+}
+
+func nonEmptyUUIDs(uuids []string) []string {
+	if len(uuids) == 0 {
+		return []string{""}
+	}
+	return uuids
+}
+
+// A notificação com a contagem das entregas por status
+func (s *NotificationService) Get(ctx context.Context, id uint) (models.Notification, error) {
+	var n models.Notification
+	db := s.db.WithContext(ctx)
+	err := db.First(&n, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return n, ErrNotificationNotFound
+	}
+	if err != nil {
+		return n, err
+	}
+	var counts []struct {
+		Status string
+		N      int64
+	}
+	err = db.Model(&models.NotificationDelivery{}).Select("status, COUNT(*) AS n").
+		Where("notification_id = ?", id).Group("status").Scan(&counts).Error
+	stats := models.NotificationStats{}
+	for _, c := range counts {
+		stats.Total += c.N
+		switch c.Status {
+		case models.DeliveryPending:
+			stats.Pending = c.N
+		case models.DeliveryDelivered:
+			stats.Delivered = c.N
+		case models.DeliveryFailed:
+			stats.Failed = c.N
+		}
+	}
+	n.Stats = &stats
+	return n, err
+}
+
+// Entregas da notificação, por dispositivo, com o total para a paginação
+func (s *NotificationService) Deliveries(ctx context.Context, id uint, limit, offset int) ([]models.NotificationDelivery, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.NotificationDelivery{}).Where("notification_id = ?", id)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var deliveries []models.NotificationDelivery
+	err := query.Order("id").Limit(limit).Offset(offset).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// --- Entrega ---
+
+// Envia as entregas vencidas; devolve quantas foram aceitas pelos provedores
+func (s *NotificationService) DeliverDue(ctx context.Context) int {
+	db := s.db.WithContext(ctx)
+	var due []models.NotificationDelivery
+	err := db.Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, time.Now()).
+		Order("id").Limit(pushBatchSize).Find(&due).Error
+	if err != nil {
+		slog.WarnContext(ctx, "busca das entregas push falhou", "error", err)
+		return 0
+	}
+
+	// Reserva: só quem incrementar attempts a partir do valor lido envia
+	var reserved []models.NotificationDelivery
+	for _, d := range due {
+		res := db.Model(&models.NotificationDelivery{}).
+			Where("id = ? AND status = ? AND attempts = ?", d.ID, models.DeliveryPending, d.Attempts).
+			Updates(map[string]interface{}{"attempts": d.Attempts + 1, "next_attempt_at": time.Now().Add(pushLease)})
+		if res.Error == nil && res.RowsAffected == 1 {
+			d.Attempts++
+			reserved = append(reserved, d)
+		}
+	}
+	if len(reserved) == 0 {
+		return 0
+	}
+
+	notifications := map[uint]models.Notification{}
+	tokens := map[uint]models.PushToken{}
+	var nIDs, deviceIDs []uint
+	for _, d := range reserved {
+		nIDs = append(nIDs, d.NotificationID)
+		deviceIDs = append(deviceIDs, d.DeviceID)
+	}
+	var ns []models.Notification
+	var ts []models.PushToken
+	if err := db.Where("id IN ?", nIDs).Find(&ns).Error; err != nil {
+		slog.WarnContext(ctx, "busca das notificações falhou", "error", err)
+		return 0
+	}
+	if err := db.Where("device_id IN ?", deviceIDs).Find(&ts).Error; err != nil {
+		slog.WarnContext(ctx, "busca dos tokens push falhou", "error", err)
+		return 0
+	}
+	for _, n := range ns {
+		notifications[n.ID] = n
+	}
+	for _, t := range ts {
+		tokens[t.DeviceID] = t
+	}
+
+	// Um lote por plataforma
+	batches := map[string][]models.NotificationDelivery{}
+	messages := map[string][]push.Message{}
+	for _, d := range reserved {
+		token, ok := tokens[d.DeviceID]
+		n, found := notifications[d.NotificationID]
+		if !ok || !found {
+			s.finish(ctx, d, push.ErrUnregistered, "")
+			continue
+		}
+		var data map[string]string
+		json.Unmarshal(n.Data, &data)
+		batches[token.Platform] = append(batches[token.Platform], d)
+		messages[token.Platform] = append(messages[token.Platform], push.Message{Token: token.Token, Title: n.Title, Body: n.Body, Data: data})
+	}
+
+	delivered := 0
+	for platform, batch := range batches {
+		provider, ok := s.providers[platform]
+		var errs []error
+		if ok {
+			errs = provider.Send(ctx, messages[platform])
+		}
+		for i, d := range batch {
+			err := errors.New("no push provider for " + platform)
+			if ok {
+				err = errs[i]
+			}
+			if err == nil {
+				delivered++
+			}
+			s.finish(ctx, d, err, messages[platform][i].Token)
+		}
+	}
+	return delivered
+}
+
+// Grava o resultado da tentativa; token recusado pelo provedor é apagado
+func (s *NotificationService) finish(ctx context.Context, d models.NotificationDelivery, sendErr error, token string) {
+	db := s.db.WithContext(ctx)
+	now := time.Now()
+	updates := map[string]interface{}{"last_error": ""}
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.DeliveryDelivered
+		updates["delivered_at"] = now
+	case errors.Is(sendErr, push.ErrUnregistered):
+		updates["status"] = models.DeliveryFailed
+		updates["last_error"] = sendErr.Error()
+		if token != "" {
+			db.Where("token = ?", token).Delete(&models.PushToken{})
+		}
+	case d.Attempts >= s.policy.MaxAttempts:
+		updates["status"] = models.DeliveryFailed
+		updates["last_error"] = sendErr.Error()
+		slog.WarnContext(ctx, "entrega push desistida", "delivery_id", d.ID, "device_id", d.DeviceID, "attempts", d.Attempts, "error", sendErr)
+	default:
+		updates["next_attempt_at"] = now.Add(s.retryDelay(d.Attempts))
+		updates["last_error"] = sendErr.Error()
+	}
+	if err := db.Model(&d).Updates(updates).Error; err != nil {
+		slog.WarnContext(ctx, "gravação da entrega push falhou", "delivery_id", d.ID, "error", err)
+	}
+}
+
+// Espera antes da próxima tentativa: RetryDelay, 2x, 4x... até o teto dos
+// webhooks
+func (s *NotificationService) retryDelay(attempts int) time.Duration {
+	delay := s.policy.RetryDelay
+	for i := 1; i < attempts && delay < webhookMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookMaxRetryDelay)
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"go_api/broker"
+	"go_api/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- Outbox dos Eventos de Domínio ---
+// Os eventos para os outros serviços do sistema (user.*, device.online,
+// reading.ingested) são gravados em outbox_events na mesma transação da
+// alteração: nada confirmado fica sem evento, e nada desfeito é anunciado.
+// O job "outbox.publish" (OutboxRelay) lê os pendentes em ordem e publica
+// no broker. Um evento só sai do outbox depois de aceito pelo broker, então
+// a entrega é "pelo menos uma vez" (o consumidor deduplica pelo id).
+//
+// Os user.* saem de um callback do GORM, como os webhooks; as leituras e a
+// presença chamam enqueueOutbox na própria transação. Sem WatchOutbox
+// (OUTBOX_BROKER vazio), nada é gravado.
+
+const (
+	outboxCallback = "outbox:users"
+
+	// Eventos por rodada e rodadas por execução do job (com fila acumulada)
+	outboxBatchSize = 100
+	outboxRounds    = 20
+	// Prazo da publicação antes de outra réplica poder retomar o evento
+	outboxLease = 30 * time.Second
+	// Espera depois de uma falha: dobra até o teto; o evento nunca é
+	// descartado, espera o broker voltar
+	outboxRetryDelay    = time.Second
+	outboxMaxRetryDelay = 5 * time.Minute
+	// Por quanto tempo os publicados ficam na tabela (job "outbox.purge")
+	outboxKeep = 24 * time.Hour
+)
+
+// Registra o callback dos user.* e liga a gravação dos demais eventos
+func WatchOutbox(db *gorm.DB) {
+	db.Callback().Create().After("gorm:create").Register(outboxCallback, func(tx *gorm.DB) {
+		events := createdUserEvents(tx)
+		if len(events) == 0 {
+			return
+		}
+		var rows []models.OutboxEvent
+		for _, p := range userPayloads(events) {
+			raw, _ := json.Marshal(p)
+			rows = append(rows, models.OutboxEvent{
+				TenantID:   p.Changes[0].TenantID,
+				Type:       p.Event,
+				Entity:     fmt.Sprintf("user:%d", p.UserID),
+				Payload:    raw,
+				OccurredAt: p.OccurredAt,
+			})
+		}
+		if err := enqueueOutbox(tx.Session(&gorm.Session{NewDB: true}), rows...); err != nil {
+			tx.AddError(err)
+		}
+	})
+}
+
+func outboxEnabled(db *gorm.DB) bool {
+	return db.Callback().Create().Get(outboxCallback) != nil
+}
+
+// Grava os eventos na transação de tx (nada, com o outbox desligado)
+func enqueueOutbox(tx *gorm.DB, events ...models.OutboxEvent) error {
+	if len(events) == 0 || !outboxEnabled(tx) {
+		return nil
+	}
+	now := time.Now()
+	for i := range events {
+		events[i].NextAttemptAt = now
+	}
+	return tx.Create(&events).Error
+}
+
+func deviceOnlineEvent(device models.Device, at time.Time) models.OutboxEvent {
+	raw, _ := json.Marshal(models.DeviceOnlineEvent{DeviceID: device.ID, UserID: device.UserID, LastSeen: at})
+	return models.OutboxEvent{
+		TenantID:   device.TenantID,
+		Type:       models.OutboxDeviceOnline,
+		Entity:     fmt.Sprintf("device:%d", device.ID),
+		Payload:    raw,
+		OccurredAt: at,
+	}
+}
+
+// Um evento por lote gravado: com milhares de leituras por minuto, um por
+// leitura só encheria o broker
+func readingsIngestedEvent(device models.Device, readings []models.Reading) models.OutboxEvent {
+	data := models.ReadingsIngestedEvent{DeviceID: device.ID, UserID: device.UserID, Count: len(readings)}
+	for i, r := range readings {
+		if i == 0 || r.Timestamp.Before(data.From) {
+			data.From = r.Timestamp
+		}
+		if r.Timestamp.After(data.To) {
+			data.To = r.Timestamp
+		}
+		data.Metrics = append(data.Metrics, r.Metric)
+	}
+	data.Metrics = slices.Compact(slices.Sorted(slices.Values(data.Metrics)))
+	raw, _ := json.Marshal(data)
+	return models.OutboxEvent{
+		TenantID:   device.TenantID,
+		Type:       models.OutboxReadingIngested,
+		Entity:     fmt.Sprintf("device:%d", device.ID),
+		Payload:    raw,
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// --- Relay ---
+
+type OutboxRelay struct {
+	db        *gorm.DB
+	publisher broker.Publisher
+	prefix    string // OUTBOX_TOPIC_PREFIX
+}
+
+func NewOutboxRelay(db *gorm.DB, publisher broker.Publisher, prefix string) *OutboxRelay {
+	return &OutboxRelay{db: db, publisher: publisher, prefix: prefix}
+}
+
+// Publica os eventos pendentes; devolve quantos o broker aceitou
+func (r *OutboxRelay) PublishDue(ctx context.Context) int {
+	published := 0
+	for range outboxRounds {
+		claimed, err := r.claim(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "busca dos eventos do outbox falhou", "error", err)
+			break
+		}
+		ok := r.publish(ctx, claimed)
+		published += ok
+		// Rodada incompleta ou com falha: o resto fica para a próxima execução
+		if len(claimed) < outboxBatchSize || ok < len(claimed) {
+			break
+		}
+	}
+	return published
+}
+
+// Reserva os próximos pendentes num UPDATE só: a réplica que mudar o
+// next_attempt_at primeiro fica com o evento até o fim do lease
+func (r *OutboxRelay) claim(ctx context.Context) ([]models.OutboxEvent, error) {
+	db := r.db.WithContext(ctx)
+	now := time.Now()
+	due := db.Model(&models.OutboxEvent{}).Select("id").
+		Where("published_at IS NULL AND next_attempt_at <= ?", now).
+		Order("id").Limit(outboxBatchSize)
+	var claimed []models.OutboxEvent
+	err := db.Model(&claimed).Clauses(clause.Returning{}).
+		Where("id IN (?) AND published_at IS NULL AND next_attempt_at <= ?", due, now).
+		Updates(map[string]interface{}{"attempts": gorm.Expr("attempts + 1"), "next_attempt_at": now.Add(outboxLease)}).Error
+	slices.SortFunc(claimed, func(a, b models.OutboxEvent) int { return cmp.Compare(a.ID, b.ID) })
+	return claimed, err
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, events []models.OutboxEvent) int {
+	if len(events) == 0 {
+		return 0
+	}
+	msgs := make([]broker.Message, len(events))
+	for i, e := range events {
+		value, _ := json.Marshal(e)
+		msgs[i] = broker.Message{Topic: r.prefix + e.Type, Key: e.Entity, Value: value}
+	}
+	errs := r.publisher.Publish(ctx, msgs)
+
+	db := r.db.WithContext(ctx)
+	now := time.Now()
+	var done []uint
+	for i, e := range events {
+		if errs[i] == nil {
+			done = append(done, e.ID)
+			continue
+		}
+		slog.WarnContext(ctx, "publicação de evento do outbox falhou", "event_id", e.ID, "type", e.Type, "broker", r.publisher.Name(), "attempts", e.Attempts, "error", errs[i])
+		err := db.Model(&e).Updates(map[string]interface{}{
+			"next_attempt_at": now.Add(outboxBackoff(e.Attempts)),
+			"last_error":      errs[i].Error(),
+		}).Error
+		if err != nil {
+			slog.WarnContext(ctx, "gravação do evento do outbox falhou", "event_id", e.ID, "error", err)
+		}
+	}
+	if len(done) > 0 {
+		err := db.Model(&models.OutboxEvent{}).Where("id IN ?", done).
+			Updates(map[string]interface{}{"published_at": now, "last_error": ""}).Error
+		if err != nil {
+			// Voltam a ser publicados depois do lease
+			slog.WarnContext(ctx, "gravação dos eventos publicados falhou", "events", len(done), "error", err)
+		}
+	}
+	return len(done)
+}
+
+// outboxRetryDelay, 2x, 4x... até o teto
+func outboxBackoff(attempts int) time.Duration {
+	delay := outboxRetryDelay
+	for i := 1; i < attempts && delay < outboxMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, outboxMaxRetryDelay)
+}
+
+// Apaga os eventos publicados há mais de outboxKeep
+func (r *OutboxRelay) Cleanup(ctx context.Context) error {
+	return r.db.WithContext(ctx).
+		Where("published_at < ?", time.Now().Add(-outboxKeep)).
+		Delete(&models.OutboxEvent{}).Error
+}
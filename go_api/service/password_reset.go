@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go_api/mailer"
+	"go_api/models"
+	"go_api/repository"
+)
+
+// --- Redefinição de Senha ---
+// POST /password/forgot gera um token aleatório, guarda só o hash e manda o
+// token por e-mail; POST /password/reset troca a senha com ele. O token
+// vale uma vez, até TTL. Um pedido novo invalida os anteriores.
+
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+type PasswordResetPolicy struct {
+	TTL time.Duration
+	URL string // opcional: o e-mail leva URL + token (ex: "https://app/reset?token=")
+}
+
+// Mesmo service, com a redefinição de senha ligada (exige WithMailer)
+func (s *UserService) WithPasswordReset(tokens repository.PasswordResetStore, policy PasswordResetPolicy) *UserService {
+	copied := *s
+	copied.resetTokens = tokens
+	copied.reset = policy
+	return &copied
+}
+
+// E-mail não cadastrado não é erro: a resposta é a mesma, para não revelar
+// quem tem conta
+func (s *UserService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.store.FindByLogin(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && user.Email != email) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, hash, err := newEmailToken()
+	if err != nil {
+		return err
+	}
+	err = s.resetTokens.Create(ctx, &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.reset.TTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Hi %s,\n\nUse this code to reset your password: %s%s\n\n"+
+			"It expires in %s. If you did not ask for it, ignore this message.\n",
+			user.Name, s.reset.URL, token, s.reset.TTL),
+	})
+}
+
+// Token, senha e sessões numa transação só: se a troca falhar, o token
+// continua valendo para uma nova tentativa
+func (s *UserService) ResetPassword(ctx context.Context, input models.ResetPasswordInput) error {
+	hash, err := models.HashPassword(input.NewPassword)
+	if err != nil {
+		return err
+	}
+	return s.InTx(ctx, func(users *UserService) error {
+		userID, err := users.resetTokens.Consume(ctx, hashEmailToken(input.Token), time.Now())
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidResetToken
+		}
+		if err != nil {
+			return err
+		}
+
+		// Usuário removido depois do pedido: o token não serve mais
+		user, err := users.Get(ctx, userID)
+		if errors.Is(err, ErrUserNotFound) {
+			return ErrInvalidResetToken
+		}
+		if err != nil {
+			return err
+		}
+		event := models.NewUserEvent(user.ID, models.EventPasswordChanged, models.UserEventData{})
+		if err := users.store.Update(ctx, &user, map[string]interface{}{"password": hash}, event); err != nil {
+			return err
+		}
+		return users.revokeSessions(ctx, user.ID)
+	})
+}
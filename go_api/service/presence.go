@@ -0,0 +1,205 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Presença dos Dispositivos (em memória) ---
+// Cada chamada de um dispositivo à API (leituras, heartbeat) passa por
+// Seen. O primeiro Seen (ou o primeiro depois de ficar offline) gera
+// "device.online"; os seguintes geram "device.seen", no máximo um a cada
+// seenInterval por dispositivo para não inundar os painéis. Quem fica mais
+// de timeout sem chamar vira "device.offline" na próxima varredura.
+//
+// Assim como o EventHub, vale para a réplica: um painel conectado numa
+// réplica só vê os dispositivos que chamam essa mesma réplica.
+
+const (
+	PresenceOnline  = "device.online"
+	PresenceSeen    = "device.seen"
+	PresenceOffline = "device.offline"
+
+	seenInterval = 5 * time.Second
+)
+
+type PresenceEvent struct {
+	Type     string    `json:"type"`
+	DeviceID uint      `json:"device_id"`
+	UserID   uint      `json:"user_id"`
+	TenantID uint      `json:"tenant_id"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+type devicePresence struct {
+	userID    uint
+	tenantID  uint
+	lastSeen  time.Time
+	published time.Time // último evento enviado (online ou seen)
+}
+
+type PresenceHub struct {
+	timeout time.Duration
+
+	mu          sync.Mutex
+	online      map[uint]*devicePresence
+	subscribers map[chan PresenceEvent]struct{}
+	closed      bool
+}
+
+func NewPresenceHub(timeout time.Duration) *PresenceHub {
+	return &PresenceHub{
+		timeout:     timeout,
+		online:      make(map[uint]*devicePresence),
+		subscribers: make(map[chan PresenceEvent]struct{}),
+	}
+}
+
+// Inscreve um ouvinte. O canal é fechado no Close (encerramento da réplica).
+func (p *PresenceHub) Subscribe() (<-chan PresenceEvent, func()) {
+	ch := make(chan PresenceEvent, 64)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	p.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if _, ok := p.subscribers[ch]; ok {
+			delete(p.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Estado atual: um "device.online" para cada dispositivo conectado
+func (p *PresenceHub) Snapshot() []PresenceEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	events := make([]PresenceEvent, 0, len(p.online))
+	for id, d := range p.online {
+		events = append(events, PresenceEvent{Type: PresenceOnline, DeviceID: id, UserID: d.userID, TenantID: d.tenantID, LastSeen: d.lastSeen})
+	}
+	return events
+}
+
+// Sem bloquear: quem estiver com o buffer cheio perde o evento (chamar com
+// o mutex travado)
+func (p *PresenceHub) publish(e PresenceEvent) {
+	for ch := range p.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+func (p *PresenceHub) Seen(device models.Device, at time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d, ok := p.online[device.ID]
+	if !ok {
+		p.online[device.ID] = &devicePresence{userID: device.UserID, tenantID: device.TenantID, lastSeen: at, published: at}
+		p.publish(PresenceEvent{Type: PresenceOnline, DeviceID: device.ID, UserID: device.UserID, TenantID: device.TenantID, LastSeen: at})
+		return
+	}
+	d.lastSeen = at
+	if at.Sub(d.published) >= seenInterval {
+		d.published = at
+		p.publish(PresenceEvent{Type: PresenceSeen, DeviceID: device.ID, UserID: device.UserID, TenantID: device.TenantID, LastSeen: at})
+	}
+}
+
+// Grava o last_seen do dispositivo escolhido por scope (sobre
+// db.Model(device)) e devolve quantos foram alterados. O caso comum, um
+// dispositivo que já estava online, continua sendo um UPDATE só; quem volta
+// de offline (ou chama pela primeira vez) grava o device.online do outbox
+// na mesma transação. UpdateColumn: o sinal de vida não mexe no updated_at.
+func (p *PresenceHub) touch(db *gorm.DB, device *models.Device, now time.Time, scope func(*gorm.DB) *gorm.DB) (int64, error) {
+	if !outboxEnabled(db) {
+		res := scope(db.Model(device)).UpdateColumn("last_seen", now)
+		return res.RowsAffected, res.Error
+	}
+	cutoff := now.Add(-p.timeout)
+	res := scope(db.Model(device)).Where("last_seen >= ?", cutoff).UpdateColumn("last_seen", now)
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.RowsAffected, res.Error
+	}
+	var cameOnline int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := scope(tx.Model(device)).Where("last_seen IS NULL OR last_seen < ?", cutoff).UpdateColumn("last_seen", now)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		cameOnline = res.RowsAffected
+		return enqueueOutbox(tx, deviceOnlineEvent(*device, now))
+	})
+	if err != nil || cameOnline > 0 {
+		return cameOnline, err
+	}
+	// Outra réplica marcou antes (ou nada corresponde ao scope)
+	res = scope(db.Model(device)).UpdateColumn("last_seen", now)
+	return res.RowsAffected, res.Error
+}
+
+// Preenche Online pelo last_seen gravado no banco: ao contrário do estado
+// em memória, vale para os dispositivos que chamam qualquer réplica
+func (p *PresenceHub) markOnline(devices []models.Device, now time.Time) {
+	for i := range devices {
+		devices[i].Online = p.isOnline(devices[i].LastSeen, now)
+	}
+}
+
+func (p *PresenceHub) isOnline(lastSeen *time.Time, now time.Time) bool {
+	return lastSeen != nil && now.Sub(*lastSeen) <= p.timeout
+}
+
+// Dispositivo removido: sai da presença na hora
+func (p *PresenceHub) Forget(device models.Device) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if d, ok := p.online[device.ID]; ok {
+		delete(p.online, device.ID)
+		p.publish(PresenceEvent{Type: PresenceOffline, DeviceID: device.ID, UserID: d.userID, TenantID: d.tenantID, LastSeen: d.lastSeen})
+	}
+}
+
+// Marca como offline quem está há mais de timeout sem chamar
+func (p *PresenceHub) Sweep(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for id, d := range p.online {
+		if now.Sub(d.lastSeen) > p.timeout {
+			delete(p.online, id)
+			p.publish(PresenceEvent{Type: PresenceOffline, DeviceID: id, UserID: d.userID, TenantID: d.tenantID, LastSeen: d.lastSeen})
+		}
+	}
+}
+
+// Varre a cada interval; roda até o processo acabar
+func (p *PresenceHub) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		p.Sweep(now)
+	}
+}
+
+// Fecha todas as inscrições (as conexões WebSocket terminam)
+func (p *PresenceHub) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for ch := range p.subscribers {
+		delete(p.subscribers, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go_api/mailer"
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Privacidade (Exportação e Exclusão da Conta) ---
+// GET /me/export entrega tudo o que está ligado ao usuário; DELETE /me
+// apaga a conta em dois passos (pedido + código por e-mail). Na exclusão,
+// os dados pessoais somem de vez (dispositivos, leituras, posições,
+// atividades, consentimentos, sessões, chaves, conflitos do sync...). O
+// registro do usuário fica, removido e anônimo, para os eventos e a
+// auditoria continuarem apontando para algo; os eventos, as fotos
+// antes/depois da auditoria e os payloads dos webhooks perdem o conteúdo.
+
+var ErrInvalidDeletionToken = errors.New("invalid or expired deletion token")
+
+const (
+	// Linhas lidas do banco por vez na exportação
+	exportBatchSize = 500
+	// Domínio do e-mail dos usuários anonimizados
+	anonymousEmailHost = "deleted.invalid"
+)
+
+type PrivacyService struct {
+	db      *gorm.DB
+	users   *UserService
+	avatars *AvatarService
+	ttl     time.Duration // validade do código de exclusão
+}
+
+func NewPrivacyService(db *gorm.DB, users *UserService, avatars *AvatarService, ttl time.Duration) *PrivacyService {
+	return &PrivacyService{db: db, users: users, avatars: avatars, ttl: ttl}
+}
+
+// Uma parte do arquivo exportado (um arquivo no ZIP, uma chave no JSON)
+type ExportSection struct {
+	Name string
+	// Um objeto só (o perfil) em vez de uma lista
+	Single bool
+	// Percorre os registros, em lotes, sem carregar tudo em memória
+	Each func(fn func(interface{}) error) error
+}
+
+func eachRow[T any](db *gorm.DB) func(fn func(interface{}) error) error {
+	return func(fn func(interface{}) error) error {
+		var batch []T
+		return db.FindInBatches(&batch, exportBatchSize, func(*gorm.DB, int) error {
+			for _, row := range batch {
+				if err := fn(row); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	}
+}
+
+// Seções da exportação, na ordem do arquivo
+func (s *PrivacyService) ExportSections(ctx context.Context, userID uint) ([]ExportSection, error) {
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	db := s.db.WithContext(ctx)
+	own := func() *gorm.DB { return db.Where("user_id = ?", userID) }
+	devices := db.Model(&models.Device{}).Select("id").Where("user_id = ?", userID)
+	return []ExportSection{
+		{Name: "profile", Single: true, Each: func(fn func(interface{}) error) error { return fn(user) }},
+		{Name: "identities", Each: eachRow[models.Identity](own())},
+		{Name: "sessions", Each: eachRow[models.Session](own())},
+		{Name: "api_keys", Each: eachRow[models.APIKey](own())},
+		{Name: "consents", Each: eachRow[models.Consent](own())},
+		{Name: "devices", Each: eachRow[models.Device](own())},
+		{Name: "readings", Each: eachRow[models.Reading](db.Where("device_id IN (?)", devices))},
+		{Name: "locations", Each: eachRow[models.Location](db.Where("device_id IN (?)", devices))},
+		{Name: "activities", Each: eachRow[models.ActivitySample](own())},
+		{Name: "memberships", Each: eachRow[models.Membership](own().Preload("Group"))},
+		{Name: "sync_conflicts", Each: eachRow[models.SyncConflict](own())},
+		{Name: "login_attempts", Each: eachRow[models.LoginAttempt](own())},
+		{Name: "events", Each: eachRow[models.UserEvent](own())},
+		{Name: "audit", Each: eachRow[models.AuditLog](
+			db.Where("(entity = ? AND entity_id = ?) OR actor_id = ?", "user", userID, userID))},
+	}, nil
+}
+
+// Primeiro passo do DELETE /me: manda o código por e-mail. Um pedido novo
+// invalida o anterior.
+func (s *PrivacyService) RequestDeletion(ctx context.Context, userID uint) (time.Time, error) {
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	token, hash, err := newEmailToken()
+	if err != nil {
+		return time.Time{}, err
+	}
+	expires := time.Now().Add(s.ttl)
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND used_at IS NULL", userID).Delete(&models.AccountDeletionToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.AccountDeletionToken{UserID: userID, TokenHash: hash, ExpiresAt: expires}).Error
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return expires, s.users.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm account deletion",
+		Body: fmt.Sprintf("Hi %s,\n\nUse this code to confirm the deletion of your account: %s\n\n"+
+			"It expires in %s. Your data will be erased and cannot be recovered. "+
+			"If you did not ask for it, change your password.\n",
+			user.Name, token, s.ttl),
+	})
+}
+
+// Segundo passo: o código precisa ser do próprio usuário, ainda válido.
+// Código, soft delete e limpeza numa transação só: uma falha no meio não
+// deixa a conta meio apagada nem gasta o código.
+func (s *PrivacyService) ConfirmDeletion(ctx context.Context, userID uint, token string) error {
+	// A senha anônima sai antes: o bcrypt não segura a transação aberta
+	password, err := anonymousPassword()
+	if err != nil {
+		return err
+	}
+
+	var user models.User
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		res := tx.Model(&models.AccountDeletionToken{}).
+			Where("token_hash = ? AND user_id = ? AND used_at IS NULL AND expires_at > ?", hashEmailToken(token), userID, now).
+			Update("used_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrInvalidDeletionToken
+		}
+
+		users := s.users.WithTx(tx)
+		var err error
+		if user, err = users.Get(ctx, userID); err != nil {
+			return err
+		}
+		// O soft delete de sempre (evento, auditoria, webhook user.deleted, cache)
+		if err := users.Delete(ctx, userID); err != nil {
+			return err
+		}
+		return purge(tx, user, password)
+	})
+	if err != nil {
+		return err
+	}
+	// O arquivo não volta num rollback: só sai depois do commit
+	s.avatars.Remove(ctx, user.AvatarURL)
+	return nil
+}
+
+// Usuários removidos (soft delete) antes de before e ainda não anonimizados
+// passam pela mesma limpeza da exclusão confirmada; um por transação, e os
+// já limpos não voltam a ser listados. Devolve quantos foram apagados.
+func (s *PrivacyService) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	var users []models.User
+	err := s.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ? AND anonymized_at IS NULL", before).
+		Order("id").Find(&users).Error
+	if err != nil {
+		return 0, err
+	}
+	for i, user := range users {
+		password, err := anonymousPassword()
+		if err != nil {
+			return i, err
+		}
+		err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return purge(tx, user, password)
+		})
+		if err != nil {
+			return i, fmt.Errorf("usuário %d: %w", user.ID, err)
+		}
+		s.avatars.Remove(ctx, user.AvatarURL)
+	}
+	return len(users), nil
+}
+
+// Hash de uma senha aleatória, que ninguém conhece
+func anonymousPassword() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return models.HashPassword(base64.RawURLEncoding.EncodeToString(raw))
+}
+
+// Apaga os dados ligados à conta e anonimiza o usuário (ConfirmDeletion e
+// PurgeDeleted)
+func purge(tx *gorm.DB, user models.User, password string) error {
+	// Unscoped: inclusive os dispositivos já removidos (tombstones)
+	devices := tx.Unscoped().Model(&models.Device{}).Select("id").Where("user_id = ?", user.ID)
+	keys := tx.Model(&models.APIKey{}).Select("id").Where("user_id = ?", user.ID)
+
+	// Auditoria: fica quem fez o quê e quando, sem os dados
+	err := tx.Model(&models.AuditLog{}).
+		Where("(entity = ? AND entity_id = ?) OR (entity = ? AND entity_id IN (?)) OR (entity = ? AND entity_id IN (?))",
+			"user", user.ID, "device", devices, "api_key", keys).
+		Updates(map[string]interface{}{"before": gorm.Expr("NULL"), "after": gorm.Expr("NULL"), "fields": gorm.Expr("NULL")}).Error
+	if err != nil {
+		return err
+	}
+	// e sem o IP de onde o próprio usuário agiu
+	if err := tx.Model(&models.AuditLog{}).Where("actor_id = ?", user.ID).Update("client_ip", "").Error; err != nil {
+		return err
+	}
+	if err := deleteDeviceData(tx, devices); err != nil {
+		return err
+	}
+	// Só o soft delete não aciona o CASCADE da participação
+	if err := leaveGroups(tx, user.ID); err != nil {
+		return err
+	}
+	for _, model := range []interface{}{
+		&models.Device{}, &models.ActivitySample{}, &models.Consent{}, &models.Session{}, &models.APIKey{},
+		&models.SyncConflict{}, &models.Identity{}, &models.LoginAttempt{}, &models.PasswordResetToken{},
+		&models.EmailVerificationToken{}, &models.AccountDeletionToken{},
+	} {
+		// Unscoped: aqui o dispositivo sai de vez, sem tombstone
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(&models.UserEvent{}).Where("user_id = ?", user.ID).Update("data", []byte("{}")).Error; err != nil {
+		return err
+	}
+	if err := blankWebhookPayloads(tx, user.ID); err != nil {
+		return err
+	}
+	// E-mail e username são únicos: o anônimo usa o ID para não colidir
+	return tx.Unscoped().Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"name":           "Deleted user",
+		"email":          fmt.Sprintf("deleted-%d@%s", user.ID, anonymousEmailHost),
+		"user":           fmt.Sprintf("deleted-%d", user.ID),
+		"password":       password,
+		"region":         "",
+		"avatar_url":     "",
+		"email_verified": false,
+		"anonymized_at":  time.Now(),
+	}).Error
+}
+
+// As entregas de webhook (inclusive o user.deleted recém-enfileirado) saem
+// só com o evento e o ID: as alterações com os dados da conta somem
+func blankWebhookPayloads(tx *gorm.DB, userID uint) error {
+	var events []string
+	err := tx.Model(&models.WebhookDelivery{}).Where("user_id = ?", userID).Distinct().Pluck("event", &events).Error
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		raw, _ := json.Marshal(map[string]any{"event": event, "user_id": userID, "changes": []models.UserEvent{}})
+		err := tx.Model(&models.WebhookDelivery{}).Where("user_id = ? AND event = ?", userID, event).Update("payload", raw).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apaga os códigos de exclusão vencidos (job "tokens.purge")
+func (s *PrivacyService) CleanupTokens(ctx context.Context) error {
+	return s.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.AccountDeletionToken{}).Error
+}
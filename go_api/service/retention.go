@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go_api/models"
+	"go_api/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- Retenção e Agregação das Leituras ---
+// O job "readings.retention" troca as leituras brutas mais antigas que
+// Raw por agregados horários, os horários mais antigos que Hourly por
+// diários, e apaga os diários mais antigos que Daily. Cada passo agrega e
+// apaga na mesma transação, limitado ao maior ID visto no início: uma
+// leitura atrasada gravada no meio fica para a próxima rodada, não some.
+
+const rollupBatchSize = 500
+
+type RetentionPolicy struct {
+	Raw    time.Duration // 0 = leituras brutas para sempre (job desligado)
+	Hourly time.Duration // 0 = agregados horários para sempre
+	Daily  time.Duration // 0 = agregados diários para sempre
+}
+
+type ReadingRetention struct {
+	db     *gorm.DB
+	policy RetentionPolicy
+}
+
+func NewReadingRetention(db *gorm.DB, policy RetentionPolicy) *ReadingRetention {
+	return &ReadingRetention{db: db, policy: policy}
+}
+
+// Soma, contagem, mínimo e máximo de um intervalo (slot = início, em
+// segundos desde 1970)
+type rollupGroup struct {
+	DeviceID uint
+	Metric   string
+	Slot     int64
+	Count    int64
+	Sum      float64
+	Min      float64
+	Max      float64
+}
+
+func (r *ReadingRetention) Run(ctx context.Context) error {
+	if r.policy.Raw <= 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	db := r.db.WithContext(ctx)
+
+	// Só horas inteiras: uma hora nunca fica metade bruta, metade agregada
+	cutoff := now.Add(-r.policy.Raw).Truncate(time.Hour)
+	raw := func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&models.Reading{}).Where(`"timestamp" < ?`, cutoff)
+	}
+	rawSelect := fmt.Sprintf("device_id, metric, %s AS slot, COUNT(*) AS count, SUM(value) AS sum, MIN(value) AS min, MAX(value) AS max",
+		bucketExpr(db, `"timestamp"`, time.Hour))
+	if err := r.rollup(db, raw, rawSelect, models.ResolutionHourly, &models.Reading{}); err != nil {
+		return fmt.Errorf("agregando leituras: %w", err)
+	}
+
+	if r.policy.Hourly > 0 {
+		cutoff := now.Add(-r.policy.Hourly).Truncate(24 * time.Hour)
+		hourly := func(tx *gorm.DB) *gorm.DB {
+			return tx.Model(&models.ReadingRollup{}).Where("resolution = ? AND bucket < ?", models.ResolutionHourly, cutoff)
+		}
+		if err := r.rollup(db, hourly, rollupSelect(db, 24*time.Hour), models.ResolutionDaily, &models.ReadingRollup{}); err != nil {
+			return fmt.Errorf("agregando horas: %w", err)
+		}
+	}
+
+	if r.policy.Daily > 0 {
+		err := db.Where("resolution = ? AND bucket < ?", models.ResolutionDaily, now.Add(-r.policy.Daily)).
+			Delete(&models.ReadingRollup{}).Error
+		if err != nil {
+			return fmt.Errorf("apagando dias: %w", err)
+		}
+	}
+	return nil
+}
+
+// Agrega as linhas de source em agregados da resolução e apaga as linhas
+func (r *ReadingRetention) rollup(db *gorm.DB, source func(*gorm.DB) *gorm.DB, selectSQL, resolution string, model interface{}) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var maxID uint
+		if err := source(tx).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error; err != nil || maxID == 0 {
+			return err
+		}
+
+		var groups []rollupGroup
+		err := source(tx).Where("id <= ?", maxID).Select(selectSQL).
+			Group("device_id, metric, slot").Scan(&groups).Error
+		if err != nil {
+			return err
+		}
+		rollups := make([]models.ReadingRollup, 0, len(groups))
+		for _, g := range groups {
+			rollups = append(rollups, models.ReadingRollup{
+				DeviceID: g.DeviceID, Resolution: resolution, Metric: g.Metric, Bucket: time.Unix(g.Slot, 0).UTC(),
+				Count: g.Count, Sum: g.Sum, Min: g.Min, Max: g.Max,
+			})
+		}
+		// O intervalo já agregado (leituras atrasadas) soma com o anterior
+		err = tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "device_id"}, {Name: "resolution"}, {Name: "metric"}, {Name: "bucket"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"count": gorm.Expr("reading_rollups.count + excluded.count"),
+				"sum":   gorm.Expr("reading_rollups.sum + excluded.sum"),
+				"min":   gorm.Expr("CASE WHEN excluded.min < reading_rollups.min THEN excluded.min ELSE reading_rollups.min END"),
+				"max":   gorm.Expr("CASE WHEN excluded.max > reading_rollups.max THEN excluded.max ELSE reading_rollups.max END"),
+			}),
+		}).CreateInBatches(&rollups, rollupBatchSize).Error
+		if err != nil {
+			return err
+		}
+		return source(tx).Where("id <= ?", maxID).Delete(model).Error
+	})
+}
+
+// Início do intervalo de size que contém column, em segundos desde 1970 (UTC)
+func bucketExpr(db *gorm.DB, column string, size time.Duration) string {
+	seconds := int64(size / time.Second)
+	if db.Dialector.Name() == "postgres" {
+		return fmt.Sprintf("(FLOOR(EXTRACT(EPOCH FROM %s) / %d)::bigint * %d)", column, seconds, seconds)
+	}
+	return fmt.Sprintf("(CAST(strftime('%%s', %s) AS INTEGER) / %d * %d)", column, seconds, seconds)
+}
+
+// Junta agregados já gravados em intervalos de size
+func rollupSelect(db *gorm.DB, size time.Duration) string {
+	return fmt.Sprintf("device_id, metric, %s AS slot, SUM(count) AS count, SUM(sum) AS sum, MIN(min) AS min, MAX(max) AS max",
+		bucketExpr(db, "bucket", size))
+}
+
+// --- Consulta da Série Agregada ---
+
+type SeriesQuery struct {
+	Bucket   time.Duration // tamanho de cada intervalo (alinhado em 1970, UTC)
+	From, To time.Time
+	Metric   string
+	Limit    int
+}
+
+// Série do dispositivo em intervalos de Bucket, do mais recente para o mais
+// antigo, calculada no banco. Junta o que ainda está bruto com os agregados
+// gravados que cabem inteiros no intervalo (horários se Bucket é múltiplo
+// de 1 h, diários se de 24 h); o intervalo que aparece em mais de uma fonte
+// é somado.
+func (t *Telemetry) Series(ctx context.Context, deviceID uint, q SeriesQuery) ([]models.ReadingAggregate, error) {
+	// Série histórica: pode vir de uma réplica de leitura (a sessão deixa
+	// montar as várias consultas a partir dela)
+	db := repository.Replica(t.db.WithContext(ctx)).Session(&gorm.Session{})
+
+	filter := func(query *gorm.DB, column string) *gorm.DB {
+		query = query.Where("device_id = ?", deviceID)
+		if !q.From.IsZero() {
+			query = query.Where(column+" >= ?", q.From)
+		}
+		if !q.To.IsZero() {
+			query = query.Where(column+" < ?", q.To)
+		}
+		if q.Metric != "" {
+			query = query.Where("metric = ?", q.Metric)
+		}
+		// Cada fonte em ordem e com o mesmo limite: os primeiros "limit" do
+		// resultado estão sempre entre os primeiros de cada fonte
+		return query.Group("device_id, metric, slot").Order("slot DESC, metric").Limit(q.Limit)
+	}
+
+	sources := []*gorm.DB{
+		filter(db.Model(&models.Reading{}).Select(fmt.Sprintf(
+			"device_id, metric, %s AS slot, COUNT(*) AS count, SUM(value) AS sum, MIN(value) AS min, MAX(value) AS max",
+			bucketExpr(db, `"timestamp"`, q.Bucket))), `"timestamp"`),
+	}
+	for resolution, size := range map[string]time.Duration{models.ResolutionHourly: time.Hour, models.ResolutionDaily: 24 * time.Hour} {
+		if q.Bucket%size == 0 {
+			sources = append(sources, filter(db.Model(&models.ReadingRollup{}).Select(rollupSelect(db, q.Bucket)).
+				Where("resolution = ?", resolution), "bucket"))
+		}
+	}
+
+	type key struct {
+		slot   int64
+		metric string
+	}
+	merged := make(map[key]*rollupGroup)
+	for _, source := range sources {
+		var groups []rollupGroup
+		if err := source.Scan(&groups).Error; err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			k := key{g.Slot, g.Metric}
+			m, ok := merged[k]
+			if !ok {
+				g := g
+				merged[k] = &g
+				continue
+			}
+			m.Count += g.Count
+			m.Sum += g.Sum
+			m.Min = min(m.Min, g.Min)
+			m.Max = max(m.Max, g.Max)
+		}
+	}
+
+	series := make([]models.ReadingAggregate, 0, len(merged))
+	for _, g := range merged {
+		series = append(series, models.ReadingAggregate{
+			Timestamp: time.Unix(g.Slot, 0).UTC(),
+			Metric:    g.Metric,
+			Count:     g.Count,
+			Avg:       g.Sum / float64(g.Count),
+			Min:       g.Min,
+			Max:       g.Max,
+		})
+	}
+	sort.Slice(series, func(i, j int) bool {
+		if !series[i].Timestamp.Equal(series[j].Timestamp) {
+			return series[i].Timestamp.After(series[j].Timestamp)
+		}
+		return series[i].Metric < series[j].Metric
+	})
+	if len(series) > q.Limit {
+		series = series[:q.Limit]
+	}
+	return series, nil
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Dados de Demonstração (./server seed) ---
+// Usuários, dispositivos e leituras falsos, gravados direto no banco em
+// lotes (CreateInBatches), sem passar pelo cadastro: nada de e-mail de
+// verificação, eventos ou webhooks. Todos os usuários gerados têm a senha
+// SeedPassword, com um único hash bcrypt para o lote inteiro. Rodar de novo
+// acrescenta mais usuários em vez de conflitar com os anteriores.
+
+const (
+	SeedPassword  = "senha-demo"
+	seedEmailHost = "seed.example.com"
+)
+
+var (
+	seedFirstNames = []string{"Ana", "Bruno", "Carla", "Daniel", "Eduarda", "Felipe", "Gabriela", "Henrique", "Isabela", "João", "Larissa", "Marcos", "Natália", "Otávio", "Paula", "Rafael", "Sofia", "Thiago", "Vitória", "Yuri"}
+	seedLastNames  = []string{"Silva", "Santos", "Oliveira", "Souza", "Lima", "Pereira", "Costa", "Rodrigues", "Almeida", "Nascimento", "Carvalho", "Ribeiro", "Gomes", "Martins", "Rocha"}
+	seedTypes      = []string{"sensor", "wearable", "phone", "sensor", "gateway"}
+	seedTypeNames  = map[string]string{"sensor": "Sensor", "wearable": "Pulseira", "phone": "Celular", "gateway": "Gateway"}
+)
+
+type SeedOptions struct {
+	Users             int
+	DevicesPerUser    int
+	ReadingsPerDevice int
+	Span              time.Duration // leituras espalhadas por esse período, até agora
+	BatchSize         int
+	// Contas fixas para a demonstração: "admin" (admin) e "demo", criadas
+	// só se ainda não existem
+	Demo bool
+	Rand *rand.Rand // nil = semente aleatória
+}
+
+type SeedResult struct {
+	Users, Devices, Readings int
+}
+
+type Seeder struct {
+	db *gorm.DB
+}
+
+func NewSeeder(db *gorm.DB) *Seeder {
+	return &Seeder{db: db}
+}
+
+func (s *Seeder) Run(ctx context.Context, opts SeedOptions) (SeedResult, error) {
+	var result SeedResult
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	if opts.Span <= 0 {
+		opts.Span = 24 * time.Hour
+	}
+	rnd := opts.Rand
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	hash, err := models.HashPassword(SeedPassword)
+	if err != nil {
+		return result, err
+	}
+	db := s.db.WithContext(ctx)
+
+	// Continua a numeração das rodadas anteriores
+	var start int64
+	if err := db.Unscoped().Model(&models.User{}).Where("email LIKE ?", "%@"+seedEmailHost).Count(&start).Error; err != nil {
+		return result, err
+	}
+	users := make([]models.User, 0, opts.Users+2)
+	for i := range opts.Users {
+		n := int(start) + i + 1
+		first, last := seedFirstNames[rnd.Intn(len(seedFirstNames))], seedLastNames[rnd.Intn(len(seedLastNames))]
+		login := fmt.Sprintf("%s.%s.%d", asciiLower(first), asciiLower(last), n)
+		users = append(users, models.User{
+			Name: first + " " + last, Email: login + "@" + seedEmailHost, User: login,
+			Password: hash, Role: models.RoleUser, TenantID: models.DefaultTenantID, EmailVerified: true,
+		})
+	}
+	if opts.Demo {
+		for _, account := range []struct{ user, role string }{{"admin", models.RoleAdmin}, {"demo", models.RoleUser}} {
+			var count int64
+			if err := db.Unscoped().Model(&models.User{}).Where(`"user" = ?`, account.user).Count(&count).Error; err != nil {
+				return result, err
+			}
+			if count == 0 {
+				users = append(users, models.User{
+					Name: "Conta " + account.user, Email: account.user + "@" + seedEmailHost, User: account.user,
+					Password: hash, Role: account.role, TenantID: models.DefaultTenantID, EmailVerified: true,
+				})
+			}
+		}
+	}
+	if len(users) > 0 {
+		if err := db.CreateInBatches(&users, opts.BatchSize).Error; err != nil {
+			return result, fmt.Errorf("usuários: %w", err)
+		}
+	}
+	result.Users = len(users)
+
+	devices := make([]models.Device, 0, len(users)*opts.DevicesPerUser)
+	for _, user := range users {
+		for range opts.DevicesPerUser {
+			_, tokenHash, err := generateDeviceToken()
+			if err != nil {
+				return result, err
+			}
+			kind := seedTypes[rnd.Intn(len(seedTypes))]
+			devices = append(devices, models.Device{
+				UserID: user.ID, TenantID: user.TenantID, Type: kind, Token: tokenHash,
+				Name: seedTypeNames[kind] + " de " + user.Name,
+			})
+		}
+	}
+	if len(devices) > 0 {
+		if err := db.CreateInBatches(&devices, opts.BatchSize).Error; err != nil {
+			return result, fmt.Errorf("dispositivos: %w", err)
+		}
+	}
+	result.Devices = len(devices)
+
+	// Um lote por vez na memória: o total pode passar de milhões de leituras
+	now := time.Now().UTC()
+	batch := make([]models.Reading, 0, opts.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := db.CreateInBatches(&batch, opts.BatchSize).Error; err != nil {
+			return fmt.Errorf("leituras: %w", err)
+		}
+		result.Readings += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	for _, device := range devices {
+		if opts.ReadingsPerDevice <= 0 {
+			break
+		}
+		step := opts.Span / time.Duration(opts.ReadingsPerDevice)
+		for i := range opts.ReadingsPerDevice {
+			at := now.Add(-opts.Span + time.Duration(i)*step)
+			metric, value := seedReading(device.Type, at, rnd)
+			batch = append(batch, models.Reading{DeviceID: device.ID, Timestamp: at, Metric: metric, Value: value})
+			if len(batch) == opts.BatchSize {
+				if err := flush(); err != nil {
+					return result, err
+				}
+			}
+		}
+	}
+	return result, flush()
+}
+
+// Métrica e valor plausíveis para o tipo do dispositivo no horário
+func seedReading(kind string, at time.Time, rnd *rand.Rand) (string, float64) {
+	// Ciclo diário: mínimo de madrugada, máximo à tarde
+	hour := float64(at.Hour()) + float64(at.Minute())/60
+	daily := math.Sin((hour - 9) / 24 * 2 * math.Pi)
+	round := func(v float64) float64 { return math.Round(v*10) / 10 }
+
+	switch kind {
+	case "sensor":
+		if rnd.Intn(2) == 0 {
+			return "humidity", round(60 - 15*daily + rnd.NormFloat64()*3)
+		}
+		return "temp", round(22 + 5*daily + rnd.NormFloat64()*0.5)
+	case "wearable":
+		return "hr", math.Round(68 + 12*math.Max(daily, 0) + rnd.NormFloat64()*5)
+	case "phone":
+		// Na tomada de madrugada; sai às 7 h e descarrega até a meia-noite
+		if hour < 7 {
+			return "battery", 100
+		}
+		return "battery", math.Round(100 - 85*(hour-7)/17)
+	default:
+		return "rssi", math.Round(-60 + rnd.NormFloat64()*4)
+	}
+}
+
+// Nome sem acento nem maiúscula, para o username e o e-mail
+func asciiLower(s string) string {
+	replacer := map[rune]rune{'á': 'a', 'ã': 'a', 'â': 'a', 'é': 'e', 'ê': 'e', 'í': 'i', 'ó': 'o', 'ô': 'o', 'õ': 'o', 'ú': 'u', 'ç': 'c'}
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		if ascii, ok := replacer[r]; ok {
+			r = ascii
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
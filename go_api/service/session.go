@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go_api/models"
+	"go_api/repository"
+)
+
+// --- Sessões (Refresh Tokens) ---
+// O service cuida do estado das sessões; assinar os JWT continua com os
+// handlers. Sem WithSessions, os refresh tokens valem só pela assinatura,
+// como antes.
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrInvalidSession  = errors.New("invalid, expired or revoked session")
+)
+
+// O user agent vem do cliente: não cresce sem limite no banco
+const maxUserAgentLen = 255
+
+type SessionPolicy struct {
+	// Validade da sessão, renovada a cada refresh (JWT_REFRESH_TTL)
+	TTL time.Duration
+}
+
+// Mesmo service, guardando as sessões em store
+func (s *UserService) WithSessions(store repository.SessionStore, policy SessionPolicy) *UserService {
+	copied := *s
+	copied.sessions = store
+	copied.session = policy
+	return &copied
+}
+
+// De onde veio o login ou o refresh
+type SessionClient struct {
+	UserAgent string
+	IP        string
+}
+
+func (s *UserService) newSession(userID uint, client SessionClient, now time.Time) (models.Session, string, error) {
+	jti, hash, err := newEmailToken()
+	if err != nil {
+		return models.Session{}, "", err
+	}
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLen {
+		userAgent = userAgent[:maxUserAgentLen]
+	}
+	return models.Session{
+		UserID:     userID,
+		TokenHash:  hash,
+		UserAgent:  userAgent,
+		IP:         client.IP,
+		ExpiresAt:  now.Add(s.session.TTL),
+		LastUsedAt: now,
+	}, jti, nil
+}
+
+// Abre a sessão do login e devolve o jti do primeiro refresh token
+func (s *UserService) StartSession(ctx context.Context, userID uint, client SessionClient) (models.Session, string, error) {
+	if s.sessions == nil {
+		return models.Session{}, "", nil
+	}
+	session, jti, err := s.newSession(userID, client, time.Now())
+	if err != nil {
+		return session, "", err
+	}
+	return session, jti, s.sessions.Create(ctx, &session)
+}
+
+// POST /refresh: confere o jti do token apresentado e troca por um novo.
+// Token sem sessão (id 0, emitido antes das sessões), sessão encerrada ou
+// token reaproveitado: ErrInvalidSession.
+func (s *UserService) RefreshSession(ctx context.Context, userID, id uint, jti string, client SessionClient) (models.Session, string, error) {
+	if s.sessions == nil {
+		return models.Session{}, "", nil
+	}
+	if id == 0 || jti == "" {
+		return models.Session{}, "", ErrInvalidSession
+	}
+	now := time.Now()
+	session, next, err := s.newSession(userID, client, now)
+	if err != nil {
+		return session, "", err
+	}
+	session.ID = id
+	err = s.sessions.Rotate(ctx, &session, hashEmailToken(jti), now)
+	switch {
+	case errors.Is(err, repository.ErrTokenReused):
+		slog.WarnContext(ctx, "refresh token reaproveitado, sessão encerrada", "user_id", userID, "session_id", id)
+		return session, "", ErrInvalidSession
+	case errors.Is(err, repository.ErrNotFound):
+		return session, "", ErrInvalidSession
+	case err != nil:
+		return session, "", err
+	}
+	return session, next, nil
+}
+
+func (s *UserService) ListSessions(ctx context.Context, userID uint) ([]models.Session, error) {
+	if s.sessions == nil {
+		return []models.Session{}, nil
+	}
+	return s.sessions.ListActive(ctx, userID, time.Now())
+}
+
+// O refresh token da sessão para de valer; o access token já emitido vale
+// até expirar (JWT_ACCESS_TTL)
+func (s *UserService) RevokeSession(ctx context.Context, userID, id uint) error {
+	if s.sessions == nil {
+		return ErrSessionNotFound
+	}
+	err := s.sessions.Revoke(ctx, userID, id, time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrSessionNotFound
+	}
+	return err
+}
+
+// Troca de senha: todos os aparelhos precisam fazer login de novo
+func (s *UserService) revokeSessions(ctx context.Context, userID uint) error {
+	if s.sessions == nil {
+		return nil
+	}
+	return s.sessions.RevokeAll(ctx, userID, time.Now())
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Estatísticas do Tenant (GET /admin/stats) ---
+// Só contagens agregadas no banco (COUNT/GROUP BY), nunca as linhas. Os
+// novos por dia vêm dos eventos UserRegistered, não do created_at: contam
+// também as contas já removidas, e nas anteriores à coluna o created_at é
+// só a estimativa da migração (pela auditoria).
+
+const MaxStatsDays = 365
+
+type DailyCount struct {
+	Date  string `json:"date"` // AAAA-MM-DD, em UTC
+	Count int64  `json:"count"`
+}
+
+type UserStats struct {
+	Total     int64            `json:"total"`
+	Deleted   int64            `json:"deleted"`
+	ByRole    map[string]int64 `json:"by_role"`
+	NewPerDay []DailyCount     `json:"new_per_day"` // do mais antigo para hoje, com os dias sem cadastro
+}
+
+type DeviceStats struct {
+	Total  int64            `json:"total"`
+	Online int64            `json:"online"` // last_seen há menos de PRESENCE_TIMEOUT
+	ByType map[string]int64 `json:"by_type"`
+}
+
+type Stats struct {
+	Users   UserStats   `json:"users"`
+	Devices DeviceStats `json:"devices"`
+}
+
+type StatsService struct {
+	db       *gorm.DB
+	presence *PresenceHub
+}
+
+func NewStatsService(db *gorm.DB, presence *PresenceHub) *StatsService {
+	return &StatsService{db: db, presence: presence}
+}
+
+type groupCount struct {
+	Name  string
+	Count int64
+}
+
+func countBy(query *gorm.DB, column string) (map[string]int64, error) {
+	var rows []groupCount
+	if err := query.Select(column + " AS name, COUNT(*) AS count").Group(column).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		counts[r.Name] = r.Count
+	}
+	return counts, nil
+}
+
+// Números do tenant do contexto; days = dias do NewPerDay, contando hoje
+func (s *StatsService) Get(ctx context.Context, days int) (Stats, error) {
+	db := s.db.WithContext(ctx)
+	now := time.Now().UTC()
+	var stats Stats
+	var err error
+
+	if err = db.Model(&models.User{}).Count(&stats.Users.Total).Error; err != nil {
+		return stats, err
+	}
+	err = db.Unscoped().Model(&models.User{}).Where("deleted_at IS NOT NULL").Count(&stats.Users.Deleted).Error
+	if err != nil {
+		return stats, err
+	}
+	if stats.Users.ByRole, err = countBy(db.Model(&models.User{}), "role"); err != nil {
+		return stats, err
+	}
+
+	first := now.Truncate(24*time.Hour).AddDate(0, 0, -(days - 1))
+	var perDay []struct {
+		Slot  int64
+		Count int64
+	}
+	err = db.Model(&models.UserEvent{}).
+		Select(bucketExpr(db, "created_at", 24*time.Hour)+" AS slot, COUNT(*) AS count").
+		Where("type = ? AND created_at >= ?", models.EventUserRegistered, first).
+		Group("slot").Scan(&perDay).Error
+	if err != nil {
+		return stats, err
+	}
+	registered := make(map[int64]int64, len(perDay))
+	for _, d := range perDay {
+		registered[d.Slot] = d.Count
+	}
+	stats.Users.NewPerDay = make([]DailyCount, 0, days)
+	for day := first; !day.After(now); day = day.AddDate(0, 0, 1) {
+		stats.Users.NewPerDay = append(stats.Users.NewPerDay, DailyCount{Date: day.Format(time.DateOnly), Count: registered[day.Unix()]})
+	}
+
+	if err = db.Model(&models.Device{}).Count(&stats.Devices.Total).Error; err != nil {
+		return stats, err
+	}
+	err = db.Model(&models.Device{}).Where("last_seen >= ?", now.Add(-s.presence.timeout)).Count(&stats.Devices.Online).Error
+	if err != nil {
+		return stats, err
+	}
+	stats.Devices.ByType, err = countBy(db.Model(&models.Device{}), "type")
+	return stats, err
+}
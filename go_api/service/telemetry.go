@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Ingestão de Telemetria ---
+// Caminho único das leituras, venha o corpo do POST /devices/:id/readings
+// ou de uma mensagem MQTT: valida, grava em bloco e marca o dispositivo
+// como visto (last_seen + presença).
+
+const (
+	MaxReadingsPerMessage = 5000
+	readingsBatchSize     = 500
+)
+
+// Corpo inválido; Index aponta a leitura com problema (-1 = o corpo todo)
+type ReadingsError struct {
+	Index   int
+	Message string
+}
+
+func (e *ReadingsError) Error() string {
+	if e.Index < 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("reading %d: %s", e.Index, e.Message)
+}
+
+type Telemetry struct {
+	db       *gorm.DB
+	presence *PresenceHub
+
+	// Ouvintes das leituras novas de cada dispositivo (SSE)
+	mu        sync.Mutex
+	listeners map[uint]map[chan struct{}]struct{}
+	closed    bool
+
+	// nil = grava em cada requisição (ver telemetry_buffer.go)
+	buffer *readingBuffer
+}
+
+func NewTelemetry(db *gorm.DB, presence *PresenceHub) *Telemetry {
+	return &Telemetry{db: db, presence: presence, listeners: make(map[uint]map[chan struct{}]struct{})}
+}
+
+// Aceita uma leitura ({...}) ou várias ([{...}, ...]) e devolve quantas gravou
+func (t *Telemetry) Ingest(ctx context.Context, device models.Device, body []byte) (int, error) {
+	var inputs []models.ReadingInput
+	var err error
+	if len(body) > 0 && body[0] == '[' {
+		err = json.Unmarshal(body, &inputs)
+	} else {
+		var single models.ReadingInput
+		err = json.Unmarshal(body, &single)
+		inputs = []models.ReadingInput{single}
+	}
+	if err != nil {
+		return 0, &ReadingsError{Index: -1, Message: "Invalid JSON: " + err.Error()}
+	}
+	return t.IngestInputs(ctx, device, inputs)
+}
+
+// Leituras já decodificadas (ex: gRPC)
+func (t *Telemetry) IngestInputs(ctx context.Context, device models.Device, inputs []models.ReadingInput) (int, error) {
+	if len(inputs) == 0 || len(inputs) > MaxReadingsPerMessage {
+		return 0, &ReadingsError{Index: -1, Message: fmt.Sprintf("A request must contain between 1 and %d readings", MaxReadingsPerMessage)}
+	}
+
+	now := time.Now().UTC()
+	readings := make([]models.Reading, 0, len(inputs))
+	for i, in := range inputs {
+		if in.Metric == "" {
+			return 0, &ReadingsError{Index: i, Message: "metric is required"}
+		}
+		ts := in.Timestamp.UTC()
+		if in.Timestamp.IsZero() {
+			ts = now
+		}
+		readings = append(readings, models.Reading{
+			DeviceID:  device.ID,
+			Timestamp: ts,
+			Metric:    in.Metric,
+			Value:     in.Value,
+			Payload:   in.Payload,
+		})
+	}
+
+	// Com o buffer ligado, a gravação fica para o próximo flush
+	if b := t.bufferFor(); b != nil {
+		err := b.add(device, readings)
+		if err == nil {
+			t.Seen(ctx, &device)
+			return len(readings), nil
+		}
+		if !errors.Is(err, errBufferStopped) {
+			return 0, err
+		}
+	}
+
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(&readings, readingsBatchSize).Error; err != nil {
+			return err
+		}
+		return enqueueOutbox(tx, readingsIngestedEvent(device, readings))
+	})
+	if err != nil {
+		return 0, err
+	}
+	t.notify(device.ID)
+	t.Seen(ctx, &device)
+	return len(readings), nil
+}
+
+// Atualiza o last_seen e avisa os painéis (/ws)
+func (t *Telemetry) Seen(ctx context.Context, device *models.Device) {
+	now := time.Now().UTC()
+	t.presence.touch(t.db.WithContext(ctx), device, now, func(q *gorm.DB) *gorm.DB { return q })
+	t.presence.Seen(*device, now)
+}
+
+// --- Aviso de Leituras Novas ---
+// Como o EventHub, só um sinal ("tem leitura nova") e só na réplica: quem
+// escuta busca as leituras no banco a partir do próprio cursor, então não
+// perde nada com o buffer cheio nem com as leituras que chegam por outra
+// réplica (basta reconsultar de tempos em tempos).
+
+// Inscreve um ouvinte das leituras do dispositivo. O canal é fechado no
+// Close (encerramento da réplica).
+func (t *Telemetry) Subscribe(deviceID uint) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if t.listeners[deviceID] == nil {
+		t.listeners[deviceID] = make(map[chan struct{}]struct{})
+	}
+	t.listeners[deviceID][ch] = struct{}{}
+
+	return ch, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if _, ok := t.listeners[deviceID][ch]; ok {
+			delete(t.listeners[deviceID], ch)
+			if len(t.listeners[deviceID]) == 0 {
+				delete(t.listeners, deviceID)
+			}
+			close(ch)
+		}
+	}
+}
+
+func (t *Telemetry) notify(deviceID uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for ch := range t.listeners[deviceID] {
+		select {
+		case ch <- struct{}{}:
+		default: // já tem um aviso pendente
+		}
+	}
+}
+
+// Fecha os canais dos ouvintes: as conexões abertas terminam e os clientes
+// reconectam em outra réplica
+func (t *Telemetry) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	for _, chans := range t.listeners {
+		for ch := range chans {
+			close(ch)
+		}
+	}
+	t.listeners = nil
+}
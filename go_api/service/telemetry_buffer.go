@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Buffer de Ingestão ---
+// Com centenas de sensores mandando uma leitura por segundo, cada POST vira
+// uma transação com um INSERT de uma linha. Com READINGS_BUFFER_INTERVAL, as
+// leituras validadas ficam em memória e são gravadas juntas (CreateInBatches,
+// numa transação só, com um evento reading.ingested por dispositivo) a cada
+// Interval ou assim que juntam Size linhas. O last_seen e a presença
+// continuam na hora; o aviso do SSE sai depois da gravação.
+//
+// O preço: o que está no buffer se perde se a réplica morrer sem o
+// encerramento gracioso (no SIGTERM tudo é gravado) e o cliente recebe 202
+// antes de a leitura estar no banco. Se a gravação falha, as leituras voltam
+// para o buffer; cheio (10x Size), a ingestão responde 503.
+
+var ErrReadingsBufferFull = errors.New("readings buffer is full")
+
+// Chegou depois da última gravação do encerramento: grava direto
+var errBufferStopped = errors.New("readings buffer stopped")
+
+type ReadingBufferPolicy struct {
+	Interval time.Duration // 0 = sem buffer (grava em cada requisição)
+	Size     int           // linhas que disparam a gravação antes do Interval
+}
+
+type pendingReadings struct {
+	device   models.Device
+	readings []models.Reading
+}
+
+type readingBuffer struct {
+	policy ReadingBufferPolicy
+
+	mu      sync.Mutex
+	pending map[uint]*pendingReadings
+	rows    int
+	stopped bool
+
+	full chan struct{} // chegou a Size linhas
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Liga o buffer (uma vez, na montagem dos handlers); StopBuffer grava o que
+// sobrou
+func (t *Telemetry) StartBuffer(policy ReadingBufferPolicy) {
+	if policy.Interval <= 0 || policy.Size <= 0 {
+		return
+	}
+	b := &readingBuffer{
+		policy:  policy,
+		pending: map[uint]*pendingReadings{},
+		full:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	t.mu.Lock()
+	t.buffer = b
+	t.mu.Unlock()
+	go t.runBuffer(b)
+}
+
+// Encerramento: para o timer e grava o que está no buffer. Depois disso a
+// ingestão volta a gravar direto.
+func (t *Telemetry) StopBuffer(ctx context.Context) {
+	t.mu.Lock()
+	b := t.buffer
+	t.buffer = nil
+	t.mu.Unlock()
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.stopped = true
+	b.mu.Unlock()
+	close(b.stop)
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		slog.Error("leituras do buffer não gravadas no encerramento", "rows", b.size(), "error", ctx.Err())
+	}
+}
+
+func (t *Telemetry) bufferFor() *readingBuffer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.buffer
+}
+
+// A ingestão passa pelo buffer? (o handler responde 202 em vez de 201)
+func (t *Telemetry) Buffered() bool {
+	return t.bufferFor() != nil
+}
+
+func (b *readingBuffer) size() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rows
+}
+
+func (b *readingBuffer) add(device models.Device, readings []models.Reading) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped {
+		return errBufferStopped
+	}
+	if b.rows+len(readings) > 10*b.policy.Size {
+		return ErrReadingsBufferFull
+	}
+	b.put(device, readings)
+	if b.rows >= b.policy.Size {
+		select {
+		case b.full <- struct{}{}:
+		default: // a gravação já foi pedida
+		}
+	}
+	return nil
+}
+
+func (b *readingBuffer) put(device models.Device, readings []models.Reading) {
+	p, ok := b.pending[device.ID]
+	if !ok {
+		p = &pendingReadings{device: device}
+		b.pending[device.ID] = p
+	}
+	p.readings = append(p.readings, readings...)
+	b.rows += len(readings)
+}
+
+func (t *Telemetry) runBuffer(b *readingBuffer) {
+	defer close(b.done)
+	ticker := time.NewTicker(b.policy.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-b.full:
+		case <-b.stop:
+			t.flush(b)
+			if rows := b.size(); rows > 0 {
+				slog.Error("leituras do buffer descartadas no encerramento", "rows", rows)
+			}
+			return
+		}
+		t.flush(b)
+	}
+}
+
+// Grava tudo o que está no buffer numa transação
+func (t *Telemetry) flush(b *readingBuffer) {
+	b.mu.Lock()
+	pending, rows := b.pending, b.rows
+	b.pending, b.rows = map[uint]*pendingReadings{}, 0
+	b.mu.Unlock()
+	if rows == 0 {
+		return
+	}
+
+	readings := make([]models.Reading, 0, rows)
+	for _, p := range pending {
+		readings = append(readings, p.readings...)
+	}
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(&readings, readingsBatchSize).Error; err != nil {
+			return err
+		}
+		for _, p := range pending {
+			if err := enqueueOutbox(tx, readingsIngestedEvent(p.device, p.readings)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.requeue(b, pending, rows, err)
+		return
+	}
+	for id := range pending {
+		t.notify(id)
+	}
+}
+
+// Gravação que falhou: as leituras voltam para a próxima, se couberem
+func (t *Telemetry) requeue(b *readingBuffer, pending map[uint]*pendingReadings, rows int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rows+rows > 10*b.policy.Size {
+		slog.Error("leituras do buffer descartadas", "rows", rows, "error", err)
+		return
+	}
+	slog.Warn("gravação do buffer de leituras falhou, tentando de novo", "rows", rows, "error", err)
+	for _, p := range pending {
+		b.put(p.device, p.readings)
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Tenants ---
+// Cadastro das organizações (só o admin da plataforma, isto é, um admin do
+// tenant padrão). O isolamento em si fica no repository.ScopeTenants.
+
+var (
+	ErrTenantNotFound  = errors.New("tenant not found")
+	ErrTenantSlugTaken = errors.New("tenant slug already in use")
+)
+
+type TenantService struct {
+	db *gorm.DB
+}
+
+func NewTenantService(db *gorm.DB) *TenantService {
+	return &TenantService{db: db}
+}
+
+func (s *TenantService) Create(ctx context.Context, input models.TenantInput) (models.Tenant, error) {
+	tenant := models.Tenant{Slug: input.Slug, Name: input.Name}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Tenant{}).Where("slug = ?", input.Slug).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrTenantSlugTaken
+		}
+		return tx.Create(&tenant).Error
+	})
+	return tenant, err
+}
+
+func (s *TenantService) List(ctx context.Context) ([]models.Tenant, error) {
+	var tenants []models.Tenant
+	err := s.db.WithContext(ctx).Order("id").Find(&tenants).Error
+	return tenants, err
+}
+
+// Tenant pelo slug do X-Tenant ou do subdomínio
+func (s *TenantService) BySlug(ctx context.Context, slug string) (models.Tenant, error) {
+	var tenant models.Tenant
+	err := s.db.WithContext(ctx).Where("slug = ?", slug).First(&tenant).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tenant, ErrTenantNotFound
+	}
+	return tenant, err
+}
@@ -0,0 +1,429 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"go_api/mailer"
+	"go_api/models"
+	"go_api/repository"
+
+	"gorm.io/gorm"
+)
+
+// --- Regras de Negócio dos Usuários ---
+// Cadastro, edição, login e remoção dos usuários ficam aqui; o que toca a
+// tabela users passa pelo repository.UserStore.
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrUserConflict       = errors.New("user or email already exists")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrWrongPassword      = errors.New("current password is incorrect")
+	ErrInvalidRole        = errors.New("invalid role")
+	ErrUserNotDeleted     = errors.New("user is not deleted")
+	ErrUserAnonymized     = errors.New("user was erased and cannot be restored")
+)
+
+// Conflito num campo específico; errors.Is(err, ErrUserConflict) continua valendo
+type ConflictError struct {
+	Field string // "email" ou "user"
+}
+
+func (e *ConflictError) Error() string {
+	return e.Field + " already exists"
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrUserConflict
+}
+
+// A revisão do usuário não é mais a que o cliente leu (If-Match ou outra
+// escrita no meio do caminho). Current é o estado atual, para o cliente
+// refazer a alteração em cima dele.
+type RevisionConflictError struct {
+	Current models.User
+}
+
+func (e *RevisionConflictError) Error() string {
+	return "user was modified by another request"
+}
+
+func (e *RevisionConflictError) Is(target error) bool {
+	return target == repository.ErrStaleRevision
+}
+
+// Troca o ErrStaleRevision do repositório pelo conflito com o estado atual
+func (s *UserService) staleRevision(ctx context.Context, id uint, err error) error {
+	if !errors.Is(err, repository.ErrStaleRevision) {
+		return err
+	}
+	current, findErr := s.Get(ctx, id)
+	if findErr != nil {
+		return findErr
+	}
+	return &RevisionConflictError{Current: current}
+}
+
+// Lock otimista pedido pelo cliente (If-Match); 0 aceita qualquer revisão
+func checkRevision(user models.User, ifRevision uint) error {
+	if ifRevision != 0 && user.Revision != ifRevision {
+		return &RevisionConflictError{Current: user}
+	}
+	return nil
+}
+
+// Traduz a violação de índice único do banco para o conflito do campo
+func conflict(err error) error {
+	var dup *repository.DuplicateError
+	if errors.As(err, &dup) {
+		return &ConflictError{Field: dup.Field}
+	}
+	return err
+}
+
+// Verifica antes de gravar, para responder qual campo conflita. O índice
+// único continua sendo a garantia final (duas requisições ao mesmo tempo
+// passam pela verificação, mas só uma pelo índice).
+func (s *UserService) checkUnique(ctx context.Context, exceptID uint, email, username string) error {
+	for _, f := range []struct{ field, value string }{{"email", email}, {"user", username}} {
+		if f.value == "" {
+			continue
+		}
+		taken, err := s.store.Taken(ctx, f.field, f.value, exceptID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return &ConflictError{Field: f.field}
+		}
+	}
+	return nil
+}
+
+type UserService struct {
+	store repository.UserStore
+
+	// Opcionais (WithLockout): tentativas de login e bloqueio de conta
+	attempts repository.LoginAttemptStore
+	lockout  LockoutPolicy
+
+	// Opcionais: e-mails (WithMailer), redefinição de senha
+	// (WithPasswordReset) e verificação de e-mail (WithEmailVerification)
+	mailer       mailer.Mailer
+	resetTokens  repository.PasswordResetStore
+	reset        PasswordResetPolicy
+	verifyTokens repository.EmailVerificationStore
+	verify       EmailVerificationPolicy
+
+	// Opcionais (WithSessions): sessões dos refresh tokens
+	sessions repository.SessionStore
+	session  SessionPolicy
+
+	// Opcional (WithIdentities): contas do login social
+	identities repository.IdentityStore
+}
+
+func NewUserService(store repository.UserStore) *UserService {
+	return &UserService{store: store}
+}
+
+// Mesmo service dentro de uma transação já aberta: todos os repositórios
+// passam a gravar pelo tx (usado no sync e no InTx)
+func (s *UserService) WithTx(tx *gorm.DB) *UserService {
+	copied := *s
+	copied.store = s.store.WithTx(tx)
+	if s.attempts != nil {
+		copied.attempts = s.attempts.WithTx(tx)
+	}
+	if s.resetTokens != nil {
+		copied.resetTokens = s.resetTokens.WithTx(tx)
+	}
+	if s.verifyTokens != nil {
+		copied.verifyTokens = s.verifyTokens.WithTx(tx)
+	}
+	if s.sessions != nil {
+		copied.sessions = s.sessions.WithTx(tx)
+	}
+	if s.identities != nil {
+		copied.identities = s.identities.WithTx(tx)
+	}
+	return &copied
+}
+
+// Unidade de trabalho: fn recebe o service numa transação e as escritas
+// de todos os passos valem juntas ou nenhuma vale (erro ou pânico em fn
+// desfaz tudo). E-mails ficam fora: quem chama envia depois do commit.
+func (s *UserService) InTx(ctx context.Context, fn func(users *UserService) error) error {
+	return s.store.Transaction(ctx, func(tx *gorm.DB) error {
+		return fn(s.WithTx(tx))
+	})
+}
+
+func (s *UserService) Get(ctx context.Context, id uint) (models.User, error) {
+	user, err := s.store.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return user, ErrUserNotFound
+	}
+	return user, err
+}
+
+// Inclui os usuários removidos (soft delete); só para admin
+func (s *UserService) GetAny(ctx context.Context, id uint) (models.User, error) {
+	user, err := s.store.FindAnyByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return user, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (s *UserService) List(ctx context.Context, q repository.UserQuery) ([]models.User, int64, error) {
+	return s.store.List(ctx, q)
+}
+
+func (s *UserService) Search(ctx context.Context, term string, limit, offset int) ([]models.User, error) {
+	return s.store.Search(ctx, term, limit, offset)
+}
+
+// Exportação: fn recebe um usuário por vez, direto do banco
+func (s *UserService) Each(ctx context.Context, q repository.UserQuery, fn func(models.User) error) error {
+	return s.store.Each(ctx, q, fn)
+}
+
+// Cadastro público: todo usuário novo começa com o papel "user". A região
+// já chega validada pelo handler.
+func (s *UserService) Register(ctx context.Context, input models.CreateUserInput) (models.User, error) {
+	user := models.User{
+		Name:     input.Name,
+		Email:    input.Email,
+		User:     input.User,
+		Password: input.Password,
+		Region:   input.Region,
+		Role:     models.RoleUser,
+	}
+	if err := s.checkUnique(ctx, 0, input.Email, input.User); err != nil {
+		return user, err
+	}
+	if err := conflict(s.store.Create(ctx, &user)); err != nil {
+		return user, err
+	}
+	s.notifyVerification(ctx, user)
+	return user, nil
+}
+
+// Administrador criado pela linha de comando (./server admin create-admin):
+// já nasce admin e com o e-mail verificado, sem o link de verificação
+func (s *UserService) CreateAdmin(ctx context.Context, input models.CreateUserInput) (models.User, error) {
+	user := models.User{
+		Name:          input.Name,
+		Email:         input.Email,
+		User:          input.User,
+		Password:      input.Password,
+		Region:        input.Region,
+		Role:          models.RoleAdmin,
+		EmailVerified: true,
+	}
+	if err := s.checkUnique(ctx, 0, input.Email, input.User); err != nil {
+		return user, err
+	}
+	return user, conflict(s.store.Create(ctx, &user))
+}
+
+// Username ou e-mail (linha de comando)
+func (s *UserService) GetByLogin(ctx context.Context, login string) (models.User, error) {
+	user, err := s.store.FindByLogin(ctx, login)
+	if errors.Is(err, repository.ErrNotFound) {
+		return user, ErrUserNotFound
+	}
+	return user, err
+}
+
+// Mesma resposta para usuário inexistente e senha errada. Com WithLockout,
+// toda tentativa é registrada e uma conta bloqueada devolve
+// *AccountLockedError sem verificar a senha.
+func (s *UserService) Authenticate(ctx context.Context, login, password, ip string) (models.User, error) {
+	user, err := s.store.FindByLogin(ctx, login)
+	if errors.Is(err, repository.ErrNotFound) {
+		if err := s.recordAttempt(ctx, login, ip, nil, false); err != nil {
+			return models.User{}, err
+		}
+		return models.User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if err := s.checkLockout(ctx, user.ID); err != nil {
+		return models.User{}, err
+	}
+
+	ok := user.ComparePassword(password)
+	if err := s.recordAttempt(ctx, login, ip, &user, ok); err != nil {
+		return models.User{}, err
+	}
+	if ok {
+		if s.verify.Required && !user.EmailVerified {
+			return models.User{}, ErrEmailNotVerified
+		}
+		return user, nil
+	}
+
+	// A falha que atinge o limite já responde com o bloqueio
+	if err := s.checkLockout(ctx, user.ID); err != nil {
+		return models.User{}, err
+	}
+	return models.User{}, ErrInvalidCredentials
+}
+
+// ifRevision != 0 só aplica se o usuário ainda estiver nessa revisão
+func (s *UserService) Update(ctx context.Context, id uint, input models.UpdateUserInput, ifRevision uint) (models.User, error) {
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return user, err
+	}
+	if err := checkRevision(user, ifRevision); err != nil {
+		return user, err
+	}
+	return user, s.applyAndNotify(ctx, &user, input)
+}
+
+// Apply fora do sync: um e-mail novo recebe o link de verificação (o push
+// do sync roda em transação e chama NotifyEmailChanged depois do commit)
+func (s *UserService) applyAndNotify(ctx context.Context, user *models.User, input models.UpdateUserInput) error {
+	previous := user.Email
+	if err := s.Apply(ctx, user, input); err != nil {
+		return err
+	}
+	if user.Email != previous {
+		s.notifyVerification(ctx, *user)
+	}
+	return nil
+}
+
+// Link de verificação para o e-mail novo gravado por um Apply em transação
+func (s *UserService) NotifyEmailChanged(ctx context.Context, user models.User) {
+	s.notifyVerification(ctx, user)
+}
+
+// Atualiza a projeção, grava os eventos e incrementa a revisão.
+// Compartilhado entre o PUT e o push do sync. Papel, região e senha ficam de
+// fora: cada um tem sua própria rota.
+func (s *UserService) Apply(ctx context.Context, user *models.User, input models.UpdateUserInput) error {
+	changes := map[string]interface{}{}
+	if input.Name != "" {
+		changes["name"] = input.Name
+	}
+	if input.Email != "" {
+		changes["email"] = input.Email
+		if input.Email != user.Email {
+			changes["email_verified"] = false
+		}
+	}
+	if input.User != "" {
+		changes["user"] = input.User
+	}
+	if err := s.checkUnique(ctx, user.ID, input.Email, input.User); err != nil {
+		return err
+	}
+	err := conflict(s.store.Update(ctx, user, changes, models.UserChangeEvents(*user, input)...))
+	return s.staleRevision(ctx, user.ID, err)
+}
+
+// Só os campos presentes mudam; os vazios já foram barrados na validação,
+// então a conversão para UpdateUserInput (que ignora vazios) não perde nada.
+func (s *UserService) Patch(ctx context.Context, id uint, input models.PatchUserInput, ifRevision uint) (models.User, error) {
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return user, err
+	}
+	if err := checkRevision(user, ifRevision); err != nil {
+		return user, err
+	}
+	var changes models.UpdateUserInput
+	if input.Name != nil {
+		changes.Name = *input.Name
+	}
+	if input.Email != nil {
+		changes.Email = *input.Email
+	}
+	if input.User != nil {
+		changes.User = *input.User
+	}
+	return user, s.applyAndNotify(ctx, &user, changes)
+}
+
+// Quem troca a própria senha (actorID == id) precisa confirmar a atual; um
+// admin pode redefinir a senha de outro usuário sem ela. Em qualquer caso,
+// as sessões abertas são encerradas.
+func (s *UserService) ChangePassword(ctx context.Context, id, actorID uint, input models.ChangePasswordInput) error {
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.ID == actorID && !user.ComparePassword(input.CurrentPassword) {
+		return ErrWrongPassword
+	}
+	hash, err := models.HashPassword(input.NewPassword)
+	if err != nil {
+		return err
+	}
+	// Senha nova e sessões encerradas juntas: nunca uma sem a outra
+	event := models.NewUserEvent(user.ID, models.EventPasswordChanged, models.UserEventData{})
+	return s.InTx(ctx, func(users *UserService) error {
+		if err := users.store.Update(ctx, &user, map[string]interface{}{"password": hash}, event); err != nil {
+			return err
+		}
+		return users.revokeSessions(ctx, user.ID)
+	})
+}
+
+func (s *UserService) ChangeRole(ctx context.Context, id uint, role string) (models.User, error) {
+	if !models.ValidRoles[role] {
+		return models.User{}, ErrInvalidRole
+	}
+	user, err := s.Get(ctx, id)
+	if err != nil || user.Role == role {
+		return user, err
+	}
+	event := models.NewUserEvent(user.ID, models.EventRoleChanged, models.UserEventData{Role: role})
+	return user, s.store.Update(ctx, &user, map[string]interface{}{"role": role}, event)
+}
+
+// avatar_url já gravado no armazenamento (AvatarService.Upload)
+func (s *UserService) SetAvatar(ctx context.Context, id uint, url string) (models.User, error) {
+	user, err := s.Get(ctx, id)
+	if err != nil || user.AvatarURL == url {
+		return user, err
+	}
+	event := models.NewUserEvent(user.ID, models.EventAvatarChanged, models.UserEventData{AvatarURL: url})
+	return user, s.store.Update(ctx, &user, map[string]interface{}{"avatar_url": url}, event)
+}
+
+func (s *UserService) Delete(ctx context.Context, id uint) error {
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.store.Delete(ctx, &user)
+}
+
+// Desfaz um soft delete
+func (s *UserService) Restore(ctx context.Context, id uint) (models.User, error) {
+	user, err := s.GetAny(ctx, id)
+	if err != nil {
+		return user, err
+	}
+	if !user.DeletedAt.Valid {
+		return user, ErrUserNotDeleted
+	}
+	// Anonimizada: os dados já foram apagados, não há o que restaurar
+	if user.AnonymizedAt != nil {
+		return user, ErrUserAnonymized
+	}
+	return user, s.store.Restore(ctx, &user)
+}
+
+// Remove um usuário já carregado (usado no sync)
+func (s *UserService) Remove(ctx context.Context, user *models.User) error {
+	return s.store.Delete(ctx, user)
+}
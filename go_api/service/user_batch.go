@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"go_api/models"
+)
+
+// --- Cadastro em Lote ---
+// Matrículas chegam aos milhares de uma vez (script da secretaria). Os
+// conflitos são verificados com uma consulta por coluna para o lote todo,
+// e os hashes bcrypt (a parte cara do cadastro) são calculados em paralelo
+// antes das inserções.
+
+const MaxBatchUsers = 1000
+
+// Resultado de um item, na mesma posição da entrada. Err é nil (criado),
+// *ConflictError ou outro erro do banco; Skipped marca os itens que não foram
+// tentados porque o lote atômico parou antes.
+type BatchResult struct {
+	User    models.User
+	Err     error
+	Skipped bool
+}
+
+// Cadastra vários usuários como no Register (papel "user", região já
+// validada). Com atomic, nenhum item é gravado se algum conflitar, e a
+// gravação para no primeiro erro: quem chamou roda numa transação (WithTx) e
+// a desfaz. O e-mail de verificação não sai daqui: chame NotifyRegistered
+// depois do commit.
+func (s *UserService) RegisterBatch(ctx context.Context, inputs []models.CreateUserInput, atomic bool) ([]BatchResult, error) {
+	results := make([]BatchResult, len(inputs))
+	for i, in := range inputs {
+		results[i].User = models.User{
+			Name:     in.Name,
+			Email:    in.Email,
+			User:     in.User,
+			Password: in.Password,
+			Region:   in.Region,
+			Role:     models.RoleUser,
+		}
+	}
+	conflicts, err := s.batchConflicts(ctx, inputs, results)
+	if err != nil {
+		return nil, err
+	}
+	if atomic && conflicts {
+		for i := range results {
+			results[i].Skipped = results[i].Err == nil
+		}
+		return results, nil
+	}
+
+	if err := hashBatchPasswords(results); err != nil {
+		return nil, err
+	}
+	failed := false
+	for i := range results {
+		r := &results[i]
+		if r.Err != nil {
+			continue
+		}
+		if failed {
+			r.Skipped = true
+			continue
+		}
+		r.Err = conflict(s.store.Create(ctx, &r.User))
+		failed = atomic && r.Err != nil
+	}
+	return results, nil
+}
+
+// Marca os conflitos com o banco e com itens anteriores do próprio lote
+func (s *UserService) batchConflicts(ctx context.Context, inputs []models.CreateUserInput, results []BatchResult) (bool, error) {
+	emails := make([]string, len(inputs))
+	usernames := make([]string, len(inputs))
+	for i, in := range inputs {
+		emails[i], usernames[i] = in.Email, in.User
+	}
+	takenEmails, err := s.store.TakenValues(ctx, "email", emails)
+	if err != nil {
+		return false, err
+	}
+	takenUsers, err := s.store.TakenValues(ctx, "user", usernames)
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	for i, in := range inputs {
+		switch {
+		case takenEmails[in.Email]:
+			results[i].Err = &ConflictError{Field: "email"}
+		case takenUsers[in.User]:
+			results[i].Err = &ConflictError{Field: "user"}
+		default:
+			takenEmails[in.Email], takenUsers[in.User] = true, true
+			continue
+		}
+		found = true
+	}
+	return found, nil
+}
+
+// Um hash por núcleo ao mesmo tempo; o hook BeforeSave pula senhas que já
+// são hash
+func hashBatchPasswords(results []BatchResult) error {
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	sem := make(chan struct{}, runtime.NumCPU())
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(user *models.User) {
+			defer func() { <-sem; wg.Done() }()
+			hash, err := models.HashPassword(user.Password)
+			if err != nil {
+				once.Do(func() { firstErr = err })
+				return
+			}
+			user.Password = hash
+		}(&results[i].User)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// Envia a verificação de e-mail de cada usuário criado
+func (s *UserService) NotifyRegistered(ctx context.Context, users ...models.User) {
+	for _, user := range users {
+		s.notifyVerification(ctx, user)
+	}
+}
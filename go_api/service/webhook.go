@@ -0,0 +1,332 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Webhooks ---
+// Outbox transacional: o callback registrado por WatchWebhooks grava as
+// entregas na mesma transação dos UserEvents, então nenhuma alteração
+// confirmada fica sem aviso (e nada é avisado se a transação for desfeita).
+// O job "webhooks.deliver" faz os POSTs; a tentativa é "reservada" com
+// um UPDATE condicional, para duas réplicas não entregarem a mesma.
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// Evento de webhook de cada tipo de UserEvent. A troca de senha não é
+// anunciada: não muda nada que outro serviço precise saber.
+var webhookEvents = map[string]string{
+	models.EventUserRegistered:  models.WebhookUserCreated,
+	models.EventNameChanged:     models.WebhookUserUpdated,
+	models.EventEmailChanged:    models.WebhookUserUpdated,
+	models.EventUsernameChanged: models.WebhookUserUpdated,
+	models.EventRoleChanged:     models.WebhookUserUpdated,
+	models.EventEmailVerified:   models.WebhookUserUpdated,
+	models.EventAvatarChanged:   models.WebhookUserUpdated,
+	models.EventUserRestored:    models.WebhookUserUpdated,
+	models.EventUserDeleted:     models.WebhookUserDeleted,
+}
+
+const (
+	// Entregas processadas por rodada e POSTs simultâneos
+	webhookBatchSize   = 50
+	webhookConcurrency = 8
+	// Teto da espera entre tentativas
+	webhookMaxRetryDelay = 6 * time.Hour
+)
+
+type WebhookPolicy struct {
+	MaxAttempts int
+	Timeout     time.Duration // de cada POST
+	RetryDelay  time.Duration // primeira espera; dobra a cada falha
+}
+
+type WebhookService struct {
+	db     *gorm.DB
+	policy WebhookPolicy
+	client *http.Client
+}
+
+func NewWebhookService(db *gorm.DB, policy WebhookPolicy) *WebhookService {
+	return &WebhookService{
+		db:     db,
+		policy: policy,
+		client: &http.Client{
+			Timeout: policy.Timeout,
+			// Um POST assinado não segue redirecionamento: 3xx conta como falha
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+// Assinatura do corpo: "sha256=" + HMAC-SHA256(secret, "<timestamp>.<corpo>")
+// em hex. O timestamp (X-Webhook-Timestamp) entra na conta para o
+// destinatário recusar reenvios antigos.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// --- Assinaturas ---
+
+// Devolve o webhook e o secret (gerado quando não veio na entrada). O
+// secret fica em texto puro no banco: é preciso para assinar cada POST.
+func (s *WebhookService) Create(ctx context.Context, createdBy uint, input models.WebhookInput) (models.Webhook, string, error) {
+	secret := input.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return models.Webhook{}, "", err
+		}
+		secret = "whsec_" + hex.EncodeToString(buf)
+	}
+	events := slices.Compact(slices.Sorted(slices.Values(input.Events)))
+	raw, _ := json.Marshal(events)
+	hook := models.Webhook{URL: input.URL, Secret: secret, Events: raw, CreatedBy: createdBy}
+	err := s.db.WithContext(ctx).Create(&hook).Error
+	return hook, secret, err
+}
+
+func (s *WebhookService) List(ctx context.Context) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	err := s.db.WithContext(ctx).Order("id").Find(&hooks).Error
+	return hooks, err
+}
+
+func (s *WebhookService) Get(ctx context.Context, id uint) (models.Webhook, error) {
+	var hook models.Webhook
+	err := s.db.WithContext(ctx).First(&hook, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return hook, ErrWebhookNotFound
+	}
+	return hook, err
+}
+
+// Remove a assinatura e as entregas dela (inclusive as pendentes)
+func (s *WebhookService) Delete(ctx context.Context, id uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Delete(&models.Webhook{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrWebhookNotFound
+		}
+		return tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error
+	})
+}
+
+// Entregas do webhook, mais recentes primeiro, com o total para a paginação
+func (s *WebhookService) Deliveries(ctx context.Context, webhookID uint, limit, offset int) ([]models.WebhookDelivery, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var deliveries []models.WebhookDelivery
+	err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// --- Enfileiramento ---
+
+// Callback do GORM, como o EventHub.Watch: para cada UserEvent gravado,
+// uma entrega por webhook inscrito no evento. Os eventos do mesmo usuário
+// gravados juntos (ex: nome e e-mail num PUT) saem num único POST.
+func WatchWebhooks(db *gorm.DB) {
+	db.Callback().Create().After("gorm:create").Register("webhooks:enqueue", func(tx *gorm.DB) {
+		events := createdUserEvents(tx)
+		if len(events) == 0 {
+			return
+		}
+		// Mesma conexão (e transação) do statement, sem as condições dele
+		session := tx.Session(&gorm.Session{NewDB: true})
+		var hooks []models.Webhook
+		if err := session.Find(&hooks).Error; err != nil {
+			tx.AddError(err)
+			return
+		}
+		if len(hooks) == 0 {
+			return
+		}
+
+		payloads := userPayloads(events)
+		now := time.Now()
+		var deliveries []models.WebhookDelivery
+		for _, hook := range hooks {
+			var subscribed []string
+			json.Unmarshal(hook.Events, &subscribed)
+			for _, p := range payloads {
+				// Os eventos do payload são de um usuário só, logo de um tenant só
+				if hook.TenantID != p.Changes[0].TenantID || !slices.Contains(subscribed, p.Event) {
+					continue
+				}
+				raw, _ := json.Marshal(p)
+				deliveries = append(deliveries, models.WebhookDelivery{
+					WebhookID:     hook.ID,
+					UserID:        p.UserID,
+					Event:         p.Event,
+					Payload:       raw,
+					Status:        models.DeliveryPending,
+					NextAttemptAt: now,
+				})
+			}
+		}
+		if len(deliveries) > 0 {
+			if err := session.Create(&deliveries).Error; err != nil {
+				tx.AddError(err)
+			}
+		}
+	})
+}
+
+// Agrupa os UserEvents por usuário e evento anunciado (user.created,
+// user.updated, user.deleted), na ordem em que foram gravados. Também
+// usado pelo outbox.
+func userPayloads(events []models.UserEvent) []*models.WebhookPayload {
+	var payloads []*models.WebhookPayload
+	byKey := make(map[string]*models.WebhookPayload)
+	for _, e := range events {
+		event, ok := webhookEvents[e.Type]
+		if !ok {
+			continue
+		}
+		key := fmt.Sprintf("%s/%d", event, e.UserID)
+		p, ok := byKey[key]
+		if !ok {
+			p = &models.WebhookPayload{Event: event, UserID: e.UserID}
+			byKey[key] = p
+			payloads = append(payloads, p)
+		}
+		p.Changes = append(p.Changes, e)
+		p.OccurredAt = e.CreatedAt
+	}
+	return payloads
+}
+
+// --- Entrega ---
+
+// Tenta as entregas vencidas; devolve quantas foram entregues agora
+func (s *WebhookService) DeliverDue(ctx context.Context) int {
+	var due []models.WebhookDelivery
+	err := s.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, time.Now()).
+		Order("id").Limit(webhookBatchSize).Find(&due).Error
+	if err != nil {
+		slog.WarnContext(ctx, "busca das entregas de webhook falhou", "error", err)
+		return 0
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		delivered int
+	)
+	sem := make(chan struct{}, webhookConcurrency)
+	for _, d := range due {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			if s.attempt(ctx, d) {
+				mu.Lock()
+				delivered++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return delivered
+}
+
+// Espera antes da próxima tentativa: RetryDelay, 2x, 4x... até o teto
+func (s *WebhookService) retryDelay(attempts int) time.Duration {
+	delay := s.policy.RetryDelay
+	for i := 1; i < attempts && delay < webhookMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookMaxRetryDelay)
+}
+
+func (s *WebhookService) attempt(ctx context.Context, d models.WebhookDelivery) bool {
+	db := s.db.WithContext(ctx)
+	// Reserva: só quem incrementar attempts a partir do valor lido faz o
+	// POST. O lease cobre o prazo do POST caso a réplica morra no meio.
+	res := db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", d.ID, models.DeliveryPending, d.Attempts).
+		Updates(map[string]interface{}{"attempts": d.Attempts + 1, "next_attempt_at": time.Now().Add(2 * s.policy.Timeout)})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false
+	}
+	d.Attempts++
+
+	var hook models.Webhook
+	if err := db.First(&hook, d.WebhookID).Error; err != nil {
+		return false
+	}
+
+	status, sendErr := s.send(ctx, hook, d)
+	updates := map[string]interface{}{"last_status": status, "last_error": ""}
+	now := time.Now()
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.DeliveryDelivered
+		updates["delivered_at"] = now
+	case d.Attempts >= s.policy.MaxAttempts:
+		updates["status"] = models.DeliveryFailed
+		updates["last_error"] = sendErr.Error()
+		slog.WarnContext(ctx, "entrega de webhook desistida", "delivery_id", d.ID, "webhook_id", hook.ID, "attempts", d.Attempts, "error", sendErr)
+	default:
+		updates["next_attempt_at"] = now.Add(s.retryDelay(d.Attempts))
+		updates["last_error"] = sendErr.Error()
+	}
+	if err := db.Model(&d).Updates(updates).Error; err != nil {
+		slog.WarnContext(ctx, "gravação da entrega de webhook falhou", "delivery_id", d.ID, "error", err)
+	}
+	return sendErr == nil
+}
+
+// POST assinado; qualquer status fora de 2xx é falha
+func (s *WebhookService) send(ctx context.Context, hook models.Webhook, d models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go_api-webhooks")
+	req.Header.Set("X-Webhook-Event", d.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(d.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", SignWebhook(hook.Secret, timestamp, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go_api/config"
+)
+
+// --- Armazenamento de Arquivos ---
+// Onde ficam os avatares. Quem grava depende só da interface Storage: no
+// disco (AVATAR_STORAGE=local, servidos pela própria API em /avatars) ou
+// num bucket S3-compatível (AWS, MinIO...), com o bucket ou um CDN na
+// frente servindo os arquivos. As chaves são geradas pela API, nunca pelo
+// cliente.
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+type Storage interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Delete(ctx context.Context, key string) error
+	// Endereço público do arquivo (vai no avatar_url)
+	URL(key string) string
+}
+
+func New(cfg config.Config) Storage {
+	if cfg.AvatarStorage == "s3" {
+		return &S3{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			BaseURL:   cfg.AvatarBaseURL,
+			Client:    &http.Client{Timeout: 30 * time.Second},
+		}
+	}
+	return &Local{Dir: cfg.AvatarDir, BaseURL: cfg.AvatarBaseURL}
+}
+
+// Chave numa pasta só: sem "/", "\" nem "..", que sairiam do diretório
+func validKey(key string) bool {
+	return key != "" && !strings.ContainsAny(key, `/\`) && !strings.Contains(key, "..")
+}
+
+// --- Disco Local ---
+
+type Local struct {
+	Dir     string
+	BaseURL string
+}
+
+// Caminho do arquivo da chave (GET /avatars/:file)
+func (l *Local) Path(key string) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(l.Dir, key), nil
+}
+
+// Grava num temporário e renomeia: quem lê nunca vê o arquivo pela metade
+func (l *Local) Put(_ context.Context, key, _ string, data []byte) error {
+	path, err := l.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(l.Dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(l.Dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	path, err := l.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) URL(key string) string {
+	return l.BaseURL + "/" + key
+}
+
+// --- S3-Compatível ---
+// PUT e DELETE de objetos com endereço no estilo path
+// (<endpoint>/<bucket>/<chave>), que o MinIO e a AWS aceitam, assinados
+// com AWS Signature V4. Só isso: não vale trazer um SDK inteiro para dois
+// comandos.
+
+type S3 struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	BaseURL   string
+	Client    *http.Client
+}
+
+func (s *S3) Put(ctx context.Context, key, contentType string, data []byte) error {
+	return s.do(ctx, http.MethodPut, key, contentType, data)
+}
+
+// Objeto inexistente também responde 204: nada a tratar
+func (s *S3) Delete(ctx context.Context, key string) error {
+	return s.do(ctx, http.MethodDelete, key, "", nil)
+}
+
+func (s *S3) URL(key string) string {
+	return s.BaseURL + "/" + key
+}
+
+func (s *S3) do(ctx context.Context, method, key, contentType string, data []byte) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	target, err := url.Parse(s.Endpoint + "/" + url.PathEscape(s.Bucket) + "/" + url.PathEscape(key))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, data, time.Now().UTC())
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 %s %s: status %d: %s", method, key, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// AWS Signature V4: assina método, caminho, cabeçalhos e o hash do corpo
+func (s *S3) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256.Sum256(body)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	values := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": req.Header.Get("X-Amz-Content-Sha256"),
+		"x-amz-date":           amzDate,
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		signed = append([]string{"content-type"}, signed...)
+		values["content-type"] = ct
+	}
+	var headers strings.Builder
+	for _, name := range signed {
+		headers.WriteString(name + ":" + values[name] + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		headers.String(),
+		signedHeaders,
+		values["x-amz-content-sha256"],
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signedHeaders, signature))
+}
@@ -0,0 +1,35 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// Falha do banco é 500, não um dia sem atividades
+func TestActivitiesDatabaseError(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	env.grantConsent(ana.ID, token, models.PurposeAnalytics)
+
+	fail := func(db *gorm.DB) {
+		if db.Statement.Table == "activity_samples" {
+			db.AddError(errors.New("conexão perdida"))
+		}
+	}
+	env.db.Callback().Query().Before("gorm:query").Register("test:activities", fail)
+	env.db.Callback().Row().Before("gorm:row").Register("test:activities", fail)
+	t.Cleanup(func() {
+		env.db.Callback().Query().Remove("test:activities")
+		env.db.Callback().Row().Remove("test:activities")
+	})
+	for _, path := range []string{"activities", "activities/summary"} {
+		w := env.do(http.MethodGet, fmt.Sprintf("/users/%d/%s", ana.ID, path), nil, token)
+		expectStatus(t, w, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go_api/models"
+	"go_api/service"
+)
+
+// O que o ./server admin chama, sem passar pelo HTTP
+
+func TestCreateAdminAndResetPassword(t *testing.T) {
+	env := newTestEnv(t)
+	users := env.handler.Users
+	ctx := context.Background()
+
+	input := models.CreateUserInput{Name: "Operação", Email: "ops@exemplo.com", User: "ops", Password: "senha-ops"}
+	admin, err := users.CreateAdmin(ctx, input)
+	if err != nil {
+		t.Fatalf("create-admin: %v", err)
+	}
+	if admin.Role != models.RoleAdmin || !admin.EmailVerified {
+		t.Fatalf("admin = %+v", admin)
+	}
+	if _, err := users.CreateAdmin(ctx, input); !errors.Is(err, service.ErrUserConflict) {
+		t.Fatalf("repetido: %v", err)
+	}
+	token := env.login("ops", "senha-ops")
+	expectStatus(t, env.do(http.MethodGet, "/users", nil, token), http.StatusOK)
+
+	// reset-password acha pelo e-mail, dispensa a senha atual e encerra as sessões
+	found, err := users.GetByLogin(ctx, "ops@exemplo.com")
+	if err != nil || found.ID != admin.ID {
+		t.Fatalf("GetByLogin = %+v, %v", found, err)
+	}
+	if err := users.ChangePassword(ctx, found.ID, 0, models.ChangePasswordInput{NewPassword: "senha-nova"}); err != nil {
+		t.Fatalf("reset-password: %v", err)
+	}
+	w := env.do(http.MethodPost, "/login", map[string]string{"user": "ops", "password": "senha-ops"}, "")
+	expectStatus(t, w, http.StatusUnauthorized)
+	env.login("ops", "senha-nova")
+	if _, err := users.GetByLogin(ctx, "ninguem"); !errors.Is(err, service.ErrUserNotFound) {
+		t.Errorf("login desconhecido: %v", err)
+	}
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	env := newTestEnv(t)
+	_, adminToken := env.seedUser("admin", models.RoleAdmin)
+	ana, anaToken := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, anaToken)
+	bia, _ := env.seedUser("bia", models.RoleUser)
+	for _, id := range []uint{ana.ID, bia.ID} {
+		expectStatus(t, env.do(http.MethodDelete, fmt.Sprintf("/users/%d", id), nil, adminToken), http.StatusOK)
+	}
+	// Só a ana foi removida há mais de um mês
+	env.db.Unscoped().Model(&models.User{}).Where("id = ?", ana.ID).Update("deleted_at", time.Now().Add(-40*24*time.Hour))
+
+	purged, err := env.handler.Privacy.PurgeDeleted(context.Background(), time.Now().Add(-30*24*time.Hour))
+	if err != nil || purged != 1 {
+		t.Fatalf("purge = %d, %v", purged, err)
+	}
+	var user models.User
+	env.db.Unscoped().First(&user, ana.ID)
+	if user.Email == "ana@exemplo.com" || user.User == "ana" || !user.DeletedAt.Valid || user.AnonymizedAt == nil {
+		t.Errorf("ana = %+v", user)
+	}
+	// Anonimizada não volta mais
+	w := env.do(http.MethodPost, fmt.Sprintf("/users/%d/restore", ana.ID), nil, adminToken)
+	expectStatus(t, w, http.StatusConflict)
+	if e := decodeError(t, w); e.Code != "user_erased" {
+		t.Errorf("code = %q", e.Code)
+	}
+	var devices int64
+	env.db.Model(&models.Device{}).Where("id = ?", device.ID).Count(&devices)
+	if devices != 0 {
+		t.Errorf("dispositivo da ana ainda existe")
+	}
+	var recent models.User
+	env.db.Unscoped().First(&recent, bia.ID)
+	if recent.User != "bia" {
+		t.Errorf("bia removida há pouco foi anonimizada: %+v", recent)
+	}
+
+	// Os já anonimizados não contam de novo, mesmo com outro e-mail
+	env.db.Unscoped().Model(&models.User{}).Where("id = ?", ana.ID).Update("email", "ana-apagada@exemplo.com")
+	if purged, err := env.handler.Privacy.PurgeDeleted(context.Background(), time.Now()); err != nil || purged != 1 {
+		t.Fatalf("segunda rodada = %d, %v", purged, err)
+	}
+}
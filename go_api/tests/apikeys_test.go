@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go_api/grpcapi/pb"
+	"go_api/handlers"
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func (e *testEnv) createAPIKey(userID uint, token string, body gin.H) handlers.APIKeyWithSecret {
+	e.t.Helper()
+	w := e.do(http.MethodPost, fmt.Sprintf("/users/%d/api-keys", userID), body, token)
+	expectStatus(e.t, w, http.StatusCreated)
+	var key handlers.APIKeyWithSecret
+	decode(e.t, w, &key)
+	return key
+}
+
+func withAPIKey(key string) map[string]string {
+	return map[string]string{"X-API-Key": key}
+}
+
+func TestAPIKeyAuthenticatesAsOwner(t *testing.T) {
+	env := newTestEnv(t)
+	user, token := env.seedUser("sensor_ana", "")
+
+	key := env.createAPIKey(user.ID, token, gin.H{"name": "Estação do laboratório"})
+	if !strings.HasPrefix(key.Key, key.Prefix) || len(key.Prefix) >= len(key.Key) {
+		t.Fatalf("prefixo %q não corresponde à chave", key.Prefix)
+	}
+
+	path := fmt.Sprintf("/users/%d", user.ID)
+	w := env.doWithHeaders(http.MethodGet, path, nil, "", withAPIKey(key.Key))
+	expectStatus(t, w, http.StatusOK)
+
+	// A chave age com o papel do dono: nada de rotas de admin
+	w = env.doWithHeaders(http.MethodGet, "/users", nil, "", withAPIKey(key.Key))
+	expectStatus(t, w, http.StatusForbidden)
+
+	// A listagem nunca traz a chave, só o prefixo e o último uso
+	w = env.do(http.MethodGet, path+"/api-keys", nil, token)
+	expectStatus(t, w, http.StatusOK)
+	if strings.Contains(w.Body.String(), key.Key) {
+		t.Fatal("listagem expôs a chave")
+	}
+	var keys []models.APIKey
+	decode(t, w, &keys)
+	if len(keys) != 1 || keys[0].LastUsedAt == nil {
+		t.Fatalf("chaves = %+v, want uma, com last_used_at", keys)
+	}
+}
+
+func TestRevokedAPIKeyIsRejected(t *testing.T) {
+	env := newTestEnv(t)
+	user, token := env.seedUser("sensor_bia", "")
+	key := env.createAPIKey(user.ID, token, gin.H{"name": "Job noturno"})
+
+	path := fmt.Sprintf("/users/%d/api-keys/%d", user.ID, key.ID)
+	w := env.do(http.MethodDelete, path, nil, token)
+	expectStatus(t, w, http.StatusOK)
+	var revoked models.APIKey
+	decode(t, w, &revoked)
+	if revoked.RevokedAt == nil {
+		t.Fatal("revoked_at vazio")
+	}
+
+	w = env.doWithHeaders(http.MethodGet, fmt.Sprintf("/users/%d", user.ID), nil, "", withAPIKey(key.Key))
+	expectStatus(t, w, http.StatusUnauthorized)
+
+	// Chave de outro usuário não aparece nem para revogação
+	other, otherToken := env.seedUser("sensor_caio", "")
+	w = env.do(http.MethodDelete, fmt.Sprintf("/users/%d/api-keys/%d", other.ID, key.ID), nil, otherToken)
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+func TestAPIKeyValidation(t *testing.T) {
+	env := newTestEnv(t)
+	user, token := env.seedUser("sensor_davi", "")
+	path := fmt.Sprintf("/users/%d/api-keys", user.ID)
+
+	w := env.do(http.MethodPost, path, gin.H{"name": "Vencida", "expires_at": time.Now().Add(-time.Hour)}, token)
+	expectStatus(t, w, http.StatusBadRequest)
+
+	w = env.doWithHeaders(http.MethodGet, fmt.Sprintf("/users/%d", user.ID), nil, "", withAPIKey("gak_inventada"))
+	expectStatus(t, w, http.StatusUnauthorized)
+
+	// Com a chave, não dá para criar outras
+	key := env.createAPIKey(user.ID, token, gin.H{"name": "Sensor"})
+	w = env.doWithHeaders(http.MethodPost, path, gin.H{"name": "Outra"}, "", withAPIKey(key.Key))
+	expectStatus(t, w, http.StatusForbidden)
+}
+
+func TestExpiredAPIKeyIsRejected(t *testing.T) {
+	env := newTestEnv(t)
+	user, token := env.seedUser("sensor_eva", "")
+	key := env.createAPIKey(user.ID, token, gin.H{"name": "Temporária", "expires_at": time.Now().Add(time.Hour)})
+
+	env.db.Model(&models.APIKey{}).Where("id = ?", key.ID).Update("expires_at", time.Now().Add(-time.Minute))
+	w := env.doWithHeaders(http.MethodGet, fmt.Sprintf("/users/%d", user.ID), nil, "", withAPIKey(key.Key))
+	expectStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestGRPCWithAPIKey(t *testing.T) {
+	env := newTestEnv(t)
+	conn := env.grpcConn()
+	user, token := env.seedUser("sensor_fabio", "")
+	key := env.createAPIKey(user.ID, token, gin.H{"name": "Gateway"})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key.Key)
+	got, err := pb.NewUserServiceClient(conn).GetUser(ctx, &pb.GetUserRequest{Id: uint64(user.ID)})
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if got.User != "sensor_fabio" {
+		t.Errorf("user = %q", got.User)
+	}
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "gak_inventada")
+	_, err = pb.NewUserServiceClient(conn).GetUser(ctx, &pb.GetUserRequest{Id: uint64(user.ID)})
+	expectCode(t, err, codes.Unauthenticated)
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (e *testEnv) audit(query, token string) []models.AuditLog {
+	e.t.Helper()
+	w := e.do(http.MethodGet, "/audit?"+query, nil, token)
+	expectStatus(e.t, w, http.StatusOK)
+	var entries []models.AuditLog
+	decode(e.t, w, &entries)
+	return entries
+}
+
+func TestAuditRecordsUserChanges(t *testing.T) {
+	env := newTestEnv(t)
+	admin, adminToken := env.seedUser("auditora", models.RoleAdmin)
+	ana, _ := env.seedUser("auditada", "")
+
+	path := fmt.Sprintf("/users/%d", ana.ID)
+	w := env.do(http.MethodPut, path, gin.H{"name": "Ana Auditada"}, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	w = env.do(http.MethodDelete, path, nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+
+	entries := env.audit(fmt.Sprintf("entity=user&entity_id=%d", ana.ID), adminToken)
+	if len(entries) != 3 {
+		t.Fatalf("registros = %d, want 3", len(entries))
+	}
+	del, upd, created := entries[0], entries[1], entries[2]
+	if created.Action != models.AuditCreate || created.ActorID != nil || created.Before != nil {
+		t.Errorf("cadastro público = %+v", created)
+	}
+	if upd.Action != models.AuditUpdate || upd.ActorID == nil || *upd.ActorID != admin.ID {
+		t.Fatalf("update = %+v, want ator %d", upd, admin.ID)
+	}
+	if string(upd.Fields) != `["name"]` {
+		t.Errorf("fields = %s", upd.Fields)
+	}
+	var before, after models.User
+	json.Unmarshal(upd.Before, &before)
+	json.Unmarshal(upd.After, &after)
+	if before.Name != "Usuário auditada" || after.Name != "Ana Auditada" {
+		t.Errorf("before/after = %q/%q", before.Name, after.Name)
+	}
+	if del.Action != models.AuditDelete || del.After != nil || upd.RequestID == "" {
+		t.Errorf("delete = %+v", del)
+	}
+}
+
+func TestAuditNeverStoresPasswords(t *testing.T) {
+	env := newTestEnv(t)
+	_, adminToken := env.seedUser("auditora", models.RoleAdmin)
+	bruno, token := env.seedUser("bruno", "")
+
+	w := env.do(http.MethodPut, fmt.Sprintf("/users/%d/password", bruno.ID),
+		gin.H{"current_password": "senha-bruno", "new_password": "nova-senha-bruno"}, token)
+	expectStatus(t, w, http.StatusOK)
+
+	w = env.do(http.MethodGet, fmt.Sprintf("/audit?entity=user&entity_id=%d&action=update", bruno.ID), nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	if body := w.Body.String(); strings.Contains(body, "$2a$") || strings.Contains(body, "nova-senha") {
+		t.Fatalf("auditoria expôs a senha: %s", body)
+	}
+	var entries []models.AuditLog
+	decode(t, w, &entries)
+	if len(entries) != 1 || string(entries[0].Fields) != `["password"]` || *entries[0].ActorID != bruno.ID {
+		t.Fatalf("registros = %+v", entries)
+	}
+}
+
+func TestAuditRecordsDevicesAndAPIKeys(t *testing.T) {
+	env := newTestEnv(t)
+	_, adminToken := env.seedUser("auditora", models.RoleAdmin)
+	carla, token := env.seedUser("carla", "")
+
+	w := env.do(http.MethodPost, fmt.Sprintf("/users/%d/devices", carla.ID), gin.H{"name": "Sensor", "type": "sensor"}, token)
+	expectStatus(t, w, http.StatusCreated)
+	var device struct{ Token string }
+	decode(t, w, &device)
+	key := env.createAPIKey(carla.ID, token, gin.H{"name": "Gateway"})
+
+	// Os segredos aparecem só na criação, nunca na auditoria
+	w = env.do(http.MethodGet, fmt.Sprintf("/audit?actor_id=%d", carla.ID), nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	for _, secret := range []string{device.Token, key.Key} {
+		if strings.Contains(w.Body.String(), secret) {
+			t.Fatal("auditoria expôs um segredo")
+		}
+	}
+
+	entries := env.audit("entity=device", adminToken)
+	if len(entries) != 1 || entries[0].Action != models.AuditCreate {
+		t.Fatalf("dispositivos = %+v", entries)
+	}
+	if keys := env.audit("entity=api_key&action=create", adminToken); len(keys) != 1 {
+		t.Fatalf("chaves = %d, want 1", len(keys))
+	}
+}
+
+func TestAuditIsAdminOnly(t *testing.T) {
+	env := newTestEnv(t)
+	_, token := env.seedUser("curiosa", "")
+	_, adminToken := env.seedUser("auditora", models.RoleAdmin)
+
+	expectStatus(t, env.do(http.MethodGet, "/audit", nil, token), http.StatusForbidden)
+	for _, query := range []string{"entity=consent", "action=read", "entity_id=abc", "from=ontem"} {
+		expectStatus(t, env.do(http.MethodGet, "/audit?"+query, nil, adminToken), http.StatusBadRequest)
+	}
+}
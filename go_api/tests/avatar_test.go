@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_api/models"
+	"go_api/storage"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 0x80, A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func (e *testEnv) uploadAvatar(userID uint, data []byte, token string) *httptest.ResponseRecorder {
+	e.t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "foto.png")
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/users/%d/avatar", userID), &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAvatarUploadResizesAndServes(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+
+	w := env.uploadAvatar(ana.ID, testPNG(t, 300, 200), token)
+	expectStatus(t, w, http.StatusOK)
+	var user models.User
+	decode(t, w, &user)
+	if !strings.HasPrefix(user.AvatarURL, "/avatars/") {
+		t.Fatalf("avatar_url = %q", user.AvatarURL)
+	}
+
+	// JPEG quadrado no tamanho do AVATAR_SIZE
+	w = env.do(http.MethodGet, user.AvatarURL, nil, "")
+	expectStatus(t, w, http.StatusOK)
+	img, err := jpeg.Decode(w.Body)
+	if err != nil {
+		t.Fatalf("avatar não é JPEG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != testCfg.AvatarSize || b.Dy() != testCfg.AvatarSize {
+		t.Fatalf("avatar %dx%d, want %dx%d", b.Dx(), b.Dy(), testCfg.AvatarSize, testCfg.AvatarSize)
+	}
+
+	// O GET do usuário traz o avatar; o histórico registra a troca
+	w = env.do(http.MethodGet, fmt.Sprintf("/users/%d", ana.ID), nil, token)
+	var got models.User
+	decode(t, w, &got)
+	if got.AvatarURL != user.AvatarURL {
+		t.Fatalf("avatar_url no GET = %q, want %q", got.AvatarURL, user.AvatarURL)
+	}
+	var events int64
+	env.db.Model(&models.UserEvent{}).Where("user_id = ? AND type = ?", ana.ID, models.EventAvatarChanged).Count(&events)
+	if events != 1 {
+		t.Fatalf("eventos AvatarChanged = %d, want 1", events)
+	}
+
+	// Novo envio: novo arquivo, e o anterior deixa de existir
+	w = env.uploadAvatar(ana.ID, testPNG(t, 40, 40), token)
+	expectStatus(t, w, http.StatusOK)
+	var updated models.User
+	decode(t, w, &updated)
+	if updated.AvatarURL == user.AvatarURL {
+		t.Fatal("avatar_url não mudou no novo envio")
+	}
+	expectStatus(t, env.do(http.MethodGet, user.AvatarURL, nil, ""), http.StatusNotFound)
+	w = env.do(http.MethodGet, updated.AvatarURL, nil, "")
+	expectStatus(t, w, http.StatusOK)
+	// Imagem menor que o AVATAR_SIZE não é ampliada
+	if cfg, err := jpeg.DecodeConfig(w.Body); err != nil || cfg.Width != 40 {
+		t.Fatalf("avatar pequeno: %+v, %v", cfg, err)
+	}
+}
+
+func TestAvatarRejectsInvalidUploads(t *testing.T) {
+	cfg := testCfg
+	cfg.AvatarMaxBytes = 4 << 10
+	env := newTestEnvWithConfig(t, cfg)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	bia, _ := env.seedUser("bia", models.RoleUser)
+
+	w := env.uploadAvatar(ana.ID, []byte("não é imagem"), token)
+	expectStatus(t, w, http.StatusBadRequest)
+	if e := decodeError(t, w); e.Code != "invalid_image" {
+		t.Fatalf("erro = %+v", e)
+	}
+	expectStatus(t, env.uploadAvatar(ana.ID, bytes.Repeat([]byte{0}, 8<<10), token), http.StatusRequestEntityTooLarge)
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/users/%d/avatar", ana.ID), nil, token), http.StatusBadRequest)
+	expectStatus(t, env.uploadAvatar(bia.ID, testPNG(t, 10, 10), token), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodGet, "/avatars/..%2Fapi.db", nil, ""), http.StatusNotFound)
+}
+
+func TestAvatarS3Storage(t *testing.T) {
+	type s3Request struct {
+		method, path, contentType, auth, payloadHash string
+		body                                         []byte
+	}
+	var got []s3Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, s3Request{r.Method, r.URL.Path, r.Header.Get("Content-Type"),
+			r.Header.Get("Authorization"), r.Header.Get("X-Amz-Content-Sha256"), body})
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	cfg := testCfg
+	cfg.AvatarStorage = "s3"
+	cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket = server.URL, "sa-east-1", "avatares"
+	cfg.S3AccessKey, cfg.S3SecretKey = "AKIDTESTE", "segredo"
+	cfg.AvatarBaseURL = "https://cdn.exemplo.com"
+	store := storage.New(cfg)
+
+	data := []byte("jpeg")
+	if err := store.Put(context.Background(), "1-abc.jpg", "image/jpeg", data); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if err := store.Delete(context.Background(), "1-abc.jpg"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if url := store.URL("1-abc.jpg"); url != "https://cdn.exemplo.com/1-abc.jpg" {
+		t.Fatalf("url = %q", url)
+	}
+	if len(got) != 2 || got[0].method != http.MethodPut || got[1].method != http.MethodDelete {
+		t.Fatalf("requisições = %+v", got)
+	}
+	put := got[0]
+	sum := sha256.Sum256(data)
+	if put.path != "/avatares/1-abc.jpg" || put.contentType != "image/jpeg" || !bytes.Equal(put.body, data) ||
+		put.payloadHash != hex.EncodeToString(sum[:]) {
+		t.Fatalf("PUT = %+v", put)
+	}
+	if !strings.HasPrefix(put.auth, "AWS4-HMAC-SHA256 Credential=AKIDTESTE/") ||
+		!strings.Contains(put.auth, "/sa-east-1/s3/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date, Signature=") {
+		t.Fatalf("Authorization = %q", put.auth)
+	}
+
+	if err := store.Put(context.Background(), "../fora.jpg", "image/jpeg", data); err == nil {
+		t.Fatal("chave com .. aceita")
+	}
+}
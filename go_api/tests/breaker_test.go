@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"go_api/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestBreakerOpensAndRecovers(t *testing.T) {
+	b := repository.NewBreaker("teste", 3, 50*time.Millisecond)
+	call := func(err error) error {
+		done, openErr := b.Allow()
+		if openErr != nil {
+			return openErr
+		}
+		done(err)
+		return nil
+	}
+
+	// Erro da consulta em si não conta; sobrecarga conta
+	for _, err := range []error{gorm.ErrRecordNotFound, &pgconn.PgError{Code: "23505"}, context.Canceled} {
+		if repository.Overloaded(err) {
+			t.Errorf("Overloaded(%v) = true", err)
+		}
+		call(err)
+	}
+	for _, err := range []error{context.DeadlineExceeded, &pgconn.PgError{Code: "53300"}, &pgconn.PgError{Code: "57014"}} {
+		if !repository.Overloaded(err) {
+			t.Errorf("Overloaded(%v) = false", err)
+		}
+		if call(err) != nil {
+			t.Fatalf("recusou antes de %d falhas", 3)
+		}
+	}
+	var open *repository.CircuitOpenError
+	if err := call(nil); !errors.As(err, &open) || open.RetryAfter <= 0 {
+		t.Fatalf("aberto: err = %v", err)
+	}
+	if b.State() != "open" {
+		t.Errorf("estado = %s", b.State())
+	}
+
+	// Passado o cooldown, uma chamada de teste; as outras esperam por ela
+	time.Sleep(60 * time.Millisecond)
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("meio aberto recusou a chamada de teste: %v", err)
+	}
+	if _, err := b.Allow(); !errors.As(err, &open) {
+		t.Errorf("segunda chamada no meio aberto: err = %v", err)
+	}
+	done(context.DeadlineExceeded)
+	if b.State() != "open" {
+		t.Fatalf("teste falhou, estado = %s, quer open", b.State())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if err := call(nil); err != nil || b.State() != "closed" {
+		t.Errorf("teste passou: err = %v, estado = %s", err, b.State())
+	}
+}
+
+func TestRouteBreaker(t *testing.T) {
+	cfg := testCfg
+	cfg.DBBreakerFailures = 2
+	cfg.DBBreakerCooldown = time.Minute
+	env := newTestEnvWithConfig(t, cfg)
+	_, token := env.seedUser("breaker_ana", "")
+
+	// Rota que sempre estoura o prazo (o banco não responde a tempo)
+	calls := 0
+	env.router.GET("/breaker-test", func(c *gin.Context) {
+		calls++
+		c.Error(context.DeadlineExceeded)
+		c.Abort()
+	})
+	for range 2 {
+		expectStatus(t, env.do(http.MethodGet, "/breaker-test", nil, ""), http.StatusRequestTimeout)
+	}
+
+	w := env.do(http.MethodGet, "/breaker-test", nil, "")
+	expectStatus(t, w, http.StatusServiceUnavailable)
+	if e := decodeError(t, w); e.Code != "circuit_open" {
+		t.Errorf("code = %q", e.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, quer 60", got)
+	}
+	if calls != 2 {
+		t.Errorf("handler chamado %d vezes com o circuito aberto, quer 2", calls)
+	}
+
+	// As outras rotas continuam respondendo
+	expectStatus(t, env.do(http.MethodGet, "/me", nil, token), http.StatusOK)
+}
+
+// O pool com o circuito global continua servindo consultas, transações e o db.DB()
+func TestDatabaseBreakerPool(t *testing.T) {
+	cfg := testCfg
+	cfg.DBBreakerFailures = 3
+	cfg.DBBreakerCooldown = time.Minute
+	db, err := repository.Connect(cfg)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil || sqlDB == nil {
+		t.Fatalf("db.DB() = %v, %v", sqlDB, err)
+	}
+	defer sqlDB.Close()
+
+	if err := db.Exec("CREATE TABLE breaker_items (id INTEGER PRIMARY KEY, name TEXT)").Error; err != nil {
+		t.Fatal(err)
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return tx.Exec("INSERT INTO breaker_items (name) VALUES (?)", "a").Error
+	})
+	if err != nil {
+		t.Fatalf("transação: %v", err)
+	}
+	var count int64
+	if err := db.Table("breaker_items").Count(&count).Error; err != nil || count != 1 {
+		t.Errorf("count = %d, err = %v", count, err)
+	}
+}
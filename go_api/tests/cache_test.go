@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go_api/models"
+	"go_api/repository"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+)
+
+func TestUserCache(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cfg := testCfg
+	cfg.RedisURL = "redis://" + mr.Addr()
+	rdb, err := repository.ConnectRedis(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := newTestEnvWithRedis(t, cfg, rdb)
+	ana, anaToken := env.seedUser("ana", models.RoleUser)
+	_, adminToken := env.seedUser("admin", models.RoleAdmin)
+	path := fmt.Sprintf("/users/%d", ana.ID)
+
+	getName := func() string {
+		t.Helper()
+		w := env.do(http.MethodGet, path, nil, adminToken)
+		expectStatus(t, w, http.StatusOK)
+		var user models.User
+		decode(t, w, &user)
+		return user.Name
+	}
+
+	getName()
+	if !mr.Exists(fmt.Sprintf("users:id:%d", ana.ID)) {
+		t.Fatal("GET não populou o cache")
+	}
+
+	// Alteração por fora do repositório não aparece: a leitura vem do cache
+	env.db.Model(&models.User{}).Where("id = ?", ana.ID).Update("name", "Por Fora")
+	if name := getName(); name != ana.Name {
+		t.Fatalf("nome = %q, esperava o do cache %q", name, ana.Name)
+	}
+
+	// Escrita pela API invalida
+	expectStatus(t, env.do(http.MethodPatch, path, `{"name":"Ana Maria"}`, anaToken), http.StatusOK)
+	if name := getName(); name != "Ana Maria" {
+		t.Fatalf("nome depois do PATCH = %q", name)
+	}
+
+	// O hash da senha sobrevive ao cache (a troca compara com ele)
+	getName()
+	w := env.do(http.MethodPut, path+"/password", gin.H{"current_password": "senha-ana", "new_password": "nova-senha"}, anaToken)
+	expectStatus(t, w, http.StatusOK)
+
+	// Listagens são invalidadas por qualquer escrita
+	list := func() string {
+		t.Helper()
+		w := env.do(http.MethodGet, "/users", nil, adminToken)
+		expectStatus(t, w, http.StatusOK)
+		return w.Header().Get("X-Total-Count")
+	}
+	if total := list(); total != "2" {
+		t.Fatalf("total = %s", total)
+	}
+	env.seedUser("bia", models.RoleUser)
+	if total := list(); total != "3" {
+		t.Fatalf("total depois do cadastro = %s", total)
+	}
+
+	// Redis fora do ar: tudo segue pelo banco
+	mr.Close()
+	if name := getName(); name != "Ana Maria" {
+		t.Fatalf("nome sem Redis = %q", name)
+	}
+	if total := list(); total != "3" {
+		t.Fatalf("total sem Redis = %s", total)
+	}
+}
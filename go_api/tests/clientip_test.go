@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go_api/handlers"
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// O httptest conecta de 192.0.2.1: aqui ele faz o papel do nginx
+func proxiedEnv(t *testing.T) *testEnv {
+	t.Helper()
+	cfg := testCfg
+	cfg.TrustedProxies = []string{"192.0.2.0/24"}
+	cfg.RateLimit = 2
+	cfg.RateLimitWindow = time.Hour
+	return newTestEnvWithConfig(t, cfg)
+}
+
+func forwardedFor(chain string) map[string]string {
+	return map[string]string{"X-Forwarded-For": chain}
+}
+
+func accessToken(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var tokens handlers.TokenPair
+	decode(t, w, &tokens)
+	return tokens.AccessToken
+}
+
+func TestClientIPBehindTrustedProxy(t *testing.T) {
+	env := proxiedEnv(t)
+	_, adminToken := env.seedUser("admin", models.RoleAdmin)
+
+	w := env.doWithHeaders(http.MethodPost, "/users", gin.H{
+		"name": "Ana", "email": "ana@exemplo.com", "user": "ana", "password": "senha-ana",
+	}, "", forwardedFor("203.0.113.9"))
+	expectStatus(t, w, http.StatusCreated)
+	var ana models.User
+	decode(t, w, &ana)
+
+	// Sessão e auditoria com o IP do cliente, não o do proxy
+	w = env.doWithHeaders(http.MethodPost, "/login", gin.H{"user": "ana", "password": "senha-ana"}, "",
+		forwardedFor("203.0.113.9, 192.0.2.7"))
+	expectStatus(t, w, http.StatusOK)
+	sessions := env.sessions(accessToken(t, w))
+	if len(sessions) != 1 || sessions[0].IP != "203.0.113.9" {
+		t.Errorf("sessões = %+v, want IP 203.0.113.9", sessions)
+	}
+	entries := env.audit(fmt.Sprintf("entity=user&entity_id=%d&action=create", ana.ID), adminToken)
+	if len(entries) != 1 || entries[0].ClientIP != "203.0.113.9" {
+		t.Errorf("auditoria = %+v, want client_ip 203.0.113.9", entries)
+	}
+
+	// Só o X-Real-IP (como o nginx do docker-compose manda)
+	w = env.doWithHeaders(http.MethodPost, "/login", gin.H{"user": "ana", "password": "senha-ana"}, "",
+		map[string]string{"X-Real-IP": "198.51.100.4"})
+	expectStatus(t, w, http.StatusOK)
+	if ip := env.sessions(accessToken(t, w))[0].IP; ip != "198.51.100.4" {
+		t.Errorf("IP pelo X-Real-IP = %s", ip)
+	}
+}
+
+// As sub-requisições do /batch enxergam o mesmo cliente que a externa
+func TestClientIPInBatch(t *testing.T) {
+	env := proxiedEnv(t)
+	env.seedUser("ana", models.RoleUser)
+
+	w := env.doWithHeaders(http.MethodPost, "/batch", []gin.H{
+		{"method": "POST", "path": "/login", "body": gin.H{"user": "ana", "password": "senha-ana"}},
+	}, "", forwardedFor("203.0.113.9"))
+	expectStatus(t, w, http.StatusOK)
+	var responses []handlers.BatchResponse
+	decode(t, w, &responses)
+	var tokens handlers.TokenPair
+	if len(responses) != 1 || json.Unmarshal(responses[0].Body, &tokens) != nil {
+		t.Fatalf("respostas = %+v", responses)
+	}
+	// A mais recente primeiro (a do seedUser veio direto, sem proxy no meio)
+	if s := env.sessions(tokens.AccessToken)[0]; !s.Current || s.IP != "203.0.113.9" {
+		t.Errorf("sessão = %+v, want IP 203.0.113.9", s)
+	}
+}
+
+func TestClientIPRateLimitPerForwardedClient(t *testing.T) {
+	env := proxiedEnv(t)
+
+	// Cada cliente atrás do proxy tem o próprio balde
+	for _, client := range []string{"203.0.113.1", "203.0.113.2"} {
+		for i := 0; i < 2; i++ {
+			expectStatus(t, env.doWithHeaders(http.MethodGet, "/time", nil, "", forwardedFor(client)), http.StatusOK)
+		}
+		expectStatus(t, env.doWithHeaders(http.MethodGet, "/time", nil, "", forwardedFor(client)), http.StatusTooManyRequests)
+	}
+
+	// Um endereço inventado à esquerda não troca de balde: vale o último
+	// que não é do proxy
+	w := env.doWithHeaders(http.MethodGet, "/time", nil, "", forwardedFor("10.9.9.9, 203.0.113.1"))
+	expectStatus(t, w, http.StatusTooManyRequests)
+}
+
+func TestClientIPIgnoresHeadersFromUntrustedPeers(t *testing.T) {
+	env := newTestEnv(t) // sem TRUSTED_PROXIES
+	env.seedUser("ana", models.RoleUser)
+
+	w := env.doWithHeaders(http.MethodPost, "/login", gin.H{"user": "ana", "password": "senha-ana"}, "",
+		map[string]string{"X-Forwarded-For": "203.0.113.9", "X-Real-IP": "203.0.113.9"})
+	expectStatus(t, w, http.StatusOK)
+	for _, s := range env.sessions(accessToken(t, w)) {
+		if s.IP != "192.0.2.1" {
+			t.Errorf("IP = %s, want o da conexão (192.0.2.1)", s.IP)
+		}
+	}
+}
+
+// A regra usada pelo gRPC, que não passa pelo gin
+func TestClientIPResolver(t *testing.T) {
+	env := proxiedEnv(t)
+	headers := func(values map[string]string) func(string) string {
+		return func(name string) string { return values[name] }
+	}
+	for _, tc := range []struct {
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"192.0.2.10:4000", map[string]string{"X-Forwarded-For": "203.0.113.9"}, "203.0.113.9"},
+		{"192.0.2.10:4000", map[string]string{"X-Forwarded-For": "6.6.6.6, 203.0.113.9, 192.0.2.11"}, "203.0.113.9"},
+		{"192.0.2.10:4000", map[string]string{"X-Real-IP": "198.51.100.4"}, "198.51.100.4"},
+		{"192.0.2.10:4000", map[string]string{"X-Forwarded-For": "lixo"}, "192.0.2.10"},
+		{"192.0.2.10:4000", nil, "192.0.2.10"},
+		{"203.0.113.50:4000", map[string]string{"X-Forwarded-For": "6.6.6.6"}, "203.0.113.50"},
+		{"[::ffff:192.0.2.10]:4000", map[string]string{"X-Real-IP": "198.51.100.4"}, "198.51.100.4"},
+	} {
+		if got := env.handler.ClientIP(tc.remote, headers(tc.headers)); got != tc.want {
+			t.Errorf("ClientIP(%s, %v) = %s, want %s", tc.remote, tc.headers, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"go_api/coap"
+	"go_api/models"
+	"go_api/mqttbridge"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Fala com o servidor CoAP por UDP de verdade, como um sensor
+type coapClient struct {
+	t    *testing.T
+	conn net.Conn
+	id   uint16
+}
+
+func (env *testEnv) startCoAP() *coapClient {
+	env.t.Helper()
+	srv := coap.New(env.handler.Devices, env.handler.Telemetry)
+	if err := srv.Serve("127.0.0.1:0"); err != nil {
+		env.t.Fatal(err)
+	}
+	env.t.Cleanup(srv.Close)
+	conn, err := net.Dial("udp", srv.Addr().String())
+	if err != nil {
+		env.t.Fatal(err)
+	}
+	env.t.Cleanup(func() { conn.Close() })
+	return &coapClient{t: env.t, conn: conn, id: 100}
+}
+
+// Requisição POST no caminho, com o Uri-Query e as opções extras
+func (cl *coapClient) post(typ coap.Type, path, query string, payload []byte, extra ...coap.Option) coap.Message {
+	cl.t.Helper()
+	cl.id++
+	req := coap.Message{Type: typ, Code: coap.POST, MessageID: cl.id, Token: []byte{0xca, 0xfe}, Payload: payload}
+	for _, segment := range bytes.Split([]byte(path), []byte("/")) {
+		req.Options = append(req.Options, coap.Option{Number: coap.OptionURIPath, Value: segment})
+	}
+	if query != "" {
+		req.Options = append(req.Options, coap.Option{Number: coap.OptionURIQuery, Value: []byte(query)})
+	}
+	req.Options = append(req.Options, extra...)
+	raw, err := req.MarshalBinary()
+	if err != nil {
+		cl.t.Fatal(err)
+	}
+	return cl.send(raw)
+}
+
+func (cl *coapClient) send(raw []byte) coap.Message {
+	cl.t.Helper()
+	if _, err := cl.conn.Write(raw); err != nil {
+		cl.t.Fatal(err)
+	}
+	cl.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 2048)
+	n, err := cl.conn.Read(buf)
+	if err != nil {
+		cl.t.Fatalf("sem resposta CoAP: %v", err)
+	}
+	res, err := coap.ParseMessage(buf[:n])
+	if err != nil {
+		cl.t.Fatalf("resposta CoAP inválida: %v", err)
+	}
+	return res
+}
+
+// O instante sai com a tag 1 (epoch), como nos sensores
+func encodeCBOR(t *testing.T, v any) []byte {
+	t.Helper()
+	var out []byte
+	if err := codec.NewEncoderBytes(&out, &codec.CborHandle{}).Encode(v); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+type sensor struct {
+	ID    uint   `json:"id"`
+	Token string `json:"token"` // o token em claro, só na resposta da criação
+}
+
+func (env *testEnv) createSensor(userID uint, token string) sensor {
+	env.t.Helper()
+	w := env.do(http.MethodPost, fmt.Sprintf("/users/%d/devices", userID), map[string]string{"name": "Sensor do jardim", "type": "sensor"}, token)
+	expectStatus(env.t, w, http.StatusCreated)
+	var device sensor
+	decode(env.t, w, &device)
+	return device
+}
+
+func TestCoAPIngestion(t *testing.T) {
+	env := newTestEnv(t)
+	ana, anaToken := env.seedUser("coap_ana", models.RoleUser)
+	device := env.createSensor(ana.ID, anaToken)
+	cl := env.startCoAP()
+	readingsPath := fmt.Sprintf("devices/%d/readings", device.ID)
+	auth := "token=" + device.Token
+
+	// Duas leituras em CBOR: instante como epoch (com e sem a tag 1)
+	payload := encodeCBOR(t, []any{
+		map[string]any{"metric": "soil_moisture", "value": 41.5, "timestamp": int64(1760000000)},
+		map[string]any{"metric": "battery", "value": 3.7, "timestamp": time.Unix(1760000060, 0), "payload": map[string]any{"rssi": -71}},
+	})
+	res := cl.post(coap.Confirmable, readingsPath, auth, payload)
+	if res.Type != coap.Acknowledgement || res.Code != coap.Created || res.MessageID != cl.id || !bytes.Equal(res.Token, []byte{0xca, 0xfe}) {
+		t.Fatalf("resposta = %+v (%s)", res, res.Code)
+	}
+	var body struct {
+		Created int `codec:"created"`
+	}
+	if err := codec.NewDecoderBytes(res.Payload, &codec.CborHandle{}).Decode(&body); err != nil || body.Created != 2 {
+		t.Errorf("payload = %x (%v)", res.Payload, err)
+	}
+	var readings []models.Reading
+	env.db.Where("device_id = ?", device.ID).Order("timestamp").Find(&readings)
+	if len(readings) != 2 || !readings[0].Timestamp.Equal(time.Unix(1760000000, 0)) || string(readings[1].Payload) != `{"rssi":-71}` {
+		t.Fatalf("leituras = %+v", readings)
+	}
+
+	// A retransmissão do mesmo CON recebe a mesma resposta e não grava de novo
+	last, _ := coap.Message{Type: coap.Confirmable, Code: coap.POST, MessageID: cl.id, Token: []byte{0xca, 0xfe}, Payload: payload,
+		Options: []coap.Option{{Number: coap.OptionURIPath, Value: []byte("devices")}, {Number: coap.OptionURIPath, Value: []byte(fmt.Sprint(device.ID))},
+			{Number: coap.OptionURIPath, Value: []byte("readings")}, {Number: coap.OptionURIQuery, Value: []byte(auth)}}}.MarshalBinary()
+	if again := cl.send(last); again.Code != coap.Created || again.MessageID != cl.id {
+		t.Errorf("retransmissão = %+v", again)
+	}
+	var count int64
+	env.db.Model(&models.Reading{}).Where("device_id = ?", device.ID).Count(&count)
+	if count != 2 {
+		t.Errorf("leituras depois da retransmissão = %d, quer 2", count)
+	}
+
+	// JSON também vale; heartbeat NON recebe resposta NON
+	json := coap.UintOption(coap.OptionContentFormat, coap.FormatJSON)
+	if res := cl.post(coap.NonConfirmable, readingsPath, auth, []byte(`{"metric":"battery","value":3.6}`), json); res.Code != coap.Created {
+		t.Errorf("JSON = %s %s", res.Code, res.Payload)
+	}
+	res = cl.post(coap.NonConfirmable, fmt.Sprintf("devices/%d/heartbeat", device.ID), auth, nil)
+	if res.Type != coap.NonConfirmable || res.Code != coap.Changed {
+		t.Errorf("heartbeat = %+v", res)
+	}
+	var seen models.Device
+	env.db.First(&seen, device.ID)
+	if seen.LastSeen == nil {
+		t.Error("heartbeat não atualizou o last_seen")
+	}
+
+	// Erros com o diagnóstico no payload
+	missing := encodeCBOR(t, map[string]any{"value": 1.0})
+	for name, tc := range map[string]struct {
+		path, query string
+		payload     []byte
+		extra       []coap.Option
+		want        coap.Code
+		diagnostic  string
+	}{
+		"token errado":          {readingsPath, "token=x", payload, nil, coap.Unauthorized, "Invalid device or token"},
+		"sem token":             {readingsPath, "", payload, nil, coap.Unauthorized, "Invalid device or token"},
+		"caminho":               {"devices/1/config", auth, nil, nil, coap.NotFound, "Resource not found"},
+		"sem metric":            {readingsPath, auth, missing, nil, coap.BadRequest, "metric is required (index 0)"},
+		"CBOR quebrado":         {readingsPath, auth, []byte{0xa1, 0x66}, nil, coap.BadRequest, ""},
+		"formato":               {readingsPath, auth, []byte("x"), []coap.Option{coap.UintOption(coap.OptionContentFormat, 41)}, coap.UnsupportedFormat, ""},
+		"opção crítica":         {readingsPath, auth, payload, []coap.Option{{Number: 9, Value: []byte{1}}}, coap.BadOption, ""},
+		"Accept que não é CBOR": {readingsPath, auth, payload, []coap.Option{coap.UintOption(coap.OptionAccept, coap.FormatJSON)}, coap.NotAcceptable, ""},
+	} {
+		res := cl.post(coap.Confirmable, tc.path, tc.query, tc.payload, tc.extra...)
+		if res.Code != tc.want || tc.diagnostic != "" && string(res.Payload) != tc.diagnostic {
+			t.Errorf("%s: %s %q, quer %s %q", name, res.Code, res.Payload, tc.want, tc.diagnostic)
+		}
+	}
+
+	// "CoAP ping": CON vazio recebe RST
+	ping, _ := coap.Message{Type: coap.Confirmable, Code: coap.CodeEmpty, MessageID: 7}.MarshalBinary()
+	if res := cl.send(ping); res.Type != coap.Reset || res.MessageID != 7 {
+		t.Errorf("ping = %+v", res)
+	}
+}
+
+// Removido o dono, o token do dispositivo deixa de valer (no CoAP e no
+// MQTT); restaurado, volta a valer
+func TestDeletedOwnerDeviceRejected(t *testing.T) {
+	env := newTestEnv(t)
+	_, adminToken := env.seedUser("root", models.RoleAdmin)
+	ana, anaToken := env.seedUser("coap_ana", models.RoleUser)
+	device := env.createSensor(ana.ID, anaToken)
+	cl := env.startCoAP()
+	heartbeat := fmt.Sprintf("devices/%d/heartbeat", device.ID)
+	bridge, err := mqttbridge.New(mqttbridge.Options{Topic: "devices/+/readings"}, env.db, env.handler.Telemetry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	topic := fmt.Sprintf("devices/%d/readings", device.ID)
+	reading := []byte(`{"metric":"temp","value":21}`)
+
+	if res := cl.post(coap.Confirmable, heartbeat, "token="+device.Token, nil); res.Code != coap.Changed {
+		t.Fatalf("antes da remoção: %s", res.Code)
+	}
+	expectStatus(t, env.do(http.MethodDelete, fmt.Sprintf("/users/%d", ana.ID), nil, adminToken), http.StatusOK)
+	if res := cl.post(coap.Confirmable, heartbeat, "token="+device.Token, nil); res.Code != coap.Unauthorized {
+		t.Errorf("dono removido: %s %s", res.Code, res.Payload)
+	}
+	if _, err := bridge.Handle(context.Background(), topic, reading); err == nil {
+		t.Error("MQTT gravou com o dono removido")
+	}
+
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/users/%d/restore", ana.ID), nil, adminToken), http.StatusOK)
+	if res := cl.post(coap.Confirmable, heartbeat, "token="+device.Token, nil); res.Code != coap.Changed {
+		t.Errorf("depois do restore: %s", res.Code)
+	}
+	if _, err := bridge.Handle(context.Background(), topic, reading); err != nil {
+		t.Errorf("MQTT depois do restore: %v", err)
+	}
+}
+
+// CBOR montado à mão, como o de um firmware: comprimento indefinido, float16
+// e o instante com a tag 1
+func TestCoAPCBORPayloads(t *testing.T) {
+	env := newTestEnv(t)
+	ana, anaToken := env.seedUser("coap_ana", models.RoleUser)
+	device := env.createSensor(ana.ID, anaToken)
+	cl := env.startCoAP()
+	path := fmt.Sprintf("devices/%d/readings", device.ID)
+	auth := "token=" + device.Token
+
+	reading := []byte{0xbf, 0x66, 'm', 'e', 't', 'r', 'i', 'c', 0x7f, 0x62, 't', 'e', 0x62, 'm', 'p', 0xff,
+		0x65, 'v', 'a', 'l', 'u', 'e', 0xf9, 0x3e, 0x00,
+		0x69, 't', 'i', 'm', 'e', 's', 't', 'a', 'm', 'p', 0xc1, 0x1a, 0x68, 0xe7, 0x78, 0x00, 0xff}
+	if res := cl.post(coap.Confirmable, path, auth, reading); res.Code != coap.Created {
+		t.Fatalf("leitura = %s %s", res.Code, res.Payload)
+	}
+	var stored models.Reading
+	env.db.Where("device_id = ?", device.ID).First(&stored)
+	if stored.Metric != "temp" || stored.Value != 1.5 || !stored.Timestamp.Equal(time.Unix(1760000000, 0)) {
+		t.Errorf("leitura gravada = %+v", stored)
+	}
+
+	for name, payload := range map[string][]byte{
+		"truncado":     {0x82, 0x01},
+		"chave número": {0xa1, 0x01, 0x02},
+		"sobra":        {0xa0, 0x02},
+		"fundo demais": bytes.Repeat([]byte{0x81}, 40),
+	} {
+		if res := cl.post(coap.Confirmable, path, auth, payload); res.Code != coap.BadRequest || !bytes.HasPrefix(res.Payload, []byte("Invalid CBOR")) {
+			t.Errorf("%s: %s %s", name, res.Code, res.Payload)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go_api/models"
+)
+
+func compressEnv(t *testing.T) (*testEnv, string) {
+	cfg := testCfg
+	cfg.CompressMinBytes = 512
+	env := newTestEnvWithConfig(t, cfg)
+	_, token := env.seedUser("admin", models.RoleAdmin)
+	for i := 0; i < 10; i++ {
+		env.seedUser(fmt.Sprintf("usuario%d", i), "")
+	}
+	return env, token
+}
+
+func getEncoded(env *testEnv, path, token, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestListUsersGzip(t *testing.T) {
+	env, token := compressEnv(t)
+
+	w := getEncoded(env, "/users?per_page=100", token, "br;q=1.0, gzip;q=0.8")
+	expectStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	var users []models.User
+	if err := json.NewDecoder(gz).Decode(&users); err != nil {
+		t.Fatalf("corpo descomprimido inválido: %v", err)
+	}
+	if len(users) != 11 {
+		t.Errorf("usuários = %d, want 11", len(users))
+	}
+}
+
+func TestListUsersDeflate(t *testing.T) {
+	env, token := compressEnv(t)
+
+	w := getEncoded(env, "/users?per_page=100", token, "gzip;q=0, deflate")
+	expectStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("Content-Encoding"); got != "deflate" {
+		t.Fatalf("Content-Encoding = %q, want deflate", got)
+	}
+	var users []models.User
+	if err := json.NewDecoder(flate.NewReader(w.Body)).Decode(&users); err != nil {
+		t.Fatalf("corpo descomprimido inválido: %v", err)
+	}
+}
+
+func TestResponseNotCompressed(t *testing.T) {
+	env, token := compressEnv(t)
+
+	// Cliente que não pediu compressão
+	w := getEncoded(env, "/users?per_page=100", token, "")
+	expectStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q, want none", got)
+	}
+	var users []models.User
+	decode(t, w, &users)
+
+	// Abaixo do limite
+	w = getEncoded(env, "/users?per_page=1", token, "gzip")
+	expectStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q, want none (corpo de %d bytes)", got, w.Body.Len())
+	}
+	decode(t, w, &users)
+
+	// Erros saem sem compressão
+	w = getEncoded(env, "/users?per_page=abc", token, "gzip")
+	expectStatus(t, w, http.StatusBadRequest)
+	decodeError(t, w)
+}
+
+func TestStreamedChangesGzip(t *testing.T) {
+	env, token := compressEnv(t)
+
+	w := getEncoded(env, "/changes", token, "gzip")
+	expectStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	lines := 0
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		lines++
+	}
+	if err := scanner.Err(); err != nil && err != io.EOF {
+		t.Fatalf("leitura: %v", err)
+	}
+	if lines < 11 {
+		t.Errorf("eventos = %d, want pelo menos 11", lines)
+	}
+}
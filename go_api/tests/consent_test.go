@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Consentimento é opt-in: as rotas que dependem dele dão 403 sem o registro
+func (e *testEnv) grantConsent(userID uint, token, purpose string) {
+	e.t.Helper()
+	w := e.do(http.MethodPost, fmt.Sprintf("/users/%d/consents", userID), gin.H{"purpose": purpose, "version": "v1"}, token)
+	expectStatus(e.t, w, http.StatusCreated)
+}
+
+// Renovar o termo encerra o registro anterior na mesma transação: se a
+// gravação do novo falha, o anterior continua ativo
+func TestGrantConsentIsAtomic(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	env.grantConsent(ana.ID, token, models.PurposeAnalytics)
+
+	env.db.Callback().Create().Before("gorm:create").Register("test:consent", func(db *gorm.DB) {
+		if db.Statement.Table == "consents" {
+			db.AddError(errors.New("disco cheio"))
+		}
+	})
+	t.Cleanup(func() { env.db.Callback().Create().Remove("test:consent") })
+	w := env.do(http.MethodPost, fmt.Sprintf("/users/%d/consents", ana.ID), gin.H{"purpose": models.PurposeAnalytics, "version": "v2"}, token)
+	expectStatus(t, w, http.StatusInternalServerError)
+
+	var active []models.Consent
+	env.db.Where("user_id = ? AND revoked_at IS NULL", ana.ID).Find(&active)
+	if len(active) != 1 || active[0].Version != "v1" {
+		t.Errorf("consentimentos ativos = %+v", active)
+	}
+}
+
+// Consentir é ato do titular: o admin só consulta e retira
+func TestGrantConsentOnlyBySelf(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	_, adminToken := env.seedUser("admin", models.RoleAdmin)
+
+	path := fmt.Sprintf("/users/%d/consents", ana.ID)
+	expectStatus(t, env.do(http.MethodPost, path, gin.H{"purpose": models.PurposeAnalytics, "version": "v1"}, adminToken), http.StatusForbidden)
+	env.grantConsent(ana.ID, token, models.PurposeAnalytics)
+
+	expectStatus(t, env.do(http.MethodGet, path, nil, adminToken), http.StatusOK)
+	expectStatus(t, env.do(http.MethodDelete, path+"/"+models.PurposeAnalytics, nil, adminToken), http.StatusOK)
+}
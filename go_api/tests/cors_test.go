@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func corsEnv(t *testing.T, credentials bool, origins ...string) *testEnv {
+	cfg := testCfg
+	cfg.CORSAllowedOrigins = origins
+	cfg.CORSAllowedMethods = []string{"GET", "POST", "PATCH"}
+	cfg.CORSAllowedHeaders = []string{"Authorization", "Content-Type", "If-Match"}
+	cfg.CORSAllowCredentials = credentials
+	return newTestEnvWithConfig(t, cfg)
+}
+
+func preflight(env *testEnv, origin, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+	req.Header.Set("Access-Control-Request-Headers", "authorization, if-match")
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSPreflightFromAllowedOrigin(t *testing.T) {
+	env := corsEnv(t, true, "https://painel.exemplo.com")
+
+	w := preflight(env, "https://painel.exemplo.com", "/users/1")
+	expectStatus(t, w, http.StatusNoContent)
+	for header, want := range map[string]string{
+		"Access-Control-Allow-Origin":      "https://painel.exemplo.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, POST, PATCH",
+		"Access-Control-Allow-Headers":     "Authorization, Content-Type, If-Match",
+		"Access-Control-Max-Age":           "0",
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestCORSRejectsUnknownOrigin(t *testing.T) {
+	env := corsEnv(t, false, "https://painel.exemplo.com")
+
+	w := preflight(env, "https://malicioso.exemplo.com", "/users/1")
+	expectStatus(t, w, http.StatusForbidden)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+	}
+
+	// Requisição simples segue sem os cabeçalhos: o navegador não a entrega
+	req := httptest.NewRequest(http.MethodGet, "/time", nil)
+	req.Header.Set("Origin", "https://malicioso.exemplo.com")
+	w = httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	expectStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+	}
+}
+
+func TestCORSExposesHeadersOnActualRequest(t *testing.T) {
+	env := corsEnv(t, false, "*")
+
+	req := httptest.NewRequest(http.MethodGet, "/time", nil)
+	req.Header.Set("Origin", "https://qualquer.exemplo.com")
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	expectStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got == "" {
+		t.Error("Access-Control-Expose-Headers ausente")
+	}
+}
+
+func TestCORSDisabledWithoutOrigins(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := preflight(env, "https://painel.exemplo.com", "/users/1")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+	}
+}
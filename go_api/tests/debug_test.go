@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_api/router"
+)
+
+func TestDebugEndpointsAdminOnly(t *testing.T) {
+	cfg := testCfg
+	cfg.DebugEndpoints = true
+	env := newTestEnvWithConfig(t, cfg)
+	_, userToken := env.seedUser("ana", "user")
+	_, adminToken := env.seedUser("admin", "admin")
+
+	expectStatus(t, env.do(http.MethodGet, "/debug/vars", nil, ""), http.StatusUnauthorized)
+	expectStatus(t, env.do(http.MethodGet, "/debug/pprof/heap", nil, userToken), http.StatusForbidden)
+
+	w := env.do(http.MethodGet, "/debug/vars", nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	var vars struct {
+		Goroutines int                    `json:"goroutines"`
+		Memory     map[string]interface{} `json:"memory"`
+		GC         map[string]interface{} `json:"gc"`
+	}
+	decode(t, w, &vars)
+	if vars.Goroutines == 0 || vars.Memory["heap_alloc_bytes"] == nil || vars.GC["count"] == nil {
+		t.Fatalf("/debug/vars incompleto: %s", w.Body.String())
+	}
+
+	w = env.do(http.MethodGet, "/debug/pprof/goroutine?debug=1", nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	if !strings.Contains(w.Body.String(), "goroutine profile") {
+		t.Fatalf("perfil de goroutines inesperado: %.200s", w.Body.String())
+	}
+}
+
+func TestDebugEndpointsOffByDefault(t *testing.T) {
+	env := newTestEnv(t)
+	_, adminToken := env.seedUser("admin", "admin")
+	expectStatus(t, env.do(http.MethodGet, "/debug/vars", nil, adminToken), http.StatusNotFound)
+}
+
+// A porta DEBUG_ADDR não pede autenticação: só o pprof e o /debug/vars
+func TestDebugServer(t *testing.T) {
+	env := newTestEnv(t)
+	debug := router.NewDebug(env.handler)
+
+	for _, path := range []string{"/debug/vars", "/debug/pprof/", "/debug/pprof/heap"} {
+		w := httptest.NewRecorder()
+		debug.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		expectStatus(t, w, http.StatusOK)
+	}
+	w := httptest.NewRecorder()
+	debug.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+	expectStatus(t, w, http.StatusNotFound)
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go_api/docs"
+	"go_api/handlers"
+)
+
+var ginParam = regexp.MustCompile(`:(\w+)`)
+
+// Toda rota registrada no router precisa estar na especificação OpenAPI. O
+// caminho documentado é relativo ao servidor (/api/v1), sem a versão.
+func TestOpenAPICoversRoutes(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(docs.Spec, &spec); err != nil {
+		t.Fatalf("openapi.json inválido: %v", err)
+	}
+
+	cfg := testCfg
+	cfg.ChaosMode = true      // inclui as rotas /chaos
+	cfg.DebugEndpoints = true // e as /debug
+	env := newTestEnvWithConfig(t, cfg)
+	for _, route := range env.router.Routes() {
+		// HEAD é o próprio GET, sem o corpo
+		if route.Path == "/docs" || route.Path == "/openapi.json" || route.Method == http.MethodHead {
+			continue
+		}
+		path := route.Path
+		for _, version := range handlers.APIVersions {
+			path = strings.TrimPrefix(path, handlers.APIPrefix(version))
+		}
+		path = ginParam.ReplaceAllString(path, "{$1}")
+		if _, ok := spec.Paths[path][strings.ToLower(route.Method)]; !ok {
+			t.Errorf("%s %s não está documentada em docs/openapi.json", route.Method, path)
+		}
+	}
+}
+
+func TestDocsEndpoints(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do(http.MethodGet, "/openapi.json", nil, "")
+	expectStatus(t, w, http.StatusOK)
+	if !json.Valid(w.Body.Bytes()) {
+		t.Fatal("/openapi.json não devolveu JSON")
+	}
+	w = env.do(http.MethodGet, "/docs", nil, "")
+	expectStatus(t, w, http.StatusOK)
+	if !strings.Contains(w.Body.String(), "swagger-ui") {
+		t.Fatalf("/docs sem o Swagger UI: %s", w.Body.String())
+	}
+}
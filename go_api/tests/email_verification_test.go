@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Token do último link de verificação enviado para email
+func (e *testEnv) verificationToken(email string) string {
+	e.t.Helper()
+	sent := e.mail.messages()
+	for i := len(sent) - 1; i >= 0; i-- {
+		if sent[i].To != email {
+			continue
+		}
+		if _, rest, ok := strings.Cut(sent[i].Body, "Confirm your email address: "); ok {
+			token, _, _ := strings.Cut(rest, "\n")
+			return token
+		}
+	}
+	e.t.Fatalf("nenhum link de verificação para %s", email)
+	return ""
+}
+
+func (e *testEnv) verifyEmail(token string) *httptest.ResponseRecorder {
+	e.t.Helper()
+	return e.do(http.MethodGet, "/verify-email?token="+url.QueryEscape(token), nil, "")
+}
+
+func TestEmailVerification(t *testing.T) {
+	env := newTestEnv(t)
+	user, token := env.seedUser("ana", models.RoleUser)
+	if user.EmailVerified {
+		t.Fatal("usuário novo já verificado")
+	}
+
+	link := env.verificationToken("ana@exemplo.com")
+	expectStatus(t, env.verifyEmail(link), http.StatusOK)
+
+	w := env.do(http.MethodGet, fmt.Sprintf("/users/%d", user.ID), nil, token)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &user)
+	if !user.EmailVerified {
+		t.Error("email_verified = false depois do link")
+	}
+
+	// O link vale uma vez
+	w = env.verifyEmail(link)
+	expectStatus(t, w, http.StatusBadRequest)
+	if body := decodeError(t, w); body.Code != "invalid_verification_token" {
+		t.Errorf("code = %q", body.Code)
+	}
+}
+
+func TestEmailChangeRequiresVerification(t *testing.T) {
+	env := newTestEnv(t)
+	user, token := env.seedUser("ana", models.RoleUser)
+	old := env.verificationToken("ana@exemplo.com")
+	expectStatus(t, env.verifyEmail(old), http.StatusOK)
+
+	w := env.do(http.MethodPatch, fmt.Sprintf("/users/%d", user.ID), gin.H{"email": "ana.nova@exemplo.com"}, token)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &user)
+	if user.EmailVerified {
+		t.Error("email_verified continua true depois de trocar o e-mail")
+	}
+	expectStatus(t, env.verifyEmail(env.verificationToken("ana.nova@exemplo.com")), http.StatusOK)
+}
+
+// O push do sync é outra forma de trocar o e-mail: o link também sai
+func TestSyncEmailChangeSendsVerification(t *testing.T) {
+	env := newTestEnv(t)
+	user, token := env.seedUser("ana", models.RoleUser)
+
+	change := gin.H{"id": user.ID, "op": "update", "base_revision": user.Revision, "modified_at": time.Now().UTC(), "data": gin.H{"email": "ana.offline@exemplo.com"}}
+	expectStatus(t, env.do(http.MethodPost, "/sync/push", gin.H{"client_id": "celular", "changes": []gin.H{change}}, token), http.StatusOK)
+	expectStatus(t, env.verifyEmail(env.verificationToken("ana.offline@exemplo.com")), http.StatusOK)
+}
+
+func TestLoginRequiresVerifiedEmail(t *testing.T) {
+	cfg := testCfg
+	cfg.RequireEmailVerification = true
+	env := newTestEnvWithConfig(t, cfg)
+
+	expectStatus(t, env.do(http.MethodPost, "/users", gin.H{
+		"name": "Ana", "email": "ana@exemplo.com", "user": "ana", "password": "senha-ana",
+	}, ""), http.StatusCreated)
+
+	w := env.do(http.MethodPost, "/login", gin.H{"user": "ana", "password": "senha-ana"}, "")
+	expectStatus(t, w, http.StatusForbidden)
+	if body := decodeError(t, w); body.Code != "email_not_verified" {
+		t.Errorf("code = %q", body.Code)
+	}
+
+	// Link perdido: pede outro, e o anterior deixa de valer
+	first := env.verificationToken("ana@exemplo.com")
+	expectStatus(t, env.do(http.MethodPost, "/verify-email/resend", gin.H{"email": "ana@exemplo.com"}, ""), http.StatusAccepted)
+	expectStatus(t, env.verifyEmail(first), http.StatusBadRequest)
+	expectStatus(t, env.verifyEmail(env.verificationToken("ana@exemplo.com")), http.StatusOK)
+	env.login("ana", "senha-ana")
+}
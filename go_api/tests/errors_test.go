@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"go_api/models"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	env := newTestEnv(t)
+	_, token := env.seedUser("ana", models.RoleUser)
+
+	for _, tc := range []struct {
+		name, method, path, token string
+		status                    int
+		code                      string
+	}{
+		{"sem token", http.MethodGet, "/users/1", "", http.StatusUnauthorized, "unauthorized"},
+		{"não é admin", http.MethodGet, "/users", token, http.StatusForbidden, "forbidden"},
+		{"dispositivo inexistente", http.MethodGet, "/devices/999999", token, http.StatusNotFound, "not_found"},
+		{"corpo vazio", http.MethodPost, "/login", "", http.StatusBadRequest, "bad_request"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := env.do(tc.method, tc.path, nil, tc.token)
+			expectStatus(t, w, tc.status)
+			body := decodeError(t, w)
+			if body.Code != tc.code || body.Message == "" {
+				t.Errorf("erro = %+v, want code %q", body, tc.code)
+			}
+			// O request_id é o mesmo do cabeçalho, para cruzar com os logs
+			if body.RequestID == "" || body.RequestID != w.Header().Get("X-Request-ID") {
+				t.Errorf("request_id = %q, header = %q", body.RequestID, w.Header().Get("X-Request-ID"))
+			}
+		})
+	}
+}
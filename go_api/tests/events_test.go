@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go_api/models"
+
+	"gorm.io/gorm"
+)
+
+// Falha ao ler os eventos é 500, não "usuário não encontrado"
+func TestUserEventsDatabaseError(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+
+	env.db.Callback().Query().Before("gorm:query").Register("test:events", func(db *gorm.DB) {
+		if db.Statement.Table == "user_events" {
+			db.AddError(errors.New("conexão perdida"))
+		}
+	})
+	t.Cleanup(func() { env.db.Callback().Query().Remove("test:events") })
+	for _, path := range []string{"events", "history"} {
+		w := env.do(http.MethodGet, fmt.Sprintf("/users/%d/%s", ana.ID, path), nil, token)
+		expectStatus(t, w, http.StatusInternalServerError)
+	}
+}
+
+func TestPollEventsDatabaseError(t *testing.T) {
+	env := newTestEnv(t)
+	_, adminToken := env.seedUser("admin", models.RoleAdmin)
+
+	env.db.Callback().Query().Before("gorm:query").Register("test:events", func(db *gorm.DB) {
+		if db.Statement.Table == "user_events" {
+			db.AddError(errors.New("conexão perdida"))
+		}
+	})
+	t.Cleanup(func() { env.db.Callback().Query().Remove("test:events") })
+	expectStatus(t, env.do(http.MethodGet, "/events/poll?wait=0s", nil, adminToken), http.StatusInternalServerError)
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestExportUsersCSV(t *testing.T) {
+	env := newTestEnv(t)
+	_, admin := env.seedUser("root", models.RoleAdmin)
+	env.seedUser("ana", models.RoleUser)
+	bia, _ := env.seedUser("bia", models.RoleUser)
+	expectStatus(t, env.do(http.MethodDelete, fmt.Sprintf("/users/%d", bia.ID), nil, admin), http.StatusOK)
+
+	// Fórmula no nome não pode chegar executável na planilha
+	w := env.do(http.MethodPost, "/users", gin.H{
+		"name": "=HYPERLINK(\"x\")", "email": "caio@exemplo.com", "user": "caio", "password": "senha-caio",
+	}, "")
+	expectStatus(t, w, http.StatusCreated)
+
+	w = env.do(http.MethodGet, "/users/export", nil, admin)
+	expectStatus(t, w, http.StatusOK)
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, `attachment; filename="users-`) || !strings.HasSuffix(cd, `.csv"`) {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	if strings.Join(rows[0], ",") != "id,name,email,user,role,region,email_verified,revision,deleted_at" {
+		t.Fatalf("cabeçalho = %v", rows[0])
+	}
+	byUser := map[string][]string{}
+	for _, row := range rows[1:] {
+		byUser[row[3]] = row
+	}
+	if byUser["ana"] == nil || byUser["root"][4] != "admin" {
+		t.Fatalf("linhas = %v", rows[1:])
+	}
+	if byUser["bia"] != nil {
+		t.Error("usuário removido exportado sem include_deleted")
+	}
+	if got := byUser["caio"][1]; got != `'=HYPERLINK("x")` {
+		t.Errorf("nome = %q", got)
+	}
+
+	// Removidos só com include_deleted, com a data da remoção
+	w = env.do(http.MethodGet, "/users/export?include_deleted=true&filter=user==bia", nil, admin)
+	expectStatus(t, w, http.StatusOK)
+	rows, _ = csv.NewReader(w.Body).ReadAll()
+	if len(rows) != 2 || rows[1][8] == "" {
+		t.Fatalf("linhas = %v", rows)
+	}
+}
+
+func TestExportUsersJSON(t *testing.T) {
+	env := newTestEnv(t)
+	_, admin := env.seedUser("root", models.RoleAdmin)
+	env.seedUser("ana", models.RoleUser)
+
+	w := env.do(http.MethodGet, "/users/export?format=json&sort=-id", nil, admin)
+	expectStatus(t, w, http.StatusOK)
+	if !strings.HasSuffix(w.Header().Get("Content-Disposition"), `.json"`) {
+		t.Errorf("Content-Disposition = %q", w.Header().Get("Content-Disposition"))
+	}
+	var users []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &users); err != nil {
+		t.Fatalf("json: %v; body: %s", err, w.Body.String())
+	}
+	if len(users) != 2 || users[0]["user"] != "ana" {
+		t.Fatalf("usuários = %v", users)
+	}
+	if _, ok := users[0]["password"]; ok {
+		t.Error("senha exportada")
+	}
+}
+
+func TestExportUsersRules(t *testing.T) {
+	env := newTestEnv(t)
+	_, admin := env.seedUser("root", models.RoleAdmin)
+	_, token := env.seedUser("ana", models.RoleUser)
+
+	expectStatus(t, env.do(http.MethodGet, "/users/export", nil, token), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodGet, "/users/export?format=xml", nil, admin), http.StatusBadRequest)
+	expectStatus(t, env.do(http.MethodGet, "/users/export?filter=senha==x", nil, admin), http.StatusBadRequest)
+}
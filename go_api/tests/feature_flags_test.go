@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"go_api/models"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFeatureFlagsManagement(t *testing.T) {
+	env := newTestEnv(t)
+	_, platform := env.seedUser("root", models.RoleAdmin)
+	env.createTenant(platform, "acme")
+	_, acmeAdmin := env.seedTenantUser("acme", "chefe", models.RoleAdmin)
+
+	flag := gin.H{"key": "new-auth-flow", "description": "Login em duas etapas", "enabled": true, "percentage": 10, "groups": []string{"canary"}}
+	// Só o admin da plataforma: a flag vale para todos os tenants
+	expectStatus(t, env.do(http.MethodPost, "/admin/flags", flag, acmeAdmin), http.StatusForbidden)
+
+	w := env.do(http.MethodPost, "/admin/flags", flag, platform)
+	expectStatus(t, w, http.StatusCreated)
+	var created models.FeatureFlag
+	decode(t, w, &created)
+	if created.Key != "new-auth-flow" || !created.Enabled || created.Percentage != 10 || string(created.TenantIDs) != "[]" || string(created.Groups) != `["canary"]` {
+		t.Fatalf("criada = %+v", created)
+	}
+	w = env.do(http.MethodPost, "/admin/flags", flag, platform)
+	expectStatus(t, w, http.StatusConflict)
+	if body := decodeError(t, w); body.Details["key"] == "" {
+		t.Errorf("conflito sem o campo: %+v", body)
+	}
+	for _, bad := range []gin.H{{"key": "Com Espaço"}, {"key": "pct", "percentage": 101}, {"key": "grp", "groups": []string{""}}} {
+		expectStatus(t, env.do(http.MethodPost, "/admin/flags", bad, platform), http.StatusBadRequest)
+	}
+
+	w = env.do(http.MethodPut, "/admin/flags/new-auth-flow", gin.H{"enabled": false, "tenant_ids": []uint{2}}, platform)
+	expectStatus(t, w, http.StatusOK)
+	var updated models.FeatureFlag
+	decode(t, w, &updated)
+	if updated.Enabled || updated.Percentage != 0 || string(updated.TenantIDs) != "[2]" || string(updated.Groups) != "[]" {
+		t.Fatalf("PUT substitui as regras: %+v", updated)
+	}
+
+	w = env.do(http.MethodGet, "/admin/flags", nil, platform)
+	expectStatus(t, w, http.StatusOK)
+	var flags []models.FeatureFlag
+	decode(t, w, &flags)
+	if len(flags) != 1 {
+		t.Fatalf("flags = %+v", flags)
+	}
+	expectStatus(t, env.do(http.MethodDelete, "/admin/flags/new-auth-flow", nil, platform), http.StatusOK)
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		expectStatus(t, env.do(method, "/admin/flags/new-auth-flow", nil, platform), http.StatusNotFound)
+	}
+	expectStatus(t, env.do(http.MethodPut, "/admin/flags/nao-existe", gin.H{}, platform), http.StatusNotFound)
+}
+
+func TestFeatureFlagsEvaluation(t *testing.T) {
+	env := newTestEnv(t)
+	flags := env.handler.Flags
+	ctx := context.Background()
+	create := func(input models.CreateFeatureFlagInput) {
+		t.Helper()
+		if _, err := flags.Create(ctx, 1, input); err != nil {
+			t.Fatalf("flag %s: %v", input.Key, err)
+		}
+	}
+	create(models.CreateFeatureFlagInput{Key: "off", FeatureFlagInput: models.FeatureFlagInput{Percentage: 100}})
+	create(models.CreateFeatureFlagInput{Key: "acme", FeatureFlagInput: models.FeatureFlagInput{Enabled: true, TenantIDs: []uint{2}}})
+	create(models.CreateFeatureFlagInput{Key: "half", FeatureFlagInput: models.FeatureFlagInput{Enabled: true, Percentage: 50}})
+	create(models.CreateFeatureFlagInput{Key: "canary", FeatureFlagInput: models.FeatureFlagInput{Enabled: true, Percentage: 100, Groups: []string{"canary"}}})
+
+	user := service.FlagSubject{TenantID: models.DefaultTenantID, UserID: 7}
+	if flags.Enabled(ctx, "off", user) || flags.Enabled(ctx, "nao-existe", user) {
+		t.Error("flag desligada ou desconhecida valeu")
+	}
+	if flags.Enabled(ctx, "acme", user) || !flags.Enabled(ctx, "acme", service.FlagSubject{TenantID: 2}) {
+		t.Error("flag por tenant")
+	}
+	// Fora do grupo de réplicas da flag, nem com 100%
+	if flags.Enabled(ctx, "canary", user) {
+		t.Error("réplica sem REPLICA_GROUP ligou a flag do canary")
+	}
+	if !service.NewFeatureFlags(env.db, "canary", time.Minute).Enabled(ctx, "canary", user) {
+		t.Error("réplica do canary não ligou a flag")
+	}
+
+	// Percentual: mais ou menos metade, e o mesmo usuário sempre do mesmo lado
+	on := 0
+	for id := uint(1); id <= 1000; id++ {
+		subject := service.FlagSubject{TenantID: models.DefaultTenantID, UserID: id}
+		enabled := flags.Enabled(ctx, "half", subject)
+		if enabled != flags.Enabled(ctx, "half", subject) {
+			t.Fatalf("usuário %d mudou de lado", id)
+		}
+		if enabled {
+			on++
+		}
+	}
+	if on < 400 || on > 600 {
+		t.Errorf("50%% ligou para %d de 1000", on)
+	}
+	if flags.Enabled(ctx, "half", service.FlagSubject{TenantID: models.DefaultTenantID}) {
+		t.Error("percentual sem usuário")
+	}
+}
+
+func TestFeatureFlagsCache(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	other := service.NewFeatureFlags(env.db, "", time.Hour) // outra réplica
+	user := service.FlagSubject{TenantID: models.DefaultTenantID, UserID: 1}
+	if _, err := env.handler.Flags.Create(ctx, 1, models.CreateFeatureFlagInput{Key: "beta"}); err != nil {
+		t.Fatalf("flag: %v", err)
+	}
+	if other.Enabled(ctx, "beta", user) {
+		t.Fatal("flag desligada valeu")
+	}
+
+	// Quem grava enxerga na hora; a outra réplica só depois do refresh
+	on := models.FeatureFlagInput{Enabled: true, Percentage: 100}
+	if _, err := env.handler.Flags.Update(ctx, 1, "beta", on); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if !env.handler.Flags.Enabled(ctx, "beta", user) {
+		t.Error("réplica que gravou não viu a alteração")
+	}
+	if other.Enabled(ctx, "beta", user) {
+		t.Error("cache da outra réplica relido antes do refresh")
+	}
+	if !service.NewFeatureFlags(env.db, "", 0).Enabled(ctx, "beta", user) {
+		t.Error("refresh 0 não leu do banco")
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// Chaves do JSON de cada item, para conferir que só vieram as pedidas
+func jsonKeys(t *testing.T, raw []byte) []map[string]json.RawMessage {
+	t.Helper()
+	var rows []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		var row map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &row); err != nil {
+			t.Fatalf("JSON inválido: %s", raw)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func TestSparseFields(t *testing.T) {
+	env := newTestEnv(t)
+	_, adminToken := env.seedUser("campos_admin", "admin")
+	ana, anaToken := env.seedUser("campos_ana", "")
+	env.createDevice(ana.ID, anaToken)
+
+	// Guarda o SQL das consultas de usuários e dispositivos
+	var mu sync.Mutex
+	var queries []string
+	env.db.Callback().Query().After("gorm:query").Register("test:fields", func(db *gorm.DB) {
+		mu.Lock()
+		defer mu.Unlock()
+		queries = append(queries, db.Statement.SQL.String())
+	})
+	t.Cleanup(func() { env.db.Callback().Query().Remove("test:fields") })
+	lastQuery := func(table string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		for i := len(queries) - 1; i >= 0; i-- {
+			if strings.Contains(queries[i], "FROM `"+table+"`") || strings.Contains(queries[i], `FROM "`+table+`"`) {
+				return queries[i]
+			}
+		}
+		return ""
+	}
+
+	w := env.do(http.MethodGet, "/users?fields=name,email", nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	for _, row := range jsonKeys(t, w.Body.Bytes()) {
+		if len(row) != 2 || row["name"] == nil || row["email"] == nil {
+			t.Errorf("GET /users?fields=name,email item = %v", row)
+		}
+	}
+	if q := lastQuery("users"); strings.Contains(q, "*") || strings.Contains(q, "revision") || !strings.Contains(q, "email") {
+		t.Errorf("SELECT dos usuários = %s, quer só as colunas pedidas", q)
+	}
+
+	// A ordem da resposta é a pedida; keyset continua com o cursor
+	w = env.do(http.MethodGet, "/users?after_id=0&per_page=1&fields=user,id", nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	if body := w.Body.String(); !strings.HasPrefix(body, `{"data":[{"user":`) || !strings.Contains(body, `"next_cursor":`) {
+		t.Errorf("keyset = %s", body)
+	}
+
+	w = env.do(http.MethodGet, fmt.Sprintf("/users/%d?fields=id,name", ana.ID), nil, anaToken)
+	expectStatus(t, w, http.StatusOK)
+	if rows := jsonKeys(t, w.Body.Bytes()); len(rows[0]) != 2 || w.Header().Get("ETag") == "" {
+		t.Errorf("GET /users/:id?fields=id,name = %s", w.Body)
+	}
+
+	w = env.do(http.MethodGet, fmt.Sprintf("/users/%d/devices?fields=name,online", ana.ID), nil, anaToken)
+	expectStatus(t, w, http.StatusOK)
+	if rows := jsonKeys(t, w.Body.Bytes()); len(rows) != 1 || len(rows[0]) != 2 || string(rows[0]["online"]) != "false" {
+		t.Errorf("dispositivos = %s", w.Body)
+	}
+	if q := lastQuery("devices"); strings.Contains(q, "*") || strings.Contains(q, "token") || !strings.Contains(q, "last_seen") {
+		t.Errorf("SELECT dos dispositivos = %s", q)
+	}
+
+	w = env.do(http.MethodGet, "/devices?fields=type", nil, anaToken)
+	expectStatus(t, w, http.StatusOK)
+	if rows := jsonKeys(t, w.Body.Bytes()); len(rows) != 1 || string(rows[0]["type"]) != `"wearable"` || len(rows[0]) != 1 {
+		t.Errorf("GET /devices?fields=type = %s", w.Body)
+	}
+
+	// A senha (e qualquer campo fora da lista) é recusada
+	for _, fields := range []string{"name,password", "", "name,,email"} {
+		w = env.do(http.MethodGet, "/users?fields="+fields, nil, adminToken)
+		expectStatus(t, w, http.StatusBadRequest)
+		if e := decodeError(t, w); e.Code != "invalid_fields" {
+			t.Errorf("fields=%q: erro = %+v", fields, e)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type graphqlError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path"`
+	Extensions map[string]interface{} `json:"extensions"`
+}
+
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []graphqlError  `json:"errors"`
+}
+
+func (e *testEnv) graphql(token, query string, variables gin.H) (*graphqlResponse, int) {
+	e.t.Helper()
+	w := e.do(http.MethodPost, "/graphql", gin.H{"query": query, "variables": variables}, token)
+	var res graphqlResponse
+	decode(e.t, w, &res)
+	return &res, w.Code
+}
+
+func TestGraphQLNestedQuery(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, token)
+	env.postReadings(device, token, []gin.H{
+		{"metric": "temp", "value": 21, "timestamp": "2026-10-14T10:00:00Z"},
+		{"metric": "humidity", "value": 40, "timestamp": "2026-10-14T10:05:00Z"},
+		{"metric": "temp", "value": 23.5, "timestamp": "2026-10-14T10:10:00Z"},
+	})
+
+	// O usuário, os dispositivos e as últimas leituras numa requisição só
+	res, status := env.graphql(token, `
+		query Dashboard($metric: String) {
+			me {
+				id
+				name
+				devices {
+					...DeviceCard
+					temps: readings(metric: $metric, limit: 5) { value timestamp }
+				}
+			}
+		}
+		fragment DeviceCard on Device {
+			__typename
+			name
+			online
+			latest_reading { metric value }
+		}`, gin.H{"metric": "temp"})
+	if status != http.StatusOK || len(res.Errors) > 0 {
+		t.Fatalf("status %d, erros %+v", status, res.Errors)
+	}
+	var data struct {
+		Me struct {
+			ID      string `json:"id"`
+			Name    string `json:"name"`
+			Devices []struct {
+				Typename string `json:"__typename"`
+				Name     string `json:"name"`
+				Online   bool   `json:"online"`
+				Latest   struct {
+					Metric string  `json:"metric"`
+					Value  float64 `json:"value"`
+				} `json:"latest_reading"`
+				Temps []struct {
+					Value     float64 `json:"value"`
+					Timestamp string  `json:"timestamp"`
+				} `json:"temps"`
+			} `json:"devices"`
+		} `json:"me"`
+	}
+	if err := json.Unmarshal(res.Data, &data); err != nil {
+		t.Fatalf("data: %v (%s)", err, res.Data)
+	}
+	if data.Me.ID != fmt.Sprint(ana.ID) || data.Me.Name != ana.Name || len(data.Me.Devices) != 1 {
+		t.Fatalf("me = %s", res.Data)
+	}
+	d := data.Me.Devices[0]
+	if d.Typename != "Device" || d.Name != device.Name || !d.Online || d.Latest.Metric != "temp" || d.Latest.Value != 23.5 {
+		t.Errorf("dispositivo = %+v", d)
+	}
+	if len(d.Temps) != 2 || d.Temps[0].Value != 23.5 || d.Temps[1].Timestamp != "2026-10-14T10:00:00Z" {
+		t.Errorf("temps = %+v", d.Temps)
+	}
+	// Os campos saem na ordem da consulta
+	if !strings.HasPrefix(string(res.Data), `{"me":{"id":`) {
+		t.Errorf("ordem = %s", res.Data)
+	}
+
+	// GET também serve para consultas
+	w := env.do(http.MethodGet, "/graphql?query="+url.QueryEscape("{ me { user } }"), nil, token)
+	expectStatus(t, w, http.StatusOK)
+	if !strings.Contains(w.Body.String(), `"user":"ana"`) {
+		t.Errorf("GET = %s", w.Body.String())
+	}
+}
+
+func TestGraphQLAccess(t *testing.T) {
+	env := newTestEnv(t)
+	ana, anaToken := env.seedUser("ana", models.RoleUser)
+	_, bobToken := env.seedUser("bob", models.RoleUser)
+	_, adminToken := env.seedUser("admin", models.RoleAdmin)
+	device := env.createDevice(ana.ID, anaToken)
+
+	// Sem token, nem chega ao executor
+	expectStatus(t, env.do(http.MethodPost, "/graphql", gin.H{"query": "{ me { id } }"}, ""), http.StatusUnauthorized)
+
+	// Outro usuário: os campos recusados viram null com o erro do REST, e o
+	// resto da consulta ainda responde
+	res, status := env.graphql(bobToken, `query($id: ID!, $device: ID!) {
+		me { user }
+		user(id: $id) { name }
+		device(id: $device) { name }
+	}`, gin.H{"id": ana.ID, "device": fmt.Sprint(device.ID)})
+	if status != http.StatusOK || string(res.Data) != `{"me":{"user":"bob"},"user":null,"device":null}` {
+		t.Fatalf("status %d, data %s", status, res.Data)
+	}
+	if len(res.Errors) != 2 || res.Errors[0].Extensions["code"] != "forbidden" || res.Errors[0].Path[0] != "user" ||
+		res.Errors[1].Path[0] != "device" || res.Errors[1].Message != "You can only access your own devices" {
+		t.Errorf("erros = %+v", res.Errors)
+	}
+	res, _ = env.graphql(bobToken, `{ users { id } }`, nil)
+	if string(res.Data) != "null" || len(res.Errors) != 1 || res.Errors[0].Extensions["status"] != float64(http.StatusForbidden) {
+		t.Errorf("users como user = %s %+v", res.Data, res.Errors)
+	}
+	res, _ = env.graphql(bobToken, `{ device(id: "999999") { id } }`, nil)
+	if len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != "not_found" {
+		t.Errorf("dispositivo inexistente = %+v", res.Errors)
+	}
+
+	// Admin lista todo mundo (paginado pelo id) e enxerga os dispositivos
+	res, _ = env.graphql(adminToken, fmt.Sprintf(`{
+		page: users(first: 2) { user }
+		next: users(first: 2, after: %d) { user devices { owner { user } } }
+	}`, ana.ID), nil)
+	want := `{"page":[{"user":"ana"},{"user":"bob"}],"next":[{"user":"bob","devices":[]},{"user":"admin","devices":[]}]}`
+	if len(res.Errors) > 0 || string(res.Data) != want {
+		t.Errorf("admin = %s %+v", res.Data, res.Errors)
+	}
+	res, _ = env.graphql(adminToken, fmt.Sprintf(`{ device(id: %d) { owner { user } } }`, device.ID), nil)
+	if string(res.Data) != `{"device":{"owner":{"user":"ana"}}}` {
+		t.Errorf("dono = %s %+v", res.Data, res.Errors)
+	}
+}
+
+func TestGraphQLMutations(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	bob, _ := env.seedUser("bob", models.RoleUser)
+
+	res, _ := env.graphql(token, `mutation($input: DeviceInput!) {
+		create_device(input: $input) { token device { id name type user_id } }
+	}`, gin.H{"input": gin.H{"name": "Sensor da sala", "type": "sensor"}})
+	var created struct {
+		CreateDevice struct {
+			Token  string `json:"token"`
+			Device struct {
+				ID     string `json:"id"`
+				Name   string `json:"name"`
+				UserID string `json:"user_id"`
+			} `json:"device"`
+		} `json:"create_device"`
+	}
+	json.Unmarshal(res.Data, &created)
+	if len(res.Errors) > 0 || created.CreateDevice.Token == "" || created.CreateDevice.Device.UserID != fmt.Sprint(ana.ID) {
+		t.Fatalf("create_device = %s %+v", res.Data, res.Errors)
+	}
+	id := created.CreateDevice.Device.ID
+
+	// Mesmas validações do REST
+	res, status := env.graphql(token, `mutation { create_device(input: {name: "X", type: "toaster"}) { token } }`, nil)
+	if status != http.StatusOK || string(res.Data) != "null" || len(res.Errors) != 1 ||
+		res.Errors[0].Extensions["code"] != "validation_failed" || res.Errors[0].Extensions["details"].(map[string]interface{})["type"] == nil {
+		t.Errorf("tipo inválido = %d %s %+v", status, res.Data, res.Errors)
+	}
+	res, _ = env.graphql(token, fmt.Sprintf(`mutation { create_device(user_id: %d, input: {name: "X", type: "phone"}) { token } }`, bob.ID), nil)
+	if len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != "forbidden" {
+		t.Errorf("dispositivo para outro usuário = %+v", res.Errors)
+	}
+
+	// As mutações rodam em ordem: a leitura gravada já aparece na consulta
+	// do dispositivo renomeado
+	res, _ = env.graphql(token, `mutation($id: ID!) {
+		stored: create_readings(device_id: $id, readings: [{metric: "temp", value: 19.5, payload: {door: "open"}}, {metric: "temp", value: 20}])
+		update_device(id: $id, input: {name: "Sensor do quarto", type: "sensor"}) {
+			name
+			readings(limit: 1) { value payload }
+		}
+		update_user(input: {name: "Ana Maria"}) { name revision }
+	}`, gin.H{"id": id})
+	if len(res.Errors) > 0 || !strings.HasPrefix(string(res.Data), `{"stored":2,"update_device":{"name":"Sensor do quarto","readings":[{"value":`) ||
+		!strings.Contains(string(res.Data), `"update_user":{"name":"Ana Maria","revision":2}`) {
+		t.Fatalf("mutações = %s %+v", res.Data, res.Errors)
+	}
+	res, _ = env.graphql(token, `mutation($id: ID!) { create_readings(device_id: $id, readings: []) }`, gin.H{"id": id})
+	if len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != "bad_request" {
+		t.Errorf("lote vazio = %+v", res.Errors)
+	}
+
+	// Mutação não passa pelo GET
+	w := env.do(http.MethodGet, "/graphql?query="+url.QueryEscape(fmt.Sprintf(`mutation { delete_device(id: %s) }`, id)), nil, token)
+	expectStatus(t, w, http.StatusBadRequest)
+
+	res, _ = env.graphql(token, `mutation($id: ID!) { delete_device(id: $id) }`, gin.H{"id": id})
+	if string(res.Data) != `{"delete_device":true}` {
+		t.Errorf("delete_device = %s %+v", res.Data, res.Errors)
+	}
+	expectStatus(t, env.do(http.MethodGet, "/devices/"+id, nil, token), http.StatusNotFound)
+}
+
+func TestGraphQLInvalidQueries(t *testing.T) {
+	env := newTestEnv(t)
+	_, token := env.seedUser("ana", models.RoleUser)
+
+	cases := []struct {
+		name, query, code, message string
+		variables                  gin.H
+	}{
+		{"sintaxe", `{ me { id }`, "graphql_parse_failed", "Syntax Error", nil},
+		{"campo desconhecido", `{ me { password } }`, "graphql_validation_failed", `Cannot query field "password" on type "User"`, nil},
+		{"sem subcampos", `{ me }`, "graphql_validation_failed", "must have a selection of subfields", nil},
+		{"argumento obrigatório", `{ device { id } }`, "graphql_validation_failed", `Argument "id" of type "ID!" is required`, nil},
+		{"variável não declarada", `{ device(id: $id) { id } }`, "graphql_validation_failed", `Variable "$id" is not defined`, nil},
+		{"variável faltando", `query($id: ID!) { device(id: $id) { id } }`, "bad_request", "was not provided", nil},
+		{"variável do tipo errado", `query($n: Int) { devices(first: $n) { id } }`, "bad_request", "Int cannot represent", gin.H{"n": "dez"}},
+		{"fragmento em ciclo", `{ me { ...A } } fragment A on User { devices { owner { ...A } } }`, "graphql_validation_failed", "within itself", nil},
+		{"profundidade", `{ me { devices { owner { devices { owner { devices { owner { devices { owner { id } } } } } } } } } }`, "graphql_validation_failed", "deeper than", nil},
+	}
+	for _, tc := range cases {
+		res, status := env.graphql(token, tc.query, tc.variables)
+		if status != http.StatusBadRequest || res.Data != nil || len(res.Errors) == 0 {
+			t.Errorf("%s: status %d, data %s, erros %+v", tc.name, status, res.Data, res.Errors)
+			continue
+		}
+		if res.Errors[0].Extensions["code"] != tc.code || !strings.Contains(res.Errors[0].Message, tc.message) {
+			t.Errorf("%s: erro = %+v", tc.name, res.Errors[0])
+		}
+	}
+
+	// Argumento inválido só anula o próprio campo
+	res, status := env.graphql(token, `{ me { user } devices(first: 0) { id } }`, nil)
+	if status != http.StatusOK || string(res.Data) != "null" || len(res.Errors) != 1 {
+		t.Errorf("first: 0 = %d %s %+v", status, res.Data, res.Errors)
+	}
+	expectStatus(t, env.do(http.MethodPost, "/graphql", gin.H{"query": ""}, token), http.StatusBadRequest)
+}
+
+func TestGraphQLSchemaSDL(t *testing.T) {
+	env := newTestEnv(t)
+	w := env.do(http.MethodGet, "/graphql/schema", nil, "")
+	expectStatus(t, w, http.StatusOK)
+	sdl := w.Body.String()
+	for _, want := range []string{
+		"type Query {\n",
+		"  user(id: ID!): User\n",
+		"  readings(metric: String, from: Time, to: Time, limit: Int = 10): [Reading!]!\n",
+		"input DeviceInput {\n  name: String!\n",
+		"scalar Time\n",
+	} {
+		if !strings.Contains(sdl, want) {
+			t.Errorf("SDL sem %q:\n%s", want, sdl)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (e *testEnv) createGroup(token, name string) models.Group {
+	e.t.Helper()
+	w := e.do(http.MethodPost, "/groups", gin.H{"name": name}, token)
+	expectStatus(e.t, w, http.StatusCreated)
+	var group models.Group
+	decode(e.t, w, &group)
+	return group
+}
+
+func TestGroupsMembership(t *testing.T) {
+	env := newTestEnv(t)
+	ana, anaToken := env.seedUser("grupo_ana", "")
+	bia, biaToken := env.seedUser("grupo_bia", "")
+	_, caioToken := env.seedUser("grupo_caio", "")
+
+	group := env.createGroup(anaToken, "Laboratório")
+	if group.CreatedBy != ana.ID {
+		t.Errorf("created_by = %d, esperado %d", group.CreatedBy, ana.ID)
+	}
+	path := fmt.Sprintf("/groups/%d", group.ID)
+
+	// Quem não é membro não enxerga o grupo
+	expectStatus(t, env.do(http.MethodGet, path, nil, biaToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodGet, "/groups/999999", nil, anaToken), http.StatusNotFound)
+
+	w := env.do(http.MethodPost, path+"/members", gin.H{"user_id": bia.ID}, anaToken)
+	expectStatus(t, w, http.StatusCreated)
+	var added models.Membership
+	decode(t, w, &added)
+	if added.Role != models.GroupMember || added.User == nil || added.User.User != "grupo_bia" {
+		t.Errorf("participação = %+v", added)
+	}
+	expectStatus(t, env.do(http.MethodPost, path+"/members", gin.H{"user_id": bia.ID}, anaToken), http.StatusConflict)
+	expectStatus(t, env.do(http.MethodPost, path+"/members", gin.H{"user_id": 999999}, anaToken), http.StatusNotFound)
+	w = env.do(http.MethodPost, path+"/members", gin.H{"user_id": bia.ID, "role": "chefe"}, anaToken)
+	expectStatus(t, w, http.StatusBadRequest)
+	if e := decodeError(t, w); e.Details["role"] == "" {
+		t.Errorf("erro = %+v", e)
+	}
+
+	// Membro comum enxerga, mas não altera a lista
+	expectStatus(t, env.do(http.MethodGet, path, nil, biaToken), http.StatusOK)
+	expectStatus(t, env.do(http.MethodPost, path+"/members", gin.H{"user_id": 1}, biaToken), http.StatusForbidden)
+
+	w = env.do(http.MethodGet, path+"/members", nil, biaToken)
+	expectStatus(t, w, http.StatusOK)
+	var members []models.Membership
+	decode(t, w, &members)
+	if len(members) != 2 || members[0].UserID != ana.ID || members[0].Role != models.GroupOwner ||
+		members[1].User == nil || members[1].User.Name == "" {
+		t.Fatalf("membros = %+v", members)
+	}
+	if members[1].User.Password != "" {
+		t.Error("senha do membro saiu na resposta")
+	}
+
+	w = env.do(http.MethodGet, fmt.Sprintf("/users/%d/groups", bia.ID), nil, biaToken)
+	expectStatus(t, w, http.StatusOK)
+	var groups []models.Membership
+	decode(t, w, &groups)
+	if len(groups) != 1 || groups[0].Group == nil || groups[0].Group.Name != "Laboratório" || groups[0].Role != models.GroupMember {
+		t.Fatalf("grupos = %+v", groups)
+	}
+	expectStatus(t, env.do(http.MethodGet, fmt.Sprintf("/users/%d/groups", bia.ID), nil, caioToken), http.StatusForbidden)
+
+	// O único owner não sai; um membro sai sozinho
+	w = env.do(http.MethodDelete, fmt.Sprintf("%s/members/%d", path, ana.ID), nil, anaToken)
+	expectStatus(t, w, http.StatusConflict)
+	expectStatus(t, env.do(http.MethodDelete, fmt.Sprintf("%s/members/%d", path, ana.ID), nil, biaToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodDelete, fmt.Sprintf("%s/members/%d", path, bia.ID), nil, biaToken), http.StatusOK)
+	expectStatus(t, env.do(http.MethodGet, path, nil, biaToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodDelete, fmt.Sprintf("%s/members/%d", path, bia.ID), nil, anaToken), http.StatusNotFound)
+
+	expectStatus(t, env.do(http.MethodDelete, path, nil, anaToken), http.StatusOK)
+	expectStatus(t, env.do(http.MethodGet, path, nil, anaToken), http.StatusNotFound)
+	var left int64
+	env.db.Model(&models.Membership{}).Where("group_id = ?", group.ID).Count(&left)
+	if left != 0 {
+		t.Errorf("%d participações sobraram após remover o grupo", left)
+	}
+}
+
+func TestGroupsShareDevices(t *testing.T) {
+	env := newTestEnv(t)
+	ana, anaToken := env.seedUser("frota_ana", "")
+	bia, biaToken := env.seedUser("frota_bia", "")
+	caio, caioToken := env.seedUser("frota_caio", "")
+	_, admin := env.seedUser("frota_admin", models.RoleAdmin)
+
+	anaDevice := env.createDevice(ana.ID, anaToken)
+	biaDevice := env.createDevice(bia.ID, biaToken)
+	env.createDevice(caio.ID, caioToken)
+
+	group := env.createGroup(anaToken, "Frota")
+	path := fmt.Sprintf("/groups/%d", group.ID)
+	expectStatus(t, env.do(http.MethodPost, path+"/members", gin.H{"user_id": bia.ID}, anaToken), http.StatusCreated)
+
+	w := env.do(http.MethodGet, path+"/devices", nil, biaToken)
+	expectStatus(t, w, http.StatusOK)
+	var devices []models.Device
+	decode(t, w, &devices)
+	if len(devices) != 2 || devices[0].ID != anaDevice.ID || devices[1].ID != biaDevice.ID {
+		t.Fatalf("frota = %+v", devices)
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "2" {
+		t.Errorf("X-Total-Count = %q", got)
+	}
+	expectStatus(t, env.do(http.MethodGet, path+"/devices", nil, caioToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodGet, path+"/devices?status=sumido", nil, anaToken), http.StatusBadRequest)
+
+	// O admin do tenant administra sem ser membro
+	expectStatus(t, env.do(http.MethodGet, path+"/devices", nil, admin), http.StatusOK)
+	expectStatus(t, env.do(http.MethodPost, path+"/members", gin.H{"user_id": caio.ID, "role": "owner"}, admin), http.StatusCreated)
+	// Com dois owners, o primeiro pode sair
+	expectStatus(t, env.do(http.MethodDelete, fmt.Sprintf("%s/members/%d", path, ana.ID), nil, anaToken), http.StatusOK)
+}
+
+func TestGroupsStayInTenant(t *testing.T) {
+	env := newTestEnv(t)
+	_, platform := env.seedUser("plataforma", models.RoleAdmin)
+	env.createTenant(platform, "acme")
+	outsider, _ := env.seedTenantUser("acme", "acme_rui", "")
+	_, acmeAdmin := env.seedTenantUser("acme", "acme_admin", models.RoleAdmin)
+	_, anaToken := env.seedUser("tenant_ana", "")
+
+	group := env.createGroup(anaToken, "Padrão")
+	path := fmt.Sprintf("/groups/%d", group.ID)
+	// Usuário de outro tenant não entra e o admin dele não enxerga o grupo
+	expectStatus(t, env.do(http.MethodPost, path+"/members", gin.H{"user_id": outsider.ID}, anaToken), http.StatusNotFound)
+	expectStatus(t, env.do(http.MethodGet, path, nil, acmeAdmin), http.StatusNotFound)
+}
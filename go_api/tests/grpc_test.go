@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"go_api/grpcapi"
+	"go_api/grpcapi/pb"
+	"go_api/models"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Sobe o servidor gRPC sobre o mesmo Handler do router, numa conexão em memória
+func (e *testEnv) grpcConn() *grpc.ClientConn {
+	e.t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpcapi.New(e.handler)
+	go srv.Serve(lis)
+	e.t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		e.t.Fatalf("grpc: %v", err)
+	}
+	e.t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func expectCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Fatalf("code = %v, want %v (err: %v)", status.Code(err), code, err)
+	}
+}
+
+func TestGRPCLoginAndGetUser(t *testing.T) {
+	env := newTestEnv(t)
+	conn := env.grpcConn()
+	user, _ := env.seedUser("grpc_ana", models.RoleUser)
+
+	tokens, err := pb.NewAuthServiceClient(conn).Login(context.Background(),
+		&pb.LoginRequest{User: "grpc_ana", Password: "senha-grpc_ana"})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if tokens.TokenType != "Bearer" || tokens.RefreshToken == "" {
+		t.Fatalf("tokens = %+v", tokens)
+	}
+
+	users := pb.NewUserServiceClient(conn)
+	got, err := users.GetUser(withToken(tokens.AccessToken), &pb.GetUserRequest{Id: uint64(user.ID)})
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if got.User != "grpc_ana" || got.Email != "grpc_ana@exemplo.com" {
+		t.Fatalf("usuário = %+v", got)
+	}
+
+	// O mesmo token serve na API REST
+	w := env.do(http.MethodGet, fmt.Sprintf("/users/%d", user.ID), nil, tokens.AccessToken)
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestGRPCAuthErrors(t *testing.T) {
+	env := newTestEnv(t)
+	conn := env.grpcConn()
+	_, token := env.seedUser("grpc_bia", models.RoleUser)
+	other, _ := env.seedUser("grpc_caio", models.RoleUser)
+
+	_, err := pb.NewAuthServiceClient(conn).Login(context.Background(),
+		&pb.LoginRequest{User: "grpc_bia", Password: "errada"})
+	expectCode(t, err, codes.Unauthenticated)
+
+	users := pb.NewUserServiceClient(conn)
+	_, err = users.GetUser(context.Background(), &pb.GetUserRequest{Id: uint64(other.ID)})
+	expectCode(t, err, codes.Unauthenticated)
+
+	_, err = users.GetUser(withToken(token), &pb.GetUserRequest{Id: uint64(other.ID)})
+	expectCode(t, err, codes.PermissionDenied)
+
+	_, err = users.ListUsers(withToken(token), &pb.ListUsersRequest{})
+	expectCode(t, err, codes.PermissionDenied)
+}
+
+func TestGRPCAdminListsAndDeletes(t *testing.T) {
+	env := newTestEnv(t)
+	conn := env.grpcConn()
+	_, admin := env.seedUser("grpc_root", models.RoleAdmin)
+	victim, _ := env.seedUser("grpc_dani", models.RoleUser)
+
+	users := pb.NewUserServiceClient(conn)
+	list, err := users.ListUsers(withToken(admin), &pb.ListUsersRequest{Page: 1, PageSize: 1})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list.Users) != 1 || list.Total < 2 {
+		t.Fatalf("listagem = %d usuários, total %d", len(list.Users), list.Total)
+	}
+
+	if _, err := users.DeleteUser(withToken(admin), &pb.DeleteUserRequest{Id: uint64(victim.ID)}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_, err = users.GetUser(withToken(admin), &pb.GetUserRequest{Id: uint64(victim.ID)})
+	expectCode(t, err, codes.NotFound)
+}
+
+func TestGRPCCreateUserValidation(t *testing.T) {
+	env := newTestEnv(t)
+	users := pb.NewUserServiceClient(env.grpcConn())
+
+	_, err := users.CreateUser(context.Background(), &pb.CreateUserRequest{
+		Name: "Eva", Email: "nao-e-email", User: "grpc_eva", Password: "curta",
+	})
+	expectCode(t, err, codes.InvalidArgument)
+	fields := map[string]bool{}
+	for _, d := range status.Convert(err).Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				fields[v.Field] = true
+			}
+		}
+	}
+	if !fields["email"] || !fields["password"] {
+		t.Fatalf("campos inválidos = %v", fields)
+	}
+
+	created, err := users.CreateUser(context.Background(), &pb.CreateUserRequest{
+		Name: "Eva", Email: "grpc_eva@exemplo.com", User: "grpc_eva", Password: "senha-forte",
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.Role != models.RoleUser {
+		t.Fatalf("papel = %q", created.Role)
+	}
+
+	_, err = users.CreateUser(context.Background(), &pb.CreateUserRequest{
+		Name: "Eva", Email: "grpc_eva@exemplo.com", User: "grpc_eva2", Password: "senha-forte",
+	})
+	expectCode(t, err, codes.AlreadyExists)
+}
+
+func TestGRPCDevicesAndReadings(t *testing.T) {
+	env := newTestEnv(t)
+	conn := env.grpcConn()
+	user, token := env.seedUser("grpc_fabi", models.RoleUser)
+	_, intruder := env.seedUser("grpc_gil", models.RoleUser)
+
+	devices := pb.NewDeviceServiceClient(conn)
+	created, err := devices.CreateDevice(withToken(token),
+		&pb.CreateDeviceRequest{UserId: uint64(user.ID), Name: "Sensor", Type: "sensor"})
+	if err != nil {
+		t.Fatalf("create device: %v", err)
+	}
+	if created.Token == "" {
+		t.Fatal("token do dispositivo vazio")
+	}
+	id := created.Device.Id
+
+	resp, err := devices.CreateReadings(withToken(token), &pb.CreateReadingsRequest{
+		DeviceId: id,
+		Readings: []*pb.Reading{
+			{Metric: "temp", Value: 21.5},
+			{Metric: "temp", Value: 22, Payload: []byte(`{"unit":"C"}`)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("readings: %v", err)
+	}
+	if resp.Created != 2 {
+		t.Fatalf("created = %d", resp.Created)
+	}
+
+	device, err := devices.GetDevice(withToken(token), &pb.GetDeviceRequest{Id: id})
+	if err != nil {
+		t.Fatalf("get device: %v", err)
+	}
+	if device.LastSeen == nil {
+		t.Fatal("last_seen não atualizado")
+	}
+
+	_, err = devices.CreateReadings(withToken(token), &pb.CreateReadingsRequest{
+		DeviceId: id, Readings: []*pb.Reading{{Value: 1}},
+	})
+	expectCode(t, err, codes.InvalidArgument)
+
+	_, err = devices.GetDevice(withToken(intruder), &pb.GetDeviceRequest{Id: id})
+	expectCode(t, err, codes.PermissionDenied)
+
+	if _, err := devices.DeleteDevice(withToken(token), &pb.DeleteDeviceRequest{Id: id}); err != nil {
+		t.Fatalf("delete device: %v", err)
+	}
+	_, err = devices.GetDevice(withToken(token), &pb.GetDeviceRequest{Id: id})
+	expectCode(t, err, codes.NotFound)
+}
@@ -0,0 +1,38 @@
+package tests
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// /readyz é público: com o banco fora, diz só que ele está "down"
+func TestReadyzHidesDatabaseError(t *testing.T) {
+	env := newTestEnv(t)
+	down, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := down.DB()
+	sqlDB.Close()
+	db := env.handler.DB
+	env.handler.DB = down
+	t.Cleanup(func() { env.handler.DB = db })
+
+	w := env.do(http.MethodGet, "/readyz", nil, "")
+	expectStatus(t, w, http.StatusServiceUnavailable)
+	var body struct {
+		Status   string         `json:"status"`
+		Database map[string]any `json:"database"`
+	}
+	decode(t, w, &body)
+	if body.Status != "unavailable" || body.Database["status"] != "down" || len(body.Database) != 1 {
+		t.Errorf("readyz = %s", w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "closed") {
+		t.Errorf("erro do banco na resposta: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"go_api/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Textos passados para as funções de mensagem dos handlers, com o arquivo
+// e a linha de cada um
+func translatableMessages(t *testing.T) map[string]string {
+	t.Helper()
+	// Função -> posição do texto nos argumentos
+	calls := map[string]int{"newAPIError": 1, "newAPIErrorf": 1, "tr": 0, "localize": 1}
+	files, _ := filepath.Glob("../handlers/*.go")
+	fset := token.NewFileSet()
+	found := map[string]string{}
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			fn, ok := call.Fun.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			pos, ok := calls[fn.Name]
+			if !ok || len(call.Args) <= pos {
+				return true
+			}
+			if lit, ok := call.Args[pos].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				text, _ := strconv.Unquote(lit.Value)
+				found[text] = fset.Position(lit.Pos()).String()
+			}
+			return true
+		})
+	}
+	if len(found) < 100 {
+		t.Fatalf("só %d mensagens encontradas", len(found))
+	}
+	return found
+}
+
+func TestEveryMessageHasTranslation(t *testing.T) {
+	for text, where := range translatableMessages(t) {
+		if i18n.T(i18n.Portuguese, text) == text {
+			t.Errorf("%s: sem tradução para pt-BR: %q", where, text)
+		}
+	}
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	for header, want := range map[string]string{
+		"":                           i18n.English,
+		"pt-BR":                      i18n.Portuguese,
+		"pt-PT,pt;q=0.9":             i18n.Portuguese,
+		"fr-FR, en;q=0.5":            i18n.English,
+		"en-US,en;q=0.9,pt-BR;q=0.8": i18n.English,
+		"en;q=0.4, pt-br;q=0.7":      i18n.Portuguese,
+		"de, *;q=0.1":                i18n.English,
+		"pt;q=0, en":                 i18n.English,
+	} {
+		if got := i18n.Negotiate(header); got != want {
+			t.Errorf("Negotiate(%q) = %s, quer %s", header, got, want)
+		}
+	}
+}
+
+func TestLocalizedErrors(t *testing.T) {
+	env := newTestEnv(t)
+	admin, token := env.seedUser("idioma_admin", "admin")
+	pt := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		return env.doWithHeaders(method, path, body, token, map[string]string{"Accept-Language": "pt-BR,pt;q=0.9,en;q=0.8"})
+	}
+
+	// Mesmo code nos dois idiomas; a mensagem segue o Accept-Language
+	w := pt(http.MethodGet, "/users/999999", nil)
+	expectStatus(t, w, http.StatusNotFound)
+	if e := decodeError(t, w); e.Code != "not_found" || e.Message != "Usuário não encontrado" {
+		t.Errorf("404 em pt-BR = %+v", e)
+	}
+	if lang := w.Header().Get("Content-Language"); lang != "pt-BR" {
+		t.Errorf("Content-Language = %q", lang)
+	}
+	w = env.do(http.MethodGet, "/users/999999", nil, token)
+	if e := decodeError(t, w); e.Message != "User not found" || w.Header().Get("Content-Language") != "en" {
+		t.Errorf("404 sem Accept-Language = %+v (%s)", e, w.Header().Get("Content-Language"))
+	}
+
+	// Detalhes da validação, inclusive os que têm parâmetro
+	w = pt(http.MethodPost, "/users", gin.H{"name": "Ana", "user": "x", "email": "não-é-email", "password": "123"})
+	expectStatus(t, w, http.StatusBadRequest)
+	e := decodeError(t, w)
+	if e.Code != "validation_failed" || e.Message != "Falha na validação" {
+		t.Errorf("validação em pt-BR = %+v", e)
+	}
+	if e.Details["email"] != "deve ser um e-mail válido" || e.Details["password"] != "deve ter pelo menos 8 caracteres" {
+		t.Errorf("detalhes = %v", e.Details)
+	}
+
+	// Mensagem com argumento e resposta de sucesso
+	w = pt(http.MethodGet, "/users?sort=senha", nil)
+	expectStatus(t, w, http.StatusBadRequest)
+	if e := decodeError(t, w); e.Message != fmt.Sprintf("Campo de ordenação inválido: %s", "senha") {
+		t.Errorf("sort inválido = %+v", e)
+	}
+	w = pt(http.MethodGet, "/nao-existe", nil)
+	if e := decodeError(t, w); e.Code != "route_not_found" || e.Message != "Rota não encontrada" {
+		t.Errorf("rota desconhecida = %+v", e)
+	}
+	device := env.createDevice(admin.ID, token)
+	w = pt(http.MethodDelete, fmt.Sprintf("/devices/%d", device.ID), nil)
+	expectStatus(t, w, http.StatusOK)
+	var done struct{ Message string }
+	decode(t, w, &done)
+	if done.Message != "Dispositivo removido" {
+		t.Errorf("DELETE /devices/:id = %+v", done)
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newUserBody(username string) gin.H {
+	return gin.H{"name": "Usuário " + username, "email": username + "@exemplo.com", "user": username, "password": "senha-forte"}
+}
+
+func TestIdempotentCreateUser(t *testing.T) {
+	env := newTestEnv(t)
+	headers := map[string]string{"Idempotency-Key": "c0ffee-1"}
+
+	first := env.doWithHeaders(http.MethodPost, "/users", newUserBody("ana"), "", headers)
+	expectStatus(t, first, http.StatusCreated)
+	var created models.User
+	decode(t, first, &created)
+
+	// A conexão caiu e o app repete: mesma resposta, sem 409
+	retry := env.doWithHeaders(http.MethodPost, "/users", newUserBody("ana"), "", headers)
+	expectStatus(t, retry, http.StatusCreated)
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("Idempotent-Replayed ausente")
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Fatalf("resposta repetida = %s, want %s", retry.Body.String(), first.Body.String())
+	}
+	if n := env.countUsers("ana"); n != 1 {
+		t.Fatalf("%d usuários criados", n)
+	}
+	if len(env.mail.messages()) != 1 {
+		t.Errorf("%d e-mails de verificação", len(env.mail.messages()))
+	}
+
+	// Mesma chave com outro corpo é erro do cliente
+	w := env.doWithHeaders(http.MethodPost, "/users", newUserBody("bia"), "", headers)
+	expectStatus(t, w, http.StatusUnprocessableEntity)
+	if code := decodeError(t, w).Code; code != "idempotency_key_reused" {
+		t.Errorf("code = %q", code)
+	}
+
+	// Sem a chave, a repetição é um cadastro novo (e conflita)
+	expectStatus(t, env.do(http.MethodPost, "/users", newUserBody("ana"), ""), http.StatusConflict)
+}
+
+func TestIdempotentReplaysClientErrors(t *testing.T) {
+	env := newTestEnv(t)
+	headers := map[string]string{"Idempotency-Key": "c0ffee-2"}
+	body := newUserBody("ana")
+	body["password"] = "curta"
+
+	first := env.doWithHeaders(http.MethodPost, "/users", body, "", headers)
+	expectStatus(t, first, http.StatusBadRequest)
+	retry := env.doWithHeaders(http.MethodPost, "/users", body, "", headers)
+	expectStatus(t, retry, http.StatusBadRequest)
+	if retry.Header().Get("Idempotent-Replayed") != "true" || decodeError(t, retry).Code != "validation_failed" {
+		t.Fatalf("repetição = %s", retry.Body.String())
+	}
+}
+
+func TestIdempotencyKeyInProgressAndExpired(t *testing.T) {
+	env := newTestEnv(t)
+	body := newUserBody("ana")
+
+	// Outra réplica ainda está processando a primeira tentativa
+	running := env.doWithHeaders(http.MethodPost, "/users", body, "", map[string]string{"Idempotency-Key": "x"})
+	expectStatus(t, running, http.StatusCreated)
+	env.db.Model(&models.IdempotencyKey{}).Where(`"key" = ?`, "x").Update("status", 0)
+	w := env.doWithHeaders(http.MethodPost, "/users", body, "", map[string]string{"Idempotency-Key": "x"})
+	expectStatus(t, w, http.StatusConflict)
+	if decodeError(t, w).Code != "idempotency_in_progress" || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("resposta = %s", w.Body.String())
+	}
+
+	// Chave vencida vale como nova
+	env.db.Model(&models.IdempotencyKey{}).Where(`"key" = ?`, "x").Update("expires_at", time.Now().Add(-time.Minute))
+	w = env.doWithHeaders(http.MethodPost, "/users", newUserBody("bia"), "", map[string]string{"Idempotency-Key": "x"})
+	expectStatus(t, w, http.StatusCreated)
+	if w.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("chave vencida foi repetida")
+	}
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestImpersonation(t *testing.T) {
+	env := newTestEnv(t)
+	admin, adminToken := env.seedUser("suporte", models.RoleAdmin)
+	other, _ := env.seedUser("outra_admin", models.RoleAdmin)
+	ana, anaToken := env.seedUser("personificada", "")
+
+	path := fmt.Sprintf("/admin/impersonate/%d", ana.ID)
+	reason := gin.H{"reason": "chamado #4321"}
+	expectStatus(t, env.do(http.MethodPost, path, reason, anaToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodPost, path, nil, adminToken), http.StatusBadRequest)
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/admin/impersonate/%d", other.ID), reason, adminToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/admin/impersonate/%d", admin.ID), reason, adminToken), http.StatusBadRequest)
+
+	w := env.do(http.MethodPost, path, reason, adminToken)
+	expectStatus(t, w, http.StatusCreated)
+	var out struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   int64       `json:"expires_in"`
+		User        models.User `json:"user"`
+	}
+	decode(t, w, &out)
+	if out.AccessToken == "" || out.ExpiresIn != 600 || out.User.ID != ana.ID {
+		t.Fatalf("resposta = %+v", out)
+	}
+	token, err := env.handler.ParseAccessToken(out.AccessToken)
+	if err != nil || token.UserID != ana.ID || token.ImpersonatorID != admin.ID || token.Role != models.RoleUser {
+		t.Fatalf("claims = %+v (%v)", token, err)
+	}
+
+	// Age como a Ana, com as permissões dela
+	var me models.User
+	w = env.do(http.MethodGet, "/me", nil, out.AccessToken)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &me)
+	if me.ID != ana.ID {
+		t.Errorf("GET /me = %d, quer %d", me.ID, ana.ID)
+	}
+	expectStatus(t, env.do(http.MethodGet, "/users", nil, out.AccessToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodPut, "/me/password", gin.H{"current_password": "senha-personificada", "new_password": "outra-senha-123"}, out.AccessToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/users/%d/api-keys", ana.ID), gin.H{"name": "vazada"}, out.AccessToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodDelete, "/me", nil, out.AccessToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/users/%d/consents", ana.ID), gin.H{"purpose": models.PurposeAnalytics, "version": "v1"}, out.AccessToken), http.StatusForbidden)
+
+	// A emissão e o que foi feito com o token ficam na auditoria
+	expectStatus(t, env.do(http.MethodPatch, fmt.Sprintf("/users/%d", ana.ID), `{"name":"Ana Suporte"}`, out.AccessToken), http.StatusOK)
+	issued := env.audit("action=impersonate", adminToken)
+	if len(issued) != 1 || *issued[0].ActorID != admin.ID || issued[0].EntityID != ana.ID {
+		t.Fatalf("emissão = %+v", issued)
+	}
+	var record map[string]string
+	json.Unmarshal(issued[0].After, &record)
+	if record["reason"] != "chamado #4321" || record["expires_at"] == "" {
+		t.Errorf("registro = %s", issued[0].After)
+	}
+	changes := env.audit(fmt.Sprintf("impersonator_id=%d", admin.ID), adminToken)
+	if len(changes) != 1 || changes[0].Action != models.AuditUpdate || *changes[0].ActorID != ana.ID {
+		t.Errorf("alterações personificadas = %+v", changes)
+	}
+
+	// O token normal da Ana continua sem a marca
+	if token, _ := env.handler.ParseAccessToken(anaToken); token.ImpersonatorID != 0 {
+		t.Errorf("token normal = %+v", token)
+	}
+}
@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go_api/jobs"
+	"go_api/models"
+	"go_api/repository"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+var jobTestOptions = jobs.Options{Workers: 2, MaxAttempts: 3, RetryDelay: 10 * time.Millisecond}
+
+// Espera a condição valer (os jobs rodam em outras goroutines)
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("tempo esgotado esperando: %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func drainPool(t *testing.T, pool *jobs.Pool) {
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		pool.Drain(ctx)
+	})
+}
+
+func jobStats(t *testing.T, pool *jobs.Pool) jobs.Stats {
+	t.Helper()
+	stats, err := pool.Stats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stats
+}
+
+func TestJobPoolRetriesWithBackoffAndRecordsFailures(t *testing.T) {
+	pool := jobs.NewPool(jobs.NewMemory(100), jobTestOptions)
+	var flakyCalls atomic.Int32
+	pool.Register("flaky", func(context.Context, json.RawMessage) error {
+		if flakyCalls.Add(1) < 3 {
+			return errors.New("instável")
+		}
+		return nil
+	})
+	pool.Register("broken", func(context.Context, json.RawMessage) error {
+		panic("sempre quebra")
+	})
+	pool.Start()
+	drainPool(t, pool)
+
+	for _, jobType := range []string{"flaky", "broken"} {
+		if err := pool.Enqueue(context.Background(), jobType, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitUntil(t, "os dois jobs terminarem", func() bool {
+		s := jobStats(t, pool)
+		return s.Types["flaky"].Processed == 1 && s.Types["broken"].Failed == 1
+	})
+
+	s := jobStats(t, pool)
+	if s.Types["flaky"].Retried != 2 || s.Types["broken"].Retried != 2 {
+		t.Fatalf("retentativas = %+v, want 2 em cada", s.Types)
+	}
+	if len(s.RecentFailures) != 1 || s.RecentFailures[0].Type != "broken" || s.RecentFailures[0].Attempts != 3 {
+		t.Fatalf("falhas = %+v, want só o broken, com 3 tentativas", s.RecentFailures)
+	}
+}
+
+func TestJobPoolDrainFinishesQueuedJobs(t *testing.T) {
+	queue := jobs.NewMemory(100)
+	pool := jobs.NewPool(queue, jobTestOptions)
+	var done atomic.Int32
+	pool.Register("slow", func(context.Context, json.RawMessage) error {
+		time.Sleep(5 * time.Millisecond)
+		done.Add(1)
+		return nil
+	})
+	for i := 0; i < 20; i++ {
+		if err := pool.Enqueue(context.Background(), "slow", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pool.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := pool.Drain(ctx); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	// A fila em memória é esvaziada antes de sair: nenhum job se perde
+	if done.Load() != 20 {
+		t.Fatalf("executados = %d, want 20", done.Load())
+	}
+	if err := pool.Enqueue(context.Background(), "slow", nil); !errors.Is(err, jobs.ErrClosed) {
+		t.Fatalf("enqueue depois do drain = %v, want ErrClosed", err)
+	}
+}
+
+func TestJobPoolRedisQueueIsSharedByReplicas(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cfg := testCfg
+	cfg.RedisURL = "redis://" + mr.Addr()
+	rdb, err := repository.ConnectRedis(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Uma réplica só enfileira; a outra executa
+	producer := jobs.NewPool(jobs.NewRedis(rdb), jobTestOptions)
+	for i := 0; i < 3; i++ {
+		if err := producer.Enqueue(context.Background(), "echo", map[string]int{"n": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s := jobStats(t, producer); s.Queue != "redis" || s.Depth != 3 {
+		t.Fatalf("stats = %+v, want 3 na fila do redis", s)
+	}
+
+	consumer := jobs.NewPool(jobs.NewRedis(rdb), jobTestOptions)
+	var sum atomic.Int32
+	consumer.Register("echo", func(_ context.Context, payload json.RawMessage) error {
+		var body struct{ N int32 }
+		json.Unmarshal(payload, &body)
+		sum.Add(body.N + 1)
+		return nil
+	})
+	consumer.Start()
+	drainPool(t, consumer)
+	waitUntil(t, "a outra réplica consumir a fila", func() bool { return sum.Load() == 6 })
+
+	// A reserva das tarefas periódicas vale para as duas réplicas
+	first, _ := jobs.NewRedis(rdb).Once(context.Background(), "every:purge", time.Minute)
+	second, _ := jobs.NewRedis(rdb).Once(context.Background(), "every:purge", time.Minute)
+	if !first || second {
+		t.Fatalf("Once = %v, %v; want true, false", first, second)
+	}
+}
+
+func TestAdminJobsEndpoint(t *testing.T) {
+	env := newTestEnv(t)
+	_, admin := env.seedUser("jobs_admin", models.RoleAdmin)
+	_, user := env.seedUser("jobs_ana", "")
+
+	// Sem fila (como no resto dos testes): 503
+	expectStatus(t, env.do(http.MethodGet, "/admin/jobs", nil, admin), http.StatusServiceUnavailable)
+
+	pool := jobs.NewPool(jobs.NewMemory(10), jobTestOptions)
+	pool.Register("email.send", func(context.Context, json.RawMessage) error { return nil })
+	env.handler.Jobs = pool
+	pool.Enqueue(context.Background(), "email.send", nil)
+
+	expectStatus(t, env.do(http.MethodGet, "/admin/jobs", nil, user), http.StatusForbidden)
+	w := env.do(http.MethodGet, "/admin/jobs", nil, admin)
+	expectStatus(t, w, http.StatusOK)
+	var stats jobs.Stats
+	decode(t, w, &stats)
+	if stats.Queue != "memory" || stats.Depth != 1 || stats.Workers != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if _, ok := stats.Types["email.send"]; !ok {
+		t.Fatalf("tipos = %v, want email.send", stats.Types)
+	}
+}
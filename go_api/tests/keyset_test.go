@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Página da paginação por cursor (?after_id=)
+type keysetPage[T any] struct {
+	Data       []T   `json:"data"`
+	NextCursor *uint `json:"next_cursor"`
+}
+
+// Segue o next_cursor até o fim; path termina em "after_id="
+func keysetAll[T any](env *testEnv, path, token string, id func(T) uint) (rows []T, pages int) {
+	env.t.Helper()
+	var after uint
+	for {
+		w := env.do(http.MethodGet, fmt.Sprintf("%s%d", path, after), nil, token)
+		expectStatus(env.t, w, http.StatusOK)
+		var page keysetPage[T]
+		decode(env.t, w, &page)
+		rows, pages = append(rows, page.Data...), pages+1
+		if page.NextCursor == nil {
+			return rows, pages
+		}
+		if len(page.Data) == 0 || *page.NextCursor != id(page.Data[len(page.Data)-1]) {
+			env.t.Fatalf("next_cursor %d não é o último da página %v", *page.NextCursor, page.Data)
+		}
+		after = *page.NextCursor
+	}
+}
+
+func TestKeysetUsers(t *testing.T) {
+	env := newTestEnv(t)
+	_, adminToken := env.seedUser("admin", models.RoleAdmin)
+	for _, name := range []string{"ana", "bia", "caio", "duda"} {
+		env.seedUser(name, models.RoleUser)
+	}
+
+	users, pages := keysetAll(env, "/users?per_page=2&after_id=", adminToken, func(u models.User) uint { return u.ID })
+	// 5 usuários em páginas de 2: a última já vem com next_cursor null
+	if len(users) != 5 || pages != 3 {
+		t.Fatalf("%d usuários em %d páginas: %v", len(users), pages, users)
+	}
+	for i := 1; i < len(users); i++ {
+		if users[i].ID <= users[i-1].ID {
+			t.Fatalf("fora da ordem de ID: %v", users)
+		}
+	}
+
+	// O filtro vale junto com o cursor, e o total continua no cabeçalho
+	w := env.do(http.MethodGet, fmt.Sprintf("/users?filter=user==a*&after_id=%d", users[0].ID), nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	var page keysetPage[models.User]
+	decode(t, w, &page)
+	if len(page.Data) != 1 || page.Data[0].User != "ana" || page.NextCursor != nil || w.Header().Get("X-Total-Count") != "1" {
+		t.Fatalf("filtro com cursor = %+v (total %s)", page, w.Header().Get("X-Total-Count"))
+	}
+
+	for _, path := range []string{"/users?after_id=-1", "/users?after_id=x", "/users?after_id=0&sort=-user", "/users?after_id=0&page=2"} {
+		expectStatus(t, env.do(http.MethodGet, path, nil, adminToken), http.StatusBadRequest)
+	}
+}
+
+func TestKeysetReadings(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, token)
+
+	// Timestamps fora da ordem de gravação: o cursor segue o ID
+	base := time.Now().UTC().Add(-time.Hour)
+	var batch []gin.H
+	for i := range 7 {
+		batch = append(batch, gin.H{"metric": "temp", "value": i, "timestamp": base.Add(time.Duration(7-i) * time.Minute)})
+	}
+	batch = append(batch, gin.H{"metric": "hr", "value": 70})
+	env.postReadings(device, token, batch)
+
+	path := fmt.Sprintf("/devices/%d/readings?metric=temp&limit=3&after_id=", device.ID)
+	readings, pages := keysetAll(env, path, token, func(r models.Reading) uint { return r.ID })
+	if len(readings) != 7 || pages != 3 {
+		t.Fatalf("%d leituras em %d páginas", len(readings), pages)
+	}
+	for i, r := range readings {
+		if r.Value != float64(i) || r.Metric != "temp" {
+			t.Fatalf("leitura %d = %+v", i, r)
+		}
+	}
+
+	expectStatus(t, env.do(http.MethodGet, fmt.Sprintf("/devices/%d/readings?resolution=hourly&after_id=0", device.ID), nil, token), http.StatusBadRequest)
+}
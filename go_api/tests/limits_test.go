@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBodyLargerThanLimitIsRejected(t *testing.T) {
+	cfg := testCfg
+	cfg.MaxBodyBytes = 256
+	env := newTestEnvWithConfig(t, cfg)
+	big := `{"user": "` + strings.Repeat("a", 1024) + `", "password": "x"}`
+
+	w := env.do(http.MethodPost, "/login", big, "")
+	expectStatus(t, w, http.StatusRequestEntityTooLarge)
+	if apiErr := decodeError(t, w); apiErr.Code != "payload_too_large" {
+		t.Errorf("code = %q, want payload_too_large", apiErr.Code)
+	}
+
+	// Sem Content-Length (chunked), o limite vale durante a leitura
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader([]byte(big)))
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	expectStatus(t, w, http.StatusRequestEntityTooLarge)
+}
+
+func TestBodyWithinLimitIsAccepted(t *testing.T) {
+	cfg := testCfg
+	cfg.MaxBodyBytes = 1024
+	env := newTestEnvWithConfig(t, cfg)
+	env.seedUser("limite", "")
+}
+
+func TestSlowRequestTimesOut(t *testing.T) {
+	env := newTestEnv(t)
+	user, token := env.seedUser("lento", "")
+
+	// Prazo que já venceu quando a consulta chega ao banco
+	env.handler.Config.RequestTimeout = time.Nanosecond
+	w := env.do(http.MethodGet, "/users/"+strconv.Itoa(int(user.ID)), nil, token)
+	expectStatus(t, w, http.StatusRequestTimeout)
+	if apiErr := decodeError(t, w); apiErr.Code != "request_timeout" {
+		t.Errorf("code = %q, want request_timeout", apiErr.Code)
+	}
+}
+
+func TestCancelledRequestStopsQuery(t *testing.T) {
+	env := newTestEnv(t)
+	user, token := env.seedUser("desistiu", "")
+
+	// Cliente que desconectou antes da consulta
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/users/"+strconv.Itoa(int(user.ID)), nil).WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	expectStatus(t, w, 499)
+	if apiErr := decodeError(t, w); apiErr.Code != "client_closed_request" {
+		t.Errorf("code = %q, want client_closed_request", apiErr.Code)
+	}
+}
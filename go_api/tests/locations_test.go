@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (e *testEnv) postLocation(device models.Device, token string, body interface{}) {
+	e.t.Helper()
+	w := e.do(http.MethodPost, fmt.Sprintf("/devices/%d/locations", device.ID), body, token)
+	expectStatus(e.t, w, http.StatusCreated)
+}
+
+func TestDeviceLocations(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	env.grantConsent(ana.ID, token, "location_tracking")
+	device := env.createDevice(ana.ID, token)
+
+	w := env.do(http.MethodGet, fmt.Sprintf("/devices/%d/location", device.ID), nil, token)
+	expectStatus(t, w, http.StatusNotFound)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	env.postLocation(device, token, []gin.H{
+		{"lat": -23.5505, "lon": -46.6333, "timestamp": now.Add(-time.Minute)},
+		{"lat": -22.9068, "lon": -43.1729, "accuracy": 12.5, "timestamp": now},
+		// Chegou atrasado: não é a posição atual
+		{"lat": 0, "lon": 0, "timestamp": now.Add(-time.Hour)},
+	})
+
+	w = env.do(http.MethodGet, fmt.Sprintf("/devices/%d/location", device.ID), nil, token)
+	expectStatus(t, w, http.StatusOK)
+	var latest models.Location
+	decode(t, w, &latest)
+	if latest.Lat != -22.9068 || latest.Accuracy == nil || *latest.Accuracy != 12.5 {
+		t.Errorf("última posição = %+v", latest)
+	}
+
+	var stored models.Device
+	env.db.First(&stored, device.ID)
+	if stored.LastSeen == nil {
+		t.Error("last_seen não atualizado")
+	}
+
+	// Só o dono (ou admin)
+	_, biaToken := env.seedUser("bia", models.RoleUser)
+	w = env.do(http.MethodGet, fmt.Sprintf("/devices/%d/location", device.ID), nil, biaToken)
+	expectStatus(t, w, http.StatusForbidden)
+}
+
+func TestDeviceLocationsValidation(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	env.grantConsent(ana.ID, token, "location_tracking")
+	device := env.createDevice(ana.ID, token)
+
+	for name, body := range map[string]interface{}{
+		"sem lon":           gin.H{"lat": 1},
+		"lat fora":          gin.H{"lat": 91, "lon": 0},
+		"lon fora":          gin.H{"lat": 0, "lon": -181},
+		"precisão negativa": gin.H{"lat": 0, "lon": 0, "accuracy": -1},
+		"lote vazio":        []gin.H{},
+		"JSON inválido":     "{",
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := env.do(http.MethodPost, fmt.Sprintf("/devices/%d/locations", device.ID), body, token)
+			expectStatus(t, w, http.StatusBadRequest)
+		})
+	}
+	w := env.do(http.MethodPost, fmt.Sprintf("/devices/%d/locations", device.ID), []gin.H{{"lat": 0, "lon": 0}, {"lat": 100, "lon": 0}}, token)
+	expectStatus(t, w, http.StatusBadRequest)
+	var body struct {
+		Error struct {
+			Details struct {
+				Index int `json:"index"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	decode(t, w, &body)
+	if body.Error.Details.Index != 1 {
+		t.Errorf("index = %d", body.Error.Details.Index)
+	}
+}
+
+func TestNearbyDevices(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	env.grantConsent(ana.ID, token, "location_tracking")
+	paulista := env.createDevice(ana.ID, token)
+	ibirapuera := env.createDevice(ana.ID, token)
+	rio := env.createDevice(ana.ID, token)
+	env.createDevice(ana.ID, token) // sem posição
+	env.postLocation(paulista, token, gin.H{"lat": -23.5614, "lon": -46.6559})
+	env.postLocation(ibirapuera, token, gin.H{"lat": -23.5874, "lon": -46.6576})
+	env.postLocation(rio, token, gin.H{"lat": -22.9068, "lon": -43.1729})
+
+	bia, biaToken := env.seedUser("bia", models.RoleUser)
+	env.grantConsent(bia.ID, biaToken, "location_tracking")
+	vizinho := env.createDevice(bia.ID, biaToken)
+	env.postLocation(vizinho, biaToken, gin.H{"lat": -23.5615, "lon": -46.6560})
+
+	// A ~3 km da Paulista: o parque entra, o Rio não; o dispositivo da Bia
+	// também fica de fora
+	w := env.do(http.MethodGet, "/devices/nearby?lat=-23.5614&lon=-46.6559&radius=5000", nil, token)
+	expectStatus(t, w, http.StatusOK)
+	var nearby []models.NearbyDevice
+	decode(t, w, &nearby)
+	if len(nearby) != 2 || nearby[0].ID != paulista.ID || nearby[1].ID != ibirapuera.ID {
+		t.Fatalf("próximos = %+v", nearby)
+	}
+	if nearby[0].DistanceM > 1 || nearby[1].DistanceM < 2500 || nearby[1].DistanceM > 3500 {
+		t.Errorf("distâncias = %.0f, %.0f", nearby[0].DistanceM, nearby[1].DistanceM)
+	}
+	if nearby[1].Location.Lat != -23.5874 {
+		t.Errorf("posição = %+v", nearby[1].Location)
+	}
+
+	// A posição que vale é a última: o dispositivo do Rio veio para São Paulo
+	env.postLocation(rio, token, gin.H{"lat": -23.5620, "lon": -46.6560})
+	w = env.do(http.MethodGet, "/devices/nearby?lat=-23.5614&lon=-46.6559&radius=500", nil, token)
+	decode(t, w, &nearby)
+	if len(nearby) != 2 {
+		t.Errorf("próximos = %+v", nearby)
+	}
+
+	// Admin enxerga os dispositivos de todos
+	_, adminToken := env.seedUser("root", models.RoleAdmin)
+	w = env.do(http.MethodGet, "/devices/nearby?lat=-23.5614&lon=-46.6559&radius=500&limit=10", nil, adminToken)
+	decode(t, w, &nearby)
+	if len(nearby) != 3 {
+		t.Errorf("próximos (admin) = %d", len(nearby))
+	}
+
+	for _, query := range []string{"lat=-23&lon=-46", "lat=91&lon=0&radius=10", "lat=0&lon=0&radius=0", "lat=0&lon=0&radius=1000000", "lat=x&lon=0&radius=10"} {
+		expectStatus(t, env.do(http.MethodGet, "/devices/nearby?"+query, nil, token), http.StatusBadRequest)
+	}
+}
+
+func TestDeviceLocationsRequireConsent(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, token)
+	path := fmt.Sprintf("/devices/%d/locations", device.ID)
+
+	// Sem registro não há consentimento
+	w := env.do(http.MethodPost, path, gin.H{"lat": 0, "lon": 0}, token)
+	expectStatus(t, w, http.StatusForbidden)
+	if e := decodeError(t, w); e.Code != "consent_required" || e.Details["purpose"] != "location_tracking" {
+		t.Errorf("erro = %+v", e)
+	}
+
+	env.grantConsent(ana.ID, token, "location_tracking")
+	env.postLocation(device, token, gin.H{"lat": -23.5505, "lon": -46.6333})
+
+	// Depois de retirar, nada mais é gravado, nem pelo admin
+	expectStatus(t, env.do(http.MethodDelete, fmt.Sprintf("/users/%d/consents/location_tracking", ana.ID), nil, token), http.StatusOK)
+	_, adminToken := env.seedUser("root", models.RoleAdmin)
+	for _, caller := range []string{token, adminToken} {
+		w = env.do(http.MethodPost, path, gin.H{"lat": 0, "lon": 0}, caller)
+		expectStatus(t, w, http.StatusForbidden)
+		if e := decodeError(t, w); e.Code != "consent_required" {
+			t.Errorf("code = %q", e.Code)
+		}
+	}
+	var count int64
+	env.db.Model(&models.Location{}).Where("device_id = ?", device.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("posições gravadas = %d, quer 1", count)
+	}
+}
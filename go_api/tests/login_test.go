@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func lockoutEnv(t *testing.T) *testEnv {
+	cfg := testCfg
+	cfg.LoginMaxAttempts = 3
+	cfg.LoginLockoutWindow = time.Minute
+	cfg.LoginLockoutDuration = time.Minute
+	return newTestEnvWithConfig(t, cfg)
+}
+
+func (e *testEnv) tryLogin(username, password string) int {
+	e.t.Helper()
+	return e.do(http.MethodPost, "/login", gin.H{"user": username, "password": password}, "").Code
+}
+
+func TestLoginLockout(t *testing.T) {
+	env := lockoutEnv(t)
+	env.seedUser("ana", models.RoleUser)
+
+	for i := 0; i < 2; i++ {
+		if code := env.tryLogin("ana", "errada"); code != http.StatusUnauthorized {
+			t.Fatalf("tentativa %d: status = %d", i+1, code)
+		}
+	}
+	// A terceira falha já bloqueia
+	w := env.do(http.MethodPost, "/login", gin.H{"user": "ana", "password": "errada"}, "")
+	expectStatus(t, w, http.StatusLocked)
+	if body := decodeError(t, w); body.Code != "account_locked" || body.Details["locked_until"] == "" {
+		t.Errorf("erro = %+v", body)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("sem Retry-After")
+	}
+
+	// Bloqueada, nem a senha certa entra (e a tentativa não conta)
+	if code := env.tryLogin("ana", "senha-ana"); code != http.StatusLocked {
+		t.Errorf("senha certa durante o bloqueio: status = %d", code)
+	}
+
+	var count int64
+	env.db.Model(&models.LoginAttempt{}).Count(&count)
+	if count != 4 { // seedUser + 3 falhas
+		t.Errorf("tentativas registradas = %d, want 4", count)
+	}
+
+	// Passado o bloqueio, volta a entrar
+	env.db.Model(&models.LoginAttempt{}).Where("1 = 1").
+		Update("created_at", time.Now().Add(-2*time.Minute))
+	if code := env.tryLogin("ana", "senha-ana"); code != http.StatusOK {
+		t.Errorf("depois do bloqueio: status = %d", code)
+	}
+}
+
+func TestLoginSuccessResetsFailures(t *testing.T) {
+	env := lockoutEnv(t)
+	env.seedUser("ana", models.RoleUser)
+
+	env.tryLogin("ana", "errada")
+	env.tryLogin("ana", "errada")
+	env.login("ana", "senha-ana")
+	env.tryLogin("ana", "errada")
+	if code := env.tryLogin("ana", "errada"); code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401 (o login certo zera a contagem)", code)
+	}
+}
+
+func TestLoginUnknownUserIsRecorded(t *testing.T) {
+	env := lockoutEnv(t)
+
+	for i := 0; i < 5; i++ {
+		if code := env.tryLogin("ninguem", "x"); code != http.StatusUnauthorized {
+			t.Fatalf("status = %d", code)
+		}
+	}
+	var attempts []models.LoginAttempt
+	env.db.Where("login = ?", "ninguem").Find(&attempts)
+	if len(attempts) != 5 || attempts[0].UserID != nil || attempts[0].Success {
+		t.Errorf("tentativas = %+v", attempts)
+	}
+}
@@ -0,0 +1,265 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"go_api/config"
+	"go_api/handlers"
+	"go_api/logging"
+	"go_api/mailer"
+	"go_api/migrations"
+	"go_api/models"
+	"go_api/ratelimit"
+	"go_api/repository"
+	"go_api/router"
+	"go_api/service"
+	"go_api/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// --- Testes de Integração ---
+// Sobem o router completo sobre um SQLite em memória, migrado uma vez só.
+// Cada teste roda dentro de uma transação desfeita no fim (fixtures
+// transacionais), então os dados de um teste nunca vazam para o outro.
+
+var (
+	baseDB  *gorm.DB
+	testCfg = config.Config{
+		GinMode:              "test",
+		LogLevel:             "error",
+		DBDriver:             "sqlite",
+		DBPath:               ":memory:",
+		DBConnectRetries:     1,
+		DBQueryRetries:       2,
+		JWTSecret:            "test-secret",
+		AccessTTL:            15 * time.Minute,
+		RefreshTTL:           time.Hour,
+		ImpersonationTTL:     10 * time.Minute,
+		MaxDecompressedBytes: 1 << 20,
+		PasswordResetTTL:     30 * time.Minute,
+		EmailVerificationTTL: time.Hour,
+		AccountDeletionTTL:   time.Hour,
+		IdempotencyTTL:       time.Hour,
+		WebhookMaxAttempts:   3,
+		WebhookTimeout:       5 * time.Second,
+		WebhookRetryDelay:    time.Minute,
+		PushMaxAttempts:      3,
+		PushRetryDelay:       time.Minute,
+		AvatarBaseURL:        "/avatars",
+		AvatarSize:           64,
+		AvatarMaxBytes:       1 << 20,
+		LegacyRoutes:         true,
+	}
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	logging.Setup(testCfg.LogLevel)
+
+	db, err := repository.Connect(testCfg)
+	if err != nil {
+		log.Fatalf("banco de teste: %v", err)
+	}
+	if err := migrations.Run(db, testCfg.DBDriver); err != nil {
+		log.Fatalf("migrações: %v", err)
+	}
+	// Como no main: consultas filtradas pelo tenant e os UserEvents gerando
+	// as entregas dos webhooks e os eventos do outbox
+	repository.ScopeTenants(db)
+	service.WatchWebhooks(db)
+	service.WatchOutbox(db)
+	baseDB = db
+	os.Exit(m.Run())
+}
+
+type testEnv struct {
+	t      *testing.T
+	db     *gorm.DB
+	router *gin.Engine
+	mail   *recordingMailer
+	// Acesso direto às dependências (ex: h.Presence nos testes de presença)
+	handler *handlers.Handler
+}
+
+// Guarda os e-mails em vez de enviar
+type recordingMailer struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func (m *recordingMailer) messages() []mailer.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]mailer.Message(nil), m.sent...)
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	return newTestEnvWithConfig(t, testCfg)
+}
+
+func newTestEnvWithConfig(t *testing.T, cfg config.Config) *testEnv {
+	t.Helper()
+	return newTestEnvWithRedis(t, cfg, nil)
+}
+
+// rdb != nil liga o cache de usuários e o rate limit no Redis
+func newTestEnvWithRedis(t *testing.T, cfg config.Config, rdb *redis.Client) *testEnv {
+	t.Helper()
+	tx := baseDB.Begin()
+	t.Cleanup(func() { tx.Rollback() })
+
+	hub := service.NewEventHub()
+	store := repository.NewUserStore(tx)
+	if rdb != nil {
+		store = repository.NewCachedUserStore(store, rdb, time.Minute)
+	}
+	mail := &recordingMailer{}
+	users := service.NewUserService(store).
+		WithLockout(repository.NewLoginAttemptStore(tx), service.LockoutPolicy{
+			MaxAttempts: cfg.LoginMaxAttempts,
+			Window:      cfg.LoginLockoutWindow,
+			Duration:    cfg.LoginLockoutDuration,
+		}).
+		WithMailer(mail).
+		WithPasswordReset(repository.NewPasswordResetStore(tx),
+			service.PasswordResetPolicy{TTL: cfg.PasswordResetTTL, URL: cfg.PasswordResetURL}).
+		WithEmailVerification(repository.NewEmailVerificationStore(tx), service.EmailVerificationPolicy{
+			TTL:      cfg.EmailVerificationTTL,
+			URL:      cfg.EmailVerificationURL,
+			Required: cfg.RequireEmailVerification,
+		}).
+		WithSessions(repository.NewSessionStore(tx), service.SessionPolicy{TTL: cfg.RefreshTTL}).
+		WithIdentities(repository.NewIdentityStore(tx))
+	h := handlers.New(tx, cfg, users, hub, service.NewPresenceHub(time.Minute), ratelimit.New(cfg.RateLimit, cfg.RateLimitWindow, rdb))
+	// Avatares no disco, numa pasta descartada no fim do teste
+	h.Avatars = service.NewAvatarService(users, &storage.Local{Dir: t.TempDir(), BaseURL: cfg.AvatarBaseURL}, cfg.AvatarSize)
+	h.Privacy = service.NewPrivacyService(tx, users, h.Avatars, cfg.AccountDeletionTTL)
+	return &testEnv{t: t, db: tx, router: router.New(h), mail: mail, handler: h}
+}
+
+// Faz a requisição pelo router; body pode ser nil, string (JSON cru) ou
+// qualquer valor serializável
+func (e *testEnv) do(method, path string, body interface{}, token string) *httptest.ResponseRecorder {
+	e.t.Helper()
+	var raw []byte
+	switch b := body.(type) {
+	case nil:
+	case string:
+		raw = []byte(b)
+	default:
+		var err error
+		if raw, err = json.Marshal(b); err != nil {
+			e.t.Fatalf("json: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	return w
+}
+
+// Requisição com cabeçalhos extras (If-Match, If-None-Match)
+func (e *testEnv) doWithHeaders(method, path string, body interface{}, token string, headers map[string]string) *httptest.ResponseRecorder {
+	e.t.Helper()
+	var raw []byte
+	if body != nil {
+		raw, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	return w
+}
+
+// Fixture: cria o usuário (e promove a admin se pedido) e faz login
+func (e *testEnv) seedUser(username, role string) (models.User, string) {
+	e.t.Helper()
+	w := e.do(http.MethodPost, "/users", gin.H{
+		"name":     "Usuário " + username,
+		"email":    username + "@exemplo.com",
+		"user":     username,
+		"password": "senha-" + username,
+	}, "")
+	expectStatus(e.t, w, http.StatusCreated)
+	var user models.User
+	decode(e.t, w, &user)
+
+	if role == models.RoleAdmin {
+		if err := e.db.Model(&user).Update("role", models.RoleAdmin).Error; err != nil {
+			e.t.Fatalf("promover admin: %v", err)
+		}
+	}
+	return user, e.login(username, "senha-"+username)
+}
+
+func (e *testEnv) login(username, password string) string {
+	e.t.Helper()
+	w := e.do(http.MethodPost, "/login", gin.H{"user": username, "password": password}, "")
+	expectStatus(e.t, w, http.StatusOK)
+	var tokens handlers.TokenPair
+	decode(e.t, w, &tokens)
+	return tokens.AccessToken
+}
+
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, status, w.Body.String())
+	}
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("resposta inválida: %v; body: %s", err, w.Body.String())
+	}
+}
+
+// Corpo padrão de erro: {"error": {"code", "message", "details", "request_id"}}
+type apiError struct {
+	Code      string            `json:"code"`
+	Message   string            `json:"message"`
+	Details   map[string]string `json:"details"`
+	RequestID string            `json:"request_id"`
+}
+
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) apiError {
+	t.Helper()
+	var body struct {
+		Error apiError `json:"error"`
+	}
+	decode(t, w, &body)
+	return body.Error
+}
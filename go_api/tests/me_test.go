@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMeResolvesFromToken(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	env.seedUser("bia", models.RoleUser)
+
+	for _, path := range []string{"/me", "/users/me"} {
+		w := env.do(http.MethodGet, path, nil, token)
+		expectStatus(t, w, http.StatusOK)
+		var got models.User
+		decode(t, w, &got)
+		if got.ID != ana.ID || got.User != "ana" {
+			t.Fatalf("GET %s = %+v", path, got)
+		}
+		if w.Header().Get("ETag") == "" {
+			t.Fatalf("GET %s sem ETag", path)
+		}
+	}
+	// /users/:id continua valendo ao lado de /users/me
+	expectStatus(t, env.do(http.MethodGet, fmt.Sprintf("/users/%d", ana.ID), nil, token), http.StatusOK)
+	expectStatus(t, env.do(http.MethodGet, "/me", nil, ""), http.StatusUnauthorized)
+
+	w := env.doWithHeaders(http.MethodPut, "/me", gin.H{"name": "Ana Maria"}, token, map[string]string{"If-Match": `"1"`})
+	expectStatus(t, w, http.StatusOK)
+	var updated models.User
+	decode(t, w, &updated)
+	if updated.ID != ana.ID || updated.Name != "Ana Maria" {
+		t.Fatalf("PUT /me = %+v", updated)
+	}
+	// Revisão antiga no If-Match: conflito, como no /users/:id
+	w = env.doWithHeaders(http.MethodPut, "/users/me", gin.H{"name": "Outra"}, token, map[string]string{"If-Match": `"1"`})
+	expectStatus(t, w, http.StatusConflict)
+}
+
+func TestMePasswordRequiresCurrent(t *testing.T) {
+	env := newTestEnv(t)
+	_, token := env.seedUser("ana", models.RoleAdmin)
+
+	// Nem admin troca a própria senha sem a atual
+	expectStatus(t, env.do(http.MethodPut, "/me/password", gin.H{"new_password": "nova-senha-123"}, token), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodPut, "/me/password",
+		gin.H{"current_password": "errada", "new_password": "nova-senha-123"}, token), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodPut, "/me/password",
+		gin.H{"current_password": "senha-ana", "new_password": "nova-senha-123"}, token), http.StatusOK)
+	env.login("ana", "nova-senha-123")
+}
+
+func TestMeDelete(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+
+	w := env.do(http.MethodPost, fmt.Sprintf("/users/%d/api-keys", ana.ID), gin.H{"name": "sensor"}, token)
+	expectStatus(t, w, http.StatusCreated)
+	var key struct {
+		Key string `json:"key"`
+	}
+	decode(t, w, &key)
+	w = env.doWithHeaders(http.MethodDelete, "/me", nil, "", map[string]string{"X-API-Key": key.Key})
+	expectStatus(t, w, http.StatusForbidden)
+
+	// Sem o código do e-mail, só pede a confirmação (ver privacy_test.go)
+	expectStatus(t, env.do(http.MethodDelete, "/me", nil, token), http.StatusAccepted)
+	expectStatus(t, env.do(http.MethodGet, "/me", nil, token), http.StatusOK)
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go_api/models"
+	"go_api/mqttbridge"
+)
+
+// A conexão com o broker é da biblioteca; aqui vale o que a ponte faz com
+// cada mensagem recebida
+func TestMQTTBridgeStoresReadings(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, token)
+
+	bridge, err := mqttbridge.New(mqttbridge.Options{Topic: "$share/go_api/devices/+/readings"}, env.db, env.handler.Telemetry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	created, err := bridge.Handle(ctx, fmt.Sprintf("devices/%d/readings", device.ID), []byte(`[{"metric":"temp","value":21.5},{"metric":"temp","value":22}]`))
+	if err != nil || created != 2 {
+		t.Fatalf("created = %d, err = %v", created, err)
+	}
+	var count int64
+	env.db.Model(&models.Reading{}).Where("device_id = ?", device.ID).Count(&count)
+	if count != 2 {
+		t.Errorf("leituras gravadas = %d, want 2", count)
+	}
+
+	// O dispositivo fica online como no HTTP
+	if snapshot := env.handler.Presence.Snapshot(); len(snapshot) != 1 || snapshot[0].DeviceID != device.ID {
+		t.Errorf("presença = %+v", snapshot)
+	}
+
+	for name, msg := range map[string]struct{ topic, payload string }{
+		"dispositivo inexistente": {"devices/999999/readings", `{"metric":"temp","value":1}`},
+		"ID inválido":             {"devices/abc/readings", `{"metric":"temp","value":1}`},
+		"sem métrica":             {fmt.Sprintf("devices/%d/readings", device.ID), `{"value":1}`},
+		"JSON inválido":           {fmt.Sprintf("devices/%d/readings", device.ID), `{`},
+	} {
+		if _, err := bridge.Handle(ctx, msg.topic, []byte(msg.payload)); err == nil {
+			t.Errorf("%s: mensagem aceita", name)
+		}
+	}
+}
+
+func TestMQTTBridgeTopicNeedsDeviceID(t *testing.T) {
+	for _, topic := range []string{"devices/readings", "devices/+/+/readings"} {
+		if _, err := mqttbridge.New(mqttbridge.Options{Topic: topic}, nil, nil); err == nil {
+			t.Errorf("tópico %q aceito", topic)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+)
+
+// Strings do msgpack como string (não []byte) ao decodificar em interface{}
+var testMsgpack = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.RawToString = true
+	return h
+}()
+
+func (e *testEnv) getAs(path, token, accept string) *httptest.ResponseRecorder {
+	e.t.Helper()
+	return e.doWithHeaders(http.MethodGet, path, nil, token, map[string]string{"Accept": accept})
+}
+
+func decodeBinary(t *testing.T, w *httptest.ResponseRecorder, handle codec.Handle, v interface{}) {
+	t.Helper()
+	if err := codec.NewDecoderBytes(w.Body.Bytes(), handle).Decode(v); err != nil {
+		t.Fatalf("corpo binário inválido: %v", err)
+	}
+}
+
+func TestMsgPackResponses(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	path := fmt.Sprintf("/api/v1/users/%d", ana.ID)
+
+	for _, accept := range []string{"application/msgpack", "application/x-msgpack"} {
+		w := env.getAs(path, token, accept)
+		expectStatus(t, w, http.StatusOK)
+		if ct := w.Header().Get("Content-Type"); ct != "application/msgpack" {
+			t.Fatalf("Content-Type = %q", ct)
+		}
+		if !strings.Contains(strings.Join(w.Header().Values("Vary"), ","), "Accept") {
+			t.Errorf("Vary = %v, sem Accept", w.Header().Values("Vary"))
+		}
+		if w.Header().Get("ETag") == "" {
+			t.Error("resposta binária sem ETag")
+		}
+		var user map[string]interface{}
+		decodeBinary(t, w, testMsgpack, &user)
+		if user["user"] != "ana" || user["email"] != "ana@exemplo.com" {
+			t.Errorf("usuário = %v", user)
+		}
+		// A senha nunca sai, em nenhum formato
+		if _, ok := user["password"]; ok {
+			t.Errorf("senha na resposta: %v", user)
+		}
+	}
+
+	// Erros no formato pedido
+	w := env.getAs("/api/v1/users/99999", token, "application/msgpack")
+	expectStatus(t, w, http.StatusForbidden)
+	var body struct {
+		Error struct {
+			Code string `codec:"code"`
+		} `codec:"error"`
+	}
+	decodeBinary(t, w, testMsgpack, &body)
+	if body.Error.Code != "forbidden" {
+		t.Errorf("erro = %+v", body)
+	}
+}
+
+func TestCBORReadings(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, token)
+	env.postReadings(device, token, []gin.H{{"metric": "temp", "value": 21.5}, {"metric": "bpm", "value": 72}})
+
+	w := env.getAs(fmt.Sprintf("/api/v1/devices/%d/readings", device.ID), token, "application/cbor")
+	expectStatus(t, w, http.StatusOK)
+	if ct := w.Header().Get("Content-Type"); ct != "application/cbor" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	var readings []struct {
+		Metric   string  `codec:"metric"`
+		Value    float64 `codec:"value"`
+		DeviceID uint    `codec:"device_id"`
+	}
+	decodeBinary(t, w, &codec.CborHandle{}, &readings)
+	values := map[string]float64{}
+	for _, r := range readings {
+		if r.DeviceID != device.ID {
+			t.Errorf("leitura de outro dispositivo: %+v", r)
+		}
+		values[r.Metric] = r.Value
+	}
+	if values["temp"] != 21.5 || values["bpm"] != 72 {
+		t.Errorf("leituras = %+v", readings)
+	}
+
+	w = env.getAs(fmt.Sprintf("/api/v1/devices/%d", device.ID), token, "application/cbor")
+	expectStatus(t, w, http.StatusOK)
+	var got map[string]interface{}
+	decodeBinary(t, w, &codec.CborHandle{}, &got)
+	if got["name"] != device.Name {
+		t.Errorf("dispositivo = %v", got)
+	}
+}
+
+// Sem Accept, com */* ou com um formato que a API não fala: JSON
+func TestJSONStaysDefault(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	path := fmt.Sprintf("/api/v1/users/%d", ana.ID)
+
+	for _, accept := range []string{"", "*/*", "application/json", "application/xml", "application/json, application/msgpack"} {
+		w := env.getAs(path, token, accept)
+		expectStatus(t, w, http.StatusOK)
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Errorf("Accept %q: Content-Type = %q", accept, ct)
+		}
+		var user models.User
+		decode(t, w, &user)
+	}
+}
@@ -0,0 +1,355 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go_api/models"
+	"go_api/push"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Provedor falso: guarda as mensagens e responde com o erro configurado
+// para cada token
+type fakePush struct {
+	mu     sync.Mutex
+	sent   []push.Message
+	errors map[string]error
+}
+
+func (p *fakePush) Send(_ context.Context, msgs []push.Message) []error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	errs := make([]error, len(msgs))
+	for i, msg := range msgs {
+		p.sent = append(p.sent, msg)
+		errs[i] = p.errors[msg.Token]
+	}
+	return errs
+}
+
+func (e *testEnv) usePush(provider push.Provider) {
+	e.handler.Notifications = service.NewNotificationService(e.db, map[string]push.Provider{
+		push.PlatformFCM: provider, push.PlatformAPNs: provider,
+	}, service.NotificationPolicy{MaxAttempts: 2, RetryDelay: time.Minute})
+}
+
+func (e *testEnv) registerPush(device models.Device, token, platform, value string) {
+	e.t.Helper()
+	w := e.do(http.MethodPut, fmt.Sprintf("/devices/%d/push-token", device.ID), gin.H{"platform": platform, "token": value}, token)
+	expectStatus(e.t, w, http.StatusOK)
+}
+
+func (e *testEnv) notify(adminToken string, body gin.H) models.Notification {
+	e.t.Helper()
+	w := e.do(http.MethodPost, "/notifications", body, adminToken)
+	expectStatus(e.t, w, http.StatusAccepted)
+	var n models.Notification
+	decode(e.t, w, &n)
+	return n
+}
+
+func TestNotificationDelivery(t *testing.T) {
+	env := newTestEnv(t)
+	provider := &fakePush{}
+	env.usePush(provider)
+	ana, anaToken := env.seedUser("ana", models.RoleUser)
+	env.grantConsent(ana.ID, anaToken, "notifications")
+	phone := env.createDevice(ana.ID, anaToken)
+	watch := env.createDevice(ana.ID, anaToken)
+	env.createDevice(ana.ID, anaToken) // sem token
+	env.registerPush(phone, anaToken, "fcm", "fcm-phone")
+	env.registerPush(watch, anaToken, "apns", "apns-watch")
+	bia, biaToken := env.seedUser("bia", models.RoleUser)
+	env.grantConsent(bia.ID, biaToken, "notifications")
+	env.registerPush(env.createDevice(bia.ID, biaToken), biaToken, "fcm", "fcm-bia")
+	_, adminToken := env.seedUser("root", models.RoleAdmin)
+
+	n := env.notify(adminToken, gin.H{"title": "Bateria fraca", "body": "Carregue o relógio", "data": gin.H{"kind": "battery"}, "user_ids": []uint{ana.ID}})
+	if n.Stats == nil || n.Stats.Total != 2 || n.Source != models.NotificationSourceAdmin {
+		t.Fatalf("notificação = %+v", n)
+	}
+	if got := env.handler.Notifications.DeliverDue(context.Background()); got != 2 {
+		t.Fatalf("entregues = %d", got)
+	}
+	if len(provider.sent) != 2 || provider.sent[0].Title != "Bateria fraca" || provider.sent[0].Data["kind"] != "battery" {
+		t.Errorf("enviadas = %+v", provider.sent)
+	}
+	// Já entregues: a próxima rodada não manda de novo
+	if got := env.handler.Notifications.DeliverDue(context.Background()); got != 0 || len(provider.sent) != 2 {
+		t.Errorf("reenvio: %d, %d mensagens", got, len(provider.sent))
+	}
+
+	w := env.do(http.MethodGet, fmt.Sprintf("/notifications/%d", n.ID), nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &n)
+	if n.Stats.Delivered != 2 || n.Stats.Pending != 0 {
+		t.Errorf("stats = %+v", n.Stats)
+	}
+	w = env.do(http.MethodGet, fmt.Sprintf("/notifications/%d/deliveries", n.ID), nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	var deliveries []models.NotificationDelivery
+	decode(t, w, &deliveries)
+	if len(deliveries) != 2 || deliveries[0].DeviceID != phone.ID || deliveries[0].DeliveredAt == nil {
+		t.Errorf("entregas = %+v", deliveries)
+	}
+
+	// Só admin dispara e consulta
+	expectStatus(t, env.do(http.MethodPost, "/notifications", gin.H{"title": "x", "user_ids": []uint{ana.ID}}, anaToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodGet, fmt.Sprintf("/notifications/%d", n.ID), nil, anaToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodPost, "/notifications", gin.H{"title": "sem destino"}, adminToken), http.StatusBadRequest)
+	expectStatus(t, env.do(http.MethodGet, "/notifications/999999", nil, adminToken), http.StatusNotFound)
+}
+
+// Dispositivos de um usuário removido ficam no banco (para o restore), mas
+// não recebem push
+func TestNotificationSkipsDeletedOwners(t *testing.T) {
+	env := newTestEnv(t)
+	env.usePush(&fakePush{})
+	ana, anaToken := env.seedUser("ana", models.RoleUser)
+	env.grantConsent(ana.ID, anaToken, "notifications")
+	phone := env.createDevice(ana.ID, anaToken)
+	env.registerPush(phone, anaToken, "fcm", "fcm-ana")
+	bia, biaToken := env.seedUser("bia", models.RoleUser)
+	env.grantConsent(bia.ID, biaToken, "notifications")
+	env.registerPush(env.createDevice(bia.ID, biaToken), biaToken, "fcm", "fcm-bia")
+	_, adminToken := env.seedUser("root", models.RoleAdmin)
+	expectStatus(t, env.do(http.MethodDelete, fmt.Sprintf("/users/%d", ana.ID), nil, adminToken), http.StatusOK)
+
+	// Nem pelo usuário nem pelo ID do dispositivo
+	n := env.notify(adminToken, gin.H{"title": "Oi", "user_ids": []uint{ana.ID, bia.ID}, "device_ids": []uint{phone.ID}})
+	if n.Stats == nil || n.Stats.Total != 1 {
+		t.Fatalf("notificação = %+v", n)
+	}
+	var deliveries int64
+	env.db.Model(&models.NotificationDelivery{}).Where("device_id = ?", phone.ID).Count(&deliveries)
+	if deliveries != 0 {
+		t.Errorf("%d entregas para o dispositivo da ana", deliveries)
+	}
+}
+
+// Push só para quem consentiu com "notifications" (opt-in), e nunca depois
+// de retirar o consentimento
+func TestNotificationRequiresConsent(t *testing.T) {
+	env := newTestEnv(t)
+	env.usePush(&fakePush{})
+	ana, anaToken := env.seedUser("ana", models.RoleUser)
+	env.grantConsent(ana.ID, anaToken, "notifications")
+	env.registerPush(env.createDevice(ana.ID, anaToken), anaToken, "fcm", "fcm-ana")
+	bia, biaToken := env.seedUser("bia", models.RoleUser)
+	biaPhone := env.createDevice(bia.ID, biaToken)
+	env.registerPush(biaPhone, biaToken, "fcm", "fcm-bia")
+	_, adminToken := env.seedUser("root", models.RoleAdmin)
+	target := gin.H{"title": "Oi", "user_ids": []uint{ana.ID}, "device_ids": []uint{biaPhone.ID}}
+
+	// A bia nunca consentiu
+	if n := env.notify(adminToken, target); n.Stats == nil || n.Stats.Total != 1 {
+		t.Fatalf("sem consentimento da bia: %+v", n.Stats)
+	}
+	env.grantConsent(bia.ID, biaToken, "notifications")
+	if n := env.notify(adminToken, target); n.Stats.Total != 2 {
+		t.Errorf("com os dois consentimentos: %+v", n.Stats)
+	}
+	expectStatus(t, env.do(http.MethodDelete, fmt.Sprintf("/users/%d/consents/notifications", ana.ID), nil, anaToken), http.StatusOK)
+	n := env.notify(adminToken, target)
+	var deliveries []models.NotificationDelivery
+	env.db.Where("notification_id = ?", n.ID).Find(&deliveries)
+	if len(deliveries) != 1 || deliveries[0].DeviceID != biaPhone.ID {
+		t.Errorf("entregas depois de a ana retirar = %+v", deliveries)
+	}
+}
+
+func TestNotificationFailures(t *testing.T) {
+	env := newTestEnv(t)
+	provider := &fakePush{errors: map[string]error{
+		"gone":  push.ErrUnregistered,
+		"flaky": fmt.Errorf("HTTP 503"),
+	}}
+	env.usePush(provider)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	env.grantConsent(ana.ID, token, "notifications")
+	gone := env.createDevice(ana.ID, token)
+	flaky := env.createDevice(ana.ID, token)
+	env.registerPush(gone, token, "fcm", "gone")
+	env.registerPush(flaky, token, "apns", "flaky")
+	_, adminToken := env.seedUser("root", models.RoleAdmin)
+
+	n := env.notify(adminToken, gin.H{"title": "Oi", "device_ids": []uint{gone.ID, flaky.ID}})
+	env.handler.Notifications.DeliverDue(context.Background())
+
+	// Token recusado: falha na hora e o token é apagado
+	var tokens int64
+	env.db.Model(&models.PushToken{}).Where("device_id = ?", gone.ID).Count(&tokens)
+	if tokens != 0 {
+		t.Error("token recusado não foi apagado")
+	}
+	var d models.NotificationDelivery
+	env.db.Where("notification_id = ? AND device_id = ?", n.ID, flaky.ID).First(&d)
+	if d.Status != models.DeliveryPending || d.Attempts != 1 || d.LastError != "HTTP 503" || !d.NextAttemptAt.After(time.Now()) {
+		t.Errorf("entrega com falha = %+v", d)
+	}
+
+	// Na segunda falha (MaxAttempts = 2), desiste
+	env.db.Model(&d).Update("next_attempt_at", time.Now().Add(-time.Second))
+	env.handler.Notifications.DeliverDue(context.Background())
+	w := env.do(http.MethodGet, fmt.Sprintf("/notifications/%d", n.ID), nil, adminToken)
+	decode(t, w, &n)
+	if n.Stats.Failed != 2 || n.Stats.Pending != 0 {
+		t.Errorf("stats = %+v", n.Stats)
+	}
+}
+
+func TestPushTokenRegistration(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	phone := env.createDevice(ana.ID, token)
+	tablet := env.createDevice(ana.ID, token)
+
+	env.registerPush(phone, token, "fcm", "tok-1")
+	// Registrar de novo troca o token do dispositivo
+	env.registerPush(phone, token, "apns", "tok-2")
+	var stored []models.PushToken
+	env.db.Find(&stored)
+	if len(stored) != 1 || stored[0].Platform != "apns" || stored[0].Token != "tok-2" {
+		t.Errorf("tokens = %+v", stored)
+	}
+	// O mesmo token em outro dispositivo sai do anterior
+	env.registerPush(tablet, token, "apns", "tok-2")
+	env.db.Find(&stored)
+	if len(stored) != 1 || stored[0].DeviceID != tablet.ID {
+		t.Errorf("tokens = %+v", stored)
+	}
+
+	w := env.do(http.MethodPut, fmt.Sprintf("/devices/%d/push-token", phone.ID), gin.H{"platform": "sms", "token": "x"}, token)
+	expectStatus(t, w, http.StatusBadRequest)
+	_, biaToken := env.seedUser("bia", models.RoleUser)
+	w = env.do(http.MethodPut, fmt.Sprintf("/devices/%d/push-token", phone.ID), gin.H{"platform": "fcm", "token": "x"}, biaToken)
+	expectStatus(t, w, http.StatusForbidden)
+
+	expectStatus(t, env.do(http.MethodDelete, fmt.Sprintf("/devices/%d/push-token", tablet.ID), nil, token), http.StatusOK)
+	expectStatus(t, env.do(http.MethodDelete, fmt.Sprintf("/devices/%d/push-token", tablet.ID), nil, token), http.StatusNotFound)
+}
+
+func TestFCMProvider(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var srv *httptest.Server
+	var mu sync.Mutex
+	var received []map[string]interface{}
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			r.ParseForm()
+			if r.Form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || r.Form.Get("assertion") == "" {
+				http.Error(w, "bad grant", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"access_token":"ya29.test","expires_in":3600}`)
+		case "/v1/projects/demo/messages:send":
+			if r.Header.Get("Authorization") != "Bearer ya29.test" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			received = append(received, body)
+			mu.Unlock()
+			if strings.Contains(fmt.Sprint(body), "dead") {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"error":{"status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`)
+				return
+			}
+			fmt.Fprint(w, `{"name":"projects/demo/messages/1"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	creds, _ := json.Marshal(map[string]string{
+		"type": "service_account", "project_id": "demo", "private_key_id": "k1",
+		"private_key": string(pemKey), "client_email": "push@demo.iam.gserviceaccount.com", "token_uri": srv.URL + "/token",
+	})
+	file := filepath.Join(t.TempDir(), "fcm.json")
+	os.WriteFile(file, creds, 0o600)
+	fcm := push.NewFCM(file)
+	fcm.BaseURL = srv.URL
+
+	errs := fcm.Send(context.Background(), []push.Message{
+		{Token: "alive", Title: "Oi", Body: "Tudo bem?", Data: map[string]string{"k": "v"}},
+		{Token: "dead", Title: "Oi"},
+	})
+	if errs[0] != nil || errs[1] != push.ErrUnregistered {
+		t.Errorf("erros = %v", errs)
+	}
+	if len(received) != 2 {
+		t.Fatalf("recebidas = %d", len(received))
+	}
+
+	// Credencial ilegível: erro em cada mensagem, sem pânico
+	broken := push.NewFCM(filepath.Join(t.TempDir(), "nada.json"))
+	if errs := broken.Send(context.Background(), []push.Message{{Token: "x"}}); errs[0] == nil {
+		t.Error("credencial ausente aceita")
+	}
+}
+
+func TestAPNsProvider(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	file := filepath.Join(t.TempDir(), "AuthKey.p8")
+	os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("apns-topic") != "br.ufu.app" || !strings.HasPrefix(r.Header.Get("Authorization"), "bearer ") {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"reason":"InvalidProviderToken"}`)
+			return
+		}
+		var body struct {
+			APS struct {
+				Alert struct {
+					Title string `json:"title"`
+				} `json:"alert"`
+			} `json:"aps"`
+			Kind string `json:"kind"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch {
+		case r.URL.Path == "/3/device/old":
+			w.WriteHeader(http.StatusGone)
+			fmt.Fprint(w, `{"reason":"Unregistered"}`)
+		case body.APS.Alert.Title != "Oi" || body.Kind != "battery":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"reason":"PayloadEmpty"}`)
+		}
+	}))
+	defer srv.Close()
+
+	apns := push.NewAPNs(file, "KEY123", "TEAM123", "br.ufu.app", true)
+	apns.BaseURL = srv.URL
+	errs := apns.Send(context.Background(), []push.Message{
+		{Token: "abc", Title: "Oi", Data: map[string]string{"kind": "battery"}},
+		{Token: "old", Title: "Oi"},
+	})
+	if errs[0] != nil || errs[1] != push.ErrUnregistered {
+		t.Errorf("erros = %v", errs)
+	}
+}
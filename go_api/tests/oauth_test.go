@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"go_api/handlers"
+	"go_api/models"
+	"go_api/oauth"
+)
+
+// GitHub falso: o code "code-<login>" vale pelo usuário fakeGitHubUser
+type fakeGitHubUser struct {
+	ID       int64
+	Login    string
+	Email    string
+	Verified bool
+}
+
+func newFakeGitHub(t *testing.T, users ...fakeGitHubUser) *oauth.GitHub {
+	t.Helper()
+	byToken := map[string]fakeGitHubUser{}
+	for _, u := range users {
+		byToken["token-"+u.Login] = u
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("client_secret") != "segredo" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "incorrect_client_credentials"})
+			return
+		}
+		login := r.Form.Get("code")[len("code-"):]
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "token-" + login})
+	})
+	current := func(r *http.Request) fakeGitHubUser {
+		return byToken[r.Header.Get("Authorization")[len("Bearer "):]]
+	}
+	mux.HandleFunc("GET /user", func(w http.ResponseWriter, r *http.Request) {
+		u := current(r)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": u.ID, "login": u.Login, "name": ""})
+	})
+	mux.HandleFunc("GET /user/emails", func(w http.ResponseWriter, r *http.Request) {
+		u := current(r)
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"email": "outro@exemplo.com", "primary": false, "verified": true},
+			{"email": u.Email, "primary": true, "verified": u.Verified},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	gh := oauth.NewGitHub("cliente", "segredo")
+	gh.AuthURL = srv.URL + "/authorize"
+	gh.TokenURL = srv.URL + "/token"
+	gh.APIURL = srv.URL
+	return gh
+}
+
+// Faz o fluxo inteiro pelo router: início, volta do provedor com o code
+func (e *testEnv) oauthLogin(provider, code string) *httptest.ResponseRecorder {
+	e.t.Helper()
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/"+provider, nil))
+	expectStatus(e.t, w, http.StatusFound)
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		e.t.Fatalf("Location: %v", err)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) == 0 {
+		e.t.Fatal("cookie do state não foi definido")
+	}
+
+	q := url.Values{"code": {code}, "state": {location.Query().Get("state")}}
+	req := httptest.NewRequest(http.MethodGet, "/auth/"+provider+"/callback?"+q.Encode(), nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestOAuthCreatesAndReusesAccount(t *testing.T) {
+	env := newTestEnv(t)
+	env.handler.OAuth = map[string]oauth.Provider{"github": newFakeGitHub(t,
+		fakeGitHubUser{ID: 42, Login: "aluna", Email: "aluna@exemplo.com", Verified: true})}
+
+	w := env.oauthLogin("github", "code-aluna")
+	expectStatus(t, w, http.StatusOK)
+	var tokens handlers.TokenPair
+	decode(t, w, &tokens)
+
+	w = env.do(http.MethodGet, "/me", nil, tokens.AccessToken)
+	expectStatus(t, w, http.StatusOK)
+	var user models.User
+	decode(t, w, &user)
+	if user.User != "aluna" || user.Email != "aluna@exemplo.com" || !user.EmailVerified || user.Name != "aluna" {
+		t.Fatalf("usuário criado = %+v", user)
+	}
+
+	// Segundo login: mesma conta, nenhuma identidade nova
+	w = env.oauthLogin("github", "code-aluna")
+	expectStatus(t, w, http.StatusOK)
+	var identities []models.Identity
+	env.db.Find(&identities)
+	if len(identities) != 1 || identities[0].UserID != user.ID || identities[0].Subject != "42" {
+		t.Fatalf("identidades = %+v", identities)
+	}
+}
+
+func TestOAuthLinksVerifiedAccount(t *testing.T) {
+	env := newTestEnv(t)
+	env.handler.OAuth = map[string]oauth.Provider{"github": newFakeGitHub(t,
+		fakeGitHubUser{ID: 7, Login: "ana-gh", Email: "ana@exemplo.com", Verified: true},
+		fakeGitHubUser{ID: 8, Login: "sem-email", Email: "sem@exemplo.com", Verified: false})}
+	ana, _ := env.seedUser("ana", models.RoleUser)
+
+	// E-mail ainda sem verificação na API: não liga
+	w := env.oauthLogin("github", "code-ana-gh")
+	expectStatus(t, w, http.StatusConflict)
+	if e := decodeError(t, w); e.Code != "account_exists" {
+		t.Fatalf("erro = %+v", e)
+	}
+
+	env.db.Model(&models.User{}).Where("id = ?", ana.ID).Update("email_verified", true)
+	w = env.oauthLogin("github", "code-ana-gh")
+	expectStatus(t, w, http.StatusOK)
+	var tokens handlers.TokenPair
+	decode(t, w, &tokens)
+	w = env.do(http.MethodGet, "/me", nil, tokens.AccessToken)
+	var user models.User
+	decode(t, w, &user)
+	if user.ID != ana.ID || user.User != "ana" {
+		t.Fatalf("usuário = %+v, want a conta da ana", user)
+	}
+
+	w = env.oauthLogin("github", "code-sem-email")
+	expectStatus(t, w, http.StatusForbidden)
+	if e := decodeError(t, w); e.Code != "unverified_email" {
+		t.Fatalf("erro = %+v", e)
+	}
+}
+
+func TestOAuthRejectsForgedState(t *testing.T) {
+	env := newTestEnv(t)
+	env.handler.OAuth = map[string]oauth.Provider{"github": newFakeGitHub(t,
+		fakeGitHubUser{ID: 42, Login: "aluna", Email: "aluna@exemplo.com", Verified: true})}
+
+	expectStatus(t, env.do(http.MethodGet, "/auth/gitlab", nil, ""), http.StatusNotFound)
+
+	// State válido, mas sem o cookie do navegador que começou o fluxo
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/github", nil))
+	location, _ := url.Parse(w.Header().Get("Location"))
+	q := url.Values{"code": {"code-aluna"}, "state": {location.Query().Get("state")}}
+	w = env.do(http.MethodGet, "/auth/github/callback?"+q.Encode(), nil, "")
+	expectStatus(t, w, http.StatusBadRequest)
+	if e := decodeError(t, w); e.Code != "invalid_oauth_state" {
+		t.Fatalf("erro = %+v", e)
+	}
+
+	w = env.do(http.MethodGet, "/auth/github/callback?error=access_denied", nil, "")
+	expectStatus(t, w, http.StatusUnauthorized)
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go_api/models"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUserETag(t *testing.T) {
+	env := newTestEnv(t)
+	user, token := env.seedUser("ana", models.RoleUser)
+	path := fmt.Sprintf("/users/%d", user.ID)
+
+	w := env.do(http.MethodGet, path, nil, token)
+	expectStatus(t, w, http.StatusOK)
+	etag := w.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("ETag = %q", etag)
+	}
+
+	w = env.doWithHeaders(http.MethodGet, path, nil, token, map[string]string{"If-None-Match": etag})
+	expectStatus(t, w, http.StatusNotModified)
+	if w.Body.Len() != 0 {
+		t.Errorf("304 com corpo: %s", w.Body.String())
+	}
+
+	w = env.doWithHeaders(http.MethodPut, path, gin.H{"name": "Ana Maria"}, token, map[string]string{"If-Match": etag})
+	expectStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("ETag"); got != `"2"` {
+		t.Fatalf("ETag depois do PUT = %q", got)
+	}
+
+	w = env.doWithHeaders(http.MethodGet, path, nil, token, map[string]string{"If-None-Match": etag})
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestUserIfMatchConflict(t *testing.T) {
+	env := newTestEnv(t)
+	user, token := env.seedUser("ana", models.RoleUser)
+	path := fmt.Sprintf("/users/%d", user.ID)
+
+	// Dois aparelhos leram a revisão 1; o primeiro grava
+	expectStatus(t, env.doWithHeaders(http.MethodPatch, path, gin.H{"name": "Celular"}, token,
+		map[string]string{"If-Match": `"1"`}), http.StatusOK)
+
+	for _, method := range []string{http.MethodPut, http.MethodPatch} {
+		w := env.doWithHeaders(method, path, gin.H{"name": "Tablet"}, token, map[string]string{"If-Match": `"1"`})
+		expectStatus(t, w, http.StatusConflict)
+		if got := w.Header().Get("ETag"); got != `"2"` {
+			t.Errorf("%s: ETag = %q", method, got)
+		}
+		var body struct {
+			Error struct {
+				Code    string `json:"code"`
+				Details struct {
+					Current models.User `json:"current"`
+				} `json:"details"`
+			} `json:"error"`
+		}
+		decode(t, w, &body)
+		if body.Error.Code != "revision_conflict" || body.Error.Details.Current.Name != "Celular" || body.Error.Details.Current.Revision != 2 {
+			t.Errorf("%s: erro = %+v", method, body.Error)
+		}
+	}
+
+	w := env.do(http.MethodGet, path, nil, token)
+	decode(t, w, &user)
+	if user.Name != "Celular" {
+		t.Fatalf("nome = %q; a escrita com revisão velha passou", user.Name)
+	}
+
+	// "*" e sem If-Match: sem condição
+	expectStatus(t, env.doWithHeaders(http.MethodPut, path, gin.H{"name": "Qualquer"}, token,
+		map[string]string{"If-Match": "*"}), http.StatusOK)
+	expectStatus(t, env.doWithHeaders(http.MethodPut, path, gin.H{"name": "Qualquer"}, token,
+		map[string]string{"If-Match": "abc"}), http.StatusBadRequest)
+}
+
+// Sem If-Match, a segunda de duas escritas concorrentes (mesma revisão lida)
+// não sobrescreve a primeira
+func TestUserConcurrentWritesDoNotLoseUpdates(t *testing.T) {
+	env := newTestEnv(t)
+	user, token := env.seedUser("ana", models.RoleUser)
+	ctx := context.Background()
+	users := env.handler.Users
+
+	stale, err := users.Get(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, env.do(http.MethodPut, fmt.Sprintf("/users/%d", user.ID), gin.H{"name": "Primeira"}, token), http.StatusOK)
+
+	err = users.Apply(ctx, &stale, models.UpdateUserInput{Name: "Segunda"})
+	var conflict *service.RevisionConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("err = %v, want RevisionConflictError", err)
+	}
+	if conflict.Current.Name != "Primeira" {
+		t.Errorf("estado atual = %+v", conflict.Current)
+	}
+	current, _ := users.Get(ctx, user.ID)
+	if current.Name != "Primeira" || current.Revision != 2 {
+		t.Fatalf("usuário = %+v", current)
+	}
+}
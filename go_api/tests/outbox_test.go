@@ -0,0 +1,265 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go_api/broker"
+	"go_api/models"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Broker falso: guarda as mensagens e falha as dos tópicos em failTopics
+type fakeBroker struct {
+	mu         sync.Mutex
+	sent       []broker.Message
+	failTopics map[string]bool
+}
+
+func (b *fakeBroker) Name() string { return "fake" }
+
+func (b *fakeBroker) Publish(_ context.Context, msgs []broker.Message) []error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	errs := make([]error, len(msgs))
+	for i, msg := range msgs {
+		if b.failTopics[msg.Topic] {
+			errs[i] = errors.New("broker fora do ar")
+			continue
+		}
+		b.sent = append(b.sent, msg)
+	}
+	return errs
+}
+
+func (e *testEnv) outboxTypes() []string {
+	e.t.Helper()
+	var types []string
+	if err := e.db.Model(&models.OutboxEvent{}).Order("id").Pluck("type", &types).Error; err != nil {
+		e.t.Fatalf("outbox: %v", err)
+	}
+	return types
+}
+
+func TestOutboxEvents(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	w := env.do(http.MethodPut, fmt.Sprintf("/users/%d", ana.ID), gin.H{"name": "Ana Maria", "email": "ana.maria@exemplo.com"}, token)
+	expectStatus(t, w, http.StatusOK)
+	// Recusado: a transação é desfeita e nada vai para o outbox
+	expectStatus(t, env.do(http.MethodPost, "/users", gin.H{"name": "Outra", "email": "ana.maria@exemplo.com", "user": "outra", "password": "senha-outra"}, ""), http.StatusConflict)
+
+	device := env.createDevice(ana.ID, token)
+	// O primeiro sinal de vida põe o dispositivo online; os seguintes, não
+	env.postReadings(device, token, []gin.H{{"metric": "temp", "value": 21, "timestamp": "2026-10-14T10:00:00Z"}, {"metric": "humidity", "value": 40, "timestamp": "2026-10-14T10:05:00Z"}, {"metric": "temp", "value": 22, "timestamp": "2026-10-14T10:10:00Z"}})
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/devices/%d/heartbeat", device.ID), nil, token), http.StatusOK)
+
+	want := []string{"user.created", "user.updated", "reading.ingested", "device.online"}
+	if got := env.outboxTypes(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("outbox = %v, quero %v", got, want)
+	}
+
+	var events []models.OutboxEvent
+	env.db.Order("id").Find(&events)
+	var updated models.WebhookPayload
+	json.Unmarshal(events[1].Payload, &updated)
+	if events[1].Entity != fmt.Sprintf("user:%d", ana.ID) || len(updated.Changes) != 2 {
+		t.Errorf("user.updated = %+v (%s)", events[1], events[1].Payload)
+	}
+	var ingested models.ReadingsIngestedEvent
+	json.Unmarshal(events[2].Payload, &ingested)
+	if ingested.DeviceID != device.ID || ingested.Count != 3 || strings.Join(ingested.Metrics, ",") != "humidity,temp" ||
+		!ingested.From.Equal(time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)) || !ingested.To.Equal(time.Date(2026, 10, 14, 10, 10, 0, 0, time.UTC)) {
+		t.Errorf("reading.ingested = %s", events[2].Payload)
+	}
+	if events[3].Entity != fmt.Sprintf("device:%d", device.ID) || events[3].TenantID != models.DefaultTenantID {
+		t.Errorf("device.online = %+v", events[3])
+	}
+
+	// Depois de PRESENCE_TIMEOUT parado, volta a ficar online
+	env.db.Model(&models.Device{}).Where("id = ?", device.ID).Update("last_seen", time.Now().Add(-time.Hour))
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/devices/%d/heartbeat", device.ID), nil, token), http.StatusOK)
+	if got := env.outboxTypes(); len(got) != 5 || got[4] != "device.online" {
+		t.Errorf("depois de offline: %v", got)
+	}
+}
+
+func TestOutboxRelay(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, token)
+	env.postReadings(device, token, gin.H{"metric": "temp", "value": 20})
+
+	fake := &fakeBroker{failTopics: map[string]bool{"go_api.reading.ingested": true}}
+	relay := service.NewOutboxRelay(env.db, fake, "go_api.")
+	ctx := context.Background()
+	if n := relay.PublishDue(ctx); n != 2 {
+		t.Fatalf("publicados = %d, quero 2", n)
+	}
+	if fake.sent[0].Topic != "go_api.user.created" || fake.sent[0].Key != fmt.Sprintf("user:%d", ana.ID) || fake.sent[1].Topic != "go_api.device.online" {
+		t.Fatalf("mensagens = %+v", fake.sent)
+	}
+	var msg struct {
+		ID         uint            `json:"id"`
+		Type       string          `json:"type"`
+		TenantID   uint            `json:"tenant_id"`
+		Data       json.RawMessage `json:"data"`
+		OccurredAt time.Time       `json:"occurred_at"`
+	}
+	if err := json.Unmarshal(fake.sent[0].Value, &msg); err != nil || msg.ID == 0 || msg.Type != "user.created" || len(msg.Data) == 0 || msg.OccurredAt.IsZero() {
+		t.Errorf("corpo = %s (%v)", fake.sent[0].Value, err)
+	}
+
+	// O que falhou espera a próxima tentativa; o que saiu não sai de novo
+	var failed models.OutboxEvent
+	env.db.Where("type = ?", models.OutboxReadingIngested).First(&failed)
+	if failed.PublishedAt != nil || failed.Attempts != 1 || failed.LastError == "" || !failed.NextAttemptAt.After(time.Now()) {
+		t.Fatalf("falhou = %+v", failed)
+	}
+	if n := relay.PublishDue(ctx); n != 0 {
+		t.Fatalf("republicou %d antes da hora", n)
+	}
+	fake.failTopics = nil
+	env.db.Model(&failed).Update("next_attempt_at", time.Now().Add(-time.Second))
+	if n := relay.PublishDue(ctx); n != 1 || fake.sent[2].Topic != "go_api.reading.ingested" {
+		t.Fatalf("nova tentativa = %d, %+v", n, fake.sent)
+	}
+
+	// Os publicados há mais de um dia saem da tabela
+	env.db.Model(&models.OutboxEvent{}).Where("type = ?", models.OutboxUserCreated).Update("published_at", time.Now().Add(-48*time.Hour))
+	if err := relay.Cleanup(ctx); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if got := env.outboxTypes(); len(got) != 2 {
+		t.Errorf("depois do cleanup: %v", got)
+	}
+}
+
+// Servidor NATS mínimo: cumprimenta, confere o CONNECT e responde PONG a
+// cada PING, guardando os PUBs recebidos
+func fakeNATS(t *testing.T, reject string) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	pubs := make(chan string, 16)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		fmt.Fprint(conn, "INFO {\"server_id\":\"teste\",\"max_payload\":1048576}\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch {
+			case strings.HasPrefix(line, "CONNECT "):
+				if reject != "" && !strings.Contains(line, reject) {
+					fmt.Fprint(conn, "-ERR 'Authorization Violation'\r\n")
+					return
+				}
+			case strings.HasPrefix(line, "PUB "):
+				var subject string
+				var size int
+				fmt.Sscanf(line, "PUB %s %d", &subject, &size)
+				payload := make([]byte, size+2)
+				io.ReadFull(r, payload)
+				pubs <- subject + " " + string(payload[:size])
+			case line == "PING":
+				fmt.Fprint(conn, "PONG\r\n")
+			}
+		}
+	}()
+	return ln.Addr().String(), pubs
+}
+
+func TestNATSPublisher(t *testing.T) {
+	addr, pubs := fakeNATS(t, `"auth_token":"segredo"`)
+	nats := broker.NewNATS("nats://segredo@" + addr)
+	msgs := []broker.Message{
+		{Topic: "go_api.user.created", Value: []byte(`{"id":1}`)},
+		{Topic: "go_api.device.online", Value: []byte(`{"id":2}`)},
+	}
+	for i, err := range nats.Publish(context.Background(), msgs) {
+		if err != nil {
+			t.Fatalf("mensagem %d: %v", i, err)
+		}
+	}
+	for _, want := range []string{`go_api.user.created {"id":1}`, `go_api.device.online {"id":2}`} {
+		if got := <-pubs; got != want {
+			t.Errorf("PUB = %q, quero %q", got, want)
+		}
+	}
+
+	// Credencial errada: o lote inteiro falha
+	addr, _ = fakeNATS(t, `"user":"api"`)
+	errs := broker.NewNATS("nats://outro@"+addr).Publish(context.Background(), msgs)
+	if errs[0] == nil || errs[1] == nil || !strings.Contains(errs[0].Error(), "Authorization Violation") {
+		t.Errorf("erros = %v", errs)
+	}
+}
+
+func TestKafkaPublisher(t *testing.T) {
+	var mu sync.Mutex
+	got := map[string][]string{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/vnd.kafka.json.v2+json" {
+			http.Error(w, `{"message":"bad request"}`, http.StatusBadRequest)
+			return
+		}
+		var body struct {
+			Records []struct {
+				Key   string          `json:"key"`
+				Value json.RawMessage `json:"value"`
+			} `json:"records"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		topic := strings.TrimPrefix(r.URL.Path, "/topics/")
+		offsets := make([]gin.H, len(body.Records))
+		mu.Lock()
+		for i, rec := range body.Records {
+			got[topic] = append(got[topic], rec.Key+" "+string(rec.Value))
+			offsets[i] = gin.H{"partition": 0, "offset": i, "error_code": nil, "error": nil}
+			// O proxy recusa registro a registro
+			if rec.Key == "device:9" {
+				offsets[i] = gin.H{"partition": nil, "offset": nil, "error_code": 50003, "error": "topic authorization failed"}
+			}
+		}
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/vnd.kafka.v2+json")
+		json.NewEncoder(w).Encode(gin.H{"offsets": offsets})
+	}))
+	defer proxy.Close()
+
+	errs := broker.NewKafka(proxy.URL+"/").Publish(context.Background(), []broker.Message{
+		{Topic: "go_api.user.created", Key: "user:1", Value: []byte(`{"id":1}`)},
+		{Topic: "go_api.device.online", Key: "device:9", Value: []byte(`{"id":2}`)},
+		{Topic: "go_api.user.created", Key: "user:2", Value: []byte(`{"id":3}`)},
+	})
+	if errs[0] != nil || errs[2] != nil || errs[1] == nil || !strings.Contains(errs[1].Error(), "50003") {
+		t.Fatalf("erros = %v", errs)
+	}
+	if users := got["go_api.user.created"]; len(users) != 2 || users[0] != `user:1 {"id":1}` || users[1] != `user:2 {"id":3}` {
+		t.Errorf("user.created = %v", users)
+	}
+}
@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go_api/models"
+)
+
+func TestMalformedPathIDs(t *testing.T) {
+	env := newTestEnv(t)
+	user, token := env.seedUser("param_ana", "")
+	_, admin := env.seedUser("param_admin", models.RoleAdmin)
+	device := env.createDevice(user.ID, token)
+
+	cases := []struct{ method, path, token, param string }{
+		{http.MethodGet, "/users/abc", admin, "id"},
+		{http.MethodGet, "/users/1;drop", admin, "id"},
+		{http.MethodGet, "/users/0", admin, "id"},
+		{http.MethodGet, "/users/-1", admin, "id"},
+		{http.MethodGet, "/users/9223372036854775808", admin, "id"}, // passa do bigint
+		{http.MethodDelete, "/users/abc", admin, "id"},
+		{http.MethodGet, "/users/abc/devices", token, "id"},
+		{http.MethodGet, "/devices/abc", token, "id"},
+		{http.MethodGet, "/devices/1.5/readings", token, "id"},
+		{http.MethodDelete, fmt.Sprintf("/users/%d/api-keys/abc", user.ID), token, "key"},
+		{http.MethodDelete, "/me/sessions/abc", token, "id"},
+		{http.MethodDelete, "/webhooks/abc", admin, "id"},
+		{http.MethodGet, "/groups/abc", token, "id"},
+	}
+	for _, tc := range cases {
+		w := env.do(tc.method, tc.path, nil, tc.token)
+		expectStatus(t, w, http.StatusBadRequest)
+		if e := decodeError(t, w); e.Code != "invalid_id" || e.Details[tc.param] == "" {
+			t.Errorf("%s %s: erro = %+v", tc.method, tc.path, e)
+		}
+	}
+
+	// Bem formado, mas inexistente, continua 404
+	expectStatus(t, env.do(http.MethodGet, "/devices/999999", nil, token), http.StatusNotFound)
+	expectStatus(t, env.do(http.MethodGet, "/users/9223372036854775807", nil, admin), http.StatusNotFound)
+	expectStatus(t, env.do(http.MethodGet, fmt.Sprintf("/devices/%d", device.ID), nil, token), http.StatusOK)
+	// Zeros à esquerda ainda são o mesmo número
+	expectStatus(t, env.do(http.MethodGet, fmt.Sprintf("/users/00%d", user.ID), nil, token), http.StatusOK)
+}
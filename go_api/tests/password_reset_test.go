@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go_api/models"
+	"go_api/repository"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Pede a redefinição e devolve o token do e-mail enviado
+func (e *testEnv) forgotPassword(email string) string {
+	e.t.Helper()
+	before := len(e.mail.messages())
+	expectStatus(e.t, e.do(http.MethodPost, "/password/forgot", gin.H{"email": email}, ""), http.StatusAccepted)
+	sent := e.mail.messages()
+	if len(sent) != before+1 {
+		e.t.Fatalf("e-mails enviados = %d, want %d", len(sent), before+1)
+	}
+	msg := sent[len(sent)-1]
+	if msg.To != email {
+		e.t.Fatalf("e-mail para %q, want %q", msg.To, email)
+	}
+	_, rest, _ := strings.Cut(msg.Body, "reset your password: ")
+	token, _, _ := strings.Cut(rest, "\n")
+	if token == "" {
+		e.t.Fatalf("token não encontrado no e-mail: %q", msg.Body)
+	}
+	return token
+}
+
+func TestPasswordReset(t *testing.T) {
+	env := newTestEnv(t)
+	env.seedUser("ana", models.RoleUser)
+
+	token := env.forgotPassword("ana@exemplo.com")
+	w := env.do(http.MethodPost, "/password/reset", gin.H{"token": token, "new_password": "nova-senha"}, "")
+	expectStatus(t, w, http.StatusOK)
+	env.login("ana", "nova-senha")
+
+	// Uso único
+	w = env.do(http.MethodPost, "/password/reset", gin.H{"token": token, "new_password": "outra-senha"}, "")
+	expectStatus(t, w, http.StatusBadRequest)
+	if body := decodeError(t, w); body.Code != "invalid_reset_token" {
+		t.Errorf("code = %q", body.Code)
+	}
+
+	// Só o hash vai para o banco
+	var stored models.PasswordResetToken
+	env.db.First(&stored)
+	if stored.TokenHash == token || stored.UsedAt == nil {
+		t.Errorf("token gravado = %+v", stored)
+	}
+}
+
+func TestPasswordResetUnknownEmail(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do(http.MethodPost, "/password/forgot", gin.H{"email": "ninguem@exemplo.com"}, "")
+	expectStatus(t, w, http.StatusAccepted)
+	if n := len(env.mail.messages()); n != 0 {
+		t.Errorf("e-mails enviados = %d, want 0", n)
+	}
+}
+
+func TestPasswordResetNewRequestInvalidatesOld(t *testing.T) {
+	env := newTestEnv(t)
+	env.seedUser("ana", models.RoleUser)
+
+	old := env.forgotPassword("ana@exemplo.com")
+	current := env.forgotPassword("ana@exemplo.com")
+
+	w := env.do(http.MethodPost, "/password/reset", gin.H{"token": old, "new_password": "nova-senha"}, "")
+	expectStatus(t, w, http.StatusBadRequest)
+	w = env.do(http.MethodPost, "/password/reset", gin.H{"token": current, "new_password": "nova-senha"}, "")
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestPasswordResetExpired(t *testing.T) {
+	env := newTestEnv(t)
+	env.seedUser("ana", models.RoleUser)
+
+	token := env.forgotPassword("ana@exemplo.com")
+	env.db.Model(&models.PasswordResetToken{}).Where("1 = 1").
+		Update("expires_at", time.Now().Add(-time.Minute))
+
+	w := env.do(http.MethodPost, "/password/reset", gin.H{"token": token, "new_password": "nova-senha"}, "")
+	expectStatus(t, w, http.StatusBadRequest)
+	env.login("ana", "senha-ana")
+}
+
+func TestPasswordResetCleanup(t *testing.T) {
+	env := newTestEnv(t)
+	env.seedUser("ana", models.RoleUser)
+	env.forgotPassword("ana@exemplo.com")
+	env.db.Model(&models.PasswordResetToken{}).Where("1 = 1").
+		Update("expires_at", time.Now().Add(-time.Minute))
+
+	users := service.NewUserService(repository.NewUserStore(env.db)).
+		WithPasswordReset(repository.NewPasswordResetStore(env.db), service.PasswordResetPolicy{TTL: time.Minute})
+	users.CleanupTokens(context.Background())
+
+	var count int64
+	env.db.Model(&models.PasswordResetToken{}).Count(&count)
+	if count != 0 {
+		t.Errorf("tokens restantes = %d, want 0", count)
+	}
+}
+
+// Sessões que não conseguem ser encerradas: o último passo da troca de senha
+type failingSessions struct {
+	repository.SessionStore
+}
+
+func (s failingSessions) RevokeAll(context.Context, uint, time.Time) error {
+	return errors.New("banco fora do ar")
+}
+
+func (s failingSessions) WithTx(tx *gorm.DB) repository.SessionStore {
+	return failingSessions{s.SessionStore.WithTx(tx)}
+}
+
+func TestPasswordResetRollsBack(t *testing.T) {
+	env := newTestEnv(t)
+	ana, anaToken := env.seedUser("ana", models.RoleUser)
+	working := env.handler.Users
+	env.handler.Users = working.WithSessions(failingSessions{repository.NewSessionStore(env.db)}, service.SessionPolicy{TTL: time.Hour})
+
+	// Falhou no fim: a senha não mudou e o token continua valendo
+	token := env.forgotPassword(ana.Email)
+	w := env.do(http.MethodPost, "/password/reset", gin.H{"token": token, "new_password": "nova-senha"}, "")
+	expectStatus(t, w, http.StatusInternalServerError)
+	env.login("ana", "senha-ana")
+
+	// Troca pelo PUT também: nada é gravado
+	w = env.do(http.MethodPut, fmt.Sprintf("/users/%d/password", ana.ID), gin.H{"current_password": "senha-ana", "new_password": "nova-senha"}, anaToken)
+	expectStatus(t, w, http.StatusInternalServerError)
+	env.login("ana", "senha-ana")
+	var events int64
+	env.db.Model(&models.UserEvent{}).Where("user_id = ? AND type = ?", ana.ID, models.EventPasswordChanged).Count(&events)
+	if events != 0 {
+		t.Errorf("eventos de troca de senha = %d", events)
+	}
+
+	env.handler.Users = working
+	w = env.do(http.MethodPost, "/password/reset", gin.H{"token": token, "new_password": "nova-senha"}, "")
+	expectStatus(t, w, http.StatusOK)
+	env.login("ana", "nova-senha")
+}
@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go_api/models"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func (e *testEnv) createDevice(userID uint, token string) models.Device {
+	e.t.Helper()
+	w := e.do(http.MethodPost, fmt.Sprintf("/users/%d/devices", userID), gin.H{"name": "Relógio", "type": "wearable"}, token)
+	expectStatus(e.t, w, http.StatusCreated)
+	var device models.Device
+	decode(e.t, w, &device)
+	return device
+}
+
+// Abre o /ws num servidor de verdade (o httptest.ResponseRecorder não faz upgrade)
+func (e *testEnv) dialPresence(token string) *websocket.Conn {
+	e.t.Helper()
+	srv := httptest.NewServer(e.router)
+	e.t.Cleanup(srv.Close)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?access_token=" + token
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		e.t.Fatalf("dial /ws: %v", err)
+	}
+	e.t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readPresence(t *testing.T, conn *websocket.Conn) service.PresenceEvent {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var e service.PresenceEvent
+	if err := conn.ReadJSON(&e); err != nil {
+		t.Fatalf("lendo evento: %v", err)
+	}
+	return e
+}
+
+func TestPresenceWebSocket(t *testing.T) {
+	env := newTestEnv(t)
+	ana, anaToken := env.seedUser("ana", models.RoleUser)
+	_, adminToken := env.seedUser("admin", models.RoleAdmin)
+	bia, biaToken := env.seedUser("bia", models.RoleUser)
+	device := env.createDevice(ana.ID, anaToken)
+	other := env.createDevice(bia.ID, biaToken)
+
+	admin := env.dialPresence(adminToken)
+	own := env.dialPresence(anaToken)
+	// A inscrição acontece antes do upgrade: depois do Dial, nada se perde
+
+	// Dispositivo de outro usuário: só o admin vê
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/devices/%d/heartbeat", other.ID), nil, biaToken), http.StatusOK)
+	if e := readPresence(t, admin); e.Type != service.PresenceOnline || e.DeviceID != other.ID {
+		t.Errorf("admin: evento = %+v", e)
+	}
+
+	w := env.do(http.MethodPost, fmt.Sprintf("/devices/%d/readings", device.ID), gin.H{"metric": "bpm", "value": 72}, anaToken)
+	expectStatus(t, w, http.StatusCreated)
+	for name, conn := range map[string]*websocket.Conn{"admin": admin, "dono": own} {
+		if e := readPresence(t, conn); e.Type != service.PresenceOnline || e.DeviceID != device.ID || e.UserID != ana.ID {
+			t.Errorf("%s: evento = %+v", name, e)
+		}
+	}
+
+	// last_seen gravado no dispositivo
+	var stored models.Device
+	env.db.First(&stored, device.ID)
+	if stored.LastSeen == nil {
+		t.Error("last_seen não foi gravado")
+	}
+
+	// Sem chamadas além do timeout: offline
+	env.handler.Presence.Sweep(time.Now().Add(time.Hour))
+	if e := readPresence(t, own); e.Type != service.PresenceOffline || e.DeviceID != device.ID {
+		t.Errorf("dono: evento = %+v", e)
+	}
+}
+
+func TestPresenceSnapshotOnConnect(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, token)
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/devices/%d/heartbeat", device.ID), nil, token), http.StatusOK)
+
+	conn := env.dialPresence(token)
+	if e := readPresence(t, conn); e.Type != service.PresenceOnline || e.DeviceID != device.ID {
+		t.Errorf("evento inicial = %+v", e)
+	}
+}
+
+func TestPresenceRequiresToken(t *testing.T) {
+	env := newTestEnv(t)
+	expectStatus(t, env.do(http.MethodGet, "/ws", nil, ""), http.StatusUnauthorized)
+}
+
+func TestHeartbeatMarksOnline(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, token)
+	if device.Online {
+		t.Error("dispositivo novo online")
+	}
+
+	w := env.do(http.MethodPost, fmt.Sprintf("/devices/%d/heartbeat", device.ID), nil, token)
+	expectStatus(t, w, http.StatusOK)
+	var beat models.Device
+	decode(t, w, &beat)
+	if !beat.Online || beat.LastSeen == nil || beat.Name != device.Name || beat.UserID != ana.ID {
+		t.Errorf("heartbeat = %+v", beat)
+	}
+
+	// Passou do PRESENCE_TIMEOUT (1 min nos testes): offline
+	w = env.do(http.MethodGet, fmt.Sprintf("/devices/%d", device.ID), nil, token)
+	decode(t, w, &beat)
+	if !beat.Online {
+		t.Errorf("dispositivo = %+v", beat)
+	}
+	env.db.Model(&models.Device{}).Where("id = ?", device.ID).Update("last_seen", time.Now().Add(-2*time.Minute))
+	w = env.do(http.MethodGet, fmt.Sprintf("/devices/%d", device.ID), nil, token)
+	decode(t, w, &beat)
+	if beat.Online {
+		t.Errorf("dispositivo = %+v", beat)
+	}
+
+	_, biaToken := env.seedUser("bia", models.RoleUser)
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/devices/%d/heartbeat", device.ID), nil, biaToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodPost, "/devices/999999/heartbeat", nil, token), http.StatusNotFound)
+	expectStatus(t, env.do(http.MethodPost, "/devices/abc/heartbeat", nil, token), http.StatusBadRequest)
+}
+
+func TestListDevicesByStatus(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	alive := env.createDevice(ana.ID, token)
+	stale := env.createDevice(ana.ID, token)
+	never := env.createDevice(ana.ID, token)
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/devices/%d/heartbeat", alive.ID), nil, token), http.StatusOK)
+	env.db.Model(&models.Device{}).Where("id = ?", stale.ID).Update("last_seen", time.Now().Add(-time.Hour))
+
+	bia, biaToken := env.seedUser("bia", models.RoleUser)
+	other := env.createDevice(bia.ID, biaToken)
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/devices/%d/heartbeat", other.ID), nil, biaToken), http.StatusOK)
+
+	list := func(query, token string) []uint {
+		t.Helper()
+		w := env.do(http.MethodGet, "/devices"+query, nil, token)
+		expectStatus(t, w, http.StatusOK)
+		var devices []models.Device
+		decode(t, w, &devices)
+		ids := make([]uint, len(devices))
+		for i, d := range devices {
+			ids[i] = d.ID
+		}
+		return ids
+	}
+	if ids := list("", token); fmt.Sprint(ids) != fmt.Sprint([]uint{alive.ID, stale.ID, never.ID}) {
+		t.Errorf("todos = %v", ids)
+	}
+	if ids := list("?status=online", token); fmt.Sprint(ids) != fmt.Sprint([]uint{alive.ID}) {
+		t.Errorf("online = %v", ids)
+	}
+	if ids := list("?status=offline", token); fmt.Sprint(ids) != fmt.Sprint([]uint{stale.ID, never.ID}) {
+		t.Errorf("offline = %v", ids)
+	}
+
+	_, adminToken := env.seedUser("root", models.RoleAdmin)
+	if ids := list("?status=online", adminToken); fmt.Sprint(ids) != fmt.Sprint([]uint{alive.ID, other.ID}) {
+		t.Errorf("online (admin) = %v", ids)
+	}
+	w := env.do(http.MethodGet, "/devices?status=online&per_page=1", nil, adminToken)
+	if total := w.Header().Get("X-Total-Count"); total != "2" {
+		t.Errorf("X-Total-Count = %q", total)
+	}
+	expectStatus(t, env.do(http.MethodGet, "/devices?status=sleeping", nil, token), http.StatusBadRequest)
+}
+
+func TestPresenceWebSocketScopedToTenant(t *testing.T) {
+	env := newTestEnv(t)
+	_, platform := env.seedUser("plataforma", models.RoleAdmin)
+	ana, anaToken := env.seedUser("ana", models.RoleUser)
+	env.createTenant(platform, "acme")
+	_, acmeAdmin := env.seedTenantUser("acme", "chefe", models.RoleAdmin)
+	bia, biaToken := env.seedTenantUser("acme", "bia", "")
+	local := env.createDevice(ana.ID, anaToken)
+	remote := env.createDevice(bia.ID, biaToken)
+
+	all := env.dialPresence(platform)
+	scoped := env.dialPresence(acmeAdmin)
+
+	// O dispositivo do tenant padrão não chega ao admin do acme: o primeiro
+	// evento que ele vê já é o do próprio tenant
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/devices/%d/heartbeat", local.ID), nil, anaToken), http.StatusOK)
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/devices/%d/heartbeat", remote.ID), nil, biaToken), http.StatusOK)
+	if e := readPresence(t, scoped); e.DeviceID != remote.ID || e.TenantID != bia.TenantID {
+		t.Errorf("admin do acme: evento = %+v", e)
+	}
+	// O admin da plataforma vê os dois
+	for _, want := range []uint{local.ID, remote.ID} {
+		if e := readPresence(t, all); e.DeviceID != want {
+			t.Errorf("plataforma: evento = %+v, quer o dispositivo %d", e, want)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pede a exclusão da conta e devolve o código do e-mail enviado
+func (e *testEnv) requestDeletion(email, token string) string {
+	e.t.Helper()
+	before := len(e.mail.messages())
+	expectStatus(e.t, e.do(http.MethodDelete, "/me", nil, token), http.StatusAccepted)
+	sent := e.mail.messages()
+	if len(sent) != before+1 {
+		e.t.Fatalf("e-mails enviados = %d, want %d", len(sent), before+1)
+	}
+	msg := sent[len(sent)-1]
+	if msg.To != email {
+		e.t.Fatalf("e-mail para %q, want %q", msg.To, email)
+	}
+	_, rest, _ := strings.Cut(msg.Body, "deletion of your account: ")
+	code, _, _ := strings.Cut(rest, "\n")
+	if code == "" {
+		e.t.Fatalf("código não encontrado no e-mail: %q", msg.Body)
+	}
+	return code
+}
+
+func TestExportMe(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, token)
+	w := env.do(http.MethodPost, fmt.Sprintf("/devices/%d/readings", device.ID), gin.H{"metric": "bpm", "value": 72}, token)
+	expectStatus(t, w, http.StatusCreated)
+	// Dados de outro usuário não entram
+	bia, biaToken := env.seedUser("bia", models.RoleUser)
+	env.createDevice(bia.ID, biaToken)
+
+	w = env.do(http.MethodGet, "/me/export", nil, token)
+	expectStatus(t, w, http.StatusOK)
+	if cd := w.Header().Get("Content-Disposition"); !strings.HasSuffix(cd, `.json"`) {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	var export struct {
+		ExportedAt time.Time         `json:"exported_at"`
+		Profile    models.User       `json:"profile"`
+		Devices    []models.Device   `json:"devices"`
+		Readings   []models.Reading  `json:"readings"`
+		Sessions   []json.RawMessage `json:"sessions"`
+		Audit      []json.RawMessage `json:"audit"`
+	}
+	decode(t, w, &export)
+	if export.Profile.ID != ana.ID || export.ExportedAt.IsZero() {
+		t.Errorf("perfil = %+v", export.Profile)
+	}
+	if len(export.Devices) != 1 || export.Devices[0].ID != device.ID {
+		t.Errorf("dispositivos = %+v", export.Devices)
+	}
+	if len(export.Readings) != 1 || len(export.Sessions) != 1 || len(export.Audit) == 0 {
+		t.Errorf("leituras = %d, sessões = %d, auditoria = %d", len(export.Readings), len(export.Sessions), len(export.Audit))
+	}
+	for _, s := range export.Sessions {
+		if bytes.Contains(s, []byte("hash")) {
+			t.Errorf("hash da sessão exportado: %s", s)
+		}
+	}
+
+	w = env.do(http.MethodGet, "/me/export?format=zip", nil, token)
+	expectStatus(t, w, http.StatusOK)
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	files := map[string][]byte{}
+	for _, f := range archive.File {
+		r, _ := f.Open()
+		files[f.Name], _ = io.ReadAll(r)
+		r.Close()
+	}
+	var devices []models.Device
+	if err := json.Unmarshal(files["devices.json"], &devices); err != nil || len(devices) != 1 {
+		t.Errorf("devices.json = %s (%v)", files["devices.json"], err)
+	}
+	if _, ok := files["profile.json"]; !ok {
+		t.Errorf("arquivos = %v", archive.File)
+	}
+
+	expectStatus(t, env.do(http.MethodGet, "/me/export?format=xml", nil, token), http.StatusBadRequest)
+}
+
+func TestDeleteMeConfirmed(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, token)
+	w := env.do(http.MethodPost, fmt.Sprintf("/devices/%d/readings", device.ID), gin.H{"metric": "bpm", "value": 72}, token)
+	expectStatus(t, w, http.StatusCreated)
+	_, biaToken := env.seedUser("bia", models.RoleUser)
+
+	// O código é do usuário que pediu
+	code := env.requestDeletion("ana@exemplo.com", token)
+	w = env.do(http.MethodDelete, "/me", gin.H{"token": code}, biaToken)
+	expectStatus(t, w, http.StatusBadRequest)
+	if body := decodeError(t, w); body.Code != "invalid_deletion_token" {
+		t.Errorf("code = %q", body.Code)
+	}
+	expectStatus(t, env.do(http.MethodDelete, "/me", gin.H{"token": code}, token), http.StatusOK)
+
+	expectStatus(t, env.do(http.MethodGet, "/me", nil, token), http.StatusNotFound)
+	w = env.do(http.MethodPost, "/login", gin.H{"user": "ana", "password": "senha-ana"}, "")
+	expectStatus(t, w, http.StatusUnauthorized)
+
+	// Os dados pessoais somem; o registro fica, anônimo
+	var user models.User
+	env.db.Unscoped().First(&user, ana.ID)
+	if user.Email == "ana@exemplo.com" || user.User == "ana" || !user.DeletedAt.Valid {
+		t.Errorf("usuário = %+v", user)
+	}
+	var devices, readings, sessions int64
+	env.db.Model(&models.Device{}).Where("user_id = ?", ana.ID).Count(&devices)
+	env.db.Model(&models.Reading{}).Where("device_id = ?", device.ID).Count(&readings)
+	env.db.Model(&models.Session{}).Where("user_id = ?", ana.ID).Count(&sessions)
+	if devices+readings+sessions != 0 {
+		t.Errorf("restantes: %d dispositivos, %d leituras, %d sessões", devices, readings, sessions)
+	}
+	var audit []models.AuditLog
+	env.db.Where("entity = ? AND entity_id = ?", "user", ana.ID).Find(&audit)
+	for _, entry := range audit {
+		if entry.Before != nil || entry.After != nil {
+			t.Errorf("auditoria com dados: %+v", entry)
+		}
+	}
+	var withIP int64
+	env.db.Model(&models.AuditLog{}).Where("actor_id = ? AND client_ip <> ''", ana.ID).Count(&withIP)
+	if withIP != 0 {
+		t.Errorf("%d registros da auditoria ainda com o IP da conta", withIP)
+	}
+
+	// Os dados já foram apagados: o admin não consegue restaurar a conta
+	_, adminToken := env.seedUser("root", models.RoleAdmin)
+	w = env.do(http.MethodPost, fmt.Sprintf("/users/%d/restore", ana.ID), nil, adminToken)
+	expectStatus(t, w, http.StatusConflict)
+	if body := decodeError(t, w); body.Code != "user_erased" {
+		t.Errorf("code = %q", body.Code)
+	}
+
+	// O username e o e-mail ficam livres
+	w = env.do(http.MethodPost, "/users", gin.H{"name": "Ana", "email": "ana@exemplo.com", "user": "ana", "password": "senha-ana"}, "")
+	expectStatus(t, w, http.StatusCreated)
+}
+
+func TestDeleteMeTokenSingleUse(t *testing.T) {
+	env := newTestEnv(t)
+	_, token := env.seedUser("ana", models.RoleUser)
+
+	old := env.requestDeletion("ana@exemplo.com", token)
+	env.requestDeletion("ana@exemplo.com", token)
+	// Um pedido novo invalida o anterior
+	expectStatus(t, env.do(http.MethodDelete, "/me", gin.H{"token": old}, token), http.StatusBadRequest)
+
+	code := env.requestDeletion("ana@exemplo.com", token)
+	env.db.Model(&models.AccountDeletionToken{}).Where("used_at IS NULL").Update("expires_at", time.Now().Add(-time.Minute))
+	expectStatus(t, env.do(http.MethodDelete, "/me", gin.H{"token": code}, token), http.StatusBadRequest)
+	expectStatus(t, env.do(http.MethodDelete, "/me", gin.H{"token": ""}, token), http.StatusBadRequest)
+	expectStatus(t, env.do(http.MethodGet, "/me", nil, token), http.StatusOK)
+}
+
+func TestDeleteMeLeavesGroups(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	bia, biaToken := env.seedUser("bia", models.RoleUser)
+	caio, _ := env.seedUser("caio", models.RoleUser)
+	lab := env.createGroup(token, "Laboratório")
+	for _, id := range []uint{bia.ID, caio.ID} {
+		expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/groups/%d/members", lab.ID), gin.H{"user_id": id}, token), http.StatusCreated)
+	}
+	solo := env.createGroup(token, "Só a Ana")
+	club := env.createGroup(biaToken, "Clube")
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/groups/%d/members", club.ID), gin.H{"user_id": ana.ID}, biaToken), http.StatusCreated)
+
+	// As participações entram na exportação
+	w := env.do(http.MethodGet, "/me/export", nil, token)
+	expectStatus(t, w, http.StatusOK)
+	var export struct {
+		Memberships []models.Membership `json:"memberships"`
+	}
+	decode(t, w, &export)
+	if len(export.Memberships) != 3 || export.Memberships[0].Group == nil || export.Memberships[0].Group.Name != "Laboratório" {
+		t.Errorf("participações exportadas = %+v", export.Memberships)
+	}
+
+	code := env.requestDeletion("ana@exemplo.com", token)
+	expectStatus(t, env.do(http.MethodDelete, "/me", gin.H{"token": code}, token), http.StatusOK)
+
+	var left int64
+	env.db.Model(&models.Membership{}).Where("user_id = ?", ana.ID).Count(&left)
+	if left != 0 {
+		t.Errorf("%d participações da ana restantes", left)
+	}
+	// O laboratório passa para o membro mais antigo; o grupo vazio some
+	var owners []models.Membership
+	env.db.Where("group_id = ? AND role = ?", lab.ID, models.GroupOwner).Find(&owners)
+	if len(owners) != 1 || owners[0].UserID != bia.ID {
+		t.Errorf("owners do laboratório = %+v", owners)
+	}
+	var groups int64
+	env.db.Model(&models.Group{}).Where("id = ?", solo.ID).Count(&groups)
+	if groups != 0 {
+		t.Error("grupo sem membros continua existindo")
+	}
+	var members int64
+	env.db.Model(&models.Membership{}).Where("group_id = ?", club.ID).Count(&members)
+	if members != 1 {
+		t.Errorf("membros do clube = %d, quer 1", members)
+	}
+}
+
+func TestDeleteMeErasesSyncConflictsAndWebhookPayloads(t *testing.T) {
+	env := newTestEnv(t)
+	receiver := newWebhookReceiver(t)
+	_, adminToken := env.seedUser("root", models.RoleAdmin)
+	env.createWebhook(adminToken, gin.H{
+		"url":    receiver.URL,
+		"events": []string{models.WebhookUserCreated, models.WebhookUserUpdated, models.WebhookUserDeleted},
+	})
+	ana, token := env.seedUser("ana", models.RoleUser)
+
+	// Revisão velha: o conflito fica guardado com os dados dos dois lados
+	change := gin.H{"id": ana.ID, "op": "update", "base_revision": ana.Revision + 5, "modified_at": time.Now().UTC(), "data": gin.H{"name": "Ana Offline"}}
+	expectStatus(t, env.do(http.MethodPost, "/sync/push", gin.H{"client_id": "celular", "changes": []gin.H{change}}, token), http.StatusOK)
+
+	w := env.do(http.MethodGet, "/me/export", nil, token)
+	expectStatus(t, w, http.StatusOK)
+	var export struct {
+		SyncConflicts []models.SyncConflict `json:"sync_conflicts"`
+	}
+	decode(t, w, &export)
+	if len(export.SyncConflicts) != 1 || export.SyncConflicts[0].ClientID != "celular" {
+		t.Errorf("conflitos exportados = %+v", export.SyncConflicts)
+	}
+
+	code := env.requestDeletion("ana@exemplo.com", token)
+	expectStatus(t, env.do(http.MethodDelete, "/me", gin.H{"token": code}, token), http.StatusOK)
+
+	var conflicts int64
+	env.db.Model(&models.SyncConflict{}).Where("user_id = ?", ana.ID).Count(&conflicts)
+	if conflicts != 0 {
+		t.Errorf("%d conflitos da ana restantes", conflicts)
+	}
+	// As entregas continuam (inclusive o user.deleted), mas sem os dados
+	var deliveries []models.WebhookDelivery
+	env.db.Where("user_id = ?", ana.ID).Find(&deliveries)
+	if len(deliveries) < 2 {
+		t.Fatalf("entregas da ana = %d", len(deliveries))
+	}
+	for _, d := range deliveries {
+		var payload models.WebhookPayload
+		if err := json.Unmarshal(d.Payload, &payload); err != nil || payload.UserID != ana.ID || payload.Event != d.Event || len(payload.Changes) != 0 {
+			t.Errorf("payload de %s = %s", d.Event, d.Payload)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Como o JSON aparece com PUBLIC_IDS=uuid
+type publicResource struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	UUID   string `json:"uuid"`
+	Token  string `json:"token"`
+}
+
+func TestPublicIDsIntMode(t *testing.T) {
+	env := newTestEnv(t)
+	user, token := env.seedUser("uuid_ana", "")
+	if !models.ValidUUID(user.UUID) || user.ID == 0 {
+		t.Fatalf("usuário = %+v, quer id numérico e uuid", user)
+	}
+	device := env.createDevice(user.ID, token)
+	if !models.ValidUUID(device.UUID) || device.UserUUID != user.UUID {
+		t.Fatalf("dispositivo = %+v, quer uuid e user_uuid = %s", device, user.UUID)
+	}
+
+	// O caminho aceita os dois formatos (período de migração)
+	var got models.User
+	w := env.do(http.MethodGet, "/users/"+user.UUID, nil, token)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &got)
+	if got.ID != user.ID {
+		t.Errorf("GET /users/<uuid> id = %d, quer %d", got.ID, user.ID)
+	}
+	expectStatus(t, env.do(http.MethodGet, "/devices/"+device.UUID, nil, token), http.StatusOK)
+	expectStatus(t, env.do(http.MethodGet, "/api/v1/users/"+user.UUID+"/devices", nil, token), http.StatusOK)
+	expectStatus(t, env.do(http.MethodGet, "/users/"+models.NewUUID(), nil, token), http.StatusNotFound)
+}
+
+func TestPublicIDsUUIDMode(t *testing.T) {
+	cfg := testCfg
+	cfg.PublicIDs = "uuid"
+	env := newTestEnvWithConfig(t, cfg)
+	t.Cleanup(func() { models.PublicUUIDs = false })
+
+	signup := func(username string) (publicResource, string) {
+		w := env.do(http.MethodPost, "/users", gin.H{
+			"name":     "Usuário " + username,
+			"email":    username + "@exemplo.com",
+			"user":     username,
+			"password": "senha-" + username,
+		}, "")
+		expectStatus(t, w, http.StatusCreated)
+		var user publicResource
+		decode(t, w, &user)
+		if !models.ValidUUID(user.ID) || user.UUID != "" {
+			t.Fatalf("usuário = %+v, quer o UUID no id", user)
+		}
+		return user, env.login(username, "senha-"+username)
+	}
+	ana, anaToken := signup("uuid_bia")
+	caio, caioToken := signup("uuid_caio")
+
+	w := env.do(http.MethodGet, "/me", nil, anaToken)
+	expectStatus(t, w, http.StatusOK)
+	var me publicResource
+	decode(t, w, &me)
+	if me.ID != ana.ID {
+		t.Errorf("GET /me id = %q, quer %q", me.ID, ana.ID)
+	}
+
+	// O ID numérico não serve mais no caminho
+	var numeric models.User
+	env.db.Where("uuid = ?", ana.ID).First(&numeric)
+	w = env.do(http.MethodGet, fmt.Sprintf("/users/%d", numeric.ID), nil, anaToken)
+	expectStatus(t, w, http.StatusBadRequest)
+	if e := decodeError(t, w); e.Code != "invalid_id" || e.Details["id"] != "must be a UUID" {
+		t.Errorf("erro = %+v", e)
+	}
+
+	w = env.do(http.MethodPost, "/users/"+ana.ID+"/devices", gin.H{"name": "Relógio", "type": "wearable"}, anaToken)
+	expectStatus(t, w, http.StatusCreated)
+	var device publicResource
+	decode(t, w, &device)
+	if !models.ValidUUID(device.ID) || device.UserID != ana.ID || device.Token == "" {
+		t.Fatalf("dispositivo = %+v", device)
+	}
+	expectStatus(t, env.do(http.MethodGet, "/devices/"+device.ID, nil, anaToken), http.StatusOK)
+	expectStatus(t, env.do(http.MethodGet, "/devices/"+device.ID, nil, caioToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodGet, "/users/"+caio.ID, nil, anaToken), http.StatusForbidden)
+
+	var devices []publicResource
+	w = env.do(http.MethodGet, "/users/"+ana.ID+"/devices", nil, anaToken)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &devices)
+	if len(devices) != 1 || devices[0].ID != device.ID {
+		t.Errorf("dispositivos = %+v", devices)
+	}
+
+	// No corpo também: o membro entra pelo UUID, e um número é recusado
+	group := env.createGroup(anaToken, "Laboratório")
+	groupPath := fmt.Sprintf("/groups/%d/members", group.ID)
+	expectStatus(t, env.do(http.MethodPost, groupPath, gin.H{"user_id": numeric.ID}, anaToken), http.StatusBadRequest)
+	w = env.do(http.MethodPost, groupPath, gin.H{"user_id": caio.ID}, anaToken)
+	expectStatus(t, w, http.StatusCreated)
+	var member publicResource
+	decode(t, w, &member)
+	if member.UserID != caio.ID {
+		t.Errorf("membro user_id = %q, quer %q", member.UserID, caio.ID)
+	}
+	expectStatus(t, env.do(http.MethodDelete, groupPath+"/"+caio.ID, nil, caioToken), http.StatusOK)
+}
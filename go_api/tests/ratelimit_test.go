@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"go_api/models"
+)
+
+func TestRateLimit(t *testing.T) {
+	cfg := testCfg
+	cfg.RateLimit = 3
+	cfg.RateLimitWindow = time.Hour
+	env := newTestEnvWithConfig(t, cfg)
+
+	for i := 3; i > 0; i-- {
+		w := env.do(http.MethodGet, "/time", nil, "")
+		expectStatus(t, w, http.StatusOK)
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != strconv.Itoa(i-1) {
+			t.Fatalf("X-RateLimit-Remaining = %s, want %d", got, i-1)
+		}
+	}
+	w := env.do(http.MethodGet, "/time", nil, "")
+	expectStatus(t, w, http.StatusTooManyRequests)
+	if retry, _ := strconv.Atoi(w.Header().Get("Retry-After")); retry <= 0 {
+		t.Fatalf("Retry-After = %q", w.Header().Get("Retry-After"))
+	}
+
+	// Health checks não contam; token inventado continua contando pelo IP
+	expectStatus(t, env.do(http.MethodGet, "/healthz", nil, ""), http.StatusOK)
+	expectStatus(t, env.do(http.MethodGet, "/time", nil, "inventado"), http.StatusTooManyRequests)
+}
+
+func TestRateLimitPerUser(t *testing.T) {
+	cfg := testCfg
+	cfg.RateLimit = 4
+	cfg.RateLimitWindow = time.Hour
+	env := newTestEnvWithConfig(t, cfg)
+
+	// Cadastro + login gastam 2 fichas do IP
+	ana, token := env.seedUser("ana", models.RoleUser)
+	path := "/users/" + strconv.Itoa(int(ana.ID))
+
+	// O usuário autenticado tem o próprio balde
+	for i := 0; i < 4; i++ {
+		expectStatus(t, env.do(http.MethodGet, path, nil, token), http.StatusOK)
+	}
+	expectStatus(t, env.do(http.MethodGet, path, nil, token), http.StatusTooManyRequests)
+	expectStatus(t, env.do(http.MethodGet, "/time", nil, ""), http.StatusOK)
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadingsBuffer(t *testing.T) {
+	cfg := testCfg
+	cfg.ReadingsBufferInterval = time.Hour // só grava pelo tamanho ou no encerramento
+	cfg.ReadingsBufferSize = 3
+	env := newTestEnvWithConfig(t, cfg)
+	t.Cleanup(func() { env.handler.Telemetry.StopBuffer(context.Background()) })
+	user, token := env.seedUser("buffer_ana", "")
+	device := env.createDevice(user.ID, token)
+	path := fmt.Sprintf("/devices/%d/readings", device.ID)
+
+	stored := func() int64 {
+		var count int64
+		env.db.Model(&models.Reading{}).Where("device_id = ?", device.ID).Count(&count)
+		return count
+	}
+	post := func(n, status int) {
+		t.Helper()
+		body := make([]gin.H, n)
+		for i := range body {
+			body[i] = gin.H{"metric": "temperature", "value": 20 + i}
+		}
+		expectStatus(t, env.do(http.MethodPost, path, body, token), status)
+	}
+
+	w := env.do(http.MethodPost, path, []gin.H{{"metric": "temperature", "value": 21}, {"metric": "humidity", "value": 60}}, token)
+	expectStatus(t, w, http.StatusAccepted)
+	var got struct{ Accepted int }
+	decode(t, w, &got)
+	if got.Accepted != 2 || stored() != 0 {
+		t.Fatalf("accepted = %d, gravadas = %d; quer 2 e 0 (ainda no buffer)", got.Accepted, stored())
+	}
+
+	// A terceira linha completa o READINGS_BUFFER_SIZE: grava todas juntas
+	post(1, http.StatusAccepted)
+	deadline := time.Now().Add(2 * time.Second)
+	for stored() != 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := stored(); n != 3 {
+		t.Fatalf("gravadas = %d depois de encher o buffer, quer 3", n)
+	}
+
+	// Acima de 10x o tamanho, o buffer recusa em vez de crescer sem limite
+	post(31, http.StatusServiceUnavailable)
+
+	// O encerramento grava o que sobrou; depois, volta a gravar direto
+	post(1, http.StatusAccepted)
+	env.handler.Telemetry.StopBuffer(context.Background())
+	if n := stored(); n != 4 {
+		t.Errorf("gravadas = %d depois do StopBuffer, quer 4", n)
+	}
+	post(1, http.StatusCreated)
+	if n := stored(); n != 5 {
+		t.Errorf("gravadas = %d sem o buffer, quer 5", n)
+	}
+}
@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type sseEvent struct {
+	ID, Event, Data string
+}
+
+// Abre o stream num servidor de verdade (o ResponseRecorder não entrega nada
+// antes do handler terminar)
+func (e *testEnv) openReadingStream(device models.Device, query string, headers map[string]string) (*bufio.Reader, *http.Response) {
+	e.t.Helper()
+	srv := httptest.NewServer(e.router)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Cancela antes do Close: o handler só termina quando a conexão cai
+	e.t.Cleanup(srv.Close)
+	e.t.Cleanup(cancel)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/devices/%d/readings/stream?%s", srv.URL, device.ID, query), nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		e.t.Fatalf("stream: %v", err)
+	}
+	e.t.Cleanup(func() { resp.Body.Close() })
+	return bufio.NewReader(resp.Body), resp
+}
+
+// Próximo evento, pulando os comentários (keep-alive)
+func readSSE(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("lendo stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && ev.Data != "":
+			return ev
+		case strings.HasPrefix(line, "id: "):
+			ev.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			ev.Data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func (e *testEnv) postReadings(device models.Device, token string, body interface{}) {
+	e.t.Helper()
+	w := e.do(http.MethodPost, fmt.Sprintf("/devices/%d/readings", device.ID), body, token)
+	expectStatus(e.t, w, http.StatusCreated)
+}
+
+func TestReadingStream(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, token)
+	env.postReadings(device, token, gin.H{"metric": "temp", "value": 20})
+
+	// O token vai na query, como no EventSource do navegador
+	stream, resp := env.openReadingStream(device, "access_token="+token, nil)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status %d, content-type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// Só as leituras gravadas depois da conexão
+	env.postReadings(device, token, []gin.H{{"metric": "temp", "value": 21}, {"metric": "hr", "value": 80}})
+	var readings []models.Reading
+	for i := 0; i < 2; i++ {
+		ev := readSSE(t, stream)
+		var r models.Reading
+		if err := json.Unmarshal([]byte(ev.Data), &r); err != nil || ev.Event != "reading" || ev.ID != fmt.Sprint(r.ID) {
+			t.Fatalf("evento = %+v (%v)", ev, err)
+		}
+		readings = append(readings, r)
+	}
+	if readings[0].Value != 21 || readings[1].Metric != "hr" {
+		t.Errorf("leituras = %+v", readings)
+	}
+
+	// Reconexão: Last-Event-ID retoma de onde parou, com o filtro de métrica
+	env.postReadings(device, token, []gin.H{{"metric": "hr", "value": 81}, {"metric": "temp", "value": 22}})
+	resumed, _ := env.openReadingStream(device, "metric=temp&access_token="+token,
+		map[string]string{"Last-Event-ID": fmt.Sprint(readings[0].ID)})
+	ev := readSSE(t, resumed)
+	var r models.Reading
+	json.Unmarshal([]byte(ev.Data), &r)
+	if r.Metric != "temp" || r.Value != 22 {
+		t.Errorf("retomada = %+v", r)
+	}
+}
+
+func TestReadingStreamAccess(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, token)
+	_, biaToken := env.seedUser("bia", models.RoleUser)
+
+	path := fmt.Sprintf("/devices/%d/readings/stream", device.ID)
+	expectStatus(t, env.do(http.MethodGet, path, nil, ""), http.StatusUnauthorized)
+	expectStatus(t, env.do(http.MethodGet, path, nil, biaToken), http.StatusForbidden)
+	w := env.doWithHeaders(http.MethodGet, path, nil, token, map[string]string{"Last-Event-ID": "abc"})
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+// Falha do banco é 500, não uma lista vazia
+func TestReadingsDatabaseError(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, token)
+
+	fail := func(db *gorm.DB) {
+		if db.Statement.Table == "readings" {
+			db.AddError(errors.New("conexão perdida"))
+		}
+	}
+	env.db.Callback().Query().Before("gorm:query").Register("test:readings", fail)
+	env.db.Callback().Row().Before("gorm:row").Register("test:readings", fail)
+	t.Cleanup(func() {
+		env.db.Callback().Query().Remove("test:readings")
+		env.db.Callback().Row().Remove("test:readings")
+	})
+	for _, path := range []string{"readings", "readings?after_id=0", "readings/stream"} {
+		w := env.do(http.MethodGet, fmt.Sprintf("/devices/%d/%s", device.ID, path), nil, token)
+		expectStatus(t, w, http.StatusInternalServerError)
+	}
+}
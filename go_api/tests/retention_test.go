@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go_api/models"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const day = 24 * time.Hour
+
+// GET /devices/:id/<path> de uma série agregada (readings?resolution= ou readings/stats)
+func (e *testEnv) series(device models.Device, token, path string) []models.ReadingAggregate {
+	e.t.Helper()
+	w := e.do(http.MethodGet, fmt.Sprintf("/devices/%d/%s", device.ID, path), nil, token)
+	expectStatus(e.t, w, http.StatusOK)
+	var series []models.ReadingAggregate
+	decode(e.t, w, &series)
+	return series
+}
+
+func TestReadingRetention(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, token)
+
+	now := time.Now().UTC()
+	old := now.Add(-10 * day).Truncate(time.Hour)         // vira agregado horário
+	older := now.Add(-100 * day).Truncate(day)            // vira agregado diário
+	expired := now.Add(-400 * day).Truncate(day)          // passa da janela diária
+	recent := now.Add(-2 * time.Hour).Truncate(time.Hour) // continua bruta
+	env.postReadings(device, token, []gin.H{
+		{"metric": "temp", "value": 1, "timestamp": old.Add(time.Minute)},
+		{"metric": "temp", "value": 2, "timestamp": old.Add(2 * time.Minute)},
+		{"metric": "temp", "value": 3, "timestamp": old.Add(3 * time.Minute)},
+		{"metric": "temp", "value": 10, "timestamp": older.Add(time.Hour)},
+		{"metric": "temp", "value": 20, "timestamp": older.Add(5 * time.Hour)},
+		{"metric": "temp", "value": 99, "timestamp": expired},
+		{"metric": "temp", "value": 5, "timestamp": recent.Add(time.Minute)},
+	})
+
+	retention := service.NewReadingRetention(env.db, service.RetentionPolicy{Raw: 7 * day, Hourly: 90 * day, Daily: 365 * day})
+	if err := retention.Run(context.Background()); err != nil {
+		t.Fatalf("retenção: %v", err)
+	}
+
+	var raw int64
+	env.db.Model(&models.Reading{}).Where("device_id = ?", device.ID).Count(&raw)
+	if raw != 1 {
+		t.Errorf("leituras brutas = %d, want 1", raw)
+	}
+
+	// Por hora: a hora ainda bruta e a já agregada, da mais recente para a mais antiga
+	hourly := env.series(device, token, "readings?resolution=hourly")
+	if len(hourly) != 2 || !hourly[0].Timestamp.Equal(recent) || !hourly[1].Timestamp.Equal(old) {
+		t.Fatalf("horária = %+v", hourly)
+	}
+	if h := hourly[1]; h.Count != 3 || h.Avg != 2 || h.Min != 1 || h.Max != 3 || h.Metric != "temp" {
+		t.Errorf("hora agregada = %+v", h)
+	}
+
+	// Por dia: também os dias que só existem como agregado diário
+	daily := env.series(device, token, "readings?resolution=daily")
+	if len(daily) != 3 || !daily[2].Timestamp.Equal(older) || daily[2].Avg != 15 || daily[2].Count != 2 {
+		t.Fatalf("diária = %+v", daily)
+	}
+	daily = env.series(device, token, "readings?resolution=daily&limit=1")
+	if len(daily) != 1 || !daily[0].Timestamp.Equal(recent.Truncate(day)) {
+		t.Errorf("diária com limit = %+v", daily)
+	}
+
+	// Leitura atrasada numa hora já agregada: soma com o agregado
+	env.postReadings(device, token, gin.H{"metric": "temp", "value": 6, "timestamp": old.Add(30 * time.Minute)})
+	if err := retention.Run(context.Background()); err != nil {
+		t.Fatalf("retenção: %v", err)
+	}
+	hourly = env.series(device, token, fmt.Sprintf("readings?resolution=hourly&to=%s", old.Add(time.Hour).Format(time.RFC3339)))
+	if len(hourly) != 1 || hourly[0].Count != 4 || hourly[0].Avg != 3 || hourly[0].Max != 6 {
+		t.Errorf("hora com leitura atrasada = %+v", hourly)
+	}
+
+	expectStatus(t, env.do(http.MethodGet, fmt.Sprintf("/devices/%d/readings?resolution=weekly", device.ID), nil, token), http.StatusBadRequest)
+}
+
+func TestReadingStats(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, token)
+
+	base := time.Now().UTC().Add(-3 * time.Hour).Truncate(time.Hour)
+	env.postReadings(device, token, []gin.H{
+		{"metric": "temp", "value": 1, "timestamp": base},
+		{"metric": "temp", "value": 2, "timestamp": base.Add(5 * time.Minute)},
+		{"metric": "temp", "value": 3, "timestamp": base.Add(10 * time.Minute)},
+		{"metric": "temp", "value": 8, "timestamp": base.Add(20 * time.Minute)},
+		{"metric": "hr", "value": 70, "timestamp": base.Add(time.Minute)},
+	})
+
+	// Sem o consentimento analytics do dono, nada de estatísticas
+	expectStatus(t, env.do(http.MethodGet, fmt.Sprintf("/devices/%d/readings/stats", device.ID), nil, token), http.StatusForbidden)
+	env.grantConsent(ana.ID, token, models.PurposeAnalytics)
+
+	stats := env.series(device, token, "readings/stats?metric=temp&bucket=15m")
+	if len(stats) != 2 || !stats[0].Timestamp.Equal(base.Add(15*time.Minute)) || stats[0].Count != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if s := stats[1]; s.Count != 3 || s.Avg != 2 || s.Min != 1 || s.Max != 3 {
+		t.Errorf("primeiro intervalo = %+v", s)
+	}
+	// Sem metric: um ponto por métrica em cada intervalo (padrão: 1h)
+	if stats := env.series(device, token, "readings/stats?bucket=1h"); len(stats) != 2 || stats[0].Metric != "hr" || stats[1].Count != 4 {
+		t.Errorf("por métrica = %+v", stats)
+	}
+
+	// Depois da agregação, só intervalos de horas inteiras enxergam o período
+	retention := service.NewReadingRetention(env.db, service.RetentionPolicy{Raw: time.Hour})
+	if err := retention.Run(context.Background()); err != nil {
+		t.Fatalf("retenção: %v", err)
+	}
+	if stats := env.series(device, token, "readings/stats?metric=temp&bucket=2h"); len(stats) != 1 || stats[0].Count != 4 || stats[0].Max != 8 {
+		t.Errorf("com agregados = %+v", stats)
+	}
+	if stats := env.series(device, token, "readings/stats?metric=temp&bucket=15m"); len(stats) != 0 {
+		t.Errorf("intervalo menor que a hora agregada = %+v", stats)
+	}
+
+	path := fmt.Sprintf("/devices/%d/readings/stats?bucket=", device.ID)
+	for _, bucket := range []string{"10s", "abc", "9000h"} {
+		expectStatus(t, env.do(http.MethodGet, path+bucket, nil, token), http.StatusBadRequest)
+	}
+}
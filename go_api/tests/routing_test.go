@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_api/models"
+)
+
+func TestUnknownRouteAndMethod(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do(http.MethodGet, "/nao-existe", nil, "")
+	expectStatus(t, w, http.StatusNotFound)
+	if body := decodeError(t, w); body.Code != "route_not_found" || body.RequestID == "" {
+		t.Errorf("erro = %+v", body)
+	}
+
+	w = env.do(http.MethodDelete, "/time", nil, "")
+	expectStatus(t, w, http.StatusMethodNotAllowed)
+	if allow := w.Header().Get("Allow"); allow != "GET, HEAD, OPTIONS" {
+		t.Errorf("Allow = %q", allow)
+	}
+	if body := decodeError(t, w); body.Code != "method_not_allowed" || body.Details["allow"] != "GET, HEAD, OPTIONS" {
+		t.Errorf("erro = %+v", body)
+	}
+
+	// OPTIONS fora do preflight do CORS: só o Allow
+	w = env.do(http.MethodOptions, "/api/v1/users", nil, "")
+	expectStatus(t, w, http.StatusNoContent)
+	allow := w.Header().Get("Allow")
+	for _, method := range []string{"GET", "HEAD", "POST", "OPTIONS"} {
+		if !strings.Contains(allow, method) {
+			t.Errorf("Allow = %q, sem %s", allow, method)
+		}
+	}
+	expectStatus(t, env.do(http.MethodOptions, "/nao-existe", nil, ""), http.StatusNotFound)
+}
+
+func TestHeadMirrorsGet(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	device := env.createDevice(ana.ID, token)
+	// Servidor de verdade: é o net/http que descarta o corpo do HEAD
+	srv := httptest.NewServer(env.router)
+	t.Cleanup(srv.Close)
+
+	request := func(method, path string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	path := fmt.Sprintf("/api/v1/users/%d", ana.ID)
+	get, head := request(http.MethodGet, path), request(http.MethodHead, path)
+	if head.StatusCode != http.StatusOK {
+		t.Fatalf("HEAD %s = %d", path, head.StatusCode)
+	}
+	if etag := head.Header.Get("ETag"); etag == "" || etag != get.Header.Get("ETag") {
+		t.Errorf("ETag do HEAD = %q, do GET = %q", etag, get.Header.Get("ETag"))
+	}
+	if head.ContentLength != get.ContentLength {
+		t.Errorf("Content-Length do HEAD = %d, do GET = %d", head.ContentLength, get.ContentLength)
+	}
+	if body, _ := io.ReadAll(head.Body); len(body) != 0 {
+		t.Errorf("HEAD com corpo: %s", body)
+	}
+	if head.Header.Get("Content-Type") != get.Header.Get("Content-Type") {
+		t.Errorf("Content-Type = %q, want %q", head.Header.Get("Content-Type"), get.Header.Get("Content-Type"))
+	}
+
+	// Também nos caminhos antigos e fora da versão
+	for _, path := range []string{"/users/me", "/healthz", "/time"} {
+		if resp := request(http.MethodHead, path); resp.StatusCode != http.StatusOK {
+			t.Errorf("HEAD %s = %d", path, resp.StatusCode)
+		}
+	}
+	// O stream não termina: sem HEAD
+	resp := request(http.MethodHead, fmt.Sprintf("/api/v1/devices/%d/readings/stream", device.ID))
+	if resp.StatusCode != http.StatusMethodNotAllowed || resp.Header.Get("Allow") != "GET, OPTIONS" {
+		t.Errorf("HEAD do stream = %d (Allow %q)", resp.StatusCode, resp.Header.Get("Allow"))
+	}
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"go_api/handlers"
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type searchResponse struct {
+	Query   string                  `json:"query"`
+	Results []handlers.SearchResult `json:"results"`
+	HasMore bool                    `json:"has_more"`
+}
+
+func (e *testEnv) search(query, token string) searchResponse {
+	e.t.Helper()
+	w := e.do(http.MethodGet, "/users/search?"+query, nil, token)
+	expectStatus(e.t, w, http.StatusOK)
+	var body searchResponse
+	decode(e.t, w, &body)
+	return body
+}
+
+func TestSearchUsersRanking(t *testing.T) {
+	env := newTestEnv(t)
+	_, admin := env.seedUser("root", models.RoleAdmin)
+	for _, u := range []gin.H{
+		{"name": "Mariana Costa", "email": "mcosta@exemplo.com", "user": "mcosta"},
+		{"name": "Ana Souza", "email": "asouza@exemplo.com", "user": "asouza"},
+		{"name": "Bruno Lima", "email": "bruno@exemplo.com", "user": "ana"},
+		{"name": "Carla Dias", "email": "anacarla@exemplo.com", "user": "cdias"},
+		{"name": "Joana Prado", "email": "jprado@exemplo.com", "user": "anapaula"},
+	} {
+		u["password"] = "senha-forte"
+		expectStatus(t, env.do(http.MethodPost, "/users", u, ""), http.StatusCreated)
+	}
+
+	body := env.search("q=Ana", admin)
+	var order []string
+	for _, r := range body.Results {
+		order = append(order, r.User.User)
+	}
+	// exato, username com prefixo, nome com palavra, e-mail com prefixo, meio
+	want := []string{"ana", "anapaula", "asouza", "cdias", "mcosta"}
+	if len(order) != len(want) {
+		t.Fatalf("resultados = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("resultados = %v, want %v", order, want)
+		}
+	}
+	if got := body.Results[2].Highlights["name"]; got != "<mark>Ana</mark> Souza" {
+		t.Errorf("highlight = %q", got)
+	}
+	if got := body.Results[4].Highlights["name"]; got != "Mari<mark>ana</mark> Costa" {
+		t.Errorf("highlight = %q", got)
+	}
+	if _, ok := body.Results[4].Highlights["email"]; ok {
+		t.Errorf("e-mail sem o termo destacado: %v", body.Results[4].Highlights)
+	}
+
+	// Paginação
+	page := env.search("q=ana&limit=2", admin)
+	if len(page.Results) != 2 || !page.HasMore {
+		t.Fatalf("primeira página = %+v", page)
+	}
+	page = env.search("q=ana&limit=2&offset=4", admin)
+	if len(page.Results) != 1 || page.HasMore || page.Results[0].User.User != "mcosta" {
+		t.Fatalf("última página = %+v", page)
+	}
+}
+
+func TestSearchUsersEscapesInput(t *testing.T) {
+	env := newTestEnv(t)
+	_, admin := env.seedUser("root", models.RoleAdmin)
+	env.seedUser("ana_paula", models.RoleUser)
+	env.seedUser("anaxpaula", models.RoleUser)
+
+	// "_" é literal, não o curinga do LIKE
+	body := env.search("q="+url.QueryEscape("a_p"), admin)
+	if len(body.Results) != 1 || body.Results[0].User.User != "ana_paula" {
+		t.Fatalf("resultados = %+v", body.Results)
+	}
+	if len(env.search("q="+url.QueryEscape("%%"), admin).Results) != 0 {
+		t.Error("% casou com todos")
+	}
+
+	w := env.do(http.MethodPost, "/users", gin.H{
+		"name": "<b>Eva</b>", "email": "eva@exemplo.com", "user": "eva", "password": "senha-forte",
+	}, "")
+	expectStatus(t, w, http.StatusCreated)
+	body = env.search("q=eva", admin)
+	if got := body.Results[0].Highlights["name"]; got != "&lt;b&gt;<mark>Eva</mark>&lt;/b&gt;" {
+		t.Errorf("highlight = %q", got)
+	}
+}
+
+func TestSearchUsersRules(t *testing.T) {
+	env := newTestEnv(t)
+	_, admin := env.seedUser("root", models.RoleAdmin)
+	_, token := env.seedUser("ana", models.RoleUser)
+
+	expectStatus(t, env.do(http.MethodGet, "/users/search?q=ana", nil, token), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodGet, "/users/search?q=a", nil, admin), http.StatusBadRequest)
+	expectStatus(t, env.do(http.MethodGet, "/users/search?q=ana&limit=0", nil, admin), http.StatusBadRequest)
+	expectStatus(t, env.do(http.MethodGet, "/users/search?q=ana&offset=-1", nil, admin), http.StatusBadRequest)
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go_api/config"
+	"go_api/logging"
+)
+
+// Arquivo de segredo como o Docker monta em /run/secrets
+func writeSecret(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigSecretsFromFiles(t *testing.T) {
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("JWT_SECRET_FILE", writeSecret(t, "jwt", "segredo-do-jwt\n"))
+	t.Setenv("SMTP_PASSWORD_FILE", writeSecret(t, "smtp", "  com espaços  \r\n"))
+	t.Setenv("REDIS_URL", "redis://:senha-redis@redis:6379/0")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.JWTSecret != "segredo-do-jwt" {
+		t.Errorf("JWTSecret = %q", cfg.JWTSecret)
+	}
+	// Só a quebra de linha final sai
+	if cfg.SMTPPassword != "  com espaços  " {
+		t.Errorf("SMTPPassword = %q", cfg.SMTPPassword)
+	}
+	secrets := strings.Join(cfg.Secrets(), "|")
+	for _, want := range []string{"segredo-do-jwt", "senha-redis", "redis://:senha-redis@redis:6379/0"} {
+		if !strings.Contains(secrets, want) {
+			t.Errorf("Secrets() sem %q: %v", want, cfg.Secrets())
+		}
+	}
+}
+
+func TestConfigSecretFileErrors(t *testing.T) {
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("JWT_SECRET", "também-no-ambiente")
+	t.Setenv("JWT_SECRET_FILE", writeSecret(t, "jwt", "segredo"))
+	t.Setenv("MQTT_PASSWORD_FILE", filepath.Join(t.TempDir(), "nao-existe"))
+	t.Setenv("DB_PASSWORD_FILE", writeSecret(t, "db", "\n"))
+
+	_, err := config.Load()
+	if err == nil {
+		t.Fatal("configuração inválida foi aceita")
+	}
+	msg := err.Error()
+	for _, want := range []string{
+		"set JWT_SECRET or JWT_SECRET_FILE, not both",
+		"MQTT_PASSWORD_FILE: open",
+		"DB_PASSWORD_FILE: " + os.Getenv("DB_PASSWORD_FILE") + " is empty",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("erro sem %q:\n%s", want, msg)
+		}
+	}
+	if strings.Contains(msg, "segredo") {
+		t.Errorf("o erro cita o segredo:\n%s", msg)
+	}
+}
+
+func TestConfigRefusesChaosInRelease(t *testing.T) {
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("JWT_SECRET", "segredo-de-teste")
+	t.Setenv("CHAOS_MODE", "true")
+	t.Setenv("GIN_MODE", "release")
+	if _, err := config.Load(); err == nil || !strings.Contains(err.Error(), "CHAOS_MODE cannot be enabled with GIN_MODE=release") {
+		t.Fatalf("Load = %v", err)
+	}
+
+	t.Setenv("GIN_MODE", "debug")
+	if _, err := config.Load(); err != nil {
+		t.Fatalf("Load em debug: %v", err)
+	}
+}
+
+func TestLogRedactsSecrets(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(logging.Redact(slog.NewJSONHandler(&buf, nil), "senha-forte-123", "abc"))
+
+	logger.With("dsn", "host=db password=senha-forte-123").
+		Error("falha ao conectar com senha-forte-123",
+			"error", errors.New("auth failed for senha-forte-123"),
+			slog.Group("db", "url", "postgres://admin:senha-forte-123@db/app"),
+			"attempt", 3)
+
+	out := buf.String()
+	if strings.Contains(out, "senha-forte-123") {
+		t.Fatalf("segredo no log: %s", out)
+	}
+	if strings.Count(out, "[REDACTED]") != 4 {
+		t.Errorf("esperado 4 trocas: %s", out)
+	}
+	// Curto demais para esconder; números continuam números
+	if !strings.Contains(out, `"attempt":3`) {
+		t.Errorf("atributo alterado: %s", out)
+	}
+	if redacted := logging.Redact(slog.Default().Handler(), "abc"); redacted != slog.Default().Handler() {
+		t.Error("segredo curto não deveria envolver o handler")
+	}
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"testing"
+
+	"go_api/models"
+	"go_api/service"
+)
+
+func TestSeedDemo(t *testing.T) {
+	env := newTestEnv(t)
+	seeder := service.NewSeeder(env.db)
+	opts := service.SeedOptions{Users: 5, DevicesPerUser: 2, ReadingsPerDevice: 30, BatchSize: 7, Demo: true, Rand: rand.New(rand.NewSource(1))}
+
+	result, err := seeder.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	// 5 gerados + admin e demo, cada um com 2 dispositivos de 30 leituras
+	if result.Users != 7 || result.Devices != 14 || result.Readings != 420 {
+		t.Fatalf("resultado = %+v", result)
+	}
+
+	// As contas fixas entram com a senha conhecida, e o admin enxerga todos
+	adminToken := env.login("admin", service.SeedPassword)
+	w := env.do(http.MethodGet, "/users?per_page=1", nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	if total := w.Header().Get("X-Total-Count"); total != "7" {
+		t.Fatalf("X-Total-Count = %s", total)
+	}
+	demoToken := env.login("demo", service.SeedPassword)
+	var demo models.User
+	if err := env.db.Where(`"user" = ?`, "demo").First(&demo).Error; err != nil {
+		t.Fatalf("conta demo: %v", err)
+	}
+	w = env.do(http.MethodGet, fmt.Sprintf("/users/%d/devices", demo.ID), nil, demoToken)
+	expectStatus(t, w, http.StatusOK)
+	var devices []models.Device
+	decode(t, w, &devices)
+	if len(devices) != 2 {
+		t.Fatalf("dispositivos da demo = %+v", devices)
+	}
+	w = env.do(http.MethodGet, fmt.Sprintf("/devices/%d/readings", devices[0].ID), nil, demoToken)
+	expectStatus(t, w, http.StatusOK)
+	var readings []models.Reading
+	decode(t, w, &readings)
+	if len(readings) != 30 {
+		t.Fatalf("%d leituras", len(readings))
+	}
+
+	// Rodar de novo acrescenta usuários sem conflitar nem duplicar as contas fixas
+	result, err = seeder.Run(context.Background(), opts)
+	if err != nil || result.Users != 5 {
+		t.Fatalf("segunda rodada = %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go_api/handlers"
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Login com user agent próprio; devolve o par inteiro
+func (e *testEnv) loginFrom(username, password, userAgent string) handlers.TokenPair {
+	e.t.Helper()
+	w := e.doWithHeaders(http.MethodPost, "/login", gin.H{"user": username, "password": password}, "",
+		map[string]string{"User-Agent": userAgent})
+	expectStatus(e.t, w, http.StatusOK)
+	var tokens handlers.TokenPair
+	decode(e.t, w, &tokens)
+	return tokens
+}
+
+func (e *testEnv) sessions(token string) []models.Session {
+	e.t.Helper()
+	w := e.do(http.MethodGet, "/me/sessions", nil, token)
+	expectStatus(e.t, w, http.StatusOK)
+	var sessions []models.Session
+	decode(e.t, w, &sessions)
+	return sessions
+}
+
+func TestSessionsListAndRevoke(t *testing.T) {
+	env := newTestEnv(t)
+	env.seedUser("ana", models.RoleUser)
+	phone := env.loginFrom("ana", "senha-ana", "Celular/1.0")
+	laptop := env.loginFrom("ana", "senha-ana", "Notebook/2.0")
+
+	// A do seedUser e as duas de agora
+	sessions := env.sessions(laptop.AccessToken)
+	if len(sessions) != 3 {
+		t.Fatalf("sessões = %+v", sessions)
+	}
+	var phoneSession models.Session
+	for _, s := range sessions {
+		if s.UserAgent == "Notebook/2.0" && !s.Current {
+			t.Errorf("sessão do próprio token sem current: %+v", s)
+		}
+		if s.UserAgent == "Celular/1.0" {
+			phoneSession = s
+		}
+	}
+	if phoneSession.ID == 0 || phoneSession.Current || phoneSession.IP == "" {
+		t.Fatalf("sessão do celular = %+v", phoneSession)
+	}
+
+	// Revogada pelo notebook: o refresh do celular para de valer
+	path := fmt.Sprintf("/me/sessions/%d", phoneSession.ID)
+	expectStatus(t, env.do(http.MethodDelete, path, nil, laptop.AccessToken), http.StatusOK)
+	w := env.do(http.MethodPost, "/refresh", gin.H{"refresh_token": phone.RefreshToken}, "")
+	expectStatus(t, w, http.StatusUnauthorized)
+	expectStatus(t, env.do(http.MethodDelete, path, nil, laptop.AccessToken), http.StatusNotFound)
+	if sessions := env.sessions(laptop.AccessToken); len(sessions) != 2 {
+		t.Fatalf("sessões depois da revogação = %+v", sessions)
+	}
+
+	// Sessão de outro usuário não existe para quem pede
+	_, biaToken := env.seedUser("bia", models.RoleAdmin)
+	w = env.do(http.MethodDelete, fmt.Sprintf("/me/sessions/%d", sessions[0].ID), nil, biaToken)
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+func TestSessionsRefreshRotates(t *testing.T) {
+	env := newTestEnv(t)
+	env.seedUser("ana", models.RoleUser)
+	first := env.loginFrom("ana", "senha-ana", "Celular/1.0")
+
+	w := env.do(http.MethodPost, "/refresh", gin.H{"refresh_token": first.RefreshToken}, "")
+	expectStatus(t, w, http.StatusOK)
+	var second handlers.TokenPair
+	decode(t, w, &second)
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token não foi trocado")
+	}
+	// Mesma sessão, renovada: continua a do token novo e vem primeiro na lista
+	sessions := env.sessions(second.AccessToken)
+	if len(sessions) != 2 || !sessions[0].Current || sessions[0].UserAgent != "Celular/1.0" {
+		t.Fatalf("sessões = %+v", sessions)
+	}
+
+	// O token antigo reaparece: a sessão inteira cai, inclusive o token novo
+	w = env.do(http.MethodPost, "/refresh", gin.H{"refresh_token": first.RefreshToken}, "")
+	expectStatus(t, w, http.StatusUnauthorized)
+	w = env.do(http.MethodPost, "/refresh", gin.H{"refresh_token": second.RefreshToken}, "")
+	expectStatus(t, w, http.StatusUnauthorized)
+	for _, s := range env.sessions(second.AccessToken) {
+		if s.ID == sessions[0].ID {
+			t.Fatalf("sessão continua ativa depois do reuso: %+v", s)
+		}
+	}
+}
+
+func TestSessionsEndOnPasswordChange(t *testing.T) {
+	env := newTestEnv(t)
+	env.seedUser("ana", models.RoleUser)
+	phone := env.loginFrom("ana", "senha-ana", "Celular/1.0")
+	laptop := env.loginFrom("ana", "senha-ana", "Notebook/2.0")
+
+	w := env.do(http.MethodPut, "/me/password", gin.H{"current_password": "senha-ana", "new_password": "nova-senha"}, laptop.AccessToken)
+	expectStatus(t, w, http.StatusOK)
+	for _, tokens := range []handlers.TokenPair{phone, laptop} {
+		w = env.do(http.MethodPost, "/refresh", gin.H{"refresh_token": tokens.RefreshToken}, "")
+		expectStatus(t, w, http.StatusUnauthorized)
+	}
+
+	// O mesmo na redefinição por e-mail
+	again := env.loginFrom("ana", "nova-senha", "Celular/1.0")
+	token := env.forgotPassword("ana@exemplo.com")
+	w = env.do(http.MethodPost, "/password/reset", gin.H{"token": token, "new_password": "outra-senha"}, "")
+	expectStatus(t, w, http.StatusOK)
+	w = env.do(http.MethodPost, "/refresh", gin.H{"refresh_token": again.RefreshToken}, "")
+	expectStatus(t, w, http.StatusUnauthorized)
+}
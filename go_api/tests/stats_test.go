@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go_api/models"
+	"go_api/service"
+)
+
+type adminStats struct {
+	service.Stats
+	Requests struct {
+		Since    time.Time        `json:"since"`
+		Total    int64            `json:"total"`
+		ByStatus map[string]int64 `json:"by_status"`
+		ByRoute  map[string]int64 `json:"by_route"`
+	} `json:"requests"`
+}
+
+func TestAdminStats(t *testing.T) {
+	env := newTestEnv(t)
+	_, adminToken := env.seedUser("admin", models.RoleAdmin)
+	ana, token := env.seedUser("ana", models.RoleUser)
+	bia, _ := env.seedUser("bia", models.RoleUser)
+	env.createDevice(ana.ID, token)
+	expectStatus(t, env.do(http.MethodGet, "/admin/stats", nil, token), http.StatusForbidden) // um 4xx
+
+	// Um cadastro de três dias atrás e um usuário apagado
+	threeDaysAgo := time.Now().UTC().Add(-3 * day)
+	err := env.db.Model(&models.UserEvent{}).Where("user_id = ? AND type = ?", ana.ID, models.EventUserRegistered).
+		Update("created_at", threeDaysAgo).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, env.do(http.MethodDelete, fmt.Sprintf("/users/%d", bia.ID), nil, adminToken), http.StatusOK)
+
+	w := env.do(http.MethodGet, "/admin/stats?days=7", nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	var stats adminStats
+	decode(t, w, &stats)
+
+	if stats.Users.Total != 2 || stats.Users.Deleted != 1 {
+		t.Errorf("usuários = %d (apagados %d), want 2 (1)", stats.Users.Total, stats.Users.Deleted)
+	}
+	if stats.Users.ByRole[models.RoleAdmin] != 1 || stats.Users.ByRole[models.RoleUser] != 1 {
+		t.Errorf("by_role = %v", stats.Users.ByRole)
+	}
+	days := stats.Users.NewPerDay
+	if len(days) != 7 || days[6].Date != time.Now().UTC().Format(time.DateOnly) {
+		t.Fatalf("new_per_day = %+v, want os 7 dias até hoje", days)
+	}
+	if days[6].Count != 2 || days[3].Count != 1 || days[3].Date != threeDaysAgo.Format(time.DateOnly) || days[0].Count != 0 {
+		t.Errorf("new_per_day = %+v", days)
+	}
+
+	if stats.Devices.Total != 1 || stats.Devices.ByType["wearable"] != 1 {
+		t.Errorf("dispositivos = %+v", stats.Devices)
+	}
+
+	// Os contadores são do processo (somam todos os testes): só cresce
+	if stats.Requests.Total == 0 || stats.Requests.ByStatus["4xx"] == 0 || stats.Requests.ByRoute["POST /users"] < 3 {
+		t.Errorf("requisições = %+v", stats.Requests)
+	}
+	if stats.Requests.Since.After(time.Now()) {
+		t.Errorf("since = %v", stats.Requests.Since)
+	}
+}
+
+func TestAdminStatsValidation(t *testing.T) {
+	env := newTestEnv(t)
+	_, adminToken := env.seedUser("admin", models.RoleAdmin)
+	_, token := env.seedUser("ana", models.RoleUser)
+
+	expectStatus(t, env.do(http.MethodGet, "/admin/stats", nil, token), http.StatusForbidden)
+	for _, days := range []string{"0", "366", "abc"} {
+		expectStatus(t, env.do(http.MethodGet, "/admin/stats?days="+days, nil, adminToken), http.StatusBadRequest)
+	}
+
+	w := env.do(http.MethodGet, "/admin/stats", nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	var stats adminStats
+	decode(t, w, &stats)
+	if len(stats.Users.NewPerDay) != 30 {
+		t.Errorf("new_per_day padrão = %d dias, want 30", len(stats.Users.NewPerDay))
+	}
+}
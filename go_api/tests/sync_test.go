@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type syncDeltaPage struct {
+	Users struct {
+		Updated []models.User `json:"updated"`
+		Deleted []uint        `json:"deleted"`
+	} `json:"users"`
+	Devices struct {
+		Updated []models.Device `json:"updated"`
+		Deleted []uint          `json:"deleted"`
+	} `json:"devices"`
+	Cursor  string `json:"cursor"`
+	HasMore bool   `json:"has_more"`
+}
+
+func TestSyncDelta(t *testing.T) {
+	env := newTestEnv(t)
+	_, adminToken := env.seedUser("delta_admin", "admin")
+	ana, anaToken := env.seedUser("delta_ana", "")
+	bia, _ := env.seedUser("delta_bia", "")
+	relogio := env.createDevice(ana.ID, anaToken)
+	sensor := env.createDevice(ana.ID, anaToken)
+
+	pull := func(token, since string) syncDeltaPage {
+		t.Helper()
+		w := env.do(http.MethodGet, "/sync?since="+url.QueryEscape(since), nil, token)
+		expectStatus(t, w, http.StatusOK)
+		var page syncDeltaPage
+		decode(t, w, &page)
+		return page
+	}
+
+	// Primeira sincronização: tudo o que a Ana enxerga
+	first := pull(anaToken, "")
+	if len(first.Users.Updated) != 1 || first.Users.Updated[0].ID != ana.ID || len(first.Devices.Updated) != 2 || first.HasMore {
+		t.Fatalf("primeira página = %+v", first)
+	}
+	if again := pull(anaToken, first.Cursor); len(again.Users.Updated)+len(again.Devices.Updated) != 0 {
+		t.Errorf("nada mudou, mas veio %+v", again)
+	}
+
+	// Uma alteração e uma remoção depois do cursor
+	expectStatus(t, env.do(http.MethodPut, fmt.Sprintf("/devices/%d", relogio.ID), `{"name":"Relógio novo","type":"wearable"}`, anaToken), http.StatusOK)
+	expectStatus(t, env.do(http.MethodDelete, fmt.Sprintf("/devices/%d", sensor.ID), nil, anaToken), http.StatusOK)
+	page := pull(anaToken, first.Cursor)
+	if len(page.Devices.Updated) != 1 || page.Devices.Updated[0].Name != "Relógio novo" || len(page.Users.Updated) != 0 {
+		t.Errorf("alterados = %+v", page.Devices.Updated)
+	}
+	if len(page.Devices.Deleted) != 1 || page.Devices.Deleted[0] != sensor.ID {
+		t.Errorf("removidos = %v, quer [%d]", page.Devices.Deleted, sensor.ID)
+	}
+	// O removido não é mais acessível, mas fica como tombstone
+	expectStatus(t, env.do(http.MethodGet, fmt.Sprintf("/devices/%d", sensor.ID), nil, anaToken), http.StatusNotFound)
+
+	// Admin vê o tenant, inclusive usuários removidos; since também aceita
+	// um instante
+	expectStatus(t, env.do(http.MethodDelete, fmt.Sprintf("/users/%d", bia.ID), nil, adminToken), http.StatusOK)
+	page = pull(adminToken, time.Now().UTC().Add(-time.Minute).Format(time.RFC3339))
+	if len(page.Users.Deleted) != 1 || page.Users.Deleted[0] != bia.ID || len(page.Users.Updated) != 2 {
+		t.Errorf("usuários = %+v", page.Users)
+	}
+
+	expectStatus(t, env.do(http.MethodGet, "/sync?since=ontem", nil, anaToken), http.StatusBadRequest)
+}
+
+type syncPushResponse struct {
+	Results []struct {
+		ID     uint   `json:"id"`
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	} `json:"results"`
+}
+
+func TestSyncPushRejectsOnlyUniqueConflicts(t *testing.T) {
+	env := newTestEnv(t)
+	ana, token := env.seedUser("push_ana", "")
+	bia, _ := env.seedUser("push_bia", "")
+
+	change := gin.H{"id": ana.ID, "op": "update", "base_revision": ana.Revision, "modified_at": time.Now().UTC(), "data": gin.H{"email": bia.Email}}
+	w := env.do(http.MethodPost, "/sync/push", gin.H{"client_id": "celular", "changes": []gin.H{change}}, token)
+	expectStatus(t, w, http.StatusOK)
+	var resp syncPushResponse
+	decode(t, w, &resp)
+	if len(resp.Results) != 1 || resp.Results[0].Status != "rejected" || resp.Results[0].Error != "User or Email already exists" {
+		t.Errorf("resultados = %+v", resp.Results)
+	}
+
+	// Falha do banco não vira "já existe": a requisição volta 500
+	env.db.Callback().Create().Before("gorm:create").Register("test:sync", func(db *gorm.DB) {
+		if db.Statement.Table == "sync_conflicts" {
+			db.AddError(errors.New("disco cheio"))
+		}
+	})
+	t.Cleanup(func() { env.db.Callback().Create().Remove("test:sync") })
+	change = gin.H{"id": ana.ID, "op": "update", "base_revision": ana.Revision + 5, "modified_at": time.Now().UTC(), "data": gin.H{"name": "Ana Offline"}}
+	w = env.do(http.MethodPost, "/sync/push", gin.H{"client_id": "celular", "changes": []gin.H{change}}, token)
+	expectStatus(t, w, http.StatusInternalServerError)
+}
+
+func TestSyncPullDatabaseError(t *testing.T) {
+	env := newTestEnv(t)
+	_, token := env.seedUser("pull_ana", "")
+
+	env.db.Callback().Query().Before("gorm:query").Register("test:sync", func(db *gorm.DB) {
+		if db.Statement.Table == "user_events" || db.Statement.Table == "sync_conflicts" {
+			db.AddError(errors.New("conexão perdida"))
+		}
+	})
+	t.Cleanup(func() { env.db.Callback().Query().Remove("test:sync") })
+	expectStatus(t, env.do(http.MethodGet, "/sync/pull", nil, token), http.StatusInternalServerError)
+	expectStatus(t, env.do(http.MethodGet, "/sync/conflicts?client_id=celular", nil, token), http.StatusInternalServerError)
+}
@@ -0,0 +1,264 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_api/handlers"
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cria o tenant pelo admin da plataforma (admin do tenant padrão)
+func (e *testEnv) createTenant(platformToken, slug string) models.Tenant {
+	e.t.Helper()
+	w := e.do(http.MethodPost, "/tenants", gin.H{"slug": slug, "name": "Turma " + slug}, platformToken)
+	expectStatus(e.t, w, http.StatusCreated)
+	var tenant models.Tenant
+	decode(e.t, w, &tenant)
+	return tenant
+}
+
+// Como o seedUser, mas com o cadastro feito com X-Tenant
+func (e *testEnv) seedTenantUser(slug, username, role string) (models.User, string) {
+	e.t.Helper()
+	w := e.doWithHeaders(http.MethodPost, "/users", gin.H{
+		"name":     "Usuário " + username,
+		"email":    username + "@exemplo.com",
+		"user":     username,
+		"password": "senha-" + username,
+	}, "", map[string]string{"X-Tenant": slug})
+	expectStatus(e.t, w, http.StatusCreated)
+	var user models.User
+	decode(e.t, w, &user)
+
+	if role == models.RoleAdmin {
+		if err := e.db.Model(&user).Update("role", models.RoleAdmin).Error; err != nil {
+			e.t.Fatalf("promover admin: %v", err)
+		}
+	}
+	return user, e.login(username, "senha-"+username)
+}
+
+func listUsernames(t *testing.T, w *httptest.ResponseRecorder) map[string]bool {
+	t.Helper()
+	expectStatus(t, w, http.StatusOK)
+	var users []models.User
+	decode(t, w, &users)
+	names := make(map[string]bool, len(users))
+	for _, u := range users {
+		names[u.User] = true
+	}
+	return names
+}
+
+func TestTenantsIsolateUsers(t *testing.T) {
+	env := newTestEnv(t)
+	ana, platform := env.seedUser("plataforma", models.RoleAdmin)
+	acme := env.createTenant(platform, "acme")
+
+	bia, acmeAdmin := env.seedTenantUser("acme", "acme_admin", models.RoleAdmin)
+	if bia.TenantID != acme.ID {
+		t.Fatalf("tenant_id = %d, want %d", bia.TenantID, acme.ID)
+	}
+
+	// Cada admin só lista o próprio tenant
+	if names := listUsernames(t, env.do(http.MethodGet, "/users", nil, acmeAdmin)); !names["acme_admin"] || names["plataforma"] {
+		t.Fatalf("listagem do acme = %v", names)
+	}
+	if names := listUsernames(t, env.do(http.MethodGet, "/users", nil, platform)); names["acme_admin"] {
+		t.Fatalf("listagem do tenant padrão = %v", names)
+	}
+
+	// Usuário de outro tenant não existe, inclusive pelas tabelas sem tenant_id
+	for _, path := range []string{
+		fmt.Sprintf("/users/%d", ana.ID),
+		fmt.Sprintf("/users/%d/activities", ana.ID),
+		fmt.Sprintf("/users/%d/api-keys", ana.ID),
+	} {
+		expectStatus(t, env.do(http.MethodGet, path, nil, acmeAdmin), http.StatusNotFound)
+	}
+	expectStatus(t, env.do(http.MethodDelete, fmt.Sprintf("/users/%d", ana.ID), nil, acmeAdmin), http.StatusNotFound)
+
+	// O token vale só no próprio tenant
+	w := env.doWithHeaders(http.MethodGet, "/users", nil, acmeAdmin, map[string]string{"X-Tenant": "default"})
+	expectStatus(t, w, http.StatusForbidden)
+
+	// ...exceto o do admin da plataforma, que entra no acme pelo X-Tenant
+	w = env.doWithHeaders(http.MethodGet, "/users", nil, platform, map[string]string{"X-Tenant": "acme"})
+	if names := listUsernames(t, w); !names["acme_admin"] || names["plataforma"] {
+		t.Fatalf("listagem do acme pelo admin da plataforma = %v", names)
+	}
+}
+
+func TestTenantsScopeDevicesAndAudit(t *testing.T) {
+	env := newTestEnv(t)
+	_, platform := env.seedUser("plataforma", models.RoleAdmin)
+	env.createTenant(platform, "acme")
+	bia, biaToken := env.seedTenantUser("acme", "bia", "")
+
+	w := env.do(http.MethodPost, fmt.Sprintf("/users/%d/devices", bia.ID), gin.H{"name": "Pulseira", "type": "wearable"}, biaToken)
+	expectStatus(t, w, http.StatusCreated)
+	var device models.Device
+	decode(t, w, &device)
+	if device.TenantID != bia.TenantID {
+		t.Fatalf("tenant_id do dispositivo = %d, want %d", device.TenantID, bia.TenantID)
+	}
+	expectStatus(t, env.do(http.MethodGet, fmt.Sprintf("/devices/%d", device.ID), nil, platform), http.StatusNotFound)
+
+	// Eventos e auditoria do cadastro ficaram no acme
+	var events []models.UserEvent
+	env.db.Where("user_id = ?", bia.ID).Find(&events)
+	var logs []models.AuditLog
+	env.db.Where("entity = ? AND entity_id = ?", "user", bia.ID).Find(&logs)
+	if len(events) == 0 || len(logs) == 0 {
+		t.Fatalf("eventos = %d, auditoria = %d", len(events), len(logs))
+	}
+	for _, e := range events {
+		if e.TenantID != bia.TenantID {
+			t.Fatalf("evento %s no tenant %d", e.Type, e.TenantID)
+		}
+	}
+	for _, l := range logs {
+		if l.TenantID != bia.TenantID {
+			t.Fatalf("auditoria %s no tenant %d", l.Action, l.TenantID)
+		}
+	}
+	w = env.do(http.MethodGet, "/audit?entity=user", nil, platform)
+	expectStatus(t, w, http.StatusOK)
+	var page []models.AuditLog
+	decode(t, w, &page)
+	for _, l := range page {
+		if l.EntityID == bia.ID {
+			t.Fatalf("auditoria do acme visível no tenant padrão: %+v", l)
+		}
+	}
+}
+
+func TestTenantsWebhooksOnlyOwnEvents(t *testing.T) {
+	env := newTestEnv(t)
+	receiver := newWebhookReceiver(t)
+	_, platform := env.seedUser("plataforma", models.RoleAdmin)
+	env.createTenant(platform, "acme")
+	env.createWebhook(platform, gin.H{"url": receiver.URL, "events": []string{models.WebhookUserCreated}})
+
+	// Cadastro no acme: o webhook do tenant padrão não fica sabendo
+	env.seedTenantUser("acme", "bia", "")
+	if n := env.handler.Webhooks.DeliverDue(context.Background()); n != 0 {
+		t.Fatalf("entregues = %d, want 0", n)
+	}
+	env.seedUser("ana", "")
+	if n := env.handler.Webhooks.DeliverDue(context.Background()); n != 1 {
+		t.Fatalf("entregues = %d, want 1", n)
+	}
+}
+
+func TestTenantsResolution(t *testing.T) {
+	cfg := testCfg
+	cfg.TenantDomain = "api.exemplo.com"
+	env := newTestEnvWithConfig(t, cfg)
+	_, platform := env.seedUser("plataforma", models.RoleAdmin)
+	acme := env.createTenant(platform, "acme")
+
+	// Subdomínio do TENANT_DOMAIN vale como X-Tenant
+	req := httptest.NewRequest(http.MethodPost, "/users",
+		strings.NewReader(`{"name":"Bia","email":"bia@exemplo.com","user":"bia","password":"senha-bia"}`))
+	req.Host = "acme.api.exemplo.com:8080"
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	expectStatus(t, w, http.StatusCreated)
+	var bia models.User
+	decode(t, w, &bia)
+	if bia.TenantID != acme.ID {
+		t.Fatalf("tenant_id = %d, want %d", bia.TenantID, acme.ID)
+	}
+
+	w = env.doWithHeaders(http.MethodPost, "/login", gin.H{"user": "plataforma", "password": "senha-plataforma"}, "",
+		map[string]string{"X-Tenant": "nao-existe"})
+	expectStatus(t, w, http.StatusNotFound)
+	if e := decodeError(t, w); e.Message != "Tenant not found" {
+		t.Fatalf("erro = %+v", e)
+	}
+	// Login com o tenant certo no cabeçalho; no errado, o usuário não existe
+	w = env.doWithHeaders(http.MethodPost, "/login", gin.H{"user": "bia", "password": "senha-bia"}, "",
+		map[string]string{"X-Tenant": "default"})
+	expectStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestTenantsManagement(t *testing.T) {
+	env := newTestEnv(t)
+	_, platform := env.seedUser("plataforma", models.RoleAdmin)
+	env.createTenant(platform, "acme")
+
+	w := env.do(http.MethodPost, "/tenants", gin.H{"slug": "acme", "name": "Outra"}, platform)
+	expectStatus(t, w, http.StatusConflict)
+	if e := decodeError(t, w); e.Details["slug"] == "" {
+		t.Fatalf("erro = %+v", e)
+	}
+	w = env.do(http.MethodPost, "/tenants", gin.H{"slug": "Com Espaço", "name": "X"}, platform)
+	expectStatus(t, w, http.StatusBadRequest)
+	if e := decodeError(t, w); e.Details["slug"] == "" {
+		t.Fatalf("erro = %+v", e)
+	}
+
+	// Admin de outro tenant não gerencia tenants
+	_, acmeAdmin := env.seedTenantUser("acme", "acme_admin", models.RoleAdmin)
+	expectStatus(t, env.do(http.MethodGet, "/tenants", nil, acmeAdmin), http.StatusForbidden)
+
+	w = env.do(http.MethodGet, "/tenants", nil, platform)
+	expectStatus(t, w, http.StatusOK)
+	var tenants []models.Tenant
+	decode(t, w, &tenants)
+	if len(tenants) != 2 || tenants[0].Slug != "default" || tenants[1].Slug != "acme" {
+		t.Fatalf("tenants = %+v", tenants)
+	}
+}
+
+// As sub-requisições do /batch ficam no tenant da requisição externa
+func TestTenantsInBatch(t *testing.T) {
+	cfg := testCfg
+	cfg.TenantDomain = "api.exemplo.com"
+	env := newTestEnvWithConfig(t, cfg)
+	_, platform := env.seedUser("plataforma", models.RoleAdmin)
+	env.createTenant(platform, "acme")
+	env.seedTenantUser("acme", "bia", "")
+
+	batchUsers := func(req *http.Request) map[string]bool {
+		t.Helper()
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+platform)
+		w := httptest.NewRecorder()
+		env.router.ServeHTTP(w, req)
+		expectStatus(t, w, http.StatusOK)
+		var responses []handlers.BatchResponse
+		decode(t, w, &responses)
+		var users []models.User
+		if len(responses) != 1 || json.Unmarshal(responses[0].Body, &users) != nil {
+			t.Fatalf("respostas = %+v", responses)
+		}
+		names := make(map[string]bool, len(users))
+		for _, u := range users {
+			names[u.User] = true
+		}
+		return names
+	}
+	const body = `[{"method":"GET","path":"/users"}]`
+
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+	req.Header.Set("X-Tenant", "acme")
+	if names := batchUsers(req); !names["bia"] || names["plataforma"] {
+		t.Errorf("lote com X-Tenant = %v", names)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+	req.Host = "acme.api.exemplo.com"
+	if names := batchUsers(req); !names["bia"] || names["plataforma"] {
+		t.Errorf("lote pelo subdomínio = %v", names)
+	}
+}
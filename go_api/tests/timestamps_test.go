@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go_api/models"
+)
+
+func TestTimestamps(t *testing.T) {
+	env := newTestEnv(t)
+	admin, adminToken := env.seedUser("tempo_admin", "admin")
+	ana, anaToken := env.seedUser("tempo_ana", "")
+	device := env.createDevice(ana.ID, anaToken)
+	if ana.CreatedAt.IsZero() || ana.UpdatedAt.IsZero() || device.CreatedAt.IsZero() {
+		t.Fatalf("usuário = %+v, dispositivo = %+v: quer created_at/updated_at", ana, device)
+	}
+
+	// O JSON sai sempre em UTC
+	w := env.do(http.MethodGet, fmt.Sprintf("/users/%d?fields=created_at,updated_at", ana.ID), nil, anaToken)
+	expectStatus(t, w, http.StatusOK)
+	var raw map[string]string
+	decode(t, w, &raw)
+	for field, value := range raw {
+		if !strings.HasSuffix(value, "Z") {
+			t.Errorf("%s = %q, quer UTC", field, value)
+		}
+	}
+
+	// Tudo criado "ontem"; só a Ana muda hoje
+	yesterday := time.Now().UTC().Add(-24 * time.Hour)
+	for _, model := range []interface{}{&models.User{}, &models.Device{}} {
+		env.db.Model(model).Where("1 = 1").UpdateColumns(map[string]interface{}{"created_at": yesterday, "updated_at": yesterday})
+	}
+	since := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	expectStatus(t, env.do(http.MethodPatch, fmt.Sprintf("/users/%d", ana.ID), `{"name":"Ana Maria"}`, anaToken), http.StatusOK)
+
+	var users []models.User
+	w = env.do(http.MethodGet, "/users?updated_after="+url.QueryEscape(since), nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &users)
+	if len(users) != 1 || users[0].ID != ana.ID {
+		t.Errorf("updated_after = %+v, quer só a Ana", users)
+	}
+	w = env.do(http.MethodGet, "/users?created_before="+url.QueryEscape(since), nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &users)
+	if len(users) != 2 || users[0].ID != admin.ID {
+		t.Errorf("created_before = %+v, quer os dois", users)
+	}
+	// O mesmo recorte no ?filter=, no ?sort= e na exportação
+	w = env.do(http.MethodGet, "/users?sort=-updated_at&filter="+url.QueryEscape("updated_at=gt="+since), nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &users)
+	if len(users) != 1 || users[0].ID != ana.ID {
+		t.Errorf("filter updated_at = %+v, quer só a Ana", users)
+	}
+	w = env.do(http.MethodGet, "/users/export?format=json&updated_after="+url.QueryEscape(since), nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &users)
+	if len(users) != 1 || users[0].ID != ana.ID {
+		t.Errorf("exportação com updated_after = %+v, quer só a Ana", users)
+	}
+
+	// O heartbeat não conta como alteração
+	expectStatus(t, env.do(http.MethodPost, fmt.Sprintf("/devices/%d/heartbeat", device.ID), nil, anaToken), http.StatusOK)
+	var devices []models.Device
+	w = env.do(http.MethodGet, "/devices?updated_after="+url.QueryEscape(since), nil, anaToken)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &devices)
+	if len(devices) != 0 {
+		t.Errorf("updated_after depois do heartbeat = %+v, quer nenhum", devices)
+	}
+	expectStatus(t, env.do(http.MethodPut, fmt.Sprintf("/devices/%d", device.ID), `{"name":"Relógio novo","type":"wearable"}`, anaToken), http.StatusOK)
+	w = env.do(http.MethodGet, "/devices?updated_after="+url.QueryEscape(since), nil, anaToken)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &devices)
+	if len(devices) != 1 {
+		t.Errorf("updated_after depois do PUT = %+v, quer o dispositivo", devices)
+	}
+
+	expectStatus(t, env.do(http.MethodGet, "/users?created_after=ontem", nil, adminToken), http.StatusBadRequest)
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"go_api/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	spanRecorder     *tracetest.SpanRecorder
+	spanRecorderOnce sync.Once
+)
+
+// Provider que guarda os spans em memória, no lugar do exportador OTLP. É
+// um só para o pacote: o plugin do GORM (registrado no TestMain) fica preso
+// ao primeiro provider global. Precisa vir antes do newTestEnv, porque o
+// otelgin pega o tracer ao montar o router.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	spanRecorderOnce.Do(func() {
+		if _, err := tracing.Setup(context.Background(), testCfg); err != nil {
+			t.Fatalf("tracing: %v", err)
+		}
+		spanRecorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	})
+	return spanRecorder
+}
+
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestTracingRequestAndQueries(t *testing.T) {
+	recorder := recordSpans(t)
+	env := newTestEnv(t)
+	_, adminToken := env.seedUser("admin", "admin")
+
+	// Trace começado por quem chamou (traceparent): a API continua o mesmo
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	w := env.doWithHeaders(http.MethodGet, "/users", nil, adminToken, map[string]string{
+		"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01",
+	})
+	expectStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("X-Trace-ID"); got != traceID {
+		t.Fatalf("X-Trace-ID = %q, esperado %q", got, traceID)
+	}
+
+	var server sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() == traceID && span.SpanKind() == trace.SpanKindServer {
+			server = span
+		}
+	}
+	if server == nil {
+		t.Fatal("span do handler não gravado")
+	}
+	if server.Name() != "GET /users" {
+		t.Fatalf("nome do span = %q", server.Name())
+	}
+
+	var queries int
+	for _, span := range recorder.Ended() {
+		if span.Parent().SpanID() != server.SpanContext().SpanID() || !strings.HasPrefix(span.Name(), "gorm.") {
+			continue
+		}
+		queries++
+		// Sem os valores dos parâmetros
+		if stmt := spanAttr(span, "db.statement"); strings.Contains(stmt, "admin") {
+			t.Fatalf("valor de parâmetro no span: %s", stmt)
+		}
+	}
+	if queries == 0 {
+		t.Fatal("nenhum span de query dentro do span do handler")
+	}
+}
+
+func TestTracingSkipsProbes(t *testing.T) {
+	recorder := recordSpans(t)
+	env := newTestEnv(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	expectStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("X-Trace-ID"); got != "" {
+		t.Fatalf("X-Trace-ID na sonda: %q", got)
+	}
+	for _, span := range recorder.Ended() {
+		if span.Name() == "GET /healthz" {
+			t.Fatal("span gravado para /healthz")
+		}
+	}
+}
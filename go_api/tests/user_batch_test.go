@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go_api/handlers"
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type batchResponse struct {
+	Mode    string                     `json:"mode"`
+	Created int                        `json:"created"`
+	Results []handlers.UserBatchResult `json:"results"`
+}
+
+func batchUser(username string) gin.H {
+	return gin.H{
+		"name":     "Aluno " + username,
+		"email":    username + "@exemplo.com",
+		"user":     username,
+		"password": "senha-" + username,
+	}
+}
+
+func (e *testEnv) countUsers(usernames ...string) int64 {
+	e.t.Helper()
+	var count int64
+	e.db.Model(&models.User{}).Where(`"user" IN ?`, usernames).Count(&count)
+	return count
+}
+
+func TestUserBatchAtomic(t *testing.T) {
+	env := newTestEnv(t)
+	_, admin := env.seedUser("root", models.RoleAdmin)
+
+	users := make([]gin.H, 0, 50)
+	names := make([]string, 0, 50)
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("aluno%02d", i)
+		users = append(users, batchUser(name))
+		names = append(names, name)
+	}
+	w := env.do(http.MethodPost, "/users/batch", gin.H{"users": users}, admin)
+	expectStatus(t, w, http.StatusCreated)
+	var body batchResponse
+	decode(t, w, &body)
+	if body.Mode != "atomic" || body.Created != 50 || len(body.Results) != 50 {
+		t.Fatalf("resposta = %+v", body)
+	}
+	for i, r := range body.Results {
+		if r.Index != i || r.Status != "created" || r.User == nil || r.User.Role != models.RoleUser {
+			t.Fatalf("item %d = %+v", i, r)
+		}
+	}
+	if n := env.countUsers(names...); n != 50 {
+		t.Fatalf("%d usuários gravados", n)
+	}
+
+	// Senha com hash e login normal; cada um recebeu o e-mail de verificação
+	env.login("aluno07", "senha-aluno07")
+	if len(env.mail.messages()) < 50 {
+		t.Errorf("%d e-mails enviados", len(env.mail.messages()))
+	}
+}
+
+func TestUserBatchAtomicRejectsEverything(t *testing.T) {
+	env := newTestEnv(t)
+	_, admin := env.seedUser("root", models.RoleAdmin)
+	env.seedUser("existente", models.RoleUser)
+	sent := len(env.mail.messages())
+
+	w := env.do(http.MethodPost, "/users/batch", gin.H{"users": []gin.H{
+		batchUser("novo1"),
+		batchUser("existente"),
+		batchUser("novo2"),
+		batchUser("novo2"), // repetido dentro do lote
+	}}, admin)
+	expectStatus(t, w, http.StatusUnprocessableEntity)
+	var body batchResponse
+	decode(t, w, &body)
+	want := []string{"skipped", "conflict", "skipped", "conflict"}
+	for i, r := range body.Results {
+		if r.Status != want[i] {
+			t.Errorf("item %d: status = %q, want %q", i, r.Status, want[i])
+		}
+	}
+	if body.Results[1].Details["email"] == nil {
+		t.Errorf("detalhes do conflito = %v", body.Results[1].Details)
+	}
+	if n := env.countUsers("novo1", "novo2"); n != 0 {
+		t.Fatalf("%d usuários gravados num lote recusado", n)
+	}
+	if len(env.mail.messages()) != sent {
+		t.Error("e-mail enviado para lote recusado")
+	}
+
+	// Item inválido também recusa o lote, antes de tocar no banco
+	invalid := batchUser("novo3")
+	invalid["email"] = "nao-e-email"
+	w = env.do(http.MethodPost, "/users/batch", gin.H{"users": []gin.H{batchUser("novo1"), invalid}}, admin)
+	expectStatus(t, w, http.StatusUnprocessableEntity)
+	decode(t, w, &body)
+	if body.Results[0].Status != "skipped" || body.Results[1].Status != "invalid" || body.Results[1].Details["email"] == nil {
+		t.Fatalf("resultados = %+v", body.Results)
+	}
+}
+
+func TestUserBatchPartial(t *testing.T) {
+	env := newTestEnv(t)
+	_, admin := env.seedUser("root", models.RoleAdmin)
+	env.seedUser("existente", models.RoleUser)
+
+	invalid := batchUser("curta")
+	invalid["password"] = "123"
+	w := env.do(http.MethodPost, "/users/batch", gin.H{"mode": "partial", "users": []gin.H{
+		batchUser("novo1"),
+		batchUser("existente"),
+		invalid,
+		batchUser("novo2"),
+	}}, admin)
+	expectStatus(t, w, http.StatusOK)
+	var body batchResponse
+	decode(t, w, &body)
+	want := []string{"created", "conflict", "invalid", "created"}
+	for i, r := range body.Results {
+		if r.Status != want[i] {
+			t.Errorf("item %d: status = %q, want %q", i, r.Status, want[i])
+		}
+	}
+	if body.Created != 2 || env.countUsers("novo1", "novo2") != 2 {
+		t.Fatalf("created = %d", body.Created)
+	}
+}
+
+func TestUserBatchRules(t *testing.T) {
+	env := newTestEnv(t)
+	_, admin := env.seedUser("root", models.RoleAdmin)
+	_, token := env.seedUser("ana", models.RoleUser)
+
+	w := env.do(http.MethodPost, "/users/batch", gin.H{"users": []gin.H{batchUser("novo1")}}, token)
+	expectStatus(t, w, http.StatusForbidden)
+
+	w = env.do(http.MethodPost, "/users/batch", gin.H{"users": []gin.H{}}, admin)
+	expectStatus(t, w, http.StatusBadRequest)
+
+	w = env.do(http.MethodPost, "/users/batch", gin.H{"mode": "talvez", "users": []gin.H{batchUser("novo1")}}, admin)
+	expectStatus(t, w, http.StatusBadRequest)
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_api/handlers"
+	"go_api/models"
+)
+
+// Envia o CSV como multipart, no campo "file"
+func (e *testEnv) importCSV(content, token string) *httptest.ResponseRecorder {
+	e.t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "alunos.csv")
+	part.Write([]byte(content))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/users/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestImportUsersCSV(t *testing.T) {
+	env := newTestEnv(t)
+	_, admin := env.seedUser("root", models.RoleAdmin)
+	env.seedUser("existente", models.RoleUser)
+
+	csv := "\ufeffUser,Name,Email,Password\n" +
+		"aluno1,Aluno Um,aluno1@exemplo.com,senha-aluno1\n" +
+		"existente,Outro,outro@exemplo.com,senha-outro\n" +
+		"\n" +
+		"aluno2,\"Silva, Aluno\",nao-e-email,senha-aluno2\n" +
+		"aluno3,Aluno Três\n" +
+		"aluno4,Aluno Quatro,aluno4@exemplo.com,senha-aluno4\n"
+	w := env.importCSV(csv, admin)
+	expectStatus(t, w, http.StatusOK)
+	var report handlers.ImportReport
+	decode(t, w, &report)
+	if report.Rows != 5 || report.Created != 2 || report.Skipped != 3 {
+		t.Fatalf("relatório = %+v", report)
+	}
+	want := map[int]string{3: "conflict", 5: "invalid", 6: "invalid"}
+	for _, e := range report.Errors {
+		if want[e.Line] != e.Status {
+			t.Errorf("linha %d: status = %q (%s)", e.Line, e.Status, e.Error)
+		}
+		delete(want, e.Line)
+	}
+	if len(want) > 0 {
+		t.Errorf("linhas sem erro no relatório: %v", want)
+	}
+	env.login("aluno4", "senha-aluno4")
+
+	// Reimportar não duplica ninguém
+	w = env.importCSV(csv, admin)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &report)
+	if report.Created != 0 || env.countUsers("aluno1", "aluno4") != 2 {
+		t.Fatalf("reimportação = %+v", report)
+	}
+}
+
+func TestImportUsersRejectsBadFiles(t *testing.T) {
+	env := newTestEnv(t)
+	_, admin := env.seedUser("root", models.RoleAdmin)
+	_, token := env.seedUser("ana", models.RoleUser)
+
+	expectStatus(t, env.importCSV("name,email,user,password\n", token), http.StatusForbidden)
+
+	w := env.importCSV("name,email\nAna,ana2@exemplo.com\n", admin)
+	expectStatus(t, w, http.StatusBadRequest)
+	if msg := decodeError(t, w).Message; !strings.Contains(msg, "user, password") {
+		t.Errorf("message = %q", msg)
+	}
+
+	w = env.importCSV("name,email,user,password\n\"Ana,ana@x.com,ana3,senha-ana3\n", admin)
+	expectStatus(t, w, http.StatusBadRequest)
+
+	// Sem multipart
+	expectStatus(t, env.do(http.MethodPost, "/users/import", `{"file":"x"}`, admin), http.StatusBadRequest)
+}
@@ -0,0 +1,305 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go_api/models"
+	"go_api/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateUser(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do(http.MethodPost, "/users", gin.H{
+		"name": "Ana", "email": "ana@exemplo.com", "user": "ana", "password": "senha-forte",
+		"role": "admin", // ignorado: cadastro público é sempre "user"
+	}, "")
+	expectStatus(t, w, http.StatusCreated)
+	if strings.Contains(w.Body.String(), "password") {
+		t.Fatalf("senha vazou na resposta: %s", w.Body.String())
+	}
+	var user models.User
+	decode(t, w, &user)
+	if user.ID == 0 || user.Role != models.RoleUser || user.Revision != 1 {
+		t.Fatalf("usuário criado = %+v", user)
+	}
+}
+
+func TestCreateUserValidation(t *testing.T) {
+	env := newTestEnv(t)
+
+	for name, body := range map[string]string{
+		"sem nome":        `{"email":"a@exemplo.com","user":"abc","password":"x"}`,
+		"e-mail inválido": `{"name":"A","email":"nao-e-email","user":"abc","password":"x"}`,
+		"user curto":      `{"name":"A","email":"a@exemplo.com","user":"ab","password":"x"}`,
+		"JSON inválido":   `{"name":`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			expectStatus(t, env.do(http.MethodPost, "/users", body, ""), http.StatusBadRequest)
+		})
+	}
+}
+
+func TestCreateUserFieldErrors(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do(http.MethodPost, "/users", `{"email":"nao-e-email","user":"ana maria","password":"curta"}`, "")
+	expectStatus(t, w, http.StatusBadRequest)
+	body := decodeError(t, w)
+	if body.Code != "validation_failed" {
+		t.Errorf("code = %q", body.Code)
+	}
+	for _, field := range []string{"name", "email", "user", "password"} {
+		if body.Details[field] == "" {
+			t.Errorf("sem erro para %q: %v", field, body.Details)
+		}
+	}
+}
+
+func TestCreateUserConflict(t *testing.T) {
+	env := newTestEnv(t)
+	env.seedUser("ana", models.RoleUser)
+
+	for field, input := range map[string]gin.H{
+		"email": {"name": "Outra Ana", "email": "ana@exemplo.com", "user": "ana2", "password": "senha-forte"},
+		"user":  {"name": "Outra Ana", "email": "ana2@exemplo.com", "user": "ana", "password": "senha-forte"},
+	} {
+		w := env.do(http.MethodPost, "/users", input, "")
+		expectStatus(t, w, http.StatusConflict)
+		body := decodeError(t, w)
+		if _, ok := body.Details[field]; !ok || len(body.Details) != 1 {
+			t.Errorf("conflito em %q: details = %v", field, body.Details)
+		}
+	}
+}
+
+func TestLogin(t *testing.T) {
+	env := newTestEnv(t)
+	env.seedUser("ana", models.RoleUser)
+
+	// Aceita o username ou o e-mail
+	env.login("ana@exemplo.com", "senha-ana")
+
+	w := env.do(http.MethodPost, "/login", gin.H{"user": "ana", "password": "errada"}, "")
+	expectStatus(t, w, http.StatusUnauthorized)
+	w = env.do(http.MethodPost, "/login", gin.H{"user": "ninguem", "password": "x"}, "")
+	expectStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestRoutesRequireToken(t *testing.T) {
+	env := newTestEnv(t)
+	user, _ := env.seedUser("ana", models.RoleUser)
+
+	expectStatus(t, env.do(http.MethodGet, fmt.Sprintf("/users/%d", user.ID), nil, ""), http.StatusUnauthorized)
+	expectStatus(t, env.do(http.MethodGet, fmt.Sprintf("/users/%d", user.ID), nil, "token-invalido"), http.StatusUnauthorized)
+}
+
+func TestGetUser(t *testing.T) {
+	env := newTestEnv(t)
+	ana, anaToken := env.seedUser("ana", models.RoleUser)
+	bia, _ := env.seedUser("bia", models.RoleUser)
+	_, adminToken := env.seedUser("admin", models.RoleAdmin)
+
+	w := env.do(http.MethodGet, fmt.Sprintf("/users/%d", ana.ID), nil, anaToken)
+	expectStatus(t, w, http.StatusOK)
+	var got models.User
+	decode(t, w, &got)
+	if got.User != "ana" {
+		t.Fatalf("GET próprio usuário = %+v", got)
+	}
+
+	// Usuário comum não vê os outros; admin vê todos
+	expectStatus(t, env.do(http.MethodGet, fmt.Sprintf("/users/%d", bia.ID), nil, anaToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodGet, fmt.Sprintf("/users/%d", bia.ID), nil, adminToken), http.StatusOK)
+	expectStatus(t, env.do(http.MethodGet, "/users/99999", nil, adminToken), http.StatusNotFound)
+}
+
+func TestListUsers(t *testing.T) {
+	env := newTestEnv(t)
+	_, anaToken := env.seedUser("ana", models.RoleUser)
+	env.seedUser("bia", models.RoleUser)
+	_, adminToken := env.seedUser("admin", models.RoleAdmin)
+
+	expectStatus(t, env.do(http.MethodGet, "/users", nil, anaToken), http.StatusForbidden)
+
+	w := env.do(http.MethodGet, "/users?sort=-user&per_page=2", nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	var users []models.User
+	decode(t, w, &users)
+	if w.Header().Get("X-Total-Count") != "3" || len(users) != 2 || users[0].User != "bia" {
+		t.Fatalf("listagem = %v (total %s)", users, w.Header().Get("X-Total-Count"))
+	}
+
+	w = env.do(http.MethodGet, "/users?filter=user==a*", nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &users)
+	if len(users) != 2 {
+		t.Fatalf("filtro user==a* = %v", users)
+	}
+
+	expectStatus(t, env.do(http.MethodGet, "/users?sort=password", nil, adminToken), http.StatusBadRequest)
+	expectStatus(t, env.do(http.MethodGet, "/users?filter=password==x", nil, adminToken), http.StatusBadRequest)
+}
+
+func TestUpdateUser(t *testing.T) {
+	env := newTestEnv(t)
+	ana, anaToken := env.seedUser("ana", models.RoleUser)
+	env.seedUser("bia", models.RoleUser)
+	path := fmt.Sprintf("/users/%d", ana.ID)
+
+	// id e password no corpo não têm efeito no PUT
+	w := env.do(http.MethodPut, path, gin.H{"id": 999, "name": "Ana Maria", "password": "trocada"}, anaToken)
+	expectStatus(t, w, http.StatusOK)
+	var got models.User
+	decode(t, w, &got)
+	if got.ID != ana.ID || got.Name != "Ana Maria" || got.Email != ana.Email || got.Revision != 2 {
+		t.Fatalf("PUT = %+v", got)
+	}
+	env.login("ana", "senha-ana")
+
+	expectStatus(t, env.do(http.MethodPut, path, gin.H{"email": "bia@exemplo.com"}, anaToken), http.StatusConflict)
+	expectStatus(t, env.do(http.MethodPut, path, gin.H{"email": "invalido"}, anaToken), http.StatusBadRequest)
+}
+
+func TestPatchUser(t *testing.T) {
+	env := newTestEnv(t)
+	ana, anaToken := env.seedUser("ana", models.RoleUser)
+	path := fmt.Sprintf("/users/%d", ana.ID)
+
+	w := env.do(http.MethodPatch, path, `{"user":"ana.maria"}`, anaToken)
+	expectStatus(t, w, http.StatusOK)
+	var got models.User
+	decode(t, w, &got)
+	if got.User != "ana.maria" || got.Name != ana.Name {
+		t.Fatalf("PATCH = %+v", got)
+	}
+
+	expectStatus(t, env.do(http.MethodPatch, path, `{"name":""}`, anaToken), http.StatusBadRequest)
+	expectStatus(t, env.do(http.MethodPatch, path, `{"name":null}`, anaToken), http.StatusBadRequest)
+	expectStatus(t, env.do(http.MethodPatch, path, `{"role":"admin"}`, anaToken), http.StatusBadRequest)
+	expectStatus(t, env.do(http.MethodPatch, path, `[1]`, anaToken), http.StatusBadRequest)
+}
+
+func TestChangePassword(t *testing.T) {
+	env := newTestEnv(t)
+	ana, anaToken := env.seedUser("ana", models.RoleUser)
+	bia, _ := env.seedUser("bia", models.RoleUser)
+	_, adminToken := env.seedUser("admin", models.RoleAdmin)
+	path := fmt.Sprintf("/users/%d/password", ana.ID)
+
+	w := env.do(http.MethodPut, path, gin.H{"current_password": "errada", "new_password": "nova-senha"}, anaToken)
+	expectStatus(t, w, http.StatusForbidden)
+
+	w = env.do(http.MethodPut, path, gin.H{"current_password": "senha-ana", "new_password": "nova-senha"}, anaToken)
+	expectStatus(t, w, http.StatusOK)
+	env.login("ana", "nova-senha")
+
+	// Admin redefine a senha de outro sem saber a atual
+	w = env.do(http.MethodPut, fmt.Sprintf("/users/%d/password", bia.ID), gin.H{"new_password": "redefinida"}, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	env.login("bia", "redefinida")
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	env := newTestEnv(t)
+	ana, anaToken := env.seedUser("ana", models.RoleUser)
+	_, adminToken := env.seedUser("admin", models.RoleAdmin)
+	path := fmt.Sprintf("/users/%d/role", ana.ID)
+
+	expectStatus(t, env.do(http.MethodPut, path, gin.H{"role": "admin"}, anaToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodPut, path, gin.H{"role": "root"}, adminToken), http.StatusBadRequest)
+
+	w := env.do(http.MethodPut, path, gin.H{"role": "admin"}, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	var got models.User
+	decode(t, w, &got)
+	if got.Role != models.RoleAdmin {
+		t.Fatalf("role = %q", got.Role)
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	env := newTestEnv(t)
+	ana, anaToken := env.seedUser("ana", models.RoleUser)
+	_, adminToken := env.seedUser("admin", models.RoleAdmin)
+	path := fmt.Sprintf("/users/%d", ana.ID)
+
+	expectStatus(t, env.do(http.MethodDelete, path, nil, anaToken), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodDelete, path, nil, adminToken), http.StatusOK)
+	expectStatus(t, env.do(http.MethodGet, path, nil, adminToken), http.StatusNotFound)
+	expectStatus(t, env.do(http.MethodDelete, path, nil, adminToken), http.StatusNotFound)
+
+	// O histórico continua disponível depois da remoção
+	w := env.do(http.MethodGet, path+"/events", nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	var events []models.UserEvent
+	decode(t, w, &events)
+	if last := events[len(events)-1]; last.Type != models.EventUserDeleted {
+		t.Fatalf("último evento = %s", last.Type)
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+	env := newTestEnv(t)
+	ana, anaToken := env.seedUser("ana", models.RoleUser)
+	_, adminToken := env.seedUser("admin", models.RoleAdmin)
+	path := fmt.Sprintf("/users/%d", ana.ID)
+
+	expectStatus(t, env.do(http.MethodPost, path+"/restore", nil, adminToken), http.StatusConflict)
+	expectStatus(t, env.do(http.MethodDelete, path, nil, adminToken), http.StatusOK)
+
+	// Removido some das consultas e do login, mas o admin ainda o enxerga
+	expectStatus(t, env.do(http.MethodPost, "/login", gin.H{"user": "ana", "password": "senha-ana"}, ""), http.StatusUnauthorized)
+	expectStatus(t, env.do(http.MethodGet, path+"?include_deleted=true", nil, anaToken), http.StatusForbidden)
+	w := env.do(http.MethodGet, path+"?include_deleted=true", nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	var got models.User
+	decode(t, w, &got)
+	if !got.DeletedAt.Valid {
+		t.Fatalf("deleted_at vazio: %s", w.Body.String())
+	}
+
+	w = env.do(http.MethodGet, "/users", nil, adminToken)
+	var users []models.User
+	decode(t, w, &users)
+	if len(users) != 1 {
+		t.Fatalf("listagem sem removidos = %v", users)
+	}
+	w = env.do(http.MethodGet, "/users?include_deleted=true", nil, adminToken)
+	decode(t, w, &users)
+	if len(users) != 2 {
+		t.Fatalf("listagem com removidos = %v", users)
+	}
+
+	expectStatus(t, env.do(http.MethodPost, path+"/restore", nil, anaToken), http.StatusForbidden)
+	w = env.do(http.MethodPost, path+"/restore", nil, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &got)
+	if got.DeletedAt.Valid || got.Revision != ana.Revision+1 {
+		t.Fatalf("restaurado = %+v", got)
+	}
+	env.login("ana", "senha-ana")
+	expectStatus(t, env.do(http.MethodPost, "/users/99999/restore", nil, adminToken), http.StatusNotFound)
+}
+
+// Sem a verificação prévia do service, o índice único ainda diz o campo
+func TestDuplicateFromUniqueIndex(t *testing.T) {
+	env := newTestEnv(t)
+	env.seedUser("ana", models.RoleUser)
+
+	store := repository.NewUserStore(env.db)
+	err := store.Create(context.Background(), &models.User{
+		Name: "Outra", Email: "ana@exemplo.com", User: "outra", Password: "senha-forte", Role: models.RoleUser,
+	})
+	var dup *repository.DuplicateError
+	if !errors.As(err, &dup) || dup.Field != "email" {
+		t.Fatalf("err = %v, want DuplicateError{email}", err)
+	}
+}
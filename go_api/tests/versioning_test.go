@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go_api/handlers"
+	"go_api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVersionedRoutes(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do(http.MethodPost, "/api/v1/users", gin.H{
+		"name": "Ana", "email": "ana@exemplo.com", "user": "ana", "password": "senha-ana",
+	}, "")
+	expectStatus(t, w, http.StatusCreated)
+	var ana models.User
+	decode(t, w, &ana)
+	w = env.do(http.MethodPost, "/api/v1/login", gin.H{"user": "ana", "password": "senha-ana"}, "")
+	expectStatus(t, w, http.StatusOK)
+	token := accessToken(t, w)
+
+	w = env.do(http.MethodGet, fmt.Sprintf("/api/v1/users/%d", ana.ID), nil, token)
+	expectStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("API-Version"); got != "1" {
+		t.Errorf("API-Version = %q, want 1", got)
+	}
+	for _, header := range []string{"Deprecation", "Sunset", "Link"} {
+		if got := w.Header().Get(header); got != "" {
+			t.Errorf("%s = %q na rota versionada", header, got)
+		}
+	}
+
+	// O mesmo usuário pelo caminho antigo
+	w = env.do(http.MethodGet, fmt.Sprintf("/users/%d?fields=name", ana.ID), nil, token)
+	expectStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("Deprecation"); !strings.HasPrefix(got, "@") {
+		t.Errorf("Deprecation = %q, want uma data (@<segundos>)", got)
+	}
+	if got, want := w.Header().Get("Link"), fmt.Sprintf(`</api/v1/users/%d?fields=name>; rel="successor-version"`, ana.ID); got != want {
+		t.Errorf("Link = %q, want %q", got, want)
+	}
+	if got := w.Header().Get("API-Version"); got != "1" {
+		t.Errorf("API-Version = %q, want 1", got)
+	}
+	if got := w.Header().Get("Sunset"); got != "" {
+		t.Errorf("Sunset = %q sem LEGACY_ROUTES_SUNSET", got)
+	}
+
+	// Lote dentro de lote também é recusado pelo caminho versionado
+	w = env.do(http.MethodPost, "/api/v1/batch", []gin.H{{"method": "GET", "path": "/api/v1/batch"}}, token)
+	expectStatus(t, w, http.StatusOK)
+	var responses []handlers.BatchResponse
+	decode(t, w, &responses)
+	if len(responses) != 1 || responses[0].Status != http.StatusBadRequest {
+		t.Errorf("respostas = %+v", responses)
+	}
+}
+
+func TestLegacyRoutesNegotiation(t *testing.T) {
+	cfg := testCfg
+	cfg.LegacyRoutesSunset = time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC)
+	env := newTestEnvWithConfig(t, cfg)
+
+	w := env.do(http.MethodGet, "/time", nil, "")
+	expectStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("Sunset"); got != "Wed, 30 Jun 2027 00:00:00 GMT" {
+		t.Errorf("Sunset = %q", got)
+	}
+
+	for _, version := range []string{"1", "v1"} {
+		w = env.doWithHeaders(http.MethodGet, "/time", nil, "", map[string]string{"API-Version": version})
+		expectStatus(t, w, http.StatusOK)
+	}
+	w = env.doWithHeaders(http.MethodGet, "/time", nil, "", map[string]string{"API-Version": "2"})
+	expectStatus(t, w, http.StatusBadRequest)
+	if body := decodeError(t, w); body.Code != "unsupported_api_version" {
+		t.Errorf("code = %q", body.Code)
+	}
+}
+
+func TestLegacyRoutesDisabled(t *testing.T) {
+	cfg := testCfg
+	cfg.LegacyRoutes = false
+	env := newTestEnvWithConfig(t, cfg)
+
+	expectStatus(t, env.do(http.MethodGet, "/time", nil, ""), http.StatusNotFound)
+	expectStatus(t, env.do(http.MethodGet, "/api/v1/time", nil, ""), http.StatusOK)
+	// Fora da versão, continuam como antes
+	expectStatus(t, env.do(http.MethodGet, "/healthz", nil, ""), http.StatusOK)
+}
+
+// Todo caminho antigo tem o equivalente em /api/v1
+func TestLegacyRoutesMirrorV1(t *testing.T) {
+	env := newTestEnv(t)
+	routes := map[string]bool{}
+	for _, route := range env.router.Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	prefix := handlers.APIPrefix(1)
+	var v1 int
+	for route := range routes {
+		method, path, _ := strings.Cut(route, " ")
+		if rest, ok := strings.CutPrefix(path, prefix); ok {
+			v1++
+			if !routes[method+" "+rest] {
+				t.Errorf("%s %s sem o apelido antigo", method, path)
+			}
+		}
+	}
+	if v1 == 0 {
+		t.Fatal("nenhuma rota em /api/v1")
+	}
+}
@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go_api/handlers"
+	"go_api/models"
+	"go_api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Destino de teste: guarda os POSTs e responde com o status configurado
+type webhookReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   int
+	requests []receivedWebhook
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	r := &webhookReceiver{status: http.StatusOK}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.requests = append(r.requests, receivedWebhook{header: req.Header.Clone(), body: body})
+		status := r.status
+		r.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *webhookReceiver) received() []receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedWebhook(nil), r.requests...)
+}
+
+func (e *testEnv) createWebhook(token string, body gin.H) handlers.WebhookWithSecret {
+	e.t.Helper()
+	w := e.do(http.MethodPost, "/webhooks", body, token)
+	expectStatus(e.t, w, http.StatusCreated)
+	var hook handlers.WebhookWithSecret
+	decode(e.t, w, &hook)
+	return hook
+}
+
+func TestWebhookDeliversSignedLifecycleEvents(t *testing.T) {
+	env := newTestEnv(t)
+	receiver := newWebhookReceiver(t)
+	_, admin := env.seedUser("hook_admin", models.RoleAdmin)
+	hook := env.createWebhook(admin, gin.H{
+		"url":    receiver.URL,
+		"events": []string{models.WebhookUserCreated, models.WebhookUserDeleted},
+	})
+	if hook.Secret == "" {
+		t.Fatal("secret não foi gerado")
+	}
+
+	user, _ := env.seedUser("hook_ana", "")
+	path := fmt.Sprintf("/users/%d", user.ID)
+	// user.updated não foi assinado: não gera entrega
+	expectStatus(t, env.do(http.MethodPatch, path, gin.H{"name": "Ana Souza"}, admin), http.StatusOK)
+	expectStatus(t, env.do(http.MethodDelete, path, nil, admin), http.StatusOK)
+
+	if n := env.handler.Webhooks.DeliverDue(context.Background()); n != 2 {
+		t.Fatalf("entregues = %d, want 2", n)
+	}
+	got := receiver.received()
+	if len(got) != 2 {
+		t.Fatalf("POSTs recebidos = %d, want 2", len(got))
+	}
+	events := map[string]bool{}
+	for _, req := range got {
+		ts, _ := strconv.ParseInt(req.header.Get("X-Webhook-Timestamp"), 10, 64)
+		if want := service.SignWebhook(hook.Secret, ts, req.body); req.header.Get("X-Webhook-Signature") != want {
+			t.Fatalf("assinatura = %q, want %q", req.header.Get("X-Webhook-Signature"), want)
+		}
+		var payload models.WebhookPayload
+		if err := json.Unmarshal(req.body, &payload); err != nil {
+			t.Fatalf("payload inválido: %v", err)
+		}
+		if payload.UserID != user.ID || payload.Event != req.header.Get("X-Webhook-Event") || len(payload.Changes) == 0 {
+			t.Fatalf("payload = %+v", payload)
+		}
+		events[payload.Event] = true
+	}
+	if !events[models.WebhookUserCreated] || !events[models.WebhookUserDeleted] {
+		t.Fatalf("eventos = %v", events)
+	}
+
+	w := env.do(http.MethodGet, fmt.Sprintf("/webhooks/%d/deliveries", hook.ID), nil, admin)
+	expectStatus(t, w, http.StatusOK)
+	var deliveries []models.WebhookDelivery
+	decode(t, w, &deliveries)
+	for _, d := range deliveries {
+		if d.Status != models.DeliveryDelivered || d.Attempts != 1 || d.DeliveredAt == nil {
+			t.Fatalf("entrega = %+v, want delivered na primeira tentativa", d)
+		}
+	}
+	// Já entregues: a próxima rodada não reenvia nada
+	if n := env.handler.Webhooks.DeliverDue(context.Background()); n != 0 || len(receiver.received()) != 2 {
+		t.Fatal("entrega repetida")
+	}
+}
+
+func TestWebhookRetriesWithBackoffUntilGivingUp(t *testing.T) {
+	env := newTestEnv(t)
+	receiver := newWebhookReceiver(t)
+	receiver.status = http.StatusServiceUnavailable
+	_, admin := env.seedUser("hook_admin2", models.RoleAdmin)
+	env.createWebhook(admin, gin.H{"url": receiver.URL, "events": []string{models.WebhookUserCreated}})
+	env.seedUser("hook_bia", "")
+
+	var delivery models.WebhookDelivery
+	wantDelays := []time.Duration{time.Minute, 2 * time.Minute}
+	for attempt := 1; attempt <= testCfg.WebhookMaxAttempts; attempt++ {
+		before := time.Now()
+		if n := env.handler.Webhooks.DeliverDue(context.Background()); n != 0 {
+			t.Fatalf("tentativa %d: entregues = %d, want 0", attempt, n)
+		}
+		if err := env.db.First(&delivery).Error; err != nil {
+			t.Fatal(err)
+		}
+		if delivery.Attempts != attempt || delivery.LastStatus != http.StatusServiceUnavailable {
+			t.Fatalf("tentativa %d: entrega = %+v", attempt, delivery)
+		}
+		if attempt == testCfg.WebhookMaxAttempts {
+			break
+		}
+		// Espera dobra a cada falha; antes dela, nada é reenviado
+		if delay := delivery.NextAttemptAt.Sub(before); delay < wantDelays[attempt-1] || delay > wantDelays[attempt-1]+5*time.Second {
+			t.Fatalf("tentativa %d: próxima em %v, want ~%v", attempt, delay, wantDelays[attempt-1])
+		}
+		env.handler.Webhooks.DeliverDue(context.Background())
+		if len(receiver.received()) != attempt {
+			t.Fatal("reenviou antes da hora")
+		}
+		env.db.Model(&delivery).Update("next_attempt_at", time.Now().Add(-time.Second))
+	}
+	if delivery.Status != models.DeliveryFailed || delivery.LastError == "" {
+		t.Fatalf("entrega = %+v, want failed", delivery)
+	}
+}
+
+func TestWebhookValidationAndAccess(t *testing.T) {
+	env := newTestEnv(t)
+	_, admin := env.seedUser("hook_admin3", models.RoleAdmin)
+	_, user := env.seedUser("hook_caio", "")
+
+	body := gin.H{"url": "https://exemplo.com/hooks", "events": []string{models.WebhookUserCreated}}
+	expectStatus(t, env.do(http.MethodPost, "/webhooks", body, user), http.StatusForbidden)
+	expectStatus(t, env.do(http.MethodPost, "/webhooks", gin.H{"url": "ftp://exemplo.com", "events": []string{"user.created"}}, admin), http.StatusBadRequest)
+	expectStatus(t, env.do(http.MethodPost, "/webhooks", gin.H{"url": "https://exemplo.com", "events": []string{"user.renamed"}}, admin), http.StatusBadRequest)
+
+	hook := env.createWebhook(admin, body)
+	w := env.do(http.MethodGet, "/webhooks", nil, admin)
+	expectStatus(t, w, http.StatusOK)
+	var hooks []map[string]interface{}
+	decode(t, w, &hooks)
+	if len(hooks) != 1 || hooks[0]["secret"] != nil {
+		t.Fatalf("listagem = %v, want um webhook, sem o secret", hooks)
+	}
+
+	path := fmt.Sprintf("/webhooks/%d", hook.ID)
+	expectStatus(t, env.do(http.MethodDelete, path, nil, admin), http.StatusOK)
+	expectStatus(t, env.do(http.MethodDelete, path, nil, admin), http.StatusNotFound)
+	expectStatus(t, env.do(http.MethodGet, path+"/deliveries", nil, admin), http.StatusNotFound)
+}
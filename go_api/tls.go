@@ -0,0 +1,137 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go_api/config"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// --- HTTPS sem proxy na frente ---
+// Com TLS_ADDR (ex: ":443"), o próprio servidor termina o TLS, para
+// implantações sem o nginx não trafegarem senhas em texto puro. O
+// certificado vem de TLS_CERT_FILE/TLS_KEY_FILE (relidos quando o arquivo
+// muda, ex: renovação do certbot) ou, com AUTOCERT_DOMAINS, é emitido e
+// renovado sozinho pelo Let's Encrypt. HTTPS_REDIRECT=true faz a porta HTTP
+// só redirecionar (os health checks continuam nela).
+
+const certCheckInterval = time.Minute
+
+// Certificado em arquivo, recarregado quando o arquivo é substituído
+type certReloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) reload() error {
+	info, err := os.Stat(r.certFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert, r.modTime = &cert, info.ModTime()
+	return nil
+}
+
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checked) >= certCheckInterval {
+		r.checked = time.Now()
+		// Arquivo trocado: tenta o novo; se falhar, segue com o atual
+		if info, err := os.Stat(r.certFile); err == nil && !info.ModTime().Equal(r.modTime) {
+			if err := r.reload(); err != nil {
+				log.Printf("Erro ao recarregar o certificado TLS: %v", err)
+			} else {
+				log.Printf("Certificado TLS recarregado de %s", r.certFile)
+			}
+		}
+	}
+	return r.cert, nil
+}
+
+// Servidor HTTPS e o handler que passa a responder na porta HTTP
+// (redirecionamento e desafios do ACME). Sem TLS_ADDR, nada muda.
+func newTLSServer(cfg config.Config, handler http.Handler) (*http.Server, http.Handler, error) {
+	if cfg.TLSAddr == "" {
+		return nil, handler, nil
+	}
+	srv := &http.Server{
+		Addr:              cfg.TLSAddr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	plain := handler
+	if cfg.HTTPSRedirect {
+		plain = redirectHTTPS(cfg.TLSAddr, handler)
+	}
+
+	if len(cfg.AutocertDomains) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertDomains...),
+			Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+			Email:      cfg.AutocertEmail,
+		}
+		srv.TLSConfig = manager.TLSConfig()
+		// O desafio HTTP-01 do Let's Encrypt chega pela porta HTTP
+		return srv, manager.HTTPHandler(plain), nil
+	}
+
+	reloader, err := newCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("certificado TLS: %w", err)
+	}
+	srv.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+	return srv, plain, nil
+}
+
+// Health checks seguem em HTTP (as sondas do orquestrador não seguem
+// redirecionamento); o resto vai para a mesma URL em HTTPS
+func redirectHTTPS(tlsAddr string, handler http.Handler) http.Handler {
+	_, port, _ := net.SplitHostPort(tlsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		// 308 preserva o método e o corpo (ex: POST /login)
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}